@@ -11,11 +11,14 @@ import (
 	"fmt"
 	"os"
 
+	ociopts "github.com/gardener/component-cli/ociclient/options"
 	cachecmd "github.com/gardener/component-cli/pkg/commands/cache"
+	"github.com/gardener/component-cli/pkg/commands/component"
 	"github.com/gardener/component-cli/pkg/commands/componentarchive"
 	"github.com/gardener/component-cli/pkg/commands/ctf"
 	"github.com/gardener/component-cli/pkg/commands/imagevector"
 	"github.com/gardener/component-cli/pkg/commands/oci"
+	"github.com/gardener/component-cli/pkg/commands/transport"
 	"github.com/gardener/component-cli/pkg/logcontext"
 	"github.com/gardener/component-cli/pkg/logger"
 	"github.com/gardener/component-cli/pkg/version"
@@ -41,45 +44,16 @@ func NewComponentsCliCommand(ctx context.Context) *cobra.Command {
 	}
 
 	logger.InitFlags(cmd.PersistentFlags())
+	ociopts.InitGlobalFlags(cmd.PersistentFlags())
 
-	cmd.AddCommand(NewVersionCommand())
+	cmd.AddCommand(NewVersionCommand(ctx))
 	cmd.AddCommand(ctf.NewCTFCommand(ctx))
 	cmd.AddCommand(componentarchive.NewComponentArchiveCommand(ctx))
+	cmd.AddCommand(component.NewComponentCommand(ctx))
 	cmd.AddCommand(imagevector.NewImageVectorCommand(ctx))
 	cmd.AddCommand(oci.NewOCICommand(ctx))
 	cmd.AddCommand(cachecmd.NewCacheCommand(ctx))
+	cmd.AddCommand(transport.NewTransportCommand(ctx))
 
 	return cmd
 }
-
-func NewVersionCommand() *cobra.Command {
-	return &cobra.Command{
-		Use:     "version",
-		Aliases: []string{"v"},
-		Short:   "displays the version",
-		Run: func(cmd *cobra.Command, args []string) {
-			v := version.Get()
-			fmt.Printf("\nComponent CLI Version: %s\n", v.GitVersion)
-
-			if v.GitCommit != "" {
-				fmt.Printf("  GitCommit: %s\n", v.GitCommit)
-			}
-
-			if v.GitTreeState != "" {
-				fmt.Printf("  GitTreeState: %s\n", v.GitTreeState)
-			}
-
-			if v.GoVersion != "" {
-				fmt.Printf("  GoVersion: %s\n", v.GoVersion)
-			}
-
-			if v.Compiler != "" {
-				fmt.Printf("  Compiler: %s\n", v.Compiler)
-			}
-
-			if v.Platform != "" {
-				fmt.Printf("  Platform: %s\n", v.Platform)
-			}
-		},
-	}
-}
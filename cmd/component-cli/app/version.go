@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Masterminds/semver/v3"
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/ociclient"
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/utils"
+	"github.com/gardener/component-cli/pkg/version"
+)
+
+const (
+	// DefaultReleaseRepository is the repository where the component descriptors of the released component-cli versions are published.
+	DefaultReleaseRepository = "eu.gcr.io/gardener-project/development"
+	// DefaultComponentName is the name of the component-cli component.
+	DefaultComponentName = "github.com/gardener/component-cli"
+)
+
+// VersionOptions contains all options to display the version of the cli.
+type VersionOptions struct {
+	// CheckLatest defines if the latest released version should be looked up in the release repository.
+	CheckLatest bool
+	// ReleaseRepository is the oci repository where the component descriptors of the releases are stored.
+	ReleaseRepository string
+	// ComponentName is the name of the component-cli component in the release repository.
+	ComponentName string
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+}
+
+// LatestVersion describes the latest released version of the component-cli.
+type LatestVersion struct {
+	// Version is the latest released version.
+	Version *semver.Version
+	// Ref is the oci reference of the component descriptor of the latest version.
+	Ref string
+	// Digest is the digest of the component descriptor of the latest version.
+	Digest string
+}
+
+func NewVersionCommand(ctx context.Context) *cobra.Command {
+	opts := &VersionOptions{}
+	cmd := &cobra.Command{
+		Use:     "version",
+		Aliases: []string{"v"},
+		Short:   "displays the version",
+		Long: `
+displays the version of the component-cli.
+
+If "--check-latest" is set, the component descriptor of the component-cli is looked up in the release repository
+and the cli reports whether a newer release is available.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+func (o *VersionOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	v := version.Get()
+	fmt.Printf("\nComponent CLI Version: %s\n", v.GitVersion)
+
+	if v.GitCommit != "" {
+		fmt.Printf("  GitCommit: %s\n", v.GitCommit)
+	}
+
+	if v.GitTreeState != "" {
+		fmt.Printf("  GitTreeState: %s\n", v.GitTreeState)
+	}
+
+	if v.GoVersion != "" {
+		fmt.Printf("  GoVersion: %s\n", v.GoVersion)
+	}
+
+	if v.Compiler != "" {
+		fmt.Printf("  Compiler: %s\n", v.Compiler)
+	}
+
+	if v.Platform != "" {
+		fmt.Printf("  Platform: %s\n", v.Platform)
+	}
+
+	if !o.CheckLatest {
+		return nil
+	}
+
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+
+	latest, err := GetLatestVersion(ctx, ociClient, o.ReleaseRepository, o.ComponentName)
+	if err != nil {
+		return fmt.Errorf("unable to check for the latest version: %w", err)
+	}
+
+	current, err := semver.NewVersion(v.GitVersion)
+	if err != nil {
+		fmt.Printf("\nUnable to compare the current version %q with the latest release %s (%s)\n", v.GitVersion, latest.Version.Original(), latest.Digest)
+		return nil
+	}
+	if latest.Version.GreaterThan(current) {
+		fmt.Printf("\nA newer release is available: %s\n", latest.Version.Original())
+		fmt.Printf("  Ref: %s\n", latest.Ref)
+		fmt.Printf("  Digest: %s\n", latest.Digest)
+		return nil
+	}
+	fmt.Printf("\nThe component-cli is up to date (latest release %s)\n", latest.Version.Original())
+	return nil
+}
+
+// GetLatestVersion looks up the latest released version of a component in the given repository.
+// Only tags that are valid semantic versions and no prereleases are considered.
+func GetLatestVersion(ctx context.Context, client ociclient.ExtendedClient, repository, componentName string) (*LatestVersion, error) {
+	repoCtx := cdv2.NewOCIRegistryRepository(repository, "")
+	ref, err := components.OCIRef(repoCtx, componentName, "latest")
+	if err != nil {
+		return nil, fmt.Errorf("invalid component reference: %w", err)
+	}
+	repo, _, err := ociclient.ParseImageRef(ref)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse component reference %q: %w", ref, err)
+	}
+
+	tags, err := client.ListTags(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list versions of %q: %w", repo, err)
+	}
+
+	var latest *semver.Version
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil || len(v.Prerelease()) != 0 {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no released version found in %q", repo)
+	}
+
+	latestRef, err := components.OCIRef(repoCtx, componentName, latest.Original())
+	if err != nil {
+		return nil, fmt.Errorf("invalid component reference: %w", err)
+	}
+	_, desc, err := client.Resolve(ctx, latestRef)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve component descriptor %q: %w", latestRef, err)
+	}
+
+	return &LatestVersion{
+		Version: latest,
+		Ref:     latestRef,
+		Digest:  desc.Digest.String(),
+	}, nil
+}
+
+func (o *VersionOptions) Complete(args []string) error {
+	if !o.CheckLatest {
+		return nil
+	}
+
+	var err error
+	o.OciOptions.CacheDir, err = utils.CacheDir()
+	if err != nil {
+		return fmt.Errorf("unable to get oci cache directory: %w", err)
+	}
+	if len(o.ReleaseRepository) == 0 {
+		return fmt.Errorf("a release repository has to be specified")
+	}
+	if len(o.ComponentName) == 0 {
+		return fmt.Errorf("a component name has to be specified")
+	}
+	return nil
+}
+
+func (o *VersionOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.CheckLatest, "check-latest", false, "checks whether a newer release of the component-cli is available")
+	fs.StringVar(&o.ReleaseRepository, "release-repository", DefaultReleaseRepository, "repository where the component descriptors of the component-cli releases are published")
+	fs.StringVar(&o.ComponentName, "component-name", DefaultComponentName, "name of the component-cli component in the release repository")
+	o.OciOptions.AddFlags(fs)
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app_test
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/cmd/component-cli/app"
+	mock_ociclient "github.com/gardener/component-cli/ociclient/mock"
+)
+
+// tagsClient is a mocked oci client that lists the given tags.
+type tagsClient struct {
+	*mock_ociclient.MockClient
+	tags []string
+}
+
+func (c *tagsClient) ListTags(_ context.Context, _ string) ([]string, error) {
+	return c.tags, nil
+}
+
+func (c *tagsClient) ListRepositories(_ context.Context, _ string) ([]string, error) {
+	return nil, nil
+}
+
+var _ = Describe("Version", func() {
+
+	var (
+		mockCtrl   *gomock.Controller
+		mockClient *mock_ociclient.MockClient
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockClient = mock_ociclient.NewMockClient(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should return the highest released version", func() {
+		dig := digest.FromString("cd")
+		client := &tagsClient{
+			MockClient: mockClient,
+			tags:       []string{"v0.9.0", "v0.10.0", "v0.11.0-dev-abc", "latest", "v0.2.0"},
+		}
+		ref := "example.com/releases/component-descriptors/github.com/gardener/component-cli:v0.10.0"
+		mockClient.EXPECT().Resolve(gomock.Any(), ref).Return(ref, ocispecv1.Descriptor{Digest: dig}, nil)
+
+		latest, err := app.GetLatestVersion(context.TODO(), client, "example.com/releases", app.DefaultComponentName)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(latest.Version.Original()).To(Equal("v0.10.0"))
+		Expect(latest.Ref).To(Equal(ref))
+		Expect(latest.Digest).To(Equal(dig.String()))
+	})
+
+	It("should fail if no released version exists", func() {
+		client := &tagsClient{
+			MockClient: mockClient,
+			tags:       []string{"v0.11.0-dev-abc", "latest"},
+		}
+
+		_, err := app.GetLatestVersion(context.TODO(), client, "example.com/releases", app.DefaultComponentName)
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -13,7 +13,7 @@ if [[ $EFFECTIVE_VERSION == "" ]]; then
   EFFECTIVE_VERSION=$(cat $PROJECT_ROOT/VERSION)
 fi
 
-CGO_ENABLED=0 GOOS=$(go env GOOS) GOARCH=$(go env GOARCH) GO111MODULE=on \
+CGO_ENABLED=${CGO_ENABLED:-0} GOOS=$(go env GOOS) GOARCH=$(go env GOARCH) GO111MODULE=on \
   go install -mod=vendor \
   -ldflags "-s -w \
             -X github.com/gardener/component-cli/pkg/version.gitVersion=$EFFECTIVE_VERSION \
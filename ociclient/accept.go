@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// acceptMediaTypesContextKey is the context key of the accept media types of a request.
+type acceptMediaTypesContextKey struct{}
+
+// WithAcceptMediaTypesContext returns a context that overwrites the media types that are accepted
+// when manifests are resolved or fetched with Resolve and GetRawManifest.
+// This allows to fetch artifacts of any type, e.g. helm charts, singularity images or wasm modules, that are not
+// served by registries for the default accept media types of the client.
+func WithAcceptMediaTypesContext(ctx context.Context, mediaTypes ...string) context.Context {
+	return context.WithValue(ctx, acceptMediaTypesContextKey{}, mediaTypes)
+}
+
+// WithAcceptMediaTypes configures the media types that are accepted by default when manifests are resolved or fetched.
+// The default media types of docker and oci image manifests and indexes are used if no media types are configured.
+type WithAcceptMediaTypes []string
+
+func (c WithAcceptMediaTypes) ApplyOption(options *Options) {
+	options.AcceptMediaTypes = append(options.AcceptMediaTypes, c...)
+}
+
+// acceptMediaTypes returns the media types that are accepted for a request.
+// Nil is returned if the default media types should be used.
+func (c *client) acceptMediaTypes(ctx context.Context) []string {
+	if mediaTypes, ok := ctx.Value(acceptMediaTypesContextKey{}).([]string); ok && len(mediaTypes) != 0 {
+		return mediaTypes
+	}
+	return c.defaultAcceptMediaTypes
+}
+
+// acceptHeaders returns the headers of resolve requests or nil if the default media types should be used.
+func (c *client) acceptHeaders(ctx context.Context) http.Header {
+	mediaTypes := c.acceptMediaTypes(ctx)
+	if len(mediaTypes) == 0 {
+		return nil
+	}
+	header := http.Header{}
+	header.Set("Accept", strings.Join(mediaTypes, ", "))
+	return header
+}
+
+// isAcceptedMediaType checks whether the media type has been explicitly accepted for a request.
+func (c *client) isAcceptedMediaType(ctx context.Context, mediaType string) bool {
+	for _, accepted := range c.acceptMediaTypes(ctx) {
+		if accepted == mediaType || accepted == "*/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchManifest fetches a manifest of a media type that is not known to the containerd fetcher
+// from the manifests endpoint of the registry. The containerd fetcher would fetch it from the blobs endpoint.
+func (c *client) fetchManifest(ctx context.Context, refspec oci.RefSpec, desc ocispecv1.Descriptor) ([]byte, error) {
+	if c.offline {
+		data := bytes.NewBuffer([]byte{})
+		if err := c.Fetch(ctx, refspec.String(), desc, data); err != nil {
+			return nil, err
+		}
+		return data.Bytes(), nil
+	}
+
+	hosts, err := c.getHostConfig(refspec.Host)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find registry host: %w", err)
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no host configuration found for %s", refspec.Host)
+	}
+	hostConfig := hosts[0]
+
+	trp, err := c.getTransportForRef(ctx, refspec.Name(), transport.PullScope)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create transport: %w", err)
+	}
+	httpClient := c.getHttpClient()
+	httpClient.Transport = trp
+
+	u := &url.URL{
+		Scheme: hostConfig.Scheme,
+		Host:   hostConfig.Host,
+		Path:   path.Join(hostConfig.Path, refspec.Repository, "manifests", desc.Digest.String()),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Accept", desc.MediaType)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("manifest %s not found: %w", desc.Digest, errdefs.ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to get manifest: unexpected status code %d", resp.StatusCode)
+	}
+	var data bytes.Buffer
+	if _, err := io.Copy(&data, io.LimitReader(resp.Body, desc.Size+1)); err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+	if actual := digest.FromBytes(data.Bytes()); actual != desc.Digest {
+		return nil, fmt.Errorf("digest of manifest %s does not match the expected digest %s", actual, desc.Digest)
+	}
+	return data.Bytes(), nil
+}
+
+// ArtifactType returns the type of the artifact that is described by the manifest.
+// The type is the "artifactType" of the manifest if defined,
+// the media type of the config for image manifests (e.g. "application/vnd.cncf.helm.config.v1+json" for helm charts)
+// and the media type of the manifest otherwise.
+func ArtifactType(desc ocispecv1.Descriptor, rawManifest []byte) (string, error) {
+	manifest := struct {
+		ArtifactType string                `json:"artifactType"`
+		Config       *ocispecv1.Descriptor `json:"config"`
+	}{}
+	if err := json.Unmarshal(rawManifest, &manifest); err != nil {
+		return "", fmt.Errorf("unable to decode manifest: %w", err)
+	}
+	if len(manifest.ArtifactType) != 0 {
+		return manifest.ArtifactType, nil
+	}
+	if IsSingleArchImage(desc.MediaType) && manifest.Config != nil && len(manifest.Config.MediaType) != 0 {
+		return manifest.Config.MediaType, nil
+	}
+	return desc.MediaType, nil
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/credentials"
+)
+
+var _ = Describe("accept media types", func() {
+
+	const (
+		wasmManifestMediaType = "application/vnd.example.wasm.manifest.v1+json"
+		helmConfigMediaType   = "application/vnd.cncf.helm.config.v1+json"
+	)
+
+	var (
+		server        *httptest.Server
+		host          string
+		mux           sync.Mutex
+		acceptHeaders []string
+		manifestBytes []byte
+	)
+
+	BeforeEach(func() {
+		acceptHeaders = []string{}
+		manifestBytes = []byte(`{"schemaVersion":2,"artifactType":"application/vnd.example.wasm.v1","layers":[]}`)
+		dgst := digest.FromBytes(manifestBytes)
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch {
+			case req.URL.Path == "/v2/":
+				w.WriteHeader(http.StatusOK)
+			case strings.Contains(req.URL.Path, "/manifests/"):
+				mux.Lock()
+				acceptHeaders = append(acceptHeaders, req.Header.Get("Accept"))
+				mux.Unlock()
+				w.Header().Set("Content-Type", wasmManifestMediaType)
+				w.Header().Set("Content-Length", strconv.Itoa(len(manifestBytes)))
+				w.Header().Set(ociclient.HeaderDockerContentDigest, dgst.String())
+				w.WriteHeader(http.StatusOK)
+				if req.Method == http.MethodGet {
+					_, _ = w.Write(manifestBytes)
+				}
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		hostUrl, err := url.Parse(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		host = hostUrl.Host
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func(opts ...ociclient.Option) ociclient.Client {
+		opts = append(opts, ociclient.AllowPlainHttp(true), ociclient.WithKeyring(credentials.New()))
+		client, err := ociclient.NewClient(logr.Discard(), opts...)
+		Expect(err).ToNot(HaveOccurred())
+		return client
+	}
+
+	It("should reject manifests of unknown media types by default", func() {
+		_, _, err := newClient().GetRawManifest(context.TODO(), host+"/myproject/mymodule:1.0.0")
+		Expect(err).To(HaveOccurred())
+		Expect(acceptHeaders[0]).To(ContainSubstring(ocispecv1.MediaTypeImageManifest))
+	})
+
+	It("should use the configured accept media types and return the manifest", func() {
+		client := newClient(ociclient.WithAcceptMediaTypes{wasmManifestMediaType})
+		desc, raw, err := client.GetRawManifest(context.TODO(), host+"/myproject/mymodule:1.0.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(desc.MediaType).To(Equal(wasmManifestMediaType))
+		Expect(raw).To(Equal(manifestBytes))
+		Expect(acceptHeaders[0]).To(Equal(wasmManifestMediaType))
+
+		artifactType, err := ociclient.ArtifactType(desc, raw)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(artifactType).To(Equal("application/vnd.example.wasm.v1"))
+	})
+
+	It("should overwrite the accept media types per request", func() {
+		ctx := ociclient.WithAcceptMediaTypesContext(context.TODO(), wasmManifestMediaType, ocispecv1.MediaTypeImageManifest)
+		_, desc, err := newClient().Resolve(ctx, host+"/myproject/mymodule:1.0.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(desc.MediaType).To(Equal(wasmManifestMediaType))
+		Expect(acceptHeaders[0]).To(Equal(wasmManifestMediaType + ", " + ocispecv1.MediaTypeImageManifest))
+	})
+
+	It("should return the config media type as artifact type of image manifests", func() {
+		raw := []byte(`{"schemaVersion":2,"config":{"mediaType":"` + helmConfigMediaType + `"},"layers":[]}`)
+		artifactType, err := ociclient.ArtifactType(ocispecv1.Descriptor{MediaType: ocispecv1.MediaTypeImageManifest}, raw)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(artifactType).To(Equal(helmConfigMediaType))
+	})
+
+})
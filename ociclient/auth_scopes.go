@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// DefaultAuthScopeTTL is the default time after which cached authenticated transports are not reused anymore.
+const DefaultAuthScopeTTL = 10 * time.Minute
+
+// authScopeCache caches the authenticated transports per repository together with the actions
+// (e.g. pull, push) that have been granted for the repository.
+// A transport is reused as long as it has been granted all requested actions.
+// If additional actions are requested, a new transport is created for the union of the already granted
+// and the requested actions so that e.g. a push following a pull does not need another scope upgrade
+// and a pull following a push reuses the existing token.
+// The entries are keyed by the repository and the generation of the credentials of the keyring,
+// so that transports of rotated credentials are never reused.
+// Every entry expires after the ttl of the cache or when its short-lived credentials expire, whatever is earlier,
+// so that the credentials are resolved again and long-running transports do not use stale tokens.
+type authScopeCache struct {
+	mux     sync.Mutex
+	ttl     time.Duration
+	entries map[authScopeKey]*authScopeEntry
+}
+
+type authScopeKey struct {
+	repository string
+	generation uint64
+}
+
+type authScopeEntry struct {
+	// expires is the time at which the entry expires.
+	// The zero time means that the entry does not expire.
+	expires   time.Time
+	actions   sets.String
+	transport http.RoundTripper
+}
+
+// expired checks whether the entry is expired.
+func (e *authScopeEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// newAuthScopeCache creates a new cache whose entries expire after the given ttl.
+// The entries only expire with their credentials if the ttl is 0 or less.
+func newAuthScopeCache(ttl time.Duration) *authScopeCache {
+	return &authScopeCache{
+		ttl:     ttl,
+		entries: map[authScopeKey]*authScopeEntry{},
+	}
+}
+
+// Get returns the cached transport of the repository if it has been granted all requested actions
+// with the given generation of credentials and the entry is not expired.
+// Otherwise, the actions that should be requested for a new transport are returned.
+func (c *authScopeCache) Get(repository string, generation uint64, scopes ...string) (http.RoundTripper, []string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	requested := scopeActions(scopes...)
+	key := authScopeKey{repository: repository, generation: generation}
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, requested.List()
+	}
+	if entry.expired(time.Now()) {
+		delete(c.entries, key)
+		return nil, requested.List()
+	}
+	if entry.actions.IsSuperset(requested) {
+		return entry.transport, nil
+	}
+	return nil, entry.actions.Union(requested).List()
+}
+
+// Set caches the transport that has been granted the given actions for the repository with the given generation of credentials.
+// The entry expires after the ttl of the cache or at the given expiry of its credentials, whatever is earlier.
+// The zero time means that the credentials do not expire.
+// An already cached valid transport is only replaced if the new transport has been granted at least the same actions.
+// Entries of other generations and expired entries are removed.
+func (c *authScopeCache) Set(repository string, generation uint64, expires time.Time, actions []string, trp http.RoundTripper) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	now := time.Now()
+	for key, entry := range c.entries {
+		if key.generation != generation || entry.expired(now) {
+			delete(c.entries, key)
+		}
+	}
+
+	if c.ttl > 0 {
+		if ttlExpiry := now.Add(c.ttl); expires.IsZero() || ttlExpiry.Before(expires) {
+			expires = ttlExpiry
+		}
+	}
+	granted := sets.NewString(actions...)
+	key := authScopeKey{repository: repository, generation: generation}
+	if entry, ok := c.entries[key]; ok && !granted.IsSuperset(entry.actions) {
+		return
+	}
+	c.entries[key] = &authScopeEntry{
+		expires:   expires,
+		actions:   granted,
+		transport: trp,
+	}
+}
+
+// scopeActions parses the actions of scopes like "pull" or "push,pull".
+func scopeActions(scopes ...string) sets.String {
+	actions := sets.NewString()
+	for _, scope := range scopes {
+		for _, action := range strings.Split(scope, ",") {
+			if action = strings.TrimSpace(action); len(action) != 0 {
+				actions.Insert(action)
+			}
+		}
+	}
+	return actions
+}
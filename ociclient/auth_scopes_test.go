@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/credentials"
+)
+
+var _ = Describe("auth scopes", func() {
+
+	var (
+		server        *httptest.Server
+		host          string
+		mux           sync.Mutex
+		lastUsername  string
+		manifestBytes []byte
+	)
+
+	BeforeEach(func() {
+		lastUsername = ""
+		manifestBytes = []byte(`{"schemaVersion":2,"config":{"mediaType":"application/vnd.oci.image.config.v1+json"},"layers":[]}`)
+		dgst := digest.FromBytes(manifestBytes)
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			username, _, ok := req.BasicAuth()
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			switch {
+			case req.URL.Path == "/v2/":
+				w.WriteHeader(http.StatusOK)
+			case strings.Contains(req.URL.Path, "/manifests/"):
+				mux.Lock()
+				lastUsername = username
+				mux.Unlock()
+				w.Header().Set("Content-Type", ocispecv1.MediaTypeImageManifest)
+				w.Header().Set("Content-Length", strconv.Itoa(len(manifestBytes)))
+				w.Header().Set(ociclient.HeaderDockerContentDigest, dgst.String())
+				w.WriteHeader(http.StatusOK)
+				if req.Method == http.MethodGet {
+					_, _ = w.Write(manifestBytes)
+				}
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		hostUrl, err := url.Parse(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		host = hostUrl.Host
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should use the rotated credentials of a reloaded keyring for subsequent requests", func() {
+		fs := memoryfs.New()
+		writeDockerConfig := func(username string) {
+			auth := base64.StdEncoding.EncodeToString([]byte(username + ":abc"))
+			data := []byte(fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, host, auth))
+			Expect(vfs.WriteFile(fs, "/dockerconfig.json", data, os.ModePerm)).To(Succeed())
+		}
+		writeDockerConfig("old")
+		keyring, err := credentials.NewBuilder(logr.Discard()).
+			DisableDefaultConfig().
+			WithFS(fs).
+			FromConfigFiles("/dockerconfig.json").
+			BuildReloadable(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+
+		client, err := ociclient.NewClient(logr.Discard(), ociclient.AllowPlainHttp(true), ociclient.WithKeyring(keyring))
+		Expect(err).ToNot(HaveOccurred())
+
+		ref := host + "/myproject/mymodule:1.0.0"
+		_, _, err = client.GetRawManifest(context.TODO(), ref)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lastUsername).To(Equal("old"))
+
+		writeDockerConfig("rotated")
+		Expect(keyring.Reload(context.TODO())).To(Succeed())
+		_, _, err = client.GetRawManifest(context.TODO(), ref)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lastUsername).To(Equal("rotated"))
+	})
+
+	It("should resolve the credentials again if the credentials of a cached transport expired", func() {
+		var tokens int
+		keyring := credentials.New()
+		Expect(keyring.AddAuthConfigGetter(host, func(_ string) (credentials.Auth, error) {
+			tokens++
+			return credentials.AuthConfig{
+				Username: fmt.Sprintf("token-%d", tokens),
+				Password: "abc",
+				// the token is already expired so that it is resolved again for the next request
+				Expires: time.Now().Add(-time.Second),
+			}, nil
+		})).To(Succeed())
+
+		client, err := ociclient.NewClient(logr.Discard(), ociclient.AllowPlainHttp(true), ociclient.WithKeyring(keyring))
+		Expect(err).ToNot(HaveOccurred())
+
+		ref := host + "/myproject/mymodule:1.0.0"
+		_, _, err = client.GetRawManifest(context.TODO(), ref)
+		Expect(err).ToNot(HaveOccurred())
+		firstUsername := lastUsername
+		Expect(firstUsername).To(HavePrefix("token-"))
+
+		_, _, err = client.GetRawManifest(context.TODO(), ref)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lastUsername).To(HavePrefix("token-"))
+		Expect(lastUsername).ToNot(Equal(firstUsername))
+	})
+
+	It("should resolve the credentials again after the ttl of a cached transport", func() {
+		var tokens int
+		keyring := credentials.New()
+		Expect(keyring.AddAuthConfigGetter(host, func(_ string) (credentials.Auth, error) {
+			tokens++
+			return credentials.AuthConfig{
+				Username: fmt.Sprintf("token-%d", tokens),
+				Password: "abc",
+			}, nil
+		})).To(Succeed())
+
+		client, err := ociclient.NewClient(logr.Discard(), ociclient.AllowPlainHttp(true), ociclient.WithKeyring(keyring), ociclient.WithAuthScopeTTL(100*time.Millisecond))
+		Expect(err).ToNot(HaveOccurred())
+
+		ref := host + "/myproject/mymodule:1.0.0"
+		_, _, err = client.GetRawManifest(context.TODO(), ref)
+		Expect(err).ToNot(HaveOccurred())
+		_, _, err = client.GetRawManifest(context.TODO(), ref)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lastUsername).To(Equal("token-1"), "Expect that the cached transport is reused within the ttl")
+
+		time.Sleep(200 * time.Millisecond)
+		_, _, err = client.GetRawManifest(context.TODO(), ref)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lastUsername).To(Equal("token-2"))
+	})
+
+})
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// ErrBlobMountNotSupported is returned by MountBlob if the blob could not be mounted from the source repository,
+// e.g. because the repositories are hosted by different registries or the registry does not support cross-repository mounts.
+// The blob has to be pushed instead.
+var ErrBlobMountNotSupported = errors.New("blob mount not supported")
+
+// PushBlobFromReader streams the blob of the given descriptor from the reader to the given ref.
+// The content is read exactly once and is not written to the cache, which allows uploading large layers
+// without a cache-backed store. The digest and the size of the content are verified during the upload.
+// Blobs that are larger than the configured chunk size are uploaded in chunks.
+func (c *client) PushBlobFromReader(ctx context.Context, ref string, desc ocispecv1.Descriptor, r io.Reader) error {
+	refspec, err := oci.ParseRef(ref)
+	if err != nil {
+		return fmt.Errorf("unable to parse ref: %w", err)
+	}
+	ref = refspec.String()
+
+	resolver, err := c.getResolverForRef(ctx, ref, transport.PushScope)
+	if err != nil {
+		return err
+	}
+	pusher, err := resolver.Pusher(ctx, ref)
+	if err != nil {
+		return err
+	}
+	return c.pushReader(ctx, ref, pusher, desc, r)
+}
+
+// pushReader uploads the content of the reader with the given pusher or in chunks if the blob is large enough.
+func (c *client) pushReader(ctx context.Context, ref string, pusher remotes.Pusher, desc ocispecv1.Descriptor, r io.Reader) error {
+	if c.shouldPushChunked(desc) {
+		return c.pushBlobChunked(ctx, ref, r, desc)
+	}
+
+	writer, err := pusher.Push(AddKnownMediaTypesToCtx(ctx, []string{desc.MediaType}), desc)
+	if err != nil {
+		if errdefs.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	defer writer.Close()
+	return content.Copy(ctx, writer, r, desc.Size, desc.Digest)
+}
+
+// MountBlob mounts the blob of the given descriptor from the repository of fromRef into the repository of ref
+// without transferring the content (cross-repository blob mount).
+// Both references must point to the same registry.
+// An error that wraps ErrBlobMountNotSupported is returned if the blob could not be mounted.
+func (c *client) MountBlob(ctx context.Context, ref, fromRef string, desc ocispecv1.Descriptor) error {
+	refspec, err := oci.ParseRef(ref)
+	if err != nil {
+		return fmt.Errorf("unable to parse ref: %w", err)
+	}
+	fromRefspec, err := oci.ParseRef(fromRef)
+	if err != nil {
+		return fmt.Errorf("unable to parse source ref: %w", err)
+	}
+	if refspec.Host != fromRefspec.Host {
+		return fmt.Errorf("%w: %s and %s are hosted by different registries", ErrBlobMountNotSupported, refspec.Name(), fromRefspec.Name())
+	}
+
+	hosts, err := c.getHostConfig(refspec.Host)
+	if err != nil {
+		return fmt.Errorf("unable to find registry host: %w", err)
+	}
+	if len(hosts) == 0 {
+		return fmt.Errorf("no host configuration found for %s", refspec.Host)
+	}
+	hostConfig := hosts[0]
+
+	trp, err := c.getMountTransport(ctx, refspec.String(), fromRefspec.String())
+	if err != nil {
+		return err
+	}
+	httpClient := c.getHttpClient()
+	httpClient.Transport = trp
+
+	mountURL := &url.URL{
+		Scheme: hostConfig.Scheme,
+		Host:   hostConfig.Host,
+		Path:   path.Join(hostConfig.Path, refspec.Repository, "blobs", "uploads") + "/",
+		RawQuery: url.Values{
+			"mount": []string{desc.Digest.String()},
+			"from":  []string{fromRefspec.Repository},
+		}.Encode(),
+	}
+	resp, err := c.doUploadRequest(ctx, httpClient, http.MethodPost, mountURL, nil, nil)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		c.log.V(7).Info("blob mounted", "ref", ref, "from", fromRef, "digest", desc.Digest.String())
+		return nil
+	case http.StatusAccepted:
+		// the registry started an upload session instead of mounting the blob.
+		// The session is not used and therefore cancelled.
+		if location, err := getUploadLocation(mountURL, resp); err == nil {
+			if _, err := c.doUploadRequest(ctx, httpClient, http.MethodDelete, location, nil, nil); err != nil {
+				c.log.V(5).Info("unable to cancel upload session", "error", err.Error())
+			}
+		}
+		return fmt.Errorf("%w: registry did not mount %s from %s", ErrBlobMountNotSupported, desc.Digest, fromRefspec.Name())
+	default:
+		return fmt.Errorf("unable to mount blob %s from %s: unexpected status code %d", desc.Digest, fromRefspec.Name(), resp.StatusCode)
+	}
+}
+
+// getMountTransport returns a transport that is authorized to push to the repository of ref
+// and to pull from the repository of fromRef.
+func (c *client) getMountTransport(ctx context.Context, ref, fromRef string) (http.RoundTripper, error) {
+	if c.offline {
+		return nil, &OfflineError{Ref: ref}
+	}
+	parseOptions, err := c.getRefParserOptions(ref)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get ref parser options: %w", err)
+	}
+	repo, err := name.ParseReference(ref, parseOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse ref: %w", err)
+	}
+	fromRepo, err := name.ParseReference(fromRef, parseOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse source ref: %w", err)
+	}
+
+	auth, err := c.keychain.ResolveWithContext(ctx, repo.Context())
+	if err != nil {
+		return nil, fmt.Errorf("unable to get authentication: %w", err)
+	}
+	scopes := []string{
+		repo.Scope(transport.PushScope),
+		fromRepo.Scope(transport.PullScope),
+	}
+	trp, err := transport.NewWithContext(ctx, repo.Context().Registry, auth, c.transport, scopes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create transport: %w", err)
+	}
+	return trp, nil
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/credentials"
+)
+
+// blobRegistry is a minimal registry that supports monolithic blob uploads and cross-repository blob mounts.
+type blobRegistry struct {
+	mux sync.Mutex
+	// blobs maps repositories to the digests and contents of their blobs.
+	blobs map[string]map[string][]byte
+}
+
+func (r *blobRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if req.URL.Path == "/v2/" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/v2/"), "/blobs/", 2)
+	if len(parts) != 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	repo, rest := parts[0], parts[1]
+	switch {
+	case req.Method == http.MethodHead || req.Method == http.MethodGet:
+		if _, ok := r.blobs[repo][rest]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case req.Method == http.MethodPost && rest == "uploads/":
+		if mount := req.URL.Query().Get("mount"); len(mount) != 0 {
+			if data, ok := r.blobs[req.URL.Query().Get("from")][mount]; ok {
+				r.add(repo, mount, data)
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+		}
+		w.Header().Set("Location", "/v2/"+repo+"/blobs/uploads/session")
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == http.MethodPut && rest == "uploads/session":
+		data, _ := ioutil.ReadAll(req.Body)
+		dgst := req.URL.Query().Get("digest")
+		if digest.FromBytes(data).String() != dgst {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.add(repo, dgst, data)
+		w.Header().Set(ociclient.HeaderDockerContentDigest, dgst)
+		w.WriteHeader(http.StatusCreated)
+	case req.Method == http.MethodDelete && rest == "uploads/session":
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *blobRegistry) add(repo, dgst string, data []byte) {
+	if r.blobs[repo] == nil {
+		r.blobs[repo] = map[string][]byte{}
+	}
+	r.blobs[repo][dgst] = data
+}
+
+var _ = Describe("blob push", func() {
+
+	var (
+		registry *blobRegistry
+		server   *httptest.Server
+		host     string
+		client   ociclient.Client
+		data     []byte
+		desc     ocispecv1.Descriptor
+	)
+
+	BeforeEach(func() {
+		registry = &blobRegistry{blobs: map[string]map[string][]byte{}}
+		server = httptest.NewServer(registry)
+		hostUrl, err := url.Parse(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		host = hostUrl.Host
+
+		client, err = ociclient.NewClient(logr.Discard(), ociclient.AllowPlainHttp(true), ociclient.WithKeyring(credentials.New()))
+		Expect(err).ToNot(HaveOccurred())
+
+		data = []byte("layer-data")
+		desc = ocispecv1.Descriptor{
+			MediaType: ocispecv1.MediaTypeImageLayer,
+			Digest:    digest.FromBytes(data),
+			Size:      int64(len(data)),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should stream a blob from a reader", func() {
+		Expect(client.PushBlobFromReader(context.TODO(), host+"/target:v1", desc, bytes.NewReader(data))).To(Succeed())
+		Expect(registry.blobs["target"]).To(HaveKeyWithValue(desc.Digest.String(), data))
+	})
+
+	It("should fail if the content does not match the descriptor", func() {
+		Expect(client.PushBlobFromReader(context.TODO(), host+"/target:v1", desc, bytes.NewReader([]byte("other-data")))).ToNot(Succeed())
+		Expect(registry.blobs["target"]).ToNot(HaveKey(desc.Digest.String()))
+	})
+
+	It("should mount a blob from another repository", func() {
+		registry.add("source", desc.Digest.String(), data)
+		Expect(client.MountBlob(context.TODO(), host+"/target:v1", host+"/source:v1", desc)).To(Succeed())
+		Expect(registry.blobs["target"]).To(HaveKeyWithValue(desc.Digest.String(), data))
+	})
+
+	It("should return a mount not supported error if the registry does not mount the blob", func() {
+		err := client.MountBlob(context.TODO(), host+"/target:v1", host+"/source:v1", desc)
+		Expect(errors.Is(err, ociclient.ErrBlobMountNotSupported)).To(BeTrue())
+	})
+
+	It("should return a mount not supported error for repositories of different registries", func() {
+		err := client.MountBlob(context.TODO(), host+"/target:v1", "example.com/source:v1", desc)
+		Expect(errors.Is(err, ociclient.ErrBlobMountNotSupported)).To(BeTrue())
+	})
+
+})
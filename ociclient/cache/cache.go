@@ -10,13 +10,16 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/memoryfs"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/projectionfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
 	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/gardener/component-cli/ociclient/metrics"
@@ -28,6 +31,9 @@ type layeredCache struct {
 
 	baseFs    *FileSystem
 	overlayFs *FileSystem
+	// compress defines whether blobs are stored compressed in the base filesystem.
+	// The in memory overlay always contains the uncompressed blobs.
+	compress bool
 }
 
 // NewCache creates a new cache with the given options.
@@ -36,12 +42,23 @@ func NewCache(log logr.Logger, options ...Option) (*layeredCache, error) {
 	opts := &Options{}
 	opts = opts.ApplyOptions(options)
 	opts.ApplyDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
 
 	if err := initBasePath(opts); err != nil {
 		return nil, err
 	}
 
-	base, err := projectionfs.New(osfs.New(), opts.BasePath)
+	basePath := opts.BasePath
+	if len(opts.Namespace) != 0 {
+		basePath = NamespacePath(opts.BasePath, opts.Namespace)
+		if err := os.MkdirAll(basePath, os.ModePerm); err != nil {
+			return nil, fmt.Errorf("unable to create namespace directory %q: %w", basePath, err)
+		}
+	}
+
+	base, err := projectionfs.New(osfs.New(), basePath)
 	if err != nil {
 		return nil, err
 	}
@@ -73,6 +90,7 @@ func NewCache(log logr.Logger, options ...Option) (*layeredCache, error) {
 		mux:       sync.RWMutex{},
 		baseFs:    baseCFs,
 		overlayFs: overlayCFs,
+		compress:  opts.Compress,
 	}, nil
 }
 
@@ -101,6 +119,11 @@ func initBasePath(opts *Options) error {
 	return nil
 }
 
+// NamespacePath returns the path of the namespace in the given base path.
+func NamespacePath(basePath, namespace string) string {
+	return filepath.Join(basePath, NamespacesDirectoryName, namespace)
+}
+
 // Close implements the io.Closer interface that cleanups all resource used by the cache.
 func (lc *layeredCache) Close() error {
 	if err := lc.baseFs.Close(); err != nil {
@@ -117,6 +140,10 @@ func (lc *layeredCache) Close() error {
 func (lc *layeredCache) Get(desc ocispecv1.Descriptor) (io.ReadCloser, error) {
 	_, file, err := lc.get(Path(desc), desc)
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			// the blob may have been added compressed, independent of the current compression setting.
+			return lc.getCompressed(desc)
+		}
 		return nil, err
 	}
 	return file, nil
@@ -124,25 +151,54 @@ func (lc *layeredCache) Get(desc ocispecv1.Descriptor) (io.ReadCloser, error) {
 
 func (lc *layeredCache) Add(desc ocispecv1.Descriptor, reader io.ReadCloser) error {
 	path := Path(desc)
-	lc.mux.Lock()
-	defer lc.mux.Unlock()
 	defer reader.Close()
 
-	file, err := lc.baseFs.Create(path, desc.Size)
+	// the blob is written to a temp file first so that interrupted adds do not leave incomplete blobs in the cache.
+	file, err := lc.baseFs.CreateTemp()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	var size int64
+	if lc.compress {
+		path = CompressedPath(desc)
+		_, size, err = writeCompressed(file, reader)
+	} else {
+		size, err = io.Copy(file, reader)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		if err := lc.baseFs.FileSystem.Remove(file.Name()); err != nil {
+			lc.log.V(7).Info("unable to remove temp file", "file", file.Name(), "err", err.Error())
+		}
+		return err
+	}
 
-	_, err = io.Copy(file, reader)
-	return err
+	lc.mux.Lock()
+	defer lc.mux.Unlock()
+	// a blob is only stored once, either compressed or uncompressed.
+	stalePath := CompressedPath(desc)
+	if lc.compress {
+		stalePath = Path(desc)
+	}
+	if _, err := lc.baseFs.RemoveFiles(stalePath); err != nil {
+		lc.log.V(7).Info("unable to remove stale blob", "file", stalePath, "err", err.Error())
+	}
+	return lc.baseFs.Commit(file.Name(), path, size)
 }
 
 func (lc *layeredCache) Info() (Info, error) {
+	tempFilesCount, tempSize, err := lc.baseFs.TempUsage()
+	if err != nil {
+		return Info{}, err
+	}
 	return Info{
-		Size:        lc.baseFs.Size,
-		CurrentSize: lc.baseFs.CurrentSize(),
-		ItemsCount:  int64(lc.baseFs.index.Len()),
+		Size:           lc.baseFs.Size,
+		CurrentSize:    lc.baseFs.CurrentSize(),
+		ItemsCount:     int64(lc.baseFs.index.Len()),
+		TempFilesCount: tempFilesCount,
+		TempSize:       tempSize,
 	}, nil
 }
 
@@ -150,6 +206,53 @@ func (lc *layeredCache) Prune() error {
 	return lc.baseFs.DeleteAll()
 }
 
+func (lc *layeredCache) GarbageCollect(maxSize int64, maxAge time.Duration) (EvictionResult, error) {
+	lc.mux.Lock()
+	defer lc.mux.Unlock()
+	return lc.baseFs.EvictLRU(maxSize, maxAge), nil
+}
+
+func (lc *layeredCache) Delete(digests ...digest.Digest) (EvictionResult, error) {
+	lc.mux.Lock()
+	defer lc.mux.Unlock()
+	paths := make([]string, 0, 2*len(digests))
+	for _, dgst := range digests {
+		paths = append(paths, dgst.Encoded(), dgst.Encoded()+CompressedFileSuffix)
+	}
+	if lc.overlayFs != nil {
+		if _, err := lc.overlayFs.RemoveFiles(paths...); err != nil {
+			return EvictionResult{}, err
+		}
+	}
+	return lc.baseFs.RemoveFiles(paths...)
+}
+
+func (lc *layeredCache) Stat(dgst digest.Digest) (int64, error) {
+	path := Path(ocispecv1.Descriptor{Digest: dgst})
+	lc.mux.RLock()
+	defer lc.mux.RUnlock()
+	if lc.overlayFs != nil {
+		if info, err := lc.overlayFs.Stat(path); err == nil {
+			return info.Size(), nil
+		}
+	}
+	info, err := lc.baseFs.Stat(path)
+	if err == nil {
+		return info.Size(), nil
+	}
+	if !os.IsNotExist(err) {
+		return 0, err
+	}
+	size, err := readCompressedSize(lc.baseFs.FileSystem, path+CompressedFileSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+	return size, nil
+}
+
 func (lc *layeredCache) get(dgst string, desc ocispecv1.Descriptor) (os.FileInfo, vfs.File, error) {
 	lc.mux.RLock()
 	defer lc.mux.RUnlock()
@@ -212,6 +315,65 @@ func (lc *layeredCache) get(dgst string, desc ocispecv1.Descriptor) (os.FileInfo
 	return info, file, nil
 }
 
+// getCompressed returns a reader for the decompressed content of a compressed blob of the base filesystem.
+// The decompressed blob is copied to the in memory overlay.
+func (lc *layeredCache) getCompressed(desc ocispecv1.Descriptor) (io.ReadCloser, error) {
+	lc.mux.RLock()
+	defer lc.mux.RUnlock()
+
+	path := CompressedPath(desc)
+	if _, err := lc.baseFs.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	verified, err := verifyCompressedBlob(lc.baseFs.FileSystem, path, desc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to verify blob: %w", err)
+	}
+	if !verified {
+		// remove invalid blob from cache
+		if err := lc.baseFs.Remove(path); err != nil {
+			lc.log.V(7).Info("unable to remove invalid blob", "digest", path, "err", err.Error())
+		}
+		return nil, ErrNotFound
+	}
+
+	if lc.overlayFs != nil {
+		if err := lc.copyCompressedToOverlay(path, desc); err != nil {
+			// do not return an error here as we are only unable to write to better cache
+			lc.log.V(5).Info(err.Error())
+		}
+	}
+
+	file, err := lc.baseFs.OpenFile(path, os.O_RDONLY, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	return newDecompressingReader(file)
+}
+
+// copyCompressedToOverlay copies the decompressed content of a compressed blob to the in memory overlay.
+func (lc *layeredCache) copyCompressedToOverlay(path string, desc ocispecv1.Descriptor) error {
+	file, err := lc.baseFs.FileSystem.OpenFile(path, os.O_RDONLY, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	reader, err := newDecompressingReader(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	overlayFile, err := lc.overlayFs.Create(Path(desc), desc.Size)
+	if err != nil {
+		return err
+	}
+	defer overlayFile.Close()
+	_, err = io.Copy(overlayFile, reader)
+	return err
+}
+
 func (lc *layeredCache) getFromOverlay(dgst string, desc ocispecv1.Descriptor) (os.FileInfo, vfs.File, error) {
 	if lc.overlayFs == nil {
 		return nil, nil, ErrNotFound
@@ -90,6 +90,181 @@ var _ = Describe("Cache", func() {
 			Expect(err).To(Equal(ErrNotFound))
 		})
 
+		Context("namespaces", func() {
+			It("should isolate the entries of namespaced caches that share a base path", func() {
+				path, err := ioutil.TempDir(os.TempDir(), "ocicache")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(path)
+
+				src, err := NewCache(logr.Discard(), WithBasePath(path), WithNamespace("source"))
+				Expect(err).ToNot(HaveOccurred())
+				defer src.Close()
+				tgt, err := NewCache(logr.Discard(), WithBasePath(path), WithNamespace("target"))
+				Expect(err).ToNot(HaveOccurred())
+				defer tgt.Close()
+
+				desc, data := exampleDataSet(10)
+				Expect(src.Add(desc, data)).To(Succeed())
+				Expect(filepath.Join(NamespacePath(path, "source"), Path(desc))).To(BeAnExistingFile())
+
+				_, err = tgt.Get(desc)
+				Expect(err).To(Equal(ErrNotFound))
+
+				srcInfo, err := src.Info()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(srcInfo.ItemsCount).To(Equal(int64(1)))
+				tgtInfo, err := tgt.Info()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(tgtInfo.ItemsCount).To(Equal(int64(0)))
+
+				Expect(tgt.Prune()).To(Succeed())
+				r, err := src.Get(desc)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(r.Close()).To(Succeed())
+			})
+
+			It("should ignore namespaces in the accounting of the shared base cache", func() {
+				path, err := ioutil.TempDir(os.TempDir(), "ocicache")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(path)
+
+				ns, err := NewCache(logr.Discard(), WithBasePath(path), WithNamespace("ns"))
+				Expect(err).ToNot(HaveOccurred())
+				defer ns.Close()
+				desc, data := exampleDataSet(10)
+				Expect(ns.Add(desc, data)).To(Succeed())
+
+				base, err := NewCache(logr.Discard(), WithBasePath(path))
+				Expect(err).ToNot(HaveOccurred())
+				defer base.Close()
+				info, err := base.Info()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.ItemsCount).To(Equal(int64(0)))
+				Expect(base.Prune()).To(Succeed())
+			})
+
+			It("should reject invalid namespaces", func() {
+				_, err := NewCache(logr.Discard(), WithNamespace("../other"))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("compression", func() {
+			It("should store blobs compressed and decompress them on get", func() {
+				path, err := ioutil.TempDir(os.TempDir(), "ocicache")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(path)
+
+				c, err := NewCache(logr.Discard(), WithBasePath(path), WithCompression(true), WithInMemoryOverlay(true))
+				Expect(err).ToNot(HaveOccurred())
+				defer c.Close()
+
+				data := bytes.Repeat([]byte("compressible data "), 1024)
+				desc := exampleDesc(bytes.NewBuffer(data))
+				Expect(c.Add(desc, ioutil.NopCloser(bytes.NewReader(data)))).To(Succeed())
+
+				info, err := os.Stat(filepath.Join(path, CompressedPath(desc)))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.Size()).To(BeNumerically("<", desc.Size))
+				_, err = os.Stat(filepath.Join(path, Path(desc)))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+
+				size, err := c.Stat(desc.Digest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(size).To(Equal(desc.Size))
+
+				for i := 0; i < 2; i++ {
+					r, err := c.Get(desc)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(readIntoBuffer(r).Bytes()).To(Equal(data))
+				}
+			})
+
+			It("should read blobs that have been added with another compression setting", func() {
+				path, err := ioutil.TempDir(os.TempDir(), "ocicache")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(path)
+
+				c, err := NewCache(logr.Discard(), WithBasePath(path), WithCompression(true))
+				Expect(err).ToNot(HaveOccurred())
+				desc, data := exampleDataSet(100)
+				Expect(c.Add(desc, data)).To(Succeed())
+				Expect(c.Close()).To(Succeed())
+
+				c, err = NewCache(logr.Discard(), WithBasePath(path))
+				Expect(err).ToNot(HaveOccurred())
+				defer c.Close()
+				r, err := c.Get(desc)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(int64(readIntoBuffer(r).Len())).To(Equal(desc.Size))
+
+				res, err := c.Delete(desc.Digest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.ItemsCount).To(Equal(int64(1)))
+				_, err = c.Get(desc)
+				Expect(err).To(Equal(ErrNotFound))
+			})
+
+			It("should detect tampered compressed data and remove the tampered blob", func() {
+				path, err := ioutil.TempDir(os.TempDir(), "ocicache")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(path)
+
+				c, err := NewCache(logr.Discard(), WithBasePath(path), WithCompression(true))
+				Expect(err).ToNot(HaveOccurred())
+				defer c.Close()
+
+				desc, data := exampleDataSet(10)
+				Expect(c.Add(desc, data)).To(Succeed())
+
+				// temper data
+				Expect(os.WriteFile(filepath.Join(path, CompressedPath(desc)), exampleData(20).Bytes(), os.ModePerm)).To(Succeed())
+
+				_, err = c.Get(desc)
+				Expect(err).To(Equal(ErrNotFound))
+				_, err = os.Stat(filepath.Join(path, CompressedPath(desc)))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+		})
+
+		Context("temp files", func() {
+			It("should not leave temp files after a blob has been added", func() {
+				c, err := NewCache(logr.Discard())
+				Expect(err).ToNot(HaveOccurred())
+				defer c.Close()
+				desc, data := exampleDataSet(10)
+				Expect(c.Add(desc, data)).To(Succeed())
+
+				info, err := c.Info()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.ItemsCount).To(Equal(int64(1)))
+				Expect(info.CurrentSize).To(Equal(int64(10)))
+				Expect(info.TempFilesCount).To(Equal(int64(0)))
+			})
+
+			It("should report and remove orphaned temp files when the cache is loaded", func() {
+				path, err := ioutil.TempDir(os.TempDir(), "ocicache")
+				Expect(err).ToNot(HaveOccurred())
+				defer os.RemoveAll(path)
+				tmpDir := filepath.Join(path, TempDirectoryName)
+				Expect(os.MkdirAll(tmpDir, os.ModePerm)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(tmpDir, "blob-orphaned"), []byte("orphaned"), os.ModePerm)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(tmpDir, "blob-active"), []byte("active"), os.ModePerm)).To(Succeed())
+				old := time.Now().Add(-2 * TempFileMaxAge)
+				Expect(os.Chtimes(filepath.Join(tmpDir, "blob-orphaned"), old, old)).To(Succeed())
+
+				c, err := NewCache(logr.Discard(), WithBasePath(path))
+				Expect(err).ToNot(HaveOccurred())
+				defer c.Close()
+				info, err := c.Info()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.ItemsCount).To(Equal(int64(0)))
+				Expect(info.TempFilesCount).To(Equal(int64(1)))
+				Expect(info.TempSize).To(Equal(int64(len("active"))))
+				Expect(filepath.Join(tmpDir, "blob-orphaned")).ToNot(BeAnExistingFile())
+			})
+		})
+
 		Context("metrics", func() {
 			It("should read data from the in memory cache", func() {
 				uid := "unit-test"
@@ -235,6 +410,110 @@ var _ = Describe("Cache", func() {
 
 			Eventually(c.baseFs.CurrentSize).Should(BeNumerically("<", 1024))
 		})
+
+		It("should evict the least recently used files until the max size is reached", func() {
+			c, err := NewCache(logr.Discard())
+			Expect(err).ToNot(HaveOccurred())
+			defer c.Close()
+
+			desc1, buf := exampleDataSet(500)
+			Expect(c.Add(desc1, buf)).To(Succeed())
+			desc2, buf := exampleDataSet(500)
+			Expect(c.Add(desc2, buf)).To(Succeed())
+			desc3, buf := exampleDataSet(500)
+			Expect(c.Add(desc3, buf)).To(Succeed())
+			c.baseFs.index.entries[Path(desc1)] = withLastAccess(c.baseFs.index.entries[Path(desc1)], newDate("3:00PM"))
+			c.baseFs.index.entries[Path(desc2)] = withLastAccess(c.baseFs.index.entries[Path(desc2)], newDate("1:00PM"))
+			c.baseFs.index.entries[Path(desc3)] = withLastAccess(c.baseFs.index.entries[Path(desc3)], newDate("2:00PM"))
+
+			res, err := c.GarbageCollect(600, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.ItemsCount).To(Equal(int64(2)))
+			Expect(res.Size).To(Equal(int64(1000)))
+			Expect(c.baseFs.index.entries).To(HaveLen(1))
+			Expect(c.baseFs.index.entries).To(HaveKey(Path(desc1)))
+		})
+
+		It("should garbage collect the least recently used files when the cache reaches its max size", func() {
+			c, err := NewCache(logr.Discard())
+			Expect(err).ToNot(HaveOccurred())
+			defer c.Close()
+
+			desc1, buf := exampleDataSet(500)
+			Expect(c.Add(desc1, buf)).To(Succeed())
+			desc2, buf := exampleDataSet(500)
+			Expect(c.Add(desc2, buf)).To(Succeed())
+			// the least recently used file is deleted even though it has more hits
+			entry1 := withLastAccess(c.baseFs.index.entries[Path(desc1)], newDate("1:00PM"))
+			entry1.Hits = 10
+			c.baseFs.index.entries[Path(desc1)] = entry1
+			c.baseFs.index.entries[Path(desc2)] = withLastAccess(c.baseFs.index.entries[Path(desc2)], newDate("3:00PM"))
+
+			c.baseFs.Size = 1024
+			c.baseFs.RunGarbageCollection()
+			Expect(c.baseFs.index.entries).To(HaveLen(1))
+			Expect(c.baseFs.index.entries).To(HaveKey(Path(desc2)))
+		})
+
+		It("should evict files that have not been accessed within the max age", func() {
+			c, err := NewCache(logr.Discard())
+			Expect(err).ToNot(HaveOccurred())
+			defer c.Close()
+
+			desc1, buf := exampleDataSet(500)
+			Expect(c.Add(desc1, buf)).To(Succeed())
+			desc2, buf := exampleDataSet(500)
+			Expect(c.Add(desc2, buf)).To(Succeed())
+			c.baseFs.index.entries[Path(desc1)] = withLastAccess(c.baseFs.index.entries[Path(desc1)], time.Now().Add(-2*time.Hour))
+
+			res, err := c.GarbageCollect(0, time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.ItemsCount).To(Equal(int64(1)))
+			Expect(c.baseFs.index.entries).To(HaveLen(1))
+			Expect(c.baseFs.index.entries).To(HaveKey(Path(desc2)))
+		})
+
+		It("should delete the blobs with the given digests", func() {
+			c, err := NewCache(logr.Discard(), WithInMemoryOverlay(true))
+			Expect(err).ToNot(HaveOccurred())
+			defer c.Close()
+
+			desc1, buf := exampleDataSet(500)
+			Expect(c.Add(desc1, buf)).To(Succeed())
+			desc2, buf := exampleDataSet(500)
+			Expect(c.Add(desc2, buf)).To(Succeed())
+			r, err := c.Get(desc1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.Close()).To(Succeed())
+
+			res, err := c.Delete(desc1.Digest, digest.FromString("unknown"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.ItemsCount).To(Equal(int64(1)))
+			Expect(res.Size).To(Equal(int64(500)))
+			_, err = c.Get(desc1)
+			Expect(err).To(MatchError(ErrNotFound))
+			Expect(c.baseFs.index.entries).To(HaveLen(1))
+			Expect(c.overlayFs.index.entries).To(HaveLen(0))
+		})
+
+		It("should update the last access of a file when it is read", func() {
+			c, err := NewCache(logr.Discard())
+			Expect(err).ToNot(HaveOccurred())
+			defer c.Close()
+
+			desc1, buf := exampleDataSet(500)
+			Expect(c.Add(desc1, buf)).To(Succeed())
+			desc2, buf := exampleDataSet(500)
+			Expect(c.Add(desc2, buf)).To(Succeed())
+			c.baseFs.index.entries[Path(desc1)] = withLastAccess(c.baseFs.index.entries[Path(desc1)], time.Now().Add(-2*time.Hour))
+			r, err := c.Get(desc1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.Close()).To(Succeed())
+
+			list := c.baseFs.index.LRUList()
+			Expect(list).To(HaveLen(2))
+			Expect(list[0].Name).To(Equal(Path(desc2)))
+		})
 	})
 
 	Context("Index", func() {
@@ -448,6 +727,11 @@ func exampleData(size int) *bytes.Buffer {
 	return bytes.NewBuffer(data)
 }
 
+func withLastAccess(entry IndexEntry, lastAccessedAt time.Time) IndexEntry {
+	entry.LastAccessedAt = lastAccessedAt
+	return entry
+}
+
 func newDate(val string) time.Time {
 	t, err := time.Parse(time.Kitchen, val)
 	Expect(err).ToNot(HaveOccurred())
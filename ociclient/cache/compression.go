@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// CompressedFileSuffix is the suffix of cached blobs that are stored compressed with zstd.
+const CompressedFileSuffix = ".zst"
+
+// compressedHeaderSize is the size of the header of compressed blobs.
+// The header contains the uncompressed size of the blob as big endian uint64
+// so that the size is known without decompressing the blob.
+const compressedHeaderSize = 8
+
+// CompressedPath returns the path of the compressed blob of the descriptor.
+func CompressedPath(desc ocispecv1.Descriptor) string {
+	return Path(desc) + CompressedFileSuffix
+}
+
+// writeCompressed writes the zstd compressed content of the reader to the file.
+// It returns the uncompressed size of the content and the size of the file.
+func writeCompressed(file vfs.File, r io.Reader) (int64, int64, error) {
+	if _, err := file.Write(make([]byte, compressedHeaderSize)); err != nil {
+		return 0, 0, fmt.Errorf("unable to write header: %w", err)
+	}
+	fileWriter := &countingWriter{Writer: file}
+	encoder, err := zstd.NewWriter(fileWriter)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to create zstd encoder: %w", err)
+	}
+	size, err := io.Copy(encoder, r)
+	if closeErr := encoder.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	header := make([]byte, compressedHeaderSize)
+	binary.BigEndian.PutUint64(header, uint64(size))
+	if _, err := file.WriteAt(header, 0); err != nil {
+		return 0, 0, fmt.Errorf("unable to write header: %w", err)
+	}
+	return size, fileWriter.n + compressedHeaderSize, nil
+}
+
+// readCompressedSize reads the uncompressed size from the header of a compressed blob.
+func readCompressedSize(fs vfs.FileSystem, path string) (int64, error) {
+	file, err := fs.OpenFile(path, os.O_RDONLY, os.ModePerm)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return readHeader(file)
+}
+
+func readHeader(r io.Reader) (int64, error) {
+	header := make([]byte, compressedHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, fmt.Errorf("unable to read header: %w", err)
+	}
+	return int64(binary.BigEndian.Uint64(header)), nil
+}
+
+// verifyCompressedBlob validates the size and the digest of the decompressed content of a compressed blob.
+func verifyCompressedBlob(fs vfs.FileSystem, path string, desc ocispecv1.Descriptor) (bool, error) {
+	file, err := fs.OpenFile(path, os.O_RDONLY, os.ModePerm)
+	if err != nil {
+		return false, err
+	}
+	reader, err := newDecompressingReader(file)
+	if err != nil {
+		// a blob with a corrupted header or stream is treated like an invalid blob.
+		return false, nil
+	}
+	defer reader.Close()
+	if reader.size != desc.Size {
+		// do a simple check by checking the blob size
+		return false, nil
+	}
+
+	verifier := desc.Digest.Verifier()
+	size, err := io.Copy(verifier, reader)
+	if err != nil {
+		return false, nil
+	}
+	return size == desc.Size && verifier.Verified(), nil
+}
+
+// decompressingReader decompresses a compressed blob on the fly.
+type decompressingReader struct {
+	file    vfs.File
+	decoder *zstd.Decoder
+	// size is the uncompressed size of the blob.
+	size int64
+}
+
+// newDecompressingReader reads the header of the compressed blob and returns a reader for the decompressed content.
+// The file is closed when the reader is closed.
+func newDecompressingReader(file vfs.File) (*decompressingReader, error) {
+	size, err := readHeader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	decoder, err := zstd.NewReader(file, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("unable to create zstd decoder: %w", err)
+	}
+	return &decompressingReader{
+		file:    file,
+		decoder: decoder,
+		size:    size,
+	}, nil
+}
+
+func (r *decompressingReader) Read(p []byte) (int, error) {
+	return r.decoder.Read(p)
+}
+
+func (r *decompressingReader) Close() error {
+	r.decoder.Close()
+	return r.file.Close()
+}
+
+type countingWriter struct {
+	io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.n += int64(n)
+	return n, err
+}
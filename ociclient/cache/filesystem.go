@@ -9,6 +9,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"path/filepath"
 	"sort"
 	"sync"
 	"time"
@@ -31,6 +32,12 @@ const ResetInterval time.Duration = 1 * time.Hour
 // PreservedHitsProportion defines the default percent of hits that should be preserved.
 const PreservedHitsProportion = 0.5
 
+// TempDirectoryName is the name of the directory that contains the temporary files of blobs that are currently added.
+const TempDirectoryName = "tmp"
+
+// TempFileMaxAge defines the default age after which temporary files are considered orphaned.
+const TempFileMaxAge time.Duration = 1 * time.Hour
+
 // GarbageCollectionConfiguration contains all options for the cache garbage collection.
 type GarbageCollectionConfiguration struct {
 	// Size is the size of the filesystem.
@@ -46,6 +53,12 @@ type GarbageCollectionConfiguration struct {
 	ResetInterval time.Duration
 	// PreservedHitsProportion defines the percent of hits that should be preserved.
 	PreservedHitsProportion float64
+	// MaxAge defines the duration after which files that have not been accessed are garbage collected.
+	// If the value is 0 files do not expire.
+	MaxAge time.Duration
+	// TempFileMaxAge defines the age after which temporary files are considered orphaned.
+	// Orphaned temporary files are left by interrupted adds and are removed when the filesystem is loaded.
+	TempFileMaxAge time.Duration
 }
 
 // FileSystem is a internal representation of FileSystem with a optional max size
@@ -67,6 +80,11 @@ type FileSystem struct {
 	ResetInterval time.Duration
 	// PreservedHitsProportion defines the percent of hits that should be preserved.
 	PreservedHitsProportion float64
+	// MaxAge defines the duration after which files that have not been accessed are garbage collected.
+	// If the value is 0 files do not expire.
+	MaxAge time.Duration
+	// TempFileMaxAge defines the age after which temporary files are considered orphaned.
+	TempFileMaxAge time.Duration
 
 	index Index
 	// currentSize is the current size of the filesystem.
@@ -82,6 +100,11 @@ type FileSystem struct {
 // ApplyOptions parses and applies the options to the filesystem.
 // It also applies defaults
 func (o GarbageCollectionConfiguration) ApplyOptions(fs *FileSystem) error {
+	fs.MaxAge = o.MaxAge
+	if o.TempFileMaxAge == 0 {
+		o.TempFileMaxAge = TempFileMaxAge
+	}
+	fs.TempFileMaxAge = o.TempFileMaxAge
 	if len(o.Size) == 0 {
 		// no garbage collection configured ignore all other values
 		return nil
@@ -135,6 +158,12 @@ func (o GarbageCollectionConfiguration) Merge(cfg *GarbageCollectionConfiguratio
 	if o.PreservedHitsProportion != 0 {
 		cfg.PreservedHitsProportion = o.PreservedHitsProportion
 	}
+	if o.MaxAge != 0 {
+		cfg.MaxAge = o.MaxAge
+	}
+	if o.TempFileMaxAge != 0 {
+		cfg.TempFileMaxAge = o.TempFileMaxAge
+	}
 }
 
 // NewCacheFilesystem creates a new FileSystem cache.
@@ -150,6 +179,9 @@ func NewCacheFilesystem(log logr.Logger, fs vfs.FileSystem, gcOpts GarbageCollec
 	if err := gcOpts.ApplyOptions(cFs); err != nil {
 		return nil, err
 	}
+	if err := cFs.removeOrphanedTempFiles(); err != nil {
+		return nil, err
+	}
 
 	// load all cached files from the filesystem
 	files, err := vfs.ReadDir(fs, "/")
@@ -157,6 +189,10 @@ func NewCacheFilesystem(log logr.Logger, fs vfs.FileSystem, gcOpts GarbageCollec
 		return nil, fmt.Errorf("unable to read current cached files: %w", err)
 	}
 	for _, file := range files {
+		// directories are namespaces of other caches
+		if file.IsDir() {
+			continue
+		}
 		cFs.currentSize = cFs.currentSize + file.Size()
 		cFs.index.Add(file.Name(), file.Size(), file.ModTime())
 	}
@@ -220,20 +256,114 @@ func (fs *FileSystem) Create(path string, size int64) (vfs.File, error) {
 	if err != nil {
 		return nil, err
 	}
+	fs.added(path, size)
+	return file, err
+}
+
+// CreateTemp creates a temporary file for a blob that is added to the filesystem.
+// The temporary file is not part of the cache until it is committed with Commit.
+// Temporary files that are never committed, e.g. because the process crashed,
+// are removed when the filesystem is loaded again and they are older than the TempFileMaxAge.
+func (fs *FileSystem) CreateTemp() (vfs.File, error) {
+	if err := fs.FileSystem.MkdirAll(TempDirectoryName, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("unable to create temp directory: %w", err)
+	}
+	return vfs.TempFile(fs.FileSystem, TempDirectoryName, "blob-")
+}
+
+// Commit moves a temporary file created with CreateTemp to the given path and adds it to the cache.
+// A file that already exists at the path is replaced.
+func (fs *FileSystem) Commit(tempPath, path string, size int64) error {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+	if _, ok := fs.index.Lookup(path); ok {
+		if err := fs.Remove(path); err != nil {
+			return fmt.Errorf("unable to remove existing file %s: %w", path, err)
+		}
+	}
+	if err := fs.FileSystem.Rename(tempPath, path); err != nil {
+		return fmt.Errorf("unable to move temp file %s to %s: %w", tempPath, path, err)
+	}
+	fs.added(path, size)
+	return nil
+}
+
+// added adds a new file to the index and triggers the garbage collection.
+// The caller has to hold the lock of the filesystem.
+func (fs *FileSystem) added(path string, size int64) {
 	fs.setCurrentSize(fs.currentSize + size)
 	fs.index.Add(path, size, time.Now())
 	if fs.itemsCountMetric != nil {
 		fs.itemsCountMetric.Inc()
 	}
 	go fs.RunGarbageCollection()
-	return file, err
 }
 
+// TempUsage returns the number and the size of all temporary files of blobs that are currently added
+// or that are left by interrupted adds.
+func (fs *FileSystem) TempUsage() (int64, int64, error) {
+	files, err := vfs.ReadDir(fs.FileSystem, TempDirectoryName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("unable to read temp files: %w", err)
+	}
+	var count, size int64
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		count++
+		size += file.Size()
+	}
+	return count, size, nil
+}
+
+// removeOrphanedTempFiles removes all temporary files that are older than the TempFileMaxAge.
+// Younger files are kept as they may still be written by another process that uses the same cache.
+func (fs *FileSystem) removeOrphanedTempFiles() error {
+	files, err := vfs.ReadDir(fs.FileSystem, TempDirectoryName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to read temp files: %w", err)
+	}
+	expiry := time.Now().Add(-fs.TempFileMaxAge)
+	var (
+		count int64
+		size  int64
+	)
+	for _, file := range files {
+		if file.IsDir() || !file.ModTime().Before(expiry) {
+			continue
+		}
+		if err := fs.FileSystem.Remove(filepath.Join(TempDirectoryName, file.Name())); err != nil {
+			fs.log.Error(err, "unable to remove orphaned temp file", "file", file.Name())
+			continue
+		}
+		count++
+		size += file.Size()
+	}
+	if count != 0 {
+		fs.log.V(3).Info("removed orphaned temp files", "count", count, "size", size)
+	}
+	return nil
+}
+
+// OpenFile opens a file and records the access in the index.
+// The modification time of the file is set to the access time so that the last access is preserved
+// when the cache is loaded again.
 func (fs *FileSystem) OpenFile(name string, flags int, perm os.FileMode) (vfs.File, error) {
 	fs.index.Hit(name)
 	if fs.hitsCountMetric != nil {
 		fs.hitsCountMetric.Inc()
 	}
+	now := time.Now()
+	if err := fs.FileSystem.Chtimes(name, now, now); err != nil {
+		fs.log.V(7).Info("unable to update access time", "file", name, "err", err.Error())
+	}
 	return fs.FileSystem.OpenFile(name, flags, perm)
 }
 
@@ -259,6 +389,9 @@ func (fs *FileSystem) DeleteAll() error {
 		return fmt.Errorf("unable to read current cached files: %w", err)
 	}
 	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
 		if err := fs.Remove(file.Name()); err != nil {
 			return err
 		}
@@ -285,12 +418,16 @@ func (fs *FileSystem) usage() float64 {
 }
 
 // RunGarbageCollection runs the garbage collection of the filesystem.
-// The gc only deletes items when the max size reached a certain threshold.
-// If that threshold is reached the files are deleted with the following priority:
-// - least hits
-// - oldest
-// - random
+// Files that have not been accessed within the max age are always deleted.
+// Other files are only deleted when the max size reached a certain threshold.
+// If that threshold is reached the least recently used files are deleted (see EvictLRU)
+// until the usage is below the low threshold.
 func (fs *FileSystem) RunGarbageCollection() {
+	if fs.MaxAge != 0 {
+		fs.mux.Lock()
+		fs.evict(0, fs.MaxAge)
+		fs.mux.Unlock()
+	}
 	// do not run gc if the size is infinite
 	if fs.Size == 0 {
 		return
@@ -301,29 +438,68 @@ func (fs *FileSystem) RunGarbageCollection() {
 		return
 	}
 
-	// while the index is read and copied no write should happen
-	fs.mux.Lock()
-	index := fs.index.DeepCopy()
-	fs.mux.Unlock()
-
 	// while the garbage collection is running read operations are blocked
 	// todo: improve to only block read operations on really deleted objects
 	fs.mux.Lock()
 	defer fs.mux.Unlock()
+	fs.evict(int64(float64(fs.Size)*GCLowThreshold), 0)
+}
 
-	// sort all files according to their deletion priority
-	items := index.PriorityList()
-	for fs.usage() > GCLowThreshold {
-		if len(items) == 0 {
-			return
+// EvictionResult describes the files that have been deleted by an eviction.
+type EvictionResult struct {
+	// ItemsCount is the number of deleted files.
+	ItemsCount int64
+	// Size is the size of all deleted files in bytes.
+	Size int64
+}
+
+// EvictLRU deletes all files that have not been accessed within maxAge and
+// afterwards deletes the least recently used files until the size of the filesystem does not exceed maxSize.
+// A maxSize or maxAge of 0 disables the respective limit.
+func (fs *FileSystem) EvictLRU(maxSize int64, maxAge time.Duration) EvictionResult {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+	return fs.evict(maxSize, maxAge)
+}
+
+// RemoveFiles deletes the given files from the filesystem.
+// Files that are not part of the filesystem are ignored.
+func (fs *FileSystem) RemoveFiles(names ...string) (EvictionResult, error) {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+	res := EvictionResult{}
+	for _, name := range names {
+		entry, ok := fs.index.Lookup(name)
+		if !ok {
+			continue
+		}
+		if err := fs.Remove(name); err != nil {
+			return res, fmt.Errorf("unable to delete %s: %w", name, err)
+		}
+		res.ItemsCount++
+		res.Size += entry.Size
+	}
+	return res, nil
+}
+
+// evict implements EvictLRU. The caller has to hold the lock of the filesystem.
+func (fs *FileSystem) evict(maxSize int64, maxAge time.Duration) EvictionResult {
+	res := EvictionResult{}
+	expiry := time.Now().Add(-maxAge)
+	for _, item := range fs.index.LRUList() {
+		expired := maxAge != 0 && item.LastAccessedAt.Before(expiry)
+		if !expired && (maxSize == 0 || fs.currentSize <= maxSize) {
+			// all following items are accessed more recently
+			break
 		}
-		item := items[0]
 		if err := fs.Remove(item.Name); err != nil {
 			fs.log.Error(err, "unable to delete file", "file", item.Name)
+			continue
 		}
-		// remove currently garbage collected item
-		items = items[1:]
+		res.ItemsCount++
+		res.Size += item.Size
 	}
+	return res
 }
 
 type Index struct {
@@ -350,6 +526,8 @@ type IndexEntry struct {
 	CreatedAt time.Time
 	// HitsSinceLastReset is the number hits since the last reset interval
 	HitsSinceLastReset int64
+	// LastAccessedAt is the time when the file has been accessed the last time.
+	LastAccessedAt time.Time
 }
 
 // Add adds a entry to the index.
@@ -360,6 +538,7 @@ func (i *Index) Add(name string, size int64, createdAt time.Time) {
 		Hits:               0,
 		CreatedAt:          createdAt,
 		HitsSinceLastReset: 0,
+		LastAccessedAt:     createdAt,
 	}
 }
 
@@ -375,6 +554,14 @@ func (i *Index) Get(name string) IndexEntry {
 	return i.entries[name]
 }
 
+// Lookup returns the index entry with the given name and whether the entry exists.
+func (i *Index) Lookup(name string) (IndexEntry, bool) {
+	i.mut.RLock()
+	defer i.mut.RUnlock()
+	entry, ok := i.entries[name]
+	return entry, ok
+}
+
 // Remove removes the entry from the index.
 func (i *Index) Remove(name string) {
 	i.mut.Lock()
@@ -382,7 +569,7 @@ func (i *Index) Remove(name string) {
 	delete(i.entries, name)
 }
 
-// Hit increases the hit count for the file.
+// Hit increases the hit count for the file and records the time of the access.
 func (i *Index) Hit(name string) {
 	i.mut.Lock()
 	defer i.mut.Unlock()
@@ -392,6 +579,7 @@ func (i *Index) Hit(name string) {
 	}
 	entry.Hits++
 	entry.HitsSinceLastReset++
+	entry.LastAccessedAt = time.Now()
 	i.entries[name] = entry
 }
 
@@ -421,6 +609,21 @@ func (i *Index) DeepCopy() *Index {
 	return index
 }
 
+// LRUList returns all entries sorted by their last access.
+// The least recently used entry is the first item.
+func (i *Index) LRUList() []IndexEntry {
+	i.mut.RLock()
+	defer i.mut.RUnlock()
+	entries := make([]IndexEntry, 0, len(i.entries))
+	for _, entry := range i.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].LastAccessedAt.Before(entries[b].LastAccessedAt)
+	})
+	return entries
+}
+
 // PriorityList returns a entries of all entries
 // sorted by their gc priority.
 // The entry with the lowest priority is the first item.
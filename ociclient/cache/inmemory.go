@@ -10,6 +10,7 @@ import (
 	"io"
 	"io/ioutil"
 
+	"github.com/opencontainers/go-digest"
 	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
@@ -36,6 +37,14 @@ func (fs *inmemoryCache) Get(desc ocispecv1.Descriptor) (io.ReadCloser, error) {
 	return ioutil.NopCloser(bytes.NewBuffer(data)), nil
 }
 
+func (fs *inmemoryCache) Stat(dgst digest.Digest) (int64, error) {
+	data, ok := fs.store[dgst.String()]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return int64(len(data)), nil
+}
+
 func (fs *inmemoryCache) Add(desc ocispecv1.Descriptor, reader io.ReadCloser) error {
 	if _, ok := fs.store[desc.Digest.String()]; ok {
 		// already cached
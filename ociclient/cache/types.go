@@ -6,8 +6,12 @@ package cache
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"regexp"
+	"time"
 
+	"github.com/opencontainers/go-digest"
 	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/google/uuid"
@@ -18,6 +22,11 @@ var (
 	ErrNotFound = errors.New("not cached")
 )
 
+// NamespacesDirectoryName is the name of the directory in the base path that contains the namespaced caches.
+const NamespacesDirectoryName = "namespaces"
+
+var namespaceRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
 // CacheDirEnvName is the name of the environment variable that configures cache directory.
 const CacheDirEnvName = "OCI_CACHE_DIR"
 
@@ -38,6 +47,11 @@ type Info struct {
 	CurrentSize int64 `json:"currentSize"`
 	// ItemsCount is the number of items that are currently managed by the cache.
 	ItemsCount int64 `json:"items"`
+	// TempFilesCount is the number of temporary files of items that are currently added
+	// or that are left by interrupted adds.
+	TempFilesCount int64 `json:"tempFiles"`
+	// TempSize is the size of all temporary files in bytes.
+	TempSize int64 `json:"tempSize"`
 }
 
 // InfoInterface describes an interface that can be optionally exposed by a cache to give additional information.
@@ -50,6 +64,30 @@ type PruneInterface interface {
 	Prune() error
 }
 
+// GarbageCollectInterface describes an interface that can be optionally exposed by a cache to manually
+// garbage collect the cache.
+type GarbageCollectInterface interface {
+	// GarbageCollect deletes all entries that have not been accessed within maxAge and
+	// afterwards the least recently used entries until the size of the cache does not exceed maxSize.
+	// A maxSize or maxAge of 0 disables the respective limit.
+	GarbageCollect(maxSize int64, maxAge time.Duration) (EvictionResult, error)
+}
+
+// DeleteInterface describes an interface that can be optionally exposed by a cache to delete specific entries.
+type DeleteInterface interface {
+	// Delete deletes the entries with the given digests.
+	// Digests that are not cached are ignored.
+	Delete(digests ...digest.Digest) (EvictionResult, error)
+}
+
+// StatInterface describes an interface that can be optionally exposed by a cache to get the size of a cached blob
+// if only its digest is known.
+type StatInterface interface {
+	// Stat returns the size of the cached blob with the given digest.
+	// ErrNotFound is returned if the blob is not cached.
+	Stat(dgst digest.Digest) (int64, error)
+}
+
 // InjectCache is a interface to inject a cache.
 type InjectCache interface {
 	InjectCache(c Cache) error
@@ -83,6 +121,19 @@ type Options struct {
 
 	// UID is the identity of a cache, if not specified a UID will be generated
 	UID string
+
+	// Namespace isolates the entries of the cache in a dedicated directory of the base path.
+	// Caches with different namespaces can share a base path
+	// whereas each namespace has its own accounting and garbage collection.
+	// +optional
+	Namespace string
+
+	// Compress stores the blobs of the base cache compressed with zstd.
+	// Blobs are decompressed on the fly when they are read.
+	// This reduces the disk usage of uncompressed blobs at the cost of cpu.
+	// Blobs that have been added with another compression setting remain readable.
+	// +optional
+	Compress bool
 }
 
 // Option is the interface to specify different cache options
@@ -110,6 +161,14 @@ func (o *Options) ApplyDefaults() {
 	}
 }
 
+// Validate validates the options.
+func (o *Options) Validate() error {
+	if len(o.Namespace) != 0 && !namespaceRegexp.MatchString(o.Namespace) {
+		return fmt.Errorf("invalid namespace %q: must match %s", o.Namespace, namespaceRegexp.String())
+	}
+	return nil
+}
+
 // WithInMemoryOverlay is the options to specify the usage of a in memory overlayFs
 type WithInMemoryOverlay bool
 
@@ -167,6 +226,28 @@ func (p WithInMemoryGCConfig) ApplyOption(options *Options) {
 	cfg.Merge(&options.InMemoryGCConfig)
 }
 
+// WithBaseMaxAge sets the duration after which files of the base file system that have not been accessed
+// are garbage collected.
+type WithBaseMaxAge time.Duration
+
+func (p WithBaseMaxAge) ApplyOption(options *Options) {
+	options.BaseGCConfig.MaxAge = time.Duration(p)
+}
+
+// WithNamespace is the option to isolate the cache entries in a namespace of the base path.
+type WithNamespace string
+
+func (p WithNamespace) ApplyOption(options *Options) {
+	options.Namespace = string(p)
+}
+
+// WithCompression is the option to store the blobs of the base cache compressed with zstd.
+type WithCompression bool
+
+func (p WithCompression) ApplyOption(options *Options) {
+	options.Compress = bool(p)
+}
+
 // WithUID is the option to give a cache an identity
 type WithUID string
 
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// shouldPushChunked returns whether the blob should be uploaded in chunks.
+// Manifests are always uploaded in a single request as the distribution spec does not allow chunked manifest uploads.
+func (c *client) shouldPushChunked(desc ocispecv1.Descriptor) bool {
+	if c.chunkSize <= 0 || desc.Size <= c.chunkSize {
+		return false
+	}
+	return !IsSingleArchImage(desc.MediaType) && !IsMultiArchImage(desc.MediaType)
+}
+
+// pushBlobChunked uploads a blob with a chunked upload session as defined by the distribution spec:
+// the session is opened with a POST request, the blob is uploaded in PATCH requests of the configured chunk size
+// and the session is closed with a PUT request that contains the digest of the blob.
+// See https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pushing-a-blob-in-chunks
+func (c *client) pushBlobChunked(ctx context.Context, ref string, r io.Reader, desc ocispecv1.Descriptor) error {
+	refspec, err := oci.ParseRef(ref)
+	if err != nil {
+		return fmt.Errorf("unable to parse ref: %w", err)
+	}
+	hosts, err := c.getHostConfig(refspec.Host)
+	if err != nil {
+		return fmt.Errorf("unable to find registry host: %w", err)
+	}
+	if len(hosts) == 0 {
+		return fmt.Errorf("no host configuration found: %w", err)
+	}
+	hostConfig := hosts[0]
+
+	trp, err := c.getTransportForRef(ctx, ref, transport.PushScope)
+	if err != nil {
+		return fmt.Errorf("unable to create transport: %w", err)
+	}
+	httpClient := c.getHttpClient()
+	httpClient.Transport = trp
+
+	blobsURL := &url.URL{
+		Scheme: hostConfig.Scheme,
+		Host:   hostConfig.Host,
+		Path:   path.Join(hostConfig.Path, refspec.Repository, "blobs"),
+	}
+
+	// skip the upload if the blob already exists
+	existsURL := *blobsURL
+	existsURL.Path = path.Join(existsURL.Path, desc.Digest.String())
+	resp, err := c.doUploadRequest(ctx, httpClient, http.MethodHead, &existsURL, nil, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		c.log.V(7).Info("blob already exists", "ref", ref, "digest", desc.Digest.String())
+		return nil
+	}
+
+	uploadURL := *blobsURL
+	uploadURL.Path = uploadURL.Path + "/uploads/"
+	resp, err = c.doUploadRequest(ctx, httpClient, http.MethodPost, &uploadURL, nil, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("unable to start upload session for %s: unexpected status code %d", desc.Digest, resp.StatusCode)
+	}
+	location, err := getUploadLocation(&uploadURL, resp)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, c.chunkSize)
+	var offset int64
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if readErr != nil && readErr != io.ErrUnexpectedEOF && readErr != io.EOF {
+			return fmt.Errorf("unable to read blob %s: %w", desc.Digest, readErr)
+		}
+		if n == 0 {
+			break
+		}
+		header := http.Header{}
+		header.Set("Content-Type", "application/octet-stream")
+		header.Set("Content-Range", fmt.Sprintf("%d-%d", offset, offset+int64(n)-1))
+		resp, err := c.doUploadRequest(ctx, httpClient, http.MethodPatch, location, header, buf[:n])
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusAccepted {
+			return fmt.Errorf("unable to upload chunk %d-%d of %s: unexpected status code %d", offset, offset+int64(n)-1, desc.Digest, resp.StatusCode)
+		}
+		location, err = getUploadLocation(location, resp)
+		if err != nil {
+			return err
+		}
+		offset += int64(n)
+		if readErr != nil {
+			break
+		}
+	}
+	if offset != desc.Size {
+		return fmt.Errorf("unable to upload blob %s: expected %d bytes but read %d bytes", desc.Digest, desc.Size, offset)
+	}
+
+	query := location.Query()
+	query.Set("digest", desc.Digest.String())
+	location.RawQuery = query.Encode()
+	resp, err = c.doUploadRequest(ctx, httpClient, http.MethodPut, location, nil, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unable to complete upload session for %s: unexpected status code %d", desc.Digest, resp.StatusCode)
+	}
+	return nil
+}
+
+// doUploadRequest sends a request of an upload session and discards the response body.
+func (c *client) doUploadRequest(ctx context.Context, httpClient *http.Client, method string, u *url.URL, header http.Header, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.ContentLength = int64(len(body))
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to %s %q: %w", method, u.String(), err)
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return resp, nil
+}
+
+// getUploadLocation returns the url of the upload session from the Location header.
+// The location can be relative to the url of the request.
+func getUploadLocation(base *url.URL, resp *http.Response) (*url.URL, error) {
+	location := resp.Header.Get("Location")
+	if len(location) == 0 {
+		return nil, fmt.Errorf("upload session response does not contain a location")
+	}
+	u, err := base.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse upload location %q: %w", location, err)
+	}
+	return u, nil
+}
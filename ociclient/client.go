@@ -16,9 +16,8 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
-	"github.com/containerd/containerd/content"
-	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/images"
 	containerdlog "github.com/containerd/containerd/log"
 	"github.com/containerd/containerd/remotes"
@@ -31,6 +30,7 @@ import (
 	"github.com/opencontainers/image-spec/specs-go"
 	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/gardener/component-cli/ociclient/cache"
@@ -47,8 +47,21 @@ type client struct {
 	transport      http.RoundTripper
 	allowPlainHttp bool
 	getHostConfig  docker.RegistryHosts
+	authScopes     *authScopeCache
+	listLimiter    *listRateLimiter
+	chunkSize      int64
+
+	pins            PinStore
+	trustOnFirstUse bool
+
+	strictConformance bool
+
+	offline        bool
+	offlineLayouts []*OCILayout
 
 	knownMediaTypes sets.String
+
+	defaultAcceptMediaTypes []string
 }
 
 // NewClient creates a new OCI Client.
@@ -72,7 +85,11 @@ func NewClient(log logr.Logger, opts ...Option) (*client, error) {
 			if len(options.CacheConfig.BasePath) != 0 {
 				cacheOpts = append(cacheOpts, cache.WithBasePath(options.CacheConfig.BasePath))
 			}
+			if len(options.CacheConfig.Namespace) != 0 {
+				cacheOpts = append(cacheOpts, cache.WithNamespace(options.CacheConfig.Namespace))
+			}
 			cacheOpts = append(cacheOpts, cache.WithInMemoryOverlay(options.CacheConfig.InMemoryOverlay))
+			cacheOpts = append(cacheOpts, cache.WithCompression(options.CacheConfig.Compress))
 		}
 		c, err := cache.NewCache(log, cacheOpts...)
 		if err != nil {
@@ -88,6 +105,45 @@ func NewClient(log logr.Logger, opts ...Option) (*client, error) {
 	if trp == nil {
 		trp = http.DefaultTransport
 	}
+	if options.TLSConfig != nil || len(options.RegistryTLSConfigs) != 0 {
+		var err error
+		trp, err = newTLSTransport(trp, options.TLSConfig, options.RegistryTLSConfigs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.StrictConformance {
+		handler := options.ConformanceWarningHandler
+		if handler == nil {
+			handler = logConformanceWarning(log)
+		}
+		trp = newConformanceChecker(trp, handler)
+	}
+	if options.RequestsPerSecond > 0 {
+		trp = newRequestRateLimiter(trp, options.RequestsPerSecond)
+	}
+	if len(options.RegistryLimits) != 0 {
+		trp = newRegistryLimiter(trp, options.RegistryLimits)
+		if options.RetryPolicy == nil {
+			// requests that are rejected because of a quota are retried by the retry transport
+			options.RetryPolicy = &RetryPolicy{MaxRetries: DefaultQuotaMaxRetries}
+		}
+	}
+	if options.RetryPolicy != nil {
+		trp = newRetryTransport(trp, *options.RetryPolicy)
+	}
+	if !options.SkipContentDigestVerification {
+		trp = newContentDigestVerifier(trp)
+	}
+	trp = newImmutableTagDetector(trp)
+	if options.Offline {
+		trp = offlineTransport{}
+	}
+
+	authScopeTTL := options.AuthScopeTTL
+	if authScopeTTL == 0 {
+		authScopeTTL = DefaultAuthScopeTTL
+	}
 
 	cLogger := logrus.New()
 	cLogger.SetLevel(logrus.FatalLevel)
@@ -112,7 +168,17 @@ func NewClient(log logr.Logger, opts ...Option) (*client, error) {
 				return options.AllowPlainHttp, nil
 			}),
 		),
-		knownMediaTypes: DefaultKnownMediaTypes.Union(options.CustomMediaTypes),
+		authScopes:        newAuthScopeCache(authScopeTTL),
+		listLimiter:       newListRateLimiter(options.RateLimit),
+		chunkSize:         options.ChunkSize,
+		pins:              options.PinStore,
+		trustOnFirstUse:   options.TrustOnFirstUse,
+		strictConformance: options.StrictConformance,
+		offline:           options.Offline,
+		offlineLayouts:    options.OfflineLayouts,
+		knownMediaTypes:   DefaultKnownMediaTypes.Union(options.CustomMediaTypes),
+
+		defaultAcceptMediaTypes: options.AcceptMediaTypes,
 	}, nil
 }
 
@@ -152,6 +218,9 @@ func (c *client) GetOCIArtifact(ctx context.Context, ref string) (*oci.Artifact,
 	}
 
 	if desc.MediaType == MediaTypeDockerV2Schema1Manifest || desc.MediaType == MediaTypeDockerV2Schema1SignedManifest {
+		if c.strictConformance {
+			return nil, fmt.Errorf("docker schema 1 manifest %s is not converted in strict conformance mode", ref)
+		}
 		c.log.V(7).Info("found v1 manifest -> convert to v2")
 		convertedManifestDesc, err := ConvertV1ManifestToV2(ctx, c, c.cache, ref, desc)
 		if err != nil {
@@ -230,29 +299,21 @@ func (c *client) PushOCIArtifact(ctx context.Context, ref string, artifact *oci.
 	}
 	ref = refspec.String()
 
-	opts := &PushOptions{}
-	opts.Store = c.cache
-	opts.ApplyOptions(options)
-
-	tempCache := c.cache
-	if tempCache == nil {
-		tempCache = cache.NewInMemoryCache()
-	}
-
-	resolver, err := c.getResolverForRef(ctx, ref, transport.PushScope)
-	if err != nil {
-		return err
-	}
-	pusher, err := resolver.Pusher(ctx, ref)
-	if err != nil {
-		return err
-	}
-
 	if artifact.IsManifest() {
-		_, err := c.pushManifest(ctx, artifact.GetManifest().Data, pusher, tempCache, opts)
-		return err
+		manifest := artifact.GetManifest().Data
+		desc, err := CreateDescriptorFromManifest(manifest)
+		if err != nil {
+			return fmt.Errorf("unable to create manifest descriptor: %w", err)
+		}
+		manifestBytes, err := json.Marshal(manifest)
+		if err != nil {
+			return fmt.Errorf("unable to marshal manifest: %w", err)
+		}
+		// the manifest is pushed as raw manifest so that existing manifests, immutable tags and pins
+		// are handled the same way for all pushes.
+		return c.PushRawManifest(ctx, ref, desc, manifestBytes, options...)
 	} else if artifact.IsIndex() {
-		return c.pushImageIndex(ctx, artifact.GetIndex(), pusher, tempCache, opts)
+		return c.pushImageIndex(ctx, ref, artifact.GetIndex(), options...)
 	} else {
 		// execution of this code should never happen
 		// the oci artifact should always be of type manifest or index
@@ -284,7 +345,7 @@ func (c *client) PushBlob(ctx context.Context, ref string, desc ocispecv1.Descri
 		return err
 	}
 
-	if err := c.pushContent(ctx, opts.Store, pusher, desc); err != nil {
+	if err := c.pushContent(ctx, ref, opts.Store, pusher, desc); err != nil {
 		return err
 	}
 
@@ -312,6 +373,13 @@ func (c *client) PushRawManifest(ctx context.Context, ref string, desc ocispecv1
 		return err
 	}
 
+	// skip the upload if the reference already points to the same manifest.
+	// This makes repeated pushes cheap and avoids unnecessary tag mutations.
+	if c.manifestExists(ctx, resolver, ref, desc) {
+		opts.setStatus(PushStatusAlreadyExists)
+		return nil
+	}
+
 	pusher, err := resolver.Pusher(ctx, ref)
 	if err != nil {
 		return err
@@ -334,19 +402,17 @@ func (c *client) PushRawManifest(ctx context.Context, ref string, desc ocispecv1
 			if err := tempCache.Add(dummyDesc, ioutil.NopCloser(bytes.NewBuffer(dummyConfig))); err != nil {
 				return fmt.Errorf("unable to add dummy config to cache: %w", err)
 			}
-			if err := c.pushContent(ctx, tempCache, pusher, dummyDesc); err != nil {
+			if err := c.pushContent(ctx, ref, tempCache, pusher, dummyDesc); err != nil {
 				return fmt.Errorf("unable to push dummy config: %w", err)
 			}
 		} else {
-			if err := c.pushContent(ctx, opts.Store, pusher, manifest.Config); err != nil {
+			if err := c.pushContent(ctx, ref, opts.Store, pusher, manifest.Config); err != nil {
 				return fmt.Errorf("unable to push config: %w", err)
 			}
 		}
 
-		for _, layerDesc := range manifest.Layers {
-			if err := c.pushContent(ctx, opts.Store, pusher, layerDesc); err != nil {
-				return fmt.Errorf("unable to push layer: %w", err)
-			}
+		if err := c.pushLayers(ctx, ref, opts.Store, pusher, manifest.Layers, opts.LayerConcurrency); err != nil {
+			return err
 		}
 	}
 
@@ -354,13 +420,76 @@ func (c *client) PushRawManifest(ctx context.Context, ref string, desc ocispecv1
 		return fmt.Errorf("unable to add manifest to cache: %w", err)
 	}
 
-	if err := c.pushContent(ctx, tempCache, pusher, desc); err != nil {
-		return fmt.Errorf("unable to push manifest: %w", err)
+	if err := c.pushContent(ctx, ref, tempCache, pusher, desc); err != nil {
+		registryErr, ok := asRegistryTagImmutableError(err)
+		if !ok {
+			return fmt.Errorf("unable to push manifest: %w", err)
+		}
+		tagErr := &TagImmutableError{Ref: ref, Err: registryErr}
+		if opts.ImmutableTagFallback == nil {
+			return tagErr
+		}
+		c.log.V(3).Info("tag is immutable, fall back to push by digest", "ref", ref, "digest", desc.Digest.String())
+		if err := c.pushManifestFallback(ctx, ref, tempCache, desc, *opts.ImmutableTagFallback); err != nil {
+			return fmt.Errorf("%s: %w", tagErr.Error(), err)
+		}
+		opts.setStatus(PushStatusPushedByDigest)
+		return nil
+	}
+
+	// the tag has been changed by the client itself, so the pin is moved to the pushed manifest.
+	if _, ok := normalizePinRef(ref); ok && c.pins != nil {
+		if err := c.pins.Set(ref, desc.Digest); err != nil {
+			return fmt.Errorf("unable to pin %q: %w", ref, err)
+		}
 	}
 
+	opts.setStatus(PushStatusPushed)
+	return nil
+}
+
+// pushManifestFallback pushes the manifest by digest and to the optional alternate tag
+// if the tag of the reference is immutable.
+func (c *client) pushManifestFallback(ctx context.Context, ref string, store Store, desc ocispecv1.Descriptor, fallback WithImmutableTagFallbackOption) error {
+	refs, err := fallback.fallbackRefs(ref, desc.Digest)
+	if err != nil {
+		return err
+	}
+	for _, fallbackRef := range refs {
+		// a new resolver is needed for every reference as the push status of the manifest is tracked per resolver.
+		resolver, err := c.getResolverForRef(ctx, fallbackRef, transport.PushScope)
+		if err != nil {
+			return err
+		}
+		pusher, err := resolver.Pusher(ctx, fallbackRef)
+		if err != nil {
+			return err
+		}
+		if err := c.pushContent(ctx, fallbackRef, store, pusher, desc); err != nil {
+			if registryErr, ok := asRegistryTagImmutableError(err); ok {
+				return &TagImmutableError{Ref: fallbackRef, Err: registryErr}
+			}
+			return fmt.Errorf("unable to push manifest to %q: %w", fallbackRef, err)
+		}
+	}
 	return nil
 }
 
+// manifestExists checks whether the reference already resolves to a manifest with the digest of the given descriptor.
+// Resolve errors are not returned as the manifest is expected to not exist in that case.
+func (c *client) manifestExists(ctx context.Context, resolver remotes.Resolver, ref string, desc ocispecv1.Descriptor) bool {
+	_, existingDesc, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		c.log.V(7).Info("unable to resolve existing manifest", "ref", ref, "error", err.Error())
+		return false
+	}
+	if existingDesc.Digest != desc.Digest {
+		return false
+	}
+	c.log.V(5).Info("manifest already exists", "ref", ref, "digest", desc.Digest.String())
+	return true
+}
+
 func (c *client) GetRawManifest(ctx context.Context, ref string) (ocispecv1.Descriptor, []byte, error) {
 	refspec, err := oci.ParseRef(ref)
 	if err != nil {
@@ -378,6 +507,9 @@ func (c *client) GetRawManifest(ctx context.Context, ref string) (ocispecv1.Desc
 	}
 
 	if desc.MediaType == MediaTypeDockerV2Schema1Manifest || desc.MediaType == MediaTypeDockerV2Schema1SignedManifest {
+		if c.strictConformance {
+			return ocispecv1.Descriptor{}, nil, fmt.Errorf("docker schema 1 manifest %s is not converted in strict conformance mode", ref)
+		}
 		c.log.V(7).Info("found v1 manifest -> convert to v2")
 		convertedManifestDesc, err := ConvertV1ManifestToV2(ctx, c, c.cache, ref, desc)
 		if err != nil {
@@ -386,9 +518,16 @@ func (c *client) GetRawManifest(ctx context.Context, ref string) (ocispecv1.Desc
 		desc = convertedManifestDesc
 	}
 
-	if !IsSingleArchImage(desc.MediaType) && !IsMultiArchImage(desc.MediaType) {
+	if !IsSingleArchImage(desc.MediaType) && !IsMultiArchImage(desc.MediaType) && !c.isAcceptedMediaType(ctx, desc.MediaType) {
 		return ocispecv1.Descriptor{}, nil, fmt.Errorf("media type is not an image manifest or image index: %s", desc.MediaType)
 	}
+	if !IsSingleArchImage(desc.MediaType) && !IsMultiArchImage(desc.MediaType) {
+		rawManifest, err := c.fetchManifest(ctx, refspec, desc)
+		if err != nil {
+			return ocispecv1.Descriptor{}, nil, err
+		}
+		return desc, rawManifest, nil
+	}
 
 	data := bytes.NewBuffer([]byte{})
 	if err := c.Fetch(ctx, ref, desc, data); err != nil {
@@ -399,7 +538,7 @@ func (c *client) GetRawManifest(ctx context.Context, ref string) (ocispecv1.Desc
 	return desc, rawManifest, nil
 }
 
-func (c *client) pushManifest(ctx context.Context, manifest *ocispecv1.Manifest, pusher remotes.Pusher, cache cache.Cache, opts *PushOptions) (ocispecv1.Descriptor, error) {
+func (c *client) pushManifest(ctx context.Context, ref string, manifest *ocispecv1.Manifest, pusher remotes.Pusher, cache cache.Cache, opts *PushOptions) (ocispecv1.Descriptor, error) {
 	// add dummy config if it is not set
 	if manifest.Config.Size == 0 {
 		dummyConfig := []byte("{}")
@@ -411,20 +550,18 @@ func (c *client) pushManifest(ctx context.Context, manifest *ocispecv1.Manifest,
 		if err := cache.Add(dummyDesc, ioutil.NopCloser(bytes.NewBuffer(dummyConfig))); err != nil {
 			return ocispecv1.Descriptor{}, fmt.Errorf("unable to add dummy config to cache: %w", err)
 		}
-		if err := c.pushContent(ctx, cache, pusher, dummyDesc); err != nil {
+		if err := c.pushContent(ctx, ref, cache, pusher, dummyDesc); err != nil {
 			return ocispecv1.Descriptor{}, fmt.Errorf("unable to push dummy config: %w", err)
 		}
 	} else {
-		if err := c.pushContent(ctx, opts.Store, pusher, manifest.Config); err != nil {
+		if err := c.pushContent(ctx, ref, opts.Store, pusher, manifest.Config); err != nil {
 			return ocispecv1.Descriptor{}, fmt.Errorf("unable to push config: %w", err)
 		}
 	}
 
 	// last upload all layers
-	for _, layer := range manifest.Layers {
-		if err := c.pushContent(ctx, opts.Store, pusher, layer); err != nil {
-			return ocispecv1.Descriptor{}, fmt.Errorf("unable to push layer: %w", err)
-		}
+	if err := c.pushLayers(ctx, ref, opts.Store, pusher, manifest.Layers, opts.LayerConcurrency); err != nil {
+		return ocispecv1.Descriptor{}, err
 	}
 
 	manifestDesc, err := CreateDescriptorFromManifest(manifest)
@@ -441,19 +578,30 @@ func (c *client) pushManifest(ctx context.Context, manifest *ocispecv1.Manifest,
 		return ocispecv1.Descriptor{}, fmt.Errorf("unable to add manifest to cache: %w", err)
 	}
 
-	if err := c.pushContent(ctx, cache, pusher, manifestDesc); err != nil {
+	if err := c.pushContent(ctx, ref, cache, pusher, manifestDesc); err != nil {
 		return ocispecv1.Descriptor{}, fmt.Errorf("unable to push manifest: %w", err)
 	}
 
 	return manifestDesc, nil
 }
 
-func (c *client) pushImageIndex(ctx context.Context, indexArtifact *oci.Index, pusher remotes.Pusher, cache cache.Cache, opts *PushOptions) error {
+// pushImageIndex pushes the manifests of the image index and afterwards the index itself as raw manifest.
+// Nothing is pushed if the reference already points to the same index.
+func (c *client) pushImageIndex(ctx context.Context, ref string, indexArtifact *oci.Index, options ...PushOption) error {
+	opts := &PushOptions{}
+	opts.Store = c.cache
+	opts.ApplyOptions(options)
+
+	tempCache := c.cache
+	if tempCache == nil {
+		tempCache = cache.NewInMemoryCache()
+	}
+
 	manifestDescs := []ocispecv1.Descriptor{}
 	for _, manifest := range indexArtifact.Manifests {
-		mdesc, err := c.pushManifest(ctx, manifest.Data, pusher, cache, opts)
+		mdesc, err := CreateDescriptorFromManifest(manifest.Data)
 		if err != nil {
-			return fmt.Errorf("unable to upload manifest: %w", err)
+			return fmt.Errorf("unable to create manifest descriptor: %w", err)
 		}
 		mdesc.Platform = manifest.Descriptor.Platform
 		mdesc.Annotations = manifest.Descriptor.Annotations
@@ -478,16 +626,25 @@ func (c *client) pushImageIndex(ctx context.Context, indexArtifact *oci.Index, p
 		Size:      int64(len(indexBytes)),
 	}
 
-	manifestBuf := bytes.NewBuffer(indexBytes)
-	if err := cache.Add(indexDescriptor, ioutil.NopCloser(manifestBuf)); err != nil {
+	resolver, err := c.getResolverForRef(ctx, ref, transport.PushScope)
+	if err != nil {
 		return err
 	}
-
-	if err := c.pushContent(ctx, cache, pusher, indexDescriptor); err != nil {
-		return fmt.Errorf("unable to push image index: %w", err)
+	if c.manifestExists(ctx, resolver, ref, indexDescriptor) {
+		opts.setStatus(PushStatusAlreadyExists)
+		return nil
+	}
+	pusher, err := resolver.Pusher(ctx, ref)
+	if err != nil {
+		return err
+	}
+	for _, manifest := range indexArtifact.Manifests {
+		if _, err := c.pushManifest(ctx, ref, manifest.Data, pusher, tempCache, opts); err != nil {
+			return fmt.Errorf("unable to upload manifest: %w", err)
+		}
 	}
 
-	return nil
+	return c.PushRawManifest(ctx, ref, indexDescriptor, indexBytes, options...)
 }
 
 func (c *client) GetManifest(ctx context.Context, ref string) (*ocispecv1.Manifest, error) {
@@ -616,7 +773,12 @@ func (c *client) getRefParserOptions(ref string) ([]name.Option, error) {
 }
 
 // getTransportForRef returns the authenticated transport for a reference.
+// Transports are cached per repository and reused as long as they have been granted all requested scopes
+// and the credentials of the keyring have not changed or expired.
 func (c *client) getTransportForRef(ctx context.Context, ref string, scopes ...string) (http.RoundTripper, error) {
+	if c.offline {
+		return nil, &OfflineError{Ref: ref}
+	}
 	parseOptions, err := c.getRefParserOptions(ref)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get ref parser options: %w", err)
@@ -627,32 +789,72 @@ func (c *client) getTransportForRef(ctx context.Context, ref string, scopes ...s
 		return nil, fmt.Errorf("unable to parse ref: %w", err)
 	}
 
+	repository := repo.Context().Name()
+	generation := c.keyringGeneration()
+	cachedTrp, actions := c.authScopes.Get(repository, generation, scopes...)
+	if cachedTrp != nil {
+		return cachedTrp, nil
+	}
+
 	auth, err := c.keychain.ResolveWithContext(ctx, repo.Context())
 	if err != nil {
 		return nil, fmt.Errorf("unable to get authentication: %w", err)
 	}
 
-	for i, scope := range scopes {
-		scopes[i] = repo.Scope(scope)
+	var repoScopes []string
+	if len(actions) != 0 {
+		repoScopes = append(repoScopes, repo.Scope(strings.Join(actions, ",")))
 	}
-	trp, err := transport.NewWithContext(ctx, repo.Context().Registry, auth, c.transport, scopes)
+	trp, err := transport.NewWithContext(ctx, repo.Context().Registry, auth, c.transport, repoScopes)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create transport: %w", err)
 	}
+	var expires time.Time
+	if expirer, ok := auth.(credentials.Expirer); ok {
+		expires = expirer.Expiry()
+	}
+	c.authScopes.Set(repository, generation, expires, actions, trp)
 	return trp, nil
 }
 
+// keyringGeneration returns the generation of the credentials of the keyring.
+// Keyrings whose credentials cannot change always have the generation 0.
+func (c *client) keyringGeneration() uint64 {
+	if keyring, ok := c.keychain.(credentials.GenerationKeyring); ok {
+		return keyring.Generation()
+	}
+	return 0
+}
+
 // getResolverForRef returns the authenticated resolver for a reference.
+// In offline mode, the resolver serves exclusively from the offline layouts and the cache.
 func (c *client) getResolverForRef(ctx context.Context, ref string, scopes ...string) (remotes.Resolver, error) {
-	trp, err := c.getTransportForRef(ctx, ref, scopes...)
-	if err != nil {
-		return nil, fmt.Errorf("unable to create transport: %w", err)
-	}
-	httpClient := c.getHttpClient()
-	httpClient.Transport = trp
-	return docker.NewResolver(docker.ResolverOptions{
-		Client: httpClient,
-	}), nil
+	var resolver remotes.Resolver
+	if c.offline {
+		resolver = &offlineResolver{
+			layouts: c.offlineLayouts,
+			cache:   c.cache,
+		}
+	} else {
+		trp, err := c.getTransportForRef(ctx, ref, scopes...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create transport: %w", err)
+		}
+		httpClient := c.getHttpClient()
+		httpClient.Transport = trp
+		resolver = docker.NewResolver(docker.ResolverOptions{
+			Client:  httpClient,
+			Headers: c.acceptHeaders(ctx),
+		})
+	}
+	if c.pins != nil {
+		return &pinningResolver{
+			Resolver:        resolver,
+			pins:            c.pins,
+			trustOnFirstUse: c.trustOnFirstUse,
+		}, nil
+	}
+	return resolver, nil
 }
 
 // ListTags lists all tags for a given ref.
@@ -682,9 +884,11 @@ func (c *client) ListTags(ctx context.Context, ref string) ([]string, error) {
 		Scheme: hostConfig.Scheme,
 		Host:   hostConfig.Host,
 		Path:   path.Join(hostConfig.Path, refspec.Repository, "tags", "list"),
+	}
+	if !c.strictConformance {
 		// ECR returns an error if n > 1000:
 		// https://github.com/google/go-containerregistry/issues/681
-		RawQuery: "n=1000",
+		u.RawQuery = "n=1000"
 	}
 
 	var tags []string
@@ -717,6 +921,9 @@ func (c *client) ListTags(ctx context.Context, ref string) ([]string, error) {
 
 // ListRepositories lists all repositories for the given registry host.
 func (c *client) ListRepositories(ctx context.Context, ref string) ([]string, error) {
+	if c.offline {
+		return nil, &OfflineError{Ref: ref}
+	}
 	parseOptions, err := c.getRefParserOptions(ref)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get ref parser options: %w", err)
@@ -752,9 +959,11 @@ func (c *client) ListRepositories(ctx context.Context, ref string) ([]string, er
 		Scheme: hostConfig.Scheme,
 		Host:   hostConfig.Host,
 		Path:   path.Join(hostConfig.Path, "_catalog"),
+	}
+	if !c.strictConformance {
 		// ECR returns an error if n > 1000:
 		// https://github.com/google/go-containerregistry/issues/681
-		RawQuery: "n=1000",
+		u.RawQuery = "n=1000"
 	}
 
 	// parse registry to also support more specific credentials e.g. for gcr with gcr.io/my-project
@@ -805,14 +1014,15 @@ func (c *client) ListRepositories(ctx context.Context, ref string) ([]string, er
 	return repositories, nil
 }
 
-// doRequest does a authenticated request to the given oci registry
+// doRequest does a authenticated request to the given oci registry.
+// Rate limited requests are retried according to the rate limit options of the client.
 func (c *client) doRequest(ctx context.Context, httpClient *http.Client, url *url.URL) (*http.Response, error) {
 	req := &http.Request{
 		Method: http.MethodGet,
 		URL:    url,
 		Header: make(http.Header),
 	}
-	resp, err := httpClient.Do(req.WithContext(ctx))
+	resp, err := c.listLimiter.Do(ctx, httpClient, req)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get %q: %w", url.String(), err)
 	}
@@ -881,7 +1091,38 @@ func CreateDescriptorFromManifest(manifest *ocispecv1.Manifest) (ocispecv1.Descr
 	return manifestDescriptor, nil
 }
 
-func (c *client) pushContent(ctx context.Context, store Store, pusher remotes.Pusher, desc ocispecv1.Descriptor) error {
+// pushLayers pushes the layers with at most concurrency parallel uploads.
+// The remaining uploads are cancelled if the upload of a layer fails.
+func (c *client) pushLayers(ctx context.Context, ref string, store Store, pusher remotes.Pusher, layers []ocispecv1.Descriptor, concurrency int) error {
+	if concurrency <= 1 {
+		for _, layer := range layers {
+			if err := c.pushContent(ctx, ref, store, pusher, layer); err != nil {
+				return fmt.Errorf("unable to push layer: %w", err)
+			}
+		}
+		return nil
+	}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, concurrency)
+	for _, layer := range layers {
+		layer := layer
+		select {
+		case <-groupCtx.Done():
+		case sem <- struct{}{}:
+			group.Go(func() error {
+				defer func() { <-sem }()
+				if err := c.pushContent(groupCtx, ref, store, pusher, layer); err != nil {
+					return fmt.Errorf("unable to push layer %s: %w", layer.Digest, err)
+				}
+				return nil
+			})
+		}
+	}
+	return group.Wait()
+}
+
+func (c *client) pushContent(ctx context.Context, ref string, store Store, pusher remotes.Pusher, desc ocispecv1.Descriptor) error {
 	if store == nil {
 		return errors.New("a store is needed to upload content but no store has been defined")
 	}
@@ -890,16 +1131,7 @@ func (c *client) pushContent(ctx context.Context, store Store, pusher remotes.Pu
 		return err
 	}
 	defer r.Close()
-
-	writer, err := pusher.Push(AddKnownMediaTypesToCtx(ctx, []string{desc.MediaType}), desc)
-	if err != nil {
-		if errdefs.IsAlreadyExists(err) {
-			return nil
-		}
-		return err
-	}
-	defer writer.Close()
-	return content.Copy(ctx, writer, r, desc.Size, desc.Digest)
+	return c.pushReader(ctx, ref, pusher, desc, r)
 }
 
 // AddKnownMediaTypesToCtx adds a list of known media types to the context
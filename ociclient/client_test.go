@@ -5,15 +5,21 @@
 package ociclient_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/opencontainers/go-digest"
@@ -21,7 +27,9 @@ import (
 	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
 	"github.com/gardener/component-cli/ociclient/credentials"
+	"github.com/gardener/component-cli/ociclient/oci"
 	"github.com/gardener/component-cli/pkg/testutils"
 )
 
@@ -244,6 +252,52 @@ var _ = Describe("client", func() {
 			testutils.CompareRemoteManifest(ctx, client, newRef, mdesc, mbytes, configData, layersData)
 		}, 20)
 
+		It("should copy the layers of an oci artifact in parallel", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			configData := []byte("config-data")
+			layersData := [][]byte{
+				[]byte("layer-1-data"),
+				[]byte("layer-2-data"),
+				[]byte("layer-3-data"),
+				[]byte("layer-4-data"),
+			}
+			ref := testenv.Addr + "/single-arch-tests/5/src/artifact:v0.0.1"
+			mdesc, mbytes := testutils.UploadTestImage(ctx, client, ref, ocispecv1.MediaTypeImageManifest, configData, layersData)
+			newRef := testenv.Addr + "/single-arch-tests/5/tgt/artifact:v0.0.1"
+
+			Expect(ociclient.Copy(ctx, client, ref, newRef, ociclient.WithLayerConcurrency(3))).To(Succeed())
+
+			testutils.CompareRemoteManifest(ctx, client, newRef, mdesc, mbytes, configData, layersData)
+		}, 20)
+
+		It("should copy an oci artifact with separate source and target clients", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			configData := []byte("config-data")
+			layersData := [][]byte{
+				[]byte("layer-1-data"),
+				[]byte("layer-2-data"),
+			}
+			ref := testenv.Addr + "/single-arch-tests/3/src/artifact:v0.0.1"
+			mdesc, mbytes := testutils.UploadTestImage(ctx, client, ref, ocispecv1.MediaTypeImageManifest, configData, layersData)
+			newRef := testenv.Addr + "/single-arch-tests/3/tgt/artifact:v0.0.1"
+
+			tgtKeyring := credentials.New()
+			Expect(tgtKeyring.AddAuthConfig(testenv.Addr, credentials.AuthConfig{
+				Username: testenv.BasicAuth.Username,
+				Password: testenv.BasicAuth.Password,
+			})).To(Succeed())
+			tgtClient, err := ociclient.NewClient(logr.Discard(), ociclient.WithKeyring(tgtKeyring))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ociclient.CopyWithClients(ctx, client, tgtClient, ref, newRef)).To(Succeed())
+
+			testutils.CompareRemoteManifest(ctx, tgtClient, newRef, mdesc, mbytes, configData, layersData)
+		}, 20)
+
 		It("should copy an oci image index", func() {
 			ctx := context.Background()
 			defer ctx.Done()
@@ -322,6 +376,812 @@ var _ = Describe("client", func() {
 
 	})
 
+	Context("ContentDigestVerification", func() {
+		var (
+			server  *httptest.Server
+			host    string
+			handler func(http.ResponseWriter, *http.Request)
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				handler(writer, request)
+			}))
+
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			host = hostUrl.Host
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should fail with a digest mismatch error if the returned manifest does not match the returned digest", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			manifestBytes := []byte(`{"schemaVersion":2,"config":{},"layers":[]}`)
+			manifestDigest := digest.FromBytes(manifestBytes)
+			handler = func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/v2/" {
+					// first auth discovery call by the library
+					w.WriteHeader(200)
+					return
+				}
+				w.Header().Set("Content-Type", ocispecv1.MediaTypeImageManifest)
+				w.Header().Set(ociclient.HeaderDockerContentDigest, manifestDigest.String())
+				w.Header().Set("Content-Length", fmt.Sprint(len(manifestBytes)))
+				w.WriteHeader(200)
+				if req.Method == http.MethodGet {
+					// simulate a proxy that rewrites the content
+					_, _ = w.Write([]byte(`{"schemaVersion":2,"config":{},"layers":[{}]}`)[:len(manifestBytes)])
+				}
+			}
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()))
+			Expect(err).ToNot(HaveOccurred())
+			_, _, err = client.GetRawManifest(ctx, host+"/myproject/repo/myimage:0.0.1")
+			Expect(err).To(HaveOccurred())
+			Expect(ociclient.IsDigestMismatchError(err)).To(BeTrue())
+		})
+
+		It("should return the manifest if it matches the returned digest", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			manifestBytes := []byte(`{"schemaVersion":2,"config":{},"layers":[]}`)
+			manifestDigest := digest.FromBytes(manifestBytes)
+			handler = func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/v2/" {
+					// first auth discovery call by the library
+					w.WriteHeader(200)
+					return
+				}
+				w.Header().Set("Content-Type", ocispecv1.MediaTypeImageManifest)
+				w.Header().Set(ociclient.HeaderDockerContentDigest, manifestDigest.String())
+				w.Header().Set("Content-Length", fmt.Sprint(len(manifestBytes)))
+				w.WriteHeader(200)
+				if req.Method == http.MethodGet {
+					_, _ = w.Write(manifestBytes)
+				}
+			}
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()))
+			Expect(err).ToNot(HaveOccurred())
+			desc, data, err := client.GetRawManifest(ctx, host+"/myproject/repo/myimage:0.0.1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(desc.Digest).To(Equal(manifestDigest))
+			Expect(data).To(Equal(manifestBytes))
+		})
+
+		It("should verify the digest of a manifest upload that is retried", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			configDesc := ocispecv1.Descriptor{
+				MediaType: ocispecv1.MediaTypeImageConfig,
+				Digest:    digest.FromString("{}"),
+				Size:      2,
+			}
+			manifestBytes, err := json.Marshal(ocispecv1.Manifest{
+				Versioned: specs.Versioned{SchemaVersion: 2},
+				Config:    configDesc,
+				Layers:    []ocispecv1.Descriptor{},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			manifestDesc := ocispecv1.Descriptor{
+				MediaType: ocispecv1.MediaTypeImageManifest,
+				Digest:    digest.FromBytes(manifestBytes),
+				Size:      int64(len(manifestBytes)),
+			}
+			var uploads [][]byte
+			handler = func(w http.ResponseWriter, req *http.Request) {
+				switch {
+				case req.URL.Path == "/v2/":
+					// first auth discovery call by the library
+					w.WriteHeader(200)
+				case req.Method == http.MethodHead && strings.Contains(req.URL.Path, "/blobs/"):
+					// the config already exists
+					w.Header().Set("Content-Length", "2")
+					w.WriteHeader(http.StatusOK)
+				case req.Method == http.MethodPut:
+					data, err := ioutil.ReadAll(req.Body)
+					Expect(err).ToNot(HaveOccurred())
+					uploads = append(uploads, data)
+					if len(uploads) == 1 {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+					w.Header().Set(ociclient.HeaderDockerContentDigest, digest.FromBytes(data).String())
+					w.WriteHeader(http.StatusCreated)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()),
+				ociclient.WithRetryPolicy(ociclient.RetryPolicy{
+					MaxRetries:     1,
+					InitialBackoff: time.Millisecond,
+				}))
+			Expect(err).ToNot(HaveOccurred())
+			store := ociclient.GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
+				_, err := writer.Write([]byte("{}"))
+				return err
+			})
+			Expect(client.PushRawManifest(ctx, host+"/myproject/repo/myimage:0.0.1", manifestDesc, manifestBytes, ociclient.WithStore(store))).To(Succeed())
+			Expect(uploads).To(Equal([][]byte{manifestBytes, manifestBytes}))
+		})
+	})
+
+	Context("AuthScopes", func() {
+		var (
+			server         *httptest.Server
+			host           string
+			requestedScope []string
+		)
+
+		BeforeEach(func() {
+			requestedScope = []string{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch {
+				case req.URL.Path == "/token":
+					requestedScope = append(requestedScope, req.URL.Query().Get("scope"))
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"token":"test-token"}`))
+				case req.Header.Get("Authorization") != "Bearer test-token":
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, server.URL))
+					w.WriteHeader(http.StatusUnauthorized)
+				case req.URL.Path == "/v2/":
+					w.WriteHeader(http.StatusOK)
+				case req.Method == http.MethodHead:
+					// all blobs already exist
+					w.Header().Set("Content-Length", "4")
+					w.WriteHeader(http.StatusOK)
+				default:
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"name":"myproject/repo","tags":["0.0.1"]}`))
+				}
+			}))
+
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			host = hostUrl.Host
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should reuse the granted scopes of a repository", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.ListTags(ctx, host+"/myproject/repo")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = client.ListTags(ctx, host+"/myproject/repo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requestedScope).To(ConsistOf("repository:myproject/repo:pull"))
+		})
+
+		It("should upgrade the scope of a repository for a push following a pull", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.ListTags(ctx, host+"/myproject/repo")
+			Expect(err).ToNot(HaveOccurred())
+
+			blob := []byte("blob")
+			desc := ocispecv1.Descriptor{
+				MediaType: "text/plain",
+				Digest:    digest.FromBytes(blob),
+				Size:      int64(len(blob)),
+			}
+			store := cache.NewInMemoryCache()
+			Expect(store.Add(desc, io.NopCloser(bytes.NewReader(blob)))).To(Succeed())
+			Expect(client.PushBlob(ctx, host+"/myproject/repo:0.0.1", desc, ociclient.WithStore(store))).To(Succeed())
+
+			// the pull scope is covered by the upgraded scope
+			_, err = client.ListTags(ctx, host+"/myproject/repo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requestedScope).To(Equal([]string{
+				"repository:myproject/repo:pull",
+				"repository:myproject/repo:pull,push",
+			}))
+		})
+	})
+
+	Context("IdempotentPush", func() {
+		var (
+			server         *httptest.Server
+			host           string
+			existingDigest digest.Digest
+			putRequests    []string
+		)
+
+		manifestBytes := []byte(`{"schemaVersion":2,"config":{},"layers":[]}`)
+		manifestDesc := ocispecv1.Descriptor{
+			MediaType: ocispecv1.MediaTypeImageManifest,
+			Digest:    digest.FromBytes(manifestBytes),
+			Size:      int64(len(manifestBytes)),
+		}
+
+		BeforeEach(func() {
+			putRequests = []string{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch {
+				case req.URL.Path == "/v2/":
+					w.WriteHeader(http.StatusOK)
+				case req.Method == http.MethodPut:
+					putRequests = append(putRequests, req.URL.Path)
+					w.Header().Set(ociclient.HeaderDockerContentDigest, manifestDesc.Digest.String())
+					w.WriteHeader(http.StatusCreated)
+				case req.URL.Path == "/v2/myproject/repo/manifests/0.0.1" && len(existingDigest) != 0:
+					w.Header().Set("Content-Type", ocispecv1.MediaTypeImageManifest)
+					w.Header().Set(ociclient.HeaderDockerContentDigest, existingDigest.String())
+					w.Header().Set("Content-Length", fmt.Sprint(len(manifestBytes)))
+					w.WriteHeader(http.StatusOK)
+				case req.Method == http.MethodHead && req.URL.Path != "/v2/myproject/repo/manifests/0.0.1":
+					// all blobs already exist
+					w.Header().Set("Content-Length", "2")
+					w.WriteHeader(http.StatusOK)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			host = hostUrl.Host
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should skip the upload if the reference already points to the same manifest", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+			existingDigest = manifestDesc.Digest
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()))
+			Expect(err).ToNot(HaveOccurred())
+
+			var status ociclient.PushStatus
+			Expect(client.PushRawManifest(ctx, host+"/myproject/repo:0.0.1", manifestDesc, manifestBytes, ociclient.WithPushStatus(&status))).To(Succeed())
+			Expect(status).To(Equal(ociclient.PushStatusAlreadyExists))
+			Expect(putRequests).To(BeEmpty())
+		})
+
+		It("should upload the manifest if the reference points to a different manifest", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+			existingDigest = digest.FromString("other-manifest")
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()))
+			Expect(err).ToNot(HaveOccurred())
+
+			var status ociclient.PushStatus
+			Expect(client.PushRawManifest(ctx, host+"/myproject/repo:0.0.1", manifestDesc, manifestBytes, ociclient.WithPushStatus(&status))).To(Succeed())
+			Expect(status).To(Equal(ociclient.PushStatusPushed))
+			Expect(putRequests).To(ContainElement("/v2/myproject/repo/manifests/0.0.1"))
+		})
+
+		It("should skip the upload of an oci artifact if the reference already points to the same manifest", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			manifest := &ocispecv1.Manifest{}
+			Expect(json.Unmarshal(manifestBytes, manifest)).To(Succeed())
+			artifact, err := oci.NewManifestArtifact(&oci.Manifest{Data: manifest})
+			Expect(err).ToNot(HaveOccurred())
+			artifactDesc, err := ociclient.CreateDescriptorFromManifest(manifest)
+			Expect(err).ToNot(HaveOccurred())
+			existingDigest = artifactDesc.Digest
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()))
+			Expect(err).ToNot(HaveOccurred())
+
+			var status ociclient.PushStatus
+			Expect(client.PushOCIArtifact(ctx, host+"/myproject/repo:0.0.1", artifact, ociclient.WithPushStatus(&status))).To(Succeed())
+			Expect(status).To(Equal(ociclient.PushStatusAlreadyExists))
+			Expect(putRequests).To(BeEmpty())
+		})
+	})
+
+	Context("ChunkedUpload", func() {
+		var (
+			server   *httptest.Server
+			host     string
+			requests []string
+			uploaded *bytes.Buffer
+		)
+
+		BeforeEach(func() {
+			requests = []string{}
+			uploaded = bytes.NewBuffer([]byte{})
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch {
+				case req.URL.Path == "/v2/":
+					w.WriteHeader(http.StatusOK)
+				case req.Method == http.MethodHead:
+					w.WriteHeader(http.StatusNotFound)
+				case req.Method == http.MethodPost && req.URL.Path == "/v2/myproject/repo/blobs/uploads/":
+					requests = append(requests, req.Method)
+					w.Header().Set("Location", "/v2/myproject/repo/blobs/uploads/session?state=0")
+					w.WriteHeader(http.StatusAccepted)
+				case req.Method == http.MethodPatch:
+					requests = append(requests, req.Method+" "+req.Header.Get("Content-Range"))
+					_, _ = io.Copy(uploaded, req.Body)
+					w.Header().Set("Location", fmt.Sprintf("/v2/myproject/repo/blobs/uploads/session?state=%d", uploaded.Len()))
+					w.WriteHeader(http.StatusAccepted)
+				case req.Method == http.MethodPut:
+					requests = append(requests, req.Method+" "+req.URL.Query().Get("digest"))
+					w.WriteHeader(http.StatusCreated)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			host = hostUrl.Host
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should upload a blob larger than the chunk size in multiple chunks", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()),
+				ociclient.WithChunkSize(4))
+			Expect(err).ToNot(HaveOccurred())
+
+			data := []byte("0123456789")
+			desc := ocispecv1.Descriptor{
+				MediaType: "application/octet-stream",
+				Digest:    digest.FromBytes(data),
+				Size:      int64(len(data)),
+			}
+			store := ociclient.GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
+				_, err := writer.Write(data)
+				return err
+			})
+
+			Expect(client.PushBlob(ctx, host+"/myproject/repo:0.0.1", desc, ociclient.WithStore(store))).To(Succeed())
+			Expect(requests).To(Equal([]string{
+				http.MethodPost,
+				"PATCH 0-3",
+				"PATCH 4-7",
+				"PATCH 8-9",
+				"PUT " + desc.Digest.String(),
+			}))
+			Expect(uploaded.Bytes()).To(Equal(data))
+		})
+	})
+
+	Context("RetryPolicy", func() {
+		var (
+			server   *httptest.Server
+			host     string
+			requests int
+			failures int
+		)
+
+		BeforeEach(func() {
+			requests = 0
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/v2/" {
+					// first auth discovery call by the library
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				requests++
+				if requests <= failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"tags": [ "0.0.1" ]}`))
+			}))
+
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			host = hostUrl.Host
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should retry requests that failed with a server error", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+			failures = 2
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()),
+				ociclient.WithRetryPolicy(ociclient.RetryPolicy{
+					MaxRetries:     3,
+					InitialBackoff: time.Millisecond,
+					Jitter:         0.5,
+				}))
+			Expect(err).ToNot(HaveOccurred())
+			tags, err := client.ListTags(ctx, host+"/myproject/repo/myimage")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tags).To(ConsistOf("0.0.1"))
+			Expect(requests).To(Equal(3))
+		})
+
+		It("should stop retrying if the retry budget is exhausted", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+			failures = 5
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()),
+				ociclient.WithRetryPolicy(ociclient.RetryPolicy{
+					MaxRetries:     10,
+					InitialBackoff: time.Millisecond,
+					Budget:         1,
+				}))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = client.ListTags(ctx, host+"/myproject/repo/myimage")
+			Expect(err).To(HaveOccurred())
+			Expect(requests).To(Equal(2))
+		})
+	})
+
+	Context("RequestsPerSecond", func() {
+
+		It("should limit the number of requests per second", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			var (
+				mux      sync.Mutex
+				received []time.Time
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mux.Lock()
+				received = append(received, time.Now())
+				mux.Unlock()
+				w.WriteHeader(http.StatusOK)
+				if req.URL.Path != "/v2/" {
+					_, _ = w.Write([]byte(`{"tags": [ "0.0.1" ]}`))
+				}
+			}))
+			defer server.Close()
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()),
+				ociclient.WithRequestsPerSecond(20))
+			Expect(err).ToNot(HaveOccurred())
+			for i := 0; i < 3; i++ {
+				_, err := client.ListTags(ctx, hostUrl.Host+"/myproject/repo/myimage")
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			mux.Lock()
+			defer mux.Unlock()
+			Expect(len(received)).To(BeNumerically(">=", 3))
+			for i := 1; i < len(received); i++ {
+				Expect(received[i].Sub(received[i-1])).To(BeNumerically(">=", 40*time.Millisecond))
+			}
+		})
+	})
+
+	Context("RegistryLimits", func() {
+
+		It("should slow down and retry requests that are rejected because of a quota", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			var (
+				mux      sync.Mutex
+				rejected int
+				received []time.Time
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mux.Lock()
+				defer mux.Unlock()
+				if req.URL.Path != "/v2/" && rejected < 2 {
+					rejected++
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				received = append(received, time.Now())
+				w.WriteHeader(http.StatusOK)
+				if req.URL.Path != "/v2/" {
+					_, _ = w.Write([]byte(`{"tags": [ "0.0.1" ]}`))
+				}
+			}))
+			defer server.Close()
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()),
+				ociclient.WithRegistryLimits{
+					hostUrl.Host: {},
+				})
+			Expect(err).ToNot(HaveOccurred())
+			start := time.Now()
+			tags, err := client.ListTags(ctx, hostUrl.Host+"/myproject/repo/myimage")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tags).To(ConsistOf("0.0.1"))
+
+			mux.Lock()
+			defer mux.Unlock()
+			Expect(rejected).To(Equal(2))
+			// the request rate is slowed down to 10 and 5 requests per second after the quota errors.
+			Expect(received[len(received)-1].Sub(start)).To(BeNumerically(">=", 300*time.Millisecond))
+		})
+
+		It("should leave the retries of requests that are rejected because of a quota to the retry policy", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			var (
+				mux       sync.Mutex
+				bodySizes []int
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch {
+				case req.URL.Path == "/v2/":
+					w.WriteHeader(http.StatusOK)
+				case req.Method == http.MethodPost:
+					w.Header().Set("Location", "/v2/myproject/repo/blobs/uploads/session")
+					w.WriteHeader(http.StatusAccepted)
+				case req.Method == http.MethodPatch:
+					body, _ := io.ReadAll(req.Body)
+					mux.Lock()
+					defer mux.Unlock()
+					bodySizes = append(bodySizes, len(body))
+					w.WriteHeader(http.StatusTooManyRequests)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()),
+				ociclient.WithChunkSize(4),
+				ociclient.WithRetryPolicy(ociclient.RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond}),
+				ociclient.WithRegistryLimits{
+					hostUrl.Host: {MaxBytesPerSecond: 1024 * 1024},
+				})
+			Expect(err).ToNot(HaveOccurred())
+
+			data := []byte("0123456789")
+			desc := ocispecv1.Descriptor{
+				MediaType: "application/octet-stream",
+				Digest:    digest.FromBytes(data),
+				Size:      int64(len(data)),
+			}
+			Expect(client.PushBlobFromReader(ctx, hostUrl.Host+"/myproject/repo:v1", desc, bytes.NewReader(data))).ToNot(Succeed())
+
+			mux.Lock()
+			defer mux.Unlock()
+			// the first chunk is sent once and retried twice by the retry policy, every time with the whole chunk.
+			Expect(bodySizes).To(Equal([]int{4, 4, 4}))
+		})
+
+		It("should limit the number of concurrent uploads to a registry", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			var (
+				mux         sync.Mutex
+				inFlight    int
+				maxInFlight int
+			)
+			registry := &blobRegistry{blobs: map[string]map[string][]byte{}}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.Method == http.MethodPut {
+					mux.Lock()
+					inFlight++
+					if inFlight > maxInFlight {
+						maxInFlight = inFlight
+					}
+					mux.Unlock()
+					time.Sleep(20 * time.Millisecond)
+					defer func() {
+						mux.Lock()
+						inFlight--
+						mux.Unlock()
+					}()
+				}
+				registry.ServeHTTP(w, req)
+			}))
+			defer server.Close()
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()),
+				ociclient.WithRegistryLimits{
+					hostUrl.Host: {MaxConcurrentUploads: 1},
+				})
+			Expect(err).ToNot(HaveOccurred())
+
+			var wg sync.WaitGroup
+			errs := make(chan error, 4)
+			for i := 0; i < 4; i++ {
+				data := []byte(fmt.Sprintf("blob %d", i))
+				desc := ocispecv1.Descriptor{
+					MediaType: "application/octet-stream",
+					Digest:    digest.FromBytes(data),
+					Size:      int64(len(data)),
+				}
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					errs <- client.PushBlobFromReader(ctx, hostUrl.Host+"/myproject/repo:v1", desc, bytes.NewReader(data))
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			mux.Lock()
+			defer mux.Unlock()
+			Expect(maxInFlight).To(Equal(1))
+		})
+	})
+
+	Context("Ping", func() {
+
+		It("should report the reachability, auth negotiation and capabilities of a registry", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+				switch req.URL.Path {
+				case "/v2/":
+					w.WriteHeader(http.StatusOK)
+				case "/v2/_catalog":
+					_, _ = w.Write([]byte(`{"repositories": []}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()))
+			Expect(err).ToNot(HaveOccurred())
+			report, err := client.Ping(ctx, hostUrl.Host+"/myproject/repo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Errors).To(BeEmpty())
+			Expect(report.Reachable).To(BeTrue())
+			Expect(report.URL).To(Equal(fmt.Sprintf("http://%s/v2/", hostUrl.Host)))
+			Expect(report.APIVersion).To(Equal("registry/2.0"))
+			Expect(report.TLS).To(BeNil())
+			Expect(report.Auth.Authenticated).To(BeTrue())
+			Expect(report.Capabilities.Catalog).To(BeTrue())
+			Expect(report.Capabilities.Referrers).ToNot(BeNil())
+			Expect(*report.Capabilities.Referrers).To(BeFalse())
+		})
+
+		It("should report an unreachable registry", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			server := httptest.NewServer(http.NotFoundHandler())
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			server.Close()
+
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()))
+			Expect(err).ToNot(HaveOccurred())
+			report, err := client.Ping(ctx, hostUrl.Host)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Reachable).To(BeFalse())
+			Expect(report.Errors).To(HaveLen(1))
+		})
+	})
+
+	Context("Closure", func() {
+
+		It("should return the closure of an image and the diff to another image", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			ref1 := fmt.Sprintf("%s/%s", testenv.Addr, "closure-tests/artifact:v0.0.1")
+			ref2 := fmt.Sprintf("%s/%s", testenv.Addr, "closure-tests/artifact:v0.0.2")
+			testutils.UploadTestImage(ctx, client, ref1, ocispecv1.MediaTypeImageManifest, []byte("config-data"),
+				[][]byte{[]byte("layer-1-data"), []byte("layer-2-data")})
+			testutils.UploadTestImage(ctx, client, ref2, ocispecv1.MediaTypeImageManifest, []byte("config-data"),
+				[][]byte{[]byte("layer-1-data"), []byte("layer-3-data")})
+
+			closure1, err := ociclient.GetClosure(ctx, client, ref1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(closure1.Len()).To(Equal(4))
+			Expect(closure1.Contains(digest.FromBytes([]byte("layer-2-data")))).To(BeTrue())
+
+			closure2, err := ociclient.GetClosure(ctx, client, ref2)
+			Expect(err).ToNot(HaveOccurred())
+			diff := closure1.Diff(closure2)
+			// the manifest and the changed layer differ
+			Expect(diff.Added).To(HaveLen(2))
+			Expect(diff.Removed).To(HaveLen(2))
+			Expect(oci.NewClosure(diff.Removed...).Contains(digest.FromBytes([]byte("layer-2-data")))).To(BeTrue())
+		}, 20)
+
+		It("should return the closure of an image index including the images of all platforms", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			repo := fmt.Sprintf("%s/%s", testenv.Addr, "closure-tests/multi-arch")
+			manifest1Desc, _ := testutils.UploadTestImage(ctx, client, repo+":linux-amd64", ocispecv1.MediaTypeImageManifest,
+				[]byte("config-amd64"), [][]byte{[]byte("layer-amd64")})
+			manifest2Desc, _ := testutils.UploadTestImage(ctx, client, repo+":linux-arm64", ocispecv1.MediaTypeImageManifest,
+				[]byte("config-arm64"), [][]byte{[]byte("layer-arm64")})
+			manifest1Desc.Platform = &ocispecv1.Platform{Architecture: "amd64", OS: "linux"}
+			manifest2Desc.Platform = &ocispecv1.Platform{Architecture: "arm64", OS: "linux"}
+			indexDesc, _ := testutils.UploadTestIndex(ctx, client, repo+":v0.0.1", ocispecv1.MediaTypeImageIndex, ocispecv1.Index{
+				Versioned: specs.Versioned{SchemaVersion: 2},
+				Manifests: []ocispecv1.Descriptor{manifest1Desc, manifest2Desc},
+			})
+
+			closure, err := ociclient.GetClosure(ctx, client, repo+":v0.0.1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(closure.Len()).To(Equal(7))
+			Expect(closure.Contains(indexDesc.Digest)).To(BeTrue())
+			Expect(closure.Contains(manifest2Desc.Digest)).To(BeTrue())
+			Expect(closure.Contains(digest.FromBytes([]byte("layer-arm64")))).To(BeTrue())
+		}, 20)
+	})
+
 	Context("ExtendedClient", func() {
 		Context("ListTags", func() {
 			var (
@@ -377,6 +1237,80 @@ var _ = Describe("client", func() {
 				Expect(tags).To(ConsistOf("0.0.1", "0.0.2"))
 			})
 
+			It("should retry rate limited requests and cache the pages", func() {
+				var (
+					ctx        = context.Background()
+					repository = "myproject/repo/myimage"
+					requests   = 0
+				)
+				defer ctx.Done()
+				handler = func(w http.ResponseWriter, req *http.Request) {
+					if req.URL.Path == "/v2/" {
+						// first auth discovery call by the library
+						w.WriteHeader(200)
+						return
+					}
+					requests++
+					if requests == 1 {
+						w.Header().Set("Retry-After", "0")
+						w.WriteHeader(http.StatusTooManyRequests)
+						return
+					}
+					w.WriteHeader(200)
+					_, _ = w.Write([]byte(`{"tags": [ "0.0.1", "0.0.2" ]}`))
+				}
+
+				client, err := ociclient.NewClient(logr.Discard(),
+					ociclient.AllowPlainHttp(true),
+					ociclient.WithKeyring(credentials.New()),
+					ociclient.WithRateLimit(ociclient.RateLimitOptions{
+						MaxRetries:     2,
+						InitialBackoff: time.Millisecond,
+						PageCacheTTL:   time.Minute,
+					}))
+				Expect(err).ToNot(HaveOccurred())
+				tags, err := client.ListTags(ctx, makeRef(repository))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(tags).To(ConsistOf("0.0.1", "0.0.2"))
+				Expect(requests).To(Equal(2))
+
+				tags, err = client.ListTags(ctx, makeRef(repository))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(tags).To(ConsistOf("0.0.1", "0.0.2"))
+				Expect(requests).To(Equal(2), "the page should be served from the cache")
+			})
+
+			It("should fail if the request is still rate limited after all retries", func() {
+				var (
+					ctx        = context.Background()
+					repository = "myproject/repo/myimage"
+					requests   = 0
+				)
+				defer ctx.Done()
+				handler = func(w http.ResponseWriter, req *http.Request) {
+					if req.URL.Path == "/v2/" {
+						// first auth discovery call by the library
+						w.WriteHeader(200)
+						return
+					}
+					requests++
+					w.WriteHeader(http.StatusTooManyRequests)
+					_, _ = w.Write([]byte(`{"errors":[{"code":"TOOMANYREQUESTS","message":"rate limit exceeded"}]}`))
+				}
+
+				client, err := ociclient.NewClient(logr.Discard(),
+					ociclient.AllowPlainHttp(true),
+					ociclient.WithKeyring(credentials.New()),
+					ociclient.WithRateLimit(ociclient.RateLimitOptions{
+						MaxRetries:     2,
+						InitialBackoff: time.Millisecond,
+					}))
+				Expect(err).ToNot(HaveOccurred())
+				_, err = client.ListTags(ctx, makeRef(repository))
+				Expect(err).To(HaveOccurred())
+				Expect(requests).To(Equal(3))
+			})
+
 		})
 
 		Context("ListRepositories", func() {
@@ -436,4 +1370,407 @@ var _ = Describe("client", func() {
 		})
 	})
 
+	Context("Referrers", func() {
+		var (
+			server       *httptest.Server
+			host         string
+			mux          sync.Mutex
+			manifests    map[string][]byte
+			mediaTypes   map[string]string
+			blobs        map[string][]byte
+			referrersAPI bool
+		)
+
+		BeforeEach(func() {
+			manifests = map[string][]byte{}
+			mediaTypes = map[string]string{}
+			blobs = map[string][]byte{}
+			referrersAPI = false
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mux.Lock()
+				defer mux.Unlock()
+				const repoPath = "/v2/myproject/repo/"
+				p := strings.TrimPrefix(req.URL.Path, repoPath)
+				switch {
+				case req.URL.Path == "/v2/":
+					w.WriteHeader(http.StatusOK)
+				case strings.HasPrefix(p, "manifests/") && req.Method == http.MethodPut:
+					data, err := ioutil.ReadAll(req.Body)
+					Expect(err).ToNot(HaveOccurred())
+					dgst := digest.FromBytes(data).String()
+					for _, ref := range []string{strings.TrimPrefix(p, "manifests/"), dgst} {
+						manifests[ref] = data
+						mediaTypes[ref] = req.Header.Get("Content-Type")
+					}
+					w.Header().Set(ociclient.HeaderDockerContentDigest, dgst)
+					w.WriteHeader(http.StatusCreated)
+				case strings.HasPrefix(p, "manifests/"):
+					ref := strings.TrimPrefix(p, "manifests/")
+					data, ok := manifests[ref]
+					if !ok {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.Header().Set("Content-Type", mediaTypes[ref])
+					w.Header().Set(ociclient.HeaderDockerContentDigest, digest.FromBytes(data).String())
+					w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+					w.WriteHeader(http.StatusOK)
+					if req.Method == http.MethodGet {
+						_, _ = w.Write(data)
+					}
+				case p == "blobs/uploads/" && req.Method == http.MethodPost:
+					w.Header().Set("Location", repoPath+"blobs/uploads/session")
+					w.WriteHeader(http.StatusAccepted)
+				case p == "blobs/uploads/session" && req.Method == http.MethodPut:
+					data, err := ioutil.ReadAll(req.Body)
+					Expect(err).ToNot(HaveOccurred())
+					dgst := req.URL.Query().Get("digest")
+					blobs[dgst] = data
+					w.Header().Set(ociclient.HeaderDockerContentDigest, dgst)
+					w.WriteHeader(http.StatusCreated)
+				case strings.HasPrefix(p, "blobs/"):
+					data, ok := blobs[strings.TrimPrefix(p, "blobs/")]
+					if !ok {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+					w.WriteHeader(http.StatusOK)
+					if req.Method == http.MethodGet {
+						_, _ = w.Write(data)
+					}
+				case strings.HasPrefix(p, "referrers/") && referrersAPI:
+					subject := strings.TrimPrefix(p, "referrers/")
+					index := map[string]interface{}{"schemaVersion": 2, "mediaType": ocispecv1.MediaTypeImageIndex}
+					referrers := []interface{}{}
+					for ref, data := range manifests {
+						manifest := ociclient.ReferrerManifest{}
+						Expect(json.Unmarshal(data, &manifest)).To(Succeed())
+						if ref != digest.FromBytes(data).String() || manifest.Subject == nil || manifest.Subject.Digest.String() != subject {
+							continue
+						}
+						referrers = append(referrers, ociclient.Referrer{
+							Descriptor: ocispecv1.Descriptor{
+								MediaType: ocispecv1.MediaTypeImageManifest,
+								Digest:    digest.FromBytes(data),
+								Size:      int64(len(data)),
+							},
+							ArtifactType: manifest.ArtifactType,
+						})
+					}
+					index["manifests"] = referrers
+					w.Header().Set("Content-Type", ocispecv1.MediaTypeImageIndex)
+					Expect(json.NewEncoder(w).Encode(index)).To(Succeed())
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			host = hostUrl.Host
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		pushReferrers := func(ctx context.Context, client ociclient.ReferrersInterface, subjectRef string) {
+			for _, artifactType := range []string{"application/vnd.example.signature", "application/vnd.example.sbom"} {
+				layerData := []byte(artifactType)
+				layerDesc := ocispecv1.Descriptor{
+					MediaType: "application/octet-stream",
+					Digest:    digest.FromBytes(layerData),
+					Size:      int64(len(layerData)),
+				}
+				store := ociclient.GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
+					_, err := writer.Write(layerData)
+					return err
+				})
+				manifest := &ociclient.ReferrerManifest{
+					ArtifactType: artifactType,
+				}
+				manifest.Layers = []ocispecv1.Descriptor{layerDesc}
+				_, err := client.PushReferrer(ctx, subjectRef, manifest, ociclient.WithStore(store))
+				Expect(err).ToNot(HaveOccurred())
+			}
+		}
+
+		It("should push and list referrers with the referrers tag schema", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()))
+			Expect(err).ToNot(HaveOccurred())
+
+			subjectRef := host + "/myproject/repo:0.0.1"
+			Expect(client.PushManifest(ctx, subjectRef, &ocispecv1.Manifest{
+				Versioned: specs.Versioned{SchemaVersion: 2},
+				Layers:    []ocispecv1.Descriptor{},
+			})).To(Succeed())
+			pushReferrers(ctx, client, subjectRef)
+
+			subjectDigest := digest.FromBytes(manifests["0.0.1"])
+			Expect(manifests).To(HaveKey("sha256-" + subjectDigest.Encoded()))
+
+			referrers, err := client.ListReferrers(ctx, subjectRef, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(referrers).To(HaveLen(2))
+			referrers, err = client.ListReferrers(ctx, subjectRef, "application/vnd.example.sbom")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(referrers).To(HaveLen(1))
+			Expect(referrers[0].ArtifactType).To(Equal("application/vnd.example.sbom"))
+
+			referrer := ociclient.ReferrerManifest{}
+			Expect(json.Unmarshal(manifests[referrers[0].Digest.String()], &referrer)).To(Succeed())
+			Expect(referrer.Subject).ToNot(BeNil())
+			Expect(referrer.Subject.Digest).To(Equal(subjectDigest))
+			Expect(referrer.Config).To(Equal(ociclient.EmptyJSONDescriptor))
+		})
+
+		It("should use the referrers api if it is supported by the registry", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+			referrersAPI = true
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()))
+			Expect(err).ToNot(HaveOccurred())
+
+			subjectRef := host + "/myproject/repo:0.0.1"
+			Expect(client.PushManifest(ctx, subjectRef, &ocispecv1.Manifest{
+				Versioned: specs.Versioned{SchemaVersion: 2},
+				Layers:    []ocispecv1.Descriptor{},
+			})).To(Succeed())
+			pushReferrers(ctx, client, subjectRef)
+
+			subjectDigest := digest.FromBytes(manifests["0.0.1"])
+			Expect(manifests).ToNot(HaveKey("sha256-" + subjectDigest.Encoded()))
+
+			referrers, err := client.ListReferrers(ctx, subjectRef, "application/vnd.example.signature")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(referrers).To(HaveLen(1))
+			Expect(referrers[0].ArtifactType).To(Equal("application/vnd.example.signature"))
+		})
+	})
+
+	Context("DigestPinning", func() {
+		var (
+			server   *httptest.Server
+			host     string
+			mux      sync.Mutex
+			manifest []byte
+		)
+
+		setManifest := func(configData string) {
+			mux.Lock()
+			defer mux.Unlock()
+			data, err := json.Marshal(ocispecv1.Manifest{
+				Versioned: specs.Versioned{SchemaVersion: 2},
+				Config: ocispecv1.Descriptor{
+					MediaType: ocispecv1.MediaTypeImageConfig,
+					Digest:    digest.FromString(configData),
+					Size:      int64(len(configData)),
+				},
+				Layers: []ocispecv1.Descriptor{},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			manifest = data
+		}
+
+		BeforeEach(func() {
+			setManifest("config-1")
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mux.Lock()
+				defer mux.Unlock()
+				switch req.URL.Path {
+				case "/v2/":
+					w.WriteHeader(http.StatusOK)
+				case "/v2/myproject/repo/manifests/0.0.1", "/v2/myproject/repo/manifests/" + digest.FromBytes(manifest).String():
+					w.Header().Set("Content-Type", ocispecv1.MediaTypeImageManifest)
+					w.Header().Set(ociclient.HeaderDockerContentDigest, digest.FromBytes(manifest).String())
+					w.Header().Set("Content-Length", fmt.Sprint(len(manifest)))
+					w.WriteHeader(http.StatusOK)
+					if req.Method == http.MethodGet {
+						_, _ = w.Write(manifest)
+					}
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			host = hostUrl.Host
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should fail to resolve a tag that does not match its pinned digest", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+			ref := host + "/myproject/repo:0.0.1"
+			pins, err := ociclient.NewInMemoryPinStore(map[string]digest.Digest{
+				ref: digest.FromString("other"),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()),
+				ociclient.WithPinStore(pins, false))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = client.GetRawManifest(ctx, ref)
+			Expect(err).To(HaveOccurred())
+			Expect(ociclient.IsDigestPinMismatchError(err)).To(BeTrue())
+
+			Expect(pins.Set(ref, digest.FromBytes(manifest))).To(Succeed())
+			_, _, err = client.GetRawManifest(ctx, ref)
+			Expect(err).ToNot(HaveOccurred())
+
+			// references with digest are not affected by pins
+			_, _, err = client.GetRawManifest(ctx, host+"/myproject/repo@"+digest.FromBytes(manifest).String())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should pin tags on first use and detect changed tags", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+			ref := host + "/myproject/repo:0.0.1"
+			fs := memoryfs.New()
+			pins, err := ociclient.NewFilePinStore(fs, "/pins/pins.json")
+			Expect(err).ToNot(HaveOccurred())
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()),
+				ociclient.WithPinStore(pins, true))
+			Expect(err).ToNot(HaveOccurred())
+
+			desc, _, err := client.GetRawManifest(ctx, ref)
+			Expect(err).ToNot(HaveOccurred())
+
+			// the pins are persisted and loaded by new pin stores
+			pins, err = ociclient.NewFilePinStore(fs, "/pins/pins.json")
+			Expect(err).ToNot(HaveOccurred())
+			pinned, ok, err := pins.Get(ref)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(pinned).To(Equal(desc.Digest))
+
+			setManifest("config-2")
+			_, _, err = client.GetRawManifest(ctx, ref)
+			Expect(err).To(HaveOccurred())
+			Expect(ociclient.IsDigestPinMismatchError(err)).To(BeTrue())
+		})
+	})
+
+	Context("StrictConformance", func() {
+		var (
+			server   *httptest.Server
+			host     string
+			mux      sync.Mutex
+			queries  []string
+			manifest []byte
+		)
+
+		BeforeEach(func() {
+			queries = []string{}
+			data, err := json.Marshal(ocispecv1.Manifest{
+				Versioned: specs.Versioned{SchemaVersion: 2},
+				MediaType: ocispecv1.MediaTypeImageManifest,
+				Config: ocispecv1.Descriptor{
+					MediaType: ocispecv1.MediaTypeImageConfig,
+					Digest:    digest.FromString("config"),
+					Size:      6,
+				},
+				Layers: []ocispecv1.Descriptor{},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			manifest = data
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mux.Lock()
+				defer mux.Unlock()
+				switch req.URL.Path {
+				case "/v2/":
+					w.WriteHeader(http.StatusOK)
+				case "/v2/myproject/repo/tags/list":
+					queries = append(queries, req.URL.RawQuery)
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"tags":["0.0.1"]}`))
+				case "/v2/myproject/repo/manifests/0.0.1", "/v2/myproject/repo/manifests/" + digest.FromBytes(manifest).String():
+					// the content type does not match the media type of the manifest
+					w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+					w.Header().Set(ociclient.HeaderDockerContentDigest, digest.FromBytes(manifest).String())
+					w.Header().Set("Content-Length", fmt.Sprint(len(manifest)))
+					w.WriteHeader(http.StatusOK)
+					if req.Method == http.MethodGet {
+						_, _ = w.Write(manifest)
+					}
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte("not found"))
+				}
+			}))
+
+			hostUrl, err := url.Parse(server.URL)
+			Expect(err).ToNot(HaveOccurred())
+			host = hostUrl.Host
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		newClient := func(strict bool, warnings *[]ociclient.ConformanceWarning) ociclient.ExtendedClient {
+			client, err := ociclient.NewClient(logr.Discard(),
+				ociclient.AllowPlainHttp(true),
+				ociclient.WithKeyring(credentials.New()),
+				ociclient.StrictConformance(strict),
+				ociclient.WithConformanceWarningHandler(func(warning ociclient.ConformanceWarning) {
+					*warnings = append(*warnings, warning)
+				}))
+			Expect(err).ToNot(HaveOccurred())
+			return client
+		}
+
+		It("should not use registry specific list parameters and report invalid tag lists", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			var warnings []ociclient.ConformanceWarning
+			tags, err := newClient(false, &warnings).ListTags(ctx, host+"/myproject/repo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tags).To(ConsistOf("0.0.1"))
+			Expect(warnings).To(BeEmpty())
+
+			tags, err = newClient(true, &warnings).ListTags(ctx, host+"/myproject/repo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tags).To(ConsistOf("0.0.1"))
+			Expect(queries).To(Equal([]string{"n=1000", ""}))
+			Expect(warnings).To(HaveLen(1))
+			Expect(warnings[0].Message).To(ContainSubstring("no name"))
+		})
+
+		It("should report spec violations of manifest and error responses as warnings", func() {
+			ctx := context.Background()
+			defer ctx.Done()
+
+			var warnings []ociclient.ConformanceWarning
+			client := newClient(true, &warnings)
+			_, data, err := client.GetRawManifest(ctx, host+"/myproject/repo:0.0.1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal(manifest))
+			Expect(warnings).ToNot(BeEmpty())
+			Expect(warnings[len(warnings)-1].Message).To(ContainSubstring("does not match the media type"))
+
+			warnings = nil
+			_, err = client.ListTags(ctx, host+"/myproject/unknown")
+			Expect(err).To(HaveOccurred())
+			Expect(warnings).To(HaveLen(1))
+			Expect(warnings[0].Message).To(ContainSubstring("no error list"))
+		})
+	})
 })
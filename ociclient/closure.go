@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// GetClosure walks the artifact of the given reference and returns all descriptors that are referenced by the artifact.
+// For an index the closure contains the index, all its manifests and their blobs.
+// Only manifests are fetched, the blobs themselves are not downloaded.
+func GetClosure(ctx context.Context, client Client, ref string) (*oci.Closure, error) {
+	refspec, err := oci.ParseRef(ref)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse ref: %w", err)
+	}
+	closure := oci.NewClosure()
+	if err := addToClosure(ctx, client, closure, refspec, ref); err != nil {
+		return nil, err
+	}
+	return closure, nil
+}
+
+func addToClosure(ctx context.Context, client Client, closure *oci.Closure, refspec oci.RefSpec, ref string) error {
+	desc, rawManifest, err := client.GetRawManifest(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("unable to get manifest for %q: %w", ref, err)
+	}
+	if !closure.Add(desc) {
+		return nil
+	}
+
+	if IsMultiArchImage(desc.MediaType) {
+		index := ocispecv1.Index{}
+		if err := json.Unmarshal(rawManifest, &index); err != nil {
+			return fmt.Errorf("unable to unmarshal image index: %w", err)
+		}
+		for _, manifestDesc := range index.Manifests {
+			if closure.Contains(manifestDesc.Digest) {
+				continue
+			}
+			if err := addToClosure(ctx, client, closure, refspec, refspec.DigestRef(manifestDesc.Digest)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	manifest := ocispecv1.Manifest{}
+	if err := json.Unmarshal(rawManifest, &manifest); err != nil {
+		return fmt.Errorf("unable to unmarshal manifest: %w", err)
+	}
+	closure.Add(manifest.Config)
+	for _, layer := range manifest.Layers {
+		closure.Add(layer)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient/cache"
+)
+
+// componentDescriptorLayerField is the name of the field in the component descriptor config
+// that references the component descriptor layer.
+const componentDescriptorLayerField = "componentDescriptorLayer"
+
+// ComponentDescriptorConfig is the oci config of a component descriptor artifact.
+// In addition to the reference to the component descriptor layer, the config can contain arbitrary additional fields
+// like transport metadata or format versions.
+// Fields that are unknown to the client are preserved when a config is decoded and encoded again,
+// so that older clients do not drop fields that have been added by newer clients.
+type ComponentDescriptorConfig struct {
+	// ComponentDescriptorLayer is the reference to the layer that contains the component descriptor.
+	ComponentDescriptorLayer *cdoci.OciBlobRef
+	// fields contains all additional fields of the config.
+	fields map[string]json.RawMessage
+}
+
+// MarshalJSON implements the json marshaler interface.
+func (c ComponentDescriptorConfig) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]json.RawMessage, len(c.fields)+1)
+	for name, value := range c.fields {
+		obj[name] = value
+	}
+	if c.ComponentDescriptorLayer != nil {
+		layer, err := json.Marshal(c.ComponentDescriptorLayer)
+		if err != nil {
+			return nil, err
+		}
+		obj[componentDescriptorLayerField] = layer
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON implements the json unmarshaler interface.
+func (c *ComponentDescriptorConfig) UnmarshalJSON(data []byte) error {
+	obj := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	c.ComponentDescriptorLayer = nil
+	if layer, ok := obj[componentDescriptorLayerField]; ok {
+		delete(obj, componentDescriptorLayerField)
+		if string(layer) != "null" {
+			c.ComponentDescriptorLayer = &cdoci.OciBlobRef{}
+			if err := json.Unmarshal(layer, c.ComponentDescriptorLayer); err != nil {
+				return fmt.Errorf("unable to decode %s: %w", componentDescriptorLayerField, err)
+			}
+		}
+	}
+	c.fields = obj
+	return nil
+}
+
+// GetField decodes the additional field with the given name into the given object.
+// The returned bool is false if the field is not defined.
+func (c *ComponentDescriptorConfig) GetField(name string, into interface{}) (bool, error) {
+	value, ok := c.fields[name]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(value, into); err != nil {
+		return true, fmt.Errorf("unable to decode field %q: %w", name, err)
+	}
+	return true, nil
+}
+
+// SetField sets the additional field with the given name to the json encoded value.
+func (c *ComponentDescriptorConfig) SetField(name string, value interface{}) error {
+	if name == componentDescriptorLayerField {
+		return fmt.Errorf("field %q is reserved", name)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("unable to encode field %q: %w", name, err)
+	}
+	if c.fields == nil {
+		c.fields = map[string]json.RawMessage{}
+	}
+	c.fields[name] = data
+	return nil
+}
+
+// DeleteField removes the additional field with the given name.
+func (c *ComponentDescriptorConfig) DeleteField(name string) {
+	delete(c.fields, name)
+}
+
+// Fields returns the names of all additional fields.
+func (c *ComponentDescriptorConfig) Fields() []string {
+	names := make([]string, 0, len(c.fields))
+	for name := range c.fields {
+		names = append(names, name)
+	}
+	return names
+}
+
+// GetComponentDescriptorConfig fetches and decodes the component descriptor config of the given component descriptor artifact.
+func GetComponentDescriptorConfig(ctx context.Context, client Client, ref string) (*ComponentDescriptorConfig, error) {
+	manifest, err := client.GetManifest(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get manifest: %w", err)
+	}
+	if manifest.Config.MediaType != cdoci.ComponentDescriptorConfigMimeType {
+		return nil, fmt.Errorf("unexpected config media type %q, expected %q", manifest.Config.MediaType, cdoci.ComponentDescriptorConfigMimeType)
+	}
+
+	var data bytes.Buffer
+	if err := client.Fetch(ctx, ref, manifest.Config, &data); err != nil {
+		return nil, fmt.Errorf("unable to fetch component descriptor config: %w", err)
+	}
+	config := &ComponentDescriptorConfig{}
+	if err := json.Unmarshal(data.Bytes(), config); err != nil {
+		return nil, fmt.Errorf("unable to decode component descriptor config: %w", err)
+	}
+	return config, nil
+}
+
+// SetComponentDescriptorConfig encodes the config, adds it to the store and sets it as config of the manifest.
+// The manifest can then be pushed with the store, e.g. using client.PushManifest(ctx, ref, manifest, WithStore(store)).
+func SetComponentDescriptorConfig(store cache.Cache, manifest *ocispecv1.Manifest, config *ComponentDescriptorConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor config: %w", err)
+	}
+	desc := ocispecv1.Descriptor{
+		MediaType: cdoci.ComponentDescriptorConfigMimeType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	if err := store.Add(desc, ioutil.NopCloser(bytes.NewBuffer(data))); err != nil {
+		return fmt.Errorf("unable to add component descriptor config to store: %w", err)
+	}
+	manifest.Config = desc
+	return nil
+}
+
+// ReadComponentDescriptorConfig reads the component descriptor config of the manifest from the store.
+func ReadComponentDescriptorConfig(store cache.Cache, manifest *ocispecv1.Manifest) (*ComponentDescriptorConfig, error) {
+	r, err := store.Get(manifest.Config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get component descriptor config from store: %w", err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read component descriptor config: %w", err)
+	}
+	config := &ComponentDescriptorConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("unable to decode component descriptor config: %w", err)
+	}
+	return config, nil
+}
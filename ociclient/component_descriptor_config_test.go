@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient_test
+
+import (
+	"encoding/json"
+
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
+)
+
+var _ = Describe("ComponentDescriptorConfig", func() {
+
+	It("should preserve unknown fields", func() {
+		data := []byte(`{"componentDescriptorLayer":{"mediaType":"application/vnd.gardener.cloud.cnudie.component-descriptor.v2+yaml+tar","digest":"sha256:abc","size":10},"formatVersion":"v2","transport":{"source":"example.com"}}`)
+
+		config := &ociclient.ComponentDescriptorConfig{}
+		Expect(json.Unmarshal(data, config)).To(Succeed())
+		Expect(config.ComponentDescriptorLayer).ToNot(BeNil())
+		Expect(config.ComponentDescriptorLayer.Digest).To(Equal("sha256:abc"))
+		Expect(config.Fields()).To(ConsistOf("formatVersion", "transport"))
+
+		encoded, err := json.Marshal(config)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(encoded).To(MatchJSON(data))
+	})
+
+	It("should set and get additional fields", func() {
+		config := &ociclient.ComponentDescriptorConfig{}
+		Expect(config.SetField("formatVersion", "v2")).To(Succeed())
+		Expect(config.SetField("componentDescriptorLayer", "v2")).ToNot(Succeed())
+
+		var formatVersion string
+		ok, err := config.GetField("formatVersion", &formatVersion)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(formatVersion).To(Equal("v2"))
+
+		ok, err = config.GetField("unknown", &formatVersion)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeFalse())
+
+		config.DeleteField("formatVersion")
+		Expect(config.Fields()).To(BeEmpty())
+	})
+
+	It("should set the config of a manifest", func() {
+		store := cache.NewInMemoryCache()
+		manifest := &ocispecv1.Manifest{}
+
+		config := &ociclient.ComponentDescriptorConfig{
+			ComponentDescriptorLayer: &cdoci.OciBlobRef{Digest: "sha256:abc", Size: 10},
+		}
+		Expect(config.SetField("formatVersion", "v2")).To(Succeed())
+		Expect(ociclient.SetComponentDescriptorConfig(store, manifest, config)).To(Succeed())
+		Expect(manifest.Config.MediaType).To(Equal(cdoci.ComponentDescriptorConfigMimeType))
+
+		readConfig, err := ociclient.ReadComponentDescriptorConfig(store, manifest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readConfig).To(Equal(config))
+	})
+
+})
@@ -0,0 +1,214 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// ConformanceWarning describes a response of a registry that violates the oci distribution spec.
+type ConformanceWarning struct {
+	// Method is the http method of the request.
+	Method string
+	// URL is the url of the request.
+	URL string
+	// Message describes the violation.
+	Message string
+}
+
+func (w ConformanceWarning) String() string {
+	return fmt.Sprintf("%s %s: %s", w.Method, w.URL, w.Message)
+}
+
+// ConformanceWarningHandler is called for every violation of the oci distribution spec in strict conformance mode.
+type ConformanceWarningHandler func(warning ConformanceWarning)
+
+// linkHeaderRegexp matches a Link header of the form <url>; rel="next" as described in RFC5988.
+var linkHeaderRegexp = regexp.MustCompile(`^<[^>]+>;\s*rel="?next"?$`)
+
+// conformanceChecker is a http transport that checks the responses of a registry for violations of the distribution spec.
+// Violations do not fail the request but are reported as warnings.
+type conformanceChecker struct {
+	next    http.RoundTripper
+	handler ConformanceWarningHandler
+}
+
+func newConformanceChecker(next http.RoundTripper, handler ConformanceWarningHandler) http.RoundTripper {
+	return &conformanceChecker{
+		next:    next,
+		handler: handler,
+	}
+}
+
+// logConformanceWarning returns a warning handler that logs the violations.
+func logConformanceWarning(log logr.Logger) ConformanceWarningHandler {
+	return func(warning ConformanceWarning) {
+		log.Info("registry response violates the oci distribution spec", "method", warning.Method, "url", warning.URL, "violation", warning.Message)
+	}
+}
+
+func (t *conformanceChecker) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || req.URL == nil || !strings.Contains(req.URL.Path, "/v2/") {
+		return resp, err
+	}
+	warn := func(format string, args ...interface{}) {
+		t.handler(ConformanceWarning{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if resp.StatusCode >= 400 {
+		if req.Method == http.MethodHead || resp.StatusCode == http.StatusUnauthorized {
+			return resp, nil
+		}
+		body, err := t.readBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		errResp := struct {
+			Errors []struct {
+				Code string `json:"code"`
+			} `json:"errors"`
+		}{}
+		if err := json.Unmarshal(body, &errResp); err != nil || len(errResp.Errors) == 0 {
+			warn("error response with status %d has no error list", resp.StatusCode)
+			return resp, nil
+		}
+		for _, e := range errResp.Errors {
+			if len(e.Code) == 0 {
+				warn("error response with status %d contains an error without code", resp.StatusCode)
+			}
+		}
+		return resp, nil
+	}
+
+	switch {
+	case isManifestRequest(req):
+		return t.checkManifest(req, resp, warn)
+	case strings.HasSuffix(req.URL.Path, "/tags/list") && req.Method == http.MethodGet:
+		return t.checkTagList(resp, warn)
+	case strings.Contains(req.URL.Path, "/blobs/uploads/"):
+		t.checkBlobUpload(req, resp, warn)
+	}
+	return resp, nil
+}
+
+func (t *conformanceChecker) checkManifest(req *http.Request, resp *http.Response, warn func(format string, args ...interface{})) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		if resp.StatusCode != http.StatusOK {
+			warn("expected status %d but got %d", http.StatusOK, resp.StatusCode)
+			return resp, nil
+		}
+		if len(resp.Header.Get(HeaderDockerContentDigest)) == 0 {
+			warn("the %s header is missing", HeaderDockerContentDigest)
+		}
+		contentType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if err != nil {
+			warn("invalid or missing Content-Type header %q", resp.Header.Get("Content-Type"))
+		}
+		if req.Method == http.MethodHead {
+			return resp, nil
+		}
+		body, err := t.readBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		manifest := struct {
+			MediaType string `json:"mediaType"`
+		}{}
+		if err := json.Unmarshal(body, &manifest); err != nil {
+			warn("manifest is not valid json: %s", err.Error())
+			return resp, nil
+		}
+		if len(manifest.MediaType) != 0 && len(contentType) != 0 && manifest.MediaType != contentType {
+			warn("the Content-Type %q does not match the media type %q of the manifest", contentType, manifest.MediaType)
+		}
+	case http.MethodPut:
+		if resp.StatusCode != http.StatusCreated {
+			warn("expected status %d but got %d", http.StatusCreated, resp.StatusCode)
+		}
+		if len(resp.Header.Get("Location")) == 0 {
+			warn("the Location header is missing")
+		}
+	}
+	return resp, nil
+}
+
+func (t *conformanceChecker) checkTagList(resp *http.Response, warn func(format string, args ...interface{})) (*http.Response, error) {
+	if link := resp.Header.Get("Link"); len(link) != 0 && !linkHeaderRegexp.MatchString(link) {
+		warn("invalid Link header %q", link)
+	}
+	body, err := t.readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	tagList := struct {
+		Name string    `json:"name"`
+		Tags *[]string `json:"tags"`
+	}{}
+	if err := json.Unmarshal(body, &tagList); err != nil {
+		warn("tag list is not valid json: %s", err.Error())
+		return resp, nil
+	}
+	if len(tagList.Name) == 0 {
+		warn("the tag list has no name")
+	}
+	if tagList.Tags == nil {
+		warn("the tag list has no tags field")
+	}
+	return resp, nil
+}
+
+func (t *conformanceChecker) checkBlobUpload(req *http.Request, resp *http.Response, warn func(format string, args ...interface{})) {
+	expected := 0
+	switch req.Method {
+	case http.MethodPost, http.MethodPatch:
+		expected = http.StatusAccepted
+	case http.MethodPut:
+		expected = http.StatusCreated
+	default:
+		return
+	}
+	// a post with a digest is a monolithic upload that is completed with 201
+	if req.Method == http.MethodPost && len(req.URL.Query().Get("digest")) != 0 {
+		expected = http.StatusCreated
+	}
+	if resp.StatusCode != expected {
+		warn("expected status %d but got %d", expected, resp.StatusCode)
+	}
+	if len(resp.Header.Get("Location")) == 0 {
+		warn("the Location header is missing")
+	}
+	if req.Method == http.MethodPatch && len(resp.Header.Get("Range")) == 0 {
+		warn("the Range header is missing")
+	}
+}
+
+// readBody reads the body of the response and replaces it with a buffered reader.
+func (t *conformanceChecker) readBody(resp *http.Response) ([]byte, error) {
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		return nil, fmt.Errorf("unable to close body reader: %w", err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
@@ -12,6 +12,9 @@ const MediaTypeTarGzip = "application/tar+gzip"
 // MediaTypeTar is the media type for a tar
 const MediaTypeTar = "application/tar"
 
+// MediaTypeOCIImageLayoutTar is the media type of a tarred oci image layout.
+const MediaTypeOCIImageLayoutTar = "application/vnd.oci.image.layout.v1+tar"
+
 // DefaultKnownMediaTypes contain also known media types of the oci client
 var DefaultKnownMediaTypes = sets.NewString(
 	MediaTypeTarGzip,
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// HeaderDockerContentDigest is the header that is returned by registries with the digest of the requested manifest.
+const HeaderDockerContentDigest = "Docker-Content-Digest"
+
+// DigestMismatchError is returned if the digest that is reported by the registry in the Docker-Content-Digest header
+// does not match the digest that has been computed for the transferred content.
+type DigestMismatchError struct {
+	// URL is the url of the request that returned the mismatching digest.
+	URL string
+	// Computed is the digest that has been computed by the client for the transferred content.
+	Computed digest.Digest
+	// Returned is the digest that has been returned by the registry.
+	Returned digest.Digest
+}
+
+func (e *DigestMismatchError) Error() string {
+	return fmt.Sprintf("digest mismatch for %q: registry returned %s but the content has digest %s", e.URL, e.Returned, e.Computed)
+}
+
+// IsDigestMismatchError checks whether the given error is or wraps a DigestMismatchError.
+func IsDigestMismatchError(err error) bool {
+	var mismatchErr *DigestMismatchError
+	return errors.As(err, &mismatchErr)
+}
+
+// contentDigestVerifier is a http transport that verifies the Docker-Content-Digest header
+// that is returned by a registry on manifest GET and PUT requests.
+// This detects proxies or CDNs that rewrite content between the registry and the client.
+type contentDigestVerifier struct {
+	next http.RoundTripper
+}
+
+func newContentDigestVerifier(next http.RoundTripper) http.RoundTripper {
+	return &contentDigestVerifier{
+		next: next,
+	}
+}
+
+func (t *contentDigestVerifier) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isManifestRequest(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		return t.verifyGet(req)
+	case http.MethodPut:
+		return t.verifyPut(req)
+	default:
+		return t.next.RoundTrip(req)
+	}
+}
+
+func (t *contentDigestVerifier) verifyGet(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	returned, ok, err := returnedContentDigest(resp)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	if !ok {
+		return resp, nil
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unable to read manifest from %q: %w", req.URL.String(), err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		return nil, fmt.Errorf("unable to close body reader: %w", err)
+	}
+
+	computed := returned.Algorithm().FromBytes(data)
+	if computed != returned {
+		return nil, &DigestMismatchError{
+			URL:      req.URL.String(),
+			Computed: computed,
+			Returned: returned,
+		}
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
+
+func (t *contentDigestVerifier) verifyPut(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return t.next.RoundTrip(req)
+	}
+
+	// the digest is computed from a buffered copy of the manifest (manifests are small),
+	// so that retried requests that replay the body do not corrupt the computed digest.
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		_ = req.Body.Close()
+		return nil, fmt.Errorf("unable to read manifest for %q: %w", req.URL.String(), err)
+	}
+	if err := req.Body.Close(); err != nil {
+		return nil, fmt.Errorf("unable to close body reader: %w", err)
+	}
+	computed := digest.Canonical.FromBytes(data)
+	req = req.Clone(req.Context())
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.ContentLength = int64(len(data))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	returned, ok, err := returnedContentDigest(resp)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	if !ok {
+		return resp, nil
+	}
+	// only the canonical algorithm is computed for uploads
+	if returned.Algorithm() != digest.Canonical {
+		return resp, nil
+	}
+
+	if computed != returned {
+		_ = resp.Body.Close()
+		return nil, &DigestMismatchError{
+			URL:      req.URL.String(),
+			Computed: computed,
+			Returned: returned,
+		}
+	}
+	return resp, nil
+}
+
+// returnedContentDigest parses the Docker-Content-Digest header of a response.
+// The second return value is false if the registry did not return the header.
+func returnedContentDigest(resp *http.Response) (digest.Digest, bool, error) {
+	header := resp.Header.Get(HeaderDockerContentDigest)
+	if len(header) == 0 {
+		return "", false, nil
+	}
+	dig, err := digest.Parse(header)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid %s header %q: %w", HeaderDockerContentDigest, header, err)
+	}
+	return dig, true, nil
+}
+
+// isManifestRequest checks whether the request targets the manifests endpoint of the distribution spec.
+func isManifestRequest(req *http.Request) bool {
+	if req.URL == nil {
+		return false
+	}
+	return strings.Contains(req.URL.Path, "/v2/") && strings.Contains(req.URL.Path, "/manifests/")
+}
@@ -16,14 +16,23 @@ import (
 // Copy copies a oci artifact from one location to a target ref.
 // The artifact is copied without any modification.
 // This function does directly stream the blobs from the upstream it does not use any cache.
-func Copy(ctx context.Context, client Client, srcRef, tgtRef string) error {
-	desc, rawManifest, err := client.GetRawManifest(ctx, srcRef)
+// The push options are applied to all pushed manifests, e.g. WithLayerConcurrency to copy the layers in parallel.
+func Copy(ctx context.Context, client Client, srcRef, tgtRef string, options ...PushOption) error {
+	return CopyWithClients(ctx, client, client, srcRef, tgtRef, options...)
+}
+
+// CopyWithClients copies a oci artifact from one location to a target ref.
+// The source artifact is pulled with the source client and pushed with the target client,
+// so that both sides can use different credentials and transport settings.
+// The artifact is copied without any modification.
+func CopyWithClients(ctx context.Context, srcClient, tgtClient Client, srcRef, tgtRef string, options ...PushOption) error {
+	desc, rawManifest, err := srcClient.GetRawManifest(ctx, srcRef)
 	if err != nil {
 		return fmt.Errorf("unable to get manifest: %w", err)
 	}
 
 	store := GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
-		return client.Fetch(ctx, srcRef, desc, writer)
+		return srcClient.Fetch(ctx, srcRef, desc, writer)
 	})
 
 	if IsMultiArchImage(desc.MediaType) {
@@ -32,27 +41,24 @@ func Copy(ctx context.Context, client Client, srcRef, tgtRef string) error {
 			return fmt.Errorf("unable to unmarshal image index: %w", err)
 		}
 
-		srcRepo, _, err := ParseImageRef(srcRef)
-		if err != nil {
-			return fmt.Errorf("unable to parse src ref: %w", err)
-		}
-
-		tgtRepo, _, err := ParseImageRef(tgtRef)
-		if err != nil {
-			return fmt.Errorf("unable to parse tgt ref: %w", err)
-		}
-
 		for _, manifestDesc := range index.Manifests {
-			subManifestSrcRef := fmt.Sprintf("%s@%s", srcRepo, manifestDesc.Digest)
-			subManifestTgtRef := fmt.Sprintf("%s@%s", tgtRepo, manifestDesc.Digest)
+			subManifestSrcRef, err := DigestRef(srcRef, manifestDesc.Digest)
+			if err != nil {
+				return fmt.Errorf("unable to parse src ref: %w", err)
+			}
+			subManifestTgtRef, err := DigestRef(tgtRef, manifestDesc.Digest)
+			if err != nil {
+				return fmt.Errorf("unable to parse tgt ref: %w", err)
+			}
 
-			if err := Copy(ctx, client, subManifestSrcRef, subManifestTgtRef); err != nil {
+			if err := CopyWithClients(ctx, srcClient, tgtClient, subManifestSrcRef, subManifestTgtRef, options...); err != nil {
 				return fmt.Errorf("unable to copy sub manifest: %w", err)
 			}
 		}
 	}
 
-	if err := client.PushRawManifest(ctx, tgtRef, desc, rawManifest, WithStore(store)); err != nil {
+	pushOptions := append(append([]PushOption{}, options...), WithStore(store))
+	if err := tgtClient.PushRawManifest(ctx, tgtRef, desc, rawManifest, pushOptions...); err != nil {
 		return fmt.Errorf("unable to push manifest: %w", err)
 	}
 
@@ -6,6 +6,7 @@ package credentials
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"path/filepath"
 
@@ -13,12 +14,17 @@ import (
 	dockerconfig "github.com/docker/cli/cli/config"
 	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/cli/cli/config/credentials"
+	"github.com/docker/docker-credential-helpers/client"
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// credentialHelperPrefix is the prefix of the binaries of docker credential helpers.
+const credentialHelperPrefix = "docker-credential-"
+
 // KeyringBuilder is a builder to create and fill a keyring from different sources
 type KeyringBuilder struct {
 	log         logr.Logger
@@ -26,7 +32,12 @@ type KeyringBuilder struct {
 	pullSecrets []corev1.Secret
 	configFiles []string
 
+	// secretGetter and secretNames describe pull secrets that are read from a kubernetes cluster.
+	secretGetter SecretGetter
+	secretNames  []string
+
 	disableDefaultConfig bool
+	defaulted            bool
 }
 
 // NewBuilder creates a new keyring builder
@@ -38,6 +49,10 @@ func NewBuilder(log logr.Logger) *KeyringBuilder {
 
 // applyDefaults sets the builder defaults for undefined options
 func (b *KeyringBuilder) applyDefaults() {
+	if b.defaulted {
+		return
+	}
+	b.defaulted = true
 	if b.fs == nil {
 		b.fs = osfs.New()
 	}
@@ -71,6 +86,21 @@ func (b *KeyringBuilder) FromPullSecrets(secrets ...corev1.Secret) *KeyringBuild
 	return b
 }
 
+// SecretGetter gets secrets of a namespace of a kubernetes cluster.
+// It is implemented by the secret client of a kubernetes clientset, e.g. clientset.CoreV1().Secrets(namespace).
+type SecretGetter interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.Secret, error)
+}
+
+// FromKubernetesSecrets adds pull secrets that are read from a kubernetes cluster whenever the keyring is built or reloaded,
+// so that in-cluster consumers can reuse pull secrets instead of mounting docker config files.
+// The secrets are read with the given secret client of their namespace, e.g. clientset.CoreV1().Secrets(namespace).
+func (b *KeyringBuilder) FromKubernetesSecrets(secrets SecretGetter, names ...string) *KeyringBuilder {
+	b.secretGetter = secrets
+	b.secretNames = names
+	return b
+}
+
 // FromConfigFiles adds file paths to docker config definitions
 func (b *KeyringBuilder) FromConfigFiles(files ...string) *KeyringBuilder {
 	b.configFiles = files
@@ -79,9 +109,27 @@ func (b *KeyringBuilder) FromConfigFiles(files ...string) *KeyringBuilder {
 
 // Build creates a new oci registry keyring from the configured secrets.
 func (b *KeyringBuilder) Build() (*GeneralOciKeyring, error) {
+	return b.BuildWithContext(context.Background())
+}
+
+// BuildWithContext creates a new oci registry keyring from the configured secrets.
+// Reading the docker config files is aborted if the context is canceled.
+//
+// Credential helpers ("credHelpers") and credential stores ("credsStore") of the docker config files
+// are executed whenever credentials are requested, so that short-lived credentials
+// (e.g. of ECR, GCR or ACR) are refreshed by the helper without manual token refresh.
+func (b *KeyringBuilder) BuildWithContext(ctx context.Context) (*GeneralOciKeyring, error) {
 	b.applyDefaults()
 	store := New()
-	for _, secret := range b.pullSecrets {
+	pullSecrets := append([]corev1.Secret{}, b.pullSecrets...)
+	for _, name := range b.secretNames {
+		secret, err := b.secretGetter.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to get pull secret %q: %w", name, err)
+		}
+		pullSecrets = append(pullSecrets, *secret)
+	}
+	for _, secret := range pullSecrets {
 		if secret.Type != corev1.SecretTypeDockerConfigJson {
 			continue
 		}
@@ -108,6 +156,9 @@ func (b *KeyringBuilder) Build() (*GeneralOciKeyring, error) {
 		if len(configFile) == 0 {
 			continue
 		}
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("unable to read docker config %q: %w", configFile, err)
+		}
 		dockerConfigBytes, err := vfs.ReadFile(b.fs, configFile)
 		if err != nil {
 			return nil, err
@@ -118,20 +169,33 @@ func (b *KeyringBuilder) Build() (*GeneralOciKeyring, error) {
 			return nil, err
 		}
 
+		// the credential store of the config file takes precedence over the default native credential store.
+		credsStore := defaultStore
+		if len(dockerConfig.CredentialsStore) != 0 {
+			credsStore = dockerConfig.CredentialsStore
+		}
+
 		for address, dockerAuth := range dockerConfig.AuthConfigs {
 			auth := FromAuthConfig(dockerAuth)
-			// if the auth is empty use the default store to get the authentication
-			if !IsEmptyAuthConfig(auth) || len(defaultStore) == 0 {
+			// if the auth is empty use the credential store to get the authentication
+			if !IsEmptyAuthConfig(auth) || len(credsStore) == 0 {
 				if err := store.AddAuthConfig(address, auth); err != nil {
 					return nil, fmt.Errorf("unable to add auth for %q to store: %w", address, err)
 				}
 				b.log.V(10).Info(fmt.Sprintf("added authentication for %q from %q", address, configFile))
 			} else {
-				err := store.AddAuthConfigGetter(address, CredentialHelperAuthConfigGetter(b.log, dockerConfig, address, defaultStore))
+				err := store.AddAuthConfigGetter(address, CredentialHelperAuthConfigGetter(b.log, dockerConfig, address, credsStore))
 				if err != nil {
 					return nil, err
 				}
-				b.log.V(10).Info(fmt.Sprintf("added authentication for %q from %q with the default native credential store", address, configFile))
+				b.log.V(10).Info(fmt.Sprintf("added authentication for %q from %q with the native credential store %s", address, configFile, credsStore))
+			}
+		}
+
+		// add the registries that are only known by the configured credential store.
+		if len(dockerConfig.CredentialsStore) != 0 {
+			if err := b.addCredentialStoreAddresses(store, dockerConfig); err != nil {
+				return nil, err
 			}
 		}
 
@@ -148,6 +212,31 @@ func (b *KeyringBuilder) Build() (*GeneralOciKeyring, error) {
 	return store, nil
 }
 
+// addCredentialStoreAddresses adds all registries that are listed by the credential store of the docker config
+// and are neither defined as auth nor as credential helper.
+// A credential store that cannot be listed is skipped, so that the other authentication methods are still usable.
+func (b *KeyringBuilder) addCredentialStoreAddresses(store *GeneralOciKeyring, dockerConfig *configfile.ConfigFile) error {
+	helper := dockerConfig.CredentialsStore
+	addresses, err := client.List(client.NewShellProgramFunc(credentialHelperPrefix + helper))
+	if err != nil {
+		b.log.V(4).Info(fmt.Sprintf("unable to list registries of credential store %q: %s", helper, err.Error()))
+		return nil
+	}
+	for address := range addresses {
+		if _, ok := dockerConfig.AuthConfigs[address]; ok {
+			continue
+		}
+		if _, ok := dockerConfig.CredentialHelpers[address]; ok {
+			continue
+		}
+		if err := store.AddAuthConfigGetter(address, CredentialHelperAuthConfigGetter(b.log, dockerConfig, address, helper)); err != nil {
+			return fmt.Errorf("unable to add auth for %q to store: %w", address, err)
+		}
+		b.log.V(10).Info(fmt.Sprintf("added authentication for %q with credential store %s", address, helper))
+	}
+	return nil
+}
+
 // CredentialHelperAuthConfigGetter describes a default getter method for a authentication method
 func CredentialHelperAuthConfigGetter(log logr.Logger, dockerConfig *configfile.ConfigFile, address, helper string) AuthConfigGetter {
 	nativeStore := credentials.NewNativeStore(dockerConfig, helper)
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+
+	"github.com/gardener/component-cli/ociclient/credentials"
+)
+
+// ecrEndpointEnvName is the name of the environment variable that overwrites the endpoint of the ecr api.
+const ecrEndpointEnvName = "AWS_ENDPOINT_URL_ECR"
+
+// ecrHostPattern matches the hosts of private ecr registries, e.g. "123456789012.dkr.ecr.eu-west-1.amazonaws.com".
+var ecrHostPattern = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+// parseECRHost returns the region and the account id of an ecr registry host.
+func parseECRHost(host string) (string, string, error) {
+	match := ecrHostPattern.FindStringSubmatch(host)
+	if match == nil {
+		return "", "", fmt.Errorf("%q is not an ecr registry host", host)
+	}
+	return match[3], match[1], nil
+}
+
+// newECRTokenFetcher returns a fetcher that requests ecr authorization tokens with the default credential chain of the aws sdk.
+// The chain reads the credentials from the environment, the shared config and credentials files (including profiles,
+// assumed roles and sso), web identity tokens (e.g. EKS IRSA), the container credentials endpoint (ECS, CodeBuild)
+// and the instance metadata service (EC2).
+func newECRTokenFetcher(httpClient *http.Client, region, accountID string) tokenFetcher {
+	var client *ecr.Client
+	return func(ctx context.Context) (credentials.AuthConfig, time.Time, error) {
+		if client == nil {
+			opts := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(region)}
+			if httpClient != nil {
+				opts = append(opts, awsconfig.WithHTTPClient(httpClient))
+			}
+			cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+			if err != nil {
+				return credentials.AuthConfig{}, time.Time{}, fmt.Errorf("unable to load aws config: %w", err)
+			}
+			client = ecr.NewFromConfig(cfg, func(o *ecr.Options) {
+				if endpoint := os.Getenv(ecrEndpointEnvName); len(endpoint) != 0 {
+					o.BaseEndpoint = aws.String(endpoint)
+				}
+			})
+		}
+
+		res, err := client.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{
+			RegistryIds: []string{accountID},
+		})
+		if err != nil {
+			return credentials.AuthConfig{}, time.Time{}, fmt.Errorf("unable to get ecr authorization token: %w", err)
+		}
+		if len(res.AuthorizationData) == 0 || res.AuthorizationData[0].AuthorizationToken == nil {
+			return credentials.AuthConfig{}, time.Time{}, errors.New("no ecr authorization data returned")
+		}
+		data := res.AuthorizationData[0]
+		var expires time.Time
+		if data.ExpiresAt != nil {
+			expires = *data.ExpiresAt
+		}
+		// the authorization token is the base64 encoded "<user>:<password>"
+		return credentials.AuthConfig{
+			Auth: *data.AuthorizationToken,
+		}, expires, nil
+	}
+}
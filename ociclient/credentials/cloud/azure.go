@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+
+	"github.com/gardener/component-cli/ociclient/credentials"
+)
+
+const (
+	// azureManagementScope is the scope of the token that is exchanged for an acr refresh token.
+	azureManagementScope = "https://management.azure.com/.default"
+	// acrRefreshTokenUsername is the username that is used to authenticate at acr with a refresh token.
+	acrRefreshTokenUsername = "00000000-0000-0000-0000-000000000000"
+)
+
+// newACRTokenFetcher returns a fetcher that requests an azure access token with the default credential chain
+// of the azure sdk and exchanges it for an acr refresh token.
+// The chain uses the service principal of the environment, the workload identity (AKS), the managed identity
+// and the azure cli.
+func newACRTokenFetcher(httpClient *http.Client, registry string) tokenFetcher {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	var cred azcore.TokenCredential
+	return func(ctx context.Context) (credentials.AuthConfig, time.Time, error) {
+		if cred == nil {
+			defaultCred, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+				ClientOptions: azcore.ClientOptions{
+					Transport: httpClient,
+				},
+			})
+			if err != nil {
+				return credentials.AuthConfig{}, time.Time{}, fmt.Errorf("unable to create azure credentials: %w", err)
+			}
+			cred = defaultCred
+		}
+		token, err := cred.GetToken(ctx, policy.TokenRequestOptions{
+			Scopes: []string{azureManagementScope},
+		})
+		if err != nil {
+			return credentials.AuthConfig{}, time.Time{}, fmt.Errorf("unable to get azure access token: %w", err)
+		}
+
+		// exchange the azure access token for an acr refresh token
+		form := url.Values{}
+		form.Set("grant_type", "access_token")
+		form.Set("service", registry)
+		form.Set("access_token", token.Token)
+		if tenantID := os.Getenv("AZURE_TENANT_ID"); len(tenantID) != 0 {
+			form.Set("tenant", tenantID)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://%s/oauth2/exchange", registry), strings.NewReader(form.Encode()))
+		if err != nil {
+			return credentials.AuthConfig{}, time.Time{}, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		res := struct {
+			RefreshToken string `json:"refresh_token"`
+		}{}
+		if err := doJSON(httpClient, req, &res); err != nil {
+			return credentials.AuthConfig{}, time.Time{}, fmt.Errorf("unable to exchange azure access token for acr refresh token: %w", err)
+		}
+		return credentials.AuthConfig{
+			Username: acrRefreshTokenUsername,
+			Password: res.RefreshToken,
+		}, token.ExpiresOn, nil
+	}
+}
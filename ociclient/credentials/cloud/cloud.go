@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cloud provides keyrings that obtain short-lived registry credentials directly from the
+// credentials of the cloud environment (instance metadata, workload identities or credential files),
+// so that registries of cloud providers can be accessed from cloud CI runners without a docker login.
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/gardener/component-cli/ociclient/credentials"
+)
+
+// Provider is a cloud provider whose registries are supported.
+type Provider string
+
+const (
+	// ProviderAWS obtains tokens for the Elastic Container Registry (ECR) with the ecr GetAuthorizationToken api.
+	// The aws credentials are resolved with the default credential chain of the aws sdk (environment, shared config
+	// and credentials files with profiles, web identity tokens, container credentials and the instance metadata service).
+	ProviderAWS Provider = "aws"
+	// ProviderGCP obtains tokens for the Google Container Registry (GCR) and the Artifact Registry.
+	// The google credentials are resolved with the application default credentials of the google sdk (service account key,
+	// authorized user or external account credentials file, or the metadata server of GCE, GKE and Cloud Build).
+	ProviderGCP Provider = "gcp"
+	// ProviderAzure obtains tokens for the Azure Container Registry (ACR).
+	// The azure token is obtained with the default credential chain of the azure sdk (environment, workload identity,
+	// managed identity and azure cli) and is exchanged for a registry refresh token.
+	ProviderAzure Provider = "azure"
+)
+
+// tokenRefreshMargin is the time before the expiry of a token at which a new token is requested.
+const tokenRefreshMargin = 5 * time.Minute
+
+// RegistryProvider assigns a cloud provider to a registry host.
+type RegistryProvider struct {
+	Provider Provider
+	// Registry is the host of the registry, e.g. "eu.gcr.io" or "123456789012.dkr.ecr.eu-west-1.amazonaws.com".
+	Registry string
+}
+
+// ParseRegistryProviders parses registry providers of the form "<provider>=<registry>",
+// e.g. "aws=123456789012.dkr.ecr.eu-west-1.amazonaws.com".
+func ParseRegistryProviders(specs []string) ([]RegistryProvider, error) {
+	providers := make([]RegistryProvider, 0, len(specs))
+	for _, spec := range specs {
+		splitSpec := strings.SplitN(spec, "=", 2)
+		if len(splitSpec) != 2 || len(splitSpec[1]) == 0 {
+			return nil, fmt.Errorf("invalid registry provider %q: must be of the form <provider>=<registry>", spec)
+		}
+		provider := Provider(splitSpec[0])
+		switch provider {
+		case ProviderAWS, ProviderGCP, ProviderAzure:
+		default:
+			return nil, fmt.Errorf("unknown provider %q: must be one of %s, %s, %s", splitSpec[0], ProviderAWS, ProviderGCP, ProviderAzure)
+		}
+		providers = append(providers, RegistryProvider{
+			Provider: provider,
+			Registry: splitSpec[1],
+		})
+	}
+	return providers, nil
+}
+
+// KeyringBuilder creates keyrings that obtain the credentials of registries from their cloud provider.
+type KeyringBuilder struct {
+	log        logr.Logger
+	httpClient *http.Client
+	registries []RegistryProvider
+}
+
+// New creates a new cloud keyring builder.
+func New() *KeyringBuilder {
+	return &KeyringBuilder{
+		log: logr.Discard(),
+	}
+}
+
+// WithLog configures the logger.
+func (kb *KeyringBuilder) WithLog(log logr.Logger) *KeyringBuilder {
+	kb.log = log
+	return kb
+}
+
+// WithHTTPClient configures the http client that is used to request the tokens.
+// The default http clients of the cloud provider sdks are used if no client is configured.
+func (kb *KeyringBuilder) WithHTTPClient(client *http.Client) *KeyringBuilder {
+	kb.httpClient = client
+	return kb
+}
+
+// For adds registries whose credentials are obtained from their cloud provider.
+func (kb *KeyringBuilder) For(registries ...RegistryProvider) *KeyringBuilder {
+	kb.registries = append(kb.registries, registries...)
+	return kb
+}
+
+// Build creates a new keyring with the configured registries.
+// Nil is returned if no registry is configured.
+func (kb *KeyringBuilder) Build() (*credentials.GeneralOciKeyring, error) {
+	if len(kb.registries) == 0 {
+		return nil, nil
+	}
+	keyring := credentials.New()
+	if err := kb.Apply(keyring); err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+// Apply adds the configured registries to the given keyring.
+// The tokens are requested lazily when the credentials of a registry are needed and are cached until shortly before they expire.
+func (kb *KeyringBuilder) Apply(keyring *credentials.GeneralOciKeyring) error {
+	for _, registry := range kb.registries {
+		var fetch tokenFetcher
+		switch registry.Provider {
+		case ProviderAWS:
+			region, accountID, err := parseECRHost(registry.Registry)
+			if err != nil {
+				return err
+			}
+			fetch = newECRTokenFetcher(kb.httpClient, region, accountID)
+		case ProviderGCP:
+			fetch = newGCPTokenFetcher(kb.httpClient)
+		case ProviderAzure:
+			fetch = newACRTokenFetcher(kb.httpClient, registry.Registry)
+		default:
+			return fmt.Errorf("unknown provider %q for registry %q", registry.Provider, registry.Registry)
+		}
+		getter := newCachedGetter(kb.log, registry, fetch)
+		if err := keyring.AddAuthConfigGetter(registry.Registry, getter); err != nil {
+			return fmt.Errorf("unable to add auth for %q to keyring: %w", registry.Registry, err)
+		}
+		kb.log.V(10).Info(fmt.Sprintf("added authentication for %q with cloud provider %s", registry.Registry, registry.Provider))
+	}
+	return nil
+}
+
+// tokenFetcher requests new registry credentials and returns them with their expiry.
+type tokenFetcher func(ctx context.Context) (credentials.AuthConfig, time.Time, error)
+
+// newCachedGetter returns a auth config getter that caches the credentials of the fetcher until shortly before they expire.
+func newCachedGetter(log logr.Logger, registry RegistryProvider, fetch tokenFetcher) credentials.AuthConfigGetter {
+	var (
+		mux     sync.Mutex
+		auth    credentials.AuthConfig
+		expires time.Time
+	)
+	return func(_ string) (credentials.Auth, error) {
+		mux.Lock()
+		defer mux.Unlock()
+		if time.Now().Add(tokenRefreshMargin).Before(expires) {
+			return auth, nil
+		}
+		newAuth, newExpires, err := fetch(context.Background())
+		if err != nil {
+			log.V(4).Info(fmt.Sprintf("unable to get token for %q from cloud provider %s: %s", registry.Registry, registry.Provider, err.Error()))
+			return credentials.AuthConfig{}, err
+		}
+		newAuth.Metadata = map[string]string{"cloud-provider": string(registry.Provider)}
+		if !newExpires.IsZero() {
+			// transports that have been authenticated with the credentials are only reused until the token is refreshed.
+			newAuth.Expires = newExpires.Add(-tokenRefreshMargin)
+		}
+		auth, expires = newAuth, newExpires
+		return auth, nil
+	}
+}
+
+// doJSON executes the request and decodes the json response into v.
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code %d from %s: %s", resp.StatusCode, req.URL.Host, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("unable to decode response from %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
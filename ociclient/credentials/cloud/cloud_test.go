@@ -0,0 +1,326 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/ociclient/credentials"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "cloud credentials Test Suite")
+}
+
+var _ = Describe("cloud keyrings", func() {
+
+	var oldEnv map[string]*string
+
+	setEnv := func(key, value string) {
+		if _, ok := oldEnv[key]; !ok {
+			if oldValue, ok := os.LookupEnv(key); ok {
+				oldEnv[key] = &oldValue
+			} else {
+				oldEnv[key] = nil
+			}
+		}
+		if len(value) == 0 {
+			Expect(os.Unsetenv(key)).To(Succeed())
+			return
+		}
+		Expect(os.Setenv(key, value)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		oldEnv = map[string]*string{}
+	})
+
+	AfterEach(func() {
+		for key, value := range oldEnv {
+			if value == nil {
+				Expect(os.Unsetenv(key)).To(Succeed())
+				continue
+			}
+			Expect(os.Setenv(key, *value)).To(Succeed())
+		}
+	})
+
+	It("should parse registry providers", func() {
+		providers, err := ParseRegistryProviders([]string{"gcp=eu.gcr.io", "aws=123456789012.dkr.ecr.eu-west-1.amazonaws.com"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(providers).To(ConsistOf(
+			RegistryProvider{Provider: ProviderGCP, Registry: "eu.gcr.io"},
+			RegistryProvider{Provider: ProviderAWS, Registry: "123456789012.dkr.ecr.eu-west-1.amazonaws.com"},
+		))
+
+		_, err = ParseRegistryProviders([]string{"other=eu.gcr.io"})
+		Expect(err).To(HaveOccurred())
+		_, err = ParseRegistryProviders([]string{"gcp"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should get the credentials of an ecr registry with the aws credentials of the environment", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			Expect(req.Header.Get("X-Amz-Target")).To(Equal("AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken"))
+			Expect(req.Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=AKID/"))
+			Expect(req.Header.Get("Authorization")).To(ContainSubstring("/eu-west-1/ecr/aws4_request"))
+			Expect(req.Header.Get("X-Amz-Security-Token")).To(Equal("session"))
+			w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+			_, _ = w.Write([]byte(`{"authorizationData": [{"authorizationToken": "QVdTOnRva2Vu", "expiresAt": 4102444800}]}`))
+		}))
+		defer srv.Close()
+		setEnv("AWS_ACCESS_KEY_ID", "AKID")
+		setEnv("AWS_SECRET_ACCESS_KEY", "secret")
+		setEnv("AWS_SESSION_TOKEN", "session")
+		setEnv(ecrEndpointEnvName, srv.URL)
+
+		host := "123456789012.dkr.ecr.eu-west-1.amazonaws.com"
+		keyring, err := New().For(RegistryProvider{Provider: ProviderAWS, Registry: host}).Build()
+		Expect(err).ToNot(HaveOccurred())
+		auth := keyring.Get(host + "/my-image:v1")
+		Expect(auth).ToNot(BeNil())
+		Expect(auth.GetAuth()).To(Equal("QVdTOnRva2Vu"))
+		Expect(auth.(credentials.Expirer).Expiry()).To(BeTemporally("==", time.Unix(4102444800, 0).Add(-tokenRefreshMargin)))
+	})
+
+	It("should get the credentials of an ecr registry with a profile of the aws shared credentials file", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			Expect(req.Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=PROFILEKEY/"))
+			w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+			_, _ = w.Write([]byte(`{"authorizationData": [{"authorizationToken": "QVdTOnByb2ZpbGU=", "expiresAt": 4102444800}]}`))
+		}))
+		defer srv.Close()
+
+		awsDir, err := os.MkdirTemp("", "cloud-")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(awsDir)
+		credsFile := filepath.Join(awsDir, "credentials")
+		Expect(os.WriteFile(credsFile, []byte("[default]\naws_access_key_id = DEFAULTKEY\naws_secret_access_key = secret\n\n[ci]\naws_access_key_id = PROFILEKEY\naws_secret_access_key = secret\n"), os.ModePerm)).To(Succeed())
+		setEnv("AWS_ACCESS_KEY_ID", "")
+		setEnv("AWS_SECRET_ACCESS_KEY", "")
+		setEnv("AWS_SESSION_TOKEN", "")
+		setEnv("AWS_SHARED_CREDENTIALS_FILE", credsFile)
+		setEnv("AWS_CONFIG_FILE", filepath.Join(awsDir, "config"))
+		setEnv("AWS_PROFILE", "ci")
+		setEnv(ecrEndpointEnvName, srv.URL)
+
+		host := "123456789012.dkr.ecr.eu-west-1.amazonaws.com"
+		keyring, err := New().For(RegistryProvider{Provider: ProviderAWS, Registry: host}).Build()
+		Expect(err).ToNot(HaveOccurred())
+		auth := keyring.Get(host + "/my-image:v1")
+		Expect(auth).ToNot(BeNil())
+		Expect(auth.GetAuth()).To(Equal("QVdTOnByb2ZpbGU="))
+	})
+
+	It("should reject registries that are not hosted by ecr", func() {
+		_, err := New().For(RegistryProvider{Provider: ProviderAWS, Registry: "eu.gcr.io"}).Build()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should get the credentials of a google registry from the metadata server and cache the token", func() {
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			Expect(req.Header.Get("Metadata-Flavor")).To(Equal("Google"))
+			if req.URL.Path != "/computeMetadata/v1/instance/service-accounts/default/token" {
+				_, _ = w.Write([]byte("my-project"))
+				return
+			}
+			atomic.AddInt32(&calls, 1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token": "gcp-token", "expires_in": 3599, "token_type": "Bearer"}`))
+		}))
+		defer srv.Close()
+		home, err := os.MkdirTemp("", "cloud-")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(home)
+		setEnv("GOOGLE_APPLICATION_CREDENTIALS", "")
+		setEnv("HOME", home)
+		setEnv("XDG_CONFIG_HOME", "")
+		setEnv("GCE_METADATA_HOST", strings.TrimPrefix(srv.URL, "http://"))
+
+		keyring, err := New().For(RegistryProvider{Provider: ProviderGCP, Registry: "eu.gcr.io"}).Build()
+		Expect(err).ToNot(HaveOccurred())
+		for i := 0; i < 2; i++ {
+			auth := keyring.Get("eu.gcr.io/my-project/my-image:v1")
+			Expect(auth).ToNot(BeNil())
+			Expect(auth.GetUsername()).To(Equal("oauth2accesstoken"))
+			Expect(auth.GetPassword()).To(Equal("gcp-token"))
+		}
+		Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+	})
+
+	It("should get the credentials of a google registry with a service account key", func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			Expect(req.ParseForm()).To(Succeed())
+			Expect(req.PostForm.Get("grant_type")).To(Equal("urn:ietf:params:oauth:grant-type:jwt-bearer"))
+			parts := strings.Split(req.PostForm.Get("assertion"), ".")
+			Expect(parts).To(HaveLen(3))
+			signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+			Expect(err).ToNot(HaveOccurred())
+			hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			Expect(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature)).To(Succeed())
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token": "sa-token", "expires_in": 3599, "token_type": "Bearer"}`))
+		}))
+		defer srv.Close()
+
+		keyData, err := x509.MarshalPKCS8PrivateKey(key)
+		Expect(err).ToNot(HaveOccurred())
+		credsData, err := json.Marshal(map[string]string{
+			"type":         "service_account",
+			"client_email": "sa@project.iam.gserviceaccount.com",
+			"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyData})),
+			"token_uri":    srv.URL,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		credsDir, err := os.MkdirTemp("", "cloud-")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(credsDir)
+		credsFile := filepath.Join(credsDir, "credentials.json")
+		Expect(os.WriteFile(credsFile, credsData, os.ModePerm)).To(Succeed())
+		setEnv("GOOGLE_APPLICATION_CREDENTIALS", credsFile)
+
+		keyring, err := New().For(RegistryProvider{Provider: ProviderGCP, Registry: "europe-docker.pkg.dev"}).Build()
+		Expect(err).ToNot(HaveOccurred())
+		auth := keyring.Get("europe-docker.pkg.dev/my-project/my-repo/my-image:v1")
+		Expect(auth).ToNot(BeNil())
+		Expect(auth.GetPassword()).To(Equal("sa-token"))
+	})
+
+	It("should get the credentials of a google registry with an external account of the workload identity federation", func() {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			Expect(req.ParseForm()).To(Succeed())
+			Expect(req.PostForm.Get("grant_type")).To(Equal("urn:ietf:params:oauth:grant-type:token-exchange"))
+			Expect(req.PostForm.Get("subject_token")).To(Equal("oidc-token"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token": "federated-token", "issued_token_type": "urn:ietf:params:oauth:token-type:access_token", "token_type": "Bearer", "expires_in": 3600}`))
+		}))
+		defer srv.Close()
+
+		credsDir, err := os.MkdirTemp("", "cloud-")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(credsDir)
+		tokenFile := filepath.Join(credsDir, "token")
+		Expect(os.WriteFile(tokenFile, []byte("oidc-token"), os.ModePerm)).To(Succeed())
+		credsData, err := json.Marshal(map[string]interface{}{
+			"type":               "external_account",
+			"audience":           "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider",
+			"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+			"token_url":          srv.URL,
+			"credential_source": map[string]string{
+				"file": tokenFile,
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		credsFile := filepath.Join(credsDir, "credentials.json")
+		Expect(os.WriteFile(credsFile, credsData, os.ModePerm)).To(Succeed())
+		setEnv("GOOGLE_APPLICATION_CREDENTIALS", credsFile)
+
+		keyring, err := New().For(RegistryProvider{Provider: ProviderGCP, Registry: "europe-docker.pkg.dev"}).Build()
+		Expect(err).ToNot(HaveOccurred())
+		auth := keyring.Get("europe-docker.pkg.dev/my-project/my-repo/my-image:v1")
+		Expect(auth).ToNot(BeNil())
+		Expect(auth.GetPassword()).To(Equal("federated-token"))
+	})
+
+	It("should get the credentials of an acr registry with the azure workload identity", func() {
+		var aad *httptest.Server
+		aad = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch req.URL.Path {
+			case "/common/discovery/instance":
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"tenant_discovery_endpoint": aad.URL + "/tenant/v2.0/.well-known/openid-configuration",
+				})
+			case "/tenant/v2.0/.well-known/openid-configuration":
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"authorization_endpoint": aad.URL + "/tenant/oauth2/v2.0/authorize",
+					"token_endpoint":         aad.URL + "/tenant/oauth2/v2.0/token",
+					"issuer":                 aad.URL + "/tenant/v2.0",
+				})
+			case "/tenant/oauth2/v2.0/token":
+				Expect(req.ParseForm()).To(Succeed())
+				Expect(req.PostForm.Get("client_assertion")).To(Equal("federated-token"))
+				Expect(req.PostForm.Get("client_id")).To(Equal("client"))
+				_, _ = w.Write([]byte(`{"access_token": "aad-token", "expires_in": 3600, "token_type": "Bearer"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer aad.Close()
+		acr := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			Expect(req.URL.Path).To(Equal("/oauth2/exchange"))
+			Expect(req.ParseForm()).To(Succeed())
+			Expect(req.PostForm.Get("access_token")).To(Equal("aad-token"))
+			_ = json.NewEncoder(w).Encode(map[string]string{"refresh_token": "acr-token"})
+		}))
+		defer acr.Close()
+
+		tokenDir, err := os.MkdirTemp("", "cloud-")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(tokenDir)
+		tokenFile := filepath.Join(tokenDir, "token")
+		Expect(os.WriteFile(tokenFile, []byte("federated-token"), os.ModePerm)).To(Succeed())
+		setEnv("AZURE_FEDERATED_TOKEN_FILE", tokenFile)
+		setEnv("AZURE_CLIENT_ID", "client")
+		setEnv("AZURE_TENANT_ID", "tenant")
+		setEnv("AZURE_AUTHORITY_HOST", aad.URL)
+
+		registry := strings.TrimPrefix(acr.URL, "https://")
+		// the instance discovery of the azure active directory is redirected to the test server
+		httpClient := acr.Client()
+		httpClient.Transport = hostRewriter{
+			from:      "login.microsoftonline.com",
+			to:        strings.TrimPrefix(aad.URL, "https://"),
+			transport: httpClient.Transport,
+		}
+		keyring, err := New().
+			WithHTTPClient(httpClient).
+			For(RegistryProvider{Provider: ProviderAzure, Registry: registry}).
+			Build()
+		Expect(err).ToNot(HaveOccurred())
+		auth := keyring.Get(registry + "/my-image:v1")
+		Expect(auth).ToNot(BeNil())
+		Expect(auth.GetUsername()).To(Equal(acrRefreshTokenUsername))
+		Expect(auth.GetPassword()).To(Equal("acr-token"))
+	})
+
+})
+
+// hostRewriter is a transport that sends the requests of one host to another host.
+type hostRewriter struct {
+	from, to  string
+	transport http.RoundTripper
+}
+
+func (r hostRewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == r.from {
+		req = req.Clone(req.Context())
+		req.URL.Host = r.to
+		req.Host = r.to
+	}
+	return r.transport.RoundTrip(req)
+}
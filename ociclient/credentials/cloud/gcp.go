@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/gardener/component-cli/ociclient/credentials"
+)
+
+const (
+	// gcpCloudPlatformScope is the oauth2 scope that is requested for service accounts.
+	gcpCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+	// gcpRegistryUsername is the username that is used to authenticate at google registries with an access token.
+	gcpRegistryUsername = "oauth2accesstoken"
+)
+
+// newGCPTokenFetcher returns a fetcher that requests access tokens with the application default credentials of the google sdk.
+// The credentials are read from the credentials file (service account key, authorized user or external account
+// for workload identity federation) or from the metadata server (GCE, GKE, Cloud Build).
+func newGCPTokenFetcher(httpClient *http.Client) tokenFetcher {
+	var tokenSource oauth2.TokenSource
+	return func(ctx context.Context) (credentials.AuthConfig, time.Time, error) {
+		if tokenSource == nil {
+			// the token source keeps the context to refresh its tokens, so it must not be canceled.
+			sourceCtx := context.Background()
+			if httpClient != nil {
+				sourceCtx = context.WithValue(sourceCtx, oauth2.HTTPClient, httpClient)
+			}
+			creds, err := google.FindDefaultCredentials(sourceCtx, gcpCloudPlatformScope)
+			if err != nil {
+				return credentials.AuthConfig{}, time.Time{}, fmt.Errorf("unable to find google application default credentials: %w", err)
+			}
+			tokenSource = creds.TokenSource
+		}
+		token, err := tokenSource.Token()
+		if err != nil {
+			return credentials.AuthConfig{}, time.Time{}, fmt.Errorf("unable to get google access token: %w", err)
+		}
+		return credentials.AuthConfig{
+			Username: gcpRegistryUsername,
+			Password: token.AccessToken,
+		}, token.Expiry, nil
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"sync"
+	"time"
 
 	dockerreference "github.com/containerd/containerd/reference/docker"
 	dockercreds "github.com/docker/cli/cli/config/credentials"
@@ -47,6 +49,13 @@ type Informer interface {
 	Info() map[string]string
 }
 
+// Expirer describes a interface of short-lived credentials.
+// Credentials that do not implement the interface or return the zero time do not expire.
+type Expirer interface {
+	// Expiry returns the time at which the credentials expire.
+	Expiry() time.Time
+}
+
 // AuthConfig implements the Auth using the docker authconfig type.
 // It also implements the Informer interface for additional information
 type AuthConfig struct {
@@ -69,6 +78,9 @@ type AuthConfig struct {
 	RegistryToken string `json:"registrytoken,omitempty"`
 
 	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Expires is the time at which short-lived credentials expire.
+	Expires time.Time `json:"-"`
 }
 
 // FromAuthConfig creates a Auth object using the docker authConfig type
@@ -118,6 +130,20 @@ func (a AuthConfig) Info() map[string]string {
 	return a.Metadata
 }
 
+func (a AuthConfig) Expiry() time.Time {
+	return a.Expires
+}
+
+// expiringAuthenticator is a authenticator whose credentials expire.
+type expiringAuthenticator struct {
+	authn.Authenticator
+	expires time.Time
+}
+
+func (a expiringAuthenticator) Expiry() time.Time {
+	return a.expires
+}
+
 // Keyring enhances the google go-lib auth keyring with a contextified resolver
 type Keyring interface {
 	authn.Keychain
@@ -137,6 +163,14 @@ type OCIKeyring interface {
 	GetCredentials(hostname string) (username, password string, err error)
 }
 
+// GenerationKeyring is implemented by keyrings whose credentials can change after they have been used.
+// The generation changes with every change of the credentials so that state that has been derived from
+// the previous credentials (e.g. authenticated transports) can be invalidated.
+type GenerationKeyring interface {
+	// Generation returns the current generation of the credentials.
+	Generation() uint64
+}
+
 // AuthConfigGetter is a function that returns a auth config for a given host name
 type AuthConfigGetter func(address string) (Auth, error)
 
@@ -149,9 +183,13 @@ func DefaultAuthConfigGetter(config Auth) AuthConfigGetter {
 
 // GeneralOciKeyring is general implementation of a oci keyring that can be extended with other credentials.
 type GeneralOciKeyring struct {
+	// mux guards the index and the store so that the credentials can be replaced while they are used.
+	mux sync.RWMutex
 	// index is an additional index structure that also contains multi
 	index *IndexNode
 	store map[string][]AuthConfigGetter
+	// generation is increased whenever credentials are added or replaced.
+	generation uint64
 }
 
 type IndexNode struct {
@@ -207,13 +245,23 @@ func New() *GeneralOciKeyring {
 }
 
 var _ OCIKeyring = &GeneralOciKeyring{}
+var _ GenerationKeyring = &GeneralOciKeyring{}
 
 // Size returns the size of the keyring
-func (o GeneralOciKeyring) Size() int {
+func (o *GeneralOciKeyring) Size() int {
+	o.mux.RLock()
+	defer o.mux.RUnlock()
 	return len(o.store)
 }
 
-func (o GeneralOciKeyring) Get(resourceURl string) Auth {
+// Generation returns the generation of the credentials of the keyring.
+func (o *GeneralOciKeyring) Generation() uint64 {
+	o.mux.RLock()
+	defer o.mux.RUnlock()
+	return o.generation
+}
+
+func (o *GeneralOciKeyring) Get(resourceURl string) Auth {
 	ref, err := dockerreference.ParseDockerRef(resourceURl)
 	if err == nil {
 		// if the name is not conical try to treat it like a host name
@@ -232,29 +280,31 @@ func (o GeneralOciKeyring) Get(resourceURl string) Auth {
 	return nil
 }
 
-func (o GeneralOciKeyring) get(url string) Auth {
+func (o *GeneralOciKeyring) get(url string) Auth {
+	o.mux.RLock()
 	addresses, ok := o.index.Find(url)
 	if !ok {
+		o.mux.RUnlock()
 		return nil
 	}
+	getters := []AuthConfigGetter{}
 	for _, address := range addresses {
-		authGetters, ok := o.store[address]
-		if !ok {
+		getters = append(getters, o.store[address]...)
+	}
+	// the getters are called without lock as credential helpers may take some time.
+	o.mux.RUnlock()
+
+	for _, authGetter := range getters {
+		auth, err := authGetter(url)
+		if err != nil {
+			// todo: add logger
 			continue
 		}
-		for _, authGetter := range authGetters {
-			auth, err := authGetter(url)
-			if err != nil {
-				// todo: add logger
-				continue
-			}
-			if IsEmptyAuthConfig(auth) {
-				// try another config if the current one is emtpy
-				continue
-			}
-			return auth
+		if IsEmptyAuthConfig(auth) {
+			// try another config if the current one is emtpy
+			continue
 		}
-
+		return auth
 	}
 	return nil
 }
@@ -293,8 +343,11 @@ func (o *GeneralOciKeyring) AddAuthConfigGetter(address string, getter AuthConfi
 	if err != nil {
 		return err
 	}
+	o.mux.Lock()
+	defer o.mux.Unlock()
 	o.store[address] = append(o.store[address], getter)
 	o.index.Set(address, address)
+	o.generation++
 	return nil
 }
 
@@ -335,13 +388,17 @@ func (o *GeneralOciKeyring) ResolveWithContext(ctx context.Context, resource aut
 		}
 	}
 
-	return authn.FromConfig(authn.AuthConfig{
+	auth := authn.FromConfig(authn.AuthConfig{
 		Username:      authconfig.GetUsername(),
 		Password:      authconfig.GetPassword(),
 		Auth:          authconfig.GetAuth(),
 		IdentityToken: authconfig.GetIdentityToken(),
 		RegistryToken: authconfig.GetRegistryToken(),
-	}), nil
+	})
+	if expirer, ok := authconfig.(Expirer); ok && !expirer.Expiry().IsZero() {
+		return expiringAuthenticator{Authenticator: auth, expires: expirer.Expiry()}, nil
+	}
+	return auth, nil
 }
 
 func normalizeHost(u string) (string, error) {
@@ -358,7 +415,13 @@ func normalizeHost(u string) (string, error) {
 // Merge merges all authentication options from keyring 1 and 2.
 // Keyring 2 overwrites authentication from keyring 1 on clashes.
 func Merge(k1, k2 *GeneralOciKeyring) error {
+	k2.mux.RLock()
+	store := make(map[string][]AuthConfigGetter, len(k2.store))
 	for address, getters := range k2.store {
+		store[address] = getters
+	}
+	k2.mux.RUnlock()
+	for address, getters := range store {
 		for _, getter := range getters {
 			if err := k1.AddAuthConfigGetter(address, getter); err != nil {
 				return err
@@ -368,6 +431,18 @@ func Merge(k1, k2 *GeneralOciKeyring) error {
 	return nil
 }
 
+// replace replaces all authentication options of the keyring with the ones of the given keyring.
+func (o *GeneralOciKeyring) replace(k *GeneralOciKeyring) {
+	k.mux.RLock()
+	index, store := k.index, k.store
+	k.mux.RUnlock()
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	o.index = index
+	o.store = store
+	o.generation++
+}
+
 // IsEmptyAuthConfig validates if the resulting auth config contains credentails
 func IsEmptyAuthConfig(auth Auth) bool {
 	if len(auth.GetAuth()) != 0 {
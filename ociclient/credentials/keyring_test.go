@@ -5,15 +5,37 @@
 package credentials_test
 
 import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/gardener/component-cli/ociclient/credentials"
 )
 
+// fakeSecrets is a secret getter that serves the secrets of a single namespace from a map.
+type fakeSecrets map[string]*corev1.Secret
+
+func (f fakeSecrets) Get(_ context.Context, name string, _ metav1.GetOptions) (*corev1.Secret, error) {
+	secret, ok := f[name]
+	if !ok {
+		return nil, fmt.Errorf("secret %q not found", name)
+	}
+	return secret, nil
+}
+
 func TestConfig(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "credentials Test Suite")
@@ -117,4 +139,197 @@ var _ = Describe("Keyrings", func() {
 		})
 	})
 
+	Context("#Reload", func() {
+
+		writeDockerConfig := func(fs vfs.FileSystem, username string) {
+			auth := base64.StdEncoding.EncodeToString([]byte(username + ":abc"))
+			data := []byte(fmt.Sprintf(`{"auths": {"eu.gcr.io": {"auth": %q}}}`, auth))
+			Expect(vfs.WriteFile(fs, "/dockerconfig.json", data, os.ModePerm)).To(Succeed())
+		}
+
+		It("should replace the credentials with the ones of the changed docker config", func() {
+			fs := memoryfs.New()
+			writeDockerConfig(fs, "old")
+			keyring, err := credentials.NewBuilder(logr.Discard()).
+				DisableDefaultConfig().
+				WithFS(fs).
+				FromConfigFiles("/dockerconfig.json").
+				BuildReloadable(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(keyring.Get("eu.gcr.io/my-project/myimage").GetUsername()).To(Equal("old"))
+
+			reloaded, err := keyring.ReloadIfChanged(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reloaded).To(BeFalse())
+
+			writeDockerConfig(fs, "rotated")
+			reloaded, err = keyring.ReloadIfChanged(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reloaded).To(BeTrue())
+			Expect(keyring.Get("eu.gcr.io/my-project/myimage").GetUsername()).To(Equal("rotated"))
+			Expect(keyring.Size()).To(Equal(1))
+		})
+
+		It("should keep the credentials if the reload fails", func() {
+			fs := memoryfs.New()
+			writeDockerConfig(fs, "old")
+			keyring, err := credentials.NewBuilder(logr.Discard()).
+				DisableDefaultConfig().
+				WithFS(fs).
+				FromConfigFiles("/dockerconfig.json").
+				BuildReloadable(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.Remove("/dockerconfig.json")).To(Succeed())
+			Expect(keyring.Reload(context.TODO())).ToNot(Succeed())
+			Expect(keyring.Get("eu.gcr.io/my-project/myimage").GetUsername()).To(Equal("old"))
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+			writeDockerConfig(fs, "rotated")
+			Expect(keyring.Reload(ctx)).ToNot(Succeed())
+			Expect(keyring.Get("eu.gcr.io/my-project/myimage").GetUsername()).To(Equal("old"))
+		})
+
+		It("should watch the docker config files and reload the keyring on changes", func() {
+			fs := memoryfs.New()
+			writeDockerConfig(fs, "old")
+			keyring, err := credentials.NewBuilder(logr.Discard()).
+				DisableDefaultConfig().
+				WithFS(fs).
+				FromConfigFiles("/dockerconfig.json").
+				BuildReloadable(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			go keyring.Watch(ctx, 10*time.Millisecond)
+
+			writeDockerConfig(fs, "rotated")
+			Eventually(func() string {
+				return keyring.Get("eu.gcr.io/my-project/myimage").GetUsername()
+			}, time.Second, 10*time.Millisecond).Should(Equal("rotated"))
+		})
+	})
+
+	Context("#FromKubernetesSecrets", func() {
+
+		pullSecret := func(username string) *corev1.Secret {
+			auth := base64.StdEncoding.EncodeToString([]byte(username + ":abc"))
+			return &corev1.Secret{
+				Type: corev1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{
+					corev1.DockerConfigJsonKey: []byte(fmt.Sprintf(`{"auths": {"eu.gcr.io": {"auth": %q}}}`, auth)),
+				},
+			}
+		}
+
+		It("should read the pull secrets from the cluster whenever the keyring is reloaded", func() {
+			secrets := fakeSecrets{"pull-secret": pullSecret("old")}
+			keyring, err := credentials.NewBuilder(logr.Discard()).
+				DisableDefaultConfig().
+				FromKubernetesSecrets(secrets, "pull-secret").
+				BuildReloadable(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(keyring.Get("eu.gcr.io/my-project/myimage").GetUsername()).To(Equal("old"))
+
+			secrets["pull-secret"] = pullSecret("rotated")
+			Expect(keyring.Reload(context.TODO())).To(Succeed())
+			Expect(keyring.Get("eu.gcr.io/my-project/myimage").GetUsername()).To(Equal("rotated"))
+		})
+
+		It("should fail if a pull secret does not exist", func() {
+			_, err := credentials.NewBuilder(logr.Discard()).
+				DisableDefaultConfig().
+				FromKubernetesSecrets(fakeSecrets{}, "missing").
+				Build()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("missing"))
+		})
+	})
+
+	Context("#CredentialHelpers", func() {
+
+		var (
+			helperDir string
+			oldPath   string
+		)
+
+		// writeCredentialHelper writes a fake docker credential helper that returns the current content of the token file.
+		writeCredentialHelper := func(name, address string) {
+			script := fmt.Sprintf(`#!/bin/sh
+case "$1" in
+list) echo '{"%s":"user"}' ;;
+get) read url; echo "{\"ServerURL\":\"$url\",\"Username\":\"%s\",\"Secret\":\"$(cat %s/token)\"}" ;;
+*) exit 1 ;;
+esac
+`, address, name, helperDir)
+			Expect(os.WriteFile(filepath.Join(helperDir, "docker-credential-"+name), []byte(script), 0755)).To(Succeed())
+		}
+
+		buildKeyring := func(dockerConfig string) *credentials.GeneralOciKeyring {
+			fs := memoryfs.New()
+			Expect(vfs.WriteFile(fs, "/dockerconfig.json", []byte(dockerConfig), os.ModePerm)).To(Succeed())
+			keyring, err := credentials.NewBuilder(logr.Discard()).
+				DisableDefaultConfig().
+				WithFS(fs).
+				FromConfigFiles("/dockerconfig.json").
+				Build()
+			Expect(err).ToNot(HaveOccurred())
+			return keyring
+		}
+
+		BeforeEach(func() {
+			var err error
+			helperDir, err = os.MkdirTemp("", "credhelper-")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(helperDir, "token"), []byte("token1"), os.ModePerm)).To(Succeed())
+			oldPath = os.Getenv("PATH")
+			Expect(os.Setenv("PATH", helperDir+string(os.PathListSeparator)+oldPath)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.Setenv("PATH", oldPath)).To(Succeed())
+			Expect(os.RemoveAll(helperDir)).To(Succeed())
+		})
+
+		It("should get the credentials of a registry from its credential helper on every request", func() {
+			writeCredentialHelper("fake", "eu.gcr.io")
+			keyring := buildKeyring(`{"credHelpers": {"eu.gcr.io": "fake"}}`)
+
+			auth := keyring.Get("eu.gcr.io/my-project/myimage")
+			Expect(auth).ToNot(BeNil())
+			Expect(auth.GetUsername()).To(Equal("fake"))
+			Expect(auth.GetPassword()).To(Equal("token1"))
+
+			Expect(os.WriteFile(filepath.Join(helperDir, "token"), []byte("token2"), os.ModePerm)).To(Succeed())
+			Expect(keyring.Get("eu.gcr.io/my-project/myimage").GetPassword()).To(Equal("token2"))
+		})
+
+		It("should get the credentials of registries that are only known by the credential store", func() {
+			writeCredentialHelper("store", "https://registry.example.com")
+			keyring := buildKeyring(`{"credsStore": "store"}`)
+
+			auth := keyring.Get("registry.example.com/my-project/myimage")
+			Expect(auth).ToNot(BeNil())
+			Expect(auth.GetUsername()).To(Equal("store"))
+			Expect(auth.GetPassword()).To(Equal("token1"))
+			Expect(keyring.Get("eu.gcr.io/my-project/myimage")).To(BeNil())
+		})
+
+		It("should use the credential store of the config for auths without credentials", func() {
+			writeCredentialHelper("store", "eu.gcr.io")
+			keyring := buildKeyring(`{"credsStore": "store", "auths": {"eu.gcr.io": {}}}`)
+
+			auth := keyring.Get("eu.gcr.io/my-project/myimage")
+			Expect(auth).ToNot(BeNil())
+			Expect(auth.GetUsername()).To(Equal("store"))
+		})
+
+		It("should ignore a credential store that cannot be executed", func() {
+			keyring := buildKeyring(`{"credsStore": "missing", "auths": {"eu.gcr.io": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("test:pass")) + `"}}}`)
+			Expect(keyring.Get("eu.gcr.io/my-project/myimage").GetUsername()).To(Equal("test"))
+		})
+	})
+
 })
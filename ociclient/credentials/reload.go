@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// ReloadableKeyring is a keyring whose credentials can be reloaded from the sources of its builder,
+// so that long-running processes pick up rotated credentials without recreating the oci client.
+type ReloadableKeyring struct {
+	*GeneralOciKeyring
+
+	log     logr.Logger
+	builder *KeyringBuilder
+
+	// mux serializes reloads.
+	mux sync.Mutex
+	// fileStates contains the state of the docker config files that was read by the last reload.
+	fileStates map[string]configFileState
+}
+
+// configFileState describes the state of a docker config file that is used to detect changes.
+type configFileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// BuildReloadable creates a new oci registry keyring from the configured secrets that can be reloaded.
+func (b *KeyringBuilder) BuildReloadable(ctx context.Context) (*ReloadableKeyring, error) {
+	b.applyDefaults()
+	k := &ReloadableKeyring{
+		GeneralOciKeyring: New(),
+		log:               b.log,
+		builder:           b,
+	}
+	if err := k.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload reads the docker config files and pull secrets again and replaces all credentials of the keyring.
+// The credentials are not changed if the reload fails.
+func (k *ReloadableKeyring) Reload(ctx context.Context) error {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	return k.reload(ctx)
+}
+
+func (k *ReloadableKeyring) reload(ctx context.Context) error {
+	// the file states are read before the files so that changes during the reload are detected by the next check.
+	fileStates := k.readFileStates()
+	keyring, err := k.builder.BuildWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to reload keyring: %w", err)
+	}
+	k.GeneralOciKeyring.replace(keyring)
+	k.fileStates = fileStates
+	return nil
+}
+
+// ReloadIfChanged reloads the keyring if one of the docker config files has changed since the last reload.
+// It returns whether the keyring has been reloaded.
+func (k *ReloadableKeyring) ReloadIfChanged(ctx context.Context) (bool, error) {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	if !k.hasChanged() {
+		return false, nil
+	}
+	if err := k.reload(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Watch checks the docker config files for changes in the given interval and reloads the keyring on changes.
+// Watch blocks until the context is done. Failed reloads are logged and retried with the next check.
+func (k *ReloadableKeyring) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := k.ReloadIfChanged(ctx)
+			if err != nil {
+				k.log.Error(err, "unable to reload credentials")
+				continue
+			}
+			if reloaded {
+				k.log.V(3).Info("reloaded credentials from changed docker config files")
+			}
+		}
+	}
+}
+
+func (k *ReloadableKeyring) readFileStates() map[string]configFileState {
+	states := map[string]configFileState{}
+	for _, configFile := range k.builder.configFiles {
+		if len(configFile) == 0 {
+			continue
+		}
+		info, err := k.builder.fs.Stat(configFile)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				k.log.V(5).Info(fmt.Sprintf("unable to get file info for %q: %s", configFile, err.Error()))
+			}
+			states[configFile] = configFileState{}
+			continue
+		}
+		states[configFile] = configFileState{
+			exists:  true,
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		}
+	}
+	return states
+}
+
+func (k *ReloadableKeyring) hasChanged() bool {
+	for configFile, state := range k.readFileStates() {
+		last, ok := k.fileStates[configFile]
+		if !ok || last.exists != state.exists || last.size != state.size || !last.modTime.Equal(state.modTime) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,427 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+
+	"github.com/containerd/containerd/archive/compression"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// DockerArchiveManifestFile is the name of the manifest file of a docker save tarball.
+	DockerArchiveManifestFile = "manifest.json"
+	// OCILayoutIndexFile is the name of the index file of an oci image layout.
+	OCILayoutIndexFile = "index.json"
+)
+
+// dockerArchiveManifest describes an image of a docker save tarball.
+type dockerArchiveManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// archiveEntry describes the location of a file in a tarball.
+type archiveEntry struct {
+	offset int64
+	size   int64
+}
+
+// imageArchive provides random access to the files of an image tarball.
+type imageArchive struct {
+	r       io.ReaderAt
+	entries map[string]archiveEntry
+}
+
+// PushDockerArchive pushes the image of a docker save tarball or of an oci image layout tarball to the given reference
+// and returns the descriptor of the pushed manifest.
+// The images of docker save tarballs are converted to oci image manifests with the media types of their layers
+// detected from the layer content.
+// Oci image layouts are pushed as they are, including nested image indexes.
+// The tarball has to contain exactly one image.
+func PushDockerArchive(ctx context.Context, client Client, archive io.ReaderAt, size int64, ref string) (ocispecv1.Descriptor, error) {
+	a, err := readImageArchive(archive, size)
+	if err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	if a.has(OCILayoutIndexFile) {
+		return a.pushOCILayout(ctx, client, ref)
+	}
+	if a.has(DockerArchiveManifestFile) {
+		return a.pushDockerSave(ctx, client, ref)
+	}
+	return ocispecv1.Descriptor{}, fmt.Errorf("archive is neither a docker save tarball nor an oci image layout: neither %s nor %s found",
+		DockerArchiveManifestFile, OCILayoutIndexFile)
+}
+
+// ConvertDockerArchive converts a docker save tarball to an oci image layout tarball and writes it to the given writer.
+// The image is converted to an oci image manifest like in PushDockerArchive.
+// Oci image layout tarballs are written as they are.
+// The tarball has to contain exactly one image.
+// Returns the descriptor of the image manifest.
+func ConvertDockerArchive(archive io.ReaderAt, size int64, w io.Writer) (ocispecv1.Descriptor, error) {
+	a, err := readImageArchive(archive, size)
+	if err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	if a.has(OCILayoutIndexFile) {
+		index, err := a.readOCILayoutIndex()
+		if err != nil {
+			return ocispecv1.Descriptor{}, err
+		}
+		if _, err := io.Copy(w, io.NewSectionReader(archive, 0, size)); err != nil {
+			return ocispecv1.Descriptor{}, fmt.Errorf("unable to copy oci image layout: %w", err)
+		}
+		return index.Manifests[0], nil
+	}
+	if !a.has(DockerArchiveManifestFile) {
+		return ocispecv1.Descriptor{}, fmt.Errorf("archive is neither a docker save tarball nor an oci image layout: neither %s nor %s found",
+			DockerArchiveManifestFile, OCILayoutIndexFile)
+	}
+
+	desc, rawManifest, blobs, err := a.convertDockerSave()
+	if err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	if err := a.writeOCILayout(w, desc, rawManifest, blobs); err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+// readImageArchive indexes all regular files and symlinks of a tarball.
+func readImageArchive(r io.ReaderAt, size int64) (*imageArchive, error) {
+	a := &imageArchive{
+		r:       r,
+		entries: map[string]archiveEntry{},
+	}
+	sr := io.NewSectionReader(r, 0, size)
+	tr := tar.NewReader(sr)
+	links := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("unable to read tar header: %w", err)
+		}
+		name := path.Clean(header.Name)
+		switch header.Typeflag {
+		case tar.TypeReg:
+			offset, err := sr.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, fmt.Errorf("unable to get offset of %s: %w", header.Name, err)
+			}
+			a.entries[name] = archiveEntry{
+				offset: offset,
+				size:   header.Size,
+			}
+		case tar.TypeSymlink:
+			links[name] = path.Join(path.Dir(name), header.Linkname)
+		case tar.TypeLink:
+			links[name] = path.Clean(header.Linkname)
+		}
+	}
+
+	// older docker versions reference duplicated layers by symlinks
+	for name, target := range links {
+		entry, ok := a.entries[target]
+		if !ok {
+			return nil, fmt.Errorf("link %s points to unknown file %s", name, target)
+		}
+		a.entries[name] = entry
+	}
+	return a, nil
+}
+
+func (a *imageArchive) has(name string) bool {
+	_, ok := a.entries[path.Clean(name)]
+	return ok
+}
+
+// open returns a reader for the file with the given name.
+func (a *imageArchive) open(name string) (*io.SectionReader, error) {
+	entry, ok := a.entries[path.Clean(name)]
+	if !ok {
+		return nil, fmt.Errorf("file %s not found in archive", name)
+	}
+	return io.NewSectionReader(a.r, entry.offset, entry.size), nil
+}
+
+func (a *imageArchive) readFile(name string) ([]byte, error) {
+	r, err := a.open(name)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+// store returns a store that reads the blobs from the given files of the archive.
+func (a *imageArchive) store(files map[digest.Digest]string) Store {
+	return GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
+		name, ok := files[desc.Digest]
+		if !ok {
+			return fmt.Errorf("blob %s not found in archive", desc.Digest)
+		}
+		r, err := a.open(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(writer, r)
+		return err
+	})
+}
+
+// readOCILayoutIndex reads the index of an oci image layout that has to contain exactly one image.
+func (a *imageArchive) readOCILayoutIndex() (*ocispecv1.Index, error) {
+	data, err := a.readFile(OCILayoutIndexFile)
+	if err != nil {
+		return nil, err
+	}
+	index := &ocispecv1.Index{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", OCILayoutIndexFile, err)
+	}
+	if len(index.Manifests) != 1 {
+		return nil, fmt.Errorf("expected exactly one image in the oci image layout but found %d", len(index.Manifests))
+	}
+	return index, nil
+}
+
+// writeOCILayout writes an oci image layout tarball that contains the given manifest and its blobs.
+func (a *imageArchive) writeOCILayout(w io.Writer, desc ocispecv1.Descriptor, rawManifest []byte, blobs map[digest.Digest]string) error {
+	tw := tar.NewWriter(w)
+	writeFile := func(name string, size int64, r io.Reader) error {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: size, Mode: 0644}); err != nil {
+			return fmt.Errorf("unable to write header for %s: %w", name, err)
+		}
+		if _, err := io.Copy(tw, r); err != nil {
+			return fmt.Errorf("unable to write %s: %w", name, err)
+		}
+		return nil
+	}
+	writeData := func(name string, data []byte) error {
+		return writeFile(name, int64(len(data)), bytes.NewReader(data))
+	}
+	blobPath := func(dig digest.Digest) string {
+		return path.Join("blobs", dig.Algorithm().String(), dig.Encoded())
+	}
+
+	layout, err := json.Marshal(ocispecv1.ImageLayout{Version: ocispecv1.ImageLayoutVersion})
+	if err != nil {
+		return fmt.Errorf("unable to marshal oci layout: %w", err)
+	}
+	if err := writeData(ocispecv1.ImageLayoutFile, layout); err != nil {
+		return err
+	}
+	index, err := json.Marshal(ocispecv1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Manifests: []ocispecv1.Descriptor{desc},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to marshal index: %w", err)
+	}
+	if err := writeData(OCILayoutIndexFile, index); err != nil {
+		return err
+	}
+	if err := writeData(blobPath(desc.Digest), rawManifest); err != nil {
+		return err
+	}
+
+	// write the blobs in a stable order
+	digests := make([]string, 0, len(blobs))
+	for dig := range blobs {
+		digests = append(digests, dig.String())
+	}
+	sort.Strings(digests)
+	for _, dig := range digests {
+		r, err := a.open(blobs[digest.Digest(dig)])
+		if err != nil {
+			return err
+		}
+		if err := writeFile(blobPath(digest.Digest(dig)), r.Size(), r); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// pushOCILayout pushes the only manifest of the index of an oci image layout.
+func (a *imageArchive) pushOCILayout(ctx context.Context, client Client, ref string) (ocispecv1.Descriptor, error) {
+	index, err := a.readOCILayoutIndex()
+	if err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+
+	blobs := map[digest.Digest]string{}
+	for name := range a.entries {
+		dir, encoded := path.Split(name)
+		if alg := path.Base(dir); path.Dir(path.Clean(dir)) == "blobs" {
+			blobs[digest.NewDigestFromEncoded(digest.Algorithm(alg), encoded)] = name
+		}
+	}
+	desc := index.Manifests[0]
+	desc = ocispecv1.Descriptor{
+		MediaType: desc.MediaType,
+		Digest:    desc.Digest,
+		Size:      desc.Size,
+	}
+	if err := a.pushLayoutManifest(ctx, client, ref, desc, blobs); err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+// pushLayoutManifest pushes a manifest of an oci image layout.
+// The manifests of an image index are pushed by their digest before the index itself.
+func (a *imageArchive) pushLayoutManifest(ctx context.Context, client Client, ref string, desc ocispecv1.Descriptor, blobs map[digest.Digest]string) error {
+	name, ok := blobs[desc.Digest]
+	if !ok {
+		return fmt.Errorf("manifest %s not found in archive", desc.Digest)
+	}
+	rawManifest, err := a.readFile(name)
+	if err != nil {
+		return err
+	}
+
+	if IsMultiArchImage(desc.MediaType) {
+		index := ocispecv1.Index{}
+		if err := json.Unmarshal(rawManifest, &index); err != nil {
+			return fmt.Errorf("unable to unmarshal image index: %w", err)
+		}
+		for _, manifestDesc := range index.Manifests {
+			subManifestRef, err := DigestRef(ref, manifestDesc.Digest)
+			if err != nil {
+				return fmt.Errorf("unable to parse ref: %w", err)
+			}
+			if err := a.pushLayoutManifest(ctx, client, subManifestRef, manifestDesc, blobs); err != nil {
+				return fmt.Errorf("unable to push sub manifest: %w", err)
+			}
+		}
+	}
+
+	if err := client.PushRawManifest(ctx, ref, desc, rawManifest, WithStore(a.store(blobs))); err != nil {
+		return fmt.Errorf("unable to push manifest: %w", err)
+	}
+	return nil
+}
+
+// pushDockerSave converts the only image of a docker save tarball to an oci image manifest and pushes it.
+func (a *imageArchive) pushDockerSave(ctx context.Context, client Client, ref string) (ocispecv1.Descriptor, error) {
+	desc, rawManifest, blobs, err := a.convertDockerSave()
+	if err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	if err := client.PushRawManifest(ctx, ref, desc, rawManifest, WithStore(a.store(blobs))); err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to push manifest: %w", err)
+	}
+	return desc, nil
+}
+
+// convertDockerSave converts the only image of a docker save tarball to an oci image manifest.
+// It returns the descriptor of the manifest, the raw manifest and the files of all blobs of the manifest.
+func (a *imageArchive) convertDockerSave() (ocispecv1.Descriptor, []byte, map[digest.Digest]string, error) {
+	data, err := a.readFile(DockerArchiveManifestFile)
+	if err != nil {
+		return ocispecv1.Descriptor{}, nil, nil, err
+	}
+	images := []dockerArchiveManifest{}
+	if err := json.Unmarshal(data, &images); err != nil {
+		return ocispecv1.Descriptor{}, nil, nil, fmt.Errorf("unable to decode %s: %w", DockerArchiveManifestFile, err)
+	}
+	if len(images) != 1 {
+		return ocispecv1.Descriptor{}, nil, nil, fmt.Errorf("expected exactly one image in the docker archive but found %d", len(images))
+	}
+	image := images[0]
+
+	blobs := map[digest.Digest]string{}
+	configData, err := a.readFile(image.Config)
+	if err != nil {
+		return ocispecv1.Descriptor{}, nil, nil, fmt.Errorf("unable to read config: %w", err)
+	}
+	manifest := ocispecv1.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispecv1.MediaTypeImageManifest,
+		Config: ocispecv1.Descriptor{
+			MediaType: ocispecv1.MediaTypeImageConfig,
+			Digest:    digest.FromBytes(configData),
+			Size:      int64(len(configData)),
+		},
+	}
+	blobs[manifest.Config.Digest] = image.Config
+
+	for _, layer := range image.Layers {
+		desc, err := a.layerDescriptor(layer)
+		if err != nil {
+			return ocispecv1.Descriptor{}, nil, nil, err
+		}
+		blobs[desc.Digest] = layer
+		manifest.Layers = append(manifest.Layers, desc)
+	}
+
+	rawManifest, err := json.Marshal(manifest)
+	if err != nil {
+		return ocispecv1.Descriptor{}, nil, nil, fmt.Errorf("unable to marshal manifest: %w", err)
+	}
+	desc := ocispecv1.Descriptor{
+		MediaType: ocispecv1.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(rawManifest),
+		Size:      int64(len(rawManifest)),
+	}
+	return desc, rawManifest, blobs, nil
+}
+
+// layerDescriptor calculates the descriptor of a layer of a docker save tarball.
+// The media type is derived from the compression of the layer.
+func (a *imageArchive) layerDescriptor(name string) (ocispecv1.Descriptor, error) {
+	r, err := a.open(name)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to read layer: %w", err)
+	}
+	header := make([]byte, 10)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to read layer %s: %w", name, err)
+	}
+
+	var mediaType string
+	switch compression.DetectCompression(header[:n]) {
+	case compression.Uncompressed:
+		mediaType = ocispecv1.MediaTypeImageLayer
+	case compression.Gzip:
+		mediaType = ocispecv1.MediaTypeImageLayerGzip
+	case compression.Zstd:
+		mediaType = MediaTypeImageLayerZstd
+	default:
+		return ocispecv1.Descriptor{}, fmt.Errorf("unsupported compression of layer %s", name)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to read layer %s: %w", name, err)
+	}
+	dig, err := digest.FromReader(r)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to calculate digest of layer %s: %w", name, err)
+	}
+	return ocispecv1.Descriptor{
+		MediaType: mediaType,
+		Digest:    dig,
+		Size:      r.Size(),
+	}, nil
+}
@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	mock_ociclient "github.com/gardener/component-cli/ociclient/mock"
+)
+
+// archiveFile describes a file or symlink of a test tarball.
+type archiveFile struct {
+	name     string
+	data     []byte
+	linkname string
+}
+
+func createTarball(files ...archiveFile) *bytes.Reader {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		if len(f.linkname) != 0 {
+			Expect(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: f.name, Linkname: f.linkname})).To(Succeed())
+			continue
+		}
+		Expect(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: f.name, Size: int64(len(f.data)), Mode: 0644})).To(Succeed())
+		_, err := tw.Write(f.data)
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(tw.Close()).To(Succeed())
+	return bytes.NewReader(buf.Bytes())
+}
+
+func gzipData(data []byte) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write(data)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(gw.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+func readFromStore(opts []ociclient.PushOption, desc ocispecv1.Descriptor) []byte {
+	pushOpts := &ociclient.PushOptions{}
+	pushOpts.ApplyOptions(opts)
+	rc, err := pushOpts.Store.Get(desc)
+	Expect(err).ToNot(HaveOccurred())
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	Expect(err).ToNot(HaveOccurred())
+	return data
+}
+
+var _ = Describe("DockerArchive", func() {
+
+	var (
+		ctx        context.Context
+		mockCtrl   *gomock.Controller
+		mockClient *mock_ociclient.MockClient
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockClient = mock_ociclient.NewMockClient(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should convert the image of a docker save tarball to an oci manifest", func() {
+		config := []byte(`{"architecture":"amd64","os":"linux"}`)
+		layer1 := []byte("uncompressed layer")
+		layer2 := gzipData([]byte("compressed layer"))
+		manifestJSON, err := json.Marshal([]map[string]interface{}{
+			{
+				"Config":   "config.json",
+				"RepoTags": []string{"example.com/image:v1"},
+				"Layers":   []string{"a/layer.tar", "b/layer.tar", "c/layer.tar"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		archive := createTarball(
+			archiveFile{name: "config.json", data: config},
+			archiveFile{name: "a/layer.tar", data: layer1},
+			archiveFile{name: "b/layer.tar", data: layer2},
+			archiveFile{name: "c/layer.tar", linkname: "../a/layer.tar"},
+			archiveFile{name: "manifest.json", data: manifestJSON},
+		)
+
+		var (
+			pushedDesc     ocispecv1.Descriptor
+			pushedManifest ocispecv1.Manifest
+			pushedOpts     []ociclient.PushOption
+		)
+		mockClient.EXPECT().PushRawManifest(gomock.Any(), "example.com/target:v1", gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, ref string, desc ocispecv1.Descriptor, rawManifest []byte, opts ...ociclient.PushOption) error {
+				pushedDesc = desc
+				pushedOpts = opts
+				Expect(digest.FromBytes(rawManifest)).To(Equal(desc.Digest))
+				return json.Unmarshal(rawManifest, &pushedManifest)
+			})
+
+		desc, err := ociclient.PushDockerArchive(ctx, mockClient, archive, archive.Size(), "example.com/target:v1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(desc).To(Equal(pushedDesc))
+		Expect(desc.MediaType).To(Equal(ocispecv1.MediaTypeImageManifest))
+
+		Expect(pushedManifest.Config.MediaType).To(Equal(ocispecv1.MediaTypeImageConfig))
+		Expect(pushedManifest.Config.Digest).To(Equal(digest.FromBytes(config)))
+		Expect(pushedManifest.Layers).To(HaveLen(3))
+		Expect(pushedManifest.Layers[0].MediaType).To(Equal(ocispecv1.MediaTypeImageLayer))
+		Expect(pushedManifest.Layers[0].Digest).To(Equal(digest.FromBytes(layer1)))
+		Expect(pushedManifest.Layers[1].MediaType).To(Equal(ocispecv1.MediaTypeImageLayerGzip))
+		Expect(pushedManifest.Layers[1].Digest).To(Equal(digest.FromBytes(layer2)))
+		Expect(pushedManifest.Layers[2]).To(Equal(pushedManifest.Layers[0]))
+
+		Expect(readFromStore(pushedOpts, pushedManifest.Config)).To(Equal(config))
+		Expect(readFromStore(pushedOpts, pushedManifest.Layers[1])).To(Equal(layer2))
+	})
+
+	It("should push the image of an oci image layout as it is", func() {
+		config := []byte(`{"architecture":"amd64","os":"linux"}`)
+		layer := gzipData([]byte("layer"))
+		manifest := ocispecv1.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			Config: ocispecv1.Descriptor{
+				MediaType: ocispecv1.MediaTypeImageConfig,
+				Digest:    digest.FromBytes(config),
+				Size:      int64(len(config)),
+			},
+			Layers: []ocispecv1.Descriptor{
+				{
+					MediaType: ocispecv1.MediaTypeImageLayerGzip,
+					Digest:    digest.FromBytes(layer),
+					Size:      int64(len(layer)),
+				},
+			},
+		}
+		rawManifest, err := json.Marshal(manifest)
+		Expect(err).ToNot(HaveOccurred())
+		manifestDesc := ocispecv1.Descriptor{
+			MediaType: ocispecv1.MediaTypeImageManifest,
+			Digest:    digest.FromBytes(rawManifest),
+			Size:      int64(len(rawManifest)),
+		}
+		indexDesc := manifestDesc
+		indexDesc.Annotations = map[string]string{ocispecv1.AnnotationRefName: "v1"}
+		index, err := json.Marshal(ocispecv1.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			Manifests: []ocispecv1.Descriptor{indexDesc},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		archive := createTarball(
+			archiveFile{name: "oci-layout", data: []byte(`{"imageLayoutVersion":"1.0.0"}`)},
+			archiveFile{name: "index.json", data: index},
+			archiveFile{name: "blobs/sha256/" + manifestDesc.Digest.Encoded(), data: rawManifest},
+			archiveFile{name: "blobs/sha256/" + manifest.Config.Digest.Encoded(), data: config},
+			archiveFile{name: "blobs/sha256/" + manifest.Layers[0].Digest.Encoded(), data: layer},
+		)
+
+		var pushedOpts []ociclient.PushOption
+		mockClient.EXPECT().PushRawManifest(gomock.Any(), "example.com/target:v1", manifestDesc, rawManifest, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, ref string, desc ocispecv1.Descriptor, rawManifest []byte, opts ...ociclient.PushOption) error {
+				pushedOpts = opts
+				return nil
+			})
+
+		desc, err := ociclient.PushDockerArchive(ctx, mockClient, archive, archive.Size(), "example.com/target:v1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(desc).To(Equal(manifestDesc))
+		Expect(readFromStore(pushedOpts, manifest.Layers[0])).To(Equal(layer))
+	})
+
+	It("should fail if the docker save tarball contains multiple images", func() {
+		manifestJSON := []byte(`[{"Config":"a.json","Layers":[]},{"Config":"b.json","Layers":[]}]`)
+		archive := createTarball(archiveFile{name: "manifest.json", data: manifestJSON})
+
+		_, err := ociclient.PushDockerArchive(ctx, mockClient, archive, archive.Size(), "example.com/target:v1")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("exactly one image"))
+	})
+
+})
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// ErrTagImmutable is the error that all TagImmutableErrors match with errors.Is.
+var ErrTagImmutable = errors.New("tag is immutable")
+
+// TagImmutableError is returned if a manifest cannot be pushed because the target tag is immutable,
+// e.g. because of the tag immutability rules of ECR or Harbor.
+type TagImmutableError struct {
+	// Ref is the reference that has been pushed.
+	Ref string
+	// Err is the error that has been returned by the registry.
+	Err error
+}
+
+func (e *TagImmutableError) Error() string {
+	return fmt.Sprintf("unable to push %q: %s: %s", e.Ref, ErrTagImmutable.Error(), e.Err.Error())
+}
+
+// Unwrap returns the error of the registry.
+func (e *TagImmutableError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes the error match ErrTagImmutable.
+func (e *TagImmutableError) Is(target error) bool {
+	return target == ErrTagImmutable
+}
+
+// IsTagImmutableError checks whether the given error is or wraps a TagImmutableError.
+func IsTagImmutableError(err error) bool {
+	return errors.Is(err, ErrTagImmutable)
+}
+
+// immutableTagErrorMessages are the parts of the error responses of registries that reject the update of an immutable tag.
+var immutableTagErrorMessages = []string{
+	// ECR
+	"imagetagalreadyexistsexception",
+	"cannot be overwritten because the repository is immutable",
+	// Harbor
+	"configured as immutable",
+	// generic
+	"tag is immutable",
+	"immutable tag",
+}
+
+// registryTagImmutableError is the error response of a registry that rejected a manifest upload because of an immutable tag.
+type registryTagImmutableError struct {
+	status string
+	body   string
+}
+
+func (e *registryTagImmutableError) Error() string {
+	return fmt.Sprintf("%s: %s", e.status, e.body)
+}
+
+// asRegistryTagImmutableError returns the registry error if the error of a manifest push has been caused by an immutable tag.
+func asRegistryTagImmutableError(err error) (*registryTagImmutableError, bool) {
+	var registryErr *registryTagImmutableError
+	if errors.As(err, &registryErr) {
+		return registryErr, true
+	}
+	return nil, false
+}
+
+// immutableTagDetector is a http.RoundTripper that detects manifest uploads that are rejected because of an immutable tag.
+// The error response of the registry is returned as registryTagImmutableError as the response body is not
+// available in the errors of the containerd pusher.
+type immutableTagDetector struct {
+	next http.RoundTripper
+}
+
+func newImmutableTagDetector(next http.RoundTripper) http.RoundTripper {
+	return &immutableTagDetector{next: next}
+}
+
+func (d *immutableTagDetector) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := d.next.RoundTrip(req)
+	if err != nil || req.Method != http.MethodPut || !strings.Contains(req.URL.Path, "/manifests/") {
+		return resp, err
+	}
+	if resp.StatusCode < 400 || resp.StatusCode >= 500 || resp.StatusCode == http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read error response: %w", err)
+	}
+	msg := strings.ToLower(string(body))
+	for _, part := range immutableTagErrorMessages {
+		if strings.Contains(msg, part) {
+			return nil, &registryTagImmutableError{
+				status: resp.Status,
+				body:   strings.TrimSpace(string(body)),
+			}
+		}
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// WithImmutableTagFallback configures a manifest push to fall back to a push by digest if the target tag is immutable.
+// If an alternate tag pattern is defined, the manifest is additionally tagged with the expanded pattern.
+// The pattern may contain the placeholders "{tag}" (the original tag), "{digest}" (the encoded manifest digest)
+// and "{shortDigest}" (the first 12 characters of the encoded manifest digest), e.g. "{tag}-{shortDigest}".
+// The status of a push with fallback is PushStatusPushedByDigest.
+func WithImmutableTagFallback(alternateTagPattern string) WithImmutableTagFallbackOption {
+	return WithImmutableTagFallbackOption{
+		AlternateTagPattern: alternateTagPattern,
+	}
+}
+
+// WithImmutableTagFallbackOption configures a manifest push to fall back to a push by digest if the target tag is immutable.
+type WithImmutableTagFallbackOption struct {
+	AlternateTagPattern string
+}
+
+func (c WithImmutableTagFallbackOption) ApplyPushOption(options *PushOptions) {
+	options.ImmutableTagFallback = &c
+}
+
+// fallbackRefs returns the references the manifest is pushed to if the tag of the given reference is immutable.
+// The first reference is the digest reference, the optional second one the alternate tag.
+func (c WithImmutableTagFallbackOption) fallbackRefs(ref string, dgst digest.Digest) ([]string, error) {
+	refspec, err := oci.ParseRef(ref)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse ref: %w", err)
+	}
+	refs := []string{refspec.DigestRef(dgst)}
+	if len(c.AlternateTagPattern) == 0 {
+		return refs, nil
+	}
+
+	tag := ""
+	if refspec.Tag != nil {
+		tag = *refspec.Tag
+	}
+	shortDigest := dgst.Encoded()
+	if len(shortDigest) > 12 {
+		shortDigest = shortDigest[:12]
+	}
+	alternateTag := strings.NewReplacer(
+		"{tag}", tag,
+		"{digest}", dgst.Encoded(),
+		"{shortDigest}", shortDigest,
+	).Replace(c.AlternateTagPattern)
+	return append(refs, refspec.TagRef(alternateTag)), nil
+}
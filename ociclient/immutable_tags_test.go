@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/credentials"
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+var _ = Describe("immutable tags", func() {
+
+	var (
+		server        *httptest.Server
+		host          string
+		mux           sync.Mutex
+		pushedRefs    []string
+		manifestBytes []byte
+		manifestDesc  ocispecv1.Descriptor
+	)
+
+	BeforeEach(func() {
+		pushedRefs = []string{}
+		manifestBytes = []byte(`{"schemaVersion":2,"config":{},"layers":[]}`)
+		manifestDesc = ocispecv1.Descriptor{
+			MediaType: ocispecv1.MediaTypeImageManifest,
+			Digest:    digest.FromBytes(manifestBytes),
+			Size:      int64(len(manifestBytes)),
+		}
+
+		// the registry rejects updates of the "1.0.0" tag like ECR does for immutable repositories.
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch {
+			case req.URL.Path == "/v2/":
+				w.WriteHeader(http.StatusOK)
+			case strings.Contains(req.URL.Path, "/blobs/") && req.Method == http.MethodHead:
+				w.Header().Set("Content-Length", "2")
+				w.WriteHeader(http.StatusOK)
+			case strings.Contains(req.URL.Path, "/manifests/") && req.Method == http.MethodHead:
+				w.WriteHeader(http.StatusNotFound)
+			case strings.Contains(req.URL.Path, "/manifests/") && req.Method == http.MethodPut:
+				_, _ = ioutil.ReadAll(req.Body)
+				if strings.HasSuffix(req.URL.Path, "/manifests/1.0.0") {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"errors":[{"code":"TAG_INVALID","message":"The image tag '1.0.0' already exists in the 'myimage' repository and cannot be overwritten because the repository is immutable."}]}`))
+					return
+				}
+				mux.Lock()
+				pushedRefs = append(pushedRefs, req.URL.Path)
+				mux.Unlock()
+				w.Header().Set(ociclient.HeaderDockerContentDigest, manifestDesc.Digest.String())
+				w.WriteHeader(http.StatusCreated)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		hostUrl, err := url.Parse(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		host = hostUrl.Host
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func() ociclient.Client {
+		client, err := ociclient.NewClient(logr.Discard(),
+			ociclient.AllowPlainHttp(true),
+			ociclient.WithKeyring(credentials.New()))
+		Expect(err).ToNot(HaveOccurred())
+		return client
+	}
+
+	It("should return a TagImmutableError if the tag is immutable", func() {
+		ref := host + "/myproject/myimage:1.0.0"
+		err := newClient().PushRawManifest(context.TODO(), ref, manifestDesc, manifestBytes)
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, ociclient.ErrTagImmutable)).To(BeTrue())
+		tagErr := &ociclient.TagImmutableError{}
+		Expect(errors.As(err, &tagErr)).To(BeTrue())
+		Expect(tagErr.Ref).To(Equal(ref))
+		Expect(pushedRefs).To(BeEmpty())
+	})
+
+	It("should push by digest and with the alternate tag if the tag is immutable", func() {
+		var status ociclient.PushStatus
+		err := newClient().PushRawManifest(context.TODO(), host+"/myproject/myimage:1.0.0", manifestDesc, manifestBytes,
+			ociclient.WithImmutableTagFallback("{tag}-{shortDigest}"),
+			ociclient.WithPushStatus(&status))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status).To(Equal(ociclient.PushStatusPushedByDigest))
+		Expect(pushedRefs).To(Equal([]string{
+			"/v2/myproject/myimage/manifests/" + manifestDesc.Digest.String(),
+			fmt.Sprintf("/v2/myproject/myimage/manifests/1.0.0-%s", manifestDesc.Digest.Encoded()[:12]),
+		}))
+	})
+
+	It("should push oci artifacts by digest and with the alternate tag if the tag is immutable", func() {
+		manifest := &ocispecv1.Manifest{}
+		Expect(json.Unmarshal(manifestBytes, manifest)).To(Succeed())
+		artifact, err := oci.NewManifestArtifact(&oci.Manifest{Data: manifest})
+		Expect(err).ToNot(HaveOccurred())
+		manifestDesc, err = ociclient.CreateDescriptorFromManifest(manifest)
+		Expect(err).ToNot(HaveOccurred())
+
+		var status ociclient.PushStatus
+		err = newClient().PushOCIArtifact(context.TODO(), host+"/myproject/myimage:1.0.0", artifact,
+			ociclient.WithImmutableTagFallback("{tag}-{shortDigest}"),
+			ociclient.WithPushStatus(&status))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status).To(Equal(ociclient.PushStatusPushedByDigest))
+		Expect(pushedRefs).To(Equal([]string{
+			"/v2/myproject/myimage/manifests/" + manifestDesc.Digest.String(),
+			fmt.Sprintf("/v2/myproject/myimage/manifests/1.0.0-%s", manifestDesc.Digest.Encoded()[:12]),
+		}))
+	})
+
+	It("should not treat other push errors as immutable tag errors", func() {
+		err := newClient().PushRawManifest(context.TODO(), host+"/myproject/myimage:1.0.0", manifestDesc, []byte("{}"))
+		Expect(ociclient.IsTagImmutableError(err)).To(BeFalse())
+	})
+
+})
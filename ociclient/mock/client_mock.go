@@ -99,6 +99,20 @@ func (mr *MockClientMockRecorder) GetRawManifest(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRawManifest", reflect.TypeOf((*MockClient)(nil).GetRawManifest), arg0, arg1)
 }
 
+// MountBlob mocks base method.
+func (m *MockClient) MountBlob(arg0 context.Context, arg1, arg2 string, arg3 v1.Descriptor) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MountBlob", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MountBlob indicates an expected call of MountBlob.
+func (mr *MockClientMockRecorder) MountBlob(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MountBlob", reflect.TypeOf((*MockClient)(nil).MountBlob), arg0, arg1, arg2, arg3)
+}
+
 // PushBlob mocks base method.
 func (m *MockClient) PushBlob(arg0 context.Context, arg1 string, arg2 v1.Descriptor, arg3 ...ociclient.PushOption) error {
 	m.ctrl.T.Helper()
@@ -118,6 +132,20 @@ func (mr *MockClientMockRecorder) PushBlob(arg0, arg1, arg2 interface{}, arg3 ..
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushBlob", reflect.TypeOf((*MockClient)(nil).PushBlob), varargs...)
 }
 
+// PushBlobFromReader mocks base method.
+func (m *MockClient) PushBlobFromReader(arg0 context.Context, arg1 string, arg2 v1.Descriptor, arg3 io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PushBlobFromReader", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PushBlobFromReader indicates an expected call of PushBlobFromReader.
+func (mr *MockClientMockRecorder) PushBlobFromReader(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushBlobFromReader", reflect.TypeOf((*MockClient)(nil).PushBlobFromReader), arg0, arg1, arg2, arg3)
+}
+
 // PushManifest mocks base method.
 func (m *MockClient) PushManifest(arg0 context.Context, arg1 string, arg2 *v1.Manifest, arg3 ...ociclient.PushOption) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"sort"
+
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Closure is the set of all descriptors that are referenced by an artifact
+// including the descriptor of the artifact itself (index -> manifests -> config and layers).
+// Descriptors are identified by their digest, so blobs that are referenced multiple times are only contained once.
+type Closure struct {
+	descriptors map[digest.Digest]ocispecv1.Descriptor
+}
+
+// ClosureDiff describes the difference between two closures.
+type ClosureDiff struct {
+	// Added contains all descriptors that are only part of the second closure.
+	Added []ocispecv1.Descriptor
+	// Removed contains all descriptors that are only part of the first closure.
+	Removed []ocispecv1.Descriptor
+}
+
+// NewClosure creates a new closure with the given descriptors.
+func NewClosure(descs ...ocispecv1.Descriptor) *Closure {
+	c := &Closure{
+		descriptors: map[digest.Digest]ocispecv1.Descriptor{},
+	}
+	for _, desc := range descs {
+		c.Add(desc)
+	}
+	return c
+}
+
+// Add adds a descriptor to the closure.
+// It returns false if a descriptor with the same digest is already part of the closure.
+func (c *Closure) Add(desc ocispecv1.Descriptor) bool {
+	if _, ok := c.descriptors[desc.Digest]; ok {
+		return false
+	}
+	c.descriptors[desc.Digest] = desc
+	return true
+}
+
+// Contains returns whether a descriptor with the given digest is part of the closure.
+func (c *Closure) Contains(dgst digest.Digest) bool {
+	_, ok := c.descriptors[dgst]
+	return ok
+}
+
+// Len returns the number of descriptors in the closure.
+func (c *Closure) Len() int {
+	return len(c.descriptors)
+}
+
+// Size returns the accumulated size of all descriptors in the closure.
+func (c *Closure) Size() int64 {
+	var size int64
+	for _, desc := range c.descriptors {
+		size += desc.Size
+	}
+	return size
+}
+
+// Descriptors returns all descriptors of the closure sorted by their digest.
+func (c *Closure) Descriptors() []ocispecv1.Descriptor {
+	descs := make([]ocispecv1.Descriptor, 0, len(c.descriptors))
+	for _, desc := range c.descriptors {
+		descs = append(descs, desc)
+	}
+	sortDescriptors(descs)
+	return descs
+}
+
+// Diff returns the descriptors that have been added and removed in the other closure compared to this closure.
+func (c *Closure) Diff(other *Closure) ClosureDiff {
+	diff := ClosureDiff{
+		Added:   []ocispecv1.Descriptor{},
+		Removed: []ocispecv1.Descriptor{},
+	}
+	for dgst, desc := range other.descriptors {
+		if !c.Contains(dgst) {
+			diff.Added = append(diff.Added, desc)
+		}
+	}
+	for dgst, desc := range c.descriptors {
+		if !other.Contains(dgst) {
+			diff.Removed = append(diff.Removed, desc)
+		}
+	}
+	sortDescriptors(diff.Added)
+	sortDescriptors(diff.Removed)
+	return diff
+}
+
+// IsEmpty returns whether both closures contained the same descriptors.
+func (d ClosureDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+func sortDescriptors(descs []ocispecv1.Descriptor) {
+	sort.Slice(descs, func(i, j int) bool {
+		return descs[i].Digest < descs[j].Digest
+	})
+}
@@ -11,6 +11,8 @@ import (
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/gardener/component-cli/ociclient/oci"
 )
@@ -47,4 +49,34 @@ var _ = Describe("ref", func() {
 		Entry("with protocol", "https://example.com/test:0.0.1", "example.com", "test", "0.0.1", ""),
 	)
 
+	It("should reconstruct tag and digest references in the repository of a reference", func() {
+		parsed, err := oci.ParseRef("example.com/a/test:0.0.1")
+		Expect(err).ToNot(HaveOccurred())
+		dgst := digest.FromString("manifest")
+		Expect(parsed.TagRef("0.0.2")).To(Equal("example.com/a/test:0.0.2"))
+		Expect(parsed.DigestRef(dgst)).To(Equal("example.com/a/test@" + dgst.String()))
+		Expect(parsed.String()).To(Equal("example.com/a/test:0.0.1"))
+	})
+
+})
+
+var _ = Describe("closure", func() {
+
+	It("should diff two closures", func() {
+		config := ocispecv1.Descriptor{Digest: digest.FromString("config"), Size: 6}
+		layer1 := ocispecv1.Descriptor{Digest: digest.FromString("layer-1"), Size: 7}
+		layer2 := ocispecv1.Descriptor{Digest: digest.FromString("layer-2"), Size: 7}
+
+		a := oci.NewClosure(config, layer1, layer1)
+		Expect(a.Len()).To(Equal(2))
+		Expect(a.Size()).To(Equal(int64(13)))
+
+		b := oci.NewClosure(config, layer2)
+		diff := a.Diff(b)
+		Expect(diff.IsEmpty()).To(BeFalse())
+		Expect(diff.Added).To(ConsistOf(layer2))
+		Expect(diff.Removed).To(ConsistOf(layer1))
+		Expect(a.Diff(oci.NewClosure(layer1, config)).IsEmpty()).To(BeTrue())
+	})
+
 })
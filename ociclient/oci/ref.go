@@ -70,12 +70,22 @@ func (r *RefSpec) Name() string {
 	return path.Join(r.Host, r.Repository)
 }
 
+// TagRef returns the reference to the given tag in the repository of the reference.
+func (r *RefSpec) TagRef(tag string) string {
+	return fmt.Sprintf("%s:%s", r.Name(), tag)
+}
+
+// DigestRef returns the reference to the given digest in the repository of the reference.
+func (r *RefSpec) DigestRef(dgst digest.Digest) string {
+	return fmt.Sprintf("%s@%s", r.Name(), dgst)
+}
+
 func (r RefSpec) String() string {
 	if r.Tag != nil {
-		return fmt.Sprintf("%s:%s", r.Name(), *r.Tag)
+		return r.TagRef(*r.Tag)
 	}
 	if r.Digest != nil {
-		return fmt.Sprintf("%s@%s", r.Name(), r.Digest.String())
+		return r.DigestRef(*r.Digest)
 	}
 	return ""
 }
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/containerd/containerd/images"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ExportOCILayout writes the artifact of the given reference including all its manifests and blobs
+// as oci image layout tarball to the writer and returns the descriptor of the exported artifact.
+// The tarball can be pushed again with PushDockerArchive.
+func ExportOCILayout(ctx context.Context, client Client, ref string, w io.Writer) (ocispecv1.Descriptor, error) {
+	desc, _, err := client.GetRawManifest(ctx, ref)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to get manifest for %q: %w", ref, err)
+	}
+	closure, err := GetClosure(ctx, client, ref)
+	if err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+
+	tw := tar.NewWriter(w)
+	writeFile := func(name string, size int64, write func(w io.Writer) error) error {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: size, Mode: 0644}); err != nil {
+			return fmt.Errorf("unable to write header for %s: %w", name, err)
+		}
+		if err := write(tw); err != nil {
+			return fmt.Errorf("unable to write %s: %w", name, err)
+		}
+		return nil
+	}
+	writeData := func(name string, data []byte) error {
+		return writeFile(name, int64(len(data)), func(w io.Writer) error {
+			_, err := io.Copy(w, bytes.NewReader(data))
+			return err
+		})
+	}
+
+	layout, err := json.Marshal(ocispecv1.ImageLayout{Version: ocispecv1.ImageLayoutVersion})
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to marshal oci layout: %w", err)
+	}
+	if err := writeData(ocispecv1.ImageLayoutFile, layout); err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	desc = ocispecv1.Descriptor{
+		MediaType: desc.MediaType,
+		Digest:    desc.Digest,
+		Size:      desc.Size,
+	}
+	// the reference is annotated so that the layout can be used to resolve it in offline mode.
+	indexDesc := desc
+	indexDesc.Annotations = map[string]string{
+		ocispecv1.AnnotationRefName: ref,
+	}
+	index, err := json.Marshal(ocispecv1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Manifests: []ocispecv1.Descriptor{indexDesc},
+	})
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to marshal index: %w", err)
+	}
+	if err := writeData(OCILayoutIndexFile, index); err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+
+	for _, blobDesc := range closure.Descriptors() {
+		blobPath := path.Join("blobs", blobDesc.Digest.Algorithm().String(), blobDesc.Digest.Encoded())
+		if isManifestMediaType(blobDesc.MediaType) {
+			manifestRef, err := DigestRef(ref, blobDesc.Digest)
+			if err != nil {
+				return ocispecv1.Descriptor{}, fmt.Errorf("unable to parse ref: %w", err)
+			}
+			_, rawManifest, err := client.GetRawManifest(ctx, manifestRef)
+			if err != nil {
+				return ocispecv1.Descriptor{}, fmt.Errorf("unable to get manifest %s: %w", blobDesc.Digest, err)
+			}
+			if err := writeData(blobPath, rawManifest); err != nil {
+				return ocispecv1.Descriptor{}, err
+			}
+			continue
+		}
+		if err := writeFile(blobPath, blobDesc.Size, func(w io.Writer) error {
+			return client.Fetch(ctx, ref, blobDesc, w)
+		}); err != nil {
+			return ocispecv1.Descriptor{}, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to close tar writer: %w", err)
+	}
+	return desc, nil
+}
+
+// WriteOCILayout writes an oci image layout tarball that contains the given manifest with its config and layers
+// and returns the descriptor of the manifest.
+// The blobs of the config and the layers are taken from the given blobs by digest.
+func WriteOCILayout(w io.Writer, manifest ocispecv1.Manifest, blobs map[digest.Digest][]byte) (ocispecv1.Descriptor, error) {
+	rawManifest, err := json.Marshal(manifest)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to marshal manifest: %w", err)
+	}
+	desc := ocispecv1.Descriptor{
+		MediaType: ocispecv1.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(rawManifest),
+		Size:      int64(len(rawManifest)),
+	}
+
+	tw := tar.NewWriter(w)
+	writeData := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(data)), Mode: 0644}); err != nil {
+			return fmt.Errorf("unable to write header for %s: %w", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("unable to write %s: %w", name, err)
+		}
+		return nil
+	}
+	blobPath := func(dig digest.Digest) string {
+		return path.Join("blobs", dig.Algorithm().String(), dig.Encoded())
+	}
+
+	layout, err := json.Marshal(ocispecv1.ImageLayout{Version: ocispecv1.ImageLayoutVersion})
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to marshal oci layout: %w", err)
+	}
+	if err := writeData(ocispecv1.ImageLayoutFile, layout); err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	index, err := json.Marshal(ocispecv1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Manifests: []ocispecv1.Descriptor{desc},
+	})
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to marshal index: %w", err)
+	}
+	if err := writeData(OCILayoutIndexFile, index); err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	if err := writeData(blobPath(desc.Digest), rawManifest); err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+
+	written := map[digest.Digest]bool{}
+	for _, blobDesc := range append([]ocispecv1.Descriptor{manifest.Config}, manifest.Layers...) {
+		if written[blobDesc.Digest] {
+			continue
+		}
+		data, ok := blobs[blobDesc.Digest]
+		if !ok {
+			return ocispecv1.Descriptor{}, fmt.Errorf("blob %s is missing", blobDesc.Digest)
+		}
+		if err := writeData(blobPath(blobDesc.Digest), data); err != nil {
+			return ocispecv1.Descriptor{}, err
+		}
+		written[blobDesc.Digest] = true
+	}
+	if err := tw.Close(); err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to close tar writer: %w", err)
+	}
+	return desc, nil
+}
+
+func isManifestMediaType(mediaType string) bool {
+	switch mediaType {
+	case ocispecv1.MediaTypeImageManifest, images.MediaTypeDockerSchema2Manifest:
+		return true
+	}
+	return IsMultiArchImage(mediaType)
+}
@@ -0,0 +1,252 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// ErrOffline is the error that all OfflineErrors match with errors.Is.
+var ErrOffline = errors.New("network access is disabled in offline mode")
+
+// OfflineError is returned by a client in offline mode if a request can neither be served
+// from the cache nor from the configured oci layouts.
+type OfflineError struct {
+	// Ref is the reference that has been requested.
+	Ref string
+}
+
+func (e *OfflineError) Error() string {
+	return fmt.Sprintf("%q is not available offline: %s", e.Ref, ErrOffline.Error())
+}
+
+// Is makes the error match ErrOffline.
+func (e *OfflineError) Is(target error) bool {
+	return target == ErrOffline
+}
+
+// IsOfflineError checks whether the given error is or wraps an OfflineError.
+func IsOfflineError(err error) bool {
+	return errors.Is(err, ErrOffline)
+}
+
+// WithOfflineMode disables all network access of the client.
+// Manifests and blobs are served exclusively from the cache and the given oci image layouts.
+// Tagged references can only be resolved with the "org.opencontainers.image.ref.name" annotation of the layouts,
+// digest references are also resolved from the cache.
+// All other requests fail with an OfflineError.
+func WithOfflineMode(layouts ...*OCILayout) WithOfflineModeOption {
+	return WithOfflineModeOption{
+		Layouts: layouts,
+	}
+}
+
+// WithOfflineModeOption enables the offline mode of the client.
+type WithOfflineModeOption struct {
+	Layouts []*OCILayout
+}
+
+func (c WithOfflineModeOption) ApplyOption(options *Options) {
+	options.Offline = true
+	options.OfflineLayouts = append(options.OfflineLayouts, c.Layouts...)
+}
+
+// OCILayout is a read-only oci image layout that is either a directory or a tarball.
+type OCILayout struct {
+	index ocispecv1.Index
+	open  func(name string) (io.ReadCloser, error)
+}
+
+// OpenOCILayout opens the oci image layout directory or tarball at the given path.
+// Tarballs are kept open as long as the layout is used.
+func OpenOCILayout(fs vfs.FileSystem, layoutPath string) (*OCILayout, error) {
+	info, err := fs.Stat(layoutPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get info for oci layout %q: %w", layoutPath, err)
+	}
+	layout := &OCILayout{}
+	if info.IsDir() {
+		layout.open = func(name string) (io.ReadCloser, error) {
+			return fs.Open(path.Join(layoutPath, name))
+		}
+	} else {
+		file, err := fs.Open(layoutPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open oci layout %q: %w", layoutPath, err)
+		}
+		archive, err := readImageArchive(file, info.Size())
+		if err != nil {
+			return nil, fmt.Errorf("unable to read oci layout %q: %w", layoutPath, err)
+		}
+		layout.open = func(name string) (io.ReadCloser, error) {
+			r, err := archive.open(name)
+			if err != nil {
+				return nil, err
+			}
+			return ioutil.NopCloser(r), nil
+		}
+	}
+
+	indexReader, err := layout.open(OCILayoutIndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s of oci layout %q: %w", OCILayoutIndexFile, layoutPath, err)
+	}
+	defer indexReader.Close()
+	if err := json.NewDecoder(indexReader).Decode(&layout.index); err != nil {
+		return nil, fmt.Errorf("unable to decode %s of oci layout %q: %w", OCILayoutIndexFile, layoutPath, err)
+	}
+	return layout, nil
+}
+
+// Resolve returns the descriptor of the manifest of the layout that matches the reference.
+// Tagged references are matched against the ref name annotation of the manifests,
+// which is either the complete reference or only the tag.
+func (l *OCILayout) Resolve(ref string) (ocispecv1.Descriptor, bool) {
+	refspec, err := oci.ParseRef(ref)
+	if err != nil {
+		return ocispecv1.Descriptor{}, false
+	}
+	for _, desc := range l.index.Manifests {
+		if refspec.Digest != nil {
+			if desc.Digest == *refspec.Digest {
+				return desc, true
+			}
+			continue
+		}
+		refName := desc.Annotations[ocispecv1.AnnotationRefName]
+		if len(refName) == 0 {
+			continue
+		}
+		if refName == ref || refName == refspec.String() || (refspec.Tag != nil && refName == *refspec.Tag) {
+			return desc, true
+		}
+	}
+	return ocispecv1.Descriptor{}, false
+}
+
+// Fetch returns a reader for the blob of the descriptor.
+func (l *OCILayout) Fetch(desc ocispecv1.Descriptor) (io.ReadCloser, error) {
+	return l.open(path.Join("blobs", desc.Digest.Algorithm().String(), desc.Digest.Encoded()))
+}
+
+// offlineResolver resolves and fetches references from oci layouts and the cache.
+type offlineResolver struct {
+	layouts []*OCILayout
+	cache   cache.Cache
+}
+
+var _ remotes.Resolver = &offlineResolver{}
+
+func (r *offlineResolver) Resolve(ctx context.Context, ref string) (string, ocispecv1.Descriptor, error) {
+	for _, layout := range r.layouts {
+		if desc, ok := layout.Resolve(ref); ok {
+			return ref, desc, nil
+		}
+	}
+
+	refspec, err := oci.ParseRef(ref)
+	if err != nil {
+		return "", ocispecv1.Descriptor{}, fmt.Errorf("unable to parse ref: %w", err)
+	}
+	if refspec.Digest == nil {
+		return "", ocispecv1.Descriptor{}, &OfflineError{Ref: ref}
+	}
+	// cached blobs can only be read with their size, so that the cache has to be able to report it.
+	statCache, ok := r.cache.(cache.StatInterface)
+	if !ok {
+		return "", ocispecv1.Descriptor{}, &OfflineError{Ref: ref}
+	}
+	size, err := statCache.Stat(*refspec.Digest)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return "", ocispecv1.Descriptor{}, &OfflineError{Ref: ref}
+		}
+		return "", ocispecv1.Descriptor{}, err
+	}
+	desc := ocispecv1.Descriptor{
+		Digest: *refspec.Digest,
+		Size:   size,
+	}
+	reader, err := r.cache.Get(desc)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return "", ocispecv1.Descriptor{}, &OfflineError{Ref: ref}
+		}
+		return "", ocispecv1.Descriptor{}, err
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", ocispecv1.Descriptor{}, fmt.Errorf("unable to read cached manifest %s: %w", desc.Digest, err)
+	}
+	// the media type of cached manifests is not known, so that it is read from the manifest itself.
+	desc.MediaType = manifestMediaType(data)
+	return ref, desc, nil
+}
+
+func (r *offlineResolver) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	return remotes.FetcherFunc(func(ctx context.Context, desc ocispecv1.Descriptor) (io.ReadCloser, error) {
+		for _, layout := range r.layouts {
+			if reader, err := layout.Fetch(desc); err == nil {
+				return reader, nil
+			}
+		}
+		if r.cache != nil {
+			reader, err := r.cache.Get(desc)
+			if err == nil {
+				return reader, nil
+			}
+			if !errors.Is(err, cache.ErrNotFound) {
+				return nil, err
+			}
+		}
+		return nil, &OfflineError{Ref: fmt.Sprintf("%s@%s", ref, desc.Digest)}
+	}), nil
+}
+
+func (r *offlineResolver) Pusher(ctx context.Context, ref string) (remotes.Pusher, error) {
+	return nil, &OfflineError{Ref: ref}
+}
+
+// manifestMediaType returns the media type of a raw manifest or index.
+// Manifests without media type are detected by their content.
+func manifestMediaType(data []byte) string {
+	var manifest struct {
+		MediaType string            `json:"mediaType"`
+		Manifests []json.RawMessage `json:"manifests"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return ""
+	}
+	if len(manifest.MediaType) != 0 {
+		return manifest.MediaType
+	}
+	if manifest.Manifests != nil {
+		return ocispecv1.MediaTypeImageIndex
+	}
+	return ocispecv1.MediaTypeImageManifest
+}
+
+// offlineTransport rejects all requests.
+// It guards against network access of code paths that do not use the offline resolver.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, &OfflineError{Ref: req.URL.String()}
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
+)
+
+var _ = Describe("offline mode", func() {
+
+	var (
+		fs        vfs.FileSystem
+		layer     []byte
+		layerDesc ocispecv1.Descriptor
+		manifest  []byte
+		manDesc   ocispecv1.Descriptor
+	)
+
+	writeBlob := func(data []byte) ocispecv1.Descriptor {
+		dgst := digest.FromBytes(data)
+		blobPath := path.Join("/layout/blobs", dgst.Algorithm().String(), dgst.Encoded())
+		Expect(fs.MkdirAll(path.Dir(blobPath), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, blobPath, data, os.ModePerm)).To(Succeed())
+		return ocispecv1.Descriptor{Digest: dgst, Size: int64(len(data))}
+	}
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		layer = []byte("layer-data")
+		layerDesc = writeBlob(layer)
+		layerDesc.MediaType = "text/plain"
+
+		var err error
+		manifest, err = json.Marshal(ocispecv1.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispecv1.MediaTypeImageManifest,
+			Config:    writeBlob([]byte("{}")),
+			Layers:    []ocispecv1.Descriptor{layerDesc},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		manDesc = writeBlob(manifest)
+		manDesc.MediaType = ocispecv1.MediaTypeImageManifest
+
+		indexDesc := manDesc
+		indexDesc.Annotations = map[string]string{ocispecv1.AnnotationRefName: "example.com/repo:v1"}
+		index, err := json.Marshal(ocispecv1.Index{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			Manifests: []ocispecv1.Descriptor{indexDesc},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(fs, "/layout/"+ociclient.OCILayoutIndexFile, index, os.ModePerm)).To(Succeed())
+	})
+
+	It("should serve tagged references from an oci layout", func() {
+		layout, err := ociclient.OpenOCILayout(fs, "/layout")
+		Expect(err).ToNot(HaveOccurred())
+		offlineClient, err := ociclient.NewClient(logr.Discard(),
+			ociclient.WithCache(cache.NewInMemoryCache()),
+			ociclient.WithOfflineMode(layout))
+		Expect(err).ToNot(HaveOccurred())
+
+		desc, rawManifest, err := offlineClient.GetRawManifest(context.TODO(), "example.com/repo:v1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(desc.Digest).To(Equal(manDesc.Digest))
+		Expect(rawManifest).To(Equal(manifest))
+
+		var buf bytes.Buffer
+		Expect(offlineClient.Fetch(context.TODO(), "example.com/repo:v1", layerDesc, &buf)).To(Succeed())
+		Expect(buf.Bytes()).To(Equal(layer))
+	})
+
+	It("should serve digest references from the cache", func() {
+		ociCache := cache.NewInMemoryCache()
+		Expect(ociCache.Add(manDesc, ioutil.NopCloser(bytes.NewReader(manifest)))).To(Succeed())
+		offlineClient, err := ociclient.NewClient(logr.Discard(),
+			ociclient.WithCache(ociCache),
+			ociclient.WithOfflineMode())
+		Expect(err).ToNot(HaveOccurred())
+
+		m, err := offlineClient.GetManifest(context.TODO(), "example.com/repo@"+manDesc.Digest.String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(m.Layers).To(ConsistOf(layerDesc))
+	})
+
+	It("should return an offline error for all requests that require network access", func() {
+		offlineClient, err := ociclient.NewClient(logr.Discard(),
+			ociclient.WithCache(cache.NewInMemoryCache()),
+			ociclient.WithOfflineMode())
+		Expect(err).ToNot(HaveOccurred())
+
+		_, _, err = offlineClient.GetRawManifest(context.TODO(), "example.com/repo:v1")
+		Expect(err).To(MatchError(ociclient.ErrOffline))
+		_, err = offlineClient.ListTags(context.TODO(), "example.com/repo")
+		Expect(ociclient.IsOfflineError(err)).To(BeTrue())
+		err = offlineClient.PushRawManifest(context.TODO(), "example.com/repo:v1", manDesc, manifest)
+		Expect(ociclient.IsOfflineError(err)).To(BeTrue())
+	})
+})
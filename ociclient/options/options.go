@@ -5,18 +5,21 @@
 package options
 
 import (
-	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
+	"time"
 
 	cdoci "github.com/gardener/component-spec/bindings-go/oci"
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
 
 	"github.com/gardener/component-cli/ociclient"
 	"github.com/gardener/component-cli/ociclient/cache"
 	"github.com/gardener/component-cli/ociclient/credentials"
+	"github.com/gardener/component-cli/ociclient/credentials/cloud"
 	"github.com/gardener/component-cli/ociclient/credentials/secretserver"
 )
 
@@ -26,12 +29,65 @@ type Options struct {
 	AllowPlainHttp bool
 	// SkipTLSVerify specifies if the server's certificate should be checked for validity.
 	SkipTLSVerify bool
+	// CAFile is the path to a pem encoded ca bundle that is trusted in addition to the system roots.
+	CAFile string
+	// ClientCertFile is the path to a pem encoded client certificate that is presented to registries.
+	ClientCertFile string
+	// ClientKeyFile is the path to the pem encoded private key of the client certificate.
+	ClientKeyFile string
+	// TLSConfigPath is the path to a yaml file that maps registry hosts to their tls configuration.
+	// The configuration of a registry replaces the tls flags for that registry.
+	TLSConfigPath string
 	// CacheDir defines the oci cache directory
 	CacheDir string
 	// RegistryConfigPath defines a path to the dockerconfig.json with the oci registry authentication.
 	RegistryConfigPath string
 	// ConcourseConfigPath is the path to the local concourse config file.
 	ConcourseConfigPath string
+	// RegistryAuthProviders are registries whose credentials are obtained from their cloud provider.
+	// Every entry is of the form "<provider>=<registry>" (see cloud.ParseRegistryProviders).
+	RegistryAuthProviders []string
+	// MaxRequestsPerSecond limits the number of requests per second that are sent to oci registries.
+	MaxRequestsPerSecond float64
+	// CacheMaxSize is the max size of the oci cache.
+	// The least valuable blobs are garbage collected when the size is reached.
+	// See the kubernetes quantity docs for detailed description of the format.
+	CacheMaxSize string
+	// CacheMaxAge is the duration after which cached blobs that have not been accessed are garbage collected.
+	CacheMaxAge time.Duration
+	// CacheCompression stores the cached blobs compressed with zstd to reduce the disk usage of the oci cache.
+	CacheCompression bool
+	// PinFile is the path to a json file that maps tagged references to the digests they are expected to resolve to.
+	PinFile string
+	// TrustOnFirstUse pins tagged references that are not yet pinned in the pin file to the digest they first resolve to.
+	TrustOnFirstUse bool
+	// StrictConformance disables all registry specific workarounds and reports violations of the oci distribution spec as warnings.
+	StrictConformance bool
+	// Offline disables all network access so that manifests and blobs are served exclusively from the cache and the OfflineLayouts.
+	Offline bool
+	// OfflineLayouts are paths to oci image layout directories or tarballs that are used to resolve references in offline mode.
+	OfflineLayouts []string
+	// Timeout is the timeout of establishing the connection to an oci registry and of waiting for the response headers.
+	// The reading of the response body is not limited. The global timeout is used if not set.
+	Timeout time.Duration
+	// Retries is the maximal number of retries of a request that failed with a transient error.
+	// The global number of retries is used if not set.
+	Retries int
+}
+
+// globalOptions contains the registry options that are configured by the global flags.
+var globalOptions = struct {
+	Timeout time.Duration
+	Retries int
+}{}
+
+// InitGlobalFlags adds the global registry flags that apply to all commands that access oci registries.
+func InitGlobalFlags(fs *pflag.FlagSet) {
+	if fs == nil {
+		fs = pflag.CommandLine
+	}
+	fs.DurationVar(&globalOptions.Timeout, "timeout", 0, "timeout of connecting to an oci registry and of waiting for its response headers (e.g. 30s). The download of the response body is not limited. Requests do not time out if set to 0")
+	fs.IntVar(&globalOptions.Retries, "retries", 0, "maximum number of retries of a request to an oci registry that failed with a transient error (e.g. 429 or 5xx)")
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
@@ -41,13 +97,32 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 
 	fs.BoolVar(&o.AllowPlainHttp, "allow-plain-http", false, "allows the fallback to http if the oci registry does not support https")
 	fs.BoolVar(&o.SkipTLSVerify, "insecure-skip-tls-verify", false, "If true, the server's certificate will not be checked for validity. This will make your HTTPS connections insecure")
+	fs.StringVar(&o.CAFile, "registry-ca-file", "", "path to a pem encoded ca bundle that is trusted in addition to the system roots when connecting to oci registries")
+	fs.StringVar(&o.ClientCertFile, "registry-client-cert", "", "path to a pem encoded client certificate that is presented to oci registries (mTLS)")
+	fs.StringVar(&o.ClientKeyFile, "registry-client-key", "", "path to the pem encoded private key of the registry client certificate")
+	fs.StringVar(&o.TLSConfigPath, "registry-tls-config", "", "path to a yaml file that maps registry hosts to their tls configuration (caFile, certFile, keyFile, insecureSkipVerify). The configuration of a registry replaces the tls flags for that registry")
 	fs.StringVar(&o.RegistryConfigPath, "registry-config", "", "path to the dockerconfig.json with the oci registry authentication information")
 	fs.StringVar(&o.ConcourseConfigPath, "cc-config", "", "path to the local concourse config file")
+	fs.StringArrayVar(&o.RegistryAuthProviders, "registry-auth-provider", nil, "obtain the credentials of a registry from the cloud environment without docker login. Must be of the form <provider>=<registry> with provider aws (ECR), gcp (GCR, Artifact Registry) or azure (ACR), e.g. aws=123456789012.dkr.ecr.eu-west-1.amazonaws.com. Can be specified multiple times")
+	fs.Float64Var(&o.MaxRequestsPerSecond, "max-registry-requests-per-second", 0, "maximum number of requests per second that are sent to oci registries. Requests are not limited if set to 0")
+	fs.StringVar(&o.CacheMaxSize, "cache-max-size", "", "max size of the oci cache (e.g. 5Gi). Cached blobs are garbage collected when the size is reached. The cache is not limited if empty")
+	fs.DurationVar(&o.CacheMaxAge, "cache-max-age", 0, "duration after which cached blobs that have not been accessed are garbage collected. Blobs do not expire if set to 0")
+	fs.BoolVar(&o.CacheCompression, "cache-compression", false, "store cached blobs compressed with zstd. Reduces the disk usage of the oci cache at the cost of cpu")
+	fs.StringVar(&o.PinFile, "pin-file", "", "path to a json file that maps tagged references to their expected digests. Resolving a tag fails if the registry returns another digest")
+	fs.BoolVar(&o.TrustOnFirstUse, "trust-on-first-use", false, "pin tagged references that are not yet pinned in the pin file to the digest they first resolve to")
+	fs.BoolVar(&o.StrictConformance, "strict-conformance", false, "disable all workarounds for registry specific behavior and report violations of the oci distribution spec as warnings, e.g. to validate registry implementations")
+	fs.BoolVar(&o.Offline, "offline", false, "disable all network access and serve manifests and blobs exclusively from the cache and the offline layouts")
+	fs.StringSliceVar(&o.OfflineLayouts, "offline-layout", nil, "path to an oci image layout directory or tarball that is used to resolve references in offline mode. Can be specified multiple times")
 }
 
-// Build builds a new oci client based on the given options
-func (o *Options) Build(log logr.Logger, fs vfs.FileSystem) (ociclient.ExtendedClient, cache.Cache, error) {
-	cache, err := cache.NewCache(log, cache.WithBasePath(o.CacheDir))
+// Build builds a new oci client based on the given options.
+// The additional client options are applied after the options of the flags.
+func (o *Options) Build(log logr.Logger, fs vfs.FileSystem, opts ...ociclient.Option) (ociclient.ExtendedClient, cache.Cache, error) {
+	cache, err := cache.NewCache(log,
+		cache.WithBasePath(o.CacheDir),
+		cache.WithBaseSize(o.CacheMaxSize),
+		cache.WithBaseMaxAge(o.CacheMaxAge),
+		cache.WithCompression(o.CacheCompression))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -58,16 +133,76 @@ func (o *Options) Build(log logr.Logger, fs vfs.FileSystem) (ociclient.ExtendedC
 		ociclient.WithKnownMediaType(cdoci.ComponentDescriptorTarMimeType),
 		ociclient.WithKnownMediaType(cdoci.ComponentDescriptorJSONMimeType),
 		ociclient.AllowPlainHttp(o.AllowPlainHttp),
+		ociclient.WithRequestsPerSecond(o.MaxRequestsPerSecond),
+		ociclient.StrictConformance(o.StrictConformance),
+	}
+
+	if len(o.PinFile) != 0 {
+		pins, err := ociclient.NewFilePinStore(fs, o.PinFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		ociOpts = append(ociOpts, ociclient.WithPinStore(pins, o.TrustOnFirstUse))
+	}
+
+	if o.Offline {
+		layouts := make([]*ociclient.OCILayout, 0, len(o.OfflineLayouts))
+		for _, layoutPath := range o.OfflineLayouts {
+			layout, err := ociclient.OpenOCILayout(fs, layoutPath)
+			if err != nil {
+				return nil, nil, err
+			}
+			layouts = append(layouts, layout)
+		}
+		ociOpts = append(ociOpts, ociclient.WithOfflineMode(layouts...))
+	}
+
+	timeout := o.Timeout
+	if timeout == 0 {
+		timeout = globalOptions.Timeout
+	}
+	if timeout != 0 {
+		// the timeout must not limit the reading of the response body, otherwise large blob downloads are aborted.
+		trp := http.DefaultTransport.(*http.Transport).Clone()
+		trp.DialContext = (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+		trp.TLSHandshakeTimeout = timeout
+		trp.ResponseHeaderTimeout = timeout
+		ociOpts = append(ociOpts, ociclient.WithHTTPClient(http.Client{
+			Transport: trp,
+		}))
 	}
 
-	if o.SkipTLSVerify {
-		httpClient := http.Client{
-			Transport: http.DefaultTransport,
+	if o.SkipTLSVerify || len(o.CAFile) != 0 || len(o.ClientCertFile) != 0 || len(o.ClientKeyFile) != 0 {
+		ociOpts = append(ociOpts, ociclient.WithTLSConfig{
+			CAFile:             o.CAFile,
+			CertFile:           o.ClientCertFile,
+			KeyFile:            o.ClientKeyFile,
+			InsecureSkipVerify: o.SkipTLSVerify,
+		})
+	}
+	if len(o.TLSConfigPath) != 0 {
+		data, err := vfs.ReadFile(fs, o.TLSConfigPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read tls config %q: %w", o.TLSConfigPath, err)
 		}
-		httpClient.Transport.(*http.Transport).TLSClientConfig = &tls.Config{
-			InsecureSkipVerify: true,
+		registryTLSConfigs := ociclient.WithRegistryTLSConfig{}
+		if err := yaml.UnmarshalStrict(data, &registryTLSConfigs); err != nil {
+			return nil, nil, fmt.Errorf("unable to decode tls config %q: %w", o.TLSConfigPath, err)
 		}
-		ociOpts = append(ociOpts, ociclient.WithHTTPClient(httpClient))
+		ociOpts = append(ociOpts, registryTLSConfigs)
+	}
+
+	retries := o.Retries
+	if retries == 0 {
+		retries = globalOptions.Retries
+	}
+	if retries > 0 {
+		ociOpts = append(ociOpts, ociclient.WithRetryPolicy(ociclient.RetryPolicy{
+			MaxRetries: retries,
+		}))
 	}
 
 	keyring, err := credentials.NewBuilder(log).WithFS(fs).FromConfigFiles(o.RegistryConfigPath).Build()
@@ -91,6 +226,24 @@ func (o *Options) Build(log logr.Logger, fs vfs.FileSystem) (ociclient.ExtendedC
 		}
 	}
 
+	registryProviders, err := cloud.ParseRegistryProviders(o.RegistryAuthProviders)
+	if err != nil {
+		return nil, nil, err
+	}
+	cloudKeyring, err := cloud.New().
+		WithLog(log.WithName("cloud")).
+		For(registryProviders...).
+		Build()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create keyring for cloud registries: %w", err)
+	}
+	if cloudKeyring != nil {
+		if err := credentials.Merge(keyring, cloudKeyring); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ociOpts = append(ociOpts, opts...)
 	ociClient, err := ociclient.NewClient(log, ociOpts...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("unable to build oci client: %w", err)
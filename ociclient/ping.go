@@ -0,0 +1,303 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/opencontainers/go-digest"
+)
+
+// PingInterface describes an interface that can be optionally exposed by a client to diagnose the connection to a registry.
+type PingInterface interface {
+	// Ping checks the reachability and capabilities of the given registry.
+	// The registry can optionally contain a repository which is used to detect repository scoped capabilities.
+	Ping(ctx context.Context, registry string) (*PingReport, error)
+}
+
+// PingReport is the diagnostic report of a registry.
+type PingReport struct {
+	// Registry is the host of the registry.
+	Registry string `json:"registry"`
+	// Repository is the optional repository that is used to detect repository scoped capabilities.
+	Repository string `json:"repository,omitempty"`
+	// URL is the url of the api version check endpoint.
+	URL string `json:"url"`
+	// Reachable indicates whether the registry responded to the api version check.
+	Reachable bool `json:"reachable"`
+	// StatusCode is the status code of the unauthenticated api version check.
+	StatusCode int `json:"statusCode,omitempty"`
+	// Latency is the duration of the unauthenticated api version check.
+	Latency string `json:"latency,omitempty"`
+	// APIVersion is the value of the Docker-Distribution-API-Version header.
+	APIVersion string `json:"apiVersion,omitempty"`
+	// TLS contains the details of the tls connection. It is empty for plain http connections.
+	TLS *PingTLSReport `json:"tls,omitempty"`
+	// Auth contains the result of the auth negotiation.
+	Auth PingAuthReport `json:"auth"`
+	// Capabilities contains the detected optional capabilities of the registry.
+	Capabilities PingCapabilitiesReport `json:"capabilities"`
+	// Errors contains all errors that occurred during the diagnosis.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// PingTLSReport describes the tls connection to a registry.
+type PingTLSReport struct {
+	Version      string                  `json:"version"`
+	CipherSuite  string                  `json:"cipherSuite"`
+	ServerName   string                  `json:"serverName,omitempty"`
+	Certificates []PingCertificateReport `json:"certificates,omitempty"`
+}
+
+// PingCertificateReport describes a certificate that is presented by a registry.
+type PingCertificateReport struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	DNSNames  []string  `json:"dnsNames,omitempty"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+}
+
+// PingAuthReport describes the auth negotiation with a registry.
+type PingAuthReport struct {
+	// Scheme is the auth scheme of the challenge of the registry, e.g. Bearer or Basic.
+	Scheme string `json:"scheme,omitempty"`
+	// Realm is the realm of the challenge of the registry.
+	Realm string `json:"realm,omitempty"`
+	// Service is the service of the challenge of the registry.
+	Service string `json:"service,omitempty"`
+	// Authenticated indicates whether an authenticated api version check succeeded with the configured credentials.
+	Authenticated bool `json:"authenticated"`
+	// StatusCode is the status code of the authenticated api version check.
+	StatusCode int `json:"statusCode,omitempty"`
+}
+
+// PingCapabilitiesReport describes the optional capabilities of a registry.
+type PingCapabilitiesReport struct {
+	// Catalog indicates whether the registry supports listing repositories.
+	Catalog bool `json:"catalog"`
+	// Referrers indicates whether the registry supports the referrers api.
+	// It is only detected if a repository is given.
+	Referrers *bool `json:"referrers,omitempty"`
+}
+
+var _ PingInterface = &client{}
+
+// Ping checks the reachability and capabilities of the given registry.
+// Failed checks are recorded in the errors of the report so that a report is returned as long as the registry can be parsed.
+func (c *client) Ping(ctx context.Context, registry string) (*PingReport, error) {
+	host, repository := splitRegistry(registry)
+	report := &PingReport{
+		Registry:   host,
+		Repository: repository,
+	}
+	hosts, err := c.getHostConfig(host)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find registry host: %w", err)
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no host configuration found for %s", host)
+	}
+	hostConfig := hosts[0]
+	var nameOpts []name.Option
+	if hostConfig.Scheme == "http" {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+	reg, err := name.NewRegistry(host, nameOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse registry %q: %w", host, err)
+	}
+	var (
+		resource authn.Resource = reg
+		scopes                  = []string{reg.Scope("")}
+	)
+	if len(repository) != 0 {
+		repo, err := name.NewRepository(host+"/"+repository, nameOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse repository %q: %w", repository, err)
+		}
+		resource = repo
+		scopes = append(scopes, repo.Scope(transport.PullScope))
+	}
+
+	apiURL := func(elem ...string) *url.URL {
+		return &url.URL{
+			Scheme: hostConfig.Scheme,
+			Host:   hostConfig.Host,
+			Path:   path.Join(append([]string{hostConfig.Path}, elem...)...) + "/",
+		}
+	}
+	versionURL := apiURL()
+	report.URL = versionURL.String()
+
+	// unauthenticated api version check
+	httpClient := c.getHttpClient()
+	httpClient.Transport = c.transport
+	start := time.Now()
+	resp, err := doPingRequest(ctx, httpClient, versionURL)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("registry is not reachable: %s", err.Error()))
+		return report, nil
+	}
+	report.Reachable = true
+	report.Latency = time.Since(start).Round(time.Millisecond).String()
+	report.StatusCode = resp.StatusCode
+	report.APIVersion = resp.Header.Get("Docker-Distribution-API-Version")
+	report.TLS = tlsReport(resp.TLS)
+	report.Auth.Scheme, report.Auth.Realm, report.Auth.Service = parseAuthChallenge(resp.Header.Get("WWW-Authenticate"))
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		report.Errors = append(report.Errors, fmt.Sprintf("unexpected status code %d of the api version check", resp.StatusCode))
+	}
+
+	// authenticated api version check
+	auth, err := c.keychain.ResolveWithContext(ctx, resource)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("unable to get authentication: %s", err.Error()))
+		return report, nil
+	}
+	trp, err := transport.NewWithContext(ctx, reg, auth, c.transport, scopes)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("auth negotiation failed: %s", err.Error()))
+		return report, nil
+	}
+	httpClient.Transport = trp
+	resp, err = doPingRequest(ctx, httpClient, versionURL)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("authenticated api version check failed: %s", err.Error()))
+		return report, nil
+	}
+	report.Auth.StatusCode = resp.StatusCode
+	report.Auth.Authenticated = resp.StatusCode == http.StatusOK
+	if !report.Auth.Authenticated {
+		report.Errors = append(report.Errors, fmt.Sprintf("authenticated api version check failed with status code %d", resp.StatusCode))
+	}
+
+	// capability detection
+	catalogURL := apiURL("_catalog")
+	catalogURL.Path = strings.TrimSuffix(catalogURL.Path, "/")
+	catalogURL.RawQuery = "n=1"
+	if resp, err := doPingRequest(ctx, httpClient, catalogURL); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("unable to detect catalog support: %s", err.Error()))
+	} else {
+		report.Capabilities.Catalog = resp.StatusCode == http.StatusOK
+	}
+
+	if len(repository) != 0 {
+		// registries that support the referrers api return an empty index for unknown digests
+		referrersURL := apiURL(repository, "referrers", digest.FromString("").String())
+		referrersURL.Path = strings.TrimSuffix(referrersURL.Path, "/")
+		if resp, err := doPingRequest(ctx, httpClient, referrersURL); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("unable to detect referrers support: %s", err.Error()))
+		} else {
+			supported := resp.StatusCode == http.StatusOK
+			report.Capabilities.Referrers = &supported
+		}
+	}
+
+	return report, nil
+}
+
+// doPingRequest does a get request and discards the response body.
+func doPingRequest(ctx context.Context, httpClient *http.Client, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+	return resp, nil
+}
+
+// splitRegistry splits a registry with an optional scheme and repository into the host and the repository.
+func splitRegistry(registry string) (string, string) {
+	registry = strings.TrimPrefix(registry, "https://")
+	registry = strings.TrimPrefix(registry, "http://")
+	registry = strings.TrimSuffix(registry, "/")
+	splitRegistry := strings.SplitN(registry, "/", 2)
+	if len(splitRegistry) == 1 {
+		return splitRegistry[0], ""
+	}
+	return splitRegistry[0], splitRegistry[1]
+}
+
+// parseAuthChallenge parses the scheme, realm and service of a WWW-Authenticate header.
+func parseAuthChallenge(header string) (scheme, realm, service string) {
+	if len(header) == 0 {
+		return "", "", ""
+	}
+	parts := strings.SplitN(header, " ", 2)
+	scheme = parts[0]
+	if len(parts) == 1 {
+		return scheme, "", ""
+	}
+	for _, param := range strings.Split(parts[1], ",") {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.Trim(kv[1], `"`)
+		switch strings.ToLower(kv[0]) {
+		case "realm":
+			realm = value
+		case "service":
+			service = value
+		}
+	}
+	return scheme, realm, service
+}
+
+func tlsReport(state *tls.ConnectionState) *PingTLSReport {
+	if state == nil {
+		return nil
+	}
+	report := &PingTLSReport{
+		Version:     tlsVersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+	}
+	for _, cert := range state.PeerCertificates {
+		report.Certificates = append(report.Certificates, PingCertificateReport{
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			DNSNames:  cert.DNSNames,
+			NotBefore: cert.NotBefore,
+			NotAfter:  cert.NotAfter,
+		})
+	}
+	return report
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04x", version)
+	}
+}
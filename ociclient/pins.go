@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// PinStore stores the digests that tagged references are expected to resolve to.
+// Pinning protects automated processes from tags that are changed in the registry.
+type PinStore interface {
+	// Get returns the pinned digest of a reference.
+	Get(ref string) (digest.Digest, bool, error)
+	// Set pins the reference to the given digest.
+	Set(ref string, dgst digest.Digest) error
+}
+
+// DigestPinMismatchError is returned if a tagged reference resolves to another digest than the pinned one.
+type DigestPinMismatchError struct {
+	// Ref is the tagged reference.
+	Ref string
+	// Pinned is the digest the reference is pinned to.
+	Pinned digest.Digest
+	// Resolved is the digest that has been returned by the registry.
+	Resolved digest.Digest
+}
+
+func (e *DigestPinMismatchError) Error() string {
+	return fmt.Sprintf("%q is pinned to %s but the registry resolved it to %s", e.Ref, e.Pinned, e.Resolved)
+}
+
+// IsDigestPinMismatchError checks whether the given error is or wraps a DigestPinMismatchError.
+func IsDigestPinMismatchError(err error) bool {
+	var mismatchErr *DigestPinMismatchError
+	return errors.As(err, &mismatchErr)
+}
+
+// normalizePinRef returns the normalized form of a tagged reference.
+// False is returned for references without tag or with a digest, as they do not need to be pinned.
+func normalizePinRef(ref string) (string, bool) {
+	refspec, err := oci.ParseRef(ref)
+	if err != nil || refspec.Tag == nil || refspec.Digest != nil {
+		return "", false
+	}
+	return refspec.String(), true
+}
+
+// InMemoryPinStore is a pin store that keeps the pins in memory.
+type InMemoryPinStore struct {
+	mux  sync.RWMutex
+	pins map[string]digest.Digest
+}
+
+// NewInMemoryPinStore creates a new in memory pin store that is seeded with the given pins.
+func NewInMemoryPinStore(pins map[string]digest.Digest) (*InMemoryPinStore, error) {
+	s := &InMemoryPinStore{
+		pins: map[string]digest.Digest{},
+	}
+	for ref, dgst := range pins {
+		if err := s.Set(ref, dgst); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Get returns the pinned digest of a reference.
+func (s *InMemoryPinStore) Get(ref string) (digest.Digest, bool, error) {
+	normalized, ok := normalizePinRef(ref)
+	if !ok {
+		return "", false, nil
+	}
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	dgst, ok := s.pins[normalized]
+	return dgst, ok, nil
+}
+
+// Set pins the reference to the given digest.
+func (s *InMemoryPinStore) Set(ref string, dgst digest.Digest) error {
+	normalized, ok := normalizePinRef(ref)
+	if !ok {
+		return fmt.Errorf("only tagged references can be pinned: %q", ref)
+	}
+	if err := dgst.Validate(); err != nil {
+		return fmt.Errorf("invalid digest for %q: %w", ref, err)
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.pins[normalized] = dgst
+	return nil
+}
+
+// FilePinStore is a pin store that persists the pins as json map from references to digests in a file.
+type FilePinStore struct {
+	InMemoryPinStore
+	fs   vfs.FileSystem
+	path string
+}
+
+// NewFilePinStore creates a pin store that is backed by the file at the given path.
+// The file is created when the first pin is set.
+func NewFilePinStore(fs vfs.FileSystem, path string) (*FilePinStore, error) {
+	pins := map[string]digest.Digest{}
+	data, err := vfs.ReadFile(fs, path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unable to read pin file %q: %w", path, err)
+	}
+	if len(data) != 0 {
+		if err := json.Unmarshal(data, &pins); err != nil {
+			return nil, fmt.Errorf("unable to decode pin file %q: %w", path, err)
+		}
+	}
+	memStore, err := NewInMemoryPinStore(pins)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pin file %q: %w", path, err)
+	}
+	return &FilePinStore{
+		InMemoryPinStore: InMemoryPinStore{pins: memStore.pins},
+		fs:               fs,
+		path:             path,
+	}, nil
+}
+
+// Set pins the reference to the given digest and writes all pins to the file.
+func (s *FilePinStore) Set(ref string, dgst digest.Digest) error {
+	if err := s.InMemoryPinStore.Set(ref, dgst); err != nil {
+		return err
+	}
+	s.mux.RLock()
+	data, err := json.MarshalIndent(s.pins, "", "  ")
+	s.mux.RUnlock()
+	if err != nil {
+		return fmt.Errorf("unable to encode pins: %w", err)
+	}
+	if err := s.fs.MkdirAll(filepath.Dir(s.path), os.ModePerm); err != nil {
+		return fmt.Errorf("unable to create directory for pin file %q: %w", s.path, err)
+	}
+	if err := vfs.WriteFile(s.fs, s.path, data, 0644); err != nil {
+		return fmt.Errorf("unable to write pin file %q: %w", s.path, err)
+	}
+	return nil
+}
+
+// pinningResolver is a resolver that verifies that tagged references resolve to their pinned digest.
+type pinningResolver struct {
+	remotes.Resolver
+	pins            PinStore
+	trustOnFirstUse bool
+}
+
+func (r *pinningResolver) Resolve(ctx context.Context, ref string) (string, ocispecv1.Descriptor, error) {
+	name, desc, err := r.Resolver.Resolve(ctx, ref)
+	if err != nil {
+		return name, desc, err
+	}
+	if err := verifyPin(r.pins, r.trustOnFirstUse, ref, desc.Digest); err != nil {
+		return "", ocispecv1.Descriptor{}, err
+	}
+	return name, desc, nil
+}
+
+// verifyPin checks that the resolved digest of a tagged reference matches its pin.
+// References without pin are pinned to the resolved digest if trust on first use is enabled.
+func verifyPin(pins PinStore, trustOnFirstUse bool, ref string, resolved digest.Digest) error {
+	if _, ok := normalizePinRef(ref); !ok {
+		return nil
+	}
+	pinned, ok, err := pins.Get(ref)
+	if err != nil {
+		return fmt.Errorf("unable to get pin for %q: %w", ref, err)
+	}
+	if !ok {
+		if !trustOnFirstUse {
+			return nil
+		}
+		if err := pins.Set(ref, resolved); err != nil {
+			return fmt.Errorf("unable to pin %q: %w", ref, err)
+		}
+		return nil
+	}
+	if pinned != resolved {
+		return &DigestPinMismatchError{
+			Ref:      ref,
+			Pinned:   pinned,
+			Resolved: resolved,
+		}
+	}
+	return nil
+}
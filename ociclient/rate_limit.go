@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRateLimitInitialBackoff is the default backoff after the first rate limited request
+	// if the registry does not return a Retry-After header.
+	DefaultRateLimitInitialBackoff = time.Second
+	// DefaultRateLimitMaxBackoff is the default maximal backoff between two requests.
+	DefaultRateLimitMaxBackoff = time.Minute
+)
+
+// RateLimitOptions configures the handling of rate limited list operations (tags and repositories).
+type RateLimitOptions struct {
+	// MaxRetries is the maximal number of retries of a rate limited (429) request.
+	MaxRetries int
+	// InitialBackoff is the backoff after the first rate limited request.
+	// The backoff is doubled with every further rate limited request.
+	// A Retry-After header returned by the registry takes precedence.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximal backoff between two requests.
+	MaxBackoff time.Duration
+	// PageCacheTTL defines how long the pages of list operations are cached.
+	// The page cache is disabled if the ttl is 0.
+	PageCacheTTL time.Duration
+}
+
+// WithRateLimit configures the handling of rate limited list operations.
+// The backoff is shared across all list operations of the client so that concurrent
+// operations against the same registry do not hit the rate limit again.
+func WithRateLimit(opts RateLimitOptions) WithRateLimitOption {
+	return WithRateLimitOption{
+		RateLimitOptions: opts,
+	}
+}
+
+// WithRateLimitOption configures the handling of rate limited list operations.
+type WithRateLimitOption struct {
+	RateLimitOptions
+}
+
+func (c WithRateLimitOption) ApplyOption(options *Options) {
+	rl := c.RateLimitOptions
+	options.RateLimit = &rl
+}
+
+// listRateLimiter executes list requests with a backoff on rate limited requests and optionally caches the responses.
+type listRateLimiter struct {
+	opts RateLimitOptions
+
+	mux sync.Mutex
+	// blockedUntil contains the time per registry host until requests should not be sent.
+	blockedUntil map[string]time.Time
+	pages        map[string]cachedPage
+}
+
+type cachedPage struct {
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+func newListRateLimiter(opts *RateLimitOptions) *listRateLimiter {
+	rl := &listRateLimiter{
+		blockedUntil: map[string]time.Time{},
+		pages:        map[string]cachedPage{},
+	}
+	if opts != nil {
+		rl.opts = *opts
+	}
+	if rl.opts.InitialBackoff == 0 {
+		rl.opts.InitialBackoff = DefaultRateLimitInitialBackoff
+	}
+	if rl.opts.MaxBackoff == 0 {
+		rl.opts.MaxBackoff = DefaultRateLimitMaxBackoff
+	}
+	return rl
+}
+
+// Do sends the request and retries it if it is rate limited.
+// Successful responses are served from the page cache if enabled.
+func (rl *listRateLimiter) Do(ctx context.Context, httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+	if resp, ok := rl.getPage(key); ok {
+		return resp, nil
+	}
+
+	backoff := rl.opts.InitialBackoff
+	for retry := 0; ; retry++ {
+		if err := rl.wait(ctx, req.URL.Host); err != nil {
+			return nil, err
+		}
+		resp, err := httpClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || retry >= rl.opts.MaxRetries {
+			if resp.StatusCode == http.StatusOK {
+				return rl.setPage(key, resp)
+			}
+			return resp, nil
+		}
+
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+		wait := backoff
+		if retryAfter, ok := parseRetryAfter(resp.Header); ok {
+			wait = retryAfter
+		}
+		if wait > rl.opts.MaxBackoff {
+			wait = rl.opts.MaxBackoff
+		}
+		rl.block(req.URL.Host, wait)
+		backoff *= 2
+	}
+}
+
+// wait blocks until the registry host is not blocked anymore.
+func (rl *listRateLimiter) wait(ctx context.Context, host string) error {
+	rl.mux.Lock()
+	until := rl.blockedUntil[host]
+	rl.mux.Unlock()
+	d := time.Until(until)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("rate limited by %s: %w", host, ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (rl *listRateLimiter) block(host string, d time.Duration) {
+	rl.mux.Lock()
+	defer rl.mux.Unlock()
+	until := time.Now().Add(d)
+	if until.After(rl.blockedUntil[host]) {
+		rl.blockedUntil[host] = until
+	}
+}
+
+func (rl *listRateLimiter) getPage(key string) (*http.Response, bool) {
+	if rl.opts.PageCacheTTL == 0 {
+		return nil, false
+	}
+	rl.mux.Lock()
+	defer rl.mux.Unlock()
+	page, ok := rl.pages[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(page.expires) {
+		delete(rl.pages, key)
+		return nil, false
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     page.header.Clone(),
+		Body:       ioutil.NopCloser(bytes.NewReader(page.body)),
+	}, true
+}
+
+func (rl *listRateLimiter) setPage(key string, resp *http.Response) (*http.Response, error) {
+	if rl.opts.PageCacheTTL == 0 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+	rl.mux.Lock()
+	rl.pages[key] = cachedPage{
+		header:  resp.Header.Clone(),
+		body:    body,
+		expires: time.Now().Add(rl.opts.PageCacheTTL),
+	}
+	rl.mux.Unlock()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// parseRetryAfter parses the Retry-After header that is either defined as seconds or as http date.
+// The X-RateLimit-Reset header (unix timestamp) is used as fallback.
+func parseRetryAfter(header http.Header) (time.Duration, bool) {
+	if value := header.Get("Retry-After"); len(value) != 0 {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if t, err := http.ParseTime(value); err == nil {
+			return time.Until(t), true
+		}
+	}
+	if value := header.Get("X-RateLimit-Reset"); len(value) != 0 {
+		if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Until(time.Unix(ts, 0)), true
+		}
+	}
+	return 0, false
+}
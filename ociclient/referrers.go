@@ -0,0 +1,295 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// MediaTypeEmptyJSON is the media type of the empty json config of artifacts that do not need a config.
+const MediaTypeEmptyJSON = "application/vnd.oci.empty.v1+json"
+
+// emptyJSON is the content of the empty json config.
+var emptyJSON = []byte("{}")
+
+// EmptyJSONDescriptor is the descriptor of the empty json config.
+var EmptyJSONDescriptor = ocispecv1.Descriptor{
+	MediaType: MediaTypeEmptyJSON,
+	Digest:    digest.FromBytes(emptyJSON),
+	Size:      int64(len(emptyJSON)),
+}
+
+// ReferrersInterface describes an interface that can be optionally exposed by a client to attach artifacts
+// like signatures, sboms or attestations to a manifest.
+// The referrers api of the oci distribution spec 1.1 is used if the registry supports it.
+// Otherwise, the referrers are managed in an image index that is tagged with the referrers tag schema ("<alg>-<encoded digest>").
+type ReferrersInterface interface {
+	// PushReferrer uploads the manifest as referrer of the manifest the subject reference points to.
+	// The subject of the uploaded manifest is set to the descriptor of the subject manifest.
+	// The config and layers of the manifest have to be available in the store of the push options.
+	// Manifests without config get the empty json config.
+	PushReferrer(ctx context.Context, subjectRef string, manifest *ReferrerManifest, opts ...PushOption) (ocispecv1.Descriptor, error)
+	// ListReferrers returns the descriptors of all manifests that refer to the manifest the subject reference points to.
+	// If an artifact type is given only referrers of that artifact type are returned.
+	ListReferrers(ctx context.Context, subjectRef string, artifactType string) ([]Referrer, error)
+}
+
+// ReferrerManifest is an oci image manifest with the artifactType and subject fields of the oci image spec 1.1.
+type ReferrerManifest struct {
+	ocispecv1.Manifest
+	// ArtifactType is the type of the artifact, e.g. "application/vnd.dev.cosign.artifact.sig.v1+json".
+	ArtifactType string `json:"artifactType,omitempty"`
+	// Subject is the descriptor of the manifest the artifact refers to.
+	Subject *ocispecv1.Descriptor `json:"subject,omitempty"`
+}
+
+// Referrer is the descriptor of a manifest that refers to another manifest.
+type Referrer struct {
+	ocispecv1.Descriptor
+	// ArtifactType is the type of the referring artifact.
+	ArtifactType string `json:"artifactType,omitempty"`
+}
+
+// referrersIndex is the image index that is returned by the referrers api and that is used by the referrers tag schema.
+type referrersIndex struct {
+	specs.Versioned
+	MediaType string     `json:"mediaType,omitempty"`
+	Manifests []Referrer `json:"manifests"`
+}
+
+var _ ReferrersInterface = &client{}
+
+// PushReferrer uploads the manifest as referrer of the manifest the subject reference points to.
+func (c *client) PushReferrer(ctx context.Context, subjectRef string, manifest *ReferrerManifest, options ...PushOption) (ocispecv1.Descriptor, error) {
+	refspec, err := oci.ParseRef(subjectRef)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to parse ref: %w", err)
+	}
+	_, subjectDesc, err := c.Resolve(ctx, subjectRef)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to resolve subject %q: %w", subjectRef, err)
+	}
+
+	manifest.SchemaVersion = 2
+	manifest.MediaType = ocispecv1.MediaTypeImageManifest
+	manifest.Subject = &ocispecv1.Descriptor{
+		MediaType: subjectDesc.MediaType,
+		Digest:    subjectDesc.Digest,
+		Size:      subjectDesc.Size,
+	}
+	if manifest.Config.Size == 0 {
+		manifest.Config = EmptyJSONDescriptor
+	}
+	if manifest.Layers == nil {
+		manifest.Layers = []ocispecv1.Descriptor{}
+	}
+	rawManifest, err := json.Marshal(manifest)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to marshal manifest: %w", err)
+	}
+	desc := ocispecv1.Descriptor{
+		MediaType:   ocispecv1.MediaTypeImageManifest,
+		Digest:      digest.FromBytes(rawManifest),
+		Size:        int64(len(rawManifest)),
+		Annotations: manifest.Annotations,
+	}
+
+	pushOpts := &PushOptions{}
+	pushOpts.ApplyOptions(options)
+	store := pushOpts.Store
+	if store == nil {
+		store = c.cache
+	}
+	if store == nil {
+		store = cache.NewInMemoryCache()
+	}
+	options = append(options, WithStore(&emptyJSONStore{Store: store}))
+
+	referrerRef := refspec.DigestRef(desc.Digest)
+	if err := c.PushRawManifest(ctx, referrerRef, desc, rawManifest, options...); err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to push referrer: %w", err)
+	}
+
+	// registries that support the referrers api maintain the referrers themselves
+	_, supported, err := c.getReferrersIndex(ctx, refspec, subjectDesc.Digest, "")
+	if err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	if supported {
+		return desc, nil
+	}
+
+	c.log.V(5).Info("registry does not support the referrers api, fallback to the referrers tag schema", "ref", subjectRef)
+	tagRef := referrersTagRef(refspec, subjectDesc.Digest)
+	index, err := c.getReferrersTagIndex(ctx, tagRef)
+	if err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	for _, referrer := range index.Manifests {
+		if referrer.Digest == desc.Digest {
+			return desc, nil
+		}
+	}
+	index.Manifests = append(index.Manifests, Referrer{
+		Descriptor:   desc,
+		ArtifactType: manifest.ArtifactType,
+	})
+	rawIndex, err := json.Marshal(index)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to marshal referrers index: %w", err)
+	}
+	indexDesc := ocispecv1.Descriptor{
+		MediaType: ocispecv1.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(rawIndex),
+		Size:      int64(len(rawIndex)),
+	}
+	if err := c.PushRawManifest(ctx, tagRef, indexDesc, rawIndex); err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to push referrers index: %w", err)
+	}
+	return desc, nil
+}
+
+// ListReferrers returns the descriptors of all manifests that refer to the manifest the subject reference points to.
+func (c *client) ListReferrers(ctx context.Context, subjectRef string, artifactType string) ([]Referrer, error) {
+	refspec, err := oci.ParseRef(subjectRef)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse ref: %w", err)
+	}
+	_, subjectDesc, err := c.Resolve(ctx, subjectRef)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve subject %q: %w", subjectRef, err)
+	}
+
+	index, supported, err := c.getReferrersIndex(ctx, refspec, subjectDesc.Digest, artifactType)
+	if err != nil {
+		return nil, err
+	}
+	if !supported {
+		index, err = c.getReferrersTagIndex(ctx, referrersTagRef(refspec, subjectDesc.Digest))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// registries are allowed to ignore the artifact type filter
+	referrers := []Referrer{}
+	for _, referrer := range index.Manifests {
+		if len(artifactType) != 0 && referrer.ArtifactType != artifactType {
+			continue
+		}
+		referrers = append(referrers, referrer)
+	}
+	return referrers, nil
+}
+
+// getReferrersIndex gets the referrers of a manifest from the referrers api.
+// It returns false if the registry does not support the referrers api.
+func (c *client) getReferrersIndex(ctx context.Context, refspec oci.RefSpec, subject digest.Digest, artifactType string) (*referrersIndex, bool, error) {
+	hosts, err := c.getHostConfig(refspec.Host)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to find registry host: %w", err)
+	}
+	if len(hosts) == 0 {
+		return nil, false, fmt.Errorf("no host configuration found for %s", refspec.Host)
+	}
+	hostConfig := hosts[0]
+
+	trp, err := c.getTransportForRef(ctx, refspec.Name(), transport.PullScope)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to create transport: %w", err)
+	}
+	httpClient := c.getHttpClient()
+	httpClient.Transport = trp
+
+	u := &url.URL{
+		Scheme: hostConfig.Scheme,
+		Host:   hostConfig.Host,
+		Path:   path.Join(hostConfig.Path, refspec.Repository, "referrers", subject.String()),
+	}
+	if len(artifactType) != 0 {
+		u.RawQuery = url.Values{"artifactType": []string{artifactType}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Accept", ocispecv1.MediaTypeImageIndex)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to get referrers: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("unable to get referrers: unexpected status code %d", resp.StatusCode)
+	}
+	var data bytes.Buffer
+	if _, err := io.Copy(&data, resp.Body); err != nil {
+		return nil, false, fmt.Errorf("unable to read response body: %w", err)
+	}
+	index := &referrersIndex{}
+	if err := json.Unmarshal(data.Bytes(), index); err != nil {
+		return nil, false, fmt.Errorf("unable to decode referrers index: %w", err)
+	}
+	return index, true, nil
+}
+
+// getReferrersTagIndex gets the referrers index of the referrers tag schema.
+// An empty index is returned if the tag does not exist.
+func (c *client) getReferrersTagIndex(ctx context.Context, tagRef string) (*referrersIndex, error) {
+	_, rawIndex, err := c.GetRawManifest(ctx, tagRef)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return &referrersIndex{
+				Versioned: specs.Versioned{SchemaVersion: 2},
+				MediaType: ocispecv1.MediaTypeImageIndex,
+				Manifests: []Referrer{},
+			}, nil
+		}
+		return nil, fmt.Errorf("unable to get referrers index %q: %w", tagRef, err)
+	}
+	index := &referrersIndex{}
+	if err := json.Unmarshal(rawIndex, index); err != nil {
+		return nil, fmt.Errorf("unable to decode referrers index %q: %w", tagRef, err)
+	}
+	return index, nil
+}
+
+// referrersTagRef returns the reference of the referrers tag schema for a subject digest.
+func referrersTagRef(refspec oci.RefSpec, subject digest.Digest) string {
+	return fmt.Sprintf("%s:%s-%s", refspec.Name(), subject.Algorithm(), subject.Encoded())
+}
+
+// emptyJSONStore is a store that additionally contains the empty json config.
+type emptyJSONStore struct {
+	Store
+}
+
+func (s *emptyJSONStore) Get(desc ocispecv1.Descriptor) (io.ReadCloser, error) {
+	if desc.Digest == EmptyJSONDescriptor.Digest {
+		return ioutil.NopCloser(bytes.NewReader(emptyJSON)), nil
+	}
+	return s.Store.Get(desc)
+}
@@ -0,0 +1,268 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultQuotaMaxRetries is the number of retries of a request that has been rejected because of a quota
+	// if registry limits but no retry policy are configured.
+	DefaultQuotaMaxRetries = 5
+	// DefaultAdaptiveRequestsPerSecond is the request rate a registry is slowed down to after the first quota error
+	// if no request rate is configured for the registry.
+	DefaultAdaptiveRequestsPerSecond = 10
+	// minAdaptiveRequestsPerSecond is the minimal request rate a registry is slowed down to.
+	minAdaptiveRequestsPerSecond = 0.1
+	// maxThrottledReadSize is the max number of bytes that are read at once from a throttled request body,
+	// so that the bandwidth is shared evenly by concurrent uploads.
+	maxThrottledReadSize = 32 * 1024
+)
+
+// RegistryLimits limits the requests that are sent to a single registry host.
+// A value of 0 disables the respective limit.
+type RegistryLimits struct {
+	// MaxConcurrentUploads is the maximal number of concurrent upload requests (POST, PUT and PATCH) to the registry.
+	// Every request of a chunked upload session counts as a single upload request.
+	MaxConcurrentUploads int `json:"maxConcurrentUploads,omitempty"`
+	// MaxBytesPerSecond limits the bandwidth of all uploads to the registry.
+	MaxBytesPerSecond int64 `json:"maxBytesPerSecond,omitempty"`
+	// MaxRequestsPerSecond limits the number of requests per second that are sent to the registry.
+	MaxRequestsPerSecond float64 `json:"maxRequestsPerSecond,omitempty"`
+}
+
+// WithRegistryLimits configures limits per registry host (e.g. "eu.gcr.io" or "localhost:5000"),
+// so that a slow or strictly rate limited registry does not dictate the pacing of the requests to other registries.
+// Quota errors (429) of a registry with limits halve the request rate of that registry, which slowly recovers with successful requests.
+// The rejected requests are retried by the retry policy of the client, see WithRetryPolicy.
+// If no retry policy is configured, they are retried up to DefaultQuotaMaxRetries times.
+type WithRegistryLimits map[string]RegistryLimits
+
+func (c WithRegistryLimits) ApplyOption(options *Options) {
+	if options.RegistryLimits == nil {
+		options.RegistryLimits = map[string]RegistryLimits{}
+	}
+	for host, limits := range c {
+		options.RegistryLimits[host] = limits
+	}
+}
+
+// registryLimiter is a http.RoundTripper that enforces the limits of the registry hosts.
+// Requests to hosts without limits are passed through.
+type registryLimiter struct {
+	next  http.RoundTripper
+	hosts map[string]*hostLimiter
+}
+
+func newRegistryLimiter(next http.RoundTripper, limits map[string]RegistryLimits) *registryLimiter {
+	l := &registryLimiter{
+		next:  next,
+		hosts: map[string]*hostLimiter{},
+	}
+	for host, hostLimits := range limits {
+		l.hosts[host] = newHostLimiter(hostLimits)
+	}
+	return l
+}
+
+func (l *registryLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	host, ok := l.hosts[req.URL.Host]
+	if !ok {
+		return l.next.RoundTrip(req)
+	}
+	return host.roundTrip(l.next, req)
+}
+
+// hostLimiter enforces the limits of a single registry host.
+type hostLimiter struct {
+	limits  RegistryLimits
+	uploads chan struct{}
+
+	mux sync.Mutex
+	// requestInterval is the current minimal interval between two requests.
+	// It is increased on quota errors and decreased again on successful requests.
+	requestInterval time.Duration
+	// minRequestInterval is the configured minimal interval between two requests.
+	minRequestInterval time.Duration
+	nextRequest        time.Time
+	nextByte           time.Time
+}
+
+func newHostLimiter(limits RegistryLimits) *hostLimiter {
+	h := &hostLimiter{
+		limits: limits,
+	}
+	if limits.MaxConcurrentUploads > 0 {
+		h.uploads = make(chan struct{}, limits.MaxConcurrentUploads)
+	}
+	if limits.MaxRequestsPerSecond > 0 {
+		h.minRequestInterval = rateToInterval(limits.MaxRequestsPerSecond)
+		h.requestInterval = h.minRequestInterval
+	}
+	return h
+}
+
+func (h *hostLimiter) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if h.uploads != nil && isUploadRequest(req) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case h.uploads <- struct{}{}:
+		}
+		defer func() { <-h.uploads }()
+	}
+
+	if err := sleep(ctx, h.reserveRequest()); err != nil {
+		return nil, err
+	}
+	if h.limits.MaxBytesPerSecond > 0 && req.Body != nil && req.Body != http.NoBody {
+		// the request of the caller must not be modified
+		req = req.Clone(ctx)
+		req.Body = &throttledReader{ctx: ctx, host: h, ReadCloser: req.Body}
+		if getBody := req.GetBody; getBody != nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return &throttledReader{ctx: ctx, host: h, ReadCloser: body}, nil
+			}
+		}
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		h.speedUp()
+		return resp, nil
+	}
+	// the request is retried by the retry transport, so that the following requests are only slowed down.
+	wait := h.slowDown()
+	if retryAfter, ok := parseRetryAfter(resp.Header); ok && retryAfter > wait {
+		wait = retryAfter
+	}
+	h.block(wait)
+	return resp, nil
+}
+
+// reserveRequest reserves the next free request slot and returns the duration until the slot starts.
+func (h *hostLimiter) reserveRequest() time.Duration {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	now := time.Now()
+	if h.nextRequest.Before(now) {
+		h.nextRequest = now
+	}
+	wait := h.nextRequest.Sub(now)
+	h.nextRequest = h.nextRequest.Add(h.requestInterval)
+	return wait
+}
+
+// reserveBytes reserves the bandwidth for n bytes and returns the duration until the bytes may be sent.
+func (h *hostLimiter) reserveBytes(n int) time.Duration {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	now := time.Now()
+	if h.nextByte.Before(now) {
+		h.nextByte = now
+	}
+	wait := h.nextByte.Sub(now)
+	h.nextByte = h.nextByte.Add(time.Duration(float64(n) / float64(h.limits.MaxBytesPerSecond) * float64(time.Second)))
+	return wait
+}
+
+// block delays all further requests to the host for the given duration.
+func (h *hostLimiter) block(d time.Duration) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	until := time.Now().Add(d)
+	if until.After(h.nextRequest) {
+		h.nextRequest = until
+	}
+}
+
+// slowDown halves the request rate after a quota error and returns the new interval between two requests.
+func (h *hostLimiter) slowDown() time.Duration {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	if h.requestInterval == 0 {
+		h.requestInterval = rateToInterval(DefaultAdaptiveRequestsPerSecond)
+	} else {
+		h.requestInterval *= 2
+	}
+	if max := rateToInterval(minAdaptiveRequestsPerSecond); h.requestInterval > max {
+		h.requestInterval = max
+	}
+	return h.requestInterval
+}
+
+// speedUp slowly recovers the request rate after a successful request up to the configured rate.
+// The rate is not limited anymore if no rate is configured and the rate recovered to the default adaptive rate.
+func (h *hostLimiter) speedUp() {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	if h.requestInterval <= h.minRequestInterval {
+		return
+	}
+	h.requestInterval -= h.requestInterval / 10
+	if h.requestInterval < h.minRequestInterval {
+		h.requestInterval = h.minRequestInterval
+	}
+	if h.minRequestInterval == 0 && h.requestInterval < rateToInterval(DefaultAdaptiveRequestsPerSecond) {
+		h.requestInterval = 0
+	}
+}
+
+// throttledReader limits the bandwidth of a request body to the bandwidth of its host.
+type throttledReader struct {
+	io.ReadCloser
+	ctx  context.Context
+	host *hostLimiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > maxThrottledReadSize {
+		p = p[:maxThrottledReadSize]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if sleepErr := sleep(r.ctx, r.host.reserveBytes(n)); sleepErr != nil {
+			return n, sleepErr
+		}
+	}
+	return n, err
+}
+
+// isUploadRequest returns whether the request uploads content to the registry.
+func isUploadRequest(req *http.Request) bool {
+	return req.Method == http.MethodPost || req.Method == http.MethodPut || req.Method == http.MethodPatch
+}
+
+func rateToInterval(perSecond float64) time.Duration {
+	return time.Duration(float64(time.Second) / perSecond)
+}
+
+// sleep blocks for the given duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
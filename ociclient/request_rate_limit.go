@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithRequestsPerSecond limits the number of requests per second that the client sends to registries.
+// The limit is shared by all concurrent operations of the client and also applies to retries.
+// Requests are not limited if the value is 0.
+type WithRequestsPerSecond float64
+
+func (c WithRequestsPerSecond) ApplyOption(options *Options) {
+	options.RequestsPerSecond = float64(c)
+}
+
+// requestRateLimiter is a http.RoundTripper that delays requests so that the configured rate is not exceeded.
+type requestRateLimiter struct {
+	next     http.RoundTripper
+	interval time.Duration
+
+	mux      sync.Mutex
+	nextSlot time.Time
+}
+
+func newRequestRateLimiter(next http.RoundTripper, requestsPerSecond float64) *requestRateLimiter {
+	return &requestRateLimiter{
+		next:     next,
+		interval: time.Duration(float64(time.Second) / requestsPerSecond),
+	}
+}
+
+func (l *requestRateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if wait := l.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	return l.next.RoundTrip(req)
+}
+
+// reserve reserves the next free slot and returns the duration until the slot starts.
+func (l *requestRateLimiter) reserve() time.Duration {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	now := time.Now()
+	if l.nextSlot.Before(now) {
+		l.nextSlot = now
+	}
+	wait := l.nextSlot.Sub(now)
+	l.nextSlot = l.nextSlot.Add(l.interval)
+	return wait
+}
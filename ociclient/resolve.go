@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// ResolvedReference is the normalized result of resolving an oci reference.
+type ResolvedReference struct {
+	// Registry is the host of the reference, e.g. "eu.gcr.io".
+	Registry string `json:"registry"`
+	// Repository is the repository of the reference without its registry.
+	Repository string `json:"repository"`
+	// Tag is the tag of the reference.
+	// It is empty if the artifact was referenced by its digest.
+	Tag string `json:"tag,omitempty"`
+	// Digest is the digest of the resolved manifest.
+	Digest digest.Digest `json:"digest"`
+	// MediaType is the media type of the resolved manifest.
+	MediaType string `json:"mediaType"`
+	// Size is the size of the resolved manifest.
+	Size int64 `json:"size"`
+}
+
+// ResolveReference resolves the reference and returns the structured result.
+func ResolveReference(ctx context.Context, resolver Resolver, ref string) (*ResolvedReference, error) {
+	refspec, err := oci.ParseRef(ref)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse reference %q: %w", ref, err)
+	}
+	_, desc, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	resolved := &ResolvedReference{
+		Registry:   refspec.Host,
+		Repository: refspec.Repository,
+		Digest:     desc.Digest,
+		MediaType:  desc.MediaType,
+		Size:       desc.Size,
+	}
+	if refspec.Tag != nil {
+		resolved.Tag = *refspec.Tag
+	}
+	return resolved, nil
+}
+
+// Name returns the repository including its registry.
+func (r ResolvedReference) Name() string {
+	refspec := oci.RefSpec{Host: r.Registry, Repository: r.Repository}
+	return refspec.Name()
+}
+
+// TagRef returns the reference to the given tag in the repository of the resolved reference.
+func (r ResolvedReference) TagRef(tag string) string {
+	return fmt.Sprintf("%s:%s", r.Name(), tag)
+}
+
+// DigestRef returns the immutable reference to the resolved manifest.
+func (r ResolvedReference) DigestRef() string {
+	return fmt.Sprintf("%s@%s", r.Name(), r.Digest)
+}
+
+// String returns the reference that was resolved.
+// Artifacts that were referenced by their digest are returned by their digest reference.
+func (r ResolvedReference) String() string {
+	if len(r.Tag) == 0 {
+		return r.DigestRef()
+	}
+	return r.TagRef(r.Tag)
+}
+
+// Descriptor returns the oci descriptor of the resolved manifest.
+func (r ResolvedReference) Descriptor() ocispecv1.Descriptor {
+	return ocispecv1.Descriptor{
+		MediaType: r.MediaType,
+		Digest:    r.Digest,
+		Size:      r.Size,
+	}
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultRetryInitialBackoff is the default backoff before the first retry.
+	DefaultRetryInitialBackoff = 500 * time.Millisecond
+	// DefaultRetryMaxBackoff is the default maximal backoff between two retries.
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
+// RetryPolicy configures the retries of requests that failed with a transient error.
+// Transient errors are rate limited requests (429), server errors (5xx) and network errors like connection resets.
+// The policy applies to all requests of the client (e.g. Resolve, Fetch, PushManifest, ListTags and ListRepositories).
+// Requests whose body cannot be replayed (e.g. streamed blob uploads) are not retried.
+type RetryPolicy struct {
+	// MaxRetries is the maximal number of retries of a single request.
+	MaxRetries int
+	// InitialBackoff is the backoff before the first retry.
+	// The backoff is doubled with every further retry.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximal backoff between two retries.
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the backoff (0 to 1) that is randomly added to or subtracted from the backoff.
+	Jitter float64
+	// Budget is the maximal number of retries across all requests of the client.
+	// The number of retries is not limited if the budget is 0.
+	Budget int
+}
+
+// WithRetryPolicy configures the client to retry requests that failed with a transient error.
+func WithRetryPolicy(policy RetryPolicy) WithRetryPolicyOption {
+	return WithRetryPolicyOption{
+		RetryPolicy: policy,
+	}
+}
+
+// WithRetryPolicyOption configures the client to retry requests that failed with a transient error.
+type WithRetryPolicyOption struct {
+	RetryPolicy
+}
+
+func (c WithRetryPolicyOption) ApplyOption(options *Options) {
+	policy := c.RetryPolicy
+	options.RetryPolicy = &policy
+}
+
+// retryTransport is a http.RoundTripper that retries requests that failed with a transient error.
+type retryTransport struct {
+	next   http.RoundTripper
+	policy RetryPolicy
+
+	mux     sync.Mutex
+	retries int
+}
+
+func newRetryTransport(next http.RoundTripper, policy RetryPolicy) *retryTransport {
+	if policy.InitialBackoff == 0 {
+		policy.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	return &retryTransport{
+		next:   next,
+		policy: policy,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := t.policy.InitialBackoff
+	// attempt is the request that is sent. Retries send a clone with a replayed body
+	// as a round tripper must not modify the request of the caller.
+	attempt := req
+	for retry := 0; ; retry++ {
+		resp, err := t.next.RoundTrip(attempt)
+		if !isTransientError(resp, err) || retry >= t.policy.MaxRetries || !t.canReplay(req) || !t.takeBudget() {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		attempt = req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("unable to replay request body: %w", err)
+			}
+			attempt.Body = body
+		}
+
+		timer := time.NewTimer(t.jitter(backoff))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > t.policy.MaxBackoff {
+			backoff = t.policy.MaxBackoff
+		}
+	}
+}
+
+// canReplay returns whether the body of the request can be sent again.
+func (t *retryTransport) canReplay(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// takeBudget reserves a retry from the retry budget.
+func (t *retryTransport) takeBudget() bool {
+	if t.policy.Budget == 0 {
+		return true
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if t.retries >= t.policy.Budget {
+		return false
+	}
+	t.retries++
+	return true
+}
+
+func (t *retryTransport) jitter(d time.Duration) time.Duration {
+	if t.policy.Jitter <= 0 {
+		return d
+	}
+	delta := t.policy.Jitter * float64(d)
+	return d - time.Duration(delta) + time.Duration(rand.Float64()*2*delta)
+}
+
+// isTransientError returns whether the request failed with an error that is likely to succeed on a retry.
+func isTransientError(resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return true
+		}
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
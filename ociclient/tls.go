@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// TLSConfig configures the tls connections to oci registries.
+type TLSConfig struct {
+	// CAFile is the path to a pem encoded bundle of ca certificates that are trusted in addition to the system roots.
+	CAFile string `json:"caFile,omitempty"`
+	// CertFile is the path to a pem encoded client certificate that is presented to the registry (mTLS).
+	// KeyFile must be defined if a client certificate is defined.
+	CertFile string `json:"certFile,omitempty"`
+	// KeyFile is the path to the pem encoded private key of the client certificate.
+	KeyFile string `json:"keyFile,omitempty"`
+	// InsecureSkipVerify disables the verification of the certificate of the registry.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// WithTLSConfig configures the tls connections to all oci registries that have no registry specific tls configuration.
+type WithTLSConfig TLSConfig
+
+func (c WithTLSConfig) ApplyOption(options *Options) {
+	cfg := TLSConfig(c)
+	options.TLSConfig = &cfg
+}
+
+// WithRegistryTLSConfig configures the tls connections per registry host (e.g. "registry.example.com:5000").
+// The configuration of a registry replaces the configuration of WithTLSConfig for that registry.
+type WithRegistryTLSConfig map[string]TLSConfig
+
+func (c WithRegistryTLSConfig) ApplyOption(options *Options) {
+	if options.RegistryTLSConfigs == nil {
+		options.RegistryTLSConfigs = map[string]TLSConfig{}
+	}
+	for host, cfg := range c {
+		options.RegistryTLSConfigs[host] = cfg
+	}
+}
+
+// build creates the tls client configuration.
+func (c TLSConfig) build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if len(c.CAFile) != 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		caData, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ca file %q: %w", c.CAFile, err)
+		}
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no pem encoded certificate found in ca file %q", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(c.CertFile) != 0 || len(c.KeyFile) != 0 {
+		if len(c.CertFile) == 0 || len(c.KeyFile) == 0 {
+			return nil, errors.New("a client certificate requires a cert file and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// tlsTransport is a http.RoundTripper that uses a transport with the tls configuration of the registry host.
+type tlsTransport struct {
+	defaultTransport http.RoundTripper
+	hosts            map[string]http.RoundTripper
+}
+
+// newTLSTransport creates a transport that uses the given tls configurations.
+// The base transport must be a *http.Transport so that it can be cloned with the tls configurations.
+func newTLSTransport(base http.RoundTripper, defaultConfig *TLSConfig, hostConfigs map[string]TLSConfig) (http.RoundTripper, error) {
+	baseTransport, ok := base.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("a tls configuration requires a *http.Transport but the http client uses a %T", base)
+	}
+	newTransport := func(cfg TLSConfig) (http.RoundTripper, error) {
+		tlsConfig, err := cfg.build()
+		if err != nil {
+			return nil, err
+		}
+		trp := baseTransport.Clone()
+		trp.TLSClientConfig = tlsConfig
+		return trp, nil
+	}
+
+	t := &tlsTransport{
+		defaultTransport: base,
+		hosts:            map[string]http.RoundTripper{},
+	}
+	if defaultConfig != nil {
+		trp, err := newTransport(*defaultConfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tls configuration: %w", err)
+		}
+		t.defaultTransport = trp
+	}
+	for host, cfg := range hostConfigs {
+		trp, err := newTransport(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tls configuration for %q: %w", host, err)
+		}
+		t.hosts[host] = trp
+	}
+	return t, nil
+}
+
+func (t *tlsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if trp, ok := t.hosts[req.URL.Host]; ok {
+		return trp.RoundTrip(req)
+	}
+	return t.defaultTransport.RoundTrip(req)
+}
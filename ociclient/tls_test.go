@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ociclient_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/credentials"
+)
+
+var _ = Describe("TLS", func() {
+
+	var (
+		server   *httptest.Server
+		host     string
+		dir      string
+		caFile   string
+		certFile string
+		keyFile  string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "tls-")
+		Expect(err).ToNot(HaveOccurred())
+
+		// the registry requires a client certificate
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			if req.URL.Path != "/v2/" {
+				_, _ = w.Write([]byte(`{"tags": [ "0.0.1" ]}`))
+			}
+		}))
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		server.StartTLS()
+		hostUrl, err := url.Parse(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		host = hostUrl.Host
+
+		caFile = filepath.Join(dir, "ca.pem")
+		Expect(ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), os.ModePerm)).To(Succeed())
+
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "client"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		certData, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).ToNot(HaveOccurred())
+		keyData, err := x509.MarshalECPrivateKey(key)
+		Expect(err).ToNot(HaveOccurred())
+		certFile = filepath.Join(dir, "client.pem")
+		keyFile = filepath.Join(dir, "client-key.pem")
+		Expect(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certData}), os.ModePerm)).To(Succeed())
+		Expect(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyData}), os.ModePerm)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should connect to a registry with a custom ca and a client certificate of the registry tls config", func() {
+		client, err := ociclient.NewClient(logr.Discard(),
+			ociclient.WithKeyring(credentials.New()),
+			ociclient.WithHTTPClient(http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}),
+			ociclient.WithRegistryTLSConfig{
+				host: {CAFile: caFile, CertFile: certFile, KeyFile: keyFile},
+			})
+		Expect(err).ToNot(HaveOccurred())
+		tags, err := client.ListTags(context.TODO(), host+"/myproject/myimage")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tags).To(ConsistOf("0.0.1"))
+	})
+
+	It("should use the default tls config for registries without tls config", func() {
+		client, err := ociclient.NewClient(logr.Discard(),
+			ociclient.WithKeyring(credentials.New()),
+			ociclient.WithHTTPClient(http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}),
+			ociclient.WithTLSConfig{CAFile: caFile},
+			ociclient.WithRegistryTLSConfig{
+				"other.example.com": {CertFile: certFile, KeyFile: keyFile},
+			})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = client.ListTags(context.TODO(), host+"/myproject/myimage")
+		Expect(err).To(HaveOccurred(), "the registry should reject the connection without client certificate")
+	})
+
+	It("should fail if a client certificate is defined without key", func() {
+		_, err := ociclient.NewClient(logr.Discard(),
+			ociclient.WithKeyring(credentials.New()),
+			ociclient.WithTLSConfig{CertFile: certFile})
+		Expect(err).To(HaveOccurred())
+	})
+
+})
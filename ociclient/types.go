@@ -8,6 +8,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"time"
 
 	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -26,13 +27,25 @@ type Client interface {
 	// PushBlob uploads the blob for the given ocispec Descriptor to the given ref
 	PushBlob(ctx context.Context, ref string, desc ocispecv1.Descriptor, opts ...PushOption) error
 
+	// PushBlobFromReader streams the blob for the given ocispec Descriptor from the reader to the given ref
+	// without using a store or the cache.
+	PushBlobFromReader(ctx context.Context, ref string, desc ocispecv1.Descriptor, r io.Reader) error
+
+	// MountBlob mounts the blob for the given ocispec Descriptor from the repository of fromRef into the repository of ref.
+	// An error that wraps ErrBlobMountNotSupported is returned if the blob cannot be mounted and has to be pushed instead.
+	MountBlob(ctx context.Context, ref, fromRef string, desc ocispecv1.Descriptor) error
+
 	// GetRawManifest returns the raw manifest for a reference.
 	// The returned manifest can either be single arch or multi arch (image index/manifest list)
+	// or of any media type that is accepted by the client (see WithAcceptMediaTypes and WithAcceptMediaTypesContext).
+	// The media type of the manifest is returned in the descriptor.
 	GetRawManifest(ctx context.Context, ref string) (ocispecv1.Descriptor, []byte, error)
 
 	// PushRawManifest uploads the given raw manifest to the given reference.
 	// If the manifest is multi arch (image index/manifest list), only the multi arch manifest is pushed.
 	// The referenced single arch manifests must be pushed individiually before.
+	// The upload is skipped if the reference already points to a manifest with the same digest,
+	// use WithPushStatus to get the result of the push.
 	PushRawManifest(ctx context.Context, ref string, desc ocispecv1.Descriptor, rawManifest []byte, opts ...PushOption) error
 
 	// GetManifest returns the ocispec Manifest for a reference
@@ -40,6 +53,7 @@ type Client interface {
 	GetManifest(ctx context.Context, ref string) (*ocispecv1.Manifest, error)
 
 	// PushManifest uploads the given Manifest to the given reference.
+	// Like PushRawManifest, the upload is skipped if the manifest already exists.
 	// Deprecated: Please prefer PushRawManifest instead
 	PushManifest(ctx context.Context, ref string, manifest *ocispecv1.Manifest, opts ...PushOption) error
 
@@ -91,8 +105,30 @@ type PushOption interface {
 type PushOptions struct {
 	// Store is the oci cache to be used by the client
 	Store Store
+	// Status is set to the result of a manifest push if defined.
+	Status *PushStatus
+	// ImmutableTagFallback configures the fallback of a manifest push if the target tag is immutable.
+	// A TagImmutableError is returned if no fallback is defined.
+	ImmutableTagFallback *WithImmutableTagFallbackOption
+	// LayerConcurrency is the maximal number of layers of a manifest that are pushed in parallel.
+	// Layers are pushed sequentially if the value is 0 or 1.
+	LayerConcurrency int
 }
 
+// PushStatus describes the result of a manifest push.
+type PushStatus string
+
+const (
+	// PushStatusPushed means that the manifest has been uploaded to the registry.
+	PushStatusPushed PushStatus = "Pushed"
+	// PushStatusAlreadyExists means that the reference already pointed to a manifest with the same digest
+	// so that the upload has been skipped.
+	PushStatusAlreadyExists PushStatus = "AlreadyExists"
+	// PushStatusPushedByDigest means that the target tag is immutable so that the manifest
+	// has been uploaded by digest and optionally with an alternate tag (see WithImmutableTagFallback).
+	PushStatusPushedByDigest PushStatus = "PushedByDigest"
+)
+
 // ApplyOptions applies the given list options on these options,
 // and then returns itself (for convenient chaining).
 func (o *PushOptions) ApplyOptions(opts []PushOption) *PushOptions {
@@ -104,6 +140,12 @@ func (o *PushOptions) ApplyOptions(opts []PushOption) *PushOptions {
 	return o
 }
 
+func (o *PushOptions) setStatus(status PushStatus) {
+	if o.Status != nil {
+		*o.Status = status
+	}
+}
+
 // WithStore configures a store for the oci push.
 func WithStore(store Store) WithStoreOption {
 	return WithStoreOption{
@@ -120,6 +162,30 @@ func (c WithStoreOption) ApplyPushOption(options *PushOptions) {
 	options.Store = c.Store
 }
 
+// WithPushStatus configures a status that is set to the result of a manifest push.
+func WithPushStatus(status *PushStatus) WithPushStatusOption {
+	return WithPushStatusOption{
+		Status: status,
+	}
+}
+
+// WithPushStatusOption configures a status that is set to the result of a manifest push.
+type WithPushStatusOption struct {
+	Status *PushStatus
+}
+
+func (c WithPushStatusOption) ApplyPushOption(options *PushOptions) {
+	options.Status = c.Status
+}
+
+// WithLayerConcurrency configures the maximal number of layers of a manifest that are pushed in parallel.
+// The layers are also read from the store in parallel, e.g. when they are fetched from the source registry during a copy.
+type WithLayerConcurrency int
+
+func (c WithLayerConcurrency) ApplyPushOption(options *PushOptions) {
+	options.LayerConcurrency = int(c)
+}
+
 // Options contains all client options to configure the oci client.
 type Options struct {
 	// Paths configures local paths to search for docker configuration files
@@ -144,6 +210,68 @@ type Options struct {
 	CustomMediaTypes sets.String
 
 	HTTPClient *http.Client
+
+	// SkipContentDigestVerification disables the verification of the Docker-Content-Digest header
+	// that is returned by the registry on manifest requests.
+	SkipContentDigestVerification bool
+
+	// RateLimit configures the handling of rate limited list operations.
+	RateLimit *RateLimitOptions
+
+	// RetryPolicy configures the retries of requests that failed with a transient error.
+	// Requests are not retried if no policy is defined.
+	RetryPolicy *RetryPolicy
+
+	// ChunkSize enables chunked blob uploads if greater than 0.
+	// Blobs that are larger than the chunk size are uploaded in multiple PATCH requests of that size.
+	ChunkSize int64
+
+	// RequestsPerSecond limits the number of requests per second that are sent to registries.
+	// Requests are not limited if the value is 0.
+	RequestsPerSecond float64
+
+	// RegistryLimits are the limits per registry host.
+	RegistryLimits map[string]RegistryLimits
+
+	// AuthScopeTTL is the time after which authenticated transports are not reused anymore
+	// and the credentials of a repository are resolved again.
+	// DefaultAuthScopeTTL is used if the value is 0, the transports are reused until their credentials expire
+	// or the credentials of the keyring change if the value is negative.
+	AuthScopeTTL time.Duration
+
+	// TLSConfig configures the tls connections to all registries without a registry specific tls configuration.
+	TLSConfig *TLSConfig
+
+	// RegistryTLSConfigs are the tls configurations per registry host.
+	RegistryTLSConfigs map[string]TLSConfig
+
+	// PinStore contains the digests that tagged references are expected to resolve to.
+	// Resolving a tagged reference fails if the registry returns another digest than the pinned one.
+	PinStore PinStore
+
+	// TrustOnFirstUse pins tagged references that are not yet pinned to the digest they resolve to the first time.
+	TrustOnFirstUse bool
+
+	// StrictConformance disables all workarounds for registry specific behavior (e.g. the page size of list requests
+	// and the conversion of docker schema 1 manifests) and reports violations of the oci distribution spec as warnings.
+	StrictConformance bool
+
+	// ConformanceWarningHandler is called for every violation of the oci distribution spec in strict conformance mode.
+	// The violations are logged if no handler is defined.
+	ConformanceWarningHandler ConformanceWarningHandler
+
+	// Offline disables all network access.
+	// Manifests and blobs are served exclusively from the cache and the OfflineLayouts.
+	Offline bool
+
+	// OfflineLayouts are the oci image layouts that manifests and blobs are served from in offline mode.
+	OfflineLayouts []*OCILayout
+
+	// AcceptMediaTypes are the media types that are accepted when manifests are resolved or fetched.
+	// Manifests of these media types are returned by GetRawManifest even if they are no image manifests or indexes.
+	// The default media types of docker and oci image manifests and indexes are used if empty.
+	// The media types can be overwritten per request with WithAcceptMediaTypesContext.
+	AcceptMediaTypes []string
 }
 
 // Option is the interface to specify different cache options
@@ -217,6 +345,63 @@ func (c AllowPlainHttp) ApplyOption(options *Options) {
 	options.AllowPlainHttp = bool(c)
 }
 
+// SkipContentDigestVerification sets the skip content digest verification flag.
+type SkipContentDigestVerification bool
+
+func (c SkipContentDigestVerification) ApplyOption(options *Options) {
+	options.SkipContentDigestVerification = bool(c)
+}
+
+// StrictConformance sets the strict conformance flag.
+type StrictConformance bool
+
+func (c StrictConformance) ApplyOption(options *Options) {
+	options.StrictConformance = bool(c)
+}
+
+// WithConformanceWarningHandler configures the handler of violations of the oci distribution spec in strict conformance mode.
+type WithConformanceWarningHandler ConformanceWarningHandler
+
+func (c WithConformanceWarningHandler) ApplyOption(options *Options) {
+	options.ConformanceWarningHandler = ConformanceWarningHandler(c)
+}
+
+// WithChunkSize configures the chunk size for chunked blob uploads.
+// Blobs that are larger than the chunk size are uploaded in chunks
+// which is required for registries with a small request size limit.
+type WithChunkSize int64
+
+func (c WithChunkSize) ApplyOption(options *Options) {
+	options.ChunkSize = int64(c)
+}
+
+// WithAuthScopeTTL configures the time after which authenticated transports are not reused anymore.
+type WithAuthScopeTTL time.Duration
+
+func (c WithAuthScopeTTL) ApplyOption(options *Options) {
+	options.AuthScopeTTL = time.Duration(c)
+}
+
+// WithPinStore configures the pin store that is used to verify the digests of resolved tagged references.
+// If trust on first use is enabled, references without pin are pinned to the digest they first resolve to.
+func WithPinStore(store PinStore, trustOnFirstUse bool) WithPinStoreOption {
+	return WithPinStoreOption{
+		PinStore:        store,
+		TrustOnFirstUse: trustOnFirstUse,
+	}
+}
+
+// WithPinStoreOption configures the pin store of the client.
+type WithPinStoreOption struct {
+	PinStore        PinStore
+	TrustOnFirstUse bool
+}
+
+func (c WithPinStoreOption) ApplyOption(options *Options) {
+	options.PinStore = c.PinStore
+	options.TrustOnFirstUse = c.TrustOnFirstUse
+}
+
 // WithHTTPClient configures the http client.
 type WithHTTPClient http.Client
 
@@ -69,6 +69,16 @@ func ParseImageRef(ref string) (repository, version string, err error) {
 	return
 }
 
+// DigestRef returns the reference to the given digest in the repository of the image ref.
+// In contrast to oci.RefSpec the registry and repository are not normalized.
+func DigestRef(ref string, dgst digest.Digest) (string, error) {
+	repo, _, err := ParseImageRef(ref)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s@%s", repo, dgst), nil
+}
+
 // TagIsDigest checks if a tag is a digest.
 func TagIsDigest(tag string) bool {
 	_, err := digest.Parse(tag)
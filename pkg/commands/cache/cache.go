@@ -29,5 +29,6 @@ func NewCacheCommand(ctx context.Context) *cobra.Command {
 	}
 	cmd.AddCommand(NewInfoCommand(ctx))
 	cmd.AddCommand(NewPruneCommand(ctx))
+	cmd.AddCommand(NewGCCommand(ctx))
 	return cmd
 }
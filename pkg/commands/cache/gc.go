@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cachecmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	cache2 "github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// GCOptions describes the options for garbage collecting the cache
+type GCOptions struct {
+	// MaxSize is the size the cache is reduced to.
+	// See the kubernetes quantity docs for detailed description of the format.
+	MaxSize string
+	// MaxAge is the duration after which blobs that have not been accessed are deleted.
+	MaxAge time.Duration
+
+	// maxSizeBytes is the parsed max size in bytes.
+	maxSizeBytes int64
+}
+
+// NewGCCommand creates a new garbage collect cache command
+func NewGCCommand(ctx context.Context) *cobra.Command {
+	opts := &GCOptions{}
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Garbage collects the least recently used cached files",
+		Long: `
+gc deletes all cached files that have not been accessed within the given max age.
+Afterwards the least recently used files are deleted until the size of the cache does not exceed the given max size.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+func (o *GCOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.MaxSize, "max-size", "", "size the cache is reduced to (e.g. 5Gi)")
+	fs.DurationVar(&o.MaxAge, "max-age", 0, "duration after which cached files that have not been accessed are deleted (e.g. 720h)")
+}
+
+func (o *GCOptions) Complete() error {
+	if len(o.MaxSize) == 0 && o.MaxAge == 0 {
+		return errors.New("at least one of --max-size or --max-age has to be defined")
+	}
+	if o.MaxAge < 0 {
+		return errors.New("the max age must not be negative")
+	}
+	if len(o.MaxSize) != 0 {
+		quantity, err := resource.ParseQuantity(o.MaxSize)
+		if err != nil {
+			return fmt.Errorf("unable to parse max size %q: %w", o.MaxSize, err)
+		}
+		o.maxSizeBytes = quantity.Value()
+		if o.maxSizeBytes <= 0 {
+			return fmt.Errorf("the max size %q must be greater than 0", o.MaxSize)
+		}
+	}
+	return nil
+}
+
+func (o *GCOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	cacheDir, err := utils.CacheDir()
+	if err != nil {
+		return fmt.Errorf("unable to get oci cache directory: %w", err)
+	}
+
+	cache, err := cache2.NewCache(log, cache2.WithBasePath(cacheDir))
+	if err != nil {
+		return err
+	}
+	defer cache.Close()
+	res, err := cache.GarbageCollect(o.maxSizeBytes, o.MaxAge)
+	if err != nil {
+		return fmt.Errorf("unable to garbage collect the cache: %w", err)
+	}
+
+	fmt.Printf("Successfully deleted %d items (%s) from the cache %s\n", res.ItemsCount, utils.BytesString(uint64(res.Size), 2), cacheDir)
+	return nil
+}
@@ -54,14 +54,20 @@ func (o *InfoOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSyste
 	}
 
 	type extendedCacheInfo struct {
+		Location    string `json:"Location"`
 		Size        string `json:"Size,omitempty"`
 		CurrentSize string `json:"CurrentSize"`
 		ItemsCount  int64  `json:"Items"`
 		Usage       string `json:"Usage,omitempty"`
+		TempFiles   int64  `json:"TempFiles"`
+		TempSize    string `json:"TempSize"`
 	}
 	eInfo := extendedCacheInfo{
+		Location:    cacheDir,
 		CurrentSize: utils.BytesString(uint64(info.CurrentSize), 2),
 		ItemsCount:  info.ItemsCount,
+		TempFiles:   info.TempFilesCount,
+		TempSize:    utils.BytesString(uint64(info.TempSize), 2),
 	}
 	if info.Size != 0 {
 		eInfo.Size = utils.BytesString(uint64(info.Size), 2)
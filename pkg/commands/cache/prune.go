@@ -6,13 +6,17 @@ package cachecmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	cache2 "github.com/gardener/component-cli/ociclient/cache"
 	"github.com/gardener/component-cli/pkg/logger"
@@ -20,24 +24,66 @@ import (
 )
 
 // PruneOptions describes the options for pruning the cache
-type PruneOptions struct{}
+type PruneOptions struct {
+	// OlderThan is the duration after which cached files that have not been accessed are pruned.
+	OlderThan time.Duration
+	// Digests are the digests of the cached blobs that are pruned.
+	Digests []string
+
+	// digests are the parsed digests.
+	digests []digest.Digest
+}
 
 // NewPruneCommand creates a new prune cache command
 func NewPruneCommand(ctx context.Context) *cobra.Command {
 	opts := &PruneOptions{}
 	cmd := &cobra.Command{
 		Use:   "prune",
-		Short: "Prunes all currently cached files",
+		Short: "Prunes cached files",
+		Long: `
+prune deletes cached files from the oci cache.
+By default all cached files are deleted.
+
+If --older-than is defined, only the files that have not been accessed within the given duration are deleted.
+If --digest is defined, only the blobs with the given digests are deleted.
+If both are defined, the files that match any of the conditions are deleted.
+`,
 		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
 			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
 				fmt.Println(err.Error())
 				os.Exit(1)
 			}
 		},
 	}
+	opts.AddFlags(cmd.Flags())
 	return cmd
 }
 
+func (o *PruneOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.DurationVar(&o.OlderThan, "older-than", 0, "only prune cached files that have not been accessed within the given duration (e.g. 720h)")
+	fs.StringArrayVar(&o.Digests, "digest", []string{}, "only prune the cached blob with the given digest. Can be specified multiple times")
+}
+
+func (o *PruneOptions) Complete() error {
+	if o.OlderThan < 0 {
+		return errors.New("the duration of --older-than must not be negative")
+	}
+	o.digests = make([]digest.Digest, len(o.Digests))
+	for i, dgst := range o.Digests {
+		parsed, err := digest.Parse(dgst)
+		if err != nil {
+			return fmt.Errorf("invalid digest %q: %w", dgst, err)
+		}
+		o.digests[i] = parsed
+	}
+	return nil
+}
+
 func (o *PruneOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
 	cacheDir, err := utils.CacheDir()
 	if err != nil {
@@ -48,14 +94,36 @@ func (o *PruneOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSyst
 	if err != nil {
 		return err
 	}
-	info, err := cache.Info()
-	if err != nil {
-		return err
+	defer cache.Close()
+
+	if o.OlderThan == 0 && len(o.digests) == 0 {
+		info, err := cache.Info()
+		if err != nil {
+			return err
+		}
+		if err := cache.Prune(); err != nil {
+			return err
+		}
+		fmt.Printf("Successfully pruned %d items from the cache %s\n", info.ItemsCount, cacheDir)
+		return nil
 	}
-	if err := cache.Prune(); err != nil {
-		return err
+
+	res := cache2.EvictionResult{}
+	if o.OlderThan != 0 {
+		res, err = cache.GarbageCollect(0, o.OlderThan)
+		if err != nil {
+			return fmt.Errorf("unable to prune cached files older than %s: %w", o.OlderThan, err)
+		}
+	}
+	if len(o.digests) != 0 {
+		deleted, err := cache.Delete(o.digests...)
+		if err != nil {
+			return fmt.Errorf("unable to prune cached blobs: %w", err)
+		}
+		res.ItemsCount += deleted.ItemsCount
+		res.Size += deleted.Size
 	}
 
-	fmt.Printf("Successfully pruned %d items from the cache %s\n", info.ItemsCount, cacheDir)
+	fmt.Printf("Successfully pruned %d items (%s) from the cache %s\n", res.ItemsCount, utils.BytesString(uint64(res.Size), 2), cacheDir)
 	return nil
 }
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package component
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+// NewComponentCommand creates a new component command that works with the component versions of a repository context.
+func NewComponentCommand(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "component",
+		Aliases: []string{"components", "comp"},
+		Short:   "command to inspect the component versions of a repository context",
+	}
+	cmd.AddCommand(NewListCommand(ctx))
+	cmd.AddCommand(NewDiffCommand(ctx))
+	cmd.AddCommand(NewTreeCommand(ctx))
+	return cmd
+}
@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// DiffOptions defines all options for the diff command.
+type DiffOptions struct {
+	// BaseUrl is the oci registry where the components are stored.
+	BaseUrl string
+	// ToBaseUrl is the oci registry where the second component is stored.
+	// Defaults to the BaseUrl.
+	ToBaseUrl string
+	// From is the component version that is compared.
+	From components.ComponentVersion
+	// To is the component version that From is compared to.
+	To components.ComponentVersion
+
+	ComponentNameMapping string
+
+	// OutputFormat defines the format of the output.
+	OutputFormat string
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+}
+
+// NewDiffCommand creates a new command that compares two component versions.
+func NewDiffCommand(ctx context.Context) *cobra.Command {
+	opts := &DiffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff BASE_URL (COMPONENT_NAME FROM_VERSION TO_VERSION | FROM_COMPONENT TO_COMPONENT)",
+		Args:  cobra.RangeArgs(3, 4),
+		Short: "compares two component versions",
+		Long: `
+diff resolves two component descriptors and prints the added, removed and modified resources, sources,
+component references and labels, e.g. to write release notes or to review an upgrade.
+
+The command can be called in 2 different ways:
+
+diff [baseurl] [component name] [from version] [to version]
+- compares two versions of the same component.
+
+diff [baseurl] [from component] [to component]
+- compares two different components of the form <name>:<version>.
+
+The second component is resolved in the repository context given with "--to-base-url" if set.
+Elements are matched by their identity (name and extra identity).
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run resolves the component descriptors and prints their diff to stdout.
+func (o *DiffOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	return o.RunWithResolver(ctx, schema.NewResolver(ociClient), os.Stdout)
+}
+
+// RunWithResolver resolves the component descriptors with the given resolver and writes their diff to the writer.
+func (o *DiffOptions) RunWithResolver(ctx context.Context, resolver ctf.ComponentResolver, w io.Writer) error {
+	fromCd, err := o.resolve(ctx, resolver, o.BaseUrl, o.From)
+	if err != nil {
+		return err
+	}
+	toCd, err := o.resolve(ctx, resolver, o.ToBaseUrl, o.To)
+	if err != nil {
+		return err
+	}
+	diff := components.DiffComponentDescriptors(fromCd, toCd)
+
+	switch o.OutputFormat {
+	case "json":
+		out, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case "yaml":
+		out, err := yaml.Marshal(diff)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(w, string(out))
+		return err
+	}
+
+	if len(diff.Changes) == 0 {
+		_, err := fmt.Fprintf(w, "%s and %s do not differ\n", diff.From, diff.To)
+		return err
+	}
+	fmt.Fprintf(w, "Changes from %s to %s:\n", diff.From, diff.To)
+	for _, change := range diff.Changes {
+		fmt.Fprintf(w, "%s\n", change)
+		for _, field := range change.Fields {
+			fmt.Fprintf(w, "  %s: %s -> %s\n", field.Field, printableValue(field.Old), printableValue(field.New))
+		}
+	}
+	return nil
+}
+
+func (o *DiffOptions) resolve(ctx context.Context, resolver ctf.ComponentResolver, baseUrl string, cv components.ComponentVersion) (*cdv2.ComponentDescriptor, error) {
+	repoCtx := cdv2.NewOCIRegistryRepository(baseUrl, cdv2.ComponentNameMapping(o.ComponentNameMapping))
+	cd, err := resolver.Resolve(ctx, repoCtx, cv.Name, cv.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve component descriptor %s: %w", cv, err)
+	}
+	return cd, nil
+}
+
+func printableValue(value string) string {
+	if len(value) == 0 {
+		return "<none>"
+	}
+	return value
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *DiffOptions) Complete(args []string) error {
+	var err error
+	switch len(args) {
+	case 3:
+		o.BaseUrl = args[0]
+		if o.From, err = components.ParseComponentVersion(args[1]); err != nil {
+			return err
+		}
+		if o.To, err = components.ParseComponentVersion(args[2]); err != nil {
+			return err
+		}
+	case 4:
+		o.BaseUrl = args[0]
+		o.From = components.ComponentVersion{Name: args[1], Version: args[2]}
+		o.To = components.ComponentVersion{Name: args[1], Version: args[3]}
+	default:
+		return fmt.Errorf("illegal number of arguments: %d", len(args))
+	}
+	if len(o.ToBaseUrl) == 0 {
+		o.ToBaseUrl = o.BaseUrl
+	}
+
+	if len(o.OciOptions.CacheDir) == 0 {
+		cliHomeDir, err := constants.CliHomeDir()
+		if err != nil {
+			return err
+		}
+		o.OciOptions.CacheDir = filepath.Join(cliHomeDir, "components")
+		if err := os.MkdirAll(o.OciOptions.CacheDir, os.ModePerm); err != nil {
+			return fmt.Errorf("unable to create cache directory %s: %w", o.OciOptions.CacheDir, err)
+		}
+	}
+
+	if len(o.BaseUrl) == 0 {
+		return errors.New("the base url must be provided")
+	}
+	switch o.OutputFormat {
+	case "text", "json", "yaml":
+	default:
+		return fmt.Errorf("unsupported output format %q: must be text, json or yaml", o.OutputFormat)
+	}
+	return nil
+}
+
+func (o *DiffOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.ToBaseUrl, "to-base-url", "", "[OPTIONAL] oci registry where the second component is stored. Defaults to the base url")
+	fs.StringVar(&o.ComponentNameMapping, "component-name-mapping", string(cdv2.OCIRegistryURLPathMapping), "[OPTIONAL] repository context name mapping")
+	fs.StringVarP(&o.OutputFormat, "output", "o", "text", "output format of the diff. One of text, json or yaml")
+	o.OciOptions.AddFlags(fs)
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package component_test
+
+import (
+	"bytes"
+	"context"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/component"
+)
+
+// staticResolver resolves component descriptors from a static list independent of the repository context.
+type staticResolver []*cdv2.ComponentDescriptor
+
+func (r staticResolver) Resolve(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	cd, _, err := r.ResolveWithBlobResolver(ctx, repoCtx, name, version)
+	return cd, err
+}
+
+func (r staticResolver) ResolveWithBlobResolver(_ context.Context, _ cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	for _, cd := range r {
+		if cd.Name == name && cd.Version == version {
+			return cd.DeepCopy(), nil, nil
+		}
+	}
+	return nil, nil, ctf.NotFoundError
+}
+
+var _ = Describe("Diff", func() {
+
+	newComponent := func(version, depVersion string) *cdv2.ComponentDescriptor {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/app"
+		cd.Version = version
+		cd.ComponentReferences = []cdv2.ComponentReference{{Name: "dep", ComponentName: "example.com/dep", Version: depVersion}}
+		return cd
+	}
+	resolver := staticResolver{newComponent("v1.0.0", "v0.1.0"), newComponent("v1.1.0", "v0.2.0")}
+
+	It("should print the changes between two versions of a component", func() {
+		opts := &component.DiffOptions{
+			OutputFormat: "text",
+		}
+		Expect(opts.Complete([]string{"example.com/components", "example.com/app", "v1.0.0", "v1.1.0"})).To(Succeed())
+
+		var out bytes.Buffer
+		Expect(opts.RunWithResolver(context.TODO(), resolver, &out)).To(Succeed())
+		Expect(out.String()).To(Equal(`Changes from example.com/app:v1.0.0 to example.com/app:v1.1.0:
+componentReference "dep" modified
+  version: v0.1.0 -> v0.2.0
+`))
+	})
+
+	It("should fail if a component cannot be resolved", func() {
+		opts := &component.DiffOptions{
+			OutputFormat: "text",
+		}
+		Expect(opts.Complete([]string{"example.com/components", "example.com/app:v1.0.0", "example.com/app:v2.0.0"})).To(Succeed())
+		Expect(opts.RunWithResolver(context.TODO(), resolver, &bytes.Buffer{})).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/Masterminds/semver/v3"
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// ListOptions defines all options for the list command.
+type ListOptions struct {
+	// BaseUrl is the oci registry where the components are stored.
+	BaseUrl string
+	// ComponentName is the name of the component whose versions are listed.
+	// The versions of all components are listed if empty.
+	ComponentName string
+
+	ComponentNameMapping string
+
+	// Constraint is a semver constraint that the listed versions have to satisfy.
+	Constraint string
+	// OutputFormat defines the format of the output.
+	OutputFormat string
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+
+	constraint *semver.Constraints
+}
+
+// NewListCommand creates a new command that lists the component versions of a repository context.
+func NewListCommand(ctx context.Context) *cobra.Command {
+	opts := &ListOptions{}
+	cmd := &cobra.Command{
+		Use:   "list BASE_URL [COMPONENT_NAME]",
+		Args:  cobra.RangeArgs(1, 2),
+		Short: "lists the component versions of a repository context",
+		Long: `
+list lists all versions of the given component or of all components in the repository context.
+Listing all components requires the registry to support the catalog api and the "urlPath" component name mapping.
+
+The versions can be filtered with a semver constraint, e.g. --constraint ">=1.2.0 <2.0.0".
+Versions that are no valid semver versions are omitted if a constraint is given.
+
+The versions are printed as table, json or yaml.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run lists the component versions and prints them to stdout.
+func (o *ListOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	return o.RunWithClient(ctx, ociClient, os.Stdout)
+}
+
+// RunWithClient lists the component versions with the given client and writes them to the writer.
+func (o *ListOptions) RunWithClient(ctx context.Context, client components.CatalogClient, w io.Writer) error {
+	repoCtx := cdv2.OCIRegistryRepository{
+		ObjectType: cdv2.ObjectType{
+			Type: cdv2.OCIRegistryType,
+		},
+		BaseURL:              o.BaseUrl,
+		ComponentNameMapping: cdv2.ComponentNameMapping(o.ComponentNameMapping),
+	}
+
+	var (
+		cvs []components.ComponentVersion
+		err error
+	)
+	if len(o.ComponentName) != 0 {
+		cvs, err = components.ListVersions(ctx, client, repoCtx, o.ComponentName)
+	} else {
+		cvs, err = components.ListComponentVersions(ctx, client, repoCtx)
+	}
+	if err != nil {
+		return err
+	}
+	if o.constraint != nil {
+		cvs = components.FilterVersions(cvs, o.constraint)
+	}
+	components.SortVersions(cvs)
+
+	switch o.OutputFormat {
+	case "json":
+		out, err := json.MarshalIndent(cvs, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case "yaml":
+		out, err := yaml.Marshal(cvs)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(w, string(out))
+		return err
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tVERSION")
+		for _, cv := range cvs {
+			fmt.Fprintf(tw, "%s\t%s\n", cv.Name, cv.Version)
+		}
+		return tw.Flush()
+	}
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *ListOptions) Complete(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("illegal number of arguments: %d", len(args))
+	}
+	o.BaseUrl = args[0]
+	if len(args) == 2 {
+		o.ComponentName = args[1]
+	}
+
+	if len(o.OciOptions.CacheDir) == 0 {
+		cliHomeDir, err := constants.CliHomeDir()
+		if err != nil {
+			return err
+		}
+		o.OciOptions.CacheDir = filepath.Join(cliHomeDir, "components")
+		if err := os.MkdirAll(o.OciOptions.CacheDir, os.ModePerm); err != nil {
+			return fmt.Errorf("unable to create cache directory %s: %w", o.OciOptions.CacheDir, err)
+		}
+	}
+
+	return o.Validate()
+}
+
+// Validate validates the options and parses the semver constraint.
+func (o *ListOptions) Validate() error {
+	if len(o.BaseUrl) == 0 {
+		return errors.New("the base url must be provided")
+	}
+	switch o.OutputFormat {
+	case "table", "json", "yaml":
+	default:
+		return fmt.Errorf("unsupported output format %q: must be table, json or yaml", o.OutputFormat)
+	}
+	if len(o.Constraint) != 0 {
+		constraint, err := semver.NewConstraint(o.Constraint)
+		if err != nil {
+			return fmt.Errorf("invalid semver constraint %q: %w", o.Constraint, err)
+		}
+		o.constraint = constraint
+	}
+	return nil
+}
+
+func (o *ListOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.ComponentNameMapping, "component-name-mapping", string(cdv2.OCIRegistryURLPathMapping), "[OPTIONAL] repository context name mapping")
+	fs.StringVar(&o.Constraint, "constraint", "", "semver constraint that the listed versions have to satisfy, e.g. \">=1.2.0 <2.0.0\"")
+	fs.StringVarP(&o.OutputFormat, "output", "o", "table", "output format of the component versions. One of table, json or yaml")
+	o.OciOptions.AddFlags(fs)
+}
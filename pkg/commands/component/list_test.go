@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package component_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/component"
+)
+
+// staticCatalog lists the repositories and tags of a map of repository to tags.
+type staticCatalog map[string][]string
+
+func (c staticCatalog) ListTags(_ context.Context, ref string) ([]string, error) {
+	tags, ok := c[ref]
+	if !ok {
+		return nil, fmt.Errorf("repository %s not found", ref)
+	}
+	return tags, nil
+}
+
+func (c staticCatalog) ListRepositories(_ context.Context, _ string) ([]string, error) {
+	repos := make([]string, 0, len(c))
+	for repo := range c {
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+var _ = Describe("List", func() {
+
+	catalog := staticCatalog{
+		"example.com/components/component-descriptors/example.com/app":    {"v1.0.0", "v2.0.0", "v1.2.0"},
+		"example.com/components/component-descriptors/example.com/shared": {"v0.1.0"},
+	}
+
+	It("should list all component versions of the repository context as table", func() {
+		opts := &component.ListOptions{
+			OutputFormat: "table",
+		}
+		Expect(opts.Complete([]string{"example.com/components"})).To(Succeed())
+
+		var out bytes.Buffer
+		Expect(opts.RunWithClient(context.TODO(), catalog, &out)).To(Succeed())
+		Expect(out.String()).To(Equal(`NAME                VERSION
+example.com/app     v1.0.0
+example.com/app     v1.2.0
+example.com/app     v2.0.0
+example.com/shared  v0.1.0
+`))
+	})
+
+	It("should list the versions of a component that satisfy the constraint as json", func() {
+		opts := &component.ListOptions{
+			OutputFormat: "json",
+			Constraint:   ">=1.1.0",
+		}
+		Expect(opts.Complete([]string{"example.com/components", "example.com/app"})).To(Succeed())
+
+		var out bytes.Buffer
+		Expect(opts.RunWithClient(context.TODO(), catalog, &out)).To(Succeed())
+		Expect(out.String()).To(MatchJSON(`[
+  {"name": "example.com/app", "version": "v1.2.0"},
+  {"name": "example.com/app", "version": "v2.0.0"}
+]`))
+	})
+})
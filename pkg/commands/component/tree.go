@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// TreeOptions defines all options for the tree command.
+type TreeOptions struct {
+	// BaseUrl is the oci registry where the component is stored.
+	BaseUrl string
+	// Component is the root component of the tree.
+	Component components.ComponentVersion
+
+	ComponentNameMapping string
+
+	// CheckDigests adds the status of the digests of the component references to the tree.
+	CheckDigests bool
+	// OutputFormat defines the format of the output.
+	OutputFormat string
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+}
+
+// NewTreeCommand creates a new command that prints the dependency tree of a component version.
+func NewTreeCommand(ctx context.Context) *cobra.Command {
+	opts := &TreeOptions{}
+	cmd := &cobra.Command{
+		Use:   "tree BASE_URL (COMPONENT_NAME VERSION | COMPONENT)",
+		Args:  cobra.RangeArgs(2, 3),
+		Short: "prints the dependency tree of a component version",
+		Long: `
+tree recursively resolves the component references of a component version and prints the dependency tree
+with the version and repository context of every component.
+The component can be given as name and version or in the form <name>:<version>.
+
+Referenced components are resolved in the effective repository context of the referencing component.
+Components that reference one of their ancestors are marked as cycle and are not resolved again.
+
+With "--digests" the digests of the component references are compared with the referenced component descriptors.
+Only the component descriptors are hashed, the digests of their resources are not recalculated.
+
+The tree is printed as text, json or dot (graphviz), e.g.
+  component-cli component tree eu.gcr.io/gardener-project/development github.com/gardener/gardener:v1.40.0 -o dot | dot -Tsvg > tree.svg
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run resolves the dependency tree and prints it to stdout.
+func (o *TreeOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	return o.RunWithResolver(ctx, schema.NewResolver(ociClient), os.Stdout)
+}
+
+// RunWithResolver resolves the dependency tree with the given resolver and writes it to the writer.
+func (o *TreeOptions) RunWithResolver(ctx context.Context, resolver ctf.ComponentResolver, w io.Writer) error {
+	repoCtx := cdv2.NewOCIRegistryRepository(o.BaseUrl, cdv2.ComponentNameMapping(o.ComponentNameMapping))
+	cd, err := resolver.Resolve(ctx, repoCtx, o.Component.Name, o.Component.Version)
+	if err != nil {
+		return fmt.Errorf("unable to resolve component descriptor %s: %w", o.Component, err)
+	}
+	tree, err := components.BuildTree(ctx, resolver, cd, components.TreeOptions{CheckDigests: o.CheckDigests})
+	if err != nil {
+		return err
+	}
+
+	switch o.OutputFormat {
+	case "json":
+		out, err := json.MarshalIndent(tree, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case "dot":
+		return writeDotTree(w, tree)
+	default:
+		fmt.Fprintln(w, treeNodeLabel(tree))
+		writeTextTree(w, tree.Children, "")
+		return nil
+	}
+}
+
+// writeTextTree writes the nodes as ascii tree.
+func writeTextTree(w io.Writer, nodes []*components.TreeNode, prefix string) {
+	for i, node := range nodes {
+		branch, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Fprintf(w, "%s%s%s: %s\n", prefix, branch, node.ReferenceName, treeNodeLabel(node))
+		writeTextTree(w, node.Children, prefix+indent)
+	}
+}
+
+func treeNodeLabel(node *components.TreeNode) string {
+	label := fmt.Sprintf("%s (%s)", node.ComponentVersion, node.RepositoryContext)
+	if len(node.DigestStatus) != 0 {
+		label += fmt.Sprintf(" [digest: %s]", node.DigestStatus)
+	}
+	if node.Cycle {
+		label += " [cycle]"
+	}
+	return label
+}
+
+// writeDotTree writes the tree as graphviz digraph.
+// Components that are referenced multiple times are rendered as a single node.
+func writeDotTree(w io.Writer, tree *components.TreeNode) error {
+	var (
+		lines = []string{"digraph components {"}
+		nodes = map[string]bool{}
+		edges = map[string]bool{}
+	)
+	var walk func(node *components.TreeNode)
+	walk = func(node *components.TreeNode) {
+		id := node.ComponentVersion.String()
+		if !nodes[id] {
+			nodes[id] = true
+			lines = append(lines, fmt.Sprintf("  %q [label=%q];", id, node.Name+"\n"+node.Version))
+		}
+		for _, child := range node.Children {
+			edge := fmt.Sprintf("  %q -> %q [label=%q", id, child.ComponentVersion.String(), child.ReferenceName)
+			switch child.DigestStatus {
+			case components.DigestStatusMismatch, components.DigestStatusMissing:
+				edge += ", color=red"
+			}
+			if child.Cycle {
+				edge += ", style=dashed"
+			}
+			edge += "];"
+			if !edges[edge] {
+				edges[edge] = true
+				lines = append(lines, edge)
+			}
+			walk(child)
+		}
+	}
+	walk(tree)
+	lines = append(lines, "}")
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *TreeOptions) Complete(args []string) error {
+	var err error
+	switch len(args) {
+	case 2:
+		o.BaseUrl = args[0]
+		if o.Component, err = components.ParseComponentVersion(args[1]); err != nil {
+			return err
+		}
+	case 3:
+		o.BaseUrl = args[0]
+		o.Component = components.ComponentVersion{Name: args[1], Version: args[2]}
+	default:
+		return fmt.Errorf("illegal number of arguments: %d", len(args))
+	}
+
+	if len(o.OciOptions.CacheDir) == 0 {
+		cliHomeDir, err := constants.CliHomeDir()
+		if err != nil {
+			return err
+		}
+		o.OciOptions.CacheDir = filepath.Join(cliHomeDir, "components")
+		if err := os.MkdirAll(o.OciOptions.CacheDir, os.ModePerm); err != nil {
+			return fmt.Errorf("unable to create cache directory %s: %w", o.OciOptions.CacheDir, err)
+		}
+	}
+
+	if len(o.BaseUrl) == 0 {
+		return errors.New("the base url must be provided")
+	}
+	switch o.OutputFormat {
+	case "text", "json", "dot":
+	default:
+		return fmt.Errorf("unsupported output format %q: must be text, json or dot", o.OutputFormat)
+	}
+	return nil
+}
+
+func (o *TreeOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.ComponentNameMapping, "component-name-mapping", string(cdv2.OCIRegistryURLPathMapping), "[OPTIONAL] repository context name mapping")
+	fs.BoolVar(&o.CheckDigests, "digests", false, "compare the digests of the component references with the referenced component descriptors")
+	fs.StringVarP(&o.OutputFormat, "output", "o", "text", "output format of the tree. One of text, json or dot")
+	o.OciOptions.AddFlags(fs)
+}
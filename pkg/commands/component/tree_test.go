@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package component_test
+
+import (
+	"bytes"
+	"context"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/component"
+)
+
+var _ = Describe("Tree", func() {
+
+	newComponent := func(name, version string, refs ...cdv2.ComponentReference) *cdv2.ComponentDescriptor {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Name = name
+		cd.Version = version
+		Expect(cdv2.InjectRepositoryContext(cd, cdv2.NewOCIRegistryRepository("example.com/components", ""))).To(Succeed())
+		cd.ComponentReferences = refs
+		return cd
+	}
+	resolver := staticResolver{
+		newComponent("example.com/app", "v1.0.0",
+			cdv2.ComponentReference{Name: "dep", ComponentName: "example.com/dep", Version: "v0.1.0"},
+			cdv2.ComponentReference{Name: "lib", ComponentName: "example.com/lib", Version: "v0.2.0"}),
+		newComponent("example.com/dep", "v0.1.0",
+			cdv2.ComponentReference{Name: "lib", ComponentName: "example.com/lib", Version: "v0.2.0"}),
+		newComponent("example.com/lib", "v0.2.0"),
+	}
+
+	It("should print the dependency tree as text", func() {
+		opts := &component.TreeOptions{
+			OutputFormat: "text",
+		}
+		Expect(opts.Complete([]string{"example.com/components", "example.com/app", "v1.0.0"})).To(Succeed())
+
+		var out bytes.Buffer
+		Expect(opts.RunWithResolver(context.TODO(), resolver, &out)).To(Succeed())
+		Expect(out.String()).To(Equal(`example.com/app:v1.0.0 (example.com/components)
+├── dep: example.com/dep:v0.1.0 (example.com/components)
+│   └── lib: example.com/lib:v0.2.0 (example.com/components)
+└── lib: example.com/lib:v0.2.0 (example.com/components)
+`))
+	})
+
+	It("should print every component only once as dot graph", func() {
+		opts := &component.TreeOptions{
+			OutputFormat: "dot",
+		}
+		Expect(opts.Complete([]string{"example.com/components", "example.com/app:v1.0.0"})).To(Succeed())
+
+		var out bytes.Buffer
+		Expect(opts.RunWithResolver(context.TODO(), resolver, &out)).To(Succeed())
+		Expect(out.String()).To(Equal(`digraph components {
+  "example.com/app:v1.0.0" [label="example.com/app\nv1.0.0"];
+  "example.com/app:v1.0.0" -> "example.com/dep:v0.1.0" [label="dep"];
+  "example.com/dep:v0.1.0" [label="example.com/dep\nv0.1.0"];
+  "example.com/dep:v0.1.0" -> "example.com/lib:v0.2.0" [label="lib"];
+  "example.com/lib:v0.2.0" [label="example.com/lib\nv0.2.0"];
+  "example.com/app:v1.0.0" -> "example.com/lib:v0.2.0" [label="lib"];
+}
+`))
+	})
+})
@@ -19,6 +19,7 @@ import (
 	pflag "github.com/spf13/pflag"
 
 	"github.com/gardener/component-cli/pkg/commands/componentarchive/componentreferences"
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/labels"
 	"github.com/gardener/component-cli/pkg/commands/componentarchive/remote"
 	"github.com/gardener/component-cli/pkg/commands/componentarchive/resources"
 	"github.com/gardener/component-cli/pkg/commands/componentarchive/signature"
@@ -70,12 +71,18 @@ func NewComponentArchiveCommand(ctx context.Context) *cobra.Command {
 	}
 	opts.AddFlags(cmd.Flags())
 	cmd.AddCommand(NewCreateCommand(ctx))
+	cmd.AddCommand(NewDevCommand(ctx))
 	cmd.AddCommand(NewExportCommand(ctx))
+	cmd.AddCommand(NewLockCommand(ctx))
+	cmd.AddCommand(NewMergeCommand(ctx))
+	cmd.AddCommand(NewSetCommand(ctx))
+	cmd.AddCommand(NewValidateCommand(ctx))
 	cmd.AddCommand(remote.NewRemoteCommand(ctx))
 	cmd.AddCommand(resources.NewResourcesCommand(ctx))
 	cmd.AddCommand(componentreferences.NewCompRefCommand(ctx))
 	cmd.AddCommand(sources.NewSourcesCommand(ctx))
 	cmd.AddCommand(signature.NewSignaturesCommand(ctx))
+	cmd.AddCommand(labels.NewLabelsCommand(ctx))
 	return cmd
 }
 
@@ -95,7 +102,7 @@ func (o *ComponentArchiveOptions) Run(ctx context.Context, log logr.Logger, fs v
 	}
 
 	// ensure that a optional archive exists
-	_, err := o.BuilderOptions.Build(fs)
+	_, _, err := o.BuilderOptions.Build(fs)
 	if err != nil {
 		return err
 	}
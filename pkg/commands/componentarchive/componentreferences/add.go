@@ -12,11 +12,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
 	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
 	"github.com/gardener/component-spec/bindings-go/ctf"
-	"github.com/ghodss/yaml"
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
@@ -25,9 +26,14 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
 
+	"github.com/gardener/component-cli/ociclient"
+	ociopts "github.com/gardener/component-cli/ociclient/options"
 	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/components/schema"
 	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/signatures"
 	"github.com/gardener/component-cli/pkg/template"
+	"github.com/gardener/component-cli/pkg/utils"
 )
 
 // Options defines the options that are used to add resources to a component descriptor
@@ -42,6 +48,15 @@ type Options struct {
 	// ComponentReferenceObjectPath defines the path to the resources defined as yaml or json
 	// DEPRECATED
 	ComponentReferenceObjectPath string
+
+	// ResolveDigests defines whether the referenced component descriptors should be resolved
+	// and their digests should be added to the component references.
+	ResolveDigests bool
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+	// ComponentResolver is used to resolve the referenced component descriptors.
+	// Defaults to an oci resolver that uses the configured oci client.
+	ComponentResolver ctf.ComponentResolver
 }
 
 // NewAddCommand creates a command to add additional resources to a component descriptor.
@@ -72,6 +87,11 @@ version: 'v0.0.2'
 
 </pre>
 
+If "--resolve-digests" is set, the referenced component descriptors are resolved from the repository context
+given by "--repo-ctx" or from the effective repository context of the component descriptor.
+The digests of the referenced component descriptors are added to the component references.
+The command fails if a referenced component descriptor does not exist.
+
 %s
 `, opts.TemplateOptions.Usage()),
 		Run: func(cmd *cobra.Command, args []string) {
@@ -95,7 +115,7 @@ version: 'v0.0.2'
 func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
 	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
 
-	archive, err := o.BuilderOptions.Build(fs)
+	archive, schemaVersion, err := o.BuilderOptions.Build(fs)
 	if err != nil {
 		return err
 	}
@@ -105,10 +125,29 @@ func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) e
 		return err
 	}
 
-	for _, ref := range refs {
+	var digestResolver *referenceDigestResolver
+	if o.ResolveDigests {
+		digestResolver, err = o.buildReferenceDigestResolver(log, fs, archive.ComponentDescriptor)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, ref := range refs {
 		if errList := cdvalidation.ValidateComponentReference(field.NewPath(""), ref); len(errList) != 0 {
 			return fmt.Errorf("invalid component reference: %w", errList.ToAggregate())
 		}
+		if digestResolver != nil {
+			digest, err := digestResolver.Resolve(ctx, ref)
+			if err != nil {
+				return err
+			}
+			if ref.Digest != nil && !reflect.DeepEqual(ref.Digest, digest) {
+				return fmt.Errorf("calculated digest mismatches existing digest for component reference %s:%s", ref.ComponentName, ref.Version)
+			}
+			ref.Digest = digest
+			refs[i] = ref
+		}
 		id := archive.ComponentDescriptor.GetComponentReferenceIndex(ref)
 		if id != -1 {
 			archive.ComponentDescriptor.ComponentReferences[id] = ref
@@ -122,7 +161,7 @@ func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) e
 		return fmt.Errorf("invalid component descriptor: %w", err)
 	}
 
-	data, err := yaml.Marshal(archive.ComponentDescriptor)
+	data, err := schema.EncodeYAML(archive.ComponentDescriptor, schemaVersion)
 	if err != nil {
 		return fmt.Errorf("unable to encode component descriptor: %w", err)
 	}
@@ -141,6 +180,14 @@ func (o *Options) Complete(args []string) error {
 	o.BuilderOptions.ComponentArchivePath = args[0]
 	o.BuilderOptions.Default()
 
+	if o.ResolveDigests {
+		var err error
+		o.OciOptions.CacheDir, err = utils.CacheDir()
+		if err != nil {
+			return fmt.Errorf("unable to get oci cache directory: %w", err)
+		}
+	}
+
 	if len(args) > 1 {
 		o.ComponentReferenceObjectPaths = append(o.ComponentReferenceObjectPaths, args[1:]...)
 	}
@@ -158,6 +205,76 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	o.BuilderOptions.AddFlags(fs)
 	// specify the resource
 	fs.StringVarP(&o.ComponentReferenceObjectPath, "resource", "r", "", "The path to the resources defined as yaml or json")
+	fs.BoolVar(&o.ResolveDigests, "resolve-digests", false, "resolve the referenced component descriptors and add their digests to the component references")
+	o.OciOptions.AddFlags(fs)
+}
+
+// referenceDigestResolver resolves referenced component descriptors and calculates their digests.
+type referenceDigestResolver struct {
+	repoCtx   cdv2.OCIRegistryRepository
+	resolver  ctf.ComponentResolver
+	ociClient ociclient.Client
+}
+
+// buildReferenceDigestResolver creates a resolver for the digests of component references
+// that are resolved from the given repository context url or the effective repository context of the component descriptor.
+func (o *Options) buildReferenceDigestResolver(log logr.Logger, fs vfs.FileSystem, cd *cdv2.ComponentDescriptor) (*referenceDigestResolver, error) {
+	r := &referenceDigestResolver{
+		resolver: o.ComponentResolver,
+	}
+	if len(o.BaseUrl) != 0 {
+		r.repoCtx = *cdv2.NewOCIRegistryRepository(o.BaseUrl, cdv2.ComponentNameMapping(o.ComponentNameMapping))
+	} else {
+		effectiveRepoCtx := cd.GetEffectiveRepositoryContext()
+		if effectiveRepoCtx == nil {
+			return nil, errors.New("a repository context has to be defined to resolve the digests of component references")
+		}
+		if err := effectiveRepoCtx.DecodeInto(&r.repoCtx); err != nil {
+			return nil, fmt.Errorf("unable to decode repository context: %w", err)
+		}
+	}
+
+	if r.resolver == nil {
+		ociClient, _, err := o.OciOptions.Build(log, fs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to build oci client: %s", err.Error())
+		}
+		r.ociClient = ociClient
+		r.resolver = schema.NewResolver(ociClient)
+	}
+	return r, nil
+}
+
+// Resolve fetches the component descriptor of the reference and returns its digest.
+// Digests of component descriptors that are not normaliseable are calculated by recursively
+// resolving the digests of their resources and references.
+func (r *referenceDigestResolver) Resolve(ctx context.Context, ref cdv2.ComponentReference) (*cdv2.DigestSpec, error) {
+	childCd, err := r.resolver.Resolve(ctx, &r.repoCtx, ref.ComponentName, ref.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve referenced component %s:%s: %w", ref.ComponentName, ref.Version, err)
+	}
+
+	hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating hasher: %w", err)
+	}
+	digest, err := cdv2Sign.HashForComponentDescriptor(*childCd, *hasher)
+	if err == nil {
+		return digest, nil
+	}
+	if r.ociClient == nil {
+		return nil, fmt.Errorf("failed hashing referenced component %s:%s: %w", ref.ComponentName, ref.Version, err)
+	}
+
+	blobResolvers := map[string]ctf.BlobResolver{}
+	if _, err := signatures.RecursivelyAddDigestsToCd(childCd, r.repoCtx, r.ociClient, blobResolvers, ctx, nil); err != nil {
+		return nil, fmt.Errorf("unable to add digests to referenced component %s:%s: %w", ref.ComponentName, ref.Version, err)
+	}
+	digest, err = cdv2Sign.HashForComponentDescriptor(*childCd, *hasher)
+	if err != nil {
+		return nil, fmt.Errorf("failed hashing referenced component %s:%s: %w", ref.ComponentName, ref.Version, err)
+	}
+	return digest, nil
 }
 
 // generateComponentReferences parses component references from the given path and stdin.
@@ -6,11 +6,13 @@ package componentreferences_test
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
 	"github.com/gardener/component-spec/bindings-go/codec"
 	"github.com/gardener/component-spec/bindings-go/ctf"
 	"github.com/go-logr/logr"
@@ -217,4 +219,91 @@ var _ = Describe("Add", func() {
 		}))
 	})
 
+	Context("resolve digests", func() {
+
+		var resolver *testComponentResolver
+
+		BeforeEach(func() {
+			resolver = &testComponentResolver{
+				components: map[string]*cdv2.ComponentDescriptor{},
+			}
+			ubuntu := &cdv2.ComponentDescriptor{}
+			ubuntu.Metadata.Version = cdv2.SchemaVersion
+			ubuntu.Name = "github.com/gardener/ubuntu"
+			ubuntu.Version = "v0.0.1"
+			ubuntu.Provider = "internal"
+			resolver.components["github.com/gardener/ubuntu:v0.0.1"] = ubuntu
+		})
+
+		It("should add the digest of the referenced component descriptor", func() {
+			opts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{"./resources/00-ref.yaml"},
+				ResolveDigests:                true,
+				ComponentResolver:             resolver,
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+
+			hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+			Expect(err).ToNot(HaveOccurred())
+			expectedDigest, err := cdv2Sign.HashForComponentDescriptor(*resolver.components["github.com/gardener/ubuntu:v0.0.1"], *hasher)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cd.ComponentReferences).To(HaveLen(1))
+			Expect(cd.ComponentReferences[0].Digest).To(Equal(expectedDigest))
+			Expect(resolver.repoCtx).To(BeAssignableToTypeOf(&cdv2.OCIRegistryRepository{}))
+			Expect(resolver.repoCtx.(*cdv2.OCIRegistryRepository).BaseURL).To(Equal("eu.gcr.io/gardener-project/components/dev"))
+		})
+
+		It("should fail if the referenced component descriptor does not exist", func() {
+			opts := &componentreferences.Options{
+				BuilderOptions:                componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ComponentReferenceObjectPaths: []string{"./resources/02-ref.yaml"},
+				TemplateOptions: template.Options{
+					Vars: map[string]string{
+						"MY_VERSION": "v0.0.2",
+					},
+				},
+				ResolveDigests:    true,
+				ComponentResolver: resolver,
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(HaveOccurred())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.ComponentReferences).To(HaveLen(0))
+		})
+
+	})
+
 })
+
+// testComponentResolver resolves component descriptors from a static map of "name:version" to component descriptor.
+type testComponentResolver struct {
+	components map[string]*cdv2.ComponentDescriptor
+	repoCtx    cdv2.Repository
+}
+
+func (r *testComponentResolver) Resolve(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	r.repoCtx = repoCtx
+	cd, ok := r.components[name+":"+version]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return cd.DeepCopy(), nil
+}
+
+func (r *testComponentResolver) ResolveWithBlobResolver(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	cd, err := r.Resolve(ctx, repoCtx, name, version)
+	return cd, nil, err
+}
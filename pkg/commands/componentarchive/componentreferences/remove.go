@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentreferences
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// RemoveOptions defines all options for the remove component references command.
+type RemoveOptions struct {
+	componentarchive.BuilderOptions
+	// Selector selects the component references that are removed.
+	Selector componentarchive.ElementSelector
+	// IgnoreMissing defines that the command does not fail if no component reference matches the selector.
+	IgnoreMissing bool
+}
+
+// NewRemoveCommand creates a command to remove component references from a component descriptor.
+func NewRemoveCommand(ctx context.Context) *cobra.Command {
+	opts := &RemoveOptions{}
+	cmd := &cobra.Command{
+		Use:     "remove [component-archive-path]",
+		Aliases: []string{"rm"},
+		Args:    cobra.RangeArgs(0, 1),
+		Short:   "removes component references from the component descriptor of a component archive",
+		Long: `
+remove deletes all component references that match the given identity from the component descriptor of a component archive.
+The component references are selected by their name ("--name") and optionally by their version ("--version")
+and their extra identity ("--extra-identity key=value").
+The command fails if no component reference matches unless "--ignore-missing" is set.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			removed, err := opts.Run(ctx, logger.Log, osfs.New())
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Successfully removed %d component references\n", removed)
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// Run removes the selected component references and returns the number of removed component references.
+func (o *RemoveOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) (int, error) {
+	archive, schemaVersion, err := o.BuilderOptions.Build(fs)
+	if err != nil {
+		return 0, err
+	}
+	cd := archive.ComponentDescriptor
+
+	removed := componentarchive.RemoveComponentReferences(cd, o.Selector)
+	if len(removed) == 0 {
+		if o.IgnoreMissing {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("no component reference matches %q", o.Selector.Name)
+	}
+	for _, ref := range removed {
+		log.V(3).Info(fmt.Sprintf("removed component reference %q", ref.Name))
+	}
+
+	if err := cdvalidation.Validate(cd); err != nil {
+		return 0, fmt.Errorf("invalid component descriptor: %w", err)
+	}
+	data, err := schema.EncodeYAML(cd, schemaVersion)
+	if err != nil {
+		return 0, fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
+	if err := vfs.WriteFile(fs, compDescFilePath, data, 0664); err != nil {
+		return 0, fmt.Errorf("unable to write modified component descriptor: %w", err)
+	}
+	return len(removed), nil
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *RemoveOptions) Complete(args []string) error {
+	if len(args) != 0 {
+		o.BuilderOptions.ComponentArchivePath = args[0]
+	}
+	o.BuilderOptions.Default()
+	if err := o.BuilderOptions.Validate(); err != nil {
+		return err
+	}
+	return o.Selector.Validate()
+}
+
+func (o *RemoveOptions) AddFlags(fs *pflag.FlagSet) {
+	o.Selector.AddFlags(fs, "component references")
+	fs.BoolVar(&o.IgnoreMissing, "ignore-missing", false, "do not fail if no component reference matches")
+	o.BuilderOptions.AddFlags(fs)
+}
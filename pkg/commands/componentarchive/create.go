@@ -17,6 +17,7 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/components/schema"
 	"github.com/gardener/component-cli/pkg/logger"
 )
 
@@ -34,6 +35,9 @@ func NewCreateCommand(ctx context.Context) *cobra.Command {
 		Short: "Creates a component archive with a component descriptor",
 		Long: `
 Create command creates a new component archive directory with a "component-descriptor.yaml" file.
+
+The component descriptor is created in the v2 schema by default.
+Use "--schema-version ocm.software/v3alpha1" to create a component descriptor in the OCM v3alpha1 schema.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
@@ -56,7 +60,7 @@ func (o *CreateOptions) Run(_ context.Context, log logr.Logger, fs vfs.FileSyste
 	if o.Overwrite {
 		log.V(3).Info("overwrite enabled")
 	}
-	_, err := o.BuilderOptions.Build(fs)
+	_, _, err := o.BuilderOptions.Build(fs)
 	return err
 }
 
@@ -85,4 +89,5 @@ func (o *CreateOptions) validate() error {
 func (o *CreateOptions) AddFlags(fs *pflag.FlagSet) {
 	o.BuilderOptions.AddFlags(fs)
 	fs.BoolVarP(&o.BuilderOptions.Overwrite, "overwrite", "w", false, "overwrites the existing component")
+	fs.StringVar(&o.BuilderOptions.SchemaVersion, "schema-version", schema.SchemaVersionV2, fmt.Sprintf("schema version of the component descriptor. One of %v", schema.SupportedSchemaVersions))
 }
@@ -23,6 +23,8 @@ import (
 	"github.com/gardener/component-spec/bindings-go/ctf"
 
 	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	cacomponentarchive "github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/components/schema"
 )
 
 var _ = Describe("Create", func() {
@@ -63,6 +65,38 @@ var _ = Describe("Create", func() {
 			Expect(ociRepoCtx.BaseURL).To(Equal(opts.BaseUrl))
 		})
 
+		It("should create a component archive with a v3alpha1 component descriptor", func() {
+			opts := &componentarchive.CreateOptions{}
+			opts.Name = "example.com/component/name"
+			opts.Version = "v0.0.1"
+			opts.SchemaVersion = schema.SchemaVersionV3alpha1
+			opts.ComponentArchivePath = "./create-v3-test"
+			Expect(testdataFs.Mkdir(opts.ComponentArchivePath, os.ModePerm)).To(Succeed())
+			Expect(opts.Complete([]string{opts.ComponentArchivePath})).To(Succeed())
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(schema.DetectSchemaVersion(data)).To(Equal(schema.SchemaVersionV3alpha1))
+
+			// the archive can be read again
+			builderOpts := cacomponentarchive.BuilderOptions{ComponentArchivePath: opts.ComponentArchivePath}
+			archive, schemaVersion, err := builderOpts.Build(testdataFs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(schemaVersion).To(Equal(schema.SchemaVersionV3alpha1))
+			Expect(archive.ComponentDescriptor.Name).To(Equal(opts.Name))
+			Expect(archive.ComponentDescriptor.Version).To(Equal(opts.Version))
+			Expect(archive.ComponentDescriptor.Provider).To(Equal(cdv2.ProviderType("internal")))
+		})
+
+		It("should fail for an unsupported schema version", func() {
+			opts := &componentarchive.CreateOptions{}
+			opts.Name = "example.com/component/name"
+			opts.Version = "v0.0.1"
+			opts.SchemaVersion = "v4"
+			Expect(opts.Complete([]string{"./create-invalid-test"})).ToNot(Succeed())
+		})
+
 	})
 
 	Context("Overwrite", func() {
@@ -0,0 +1,408 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/componentreferences"
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/resources"
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/sources"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/template"
+)
+
+// DefaultWatchInterval is the default interval in which the watched files are checked for changes.
+const DefaultWatchInterval = time.Second
+
+// DevOptions defines all options for the dev command.
+type DevOptions struct {
+	componentarchive.BuilderOptions
+	TemplateOptions template.Options
+
+	// ResourcesPaths defines all paths to the resource definitions.
+	ResourcesPaths []string
+	// SourcesPaths defines all paths to the source definitions.
+	SourcesPaths []string
+	// ComponentReferencesPaths defines all paths to the component-references definitions.
+	ComponentReferencesPaths []string
+
+	// Watch defines whether the definitions and inputs should be watched
+	// and the component archive should be rebuilt on every change.
+	Watch bool
+	// WatchInterval is the interval in which the watched files are checked for changes.
+	WatchInterval time.Duration
+}
+
+// NewDevCommand creates a new command that builds a component archive for local development.
+func NewDevCommand(ctx context.Context) *cobra.Command {
+	opts := &DevOptions{}
+	cmd := &cobra.Command{
+		Use:   "dev [component-archive-path]",
+		Args:  cobra.MinimumNArgs(0),
+		Short: "builds and validates a component archive for local development",
+		Long: fmt.Sprintf(`
+dev adds the resources, sources and component references of the given definitions to a copy of the component archive
+and validates the resulting component descriptor. The component archive itself is not modified.
+
+With "--watch" the definitions, their input paths and the component archive are watched.
+The component archive is rebuilt on every change and the differences of the resulting component descriptor
+to the previous build are printed. Failed builds are reported and the previous result is kept.
+The command runs until it is interrupted.
+
+%s
+`, opts.TemplateOptions.Usage()),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// Run builds the component archive and watches for changes if configured.
+func (o *DevOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	return o.RunWithWriter(ctx, log, fs, os.Stdout)
+}
+
+// RunWithWriter builds the component archive and watches for changes if configured.
+// The build results are written to the writer.
+func (o *DevOptions) RunWithWriter(ctx context.Context, log logr.Logger, fs vfs.FileSystem, w io.Writer) error {
+	cd, err := o.build(ctx, log, fs)
+	if err != nil {
+		if !o.Watch {
+			return err
+		}
+		fmt.Fprintf(w, "Build failed: %s\n", err.Error())
+	} else {
+		fmt.Fprintf(w, "Successfully built and validated %s:%s\n", cd.Name, cd.Version)
+	}
+	if !o.Watch {
+		return nil
+	}
+
+	interval := o.WatchInterval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	snapshot, err := o.snapshot(log, fs)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Watching %d files for changes...\n", len(snapshot))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := o.snapshot(log, fs)
+		if err != nil {
+			fmt.Fprintf(w, "Unable to check files for changes: %s\n", err.Error())
+			continue
+		}
+		changed := snapshot.changes(current)
+		if len(changed) == 0 {
+			continue
+		}
+		snapshot = current
+		fmt.Fprintf(w, "Detected changes in %s\n", strings.Join(changed, ", "))
+
+		newCd, err := o.build(ctx, log, fs)
+		if err != nil {
+			fmt.Fprintf(w, "Build failed: %s\n", err.Error())
+			continue
+		}
+		if cd == nil {
+			fmt.Fprintf(w, "Successfully built and validated %s:%s\n", newCd.Name, newCd.Version)
+		} else {
+			printDevDiff(w, components.DiffComponentDescriptors(cd, newCd))
+		}
+		cd = newCd
+	}
+}
+
+// build adds all definitions to a temporary copy of the component archive and returns the validated component descriptor.
+func (o *DevOptions) build(ctx context.Context, log logr.Logger, fs vfs.FileSystem) (*cdv2.ComponentDescriptor, error) {
+	tempDir, err := vfs.TempDir(fs, fs.FSTempDir(), "ca-dev-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create temporary directory: %w", err)
+	}
+	defer func() {
+		if err := fs.RemoveAll(tempDir); err != nil {
+			log.Error(err, "unable to remove temporary directory", "dir", tempDir)
+		}
+	}()
+	if err := copyComponentArchive(fs, o.ComponentArchivePath, tempDir); err != nil {
+		return nil, err
+	}
+
+	builderOptions := o.BuilderOptions
+	builderOptions.ComponentArchivePath = tempDir
+	if _, _, err := builderOptions.Build(fs); err != nil {
+		return nil, err
+	}
+	if len(o.ResourcesPaths) != 0 {
+		add := &resources.Options{
+			BuilderOptions:      builderOptions,
+			TemplateOptions:     o.TemplateOptions,
+			ResourceObjectPaths: o.ResourcesPaths,
+		}
+		if err := add.Run(ctx, log, fs); err != nil {
+			return nil, err
+		}
+	}
+	if len(o.SourcesPaths) != 0 {
+		add := &sources.Options{
+			BuilderOptions:    builderOptions,
+			TemplateOptions:   o.TemplateOptions,
+			SourceObjectPaths: o.SourcesPaths,
+		}
+		if err := add.Run(ctx, log, fs); err != nil {
+			return nil, err
+		}
+	}
+	if len(o.ComponentReferencesPaths) != 0 {
+		add := &componentreferences.Options{
+			BuilderOptions:                builderOptions,
+			TemplateOptions:               o.TemplateOptions,
+			ComponentReferenceObjectPaths: o.ComponentReferencesPaths,
+		}
+		if err := add.Run(ctx, log, fs); err != nil {
+			return nil, err
+		}
+	}
+
+	archive, _, err := builderOptions.Build(fs)
+	if err != nil {
+		return nil, err
+	}
+	if err := cdvalidation.Validate(archive.ComponentDescriptor); err != nil {
+		return nil, fmt.Errorf("invalid component descriptor: %w", err)
+	}
+	return archive.ComponentDescriptor, nil
+}
+
+// watchedPaths returns the paths that are watched for changes.
+// The input paths of the definitions are resolved on every check so that new inputs are watched as well.
+// Definitions that cannot be parsed are only watched themselves as their errors are reported by the build.
+func (o *DevOptions) watchedPaths(log logr.Logger, fs vfs.FileSystem) []string {
+	paths := []string{
+		filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName),
+		filepath.Join(o.ComponentArchivePath, ctf.BlobsDirectoryName),
+	}
+	paths = append(paths, o.ResourcesPaths...)
+	paths = append(paths, o.SourcesPaths...)
+	paths = append(paths, o.ComponentReferencesPaths...)
+
+	if len(o.ResourcesPaths) != 0 {
+		// the component descriptor is only used to default the version of local resources
+		cd := &cdv2.ComponentDescriptor{}
+		compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
+		if _, err := fs.Stat(compDescFilePath); err == nil {
+			builderOptions := o.BuilderOptions
+			if archive, _, err := builderOptions.Build(fs); err == nil {
+				cd = archive.ComponentDescriptor
+			}
+		}
+		add := &resources.Options{
+			BuilderOptions:      o.BuilderOptions,
+			TemplateOptions:     o.TemplateOptions,
+			ResourceObjectPaths: o.ResourcesPaths,
+		}
+		inputPaths, err := add.InputPaths(logr.Discard(), fs, cd)
+		if err != nil {
+			log.V(3).Info("unable to get input paths of resources", "error", err.Error())
+		}
+		paths = append(paths, inputPaths...)
+	}
+	if len(o.SourcesPaths) != 0 {
+		add := &sources.Options{
+			BuilderOptions:    o.BuilderOptions,
+			TemplateOptions:   o.TemplateOptions,
+			SourceObjectPaths: o.SourcesPaths,
+		}
+		inputPaths, err := add.InputPaths(logr.Discard(), fs)
+		if err != nil {
+			log.V(3).Info("unable to get input paths of sources", "error", err.Error())
+		}
+		paths = append(paths, inputPaths...)
+	}
+	return paths
+}
+
+// fileSnapshot maps the watched files to their modification time and size.
+type fileSnapshot map[string]string
+
+// snapshot returns the current state of all watched files.
+// Missing files are part of the snapshot so that their creation is detected.
+func (o *DevOptions) snapshot(log logr.Logger, fs vfs.FileSystem) (fileSnapshot, error) {
+	snapshot := fileSnapshot{}
+	for _, p := range o.watchedPaths(log, fs) {
+		p = filepath.Clean(p)
+		if _, ok := snapshot[p]; ok {
+			continue
+		}
+		info, err := fs.Stat(p)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				snapshot[p] = "missing"
+				continue
+			}
+			return nil, fmt.Errorf("unable to get info for %q: %w", p, err)
+		}
+		if !info.IsDir() {
+			snapshot[p] = fileState(info)
+			continue
+		}
+		err = vfs.Walk(fs, p, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				snapshot[path] = fileState(info)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to walk %q: %w", p, err)
+		}
+	}
+	return snapshot, nil
+}
+
+func fileState(info os.FileInfo) string {
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// changes returns the sorted paths of all files that have been added, removed or modified.
+func (s fileSnapshot) changes(current fileSnapshot) []string {
+	changed := make([]string, 0)
+	for p, state := range current {
+		if s[p] != state {
+			changed = append(changed, p)
+		}
+	}
+	for p := range s {
+		if _, ok := current[p]; !ok {
+			changed = append(changed, p)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// copyComponentArchive copies the component descriptor and the blobs of a component archive if they exist.
+func copyComponentArchive(fs vfs.FileSystem, src, dst string) error {
+	srcCompDescFilePath := filepath.Join(src, ctf.ComponentDescriptorFileName)
+	if _, err := fs.Stat(srcCompDescFilePath); err == nil {
+		dstCompDescFilePath := filepath.Join(dst, ctf.ComponentDescriptorFileName)
+		if err := vfs.CopyFile(fs, srcCompDescFilePath, fs, dstCompDescFilePath); err != nil {
+			return fmt.Errorf("unable to copy component descriptor to %q: %w", dstCompDescFilePath, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	srcBlobDir := filepath.Join(src, ctf.BlobsDirectoryName)
+	if _, err := fs.Stat(srcBlobDir); err == nil {
+		dstBlobDir := filepath.Join(dst, ctf.BlobsDirectoryName)
+		if err := vfs.CopyDir(fs, srcBlobDir, fs, dstBlobDir); err != nil {
+			return fmt.Errorf("unable to copy blob directory to %q: %w", dstBlobDir, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// printDevDiff prints the changes of a rebuilt component descriptor.
+func printDevDiff(w io.Writer, diff components.Diff) {
+	if len(diff.Changes) == 0 && diff.From == diff.To {
+		fmt.Fprintln(w, "Successfully rebuilt, the component descriptor did not change")
+		return
+	}
+	fmt.Fprintf(w, "Successfully rebuilt, changes from %s to %s:\n", diff.From, diff.To)
+	for _, change := range diff.Changes {
+		fmt.Fprintf(w, "  %s\n", change)
+		for _, field := range change.Fields {
+			fmt.Fprintf(w, "    %s: %s -> %s\n", field.Field, devPrintableValue(field.Old), devPrintableValue(field.New))
+		}
+	}
+}
+
+func devPrintableValue(value string) string {
+	if len(value) == 0 {
+		return "<none>"
+	}
+	return value
+}
+
+// Complete validates the arguments and flags from the command line.
+func (o *DevOptions) Complete(args []string) error {
+	args = o.TemplateOptions.Parse(args)
+
+	if len(args) != 0 {
+		o.BuilderOptions.ComponentArchivePath = args[0]
+	}
+	o.BuilderOptions.Default()
+	return o.validate()
+}
+
+func (o *DevOptions) validate() error {
+	if err := o.BuilderOptions.Validate(); err != nil {
+		return err
+	}
+	if len(o.ResourcesPaths) == 0 && len(o.SourcesPaths) == 0 && len(o.ComponentReferencesPaths) == 0 {
+		return errors.New("at least one resource, source or component reference definition has to be provided")
+	}
+	for _, p := range append(append(append([]string{}, o.ResourcesPaths...), o.SourcesPaths...), o.ComponentReferencesPaths...) {
+		if p == "-" {
+			return errors.New("definitions cannot be read from stdin in dev mode")
+		}
+	}
+	return nil
+}
+
+// AddFlags adds all flags of the dev command to the flag set.
+func (o *DevOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVarP(&o.ResourcesPaths, "resources", "r", []string{}, "path to resources definition")
+	fs.StringArrayVarP(&o.SourcesPaths, "sources", "s", []string{}, "path to sources definition")
+	fs.StringArrayVarP(&o.ComponentReferencesPaths, "component-ref", "c", []string{}, "path to component references definition")
+	fs.BoolVarP(&o.Watch, "watch", "w", false, "watch the definitions, their inputs and the component archive and rebuild on every change")
+	fs.DurationVar(&o.WatchInterval, "watch-interval", DefaultWatchInterval, "interval in which the watched files are checked for changes")
+	o.BuilderOptions.AddFlags(fs)
+}
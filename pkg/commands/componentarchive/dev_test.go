@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+)
+
+// syncBuffer is a buffer that can be written and read concurrently.
+type syncBuffer struct {
+	mux sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.String()
+}
+
+var _ = Describe("Dev", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		baseFs, err := projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+		Expect(testdataFs.MkdirAll("/dev", 0755)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "/dev/data.txt", []byte("v1"), 0644)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "/dev/resources.yaml", []byte(`
+name: 'myconfig'
+type: 'plain-text'
+relation: 'local'
+input:
+  type: 'file'
+  path: './data.txt'
+`), 0644)).To(Succeed())
+	})
+
+	It("should build and validate the component archive without modifying it", func() {
+		opts := &componentarchive.DevOptions{
+			ResourcesPaths: []string{"/dev/resources.yaml"},
+		}
+		opts.ComponentArchivePath = "/00-ca"
+
+		var out bytes.Buffer
+		Expect(opts.RunWithWriter(context.TODO(), logr.Discard(), testdataFs, &out)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("Successfully built and validated example.com/component:v0.0.0"))
+
+		_, err := testdataFs.Stat("/00-ca/blobs")
+		Expect(err).To(HaveOccurred(), "the component archive should not be modified")
+	})
+
+	It("should return the error of an invalid definition", func() {
+		Expect(vfs.WriteFile(testdataFs, "/dev/resources.yaml", []byte(`
+name: 'myconfig'
+relation: 'local'
+input:
+  type: 'file'
+  path: './missing.txt'
+`), 0644)).To(Succeed())
+		opts := &componentarchive.DevOptions{
+			ResourcesPaths: []string{"/dev/resources.yaml"},
+		}
+		opts.ComponentArchivePath = "/00-ca"
+
+		var out bytes.Buffer
+		Expect(opts.RunWithWriter(context.TODO(), logr.Discard(), testdataFs, &out)).ToNot(Succeed())
+	})
+
+	It("should rebuild the component archive and print the changes if an input changes", func() {
+		opts := &componentarchive.DevOptions{
+			ResourcesPaths: []string{"/dev/resources.yaml"},
+			Watch:          true,
+			WatchInterval:  10 * time.Millisecond,
+		}
+		opts.ComponentArchivePath = "/00-ca"
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		out := &syncBuffer{}
+		done := make(chan error)
+		go func() {
+			defer GinkgoRecover()
+			done <- opts.RunWithWriter(ctx, logr.Discard(), testdataFs, out)
+		}()
+		Eventually(out.String).Should(ContainSubstring("Watching"))
+
+		Expect(vfs.WriteFile(testdataFs, "/dev/data.txt", []byte("v2-changed"), 0644)).To(Succeed())
+		Eventually(out.String).Should(ContainSubstring("Detected changes in /dev/data.txt"))
+		Eventually(out.String).Should(ContainSubstring(`resource "myconfig" modified`))
+		Expect(out.String()).To(ContainSubstring("access:"))
+
+		Expect(vfs.WriteFile(testdataFs, "/dev/resources.yaml", []byte("invalid: [yaml"), 0644)).To(Succeed())
+		Eventually(out.String).Should(ContainSubstring("Build failed"))
+
+		cancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+})
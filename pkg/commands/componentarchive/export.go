@@ -28,6 +28,10 @@ type ExportOptions struct {
 	OutputPath string
 	// OutputFormat defines the output format of the component archive.
 	OutputFormat ctf.ArchiveFormat
+	// Integrity defines whether an integrity manifest with the digests of all files should be embedded in tar and tgz archives.
+	Integrity bool
+	// Verify defines whether the given archive should only be checked against its embedded integrity manifest.
+	Verify bool
 }
 
 // NewExportCommand creates a new export command that packages a component archive and
@@ -44,6 +48,10 @@ If the given component-archive path points to a directory, the archive is expect
 Then it is exported as tar or optionally as compressed tar.
 
 If the given path points to a file, the archive is read as tar or compressed tar (tar.gz) and exported as filesystem to the given location.
+
+With "--integrity" an integrity manifest (integrity.json) that contains the sha256 digest of every file and the digest
+of the component descriptor is embedded in tar and tgz archives.
+With "--verify" the given tar or tgz archive is not exported but checked against its embedded integrity manifest.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
@@ -54,6 +62,10 @@ If the given path points to a file, the archive is read as tar or compressed tar
 				fmt.Println(err.Error())
 				os.Exit(1)
 			}
+			if opts.Verify {
+				fmt.Printf("Successfully verified the integrity of %s\n", opts.ComponentArchivePath)
+				return
+			}
 			fmt.Printf("Successfully exported component archive to %s\n", opts.OutputPath)
 		},
 	}
@@ -63,6 +75,10 @@ If the given path points to a file, the archive is read as tar or compressed tar
 
 // Run runs the export for a component archive.
 func (o *ExportOptions) Run(_ context.Context, fs vfs.FileSystem) error {
+	if o.Verify {
+		_, err := componentarchive.VerifyIntegrity(fs, o.ComponentArchivePath)
+		return err
+	}
 	ca, format, err := componentarchive.Parse(fs, o.ComponentArchivePath)
 	if err != nil {
 		return err
@@ -79,6 +95,9 @@ func (o *ExportOptions) export(fs vfs.FileSystem, ca *ctf.ComponentArchive, defa
 		o.OutputFormat = defaultFormat
 	}
 
+	if o.Integrity && o.OutputFormat != ctf.ArchiveFormatFilesystem {
+		return componentarchive.WriteWithIntegrityManifest(fs, o.OutputPath, ca, o.OutputFormat)
+	}
 	return componentarchive.Write(fs, o.OutputPath, ca, o.OutputFormat)
 }
 
@@ -97,10 +116,15 @@ func (o *ExportOptions) Complete(args []string) error {
 }
 
 func (o *ExportOptions) validate() error {
+	if o.Verify && o.Integrity {
+		return fmt.Errorf("--integrity cannot be used together with --verify")
+	}
 	return componentarchive.ValidateOutputFormat(o.OutputFormat, true)
 }
 
 func (o *ExportOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVarP(&o.OutputPath, "out", "o", "", "writes the resulting archive to the given path")
 	componentarchive.OutputFormatVar(fs, &o.OutputFormat, "format", "", componentarchive.DefaultOutputFormatUsage)
+	fs.BoolVar(&o.Integrity, "integrity", false, "embeds an integrity manifest with the sha256 digests of all files in tar and tgz archives")
+	fs.BoolVar(&o.Verify, "verify", false, "verifies the given tar or tgz archive against its embedded integrity manifest instead of exporting it")
 }
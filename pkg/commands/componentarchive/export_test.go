@@ -5,7 +5,12 @@
 package componentarchive_test
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"io"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	"github.com/gardener/component-spec/bindings-go/ctf"
@@ -18,6 +23,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	cacomponentarchive "github.com/gardener/component-cli/pkg/componentarchive"
 	"github.com/gardener/component-cli/pkg/utils"
 )
 
@@ -90,4 +96,88 @@ var _ = Describe("Export", func() {
 
 	})
 
+	Context("Integrity", func() {
+
+		It("should embed an integrity manifest and successfully verify the archive", func() {
+			opts := &componentarchive.ExportOptions{
+				ComponentArchivePath: "00-ca",
+				OutputPath:           "ca.tar.gz",
+				OutputFormat:         ctf.ArchiveFormatTarGzip,
+				Integrity:            true,
+			}
+			Expect(opts.Run(context.TODO(), testdataFs)).To(Succeed())
+
+			manifest, err := cacomponentarchive.VerifyIntegrity(testdataFs, "ca.tar.gz")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.Files).To(HaveKey(ctf.ComponentDescriptorFileName))
+			Expect(manifest.ComponentDescriptorDigest).To(HavePrefix("sha256:"))
+
+			opts = &componentarchive.ExportOptions{
+				ComponentArchivePath: "ca.tar.gz",
+				Verify:               true,
+			}
+			Expect(opts.Run(context.TODO(), testdataFs)).To(Succeed())
+
+			// the exported archive should still be readable as component archive
+			_, _, err = cacomponentarchive.Parse(testdataFs, "ca.tar.gz")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should fail to verify a modified archive", func() {
+			opts := &componentarchive.ExportOptions{
+				ComponentArchivePath: "00-ca",
+				OutputPath:           "ca.tar",
+				OutputFormat:         ctf.ArchiveFormatTar,
+				Integrity:            true,
+			}
+			Expect(opts.Run(context.TODO(), testdataFs)).To(Succeed())
+
+			// rewrite the archive with a modified component descriptor file
+			orig, err := vfs.ReadFile(testdataFs, "ca.tar")
+			Expect(err).ToNot(HaveOccurred())
+			var modified bytes.Buffer
+			tr := tar.NewReader(bytes.NewReader(orig))
+			tw := tar.NewWriter(&modified)
+			for {
+				header, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				Expect(err).ToNot(HaveOccurred())
+				data, err := ioutil.ReadAll(tr)
+				Expect(err).ToNot(HaveOccurred())
+				if header.Name == ctf.ComponentDescriptorFileName {
+					data = append(data, []byte("\n")...)
+					header.Size = int64(len(data))
+				}
+				Expect(tw.WriteHeader(header)).To(Succeed())
+				_, err = tw.Write(data)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(tw.Close()).To(Succeed())
+			Expect(vfs.WriteFile(testdataFs, "ca.tar", modified.Bytes(), os.ModePerm)).To(Succeed())
+
+			opts = &componentarchive.ExportOptions{
+				ComponentArchivePath: "ca.tar",
+				Verify:               true,
+			}
+			err = opts.Run(context.TODO(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(ctf.ComponentDescriptorFileName))
+		})
+
+		It("should fail to verify an archive without integrity manifest", func() {
+			opts := &componentarchive.ExportOptions{
+				ComponentArchivePath: "00-ca",
+				OutputPath:           "ca.tar",
+				OutputFormat:         ctf.ArchiveFormatTar,
+			}
+			Expect(opts.Run(context.TODO(), testdataFs)).To(Succeed())
+
+			_, err := cacomponentarchive.VerifyIntegrity(testdataFs, "ca.tar")
+			Expect(err).To(HaveOccurred())
+		})
+
+	})
+
 })
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package labels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// AddOptions defines all options for the add labels command.
+type AddOptions struct {
+	componentarchive.BuilderOptions
+	TargetOptions
+
+	// Labels are the labels of the form name=value that are set.
+	Labels []string
+
+	// parsedLabels are the parsed labels.
+	parsedLabels []cdv2.Label
+}
+
+// NewAddCommand creates a command to set labels of a component descriptor or one of its elements.
+func NewAddCommand(ctx context.Context) *cobra.Command {
+	opts := &AddOptions{}
+	cmd := &cobra.Command{
+		Use:   "add [component-archive-path]",
+		Args:  cobra.RangeArgs(0, 1),
+		Short: "sets labels of the component descriptor or of a resource, source or component reference",
+		Long: `
+add sets the given labels of the component descriptor of a component archive.
+Labels of a resource, source or component reference are set if the element is selected with
+"--resource", "--source" or "--reference". If multiple elements have the same name,
+the element has to be selected additionally by its extra identity ("--extra-identity key=value").
+
+Labels are given as "--label name=value". The value is parsed as json, values that are not valid json are used as string.
+Existing labels with the same name are overwritten.
+
+<pre>
+component-cli ca labels add ./my-ca --label owner=team-a --label 'cloud.gardener.cnudie/dso/scanning-hints/package-versions=[{"name":"a","version":"1.0.0"}]'
+component-cli ca labels add ./my-ca --resource my-image --label 'policy={"public":false}'
+</pre>
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Successfully set %d labels\n", len(opts.Labels))
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// Run sets the labels of the selected element.
+func (o *AddOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	archive, schemaVersion, err := o.BuilderOptions.Build(fs)
+	if err != nil {
+		return err
+	}
+	cd := archive.ComponentDescriptor
+	target, description, err := o.TargetOptions.Select(cd)
+	if err != nil {
+		return err
+	}
+
+	labels := target.GetLabels()
+	for _, label := range o.parsedLabels {
+		labels = setLabel(labels, label)
+		log.V(3).Info(fmt.Sprintf("set label %q of %s", label.Name, description))
+	}
+	target.SetLabels(labels)
+	return writeComponentDescriptor(fs, o.ComponentArchivePath, cd, schemaVersion)
+}
+
+// setLabel overwrites the label with the same name or adds the label if it does not exist yet.
+func setLabel(labels cdv2.Labels, label cdv2.Label) cdv2.Labels {
+	for i := range labels {
+		if labels[i].Name == label.Name {
+			labels[i] = label
+			return labels
+		}
+	}
+	return append(labels, label)
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *AddOptions) Complete(args []string) error {
+	if err := completeBuilderOptions(&o.BuilderOptions, args); err != nil {
+		return err
+	}
+	if err := o.TargetOptions.Validate(); err != nil {
+		return err
+	}
+	if len(o.Labels) == 0 {
+		return errors.New("at least one label has to be defined")
+	}
+	o.parsedLabels = make([]cdv2.Label, len(o.Labels))
+	for i, label := range o.Labels {
+		parsed, err := ParseLabel(label)
+		if err != nil {
+			return err
+		}
+		o.parsedLabels[i] = parsed
+	}
+	return nil
+}
+
+func (o *AddOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&o.Labels, "label", []string{}, "label of the form name=value. The value is parsed as json. Can be specified multiple times")
+	o.TargetOptions.AddFlags(fs)
+	o.BuilderOptions.AddFlags(fs)
+}
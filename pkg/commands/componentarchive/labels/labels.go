@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package labels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/components/schema"
+)
+
+// NewLabelsCommand creates a new command to modify labels of a component descriptor and its elements.
+func NewLabelsCommand(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "labels",
+		Short: "command to modify labels of a component descriptor and its resources, sources and component references",
+	}
+	cmd.AddCommand(NewAddCommand(ctx))
+	cmd.AddCommand(NewRemoveCommand(ctx))
+	cmd.AddCommand(NewListCommand(ctx))
+	return cmd
+}
+
+// TargetOptions selects the element of the component descriptor whose labels are modified.
+// The component descriptor itself is selected if no element is defined.
+type TargetOptions struct {
+	// Resource is the name of the selected resource.
+	Resource string
+	// Source is the name of the selected source.
+	Source string
+	// Reference is the name of the selected component reference.
+	Reference string
+	// ExtraIdentity is the extra identity of the selected element.
+	// It is only needed if multiple elements with the same name exist.
+	ExtraIdentity map[string]string
+}
+
+func (o *TargetOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Resource, "resource", "", "name of the resource whose labels are modified")
+	fs.StringVar(&o.Source, "source", "", "name of the source whose labels are modified")
+	fs.StringVar(&o.Reference, "reference", "", "name of the component reference whose labels are modified")
+	fs.StringToStringVar(&o.ExtraIdentity, "extra-identity", map[string]string{}, "extra identity of the selected resource, source or component reference")
+}
+
+// Validate validates the target options.
+func (o *TargetOptions) Validate() error {
+	selected := 0
+	for _, name := range []string{o.Resource, o.Source, o.Reference} {
+		if len(name) != 0 {
+			selected++
+		}
+	}
+	if selected > 1 {
+		return errors.New("only one of --resource, --source and --reference can be defined")
+	}
+	if selected == 0 && len(o.ExtraIdentity) != 0 {
+		return errors.New("--extra-identity can only be defined together with --resource, --source or --reference")
+	}
+	return nil
+}
+
+// Select returns the selected element of the component descriptor and a human readable description of it.
+func (o *TargetOptions) Select(cd *cdv2.ComponentDescriptor) (cdv2.LabelsAccessor, string, error) {
+	var (
+		kind    string
+		name    string
+		matches []cdv2.LabelsAccessor
+	)
+	switch {
+	case len(o.Resource) != 0:
+		kind, name = "resource", o.Resource
+		for i, res := range cd.Resources {
+			if res.Name == o.Resource && matchesExtraIdentity(res.ExtraIdentity, o.ExtraIdentity) {
+				matches = append(matches, &cd.Resources[i])
+			}
+		}
+	case len(o.Source) != 0:
+		kind, name = "source", o.Source
+		for i, src := range cd.Sources {
+			if src.Name == o.Source && matchesExtraIdentity(src.ExtraIdentity, o.ExtraIdentity) {
+				matches = append(matches, &cd.Sources[i])
+			}
+		}
+	case len(o.Reference) != 0:
+		kind, name = "component reference", o.Reference
+		for i, ref := range cd.ComponentReferences {
+			if ref.Name == o.Reference && matchesExtraIdentity(ref.ExtraIdentity, o.ExtraIdentity) {
+				matches = append(matches, &cd.ComponentReferences[i])
+			}
+		}
+	default:
+		return &cd.ObjectMeta, "component descriptor", nil
+	}
+
+	description := fmt.Sprintf("%s %q", kind, name)
+	if len(matches) == 0 {
+		return nil, "", fmt.Errorf("%s not found", description)
+	}
+	if len(matches) > 1 {
+		return nil, "", fmt.Errorf("%s is ambiguous: %d elements match, use --extra-identity to select one", description, len(matches))
+	}
+	return matches[0], description, nil
+}
+
+// matchesExtraIdentity checks whether the extra identity contains all selected key value pairs.
+func matchesExtraIdentity(extraIdentity cdv2.Identity, selector map[string]string) bool {
+	for key, value := range selector {
+		if extraIdentity[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseLabel parses a label of the form "name=value".
+// The value is parsed as json. Values that are not valid json are used as string.
+func ParseLabel(label string) (cdv2.Label, error) {
+	splitLabel := strings.SplitN(label, "=", 2)
+	if len(splitLabel) != 2 {
+		return cdv2.Label{}, fmt.Errorf("label %q has to be of the form name=value", label)
+	}
+	name, value := strings.TrimSpace(splitLabel[0]), strings.TrimSpace(splitLabel[1])
+	if len(name) == 0 {
+		return cdv2.Label{}, fmt.Errorf("label %q has no name", label)
+	}
+	if len(value) == 0 {
+		return cdv2.Label{}, fmt.Errorf("label %q has no value", label)
+	}
+	if !json.Valid([]byte(value)) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return cdv2.Label{}, fmt.Errorf("unable to encode value of label %q: %w", name, err)
+		}
+		value = string(data)
+	}
+	return cdv2.Label{
+		Name:  name,
+		Value: json.RawMessage(value),
+	}, nil
+}
+
+// writeComponentDescriptor validates and writes the component descriptor of the component archive in the given schema version.
+func writeComponentDescriptor(fs vfs.FileSystem, archivePath string, cd *cdv2.ComponentDescriptor, schemaVersion string) error {
+	if err := cdvalidation.Validate(cd); err != nil {
+		return fmt.Errorf("invalid component descriptor: %w", err)
+	}
+	data, err := schema.EncodeYAML(cd, schemaVersion)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	compDescFilePath := filepath.Join(archivePath, ctf.ComponentDescriptorFileName)
+	if err := vfs.WriteFile(fs, compDescFilePath, data, 0664); err != nil {
+		return fmt.Errorf("unable to write modified component descriptor: %w", err)
+	}
+	return nil
+}
+
+// completeBuilderOptions defaults the component archive path from the arguments.
+func completeBuilderOptions(builderOpts *componentarchive.BuilderOptions, args []string) error {
+	if len(args) != 0 {
+		builderOpts.ComponentArchivePath = args[0]
+	}
+	builderOpts.Default()
+	return builderOpts.Validate()
+}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package labels_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/labels"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Labels Test Suite")
+}
+
+var _ = Describe("Labels", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs, err := projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+		testdataFs = layerfs.New(memoryfs.New(), fs)
+	})
+
+	readComponentDescriptor := func() *cdv2.ComponentDescriptor {
+		data, err := vfs.ReadFile(testdataFs, filepath.Join("./00-component", ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		return cd
+	}
+
+	Context("ParseLabel", func() {
+		It("should parse json values and use other values as string", func() {
+			label, err := labels.ParseLabel(`policy={"public":false}`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(label.Name).To(Equal("policy"))
+			Expect(label.Value).To(MatchJSON(`{"public":false}`))
+
+			label, err = labels.ParseLabel("owner=team-b")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(label.Value).To(MatchJSON(`"team-b"`))
+		})
+
+		It("should fail if the label has no value", func() {
+			_, err := labels.ParseLabel("owner")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("should overwrite and add labels of the component descriptor", func() {
+		opts := &labels.AddOptions{
+			Labels: []string{"owner=team-b", "replicas=3"},
+		}
+		Expect(opts.Complete([]string{"./00-component"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		cd := readComponentDescriptor()
+		Expect(cd.Labels).To(HaveLen(2))
+		Expect(cd.Labels[0].Name).To(Equal("owner"))
+		Expect(cd.Labels[0].Value).To(MatchJSON(`"team-b"`))
+		Expect(cd.Labels[1].Name).To(Equal("replicas"))
+		Expect(cd.Labels[1].Value).To(Equal(json.RawMessage("3")))
+	})
+
+	It("should add a label to the resource selected by its extra identity", func() {
+		opts := &labels.AddOptions{
+			TargetOptions: labels.TargetOptions{
+				Resource:      "chart",
+				ExtraIdentity: map[string]string{"platform": "windows"},
+			},
+			Labels: []string{"tested=true"},
+		}
+		Expect(opts.Complete([]string{"./00-component"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		cd := readComponentDescriptor()
+		Expect(cd.Resources[1].Labels).To(BeEmpty())
+		Expect(cd.Resources[2].Labels).To(HaveLen(1))
+		Expect(cd.Resources[2].Labels[0].Value).To(MatchJSON("true"))
+	})
+
+	It("should fail if the selected resource is ambiguous", func() {
+		opts := &labels.AddOptions{
+			TargetOptions: labels.TargetOptions{Resource: "chart"},
+			Labels:        []string{"tested=true"},
+		}
+		Expect(opts.Complete([]string{"./00-component"})).To(Succeed())
+		err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("ambiguous"))
+	})
+
+	It("should remove a label of the component descriptor", func() {
+		opts := &labels.RemoveOptions{
+			Names: []string{"owner"},
+		}
+		Expect(opts.Complete([]string{"./00-component"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		cd := readComponentDescriptor()
+		Expect(cd.Labels).To(BeEmpty())
+	})
+
+	It("should fail to remove a label that does not exist", func() {
+		opts := &labels.RemoveOptions{
+			TargetOptions: labels.TargetOptions{Resource: "image"},
+			Names:         []string{"owner"},
+		}
+		Expect(opts.Complete([]string{"./00-component"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(HaveOccurred())
+
+		opts.IgnoreMissing = true
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+	})
+
+})
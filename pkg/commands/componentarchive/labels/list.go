@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package labels
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// ListOptions defines all options for the list labels command.
+type ListOptions struct {
+	componentarchive.BuilderOptions
+	TargetOptions
+}
+
+// NewListCommand creates a command to list labels of a component descriptor or one of its elements.
+func NewListCommand(ctx context.Context) *cobra.Command {
+	opts := &ListOptions{}
+	cmd := &cobra.Command{
+		Use:     "list [component-archive-path]",
+		Aliases: []string{"ls"},
+		Args:    cobra.RangeArgs(0, 1),
+		Short:   "lists the labels of the component descriptor or of a resource, source or component reference",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// Run prints the labels of the selected element as yaml.
+func (o *ListOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	archive, _, err := o.BuilderOptions.Build(fs)
+	if err != nil {
+		return err
+	}
+	target, _, err := o.TargetOptions.Select(archive.ComponentDescriptor)
+	if err != nil {
+		return err
+	}
+	labels := target.GetLabels()
+	if len(labels) == 0 {
+		return nil
+	}
+	data, err := yaml.Marshal(labels)
+	if err != nil {
+		return fmt.Errorf("unable to encode labels: %w", err)
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *ListOptions) Complete(args []string) error {
+	if err := completeBuilderOptions(&o.BuilderOptions, args); err != nil {
+		return err
+	}
+	return o.TargetOptions.Validate()
+}
+
+func (o *ListOptions) AddFlags(fs *pflag.FlagSet) {
+	o.TargetOptions.AddFlags(fs)
+	o.BuilderOptions.AddFlags(fs)
+}
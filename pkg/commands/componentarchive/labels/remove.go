@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package labels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// RemoveOptions defines all options for the remove labels command.
+type RemoveOptions struct {
+	componentarchive.BuilderOptions
+	TargetOptions
+
+	// Names are the names of the labels that are removed.
+	Names []string
+	// IgnoreMissing defines that labels that do not exist are ignored.
+	IgnoreMissing bool
+}
+
+// NewRemoveCommand creates a command to remove labels of a component descriptor or one of its elements.
+func NewRemoveCommand(ctx context.Context) *cobra.Command {
+	opts := &RemoveOptions{}
+	cmd := &cobra.Command{
+		Use:     "remove [component-archive-path]",
+		Aliases: []string{"rm"},
+		Args:    cobra.RangeArgs(0, 1),
+		Short:   "removes labels of the component descriptor or of a resource, source or component reference",
+		Long: `
+remove deletes the labels with the given names ("--label name") from the component descriptor of a component archive.
+Labels of a resource, source or component reference are removed if the element is selected with
+"--resource", "--source" or "--reference". If multiple elements have the same name,
+the element has to be selected additionally by its extra identity ("--extra-identity key=value").
+
+The command fails if a label does not exist unless "--ignore-missing" is set.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Successfully removed %d labels\n", len(opts.Names))
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// Run removes the labels of the selected element.
+func (o *RemoveOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	archive, schemaVersion, err := o.BuilderOptions.Build(fs)
+	if err != nil {
+		return err
+	}
+	cd := archive.ComponentDescriptor
+	target, description, err := o.TargetOptions.Select(cd)
+	if err != nil {
+		return err
+	}
+
+	labels := target.GetLabels()
+	for _, name := range o.Names {
+		var removed bool
+		labels, removed = removeLabel(labels, name)
+		if !removed && !o.IgnoreMissing {
+			return fmt.Errorf("label %q of %s not found", name, description)
+		}
+		log.V(3).Info(fmt.Sprintf("removed label %q of %s", name, description))
+	}
+	target.SetLabels(labels)
+	return writeComponentDescriptor(fs, o.ComponentArchivePath, cd, schemaVersion)
+}
+
+// removeLabel removes the label with the given name and returns whether the label existed.
+func removeLabel(labels cdv2.Labels, name string) (cdv2.Labels, bool) {
+	for i := range labels {
+		if labels[i].Name == name {
+			return append(labels[:i], labels[i+1:]...), true
+		}
+	}
+	return labels, false
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *RemoveOptions) Complete(args []string) error {
+	if err := completeBuilderOptions(&o.BuilderOptions, args); err != nil {
+		return err
+	}
+	if err := o.TargetOptions.Validate(); err != nil {
+		return err
+	}
+	if len(o.Names) == 0 {
+		return errors.New("at least one label has to be defined")
+	}
+	return nil
+}
+
+func (o *RemoveOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&o.Names, "label", []string{}, "name of the label that is removed. Can be specified multiple times")
+	fs.BoolVar(&o.IgnoreMissing, "ignore-missing", false, "ignore labels that do not exist")
+	o.TargetOptions.AddFlags(fs)
+	o.BuilderOptions.AddFlags(fs)
+}
@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/ociclient"
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// LockOptions defines all options for the lock command.
+type LockOptions struct {
+	componentarchive.BuilderOptions
+
+	// LockFilePath is the path to the lock file.
+	// Defaults to the lock file in the component archive.
+	LockFilePath string
+	// Pin defines whether the oci registry accesses of the component descriptor should be rewritten to their digest form.
+	Pin bool
+	// Verify defines whether the component descriptor should only be checked against an existing lock file.
+	Verify bool
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+}
+
+// NewLockCommand creates a new command that pins all external references of a component archive by their digest.
+func NewLockCommand(ctx context.Context) *cobra.Command {
+	opts := &LockOptions{}
+	cmd := &cobra.Command{
+		Use:   "lock [component-archive-path]",
+		Args:  cobra.RangeArgs(0, 1),
+		Short: "pins all external references of a component archive by their digest",
+		Long: fmt.Sprintf(`
+lock resolves every resource with an ociRegistry access and every component reference to its current digest
+and writes the digests to a lock file (defaults to %q in the component archive).
+Component references are resolved in the effective repository context of the component descriptor.
+
+With "--pin" the ociRegistry accesses of the component descriptor are additionally rewritten to their digest form
+which makes rebuilds of the component archive reproducible.
+
+With "--verify" no lock file is written but all references are resolved again and compared to the existing lock file.
+The command fails if any reference drifted from the lock.
+`, componentarchive.LockFileName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			if opts.Verify {
+				fmt.Printf("Successfully verified component archive against lock file %s\n", opts.LockFilePath)
+				return
+			}
+			fmt.Printf("Successfully written lock file %s\n", opts.LockFilePath)
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// Run locks the component archive.
+func (o *LockOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	return o.RunWithResolver(ctx, fs, ociClient)
+}
+
+// RunWithResolver locks the component archive and resolves all references with the given resolver.
+func (o *LockOptions) RunWithResolver(ctx context.Context, fs vfs.FileSystem, resolver ociclient.Resolver) error {
+	archive, schemaVersion, err := o.BuilderOptions.Build(fs)
+	if err != nil {
+		return err
+	}
+	cd := archive.ComponentDescriptor
+
+	if o.Verify {
+		data, err := vfs.ReadFile(fs, o.LockFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to read lock file: %w", err)
+		}
+		lock := &componentarchive.Lock{}
+		if err := yaml.Unmarshal(data, lock); err != nil {
+			return fmt.Errorf("unable to decode lock file: %w", err)
+		}
+		drift, err := componentarchive.VerifyLock(ctx, resolver, cd, lock)
+		if err != nil {
+			return err
+		}
+		if len(drift) != 0 {
+			return fmt.Errorf("component archive drifted from lock file %s:\n%s", o.LockFilePath, strings.Join(drift, "\n"))
+		}
+		return nil
+	}
+
+	lock, err := componentarchive.GenerateLock(ctx, resolver, cd)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("unable to encode lock file: %w", err)
+	}
+	if err := vfs.WriteFile(fs, o.LockFilePath, data, 0664); err != nil {
+		return fmt.Errorf("unable to write lock file to %s: %w", o.LockFilePath, err)
+	}
+
+	if !o.Pin {
+		return nil
+	}
+	if err := componentarchive.PinResourceAccesses(cd, lock); err != nil {
+		return err
+	}
+	if err := cdvalidation.Validate(cd); err != nil {
+		return fmt.Errorf("invalid component descriptor: %w", err)
+	}
+	cdData, err := schema.EncodeYAML(cd, schemaVersion)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
+	if err := vfs.WriteFile(fs, compDescFilePath, cdData, 0664); err != nil {
+		return fmt.Errorf("unable to write modified component descriptor: %w", err)
+	}
+	return nil
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *LockOptions) Complete(args []string) error {
+	if len(args) != 0 {
+		o.BuilderOptions.ComponentArchivePath = args[0]
+	}
+	o.BuilderOptions.Default()
+	if err := o.BuilderOptions.Validate(); err != nil {
+		return err
+	}
+	if len(o.LockFilePath) == 0 {
+		o.LockFilePath = filepath.Join(o.ComponentArchivePath, componentarchive.LockFileName)
+	}
+	if o.Pin && o.Verify {
+		return errors.New("--pin and --verify cannot be used together")
+	}
+	return nil
+}
+
+func (o *LockOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.LockFilePath, "lock-file", "", fmt.Sprintf("path to the lock file. Defaults to %q in the component archive", componentarchive.LockFileName))
+	fs.BoolVar(&o.Pin, "pin", false, "rewrite the ociRegistry accesses of the component descriptor to their digest form")
+	fs.BoolVar(&o.Verify, "verify", false, "verify the component archive against an existing lock file instead of writing it")
+	o.BuilderOptions.AddFlags(fs)
+	o.OciOptions.AddFlags(fs)
+}
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"context"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	cacomponentarchive "github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+// staticResolver resolves references to a static digest.
+type staticResolver map[string]digest.Digest
+
+func (r staticResolver) Resolve(_ context.Context, ref string) (string, ocispecv1.Descriptor, error) {
+	dgst, ok := r[ref]
+	if !ok {
+		return "", ocispecv1.Descriptor{}, fmt.Errorf("%s not found", ref)
+	}
+	return ref, ocispecv1.Descriptor{Digest: dgst}, nil
+}
+
+var _ = Describe("Lock", func() {
+
+	var (
+		testdataFs vfs.FileSystem
+		resolver   staticResolver
+	)
+
+	BeforeEach(func() {
+		baseFs, err := projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+		resolver = staticResolver{
+			"example.com/image:v0.1.0": digest.FromString("image"),
+			"example.com/components/component-descriptors/example.com/referenced:v0.1.0": digest.FromString("ref"),
+		}
+	})
+
+	It("should write a lock file with the digests of all external references", func() {
+		opts := &componentarchive.LockOptions{}
+		Expect(opts.Complete([]string{"02-ca-lock"})).To(Succeed())
+		Expect(opts.RunWithResolver(context.TODO(), testdataFs, resolver)).To(Succeed())
+
+		lock := readLockFile(testdataFs, opts.LockFilePath)
+		Expect(lock.Resources).To(HaveLen(1))
+		Expect(lock.Resources[0].Digest).To(Equal(digest.FromString("image").String()))
+		Expect(lock.ComponentReferences).To(HaveLen(1))
+		Expect(lock.ComponentReferences[0].Digest).To(Equal(digest.FromString("ref").String()))
+
+		verifyOpts := &componentarchive.LockOptions{Verify: true}
+		Expect(verifyOpts.Complete([]string{"02-ca-lock"})).To(Succeed())
+		Expect(verifyOpts.RunWithResolver(context.TODO(), testdataFs, resolver)).To(Succeed())
+
+		resolver["example.com/image:v0.1.0"] = digest.FromString("updated-image")
+		err := verifyOpts.RunWithResolver(context.TODO(), testdataFs, resolver)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`resource "image"`))
+	})
+
+	It("should rewrite oci registry accesses to their digest form", func() {
+		opts := &componentarchive.LockOptions{Pin: true}
+		Expect(opts.Complete([]string{"02-ca-lock"})).To(Succeed())
+		Expect(opts.RunWithResolver(context.TODO(), testdataFs, resolver)).To(Succeed())
+
+		archiveFs, err := projectionfs.New(testdataFs, "02-ca-lock")
+		Expect(err).ToNot(HaveOccurred())
+		archive, err := ctf.NewComponentArchiveFromFilesystem(archiveFs)
+		Expect(err).ToNot(HaveOccurred())
+		ociAccess := &cdv2.OCIRegistryAccess{}
+		Expect(archive.ComponentDescriptor.Resources[0].Access.DecodeInto(ociAccess)).To(Succeed())
+		Expect(ociAccess.ImageReference).To(Equal("example.com/image@" + digest.FromString("image").String()))
+	})
+
+})
+
+func readLockFile(fs vfs.FileSystem, path string) *cacomponentarchive.Lock {
+	data, err := vfs.ReadFile(fs, path)
+	Expect(err).ToNot(HaveOccurred())
+	lock := &cacomponentarchive.Lock{}
+	Expect(yaml.Unmarshal(data, lock)).To(Succeed())
+	return lock
+}
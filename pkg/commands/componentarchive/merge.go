@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+// MergeOptions defines all options for the merge command.
+type MergeOptions struct {
+	// FirstArchivePath is the path to the first component archive.
+	FirstArchivePath string
+	// SecondArchivePath is the path to the second component archive.
+	SecondArchivePath string
+	// OutputPath defines the path where the merged component archive should be written to.
+	OutputPath string
+	// OutputFormat defines the output format of the merged component archive.
+	OutputFormat ctf.ArchiveFormat
+	// OnConflict defines how different entries with the same identity are merged.
+	OnConflict string
+}
+
+// NewMergeCommand creates a new command that merges two component archives of the same component version.
+func NewMergeCommand(ctx context.Context) *cobra.Command {
+	opts := &MergeOptions{}
+	cmd := &cobra.Command{
+		Use:   "merge FIRST_COMPONENT_ARCHIVE_PATH SECOND_COMPONENT_ARCHIVE_PATH -o OUTPUT_PATH",
+		Args:  cobra.ExactArgs(2),
+		Short: "merges two component archives of the same component version",
+		Long: `
+merge combines the resources, sources, component references and local blobs of two component archives
+of the same component name and version into a new component archive.
+This supports build pipelines where different jobs contribute different resources to one component.
+All other fields of the component descriptor (e.g. the provider, labels and repository contexts) are taken from the first archive.
+
+Entries are matched by their identity (name and extra identity). Identical entries are added only once.
+If both archives contain a different entry with the same identity the conflict is resolved with "--on-conflict":
+- fail: the merge fails (default)
+- prefer-first: the entry of the first archive is kept
+- prefer-second: the entry of the second archive is kept
+
+The archives can be given in any format (fs, tar or tgz). The output format defaults to the format of the first archive.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			if err := opts.Run(ctx, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Successfully merged component archives to %s\n", opts.OutputPath)
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// Run merges the component archives and writes the result.
+func (o *MergeOptions) Run(ctx context.Context, fs vfs.FileSystem) error {
+	first, format, err := componentarchive.Parse(fs, o.FirstArchivePath)
+	if err != nil {
+		return fmt.Errorf("unable to read first component archive: %w", err)
+	}
+	second, _, err := componentarchive.Parse(fs, o.SecondArchivePath)
+	if err != nil {
+		return fmt.Errorf("unable to read second component archive: %w", err)
+	}
+
+	merged, err := componentarchive.Merge(ctx, first, second, componentarchive.MergeStrategy(o.OnConflict))
+	if err != nil {
+		return err
+	}
+	if err := cdvalidation.Validate(merged.ComponentDescriptor); err != nil {
+		return fmt.Errorf("invalid merged component descriptor: %w", err)
+	}
+
+	if len(o.OutputFormat) == 0 {
+		o.OutputFormat = format
+	}
+	return componentarchive.Write(fs, o.OutputPath, merged, o.OutputFormat)
+}
+
+// Complete parses the given command arguments and applies default options.
+func (o *MergeOptions) Complete(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected exactly two arguments that contain the paths to the component archives")
+	}
+	o.FirstArchivePath = args[0]
+	o.SecondArchivePath = args[1]
+	return o.validate()
+}
+
+func (o *MergeOptions) validate() error {
+	if len(o.OutputPath) == 0 {
+		return errors.New("the output path must be provided")
+	}
+	if err := componentarchive.ValidateMergeStrategy(componentarchive.MergeStrategy(o.OnConflict)); err != nil {
+		return err
+	}
+	return componentarchive.ValidateOutputFormat(o.OutputFormat, true)
+}
+
+func (o *MergeOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.OutputPath, "out", "o", "", "writes the merged component archive to the given path")
+	componentarchive.OutputFormatVar(fs, &o.OutputFormat, "format", "", componentarchive.DefaultOutputFormatUsage)
+	fs.StringVar(&o.OnConflict, "on-conflict", string(componentarchive.MergeStrategyFail), "resolution of different entries with the same identity. One of fail, prefer-first or prefer-second")
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"context"
+	"os"
+
+	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	cacomponentarchive "github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+const secondArchiveDescriptor = `
+meta:
+  schemaVersion: v2
+component:
+  name: example.com/component
+  version: v0.0.0
+  provider: internal
+  repositoryContexts: []
+  sources: []
+  componentReferences:
+  - name: dep
+    componentName: example.com/dep
+    version: v1.0.0
+  resources:
+  - name: image
+    type: ociImage
+    version: v1.0.0
+    relation: external
+    access:
+      type: ociRegistry
+      imageReference: example.com/image:v1.0.0
+`
+
+var _ = Describe("Merge", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		baseFs, err := projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+	})
+
+	writeSecondArchive := func(descriptor string) {
+		Expect(testdataFs.MkdirAll("second-ca", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "second-ca/component-descriptor.yaml", []byte(descriptor), os.ModePerm)).To(Succeed())
+	}
+
+	It("should merge the resources, references and blobs of two component archives", func() {
+		writeSecondArchive(secondArchiveDescriptor)
+		opts := &componentarchive.MergeOptions{
+			FirstArchivePath:  "01-ca-blob",
+			SecondArchivePath: "second-ca",
+			OutputPath:        "merged-ca",
+			OnConflict:        string(cacomponentarchive.MergeStrategyFail),
+		}
+		Expect(opts.Run(context.TODO(), testdataFs)).To(Succeed())
+
+		ca, _, err := cacomponentarchive.Parse(testdataFs, "merged-ca")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ca.ComponentDescriptor.Resources).To(HaveLen(2))
+		Expect(ca.ComponentDescriptor.Resources[0].Name).To(Equal("myconfig"))
+		Expect(ca.ComponentDescriptor.Resources[1].Name).To(Equal("image"))
+		Expect(ca.ComponentDescriptor.ComponentReferences).To(HaveLen(1))
+		Expect(ca.ComponentDescriptor.RepositoryContexts).To(HaveLen(1), "the repository contexts should be taken from the first archive")
+
+		_, err = testdataFs.Stat("merged-ca/blobs/sha256-ab894987c426bf8d660826c6fa52a1f351a4c4c094f913862be9c76386bcc32f")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should fail if both component archives define a resource differently", func() {
+		writeSecondArchive(secondArchiveDescriptor + `
+  - name: myconfig
+    type: json
+    version: v0.0.1
+    relation: external
+    access:
+      type: ociRegistry
+      imageReference: example.com/config:v0.0.1
+`)
+		opts := &componentarchive.MergeOptions{
+			FirstArchivePath:  "01-ca-blob",
+			SecondArchivePath: "second-ca",
+			OutputPath:        "merged-ca",
+			OnConflict:        string(cacomponentarchive.MergeStrategyFail),
+		}
+		err := opts.Run(context.TODO(), testdataFs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("resource myconfig is defined differently"))
+	})
+
+	It("should keep the resource of the second component archive on conflicts with prefer-second", func() {
+		writeSecondArchive(secondArchiveDescriptor + `
+  - name: myconfig
+    type: json
+    version: v0.0.1
+    relation: external
+    access:
+      type: ociRegistry
+      imageReference: example.com/config:v0.0.1
+`)
+		opts := &componentarchive.MergeOptions{
+			FirstArchivePath:  "01-ca-blob",
+			SecondArchivePath: "second-ca",
+			OutputPath:        "merged-ca",
+			OnConflict:        string(cacomponentarchive.MergeStrategyPreferSecond),
+		}
+		Expect(opts.Run(context.TODO(), testdataFs)).To(Succeed())
+
+		ca, _, err := cacomponentarchive.Parse(testdataFs, "merged-ca")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ca.ComponentDescriptor.Resources).To(HaveLen(2))
+		Expect(ca.ComponentDescriptor.Resources[0].Name).To(Equal("myconfig"))
+		Expect(ca.ComponentDescriptor.Resources[0].Version).To(Equal("v0.0.1"))
+
+		_, err = testdataFs.Stat("merged-ca/blobs/sha256-ab894987c426bf8d660826c6fa52a1f351a4c4c094f913862be9c76386bcc32f")
+		Expect(os.IsNotExist(err)).To(BeTrue(), "the blob of the replaced resource should not be copied")
+	})
+
+})
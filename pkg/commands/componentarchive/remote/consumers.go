@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// ConsumersOptions defines all options for the consumers command.
+type ConsumersOptions struct {
+	// BaseUrl is the oci registry where the components are stored.
+	BaseUrl string
+	// ComponentName is the unique name of the component whose consumers are searched.
+	ComponentName string
+	// Version is the version of the component whose consumers are searched.
+	// All versions are matched if empty.
+	Version string
+
+	ComponentNameMapping string
+
+	// Components are the candidate components of the form "<name>:<version>".
+	// The catalog of the registry is walked if no candidates are given.
+	Components []string
+	// Transitive also reports the components that indirectly reference the component.
+	Transitive bool
+	// OutputFormat defines the format of the output.
+	OutputFormat string
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+}
+
+// NewConsumersCommand creates a new command that lists the components that reference a component.
+func NewConsumersCommand(ctx context.Context) *cobra.Command {
+	opts := &ConsumersOptions{}
+	cmd := &cobra.Command{
+		Use:   "consumers BASE_URL COMPONENT_NAME [VERSION]",
+		Args:  cobra.RangeArgs(2, 3),
+		Short: "lists the components in a oci registry that reference a component",
+		Long: `
+consumers lists the components that reference the given component version, or any version of the component if no version is given.
+This helps to assess which components are affected before a shared component is deprecated or patched.
+
+The candidate components are either given with "--component <name>:<version>" or all component versions
+of the repository context are scanned. Scanning the repository context requires the registry to support the catalog api.
+
+If "--transitive" is set, the components that reference a consumer are reported, too.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run searches the consumers of the component and prints them.
+func (o *ConsumersOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	repoCtx := cdv2.OCIRegistryRepository{
+		ObjectType: cdv2.ObjectType{
+			Type: cdv2.OCIRegistryType,
+		},
+		BaseURL:              o.BaseUrl,
+		ComponentNameMapping: cdv2.ComponentNameMapping(o.ComponentNameMapping),
+	}
+
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+
+	opts := components.ConsumerOptions{
+		Transitive: o.Transitive,
+	}
+	var candidates []components.ComponentVersion
+	if len(o.Components) != 0 {
+		for _, c := range o.Components {
+			cv, err := components.ParseComponentVersion(c)
+			if err != nil {
+				return err
+			}
+			candidates = append(candidates, cv)
+		}
+	} else {
+		candidates, err = components.ListComponentVersions(ctx, ociClient, repoCtx)
+		if err != nil {
+			return err
+		}
+		// the catalog may contain tags that are no component descriptors.
+		opts.SkipUnresolvable = true
+		log.V(3).Info(fmt.Sprintf("found %d component versions in %s", len(candidates), o.BaseUrl))
+	}
+
+	consumers, err := components.FindConsumers(ctx, schema.NewResolver(ociClient), repoCtx, candidates, o.ComponentName, o.Version, opts)
+	if err != nil {
+		return err
+	}
+
+	if o.OutputFormat == "yaml" {
+		out, err := yaml.Marshal(consumers)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	for _, consumer := range consumers {
+		if consumer.Depth == 1 {
+			fmt.Printf("%s references %s as %q\n", consumer.ComponentVersion, consumer.References, consumer.ReferenceName)
+			continue
+		}
+		fmt.Printf("%s references %s as %q (transitive, depth %d)\n", consumer.ComponentVersion, consumer.References, consumer.ReferenceName, consumer.Depth)
+	}
+	return nil
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *ConsumersOptions) Complete(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("illegal number of arguments: %d", len(args))
+	}
+	o.BaseUrl = args[0]
+	o.ComponentName = args[1]
+	if len(args) == 3 {
+		o.Version = args[2]
+	}
+
+	if len(o.OciOptions.CacheDir) == 0 {
+		cliHomeDir, err := constants.CliHomeDir()
+		if err != nil {
+			return err
+		}
+		o.OciOptions.CacheDir = filepath.Join(cliHomeDir, "components")
+		if err := os.MkdirAll(o.OciOptions.CacheDir, os.ModePerm); err != nil {
+			return fmt.Errorf("unable to create cache directory %s: %w", o.OciOptions.CacheDir, err)
+		}
+	}
+
+	if len(o.BaseUrl) == 0 {
+		return errors.New("the base url must be provided")
+	}
+	if len(o.ComponentName) == 0 {
+		return errors.New("a component name must be provided")
+	}
+	if o.OutputFormat != "text" && o.OutputFormat != "yaml" {
+		return fmt.Errorf("unsupported output format %q: must be text or yaml", o.OutputFormat)
+	}
+	return nil
+}
+
+func (o *ConsumersOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.ComponentNameMapping, "component-name-mapping", string(cdv2.OCIRegistryURLPathMapping), "[OPTIONAL] repository context name mapping")
+	fs.StringArrayVar(&o.Components, "component", nil, "[OPTIONAL] candidate component of the form <name>:<version>. Can be specified multiple times. All components of the repository context are scanned if not set")
+	fs.BoolVar(&o.Transitive, "transitive", false, "also list the components that indirectly reference the component")
+	fs.StringVarP(&o.OutputFormat, "output", "o", "text", "output format of the consumers. One of text or yaml")
+	o.OciOptions.AddFlags(fs)
+}
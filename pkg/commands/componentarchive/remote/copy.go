@@ -32,6 +32,7 @@ import (
 	"github.com/gardener/component-cli/ociclient/cache"
 
 	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
 
 	ociopts "github.com/gardener/component-cli/ociclient/options"
 	"github.com/gardener/component-cli/pkg/logger"
@@ -69,6 +70,13 @@ type CopyOptions struct {
 
 	// ReplaceOCIRefs contains replace expressions for manipulating upload refs of resources with accessType == ociRegistry
 	ReplaceOCIRefs []string
+	// LayerConcurrency is the maximal number of layers of an oci artifact that are copied in parallel.
+	// This value is only relevant if the artifacts are copied by value.
+	LayerConcurrency int
+	// TargetSchemaVersion is the schema version of the component descriptors in the target repository.
+	// The schema version of the source component descriptors is kept if no version is defined.
+	// +optional
+	TargetSchemaVersion string
 
 	// OciOptions contains all exposed options to configure the oci client.
 	OciOptions ociopts.Options
@@ -89,6 +97,14 @@ copies a component descriptor and its blobs from the source repository to the ta
 
 By default the component descriptor and all its component references are recursively copied.
 This behavior can be overwritten by specifying "--recursive=false"
+Every component version is copied only once, even if it is referenced multiple times.
+The copy fails if the component references form a cycle.
+
+After the copy a summary of all copied and skipped component descriptors and all oci artifacts that were copied by value is printed.
+
+The component descriptors are copied in the schema version of the source component descriptors.
+If the target repository expects another schema version, the component descriptors are converted
+to the schema version given by "--target-schema-version" (e.g. "ocm.software/v3alpha1" or "v2").
 
 `,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -129,7 +145,7 @@ func (o *CopyOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSyste
 	c := Copier{
 		SrcRepoCtx:                     cdv2.NewOCIRegistryRepository(o.SourceRepository, ""),
 		TargetRepoCtx:                  cdv2.NewOCIRegistryRepository(o.TargetRepository, ""),
-		CompResolver:                   cdoci.NewResolver(ociClient),
+		CompResolver:                   schema.NewResolver(ociClient),
 		OciClient:                      ociClient,
 		Cache:                          cache,
 		Recursive:                      o.Recursive,
@@ -140,6 +156,8 @@ func (o *CopyOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSyste
 		TargetArtifactRepository:       o.TargetArtifactRepository,
 		ConvertToRelativeOCIReferences: o.ConvertToRelativeOCIReferences,
 		ReplaceOCIRefs:                 replaceOCIRefs,
+		LayerConcurrency:               o.LayerConcurrency,
+		TargetSchemaVersion:            o.TargetSchemaVersion,
 		MaxRetries:                     o.MaxRetries,
 		BackoffFactor:                  o.BackoffFactor,
 	}
@@ -149,6 +167,7 @@ func (o *CopyOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSyste
 	}
 
 	fmt.Printf("Successfully copied component descriptor %s:%s from %s to %s\n", o.ComponentName, o.ComponentVersion, o.SourceRepository, o.TargetRepository)
+	fmt.Print(c.Summary().String())
 	return nil
 }
 
@@ -182,6 +201,11 @@ func (o *CopyOptions) Validate() error {
 	if len(o.TargetRepository) == 0 {
 		return errors.New("a target repository has to be specified")
 	}
+	if len(o.TargetSchemaVersion) != 0 {
+		if err := schema.ValidateSchemaVersion(o.TargetSchemaVersion); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -198,11 +222,44 @@ func (o *CopyOptions) AddFlags(fs *pflag.FlagSet) {
 		"source repository where relative oci artifacts are copied from. This is only relevant if artifacts are copied by value and it will be defaulted to the source component repository")
 	fs.BoolVar(&o.ConvertToRelativeOCIReferences, "relative-urls", false, "converts all copied oci artifacts to relative urls")
 	fs.StringSliceVar(&o.ReplaceOCIRefs, "replace-oci-ref", []string{}, "list of replace expressions in the format left:right. For every resource with accessType == "+cdv2.OCIRegistryType+", all occurences of 'left' in the target ref are replaced with 'right' before the upload")
+	fs.IntVar(&o.LayerConcurrency, "layer-concurrency", 1, "maximum number of layers of an oci artifact that are copied in parallel. This is only relevant if artifacts are copied by value")
+	fs.StringVar(&o.TargetSchemaVersion, "target-schema-version", "", fmt.Sprintf("[OPTIONAL] schema version of the component descriptors in the target repository. One of %v. Defaults to the schema version of the source component descriptors", schema.SupportedSchemaVersions))
 	fs.Uint64Var(&o.MaxRetries, "max-retries", 0, "maximum number of retries for copying a component descriptor")
 	fs.DurationVar(&o.BackoffFactor, "backoff-factor", 1*time.Second, "a backoff factor to apply between retry attempts: backoff = backoff-factor * 2^retries. e.g. if backoff-factor is 1s, then the timeouts will be [1s, 2s, 4s, …]")
 	o.OciOptions.AddFlags(fs)
 }
 
+// ErrComponentReferenceCycle is returned by a recursive copy if the component references form a cycle.
+var ErrComponentReferenceCycle = errors.New("cyclic component references")
+
+// CopySummary summarizes the component descriptors and oci artifacts that have been copied.
+type CopySummary struct {
+	// Copied contains all component versions that have been copied in the format "name:version".
+	Copied []string
+	// Skipped contains all component versions that already existed in the target repository.
+	Skipped []string
+	// Artifacts contains all oci artifacts that have been copied by value in the format "source -> target".
+	Artifacts []string
+}
+
+// String returns a human readable report of the summary.
+func (s CopySummary) String() string {
+	var sb strings.Builder
+	write := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "%s (%d):\n", title, len(items))
+		for _, item := range items {
+			fmt.Fprintf(&sb, "  - %s\n", item)
+		}
+	}
+	write("Copied component descriptors", s.Copied)
+	write("Skipped existing component descriptors", s.Skipped)
+	write("Copied oci artifacts", s.Artifacts)
+	return sb.String()
+}
+
 // Copier copies a component descriptor from a target repo to another.
 type Copier struct {
 	SrcRepoCtx, TargetRepoCtx cdv2.Repository
@@ -230,17 +287,70 @@ type Copier struct {
 	ConvertToRelativeOCIReferences bool
 	// ReplaceOCIRefs contains replace expressions for manipulating upload refs of resources with accessType == ociRegistry
 	ReplaceOCIRefs map[string]string
+	// LayerConcurrency is the maximal number of layers of an oci artifact that are copied in parallel.
+	// Layers are copied sequentially if the value is 0 or 1.
+	LayerConcurrency int
+	// TargetSchemaVersion is the schema version of the copied component descriptors.
+	// The schema version of the source component descriptor is kept if the value is empty
+	// and the component resolver is able to report it, otherwise the v2 schema is used.
+	TargetSchemaVersion string
 
 	MaxRetries    uint64
 	BackoffFactor time.Duration
+
+	// visited contains all component versions that have already been handled by the copier.
+	visited map[string]bool
+	// path contains the component versions that are currently copied, starting with the root component.
+	path    []string
+	summary CopySummary
+}
+
+// Summary returns the summary of all copies of the copier.
+func (c *Copier) Summary() CopySummary {
+	return c.summary
 }
 
 func (c *Copier) copy(ctx context.Context, name, version string) error {
 	log := logr.FromContextOrDiscard(ctx).WithValues("component", name, "version", version)
+	key := fmt.Sprintf("%s:%s", name, version)
+	for _, p := range c.path {
+		if p == key {
+			return fmt.Errorf("%w: %s", ErrComponentReferenceCycle, strings.Join(append(c.path, key), " -> "))
+		}
+	}
+	if c.visited[key] {
+		log.V(3).Info("component descriptor has already been copied")
+		return nil
+	}
+	c.path = append(c.path, key)
+	defer func() {
+		c.path = c.path[:len(c.path)-1]
+	}()
+
 	log.Info("copy component descriptor")
-	cd, blobs, err := c.CompResolver.ResolveWithBlobResolver(ctx, c.SrcRepoCtx, name, version)
-	if err != nil {
-		return err
+	var (
+		cd            *cdv2.ComponentDescriptor
+		blobs         ctf.BlobResolver
+		schemaVersion = c.TargetSchemaVersion
+		err           error
+	)
+	if resolver, ok := c.CompResolver.(schema.ComponentResolver); ok {
+		var srcSchemaVersion string
+		cd, blobs, srcSchemaVersion, err = resolver.ResolveWithSchemaVersion(ctx, c.SrcRepoCtx, name, version)
+		if err != nil {
+			return err
+		}
+		if len(schemaVersion) == 0 {
+			schemaVersion = srcSchemaVersion
+		}
+		if schemaVersion != srcSchemaVersion {
+			log.Info(fmt.Sprintf("convert component descriptor from %s to %s", srcSchemaVersion, schemaVersion))
+		}
+	} else {
+		cd, blobs, err = c.CompResolver.ResolveWithBlobResolver(ctx, c.SrcRepoCtx, name, version)
+		if err != nil {
+			return err
+		}
 	}
 
 	if c.Recursive {
@@ -256,6 +366,7 @@ func (c *Copier) copy(ctx context.Context, name, version string) error {
 	if !c.Force && !c.CopyByValue {
 		if _, err := c.CompResolver.Resolve(ctx, c.TargetRepoCtx, name, version); err == nil {
 			log.V(3).Info("Component already exists. Nothing to copy.")
+			c.markVisited(key, &c.summary.Skipped)
 			return nil
 		}
 	}
@@ -265,6 +376,7 @@ func (c *Copier) copy(ctx context.Context, name, version string) error {
 	}
 
 	var layers []ocispecv1.Descriptor
+	copiedArtifacts := []string{}
 	blobToResource := map[string]*cdv2.Resource{}
 	// todo: parallelize upload with
 	// todo: track if something has been uploaded otherwise only upload the component descriptor if "c.Force == true"
@@ -313,9 +425,10 @@ func (c *Copier) copy(ctx context.Context, name, version string) error {
 			}
 
 			log.V(4).Info(fmt.Sprintf("copy oci artifact %s to %s", ociRegistryAcc.ImageReference, target))
-			if err := ociclient.Copy(ctx, c.OciClient, ociRegistryAcc.ImageReference, target); err != nil {
+			if err := ociclient.Copy(ctx, c.OciClient, ociRegistryAcc.ImageReference, target, ociclient.WithLayerConcurrency(c.LayerConcurrency)); err != nil {
 				return fmt.Errorf("unable to copy oci artifact %s from %s to %s: %w", res.Name, ociRegistryAcc.ImageReference, target, err)
 			}
+			copiedArtifacts = append(copiedArtifacts, fmt.Sprintf("%s -> %s", ociRegistryAcc.ImageReference, target))
 
 			if c.ConvertToRelativeOCIReferences {
 				uAcc, err := cdv2.NewUnstructured(cdv2.NewRelativeOciAccess(strings.TrimPrefix(strings.TrimPrefix(target, c.TargetArtifactRepository), "/")))
@@ -353,9 +466,10 @@ func (c *Copier) copy(ctx context.Context, name, version string) error {
 			}
 
 			log.V(4).Info(fmt.Sprintf("copy oci artifact %s to %s", src, target))
-			if err := ociclient.Copy(ctx, c.OciClient, src, target); err != nil {
+			if err := ociclient.Copy(ctx, c.OciClient, src, target, ociclient.WithLayerConcurrency(c.LayerConcurrency)); err != nil {
 				return fmt.Errorf("unable to copy oci artifact %s from %s to %s: %w", res.Name, src, target, err)
 			}
+			copiedArtifacts = append(copiedArtifacts, fmt.Sprintf("%s -> %s", src, target))
 
 			if !c.ConvertToRelativeOCIReferences {
 				uAcc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(target))
@@ -373,6 +487,9 @@ func (c *Copier) copy(ctx context.Context, name, version string) error {
 	if err != nil {
 		return fmt.Errorf("unable to build oci artifact for component acrchive: %w", err)
 	}
+	if err := schema.ReplaceComponentDescriptorLayer(c.Cache, manifest, cd, schemaVersion); err != nil {
+		return fmt.Errorf("unable to convert component descriptor to %s: %w", schemaVersion, err)
+	}
 	manifest.Layers = append(manifest.Layers, layers...)
 
 	ref, err := components.OCIRef(c.TargetRepoCtx, name, version)
@@ -411,9 +528,20 @@ func (c *Copier) copy(ctx context.Context, name, version string) error {
 		return err
 	}
 
+	c.summary.Artifacts = append(c.summary.Artifacts, copiedArtifacts...)
+	c.markVisited(key, &c.summary.Copied)
 	return nil
 }
 
+// markVisited marks the component version as handled and adds it to the given summary list.
+func (c *Copier) markVisited(key string, list *[]string) {
+	if c.visited == nil {
+		c.visited = map[string]bool{}
+	}
+	c.visited[key] = true
+	*list = append(*list, key)
+}
+
 func (c *Copier) Copy(ctx context.Context, name, version string) error {
 	log := logr.FromContextOrDiscard(ctx).WithValues("component", name, "version", version)
 
@@ -422,6 +550,9 @@ func (c *Copier) Copy(ctx context.Context, name, version string) error {
 		if err == nil {
 			break
 		}
+		if errors.Is(err, ErrComponentReferenceCycle) {
+			return err
+		}
 
 		if err != nil && retries == c.MaxRetries {
 			return fmt.Errorf("copy finished with error, max retries exceeded: %w", err)
@@ -22,7 +22,10 @@ import (
 
 	ociopts "github.com/gardener/component-cli/ociclient/options"
 	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
 	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/utils"
 )
 
 type ShowOptions struct {
@@ -35,6 +38,9 @@ type ShowOptions struct {
 
 	ComponentNameMapping string
 
+	// Stats defines whether the storage stats of the component closure should be shown instead of the component descriptor.
+	Stats bool
+
 	// OciOptions contains all exposed options to configure the oci client.
 	OciOptions ociopts.Options
 }
@@ -48,6 +54,10 @@ func NewGetCommand(ctx context.Context) *cobra.Command {
 		Short: "fetch the component descriptor from a oci registry",
 		Long: `
 get fetches the component descriptor from a baseurl with the given name and Version.
+
+If "--stats" is set, the sizes of the manifests and blobs of the component and all transitively referenced components
+are summed up per registry instead. Blobs that are shared by multiple artifacts of a registry are counted only once for the storage size
+but for every reference for the transfer size.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
@@ -85,13 +95,29 @@ func (o *ShowOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSyste
 		return fmt.Errorf("unable to build oci client: %s", err.Error())
 	}
 
-	cdresolver := cdoci.NewResolver(ociClient)
-	cd, err := cdresolver.Resolve(ctx, &repoCtx, o.ComponentName, o.Version)
+	cdresolver := schema.NewResolver(ociClient)
+	if o.Stats {
+		stats, err := components.ComputeClosureStats(ctx, ociClient, cdresolver, repoCtx, o.ComponentName, o.Version)
+		if err != nil {
+			return fmt.Errorf("unable to compute stats of %s: %w", ociRef, err)
+		}
+		out, err := yaml.Marshal(stats)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		fmt.Printf("Storage: %s\n", utils.BytesString(uint64(stats.StorageBytes), 2))
+		fmt.Printf("Transfer: %s\n", utils.BytesString(uint64(stats.TransferBytes), 2))
+		return nil
+	}
+
+	cd, _, schemaVersion, err := cdresolver.ResolveWithSchemaVersion(ctx, &repoCtx, o.ComponentName, o.Version)
 	if err != nil {
 		return fmt.Errorf("unable to to fetch component descriptor %s: %w", ociRef, err)
 	}
 
-	out, err := yaml.Marshal(cd)
+	// print the component descriptor in the schema version it has been uploaded with
+	out, err := schema.EncodeYAML(cd, schemaVersion)
 	if err != nil {
 		return err
 	}
@@ -129,5 +155,6 @@ func (o *ShowOptions) Complete(args []string) error {
 
 func (o *ShowOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.ComponentNameMapping, "component-name-mapping", string(cdv2.OCIRegistryURLPathMapping), "[OPTIONAL] repository context name mapping")
+	fs.BoolVar(&o.Stats, "stats", false, "show the storage and transfer sizes of the component and all referenced components per registry")
 	o.OciOptions.AddFlags(fs)
 }
@@ -20,7 +20,9 @@ import (
 	"github.com/gardener/component-cli/pkg/componentarchive"
 
 	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
 
+	"github.com/gardener/component-cli/ociclient"
 	ociopts "github.com/gardener/component-cli/ociclient/options"
 	"github.com/gardener/component-cli/pkg/logger"
 	"github.com/gardener/component-cli/pkg/utils"
@@ -30,6 +32,15 @@ import (
 type PushOptions struct {
 	// AdditionalTags defines additional tags that the oci artifact should be tagged with.
 	AdditionalTags []string
+	// SkipImmutableTags defines whether additional tags that are immutable in the target registry are skipped instead of failing the push.
+	SkipImmutableTags bool
+	// Limits defines the limits of the target registry that are validated before the upload.
+	Limits PushLimits
+	// Exists defines how an already existing component version is handled.
+	Exists ExistsOptions
+	// SchemaVersion is the schema version of the uploaded component descriptor.
+	// Defaults to the schema version of the component descriptor in the component archive.
+	SchemaVersion string
 
 	// OciOptions contains all exposed options to configure the oci client.
 	OciOptions ociopts.Options
@@ -54,6 +65,28 @@ push [path to component descriptor]
 
 push [baseurl] [componentname] [Version] [path to component descriptor]
 - The cli will add the baseurl as repository context and validate the name and Version.
+
+The limits of the target registry can be defined with "--max-layer-size", "--max-layer-count" and "--max-descriptor-size".
+The component archive is validated against the limits before anything is uploaded.
+
+By default an already existing component version is overwritten.
+With "--on-exists fail" (or "--fail-on-exists") the push fails if the component version already exists with a different content,
+which is recommended for release pipelines as released component versions are expected to be immutable.
+Pushing the identical content again succeeds so that a pipeline can be safely retried.
+With "--on-exists skip" an already existing component version is kept and no additional tags are set.
+The content is compared by the digest of the component descriptors, which includes the digests of all local blobs.
+
+Resources with the access type "externalFile" reference large files outside of the component archive.
+The file is referenced by its path, which is relative to the component archive directory, and its digest.
+It is only uploaded as local blob during the push so that the component archive itself stays small
+whereas the published component version is self-contained.
+The push fails if the file does not match the digest.
+
+Registries with tag immutability rules reject the update of an existing tag.
+With "--skip-immutable-tags" additional tags that cannot be updated are skipped instead of failing the push.
+
+The component descriptor is uploaded in the schema version of the component descriptor in the component archive.
+Use "--schema-version" to convert the component descriptor to another schema version (e.g. "ocm.software/v3alpha1") before the upload.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
@@ -79,10 +112,13 @@ func (o *PushOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSyste
 		return fmt.Errorf("unable to build oci client: %s", err.Error())
 	}
 
-	archive, err := o.BuilderOptions.Build(fs)
+	archive, archiveSchemaVersion, err := o.BuilderOptions.Build(fs)
 	if err != nil {
 		return fmt.Errorf("unable to build component archive: %w", err)
 	}
+	if err := componentarchive.AddExternalFileResolver(archive, fs, o.ComponentArchivePath); err != nil {
+		return err
+	}
 	// update repository context
 	if len(o.BaseUrl) != 0 {
 		if err := cdv2.InjectRepositoryContext(archive.ComponentDescriptor, cdv2.NewOCIRegistryRepository(o.BaseUrl, "")); err != nil {
@@ -90,19 +126,54 @@ func (o *PushOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSyste
 		}
 	}
 
+	schemaVersion := o.SchemaVersion
+	if len(schemaVersion) == 0 {
+		schemaVersion = archiveSchemaVersion
+	}
+
 	manifest, err := cdoci.NewManifestBuilder(cache, archive).Build(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to build oci artifact for component acrchive: %w", err)
 	}
+	if err := schema.ReplaceComponentDescriptorLayer(cache, manifest, archive.ComponentDescriptor, schemaVersion); err != nil {
+		return fmt.Errorf("unable to convert component descriptor to %s: %w", schemaVersion, err)
+	}
+	if err := o.Limits.Validate(archive.ComponentDescriptor, manifest); err != nil {
+		return err
+	}
 
 	ref, err := components.OCIRef(archive.ComponentDescriptor.GetEffectiveRepositoryContext(), archive.ComponentDescriptor.Name, archive.ComponentDescriptor.Version)
 	if err != nil {
 		return fmt.Errorf("invalid component reference: %w", err)
 	}
-	if err := ociClient.PushManifest(ctx, ref, manifest); err != nil {
+
+	existing, err := getExistingComponent(ctx, ociClient, archive.ComponentDescriptor)
+	if err != nil {
 		return err
 	}
-	log.Info(fmt.Sprintf("Successfully uploaded component descriptor at %q", ref))
+	switch {
+	case existing == nil:
+		if err := ociClient.PushManifest(ctx, ref, manifest); err != nil {
+			return err
+		}
+		log.Info(fmt.Sprintf("Successfully uploaded component descriptor at %q", ref))
+	case existing.Identical && o.Exists.OnExists != OnExistsOverwrite:
+		// the tags are still updated so that a failed push can be retried.
+		log.Info(fmt.Sprintf("Component descriptor %q already exists with identical content", ref))
+	case o.Exists.OnExists == OnExistsFail:
+		return fmt.Errorf("component descriptor %q already exists with a different content (existing digest %s, pushed digest %s): use --on-exists=overwrite to replace it", ref, existing.Digest, existing.ExpectedDigest)
+	case o.Exists.OnExists == OnExistsSkip:
+		log.Info(fmt.Sprintf("Skip upload of component descriptor %q which already exists with a different content", ref), "existingDigest", existing.Digest, "pushedDigest", existing.ExpectedDigest)
+		return nil
+	default:
+		if !existing.Identical {
+			log.Info(fmt.Sprintf("Overwrite component descriptor %q which already exists with a different content", ref), "existingDigest", existing.Digest, "pushedDigest", existing.ExpectedDigest)
+		}
+		if err := ociClient.PushManifest(ctx, ref, manifest); err != nil {
+			return err
+		}
+		log.Info(fmt.Sprintf("Successfully uploaded component descriptor at %q", ref))
+	}
 
 	for _, tag := range o.AdditionalTags {
 		ref, err := components.OCIRef(archive.ComponentDescriptor.GetEffectiveRepositoryContext(), archive.ComponentDescriptor.Name, tag)
@@ -110,6 +181,10 @@ func (o *PushOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSyste
 			return fmt.Errorf("invalid component reference: %w", err)
 		}
 		if err := ociClient.PushManifest(ctx, ref, manifest); err != nil {
+			if o.SkipImmutableTags && ociclient.IsTagImmutableError(err) {
+				log.Info(fmt.Sprintf("Skip tag %q which is immutable in the target registry", ref))
+				continue
+			}
 			return err
 		}
 		log.Info(fmt.Sprintf("Successfully tagged component descriptor %q", ref))
@@ -134,6 +209,13 @@ func (o *PushOptions) Complete(args []string) error {
 		return fmt.Errorf("unable to get oci cache directory: %w", err)
 	}
 
+	if err := o.Limits.Complete(); err != nil {
+		return err
+	}
+	if err := o.Exists.Complete(); err != nil {
+		return err
+	}
+
 	if err := o.Validate(); err != nil {
 		return err
 	}
@@ -143,11 +225,20 @@ func (o *PushOptions) Complete(args []string) error {
 // Validate validates push options
 func (o *PushOptions) Validate() error {
 	// todo: validate references exist
+	if len(o.SchemaVersion) != 0 {
+		if err := schema.ValidateSchemaVersion(o.SchemaVersion); err != nil {
+			return err
+		}
+	}
 	return o.BuilderOptions.Validate()
 }
 
 func (o *PushOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringArrayVarP(&o.AdditionalTags, "tag", "t", []string{}, "set additional tags on the oci artifact")
+	fs.StringVar(&o.SchemaVersion, "schema-version", "", fmt.Sprintf("[OPTIONAL] schema version of the uploaded component descriptor. One of %v. Defaults to the schema version of the component archive", schema.SupportedSchemaVersions))
+	fs.BoolVar(&o.SkipImmutableTags, "skip-immutable-tags", false, "skip additional tags that are immutable in the target registry (e.g. because of ECR or Harbor tag immutability rules) instead of failing")
+	o.Limits.AddFlags(fs)
+	o.Exists.AddFlags(fs)
 	o.OciOptions.AddFlags(fs)
 	o.BuilderOptions.AddFlags(fs)
 }
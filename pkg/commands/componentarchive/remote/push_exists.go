@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/containerd/containerd/errdefs"
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/opencontainers/go-digest"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
+)
+
+const (
+	// OnExistsFail fails the push if the component version already exists with a different content.
+	OnExistsFail = "fail"
+	// OnExistsSkip skips the push if the component version already exists.
+	OnExistsSkip = "skip"
+	// OnExistsOverwrite overwrites an already existing component version.
+	OnExistsOverwrite = "overwrite"
+)
+
+// ExistsOptions defines how a push handles a component version that already exists in the target repository.
+// Component versions are expected to be immutable, so that release pipelines should never overwrite them.
+type ExistsOptions struct {
+	// OnExists defines the behavior if the component version already exists.
+	// One of fail, skip or overwrite.
+	OnExists string
+	// FailOnExists is a shorthand for OnExists=fail.
+	FailOnExists bool
+	// Force is a shorthand for OnExists=overwrite.
+	Force bool
+}
+
+func (o *ExistsOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.OnExists, "on-exists", OnExistsOverwrite, "behavior if the component version already exists in the target repository. One of fail, skip or overwrite")
+	fs.BoolVar(&o.FailOnExists, "fail-on-exists", false, "fail if the component version already exists with a different content. Shorthand for --on-exists=fail")
+	fs.BoolVar(&o.Force, "force", false, "overwrite the component version if it already exists. Shorthand for --on-exists=overwrite")
+}
+
+// Complete validates the options and applies the shorthands.
+func (o *ExistsOptions) Complete() error {
+	if o.FailOnExists && o.Force {
+		return errors.New("only one of --fail-on-exists and --force can be set")
+	}
+	if o.FailOnExists {
+		o.OnExists = OnExistsFail
+	}
+	if o.Force {
+		o.OnExists = OnExistsOverwrite
+	}
+	if len(o.OnExists) == 0 {
+		o.OnExists = OnExistsOverwrite
+	}
+	switch o.OnExists {
+	case OnExistsFail, OnExistsSkip, OnExistsOverwrite:
+		return nil
+	default:
+		return fmt.Errorf("unsupported on-exists behavior %q: must be fail, skip or overwrite", o.OnExists)
+	}
+}
+
+// existingComponent describes the component version that already exists in the target repository.
+type existingComponent struct {
+	// Identical is true if the existing component descriptor matches the component descriptor that is pushed.
+	Identical bool
+	// Digest is the digest of the existing component descriptor.
+	Digest digest.Digest
+	// ExpectedDigest is the digest of the component descriptor that is pushed.
+	ExpectedDigest digest.Digest
+}
+
+// getExistingComponent resolves the component version in the target repository and compares it with the given component descriptor.
+// Nil is returned if the component version does not exist.
+// The component descriptors are compared instead of the oci manifests as the component descriptor layer
+// is not reproducible. Local blobs are covered by the comparison as they are referenced by their digest.
+func getExistingComponent(ctx context.Context, client ociclient.Client, cd *cdv2.ComponentDescriptor) (*existingComponent, error) {
+	ref, err := components.OCIRef(cd.GetEffectiveRepositoryContext(), cd.Name, cd.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid component reference: %w", err)
+	}
+	if _, _, err := client.Resolve(ctx, ref); err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to check whether %q already exists: %w", ref, err)
+	}
+
+	repoCtx := cd.GetEffectiveRepositoryContext()
+	existingCd, err := schema.NewResolver(client).Resolve(ctx, repoCtx, cd.Name, cd.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve existing component descriptor %q: %w", ref, err)
+	}
+
+	expected, err := componentDescriptorDigest(cd)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := componentDescriptorDigest(existingCd)
+	if err != nil {
+		return nil, err
+	}
+	return &existingComponent{
+		Identical:      expected == actual,
+		Digest:         actual,
+		ExpectedDigest: expected,
+	}, nil
+}
+
+// componentDescriptorDigest returns the digest of the json encoded component descriptor.
+func componentDescriptorDigest(cd *cdv2.ComponentDescriptor) (digest.Digest, error) {
+	data, err := json.Marshal(cd)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	return digest.FromBytes(data), nil
+}
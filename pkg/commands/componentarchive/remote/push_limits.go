@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// PushLimits defines limits of the target registry that are validated before a component archive is uploaded.
+// Registries reject artifacts that exceed their limits often only in the middle of an upload
+// with unspecific errors, therefore the limits are checked upfront.
+type PushLimits struct {
+	// MaxLayerSize is the max size of a single layer (e.g. 5Gi).
+	MaxLayerSize string
+	// MaxLayerCount is the max number of layers of the oci manifest.
+	MaxLayerCount int
+	// MaxDescriptorSize is the max size of the oci manifest of the component descriptor (e.g. 4Mi).
+	MaxDescriptorSize string
+
+	maxLayerSizeBytes      int64
+	maxDescriptorSizeBytes int64
+}
+
+func (l *PushLimits) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&l.MaxLayerSize, "max-layer-size", "", "max size of a single layer that is accepted by the target registry (e.g. 5Gi)")
+	fs.IntVar(&l.MaxLayerCount, "max-layer-count", 0, "max number of layers of an oci manifest that is accepted by the target registry")
+	fs.StringVar(&l.MaxDescriptorSize, "max-descriptor-size", "", "max size of the oci manifest of the component descriptor that is accepted by the target registry (e.g. 4Mi)")
+}
+
+// Complete parses and validates the limits.
+func (l *PushLimits) Complete() error {
+	var err error
+	l.maxLayerSizeBytes, err = parseLimit("max layer size", l.MaxLayerSize)
+	if err != nil {
+		return err
+	}
+	l.maxDescriptorSizeBytes, err = parseLimit("max descriptor size", l.MaxDescriptorSize)
+	if err != nil {
+		return err
+	}
+	if l.MaxLayerCount < 0 {
+		return errors.New("the max layer count must not be negative")
+	}
+	return nil
+}
+
+func parseLimit(name, value string) (int64, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse %s %q: %w", name, value, err)
+	}
+	if quantity.Value() <= 0 {
+		return 0, fmt.Errorf("the %s %q must be greater than 0", name, value)
+	}
+	return quantity.Value(), nil
+}
+
+// Validate checks that the oci manifest of the component descriptor does not exceed the limits.
+// All violations are returned in one error so that they can be fixed at once.
+func (l *PushLimits) Validate(cd *cdv2.ComponentDescriptor, manifest *ocispecv1.Manifest) error {
+	violations := []string{}
+	if l.maxDescriptorSizeBytes != 0 {
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			return fmt.Errorf("unable to marshal manifest: %w", err)
+		}
+		if size := int64(len(data)); size > l.maxDescriptorSizeBytes {
+			violations = append(violations, fmt.Sprintf("the oci manifest has a size of %s but the max descriptor size is %s: reduce the number of local blobs or their annotations",
+				utils.BytesString(uint64(size), 2), utils.BytesString(uint64(l.maxDescriptorSizeBytes), 2)))
+		}
+	}
+	if l.MaxLayerCount != 0 && len(manifest.Layers) > l.MaxLayerCount {
+		violations = append(violations, fmt.Sprintf("the oci manifest has %d layers but the max layer count is %d: upload local blobs as separate oci artifacts and reference them with an ociRegistry access",
+			len(manifest.Layers), l.MaxLayerCount))
+	}
+	if l.maxLayerSizeBytes != 0 {
+		for i, layer := range manifest.Layers {
+			if layer.Size <= l.maxLayerSizeBytes {
+				continue
+			}
+			violations = append(violations, fmt.Sprintf("%s has a size of %s but the max layer size is %s: compress the blob or upload it as separate oci artifact",
+				describeLayer(cd, i, layer), utils.BytesString(uint64(layer.Size), 2), utils.BytesString(uint64(l.maxLayerSizeBytes), 2)))
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("the component descriptor exceeds the limits of the target registry:\n- %s", strings.Join(violations, "\n- "))
+}
+
+// describeLayer returns a human readable description of a layer.
+// Layers of local blobs are described by the resources that reference them.
+func describeLayer(cd *cdv2.ComponentDescriptor, index int, layer ocispecv1.Descriptor) string {
+	resources := []string{}
+	for _, res := range cd.Resources {
+		if res.Access == nil {
+			continue
+		}
+		if dgst, ok := res.Access.Object["digest"]; ok && dgst == layer.Digest.String() {
+			resources = append(resources, fmt.Sprintf("%q", res.Name))
+		}
+	}
+	if len(resources) != 0 {
+		return fmt.Sprintf("layer %d (%s) of resource %s", index, layer.Digest, strings.Join(resources, ", "))
+	}
+	return fmt.Sprintf("layer %d (%s, %s)", index, layer.Digest, layer.MediaType)
+}
@@ -7,6 +7,7 @@ package remote_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"os"
 	"path"
 
@@ -33,6 +34,45 @@ import (
 	"github.com/gardener/component-cli/pkg/commands/componentarchive/remote"
 )
 
+// staticComponentResolver resolves component descriptors of the source repository from a static list.
+type staticComponentResolver struct {
+	srcRepoCtx cdv2.Repository
+	cds        []*cdv2.ComponentDescriptor
+}
+
+func (r *staticComponentResolver) Resolve(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	cd, _, err := r.ResolveWithBlobResolver(ctx, repoCtx, name, version)
+	return cd, err
+}
+
+func (r *staticComponentResolver) ResolveWithBlobResolver(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	if repoCtx != r.srcRepoCtx {
+		return nil, nil, ctf.NotFoundError
+	}
+	for _, cd := range r.cds {
+		if cd.Name == name && cd.Version == version {
+			return cd.DeepCopy(), nil, nil
+		}
+	}
+	return nil, nil, ctf.NotFoundError
+}
+
+func newComponentDescriptor(name string, refs ...string) *cdv2.ComponentDescriptor {
+	cd := &cdv2.ComponentDescriptor{}
+	cd.Metadata.Version = cdv2.SchemaVersion
+	cd.Name = name
+	cd.Version = "v0.0.0"
+	cd.Provider = "internal"
+	for _, ref := range refs {
+		cd.ComponentReferences = append(cd.ComponentReferences, cdv2.ComponentReference{
+			Name:          path.Base(ref),
+			ComponentName: ref,
+			Version:       "v0.0.0",
+		})
+	}
+	return cd
+}
+
 var _ = Describe("Remote", func() {
 
 	var (
@@ -85,6 +125,66 @@ var _ = Describe("Remote", func() {
 			"Expect that the first layer contains the component descriptor")
 	})
 
+	It("should fail to push a component archive that exceeds the limits of the target registry", func() {
+		pushOpts := &remote.PushOptions{
+			Limits: remote.PushLimits{
+				MaxLayerSize:      "1",
+				MaxDescriptorSize: "10",
+			},
+		}
+		pushOpts.ComponentArchivePath = "./testdata/00-ca"
+		pushOpts.BaseUrl = testenv.Addr + "/test"
+		Expect(pushOpts.Limits.Complete()).To(Succeed())
+
+		err := pushOpts.Run(context.Background(), logr.Discard(), testdataFs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("max layer size"))
+		Expect(err.Error()).To(ContainSubstring("max descriptor size"))
+	})
+
+	It("should not overwrite an existing component version with a different content", func() {
+		ctx := context.Background()
+		cf, err := testenv.GetConfigFileBytes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testdataFs, "/auth.json", cf, os.ModePerm))
+
+		data, err := vfs.ReadFile(testdataFs, "./testdata/00-ca/component-descriptor.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(testdataFs.MkdirAll("/changed-ca", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "/changed-ca/component-descriptor.yaml", bytes.Replace(data, []byte("'internal'"), []byte("'external'"), 1), os.ModePerm)).To(Succeed())
+
+		push := func(path, onExists string) error {
+			pushOpts := &remote.PushOptions{
+				OciOptions: options.Options{
+					RegistryConfigPath: "/auth.json",
+				},
+			}
+			pushOpts.ComponentArchivePath = path
+			pushOpts.BaseUrl = targetRepoCtxURL
+			pushOpts.Exists.OnExists = onExists
+			Expect(pushOpts.Exists.Complete()).To(Succeed())
+			return pushOpts.Run(ctx, logr.Discard(), testdataFs)
+		}
+		getProvider := func() cdv2.ProviderType {
+			cd, err := cdoci.NewResolver(client).Resolve(ctx, cdv2.NewOCIRegistryRepository(targetRepoCtxURL, ""), "example.com/component", "v0.0.0")
+			Expect(err).ToNot(HaveOccurred())
+			return cd.Provider
+		}
+
+		Expect(push("./testdata/00-ca", remote.OnExistsFail)).To(Succeed())
+		Expect(push("./testdata/00-ca", remote.OnExistsFail)).To(Succeed(), "pushing the identical content again should succeed")
+
+		err = push("/changed-ca", remote.OnExistsFail)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("already exists with a different content"))
+
+		Expect(push("/changed-ca", remote.OnExistsSkip)).To(Succeed())
+		Expect(getProvider()).To(Equal(cdv2.ProviderType("internal")))
+
+		Expect(push("/changed-ca", remote.OnExistsOverwrite)).To(Succeed())
+		Expect(getProvider()).To(Equal(cdv2.ProviderType("external")))
+	})
+
 	It("should get component archive", func() {
 		baseFs, err := projectionfs.New(osfs.New(), "../")
 		Expect(err).ToNot(HaveOccurred())
@@ -540,5 +640,67 @@ var _ = Describe("Remote", func() {
 			Expect(acc.Reference).To(HaveSuffix(ociImageTargetRelRef))
 		})
 
+		It("should copy every referenced component version only once and report a summary", func() {
+			ctx := context.Background()
+			ociCache, err := cache.NewCache(logr.Discard())
+			Expect(err).ToNot(HaveOccurred())
+
+			srcRepoCtx := cdv2.NewOCIRegistryRepository(srcRepoCtxURL, "")
+			c := remote.Copier{
+				SrcRepoCtx:    srcRepoCtx,
+				TargetRepoCtx: cdv2.NewOCIRegistryRepository(targetRepoCtxURL, ""),
+				CompResolver: &staticComponentResolver{
+					srcRepoCtx: srcRepoCtx,
+					cds: []*cdv2.ComponentDescriptor{
+						newComponentDescriptor("example.com/root", "example.com/b", "example.com/c"),
+						newComponentDescriptor("example.com/b", "example.com/d"),
+						newComponentDescriptor("example.com/c", "example.com/d"),
+						newComponentDescriptor("example.com/d"),
+					},
+				},
+				OciClient: client,
+				Cache:     ociCache,
+				Recursive: true,
+			}
+			Expect(c.Copy(ctx, "example.com/root", "v0.0.0")).To(Succeed())
+
+			Expect(c.Summary().Copied).To(Equal([]string{
+				"example.com/d:v0.0.0",
+				"example.com/b:v0.0.0",
+				"example.com/c:v0.0.0",
+				"example.com/root:v0.0.0",
+			}))
+			repos, err := client.ListRepositories(ctx, targetRepoCtxURL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(repos).To(ConsistOf(
+				targetRepoCtxURL+"/component-descriptors/example.com/root",
+				targetRepoCtxURL+"/component-descriptors/example.com/b",
+				targetRepoCtxURL+"/component-descriptors/example.com/c",
+				targetRepoCtxURL+"/component-descriptors/example.com/d",
+			))
+		})
+
+		It("should fail if the component references form a cycle", func() {
+			srcRepoCtx := cdv2.NewOCIRegistryRepository(srcRepoCtxURL, "")
+			c := remote.Copier{
+				SrcRepoCtx:    srcRepoCtx,
+				TargetRepoCtx: cdv2.NewOCIRegistryRepository(targetRepoCtxURL, ""),
+				CompResolver: &staticComponentResolver{
+					srcRepoCtx: srcRepoCtx,
+					cds: []*cdv2.ComponentDescriptor{
+						newComponentDescriptor("example.com/a", "example.com/b"),
+						newComponentDescriptor("example.com/b", "example.com/a"),
+					},
+				},
+				OciClient:  client,
+				Recursive:  true,
+				MaxRetries: 3,
+			}
+			err := c.Copy(context.Background(), "example.com/a", "v0.0.0")
+			Expect(errors.Is(err, remote.ErrComponentReferenceCycle)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("example.com/a:v0.0.0 -> example.com/b:v0.0.0 -> example.com/a:v0.0.0"))
+			Expect(c.Summary().Copied).To(BeEmpty())
+		})
+
 	})
 })
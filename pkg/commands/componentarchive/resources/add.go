@@ -12,6 +12,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/gardener/component-spec/bindings-go/apis/v2/cdutils"
@@ -24,10 +25,13 @@ import (
 	"github.com/spf13/pflag"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
-	"sigs.k8s.io/yaml"
 
-	"github.com/gardener/component-cli/pkg/commands/componentarchive/input"
+	"github.com/gardener/component-cli/ociclient"
+	ociopts "github.com/gardener/component-cli/ociclient/options"
 	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/componentarchive/input"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
 	"github.com/gardener/component-cli/pkg/logger"
 	"github.com/gardener/component-cli/pkg/template"
 	"github.com/gardener/component-cli/pkg/utils"
@@ -45,6 +49,21 @@ type Options struct {
 	// ResourceObjectPaths contains paths to read the yaml resource template from.
 	// If "-" is provided, the resource is read from stdin
 	ResourceObjectPaths []string
+	// SkipJSONSchemaValidation disables the validation of jsonschema resources
+	// and resources that reference a jsonschema.
+	SkipJSONSchemaValidation bool
+	// AllowDuplicates allows multiple resource definitions with the same identity.
+	// The last definition wins.
+	AllowDuplicates bool
+
+	// ResolveDigests defines whether the image references of resources with an ociRegistry access
+	// should be resolved and pinned to their digests.
+	ResolveDigests bool
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+	// ImageResolver is used to resolve the digests of image references.
+	// Defaults to the configured oci client.
+	ImageResolver ociclient.Resolver
 }
 
 // ResourceOptions contains options that are used to describe a resource
@@ -63,6 +82,8 @@ type ResourceOptionList struct {
 type InternalResourceOptions struct {
 	ResourceOptions
 	Path string
+	// Source describes where the resource is defined (file and document index).
+	Source string
 }
 
 // NewAddCommand creates a command to add additional resources to a component descriptor.
@@ -111,13 +132,52 @@ input:
   type: "dir"
   path: /my/path
   compress: true # defaults to false
-  includeFiles: # optional; list of shell file patterns
+  includeFiles: # optional; list of shell file patterns, patterns without "/" also match files in subdirectories
   - "*.txt"
-  excludeFiles: # optional; list of shell file patterns
+  excludeFiles: # optional; list of shell file patterns, excluded directories are excluded with all their content
   - "*.txt"
   mediaType: "application/gzip" # optional, defaulted to "application/x-tar" or "application/gzip" if compress=true 
   preserveDir: true # optional, defaulted to false; if true, the top level folder "my/path" is included
   followSymlinks: true # optional, defaulted to false; if true, symlinks are resolved and the content is included in the tar
+  preservePermissions: false # optional, defaulted to true; if false, files are added with default modes and without owner
+...
+---
+name: 'myimage'
+type: 'ociImage'
+relation: 'local'
+input:
+  type: "docker"
+  image: "myimage:latest" # image of the local docker daemon, exported with "docker save"
+  compress: true # defaults to false
+  mediaType: "application/vnd.oci.image.layout.v1+tar+gzip" # optional, defaulted to "application/vnd.oci.image.layout.v1+tar" or "application/vnd.oci.image.layout.v1+tar+gzip" if compress=true
+...
+---
+name: 'myimage'
+type: 'ociImage'
+relation: 'local'
+input:
+  type: "docker-archive"
+  path: "image.tar" # tarball created with "docker save" or containing an oci image layout
+...
+---
+name: 'mychart'
+type: 'helm'
+relation: 'local'
+input:
+  type: "helm"
+  path: "charts/mychart" # chart directory or packaged chart (.tgz)
+  excludeFiles: # optional; list of shell file patterns, only relevant for chart directories
+  - "*.md"
+...
+---
+name: 'mydataset'
+type: 'blob'
+relation: 'local'
+access:
+  type: "externalFile" # the file is not added to the component archive but uploaded as local blob by "remote push"
+  path: "../data/dataset.tar.gz" # absolute or relative to the component archive directory
+  digest: "sha256:..." # digest of the file, the push fails if the file does not match
+  mediaType: "application/gzip" # optional, defaulted to the resource type
 ...
 
 </pre>
@@ -146,8 +206,24 @@ resources:
 
 </pre>
 
+Input blobs of type "docker" and "docker-archive" are converted to a tarred oci image layout that contains exactly one image.
+Input blobs of type "helm" are packaged like "helm package" does and converted to a tarred oci image layout
+that contains the chart as helm chart oci artifact (chart layer with the media type "%s").
+The files of a chart directory that match the rules of its .helmignore are not packaged.
+The provenance file of a packaged chart ("<chart>.tgz.prov") is added to the artifact.
+
+Resources of type "jsonschema" with a uncompressed file input are validated to contain a valid json schema (json or yaml).
+Local resources with the label "%s" are validated against the jsonschema resource
+whose name is given as label value. The referenced jsonschema resource has to be added before the resource.
+
+With "--resolve-digests" the image references of all resources with an "ociRegistry" access are resolved in the oci registry
+and the tag is replaced by the digest of the image (e.g. "eu.gcr.io/gardener-project/component-cli@sha256:...").
+The component descriptor is then immutable even if the tag is moved to another image afterwards.
+The repository of a pinned image reference is normalized (e.g. "ubuntu" becomes "index.docker.io/library/ubuntu").
+Image references that already contain a digest are not modified.
+
 %s
-`, opts.TemplateOptions.Usage()),
+`, input.MediaTypeHelmChartContent, JSONSchemaLabelName, opts.TemplateOptions.Usage()),
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
 				fmt.Println(err.Error())
@@ -169,7 +245,7 @@ resources:
 func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
 	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
 
-	archive, err := o.BuilderOptions.Build(fs)
+	archive, schemaVersion, err := o.BuilderOptions.Build(fs)
 	if err != nil {
 		return err
 	}
@@ -178,6 +254,16 @@ func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) e
 	if err != nil {
 		return err
 	}
+	if err := checkDuplicateIdentities(log, resources, o.AllowDuplicates); err != nil {
+		return err
+	}
+	if o.ResolveDigests && o.ImageResolver == nil {
+		ociClient, _, err := o.OciOptions.Build(log, fs)
+		if err != nil {
+			return fmt.Errorf("unable to build oci client: %s", err.Error())
+		}
+		o.ImageResolver = ociClient
+	}
 
 	log.V(3).Info(fmt.Sprintf("Adding %d resources...", len(resources)))
 	for _, resource := range resources {
@@ -185,11 +271,25 @@ func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) e
 		utils.PrintPrettyYaml(resource, log.V(5).Enabled())
 
 		if resource.Input != nil {
-			log.Info(fmt.Sprintf("add input blob from %q", resource.Input.Path))
+			log.Info(fmt.Sprintf("add input blob from %q", resource.Input.Location()))
 			if err := o.addInputBlob(ctx, fs, archive, &resource); err != nil {
 				return err
 			}
+			if !o.SkipJSONSchemaValidation {
+				if err := validateJSONSchema(ctx, archive, resource.Resource, resource.Input); err != nil {
+					return err
+				}
+			}
 		} else {
+			if o.ResolveDigests {
+				pinned, err := components.ResolveDigest(ctx, o.ImageResolver, &resource.Resource)
+				if err != nil {
+					return err
+				}
+				if pinned {
+					log.V(3).Info("pinned image reference to its digest")
+				}
+			}
 			id := archive.ComponentDescriptor.GetResourceIndex(resource.Resource)
 			if id != -1 {
 				log.V(5).Info("Found existing resource in component descriptor, attempt merge...")
@@ -210,7 +310,7 @@ func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) e
 			return fmt.Errorf("invalid component descriptor: %w", err)
 		}
 
-		data, err := yaml.Marshal(archive.ComponentDescriptor)
+		data, err := schema.EncodeYAML(archive.ComponentDescriptor, schemaVersion)
 		if err != nil {
 			return fmt.Errorf("unable to encode component descriptor: %w", err)
 		}
@@ -232,6 +332,14 @@ func (o *Options) Complete(args []string) error {
 	o.BuilderOptions.ComponentArchivePath = args[0]
 	o.BuilderOptions.Default()
 
+	if o.ResolveDigests {
+		var err error
+		o.OciOptions.CacheDir, err = utils.CacheDir()
+		if err != nil {
+			return fmt.Errorf("unable to get oci cache directory: %w", err)
+		}
+	}
+
 	o.ResourceObjectPaths = append(o.ResourceObjectPaths, args[1:]...)
 	if len(o.ResourceObjectPath) != 0 {
 		o.ResourceObjectPaths = append(o.ResourceObjectPaths, o.ResourceObjectPath)
@@ -249,6 +357,34 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	// specify the resource
 	fs.StringVarP(&o.ResourceObjectPath, "resource", "r", "", "The path to the resources defined as yaml or json")
 	_ = fs.MarkDeprecated("resource", "the flag r is deprecated use command args instead")
+	fs.BoolVar(&o.AllowDuplicates, "allow-duplicates", false, "allow multiple resource definitions with the same identity. The last definition wins")
+	fs.BoolVar(&o.SkipJSONSchemaValidation, "skip-jsonschema-validation", false, "skip the validation of jsonschema resources and resources that reference a jsonschema resource with the \""+JSONSchemaLabelName+"\" label")
+	fs.BoolVar(&o.ResolveDigests, "resolve-digests", false, "resolve the image references of resources with an ociRegistry access and pin them to their digests")
+	o.TemplateOptions.AddProvenanceFlags(fs)
+	o.OciOptions.AddFlags(fs)
+}
+
+// InputPaths returns the paths of the input blobs of all resources that are defined in the resource templates.
+// Resources without input and docker inputs are ignored.
+func (o *Options) InputPaths(log logr.Logger, fs vfs.FileSystem, cd *cdv2.ComponentDescriptor) ([]string, error) {
+	resources, err := o.generateResources(log, fs, cd)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0)
+	for _, resource := range resources {
+		if resource.Input == nil {
+			continue
+		}
+		inputPath, err := resource.Input.ResolvePath(resource.Path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve input path of resource %s: %w", resource.Name, err)
+		}
+		if len(inputPath) != 0 {
+			paths = append(paths, inputPath)
+		}
+	}
+	return paths, nil
 }
 
 func (o *Options) generateResources(log logr.Logger, fs vfs.FileSystem, cd *cdv2.ComponentDescriptor) ([]InternalResourceOptions, error) {
@@ -261,11 +397,11 @@ func (o *Options) generateResources(log logr.Logger, fs vfs.FileSystem, cd *cdv2
 			return nil, nil
 		}
 		if (stdinInfo.Mode()&os.ModeNamedPipe != 0) || stdinInfo.Size() != 0 {
-			stdinResources, err := o.generateResourcesFromReader(log, cd, os.Stdin)
+			stdinResources, locations, err := o.generateResourcesFromReader(log, cd, os.Stdin)
 			if err != nil {
 				return nil, fmt.Errorf("unable to read from stdin: %w", err)
 			}
-			resources = append(resources, convertToInternalResourceOptions(stdinResources, "")...)
+			resources = append(resources, convertToInternalResourceOptions(stdinResources, locations, "")...)
 		}
 		return resources, nil
 	}
@@ -278,11 +414,11 @@ func (o *Options) generateResources(log logr.Logger, fs vfs.FileSystem, cd *cdv2
 				return nil, fmt.Errorf("unable to read from stdin: %w", err)
 			}
 			if (stdinInfo.Mode()&os.ModeNamedPipe != 0) || stdinInfo.Size() != 0 {
-				stdinResources, err := o.generateResourcesFromReader(log, cd, os.Stdin)
+				stdinResources, locations, err := o.generateResourcesFromReader(log, cd, os.Stdin)
 				if err != nil {
 					return nil, fmt.Errorf("unable to read from stdin: %w", err)
 				}
-				resources = append(resources, convertToInternalResourceOptions(stdinResources, "")...)
+				resources = append(resources, convertToInternalResourceOptions(stdinResources, locations, "")...)
 			}
 			continue
 		}
@@ -291,7 +427,7 @@ func (o *Options) generateResources(log logr.Logger, fs vfs.FileSystem, cd *cdv2
 		if err != nil {
 			return nil, fmt.Errorf("unable to read resource object from %s: %w", resourcePath, err)
 		}
-		newResources, err := o.generateResourcesFromReader(log, cd, resourceObjectReader)
+		newResources, locations, err := o.generateResourcesFromReader(log, cd, resourceObjectReader)
 		if err != nil {
 			if err2 := resourceObjectReader.Close(); err2 != nil {
 				log.Error(err, "unable to close file reader", "path", resourcePath)
@@ -301,31 +437,49 @@ func (o *Options) generateResources(log logr.Logger, fs vfs.FileSystem, cd *cdv2
 		if err := resourceObjectReader.Close(); err != nil {
 			return nil, fmt.Errorf("unable to read resource from %q: %w", resourcePath, err)
 		}
-		resources = append(resources, convertToInternalResourceOptions(newResources, resourcePath)...)
+		resources = append(resources, convertToInternalResourceOptions(newResources, locations, resourcePath)...)
 	}
 
 	return resources, nil
 }
 
 // generateResourcesFromPath generates a resource given resource options and a resource template file.
-func (o *Options) generateResourcesFromReader(log logr.Logger, cd *cdv2.ComponentDescriptor, reader io.Reader) ([]ResourceOptions, error) {
+// The location of every resource in the templated data is returned as well.
+func (o *Options) generateResourcesFromReader(log logr.Logger, cd *cdv2.ComponentDescriptor, reader io.Reader) ([]ResourceOptions, []string, error) {
 	var data bytes.Buffer
 	if _, err := io.Copy(&data, reader); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// template data
 	tmplData, err := o.TemplateOptions.Template(data.String())
 	if err != nil {
-		return nil, fmt.Errorf("unable to template resource: %w", err)
+		return nil, nil, fmt.Errorf("unable to template resource: %w", err)
 	}
 	log.V(5).Info(tmplData)
-	return generateResourcesFromReader(cd, bytes.NewBuffer([]byte(tmplData)))
+	resources, locations, err := generateResourcesFromReader(cd, bytes.NewBuffer([]byte(tmplData)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provenanceLabel, err := o.TemplateOptions.ProvenanceLabel(data.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create template provenance: %w", err)
+	}
+	if provenanceLabel != nil {
+		for i := range resources {
+			resources[i].Labels = cdutils.SetRawLabel(resources[i].Labels, provenanceLabel.Name, provenanceLabel.Value)
+		}
+	}
+	return resources, locations, nil
 }
 
 // generateResourcesFromPath generates a resource given resource options and a resource template file.
-func generateResourcesFromReader(cd *cdv2.ComponentDescriptor, reader io.Reader) ([]ResourceOptions, error) {
+// The location of every resource is returned as "document <i>" or "document <i>, resources[<j>]" for resource lists.
+func generateResourcesFromReader(cd *cdv2.ComponentDescriptor, reader io.Reader) ([]ResourceOptions, []string, error) {
 	resources := make([]ResourceOptions, 0)
+	locations := make([]string, 0)
 	yamldecoder := yamlutil.NewYAMLOrJSONDecoder(reader, 1024)
+	doc := 0
 	for {
 		// ResourceOption contains either a list of options that are used to describe a resource or a resource.
 		type ResourceOption struct {
@@ -337,7 +491,7 @@ func generateResourcesFromReader(cd *cdv2.ComponentDescriptor, reader io.Reader)
 			if err == io.EOF {
 				break
 			}
-			return nil, fmt.Errorf("unable to decode resource: %w", err)
+			return nil, nil, fmt.Errorf("unable to decode resource: %w", err)
 		}
 		if opts.ResourceOptions != nil {
 			resource := *opts.ResourceOptions
@@ -347,12 +501,13 @@ func generateResourcesFromReader(cd *cdv2.ComponentDescriptor, reader io.Reader)
 			}
 
 			if resource.Input != nil && resource.Access != nil {
-				return nil, fmt.Errorf("the resources %q input and access is defind. Only one option is allowed", resource.Name)
+				return nil, nil, fmt.Errorf("the resources %q input and access is defind. Only one option is allowed", resource.Name)
 			}
 			resources = append(resources, resource)
+			locations = append(locations, fmt.Sprintf("document %d", doc))
 		} else if opts.Resources != nil {
 			resourcesList := opts.ResourceOptionList
-			for _, res := range resourcesList.Resources {
+			for i, res := range resourcesList.Resources {
 				resource := res
 				// automatically set the version to the component descriptors version for local resources
 				if resource.Relation == cdv2.LocalRelation && len(resource.Version) == 0 {
@@ -360,49 +515,77 @@ func generateResourcesFromReader(cd *cdv2.ComponentDescriptor, reader io.Reader)
 				}
 
 				if resource.Input != nil && resource.Access != nil {
-					return nil, fmt.Errorf("the resources %q input and access is defind. Only one option is allowed", resource.Name)
+					return nil, nil, fmt.Errorf("the resources %q input and access is defind. Only one option is allowed", resource.Name)
 				}
 				resources = append(resources, resource)
+				locations = append(locations, fmt.Sprintf("document %d, resources[%d]", doc, i))
 			}
+		} else {
+			// skip empty documents
+			continue
 		}
+		doc++
 	}
 
-	return resources, nil
+	return resources, locations, nil
 }
 
-func (o *Options) addInputBlob(ctx context.Context, fs vfs.FileSystem, archive *ctf.ComponentArchive, resource *InternalResourceOptions) error {
-	blob, err := resource.Input.Read(ctx, fs, resource.Path)
-	if err != nil {
-		return err
+// checkDuplicateIdentities checks that no two resource definitions have the same identity.
+// If duplicates are allowed, only a warning is logged and the last definition wins.
+func checkDuplicateIdentities(log logr.Logger, resources []InternalResourceOptions, allowDuplicates bool) error {
+	sources := map[string][]string{}
+	order := []string{}
+	names := map[string]string{}
+	for _, res := range resources {
+		meta := res.IdentityObjectMeta
+		key := string(meta.GetIdentityDigest())
+		if _, ok := sources[key]; !ok {
+			order = append(order, key)
+			names[key] = fmt.Sprintf("%q", res.Name)
+			if len(res.ExtraIdentity) != 0 {
+				names[key] = fmt.Sprintf("%q %v", res.Name, res.ExtraIdentity)
+			}
+		}
+		sources[key] = append(sources[key], res.Source)
 	}
-	// default media type to binary data if nothing else is defined
-	resource.Input.SetMediaTypeIfNotDefined(input.MediaTypeOctetStream)
-
-	err = archive.AddResource(&resource.Resource, ctf.BlobInfo{
-		MediaType: resource.Input.MediaType,
-		Digest:    blob.Digest,
-		Size:      blob.Size,
-	}, blob.Reader)
-	if err != nil {
-		blob.Reader.Close()
-		return fmt.Errorf("unable to add input blob to archive: %w", err)
+
+	duplicates := []string{}
+	for _, key := range order {
+		if len(sources[key]) > 1 {
+			duplicates = append(duplicates, fmt.Sprintf("resource %s is defined in %s", names[key], strings.Join(sources[key], "; ")))
+		}
 	}
-	if err := blob.Reader.Close(); err != nil {
-		return fmt.Errorf("unable to close input file: %w", err)
+	if len(duplicates) == 0 {
+		return nil
 	}
-	return nil
+	if allowDuplicates {
+		for _, dup := range duplicates {
+			log.Info(fmt.Sprintf("Warning: %s. The last definition is used", dup))
+		}
+		return nil
+	}
+	return fmt.Errorf("multiple resources with the same identity are defined (use --allow-duplicates to use the last definition):\n%s", strings.Join(duplicates, "\n"))
 }
 
-func convertToInternalResourceOptions(resOpts []ResourceOptions, filepath string) []InternalResourceOptions {
+func (o *Options) addInputBlob(ctx context.Context, fs vfs.FileSystem, archive *ctf.ComponentArchive, resource *InternalResourceOptions) error {
+	return input.AddResource(ctx, fs, archive, &resource.Resource, resource.Input, resource.Path)
+}
+
+func convertToInternalResourceOptions(resOpts []ResourceOptions, locations []string, filepath string) []InternalResourceOptions {
 	if len(resOpts) == 0 {
 		return nil
 	}
+	file := filepath
+	if len(file) == 0 {
+		file = "stdin"
+	}
 	resources := make([]InternalResourceOptions, len(resOpts))
 	for i, resOpt := range resOpts {
 		opt := resOpt
 		resources[i] = InternalResourceOptions{
 			ResourceOptions: opt,
 			Path:            filepath,
+			Source:          fmt.Sprintf("%s (%s)", file, locations[i]),
 		}
 	}
 	return resources
@@ -7,7 +7,9 @@ package resources_test
 import (
 	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"os"
@@ -15,6 +17,7 @@ import (
 	"testing"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/apis/v2/cdutils"
 	"github.com/gardener/component-spec/bindings-go/codec"
 	"github.com/gardener/component-spec/bindings-go/ctf"
 	"github.com/go-logr/logr"
@@ -26,9 +29,12 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/gardener/component-cli/pkg/commands/componentarchive/resources"
 	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/componentarchive/input"
 	"github.com/gardener/component-cli/pkg/template"
 	"github.com/gardener/component-cli/pkg/utils"
 )
@@ -38,6 +44,13 @@ func TestConfig(t *testing.T) {
 	RunSpecs(t, "Resources Test Suite")
 }
 
+// imageResolverFunc implements the ociclient.Resolver interface with a function.
+type imageResolverFunc func(ctx context.Context, ref string) (string, ocispecv1.Descriptor, error)
+
+func (f imageResolverFunc) Resolve(ctx context.Context, ref string) (string, ocispecv1.Descriptor, error) {
+	return f(ctx, ref)
+}
+
 var _ = Describe("Add", func() {
 
 	var testdataFs vfs.FileSystem
@@ -102,6 +115,30 @@ var _ = Describe("Add", func() {
 		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("imageReference", "ubuntu:18.0"))
 	})
 
+	It("should pin the image reference of an oci registry access to its digest", func() {
+		dgst := digest.FromString("ubuntu")
+		resolvedRefs := []string{}
+		opts := &resources.Options{
+			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ResourceObjectPaths: []string{"./resources/00-res.yaml"},
+			ResolveDigests:      true,
+			ImageResolver: imageResolverFunc(func(_ context.Context, ref string) (string, ocispecv1.Descriptor, error) {
+				resolvedRefs = append(resolvedRefs, ref)
+				return ref, ocispecv1.Descriptor{Digest: dgst}, nil
+			}),
+		}
+
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		Expect(resolvedRefs).To(ConsistOf("ubuntu:18.0"))
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.Resources).To(HaveLen(1))
+		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("imageReference", "index.docker.io/library/ubuntu@"+dgst.String()))
+	})
+
 	It("should add a resource defined by the deprecated -r option", func() {
 		opts := &resources.Options{
 			ResourceObjectPath: "./resources/00-res.yaml",
@@ -304,6 +341,43 @@ var _ = Describe("Add", func() {
 		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("imageReference", "ubuntu:18.0"))
 	})
 
+	It("should fail if multiple resources with the same identity are defined", func() {
+		opts := &resources.Options{
+			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ResourceObjectPaths: []string{"./resources/06-duplicates.yaml"},
+		}
+
+		err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`resource "ubuntu" is defined in ./resources/06-duplicates.yaml (document 0); ./resources/06-duplicates.yaml (document 2)`))
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.Resources).To(HaveLen(0))
+	})
+
+	It("should use the last definition of resources with the same identity if duplicates are allowed", func() {
+		opts := &resources.Options{
+			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ResourceObjectPaths: []string{"./resources/06-duplicates.yaml"},
+			AllowDuplicates:     true,
+		}
+
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.Resources).To(HaveLen(2))
+		Expect(cd.Resources[0].IdentityObjectMeta).To(MatchFields(IgnoreExtras, Fields{
+			"Name":    Equal("ubuntu"),
+			"Version": Equal("v0.0.2"),
+		}))
+	})
+
 	It("should throw an error if an invalid resource is defined", func() {
 		opts := &resources.Options{
 			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
@@ -351,6 +425,46 @@ var _ = Describe("Add", func() {
 			Expect(blobs).To(HaveLen(1))
 		})
 
+		It("should validate a resource against a referenced jsonschema resource", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/26-res-config.yaml"},
+			}
+
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+			data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+			Expect(err).ToNot(HaveOccurred())
+			cd := &cdv2.ComponentDescriptor{}
+			Expect(codec.Decode(data, cd)).To(Succeed())
+			Expect(cd.Resources).To(HaveLen(2))
+		})
+
+		It("should fail if a resource is invalid according to the referenced jsonschema resource", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/26-res-config-invalid.yaml"},
+			}
+
+			err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("age"))
+
+			opts.SkipJSONSchemaValidation = true
+			Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+		})
+
+		It("should fail if a jsonschema resource does not contain a valid jsonschema", func() {
+			opts := &resources.Options{
+				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+				ResourceObjectPaths: []string{"./resources/27-res-jsonschema-invalid.yaml"},
+			}
+
+			err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not a valid json schema"))
+		})
+
 		It("should automatically tar a directory input and add it as resource", func() {
 			opts := &resources.Options{
 				BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
@@ -477,6 +591,242 @@ var _ = Describe("Add", func() {
 
 	})
 
+	It("should filter files in subdirectories of a directory input", func() {
+		Expect(testdataFs.MkdirAll("./resources/nested/docs/internal", os.ModePerm)).To(Succeed())
+		Expect(testdataFs.MkdirAll("./resources/nested/bin", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/nested/a.txt", []byte("a"), 0600)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/nested/docs/b.txt", []byte("b"), 0600)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/nested/docs/c.md", []byte("c"), 0600)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/nested/docs/internal/d.txt", []byte("d"), 0600)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/nested/bin/e.txt", []byte("e"), 0700)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/24-res-nested.yaml", []byte(`
+name: 'myconfig'
+version: 'v0.0.1'
+type: 'plain-text'
+relation: 'external'
+input:
+  type: dir
+  path: "./nested"
+  preservePermissions: false
+  includeFiles:
+  - '*.txt'
+  excludeFiles:
+  - 'docs/internal'
+`), os.ModePerm)).To(Succeed())
+
+		opts := &resources.Options{
+			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ResourceObjectPaths: []string{"./resources/24-res-nested.yaml"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobs).To(HaveLen(1))
+		blob, err := testdataFs.Open(filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName, blobs[0].Name()))
+		Expect(err).ToNot(HaveOccurred())
+		defer blob.Close()
+		modes := map[string]int64{}
+		tr := tar.NewReader(blob)
+		for {
+			header, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(modes).ToNot(HaveKey(header.Name), "every file should be added only once")
+			modes[header.Name] = header.Mode
+		}
+		Expect(modes).To(Equal(map[string]int64{
+			"a.txt":      0644,
+			"bin":        0755,
+			"bin/e.txt":  0755,
+			"docs":       0755,
+			"docs/b.txt": 0644,
+		}))
+	})
+
+	It("should convert a docker archive input to an oci image layout", func() {
+		config := []byte(`{"architecture":"amd64","os":"linux"}`)
+		layer := []byte("layer")
+		var archive bytes.Buffer
+		tw := tar.NewWriter(&archive)
+		for name, data := range map[string][]byte{
+			"config.json":   config,
+			"layer.tar":     layer,
+			"manifest.json": []byte(`[{"Config":"config.json","RepoTags":["myimage:latest"],"Layers":["layer.tar"]}]`),
+		} {
+			Expect(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(data)), Mode: 0644})).To(Succeed())
+			_, err := tw.Write(data)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(tw.Close()).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/image.tar", archive.Bytes(), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/30-docker-archive.yaml", []byte(`
+name: 'myimage'
+type: 'ociImage'
+relation: 'local'
+input:
+  type: "docker-archive"
+  path: "./image.tar"
+`), os.ModePerm)).To(Succeed())
+
+		opts := &resources.Options{
+			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ResourceObjectPaths: []string{"./resources/30-docker-archive.yaml"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.Resources).To(HaveLen(1))
+		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("type", cdv2.LocalFilesystemBlobType))
+		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("mediaType", input.MediaTypeOCIImageLayoutTar))
+
+		blobs, err := vfs.ReadDir(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobs).To(HaveLen(1))
+		blob, err := testdataFs.Open(filepath.Join(opts.ComponentArchivePath, ctf.BlobsDirectoryName, blobs[0].Name()))
+		Expect(err).ToNot(HaveOccurred())
+		defer blob.Close()
+		files := map[string]bool{}
+		tr := tar.NewReader(blob)
+		for {
+			header, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+			files[header.Name] = true
+		}
+		Expect(files).To(HaveKey("oci-layout"))
+		Expect(files).To(HaveKey("index.json"))
+		Expect(files).To(HaveKey("blobs/sha256/" + digest.FromBytes(config).Encoded()))
+		Expect(files).To(HaveKey("blobs/sha256/" + digest.FromBytes(layer).Encoded()))
+	})
+
+	It("should package a helm chart directory as helm chart oci artifact", func() {
+		Expect(testdataFs.MkdirAll("./resources/mychart/templates", os.ModePerm)).To(Succeed())
+		Expect(testdataFs.MkdirAll("./resources/mychart/ci", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/mychart/Chart.yaml", []byte("apiVersion: v2\nname: example\nversion: 0.1.0\n"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/mychart/.helmignore", []byte("# comment\nci/\n*.bak\n!keep.bak\n"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/mychart/templates/cm.yaml", []byte("kind: ConfigMap"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/mychart/templates/.cm.yaml.swp", []byte("swap"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/mychart/ci/values.yaml", []byte("ci: true"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/mychart/values.bak", []byte("old"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/mychart/keep.bak", []byte("keep"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/mychart/README.md", []byte("readme"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/31-helm.yaml", []byte(`
+name: 'mychart'
+type: 'helm'
+relation: 'local'
+input:
+  type: "helm"
+  path: "./mychart"
+  excludeFiles:
+  - "*.md"
+`), os.ModePerm)).To(Succeed())
+
+		opts := &resources.Options{
+			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ResourceObjectPaths: []string{"./resources/31-helm.yaml"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		Expect(cd.Resources).To(HaveLen(1))
+		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("mediaType", input.MediaTypeOCIImageLayoutTar))
+
+		manifest, blobs := readHelmChartArtifact(testdataFs, opts.ComponentArchivePath)
+		Expect(manifest.Config.MediaType).To(Equal(input.MediaTypeHelmChartConfig))
+		Expect(blobs[manifest.Config.Digest]).To(MatchJSON(`{"apiVersion":"v2","name":"example","version":"0.1.0"}`))
+		Expect(manifest.Annotations).To(HaveKeyWithValue(ocispecv1.AnnotationTitle, "example"))
+		Expect(manifest.Annotations).To(HaveKeyWithValue(ocispecv1.AnnotationVersion, "0.1.0"))
+		Expect(manifest.Layers).To(HaveLen(1))
+		Expect(manifest.Layers[0].MediaType).To(Equal(input.MediaTypeHelmChartContent))
+
+		gr, err := gzip.NewReader(bytes.NewReader(blobs[manifest.Layers[0].Digest]))
+		Expect(err).ToNot(HaveOccurred())
+		files := map[string]bool{}
+		tr := tar.NewReader(gr)
+		for {
+			header, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+			files[header.Name] = true
+		}
+		Expect(files).To(HaveKey("example/Chart.yaml"))
+		Expect(files).To(HaveKey("example/.helmignore"))
+		Expect(files).To(HaveKey("example/templates/cm.yaml"))
+		Expect(files).To(HaveKey("example/keep.bak"))
+		Expect(files).ToNot(HaveKey("example/templates/.cm.yaml.swp"))
+		Expect(files).ToNot(HaveKey("example/ci"))
+		Expect(files).ToNot(HaveKey("example/ci/values.yaml"))
+		Expect(files).ToNot(HaveKey("example/values.bak"))
+		Expect(files).ToNot(HaveKey("example/README.md"))
+	})
+
+	It("should add the provenance file of a packaged helm chart to the helm chart oci artifact", func() {
+		var chart bytes.Buffer
+		gw := gzip.NewWriter(&chart)
+		tw := tar.NewWriter(gw)
+		chartYaml := []byte("apiVersion: v2\nname: example\nversion: 0.2.0\n")
+		Expect(tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "example/Chart.yaml", Size: int64(len(chartYaml)), Mode: 0644})).To(Succeed())
+		_, err := tw.Write(chartYaml)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tw.Close()).To(Succeed())
+		Expect(gw.Close()).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/example-0.2.0.tgz", chart.Bytes(), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/example-0.2.0.tgz.prov", []byte("provenance"), os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/31-helm-packaged.yaml", []byte(`
+name: 'mychart'
+type: 'helm'
+relation: 'local'
+input:
+  type: "helm"
+  path: "./example-0.2.0.tgz"
+`), os.ModePerm)).To(Succeed())
+
+		opts := &resources.Options{
+			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ResourceObjectPaths: []string{"./resources/31-helm-packaged.yaml"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		manifest, blobs := readHelmChartArtifact(testdataFs, opts.ComponentArchivePath)
+		Expect(manifest.Annotations).To(HaveKeyWithValue(ocispecv1.AnnotationVersion, "0.2.0"))
+		Expect(manifest.Layers).To(HaveLen(2))
+		Expect(manifest.Layers[0].MediaType).To(Equal(input.MediaTypeHelmChartContent))
+		Expect(blobs[manifest.Layers[0].Digest]).To(Equal(chart.Bytes()))
+		Expect(manifest.Layers[1].MediaType).To(Equal(input.MediaTypeHelmChartProvenance))
+		Expect(blobs[manifest.Layers[1].Digest]).To(Equal([]byte("provenance")))
+	})
+
+	It("should fail if a helm chart has no Chart.yaml", func() {
+		Expect(testdataFs.MkdirAll("./resources/nochart", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "./resources/31-helm-invalid.yaml", []byte(`
+name: 'mychart'
+type: 'helm'
+relation: 'local'
+input:
+  type: "helm"
+  path: "./nochart"
+`), os.ModePerm)).To(Succeed())
+
+		opts := &resources.Options{
+			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			ResourceObjectPaths: []string{"./resources/31-helm-invalid.yaml"},
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(HaveOccurred())
+	})
+
 	It("should add a resource defined by a file with a template", func() {
 		opts := &resources.Options{
 			BuilderOptions: componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
@@ -510,6 +860,40 @@ var _ = Describe("Add", func() {
 		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("imageReference", "ubuntu:v0.0.2"))
 	})
 
+	It("should record the template provenance of a resource defined by a file with a template", func() {
+		opts := &resources.Options{
+			BuilderOptions: componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			TemplateOptions: template.Options{
+				Vars: map[string]string{
+					"MY_VERSION":  "v0.0.2",
+					"MY_PASSWORD": "secret",
+				},
+				RecordProvenance: true,
+			},
+			ResourceObjectPaths: []string{"./resources/04-res.yaml"},
+		}
+
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+
+		tmplData, err := vfs.ReadFile(testdataFs, "./resources/04-res.yaml")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cd.Resources).To(HaveLen(1))
+		label, ok := cdutils.GetLabel(cd.Resources[0].Labels, template.ProvenanceLabelName)
+		Expect(ok).To(BeTrue())
+		provenance := &template.Provenance{}
+		Expect(json.Unmarshal(label.Value, provenance)).To(Succeed())
+		Expect(provenance.TemplateDigest).To(Equal(digest.FromBytes(tmplData).String()))
+		Expect(provenance.Variables).To(Equal(map[string]string{"MY_VERSION": "v0.0.2"}))
+		Expect(provenance.ExcludedVariables).To(ConsistOf("MY_PASSWORD"))
+	})
+
 	It("should preserve the directory", func() {
 		opts := &resources.Options{
 			BuilderOptions:      componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
@@ -605,3 +989,30 @@ func untar(data []byte) (map[string][]byte, error) {
 		files[header.Name] = d.Bytes()
 	}
 }
+
+// readHelmChartArtifact reads the oci image layout of the only blob of the component archive
+// and returns the manifest of the helm chart oci artifact and its blobs.
+func readHelmChartArtifact(fs vfs.FileSystem, caPath string) (ocispecv1.Manifest, map[digest.Digest][]byte) {
+	blobInfos, err := vfs.ReadDir(fs, filepath.Join(caPath, ctf.BlobsDirectoryName))
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	ExpectWithOffset(1, blobInfos).To(HaveLen(1))
+	data, err := vfs.ReadFile(fs, filepath.Join(caPath, ctf.BlobsDirectoryName, blobInfos[0].Name()))
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	files, err := untar(data)
+	ExpectWithOffset(1, err).ToNot(HaveOccurred())
+	ExpectWithOffset(1, files).To(HaveKey("oci-layout"))
+
+	blobs := map[digest.Digest][]byte{}
+	for name, data := range files {
+		dir, encoded := filepath.Split(name)
+		if filepath.Dir(filepath.Clean(dir)) == "blobs" {
+			blobs[digest.NewDigestFromEncoded(digest.Algorithm(filepath.Base(dir)), encoded)] = data
+		}
+	}
+	index := ocispecv1.Index{}
+	ExpectWithOffset(1, json.Unmarshal(files["index.json"], &index)).To(Succeed())
+	ExpectWithOffset(1, index.Manifests).To(HaveLen(1))
+	manifest := ocispecv1.Manifest{}
+	ExpectWithOffset(1, json.Unmarshal(blobs[index.Manifests[0].Digest], &manifest)).To(Succeed())
+	return manifest, blobs
+}
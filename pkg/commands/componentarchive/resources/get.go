@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// GetOptions defines all options for the get resource command.
+type GetOptions struct {
+	componentarchive.ListOptions
+}
+
+// NewGetCommand creates a command to print a single resource of a component descriptor.
+func NewGetCommand(ctx context.Context) *cobra.Command {
+	opts := &GetOptions{}
+	cmd := &cobra.Command{
+		Use:   "get [component-archive-path | BASE_URL COMPONENT_NAME VERSION]",
+		Args:  cobra.RangeArgs(0, 3),
+		Short: "prints a single resource of a component archive or a remote component descriptor",
+		Long: `
+get prints the resource that matches the field selectors ("--selector name=my-image").
+The command fails if no resource or more than one resource matches.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	opts.AddFlags(cmd.Flags(), "yaml")
+	return cmd
+}
+
+// Run prints the selected resource to stdout.
+func (o *GetOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	return o.RunWithWriter(ctx, log, fs, os.Stdout)
+}
+
+// RunWithWriter writes the selected resource to the given writer.
+func (o *GetOptions) RunWithWriter(ctx context.Context, log logr.Logger, fs vfs.FileSystem, w io.Writer) error {
+	cd, err := o.ComponentDescriptor(ctx, log, fs)
+	if err != nil {
+		return err
+	}
+	selected, err := selectResources(&o.ListOptions, cd)
+	if err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		return errors.New("no resource matches the selector")
+	}
+	if len(selected) > 1 {
+		return fmt.Errorf("the selector is ambiguous: %d resources match", len(selected))
+	}
+	if o.OutputFormat == "table" {
+		return o.Print(w, selected, resourceColumns)
+	}
+	return o.Print(w, selected[0], nil)
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *GetOptions) Complete(args []string) error {
+	if err := o.ListOptions.Complete(args); err != nil {
+		return err
+	}
+	if len(o.Selector) == 0 {
+		return errors.New("at least one selector has to be defined")
+	}
+	return nil
+}
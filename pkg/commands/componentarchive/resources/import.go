@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// ImportOptions defines the options that are used to import resources of another component into a component descriptor.
+type ImportOptions struct {
+	componentarchive.BuilderOptions
+
+	// FromBaseUrl is the oci registry where the source component is stored.
+	// Defaults to the effective repository context of the component descriptor.
+	FromBaseUrl string
+	// FromComponent is the source component of the form "<name>:<version>".
+	FromComponent string
+	// Filters select the resources that are imported.
+	// Every filter is of the form "<key>=<pattern>" and all filters have to match.
+	Filters []string
+	// CopyBlobs defines whether the local blobs of the selected resources are copied into the component archive.
+	CopyBlobs bool
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+
+	fromComponent components.ComponentVersion
+	filters       []resourceFilter
+}
+
+// NewImportCommand creates a command to import resources of another component into a component descriptor.
+func NewImportCommand(ctx context.Context) *cobra.Command {
+	opts := &ImportOptions{}
+	cmd := &cobra.Command{
+		Use:   "import COMPONENT_ARCHIVE_PATH --from-component COMPONENT_NAME:VERSION",
+		Args:  cobra.ExactArgs(1),
+		Short: "Imports resources of a published component into an component archive",
+		Long: `
+import copies the resource definitions of a published component into the given component descriptor in the component archive,
+which eases the creation of aggregating components.
+If a resource is already defined (equality by identity) in the component descriptor it will be overwritten.
+
+The component archive can be specified by the first argument, the flag "--archive" or as env var "COMPONENT_ARCHIVE_PATH".
+The source component is resolved from the repository context given with "--from-repo-ctx" or from the effective repository context
+of the component descriptor in the component archive.
+
+The imported resources can be selected with "--filter <key>=<pattern>" where the key is one of "name", "version", "type", "relation"
+or an extra identity key and the pattern is a shell file name pattern. If multiple filters are given, all of them have to match.
+
+Resources with a local access (localOciBlob, localFilesystemBlob) are only valid in the component that contains the blob,
+so that they can only be imported with "--copy-blobs", which copies their blobs into the component archive.
+The version of copied local resources is set to the version of the component archive.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run imports the resources with a resolver that uses the configured oci client.
+func (o *ImportOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	return o.RunWithResolver(ctx, log, fs, schema.NewResolver(ociClient))
+}
+
+// RunWithResolver imports the resources of the source component that is resolved with the given resolver.
+func (o *ImportOptions) RunWithResolver(ctx context.Context, log logr.Logger, fs vfs.FileSystem, resolver ctf.ComponentResolver) error {
+	archive, schemaVersion, err := o.BuilderOptions.Build(fs)
+	if err != nil {
+		return err
+	}
+
+	var repoCtx cdv2.Repository
+	if len(o.FromBaseUrl) != 0 {
+		repoCtx = cdv2.NewOCIRegistryRepository(o.FromBaseUrl, "")
+	} else if effective := archive.ComponentDescriptor.GetEffectiveRepositoryContext(); effective != nil {
+		repoCtx = effective
+	} else {
+		return errors.New("a repository context must be provided as the component descriptor does not define one")
+	}
+
+	src, blobResolver, err := resolver.ResolveWithBlobResolver(ctx, repoCtx, o.fromComponent.Name, o.fromComponent.Version)
+	if err != nil {
+		return fmt.Errorf("unable to resolve component descriptor %s: %w", o.fromComponent, err)
+	}
+
+	imported := 0
+	for _, res := range src.DeepCopy().Resources {
+		res := res
+		if !o.matches(res) {
+			continue
+		}
+		log := log.WithValues("resource-name", res.Name, "resource-version", res.Version)
+		utils.PrintPrettyYaml(res, log.V(5).Enabled())
+
+		if res.Access != nil && (res.Access.GetType() == cdv2.LocalOCIBlobType || res.Access.GetType() == cdv2.LocalFilesystemBlobType) {
+			if !o.CopyBlobs {
+				return fmt.Errorf("resource %q of %s has a local access and can only be imported with --copy-blobs", res.Name, o.fromComponent)
+			}
+			log.Info(fmt.Sprintf("copy blob of resource %q", res.Name))
+			// local resources have to be versioned with the component that contains them
+			if res.Relation == cdv2.LocalRelation {
+				res.Version = archive.ComponentDescriptor.GetVersion()
+			}
+			if err := archive.AddResourceFromResolver(ctx, &res, blobResolver); err != nil {
+				return fmt.Errorf("unable to copy blob of resource %q: %w", res.Name, err)
+			}
+		} else if id := archive.ComponentDescriptor.GetResourceIndex(res); id != -1 {
+			archive.ComponentDescriptor.Resources[id] = res
+		} else {
+			archive.ComponentDescriptor.Resources = append(archive.ComponentDescriptor.Resources, res)
+		}
+		imported++
+	}
+	if imported == 0 {
+		log.Info(fmt.Sprintf("Warning: no resources of %s match the filters", o.fromComponent))
+		return nil
+	}
+
+	if err := cdvalidation.Validate(archive.ComponentDescriptor); err != nil {
+		return fmt.Errorf("invalid component descriptor: %w", err)
+	}
+	data, err := schema.EncodeYAML(archive.ComponentDescriptor, schemaVersion)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
+	if err := vfs.WriteFile(fs, compDescFilePath, data, 0664); err != nil {
+		return fmt.Errorf("unable to write modified comonent descriptor: %w", err)
+	}
+	log.V(2).Info(fmt.Sprintf("Successfully imported %d resources of %s", imported, o.fromComponent))
+	return nil
+}
+
+// matches checks whether the resource matches all filters.
+func (o *ImportOptions) matches(res cdv2.Resource) bool {
+	for _, f := range o.filters {
+		if !f.matches(res) {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *ImportOptions) Complete(args []string) error {
+	if len(args) == 0 {
+		return errors.New("at least a component archive path argument has to be defined")
+	}
+	o.BuilderOptions.ComponentArchivePath = args[0]
+	o.BuilderOptions.Default()
+
+	var err error
+	o.OciOptions.CacheDir, err = utils.CacheDir()
+	if err != nil {
+		return fmt.Errorf("unable to get oci cache directory: %w", err)
+	}
+
+	return o.Validate()
+}
+
+// Validate validates the import options and parses the source component and the filters.
+func (o *ImportOptions) Validate() error {
+	if err := o.BuilderOptions.Validate(); err != nil {
+		return err
+	}
+	if len(o.FromComponent) == 0 {
+		return errors.New("a source component must be provided with --from-component")
+	}
+	var err error
+	o.fromComponent, err = components.ParseComponentVersion(o.FromComponent)
+	if err != nil {
+		return err
+	}
+	o.filters = make([]resourceFilter, len(o.Filters))
+	for i, f := range o.Filters {
+		o.filters[i], err = parseResourceFilter(f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *ImportOptions) AddFlags(fs *pflag.FlagSet) {
+	o.BuilderOptions.AddFlags(fs)
+	fs.StringVar(&o.FromBaseUrl, "from-repo-ctx", "", "[OPTIONAL] base url of the repository of the source component. Defaults to the effective repository context of the component descriptor")
+	fs.StringVar(&o.FromComponent, "from-component", "", "source component of the form <component-name>:<component-version>")
+	fs.StringArrayVar(&o.Filters, "filter", nil, "select the imported resources by <key>=<pattern>, e.g. type=ociImage. Can be given multiple times")
+	fs.BoolVar(&o.CopyBlobs, "copy-blobs", false, "copy the local blobs of the imported resources into the component archive")
+	o.OciOptions.AddFlags(fs)
+}
+
+// resourceFilter matches an attribute of a resource against a shell file name pattern.
+type resourceFilter struct {
+	key     string
+	pattern string
+}
+
+func parseResourceFilter(s string) (resourceFilter, error) {
+	splitFilter := strings.SplitN(s, "=", 2)
+	if len(splitFilter) != 2 || len(splitFilter[0]) == 0 {
+		return resourceFilter{}, fmt.Errorf("invalid filter %q: expected <key>=<pattern>", s)
+	}
+	f := resourceFilter{
+		key:     splitFilter[0],
+		pattern: splitFilter[1],
+	}
+	if _, err := path.Match(f.pattern, ""); err != nil {
+		return resourceFilter{}, fmt.Errorf("invalid filter %q: %w", s, err)
+	}
+	return f, nil
+}
+
+func (f resourceFilter) matches(res cdv2.Resource) bool {
+	var value string
+	switch f.key {
+	case "name":
+		value = res.Name
+	case "version":
+		value = res.Version
+	case "type":
+		value = res.Type
+	case "relation":
+		value = string(res.Relation)
+	default:
+		var ok bool
+		if value, ok = res.ExtraIdentity[f.key]; !ok {
+			return false
+		}
+	}
+	ok, _ := path.Match(f.pattern, value)
+	return ok
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/resources"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+// archiveResolver resolves components from a static list of component archives.
+type archiveResolver []*ctf.ComponentArchive
+
+func (r archiveResolver) Resolve(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	cd, _, err := r.ResolveWithBlobResolver(ctx, repoCtx, name, version)
+	return cd, err
+}
+
+func (r archiveResolver) ResolveWithBlobResolver(_ context.Context, _ cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	for _, ca := range r {
+		if ca.ComponentDescriptor.Name == name && ca.ComponentDescriptor.Version == version {
+			return ca.ComponentDescriptor.DeepCopy(), ca.BlobResolver, nil
+		}
+	}
+	return nil, nil, ctf.NotFoundError
+}
+
+var _ = Describe("Import", func() {
+
+	var (
+		testdataFs vfs.FileSystem
+		resolver   archiveResolver
+		blob       []byte
+	)
+
+	BeforeEach(func() {
+		fs, err := projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+		testdataFs = layerfs.New(memoryfs.New(), fs)
+
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Metadata.Version = cdv2.SchemaVersion
+		cd.Name = "example.com/source"
+		cd.Version = "v1.0.0"
+		cd.Provider = "internal"
+		ca := ctf.NewComponentArchive(cd, memoryfs.New())
+		for _, name := range []string{"image-a", "image-b"} {
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess("example.com/" + name + ":v1.0.0"))
+			Expect(err).ToNot(HaveOccurred())
+			cd.Resources = append(cd.Resources, cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: name, Version: "v1.0.0", Type: cdv2.OCIImageType},
+				Relation:           cdv2.ExternalRelation,
+				Access:             &acc,
+			})
+		}
+		blob = []byte("some data")
+		Expect(ca.AddResource(&cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "config", Version: "v1.0.0", Type: "plain-text"},
+			Relation:           cdv2.LocalRelation,
+		}, ctf.BlobInfo{
+			MediaType: "text/plain",
+			Digest:    digest.FromBytes(blob).String(),
+			Size:      int64(len(blob)),
+		}, bytes.NewReader(blob))).To(Succeed())
+		resolver = archiveResolver{ca}
+	})
+
+	readComponentDescriptor := func(caPath string) *cdv2.ComponentDescriptor {
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(caPath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		return cd
+	}
+
+	It("should import all resources that match the filters", func() {
+		opts := &resources.ImportOptions{
+			BuilderOptions: componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			FromComponent:  "example.com/source:v1.0.0",
+			Filters:        []string{"type=ociImage", "name=*-b"},
+		}
+		Expect(opts.Validate()).To(Succeed())
+		Expect(opts.RunWithResolver(context.TODO(), logr.Discard(), testdataFs, resolver)).To(Succeed())
+
+		cd := readComponentDescriptor(opts.ComponentArchivePath)
+		Expect(cd.Resources).To(HaveLen(1))
+		Expect(cd.Resources[0].Name).To(Equal("image-b"))
+		Expect(cd.Resources[0].Access.Object).To(HaveKeyWithValue("imageReference", "example.com/image-b:v1.0.0"))
+	})
+
+	It("should only import resources with a local access if their blobs are copied", func() {
+		opts := &resources.ImportOptions{
+			BuilderOptions: componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			FromComponent:  "example.com/source:v1.0.0",
+			Filters:        []string{"relation=local"},
+		}
+		Expect(opts.Validate()).To(Succeed())
+		Expect(opts.RunWithResolver(context.TODO(), logr.Discard(), testdataFs, resolver)).ToNot(Succeed())
+
+		opts.CopyBlobs = true
+		Expect(opts.RunWithResolver(context.TODO(), logr.Discard(), testdataFs, resolver)).To(Succeed())
+		cd := readComponentDescriptor(opts.ComponentArchivePath)
+		Expect(cd.Resources).To(HaveLen(1))
+		Expect(cd.Resources[0].Name).To(Equal("config"))
+		acc := cdv2.LocalFilesystemBlobAccess{}
+		Expect(cd.Resources[0].Access.DecodeInto(&acc)).To(Succeed())
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(opts.ComponentArchivePath, ctf.BlobPath(acc.Filename)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(blob))
+	})
+
+	It("should reject invalid filters", func() {
+		opts := &resources.ImportOptions{
+			BuilderOptions: componentarchive.BuilderOptions{ComponentArchivePath: "./00-component"},
+			FromComponent:  "example.com/source:v1.0.0",
+			Filters:        []string{"ociImage"},
+		}
+		Expect(opts.Validate()).ToNot(Succeed())
+	})
+})
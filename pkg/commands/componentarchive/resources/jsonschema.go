@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/xeipuuv/gojsonschema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/componentarchive/input"
+)
+
+const (
+	// JSONSchemaResourceType is the type of resources that contain a json schema.
+	// The blob of such resources is validated to be a valid json schema when it is added.
+	JSONSchemaResourceType = "jsonschema"
+
+	// JSONSchemaLabelName is the name of the label that references a jsonschema resource of the same component by its name.
+	// The blob of a resource with that label is validated against the referenced schema when it is added.
+	JSONSchemaLabelName = "cli.gardener.cloud/jsonschema"
+)
+
+// validateJSONSchema validates the blob of a resource that has been added to the archive.
+// Resources of type jsonschema that are added from a uncompressed file have to contain a valid json schema and
+// resources with the jsonschema label have to be valid against the referenced schema resource.
+// The referenced schema resource has to be part of the component descriptor already.
+func validateJSONSchema(ctx context.Context, archive *ctf.ComponentArchive, res cdv2.Resource, in *input.BlobInput) error {
+	schemaName, err := getJSONSchemaLabel(res)
+	if err != nil {
+		return err
+	}
+	isDocument := in.Type == input.FileInputType && !in.Compress()
+	if len(schemaName) != 0 && !isDocument {
+		return fmt.Errorf("resource %q references the jsonschema %q but only uncompressed file inputs can be validated", res.Name, schemaName)
+	}
+	// jsonschema resources may also be added as directory or compressed archive which cannot be validated.
+	if !isDocument || (res.Type != JSONSchemaResourceType && len(schemaName) == 0) {
+		return nil
+	}
+
+	data, err := readJSONBlob(ctx, archive, res)
+	if err != nil {
+		return fmt.Errorf("unable to read blob of resource %q: %w", res.Name, err)
+	}
+
+	if res.Type == JSONSchemaResourceType {
+		if _, err := gojsonschema.NewSchemaLoader().Compile(gojsonschema.NewBytesLoader(data)); err != nil {
+			return fmt.Errorf("resource %q is not a valid json schema: %w", res.Name, err)
+		}
+	}
+
+	if len(schemaName) == 0 {
+		return nil
+	}
+	schemaRes, ok := getJSONSchemaResource(archive.ComponentDescriptor, schemaName)
+	if !ok {
+		return fmt.Errorf("jsonschema resource %q referenced by resource %q is not defined", schemaName, res.Name)
+	}
+	schemaData, err := readJSONBlob(ctx, archive, schemaRes)
+	if err != nil {
+		return fmt.Errorf("unable to read blob of jsonschema resource %q: %w", schemaName, err)
+	}
+	schema, err := gojsonschema.NewSchemaLoader().Compile(gojsonschema.NewBytesLoader(schemaData))
+	if err != nil {
+		return fmt.Errorf("resource %q is not a valid json schema: %w", schemaName, err)
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return fmt.Errorf("unable to validate resource %q: %w", res.Name, err)
+	}
+	if !result.Valid() {
+		errs := make([]string, len(result.Errors()))
+		for i, resErr := range result.Errors() {
+			errs[i] = resErr.String()
+		}
+		return fmt.Errorf("resource %q is invalid according to the jsonschema %q:\n%s", res.Name, schemaName, strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// getJSONSchemaResource returns the jsonschema resource with the given name.
+func getJSONSchemaResource(cd *cdv2.ComponentDescriptor, name string) (cdv2.Resource, bool) {
+	for _, res := range cd.Resources {
+		if res.Type == JSONSchemaResourceType && res.Name == name {
+			return res, true
+		}
+	}
+	return cdv2.Resource{}, false
+}
+
+// getJSONSchemaLabel returns the name of the schema resource that is referenced by the resource's jsonschema label.
+func getJSONSchemaLabel(res cdv2.Resource) (string, error) {
+	for _, label := range res.Labels {
+		if label.Name != JSONSchemaLabelName {
+			continue
+		}
+		var name string
+		if err := json.Unmarshal(label.Value, &name); err != nil {
+			return "", fmt.Errorf("the value of the label %q of resource %q has to be the name of a jsonschema resource: %w", JSONSchemaLabelName, res.Name, err)
+		}
+		return name, nil
+	}
+	return "", nil
+}
+
+// readJSONBlob reads the blob of a resource from the archive and converts it to json.
+// The blob can be either in json or yaml format.
+func readJSONBlob(ctx context.Context, archive *ctf.ComponentArchive, res cdv2.Resource) ([]byte, error) {
+	if res.Access == nil || res.Access.GetType() != cdv2.LocalFilesystemBlobType {
+		return nil, fmt.Errorf("only resources with a local blob can be validated")
+	}
+	var data bytes.Buffer
+	if _, err := archive.Resolve(ctx, res, &data); err != nil {
+		return nil, err
+	}
+	jsonData, err := yaml.YAMLToJSON(data.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode blob as json or yaml: %w", err)
+	}
+	return jsonData, nil
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// resourceColumns are the columns of the table output of resources.
+var resourceColumns = []componentarchive.ListColumn{
+	{Header: "NAME", Field: "name"},
+	{Header: "VERSION", Field: "version"},
+	{Header: "TYPE", Field: "type"},
+	{Header: "RELATION", Field: "relation"},
+	{Header: "ACCESS", Field: "access.type"},
+	{Header: "EXTRA IDENTITY", Field: "extraIdentity"},
+}
+
+// ListOptions defines all options for the list resources command.
+type ListOptions struct {
+	componentarchive.ListOptions
+}
+
+// NewListCommand creates a command to list the resources of a component descriptor.
+func NewListCommand(ctx context.Context) *cobra.Command {
+	opts := &ListOptions{}
+	cmd := &cobra.Command{
+		Use:     "list [component-archive-path | BASE_URL COMPONENT_NAME VERSION]",
+		Aliases: []string{"ls"},
+		Args:    cobra.RangeArgs(0, 3),
+		Short:   "lists the resources of a component archive or a remote component descriptor",
+		Long: `
+list prints the resources of the component descriptor of a component archive
+or of the remote component descriptor with the given base url, name and version.
+
+The resources can be filtered with field selectors ("--selector type=ociImage").
+Nested fields are separated by a dot ("--selector access.type=ociRegistry")
+and keys that are no field are matched against the extra identity.
+
+The resources are printed as table, json or yaml ("-o json").
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	opts.AddFlags(cmd.Flags(), "table")
+	return cmd
+}
+
+// Run prints the resources to stdout.
+func (o *ListOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	return o.RunWithWriter(ctx, log, fs, os.Stdout)
+}
+
+// RunWithWriter writes the resources to the given writer.
+func (o *ListOptions) RunWithWriter(ctx context.Context, log logr.Logger, fs vfs.FileSystem, w io.Writer) error {
+	cd, err := o.ComponentDescriptor(ctx, log, fs)
+	if err != nil {
+		return err
+	}
+	selected, err := selectResources(&o.ListOptions, cd)
+	if err != nil {
+		return err
+	}
+	return o.Print(w, selected, resourceColumns)
+}
+
+// selectResources returns the resources of the component descriptor that match the field selectors.
+func selectResources(opts *componentarchive.ListOptions, cd *cdv2.ComponentDescriptor) ([]cdv2.Resource, error) {
+	selected := []cdv2.Resource{}
+	for _, res := range cd.Resources {
+		ok, err := opts.Matches(res)
+		if err != nil {
+			return nil, fmt.Errorf("unable to match resource %q: %w", res.Name, err)
+		}
+		if ok {
+			selected = append(selected, res)
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/resources"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+var _ = Describe("List", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		var err error
+		testdataFs, err = projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("should print all resources as table", func() {
+		opts := &resources.ListOptions{ListOptions: componentarchive.ListOptions{OutputFormat: "table"}}
+		Expect(opts.Complete([]string{"./02-component"})).To(Succeed())
+		var out bytes.Buffer
+		Expect(opts.RunWithWriter(context.TODO(), logr.Discard(), testdataFs, &out)).To(Succeed())
+
+		lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+		Expect(lines).To(HaveLen(4))
+		Expect(string(lines[0])).To(MatchRegexp(`^NAME\s+VERSION\s+TYPE\s+RELATION\s+ACCESS\s+EXTRA IDENTITY$`))
+		Expect(string(lines[1])).To(MatchRegexp(`^config\s+v0.0.0\s+json\s+local\s+localFilesystemBlob\s+platform=linux$`))
+	})
+
+	It("should print the resources that match the selectors as json", func() {
+		opts := &resources.ListOptions{ListOptions: componentarchive.ListOptions{
+			OutputFormat: "json",
+			Selector:     map[string]string{"type": "json", "platform": "windows", "access.type": "localFilesystemBlob"},
+		}}
+		Expect(opts.Complete([]string{"./02-component"})).To(Succeed())
+		var out bytes.Buffer
+		Expect(opts.RunWithWriter(context.TODO(), logr.Discard(), testdataFs, &out)).To(Succeed())
+
+		res := []cdv2.Resource{}
+		Expect(json.Unmarshal(out.Bytes(), &res)).To(Succeed())
+		Expect(res).To(HaveLen(1))
+		Expect(res[0].Name).To(Equal("config"))
+		Expect(res[0].ExtraIdentity).To(HaveKeyWithValue("platform", "windows"))
+	})
+
+	It("should get a single resource and fail for ambiguous selectors", func() {
+		opts := &resources.GetOptions{ListOptions: componentarchive.ListOptions{
+			OutputFormat: "yaml",
+			Selector:     map[string]string{"name": "docs"},
+		}}
+		Expect(opts.Complete([]string{"./02-component"})).To(Succeed())
+		var out bytes.Buffer
+		Expect(opts.RunWithWriter(context.TODO(), logr.Discard(), testdataFs, &out)).To(Succeed())
+		res := cdv2.Resource{}
+		Expect(yaml.Unmarshal(out.Bytes(), &res)).To(Succeed())
+		Expect(res.Name).To(Equal("docs"))
+
+		opts.Selector = map[string]string{"name": "config"}
+		Expect(opts.RunWithWriter(context.TODO(), logr.Discard(), testdataFs, &out)).ToNot(Succeed())
+	})
+
+})
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package resources_test
+
+import (
+	"context"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/resources"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+var _ = Describe("Remove", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs, err := projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+		testdataFs = layerfs.New(memoryfs.New(), fs)
+	})
+
+	readComponentDescriptor := func(archivePath string) *cdv2.ComponentDescriptor {
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(archivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		return cd
+	}
+
+	It("should remove a resource selected by its extra identity and its orphaned blob", func() {
+		opts := &resources.RemoveOptions{
+			BuilderOptions: componentarchive.BuilderOptions{ComponentArchivePath: "./02-component"},
+			Selector: componentarchive.ElementSelector{
+				Name:          "config",
+				ExtraIdentity: map[string]string{"platform": "linux"},
+			},
+		}
+		removed, err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(Equal(1))
+
+		cd := readComponentDescriptor(opts.ComponentArchivePath)
+		Expect(cd.Resources).To(HaveLen(2))
+		Expect(cd.Resources[0].ExtraIdentity).To(HaveKeyWithValue("platform", "windows"))
+		Expect(vfs.Exists(testdataFs, "./02-component/blobs/config")).To(BeFalse())
+		Expect(vfs.Exists(testdataFs, "./02-component/blobs/shared")).To(BeTrue())
+	})
+
+	It("should keep blobs that are still referenced by other resources", func() {
+		opts := &resources.RemoveOptions{
+			BuilderOptions: componentarchive.BuilderOptions{ComponentArchivePath: "./02-component"},
+			Selector:       componentarchive.ElementSelector{Name: "config"},
+		}
+		removed, err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(Equal(2))
+		Expect(vfs.Exists(testdataFs, "./02-component/blobs/shared")).To(BeTrue())
+
+		opts.Selector = componentarchive.ElementSelector{Name: "docs", Version: "v0.0.0"}
+		_, err = opts.Run(context.TODO(), logr.Discard(), testdataFs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readComponentDescriptor(opts.ComponentArchivePath).Resources).To(BeEmpty())
+		Expect(vfs.Exists(testdataFs, "./02-component/blobs/shared")).To(BeFalse())
+	})
+
+	It("should fail if no resource matches", func() {
+		opts := &resources.RemoveOptions{
+			BuilderOptions: componentarchive.BuilderOptions{ComponentArchivePath: "./02-component"},
+			Selector:       componentarchive.ElementSelector{Name: "config", Version: "v0.0.1"},
+		}
+		_, err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+		Expect(err).To(HaveOccurred())
+
+		opts.IgnoreMissing = true
+		removed, err := opts.Run(context.TODO(), logr.Discard(), testdataFs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(removed).To(Equal(0))
+	})
+
+})
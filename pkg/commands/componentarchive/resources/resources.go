@@ -18,5 +18,9 @@ func NewResourcesCommand(ctx context.Context) *cobra.Command {
 		Short:   "command to modify resources of a component descriptor",
 	}
 	cmd.AddCommand(NewAddCommand(ctx))
+	cmd.AddCommand(NewRemoveCommand(ctx))
+	cmd.AddCommand(NewListCommand(ctx))
+	cmd.AddCommand(NewGetCommand(ctx))
+	cmd.AddCommand(NewImportCommand(ctx))
 	return cmd
 }
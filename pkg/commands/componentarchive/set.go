@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/labels"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// SetOptions defines all options for the set command.
+type SetOptions struct {
+	// ComponentArchivePath is the path to the component archive.
+	ComponentArchivePath string
+
+	// Name is the new name of the component.
+	Name string
+	// Version is the new version of the component.
+	Version string
+	// Provider is the new provider of the component.
+	Provider string
+	// RepositoryContexts are the base urls of the oci repository contexts that replace the existing repository contexts.
+	RepositoryContexts []string
+	// ComponentNameMapping is the component name mapping of the new repository contexts.
+	ComponentNameMapping string
+	// Labels are the labels of the form name=value that are set.
+	Labels []string
+	// RemoveLabels are the names of the labels that are removed.
+	RemoveLabels []string
+
+	// parsedLabels are the parsed labels.
+	parsedLabels []cdv2.Label
+}
+
+// NewSetCommand creates a new command that patches the top-level fields of a component descriptor.
+func NewSetCommand(ctx context.Context) *cobra.Command {
+	opts := &SetOptions{}
+	cmd := &cobra.Command{
+		Use:   "set [component-archive-path]",
+		Args:  cobra.RangeArgs(0, 1),
+		Short: "sets the name, version, provider, repository contexts or labels of a component archive",
+		Long: `
+set patches the top-level fields of the component descriptor of an existing component archive.
+Only the fields whose flags are given are modified.
+
+If the version is changed, the version of all local resources that have the old component version
+is changed as well because local resources must have the version of their component.
+
+"--repo-ctx" replaces all repository contexts. It can be specified multiple times, the last one is the effective repository context.
+Labels are given as "--label name=value" and overwrite existing labels with the same name.
+The value is parsed as json, values that are not valid json are used as string.
+
+Existing signatures of the component descriptor are no longer valid after the modification.
+
+<pre>
+component-cli ca set ./my-ca --version v1.2.3 --label release=true
+</pre>
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Successfully updated component descriptor in %s\n", opts.ComponentArchivePath)
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// Run patches the component descriptor of the component archive.
+func (o *SetOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
+	if _, err := fs.Stat(compDescFilePath); err != nil {
+		return fmt.Errorf("unable to read component descriptor from %s: %w", compDescFilePath, err)
+	}
+	builderOpts := componentarchive.BuilderOptions{ComponentArchivePath: o.ComponentArchivePath}
+	archive, schemaVersion, err := builderOpts.Build(fs)
+	if err != nil {
+		return err
+	}
+	cd := archive.ComponentDescriptor
+
+	if len(o.Name) != 0 {
+		log.V(3).Info(fmt.Sprintf("set name from %q to %q", cd.Name, o.Name))
+		cd.Name = o.Name
+	}
+	if len(o.Version) != 0 {
+		for i, res := range cd.Resources {
+			if res.Relation == cdv2.LocalRelation && res.Version == cd.Version {
+				cd.Resources[i].Version = o.Version
+			}
+		}
+		log.V(3).Info(fmt.Sprintf("set version from %q to %q", cd.Version, o.Version))
+		cd.Version = o.Version
+	}
+	if len(o.Provider) != 0 {
+		cd.Provider = cdv2.ProviderType(o.Provider)
+	}
+	if len(o.RepositoryContexts) != 0 {
+		cd.RepositoryContexts = make([]*cdv2.UnstructuredTypedObject, 0, len(o.RepositoryContexts))
+		for _, baseUrl := range o.RepositoryContexts {
+			repoCtx, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryRepository(baseUrl, cdv2.ComponentNameMapping(o.ComponentNameMapping)))
+			if err != nil {
+				return fmt.Errorf("unable to create repository context: %w", err)
+			}
+			cd.RepositoryContexts = append(cd.RepositoryContexts, &repoCtx)
+		}
+	}
+	for _, name := range o.RemoveLabels {
+		for i := range cd.Labels {
+			if cd.Labels[i].Name == name {
+				cd.Labels = append(cd.Labels[:i], cd.Labels[i+1:]...)
+				break
+			}
+		}
+	}
+	for _, label := range o.parsedLabels {
+		cd.Labels = setLabel(cd.Labels, label)
+	}
+
+	if len(cd.Signatures) != 0 {
+		log.Info("the component descriptor is signed, the existing signatures are no longer valid")
+	}
+	if err := cdvalidation.Validate(cd); err != nil {
+		return fmt.Errorf("invalid component descriptor: %w", err)
+	}
+	data, err := schema.EncodeYAML(cd, schemaVersion)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	if err := vfs.WriteFile(fs, compDescFilePath, data, 0664); err != nil {
+		return fmt.Errorf("unable to write modified component descriptor: %w", err)
+	}
+	return nil
+}
+
+// setLabel overwrites the label with the same name or adds the label if it does not exist yet.
+func setLabel(labels cdv2.Labels, label cdv2.Label) cdv2.Labels {
+	for i := range labels {
+		if labels[i].Name == label.Name {
+			labels[i] = label
+			return labels
+		}
+	}
+	return append(labels, label)
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *SetOptions) Complete(args []string) error {
+	if len(args) != 0 {
+		o.ComponentArchivePath = args[0]
+	}
+	builderOpts := componentarchive.BuilderOptions{ComponentArchivePath: o.ComponentArchivePath}
+	builderOpts.Default()
+	o.ComponentArchivePath = builderOpts.ComponentArchivePath
+	if len(o.ComponentArchivePath) == 0 {
+		return errors.New("a component archive path must be provided")
+	}
+
+	if len(o.Name) == 0 && len(o.Version) == 0 && len(o.Provider) == 0 && len(o.RepositoryContexts) == 0 &&
+		len(o.Labels) == 0 && len(o.RemoveLabels) == 0 {
+		return errors.New("at least one field has to be set")
+	}
+	o.parsedLabels = make([]cdv2.Label, len(o.Labels))
+	for i, label := range o.Labels {
+		parsed, err := labels.ParseLabel(label)
+		if err != nil {
+			return err
+		}
+		o.parsedLabels[i] = parsed
+	}
+	return nil
+}
+
+func (o *SetOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.ComponentArchivePath, "archive", "a", "", "path to the component archive directory")
+	fs.StringVar(&o.Name, "name", "", "new name of the component")
+	fs.StringVar(&o.Version, "version", "", "new version of the component")
+	fs.StringVar(&o.Provider, "provider", "", "new provider of the component")
+	fs.StringArrayVar(&o.RepositoryContexts, "repo-ctx", []string{}, "base url of an oci repository context. Replaces all existing repository contexts. Can be specified multiple times")
+	fs.StringVar(&o.ComponentNameMapping, "component-name-mapping", string(cdv2.OCIRegistryURLPathMapping), "[OPTIONAL] component name mapping of the repository contexts")
+	fs.StringArrayVar(&o.Labels, "label", []string{}, "label of the form name=value. The value is parsed as json. Can be specified multiple times")
+	fs.StringArrayVar(&o.RemoveLabels, "remove-label", []string{}, "name of a label that is removed. Can be specified multiple times")
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	"github.com/gardener/component-cli/pkg/components/schema"
+)
+
+var _ = Describe("Set", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		baseFs, err := projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+	})
+
+	readComponentDescriptor := func(archivePath string) *cdv2.ComponentDescriptor {
+		data, err := vfs.ReadFile(testdataFs, filepath.Join(archivePath, ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(data, cd)).To(Succeed())
+		return cd
+	}
+
+	It("should set the version of the component and its local resources", func() {
+		opts := &componentarchive.SetOptions{
+			Version: "v1.2.3",
+			Labels:  []string{"release=true", "owner=team-a"},
+		}
+		Expect(opts.Complete([]string{"01-ca-blob"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		cd := readComponentDescriptor("01-ca-blob")
+		Expect(cd.Name).To(Equal("example.com/component"))
+		Expect(cd.Version).To(Equal("v1.2.3"))
+		Expect(cd.Resources[0].Version).To(Equal("v1.2.3"))
+		Expect(cd.Labels).To(ConsistOf(
+			cdv2.Label{Name: "release", Value: json.RawMessage("true")},
+			cdv2.Label{Name: "owner", Value: json.RawMessage(`"team-a"`)},
+		))
+	})
+
+	It("should replace the repository contexts and the provider", func() {
+		opts := &componentarchive.SetOptions{
+			Provider:             "external",
+			RepositoryContexts:   []string{"example.com/old", "example.com/new"},
+			ComponentNameMapping: string(cdv2.OCIRegistryURLPathMapping),
+		}
+		Expect(opts.Complete([]string{"00-ca"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		cd := readComponentDescriptor("00-ca")
+		Expect(cd.Provider).To(Equal(cdv2.ProviderType("external")))
+		Expect(cd.RepositoryContexts).To(HaveLen(2))
+		repoCtx := &cdv2.OCIRegistryRepository{}
+		Expect(cd.GetEffectiveRepositoryContext().DecodeInto(repoCtx)).To(Succeed())
+		Expect(repoCtx.BaseURL).To(Equal("example.com/new"))
+	})
+
+	It("should keep the schema version of the component descriptor", func() {
+		createOpts := &componentarchive.CreateOptions{}
+		createOpts.Name = "example.com/component/name"
+		createOpts.Version = "v0.0.1"
+		createOpts.SchemaVersion = schema.SchemaVersionV3alpha1
+		Expect(testdataFs.Mkdir("./set-v3-test", os.ModePerm)).To(Succeed())
+		Expect(createOpts.Complete([]string{"./set-v3-test"})).To(Succeed())
+		Expect(createOpts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		opts := &componentarchive.SetOptions{
+			Version: "v1.2.3",
+		}
+		Expect(opts.Complete([]string{"./set-v3-test"})).To(Succeed())
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).To(Succeed())
+
+		data, err := vfs.ReadFile(testdataFs, filepath.Join("./set-v3-test", ctf.ComponentDescriptorFileName))
+		Expect(err).ToNot(HaveOccurred())
+		cd, schemaVersion, err := schema.Decode(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(schemaVersion).To(Equal(schema.SchemaVersionV3alpha1))
+		Expect(cd.Version).To(Equal("v1.2.3"))
+	})
+
+	It("should fail if no field is set", func() {
+		opts := &componentarchive.SetOptions{}
+		Expect(opts.Complete([]string{"00-ca"})).ToNot(Succeed())
+	})
+
+})
@@ -0,0 +1,240 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/signatures"
+)
+
+// IdentityTokenEnvName is the environment variable that is read for the oidc identity token of keyless signing.
+const IdentityTokenEnvName = "SIGSTORE_ID_TOKEN"
+
+// CosignSignOptions defines all options for the cosign-sign command.
+type CosignSignOptions struct {
+	// BaseUrl is the oci registry where the component is stored.
+	BaseUrl string
+	// ComponentName is the unique name of the component in the registry.
+	ComponentName string
+	// Version is the component version in the oci registry.
+	Version string
+
+	// PathToPrivateKey is the path to the PEM encoded rsa or ecdsa private key.
+	PathToPrivateKey string
+	// PathToPassphrase is the path to a file that contains the passphrase of an encrypted private key.
+	PathToPassphrase string
+	// KeyURI references a key in a kms (e.g. hashivault://mykey) that is used instead of a local private key.
+	KeyURI string
+
+	// Keyless signs with an ephemeral key and a short-lived certificate issued by fulcio.
+	Keyless bool
+	// IdentityToken is the oidc identity token that is exchanged for the fulcio certificate.
+	IdentityToken string
+	// FulcioURL is the url of the fulcio certificate authority.
+	FulcioURL string
+
+	// TlogUpload uploads the signature to the rekor transparency log.
+	TlogUpload bool
+	// RekorURL is the url of the rekor transparency log.
+	RekorURL string
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+}
+
+// NewCosignSignCommand creates a new command that signs component descriptors with cosign compatible signatures.
+func NewCosignSignCommand(ctx context.Context) *cobra.Command {
+	opts := &CosignSignOptions{}
+	cmd := &cobra.Command{
+		Use:   "cosign-sign BASE_URL COMPONENT_NAME VERSION",
+		Args:  cobra.ExactArgs(3),
+		Short: "sign the component descriptor manifest in an oci registry with a cosign compatible signature",
+		Long: `
+sign the component descriptor manifest in an oci registry with a signature that is compatible with sigstore/cosign.
+The signature is stored as oci referrer of the component descriptor manifest,
+so that it can be verified with the cosign-verify command or with cosign.
+
+The signature is either created with a PEM encoded rsa or ecdsa private key (e.g. generated by the keygen command),
+a key in a kms that is referenced with --key-uri or keyless with an ephemeral key and a short-lived certificate that is issued by fulcio for an oidc identity.
+For keyless signing the oidc identity token has to be provided with the --identity-token flag or the
+` + IdentityTokenEnvName + ` environment variable (e.g. the token of the ci system), an interactive browser login is not supported.
+
+Like cosign, the signature is uploaded to the rekor transparency log unless --tlog-upload=false is set.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run signs the component descriptor manifest and uploads the signature.
+func (o *CosignSignOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	client, ok := ociClient.(signatures.CosignClient)
+	if !ok {
+		return errors.New("the oci client does not support referrers")
+	}
+	ref, err := components.OCIRef(cdv2.NewOCIRegistryRepository(o.BaseUrl, ""), o.ComponentName, o.Version)
+	if err != nil {
+		return fmt.Errorf("invalid component reference: %w", err)
+	}
+
+	signOpts := signatures.CosignSignOptions{}
+	if o.Keyless {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return fmt.Errorf("unable to generate ephemeral key: %w", err)
+		}
+		fulcio := &signatures.FulcioClient{URL: o.FulcioURL}
+		signOpts.Certificate, signOpts.Chain, err = fulcio.RequestCertificate(ctx, key, o.IdentityToken)
+		if err != nil {
+			return fmt.Errorf("unable to get signing certificate: %w", err)
+		}
+		signOpts.Signer = key
+	} else if len(o.KeyURI) != 0 {
+		signOpts.Signer, err = signatures.NewKMSSigner(ctx, o.KeyURI)
+		if err != nil {
+			return fmt.Errorf("unable to create kms signer: %w", err)
+		}
+	} else {
+		signOpts.Signer, err = o.loadPrivateKey(fs)
+		if err != nil {
+			return err
+		}
+	}
+	if o.TlogUpload {
+		signOpts.Rekor = &signatures.RekorClient{URL: o.RekorURL}
+	}
+
+	desc, err := signatures.CosignSign(ctx, client, ref, signOpts)
+	if err != nil {
+		return fmt.Errorf("unable to sign %s: %w", ref, err)
+	}
+	log.Info(fmt.Sprintf("Successfully uploaded cosign signature %s for %s", desc.Digest, ref))
+	return nil
+}
+
+func (o *CosignSignOptions) loadPrivateKey(fs vfs.FileSystem) (crypto.Signer, error) {
+	data, err := vfs.ReadFile(fs, o.PathToPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private key: %w", err)
+	}
+	var passphrase []byte
+	if len(o.PathToPassphrase) != 0 {
+		passphrase, err = vfs.ReadFile(fs, o.PathToPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read passphrase: %w", err)
+		}
+		passphrase = []byte(strings.TrimSpace(string(passphrase)))
+	}
+	signer, err := signatures.LoadPrivateKey(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load private key: %w", err)
+	}
+	return signer, nil
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *CosignSignOptions) Complete(args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("illegal number of arguments: %d", len(args))
+	}
+	o.BaseUrl = args[0]
+	o.ComponentName = args[1]
+	o.Version = args[2]
+
+	if len(o.OciOptions.CacheDir) == 0 {
+		cliHomeDir, err := constants.CliHomeDir()
+		if err != nil {
+			return err
+		}
+		o.OciOptions.CacheDir = filepath.Join(cliHomeDir, "components")
+		if err := os.MkdirAll(o.OciOptions.CacheDir, os.ModePerm); err != nil {
+			return fmt.Errorf("unable to create cache directory %s: %w", o.OciOptions.CacheDir, err)
+		}
+	}
+
+	if len(o.BaseUrl) == 0 {
+		return errors.New("a base url must be provided")
+	}
+	if len(o.ComponentName) == 0 {
+		return errors.New("a component name must be provided")
+	}
+	if len(o.Version) == 0 {
+		return errors.New("a component version must be provided")
+	}
+
+	if len(o.PathToPrivateKey) != 0 && len(o.KeyURI) != 0 {
+		return errors.New("only one of a private key file or a key uri can be provided")
+	}
+	if o.Keyless {
+		if len(o.PathToPrivateKey) != 0 || len(o.KeyURI) != 0 {
+			return errors.New("a private key must not be provided for keyless signing")
+		}
+		if len(o.IdentityToken) == 0 {
+			o.IdentityToken = os.Getenv(IdentityTokenEnvName)
+		}
+		if len(o.IdentityToken) == 0 {
+			return fmt.Errorf("an oidc identity token must be provided for keyless signing with --identity-token or %s", IdentityTokenEnvName)
+		}
+		if len(o.FulcioURL) == 0 {
+			return errors.New("a fulcio url must be provided for keyless signing")
+		}
+	} else if len(o.PathToPrivateKey) == 0 && len(o.KeyURI) == 0 {
+		return errors.New("a path to a private key file or a key uri must be provided")
+	}
+
+	if o.TlogUpload && len(o.RekorURL) == 0 {
+		return errors.New("a rekor url must be provided to upload the signature to the transparency log")
+	}
+	return nil
+}
+
+func (o *CosignSignOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.PathToPrivateKey, "private-key", "", "path to the PEM encoded rsa or ecdsa private key used for signing")
+	fs.StringVar(&o.PathToPassphrase, "passphrase-file", "", "path to a file that contains the passphrase of an encrypted private key")
+	fs.StringVar(&o.KeyURI, "key-uri", "", fmt.Sprintf("uri of a key in a kms that is used for signing instead of a private key file, e.g. hashivault://mykey. Supported schemes: %s", strings.Join(signatures.KMSSchemes(), ", ")))
+	fs.BoolVar(&o.Keyless, "keyless", false, "sign with an ephemeral key and a certificate issued by fulcio for the oidc identity")
+	fs.StringVar(&o.IdentityToken, "identity-token", "", fmt.Sprintf("oidc identity token for keyless signing. Defaults to the %s environment variable", IdentityTokenEnvName))
+	fs.StringVar(&o.FulcioURL, "fulcio-url", signatures.DefaultFulcioURL, "url of the fulcio certificate authority")
+	fs.BoolVar(&o.TlogUpload, "tlog-upload", true, "upload the signature to the rekor transparency log")
+	fs.StringVar(&o.RekorURL, "rekor-url", signatures.DefaultRekorURL, "url of the rekor transparency log")
+	o.OciOptions.AddFlags(fs)
+}
@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/signatures"
+)
+
+// CosignVerifyOptions defines all options for the cosign-verify command.
+type CosignVerifyOptions struct {
+	// BaseUrl is the oci registry where the component is stored.
+	BaseUrl string
+	// ComponentName is the unique name of the component in the registry.
+	ComponentName string
+	// Version is the component version in the oci registry.
+	Version string
+
+	// PathToPublicKey is the path to the PEM encoded public key of signatures that have been created with a key pair.
+	PathToPublicKey string
+
+	// PathToRootCACert is the path to the PEM encoded root certificates of keyless signatures (e.g. the fulcio root).
+	PathToRootCACert string
+	// CertificateIdentity is the expected email or uri of the signing certificate of keyless signatures.
+	CertificateIdentity string
+	// CertificateOIDCIssuer is the expected oidc issuer of the signing certificate of keyless signatures.
+	CertificateOIDCIssuer string
+
+	// PathToRekorPublicKey is the path to the PEM encoded public key of the rekor transparency log.
+	// If set, only signatures that have been uploaded to the transparency log are accepted.
+	PathToRekorPublicKey string
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+}
+
+// NewCosignVerifyCommand creates a new command that verifies cosign compatible signatures of component descriptors.
+func NewCosignVerifyCommand(ctx context.Context) *cobra.Command {
+	opts := &CosignVerifyOptions{}
+	cmd := &cobra.Command{
+		Use:   "cosign-verify BASE_URL COMPONENT_NAME VERSION",
+		Args:  cobra.ExactArgs(3),
+		Short: "verify the cosign compatible signatures of the component descriptor manifest in an oci registry",
+		Long: `
+verify the cosign compatible signatures that are stored as oci referrers of the component descriptor manifest.
+The verification succeeds if at least one signature is valid.
+
+Signatures that have been created with a key pair are verified with the PEM encoded public key.
+Keyless signatures are verified with the root certificates of fulcio and the expected identity of the signing certificate.
+Fulcio certificates are only valid for a short time, therefore keyless signatures are verified at the time they have been
+added to the transparency log. The rekor bundle of the signature is verified if the public key of rekor is provided.
+Without the public key of rekor, the time of the bundle cannot be trusted and the certificates are verified at the current time.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run verifies the cosign signatures of the component descriptor manifest.
+func (o *CosignVerifyOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	client, ok := ociClient.(signatures.CosignClient)
+	if !ok {
+		return errors.New("the oci client does not support referrers")
+	}
+	ref, err := components.OCIRef(cdv2.NewOCIRegistryRepository(o.BaseUrl, ""), o.ComponentName, o.Version)
+	if err != nil {
+		return fmt.Errorf("invalid component reference: %w", err)
+	}
+
+	verifyOpts := signatures.CosignVerifyOptions{
+		Identity: o.CertificateIdentity,
+		Issuer:   o.CertificateOIDCIssuer,
+	}
+	if len(o.PathToPublicKey) != 0 {
+		verifyOpts.PublicKey, err = loadPublicKey(fs, o.PathToPublicKey)
+		if err != nil {
+			return err
+		}
+	}
+	if len(o.PathToRootCACert) != 0 {
+		data, err := vfs.ReadFile(fs, o.PathToRootCACert)
+		if err != nil {
+			return fmt.Errorf("unable to read root ca certificates: %w", err)
+		}
+		verifyOpts.Roots = x509.NewCertPool()
+		if !verifyOpts.Roots.AppendCertsFromPEM(data) {
+			return errors.New("unable to parse root ca certificates")
+		}
+	}
+	if len(o.PathToRekorPublicKey) != 0 {
+		verifyOpts.RekorPublicKey, err = loadPublicKey(fs, o.PathToRekorPublicKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	verified, err := signatures.CosignVerify(ctx, client, ref, verifyOpts)
+	if err != nil {
+		return err
+	}
+	for _, sig := range verified {
+		if sig.Certificate != nil {
+			log.Info(fmt.Sprintf("Cosign signature %s of %s is valid (issuer %q)", sig.Descriptor.Digest, ref, signatures.CertificateOIDCIssuer(sig.Certificate)))
+			continue
+		}
+		log.Info(fmt.Sprintf("Cosign signature %s of %s is valid", sig.Descriptor.Digest, ref))
+	}
+	return nil
+}
+
+func loadPublicKey(fs vfs.FileSystem, path string) (crypto.PublicKey, error) {
+	data, err := vfs.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read public key %q: %w", path, err)
+	}
+	key, err := signatures.LoadPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load public key %q: %w", path, err)
+	}
+	return key, nil
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *CosignVerifyOptions) Complete(args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("illegal number of arguments: %d", len(args))
+	}
+	o.BaseUrl = args[0]
+	o.ComponentName = args[1]
+	o.Version = args[2]
+
+	if len(o.OciOptions.CacheDir) == 0 {
+		cliHomeDir, err := constants.CliHomeDir()
+		if err != nil {
+			return err
+		}
+		o.OciOptions.CacheDir = filepath.Join(cliHomeDir, "components")
+		if err := os.MkdirAll(o.OciOptions.CacheDir, os.ModePerm); err != nil {
+			return fmt.Errorf("unable to create cache directory %s: %w", o.OciOptions.CacheDir, err)
+		}
+	}
+
+	if len(o.BaseUrl) == 0 {
+		return errors.New("a base url must be provided")
+	}
+	if len(o.ComponentName) == 0 {
+		return errors.New("a component name must be provided")
+	}
+	if len(o.Version) == 0 {
+		return errors.New("a component version must be provided")
+	}
+
+	if len(o.PathToPublicKey) == 0 && len(o.PathToRootCACert) == 0 {
+		return errors.New("a public key or root ca certificates for keyless signatures must be provided")
+	}
+	if len(o.PathToRootCACert) != 0 && len(o.CertificateIdentity) == 0 {
+		return errors.New("a certificate identity must be provided to verify keyless signatures")
+	}
+	return nil
+}
+
+func (o *CosignVerifyOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.PathToPublicKey, "public-key", "", "path to the PEM encoded public key of signatures that have been created with a key pair")
+	fs.StringVar(&o.PathToRootCACert, "ca-cert", "", "path to the PEM encoded root ca certificates of keyless signatures (e.g. the fulcio root certificate)")
+	fs.StringVar(&o.CertificateIdentity, "certificate-identity", "", "expected email or uri of the signing certificate of keyless signatures")
+	fs.StringVar(&o.CertificateOIDCIssuer, "certificate-oidc-issuer", "", "[OPTIONAL] expected oidc issuer of the signing certificate of keyless signatures")
+	fs.StringVar(&o.PathToRekorPublicKey, "rekor-public-key", "", "[OPTIONAL] path to the PEM encoded public key of rekor. Only signatures with a valid rekor bundle are accepted if set")
+	o.OciOptions.AddFlags(fs)
+}
@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/signatures"
+)
+
+const (
+	// KeyTypeRSA is the key type for rsa keys that can be used with the rsa sign and verify commands.
+	KeyTypeRSA = "rsa"
+	// KeyTypeECDSA is the key type for ecdsa keys.
+	KeyTypeECDSA = "ecdsa"
+)
+
+// KeygenOptions defines all options for the keygen command.
+type KeygenOptions struct {
+	// Type is the type of the generated key pair. Can be rsa or ecdsa.
+	Type string
+	// Bits is the size of rsa keys or the curve size of ecdsa keys.
+	Bits int
+	// PathToPrivateKey is the path where the PEM encoded private key is written to.
+	PathToPrivateKey string
+	// PathToPublicKey is the path where the PEM encoded public key is written to.
+	PathToPublicKey string
+	// PathToPassphrase is the path to a file that contains a passphrase to encrypt the private key.
+	PathToPassphrase string
+}
+
+// NewKeygenCommand creates a new command that generates key pairs for signing component descriptors.
+func NewKeygenCommand(ctx context.Context) *cobra.Command {
+	opts := &KeygenOptions{}
+	cmd := &cobra.Command{
+		Use:   "keygen",
+		Args:  cobra.NoArgs,
+		Short: "generate a key pair to sign and verify component descriptors",
+		Long: `
+generate a key pair to sign and verify component descriptors.
+The private key is written PEM encoded in the PKCS #8 form and the public key PEM encoded in the PKIX form,
+which are the formats expected by the sign and verify commands.
+The sha256 fingerprint of the public key is printed after the generation.
+
+The private key can optionally be encrypted with a passphrase.
+Encrypted private keys are written in the encrypted PKCS #8 form (PBES2 with scrypt and AES-256-CBC),
+the passphrase has to be provided with --passphrase-file when the key is used for signing.
+
+rsa keys can be used to sign component descriptors ("signatures sign rsa") and to create cosign signatures ("signatures cosign-sign"),
+ecdsa keys can only be used to create cosign signatures as component descriptor signatures are rsa signatures.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			fingerprint, err := opts.Run(ctx, osfs.New())
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Successfully generated %s key pair %s and %s\n", opts.Type, opts.PathToPrivateKey, opts.PathToPublicKey)
+			fmt.Printf("Fingerprint: %s\n", fingerprint)
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run generates the key pair, writes it to the filesystem and returns the fingerprint of the public key.
+func (o *KeygenOptions) Run(_ context.Context, fs vfs.FileSystem) (string, error) {
+	for _, path := range []string{o.PathToPrivateKey, o.PathToPublicKey} {
+		if _, err := fs.Stat(path); err == nil {
+			return "", fmt.Errorf("file %q already exists", path)
+		}
+	}
+
+	privateKey, publicKey, err := o.generate()
+	if err != nil {
+		return "", err
+	}
+
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode private key: %w", err)
+	}
+	privateKeyBlock := &pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: privateKeyBytes,
+	}
+	if len(o.PathToPassphrase) != 0 {
+		passphrase, err := vfs.ReadFile(fs, o.PathToPassphrase)
+		if err != nil {
+			return "", fmt.Errorf("unable to read passphrase: %w", err)
+		}
+		passphrase = []byte(strings.TrimSpace(string(passphrase)))
+		if len(passphrase) == 0 {
+			return "", errors.New("passphrase must not be empty")
+		}
+		privateKeyBlock, err = signatures.EncryptPKCS8PrivateKey(privateKeyBytes, passphrase)
+		if err != nil {
+			return "", fmt.Errorf("unable to encrypt private key: %w", err)
+		}
+	}
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode public key: %w", err)
+	}
+	publicKeyBlock := &pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: publicKeyBytes,
+	}
+
+	if err := vfs.WriteFile(fs, o.PathToPrivateKey, pem.EncodeToMemory(privateKeyBlock), 0600); err != nil {
+		return "", fmt.Errorf("unable to write private key to %q: %w", o.PathToPrivateKey, err)
+	}
+	if err := vfs.WriteFile(fs, o.PathToPublicKey, pem.EncodeToMemory(publicKeyBlock), 0644); err != nil {
+		return "", fmt.Errorf("unable to write public key to %q: %w", o.PathToPublicKey, err)
+	}
+
+	return Fingerprint(publicKeyBytes), nil
+}
+
+// Fingerprint returns the sha256 fingerprint of a PKIX, ASN.1 DER encoded public key.
+func Fingerprint(publicKey []byte) string {
+	return digest.SHA256.FromBytes(publicKey).String()
+}
+
+func (o *KeygenOptions) generate() (crypto.PrivateKey, crypto.PublicKey, error) {
+	switch o.Type {
+	case KeyTypeRSA:
+		key, err := rsa.GenerateKey(rand.Reader, o.Bits)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to generate rsa key: %w", err)
+		}
+		return key, &key.PublicKey, nil
+	case KeyTypeECDSA:
+		var curve elliptic.Curve
+		switch o.Bits {
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		}
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to generate ecdsa key: %w", err)
+		}
+		return key, &key.PublicKey, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type %q", o.Type)
+	}
+}
+
+// Complete validates the arguments and applies default options.
+func (o *KeygenOptions) Complete(args []string) error {
+	if o.Bits == 0 {
+		switch o.Type {
+		case KeyTypeRSA:
+			o.Bits = 4096
+		case KeyTypeECDSA:
+			o.Bits = 256
+		}
+	}
+
+	switch o.Type {
+	case KeyTypeRSA:
+		if o.Bits < 2048 {
+			return fmt.Errorf("rsa keys must have at least 2048 bits")
+		}
+	case KeyTypeECDSA:
+		if o.Bits != 256 && o.Bits != 384 && o.Bits != 521 {
+			return fmt.Errorf("ecdsa keys must have 256, 384 or 521 bits")
+		}
+	default:
+		return fmt.Errorf("unsupported key type %q. Expected %q or %q", o.Type, KeyTypeRSA, KeyTypeECDSA)
+	}
+
+	if o.PathToPrivateKey == "" {
+		return errors.New("a path to a private key file must be provided")
+	}
+	if o.PathToPublicKey == "" {
+		return errors.New("a path to a public key file must be provided")
+	}
+	return nil
+}
+
+func (o *KeygenOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Type, "type", KeyTypeRSA, fmt.Sprintf("type of the key pair. Can be %q or %q", KeyTypeRSA, KeyTypeECDSA))
+	fs.IntVar(&o.Bits, "bits", 0, "size of the key. Defaults to 4096 for rsa and 256 for ecdsa keys (ecdsa supports 256, 384 and 521)")
+	fs.StringVar(&o.PathToPrivateKey, "private-key", "private.key", "path where the private key is written to")
+	fs.StringVar(&o.PathToPublicKey, "public-key", "public.key", "path where the public key is written to")
+	fs.StringVar(&o.PathToPassphrase, "passphrase-file", "", "path to a file that contains a passphrase to encrypt the private key")
+}
@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signature_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/signature"
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/signature/sign"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/signatures"
+)
+
+var _ = Describe("Keygen", func() {
+
+	It("should generate a rsa key pair that can be used to sign and verify component descriptors", func() {
+		dir, err := os.MkdirTemp("", "keygen-")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		fs := osfs.New()
+
+		opts := &signature.KeygenOptions{
+			Type:             signature.KeyTypeRSA,
+			Bits:             2048,
+			PathToPrivateKey: dir + "/private.key",
+			PathToPublicKey:  dir + "/public.key",
+		}
+		Expect(opts.Complete(nil)).To(Succeed())
+		fingerprint, err := opts.Run(context.TODO(), fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fingerprint).To(HavePrefix("sha256:"))
+
+		signer, err := cdv2Sign.CreateRSASignerFromKeyFile(opts.PathToPrivateKey, cdv2.MediaTypePEM)
+		Expect(err).ToNot(HaveOccurred())
+		verifier, err := cdv2Sign.CreateRSAVerifierFromKeyFile(opts.PathToPublicKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		cd := cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/component"
+		cd.Version = "v0.1.0"
+		Expect(cdv2Sign.SignComponentDescriptor(&cd, signer, *hasher, "test")).To(Succeed())
+		Expect(cdv2Sign.VerifySignedComponentDescriptor(&cd, verifier, "test")).To(Succeed())
+	})
+
+	It("should generate an encrypted ecdsa private key", func() {
+		fs := memoryfs.New()
+		Expect(vfs.WriteFile(fs, "passphrase", []byte("my-passphrase\n"), os.ModePerm)).To(Succeed())
+
+		opts := &signature.KeygenOptions{
+			Type:             signature.KeyTypeECDSA,
+			PathToPrivateKey: "private.key",
+			PathToPublicKey:  "public.key",
+			PathToPassphrase: "passphrase",
+		}
+		Expect(opts.Complete(nil)).To(Succeed())
+		Expect(opts.Bits).To(Equal(256))
+		_, err := opts.Run(context.TODO(), fs)
+		Expect(err).ToNot(HaveOccurred())
+
+		data, err := vfs.ReadFile(fs, "private.key")
+		Expect(err).ToNot(HaveOccurred())
+		block, _ := pem.Decode(data)
+		Expect(block).ToNot(BeNil())
+		Expect(block.Type).To(Equal(signatures.EncryptedPrivateKeyPEMType))
+		Expect(x509.IsEncryptedPEMBlock(block)).To(BeFalse())
+
+		_, err = signatures.LoadPrivateKey(data, nil)
+		Expect(err).To(HaveOccurred())
+		_, err = signatures.LoadPrivateKey(data, []byte("other-passphrase"))
+		Expect(err).To(HaveOccurred())
+		key, err := signatures.LoadPrivateKey(data, []byte("my-passphrase"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(key).To(BeAssignableToTypeOf(&ecdsa.PrivateKey{}))
+		publicKey, err := signatures.LoadPublicKey(mustReadFile(fs, "public.key"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(key.Public()).To(Equal(publicKey))
+
+		// existing keys must not be overwritten
+		_, err = opts.Run(context.TODO(), fs)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("rsa sign", func() {
+
+		var (
+			dir         string
+			registry    *httptest.Server
+			registryURL string
+			archivePath string
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "keygen-")
+			Expect(err).ToNot(HaveOccurred())
+			registry = newFakeRegistry(map[string][]byte{})
+			registryURL = strings.TrimPrefix(registry.URL, "http://")
+
+			archivePath = filepath.Join(dir, "archive")
+			Expect(os.MkdirAll(archivePath, os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(archivePath, ctf.ComponentDescriptorFileName), []byte(fmt.Sprintf(`
+meta:
+  schemaVersion: v2
+component:
+  name: example.com/component
+  version: v0.1.0
+  provider: internal
+  repositoryContexts:
+  - type: ociRegistry
+    baseUrl: %s
+  sources: []
+  componentReferences: []
+  resources: []
+`, registryURL)), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "passphrase"), []byte("my-passphrase"), os.ModePerm)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			registry.Close()
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		newRSASignOptions := func(pathToPrivateKey string) *sign.RSASignOptions {
+			opts := &sign.RSASignOptions{
+				PathToPrivateKey: pathToPrivateKey,
+				PathToPassphrase: filepath.Join(dir, "passphrase"),
+			}
+			opts.SignatureName = "test"
+			opts.UploadBaseUrlForSigned = registryURL
+			opts.OciOptions.AllowPlainHttp = true
+			return opts
+		}
+
+		It("should sign a component descriptor with an encrypted rsa key generated by keygen", func() {
+			keygenOpts := &signature.KeygenOptions{
+				Type:             signature.KeyTypeRSA,
+				Bits:             2048,
+				PathToPrivateKey: filepath.Join(dir, "private.key"),
+				PathToPublicKey:  filepath.Join(dir, "public.key"),
+				PathToPassphrase: filepath.Join(dir, "passphrase"),
+			}
+			Expect(keygenOpts.Complete(nil)).To(Succeed())
+			_, err := keygenOpts.Run(context.TODO(), osfs.New())
+			Expect(err).ToNot(HaveOccurred())
+
+			signOpts := newRSASignOptions(keygenOpts.PathToPrivateKey)
+			Expect(signOpts.Complete([]string{archivePath})).To(Succeed())
+			Expect(signOpts.Run(context.TODO(), logr.Discard(), osfs.New())).To(Succeed())
+
+			client, err := ociclient.NewClient(logr.Discard(), ociclient.AllowPlainHttp(true))
+			Expect(err).ToNot(HaveOccurred())
+			cd, err := schema.NewResolver(client).Resolve(context.TODO(), cdv2.NewOCIRegistryRepository(registryURL, ""), "example.com/component", "v0.1.0")
+			Expect(err).ToNot(HaveOccurred())
+			verifier, err := cdv2Sign.CreateRSAVerifierFromKeyFile(keygenOpts.PathToPublicKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cdv2Sign.VerifySignedComponentDescriptor(cd, verifier, "test")).To(Succeed())
+		})
+
+		It("should reject ecdsa keys generated by keygen", func() {
+			keygenOpts := &signature.KeygenOptions{
+				Type:             signature.KeyTypeECDSA,
+				PathToPrivateKey: filepath.Join(dir, "private.key"),
+				PathToPublicKey:  filepath.Join(dir, "public.key"),
+				PathToPassphrase: filepath.Join(dir, "passphrase"),
+			}
+			Expect(keygenOpts.Complete(nil)).To(Succeed())
+			_, err := keygenOpts.Run(context.TODO(), osfs.New())
+			Expect(err).ToNot(HaveOccurred())
+
+			signOpts := newRSASignOptions(keygenOpts.PathToPrivateKey)
+			Expect(signOpts.Complete([]string{archivePath})).To(Succeed())
+			err = signOpts.Run(context.TODO(), logr.Discard(), osfs.New())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cosign"))
+		})
+
+	})
+
+})
+
+func mustReadFile(fs vfs.FileSystem, path string) []byte {
+	data, err := vfs.ReadFile(fs, path)
+	Expect(err).ToNot(HaveOccurred())
+	return data
+}
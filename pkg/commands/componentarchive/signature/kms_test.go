@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signature_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/signature"
+	"github.com/gardener/component-cli/pkg/signatures"
+)
+
+// newFakeVault creates a vault server with a transit secrets engine that contains the rsa key "mykey".
+func newFakeVault(key *rsa.PrivateKey) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		Expect(req.Header.Get("X-Vault-Token")).To(Equal("my-token"))
+		w.Header().Set("Content-Type", "application/json")
+		switch req.URL.Path {
+		case "/v1/transit/keys/mykey":
+			publicKey, err := signatures.MarshalPublicKeyPEM(&key.PublicKey)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"latest_version": 1,
+					"keys": map[string]interface{}{
+						"1": map[string]interface{}{"public_key": string(publicKey)},
+					},
+				},
+			})).To(Succeed())
+		case "/v1/transit/sign/mykey/sha2-256":
+			signReq := struct {
+				Input              string `json:"input"`
+				Prehashed          bool   `json:"prehashed"`
+				SignatureAlgorithm string `json:"signature_algorithm"`
+			}{}
+			Expect(json.NewDecoder(req.Body).Decode(&signReq)).To(Succeed())
+			Expect(signReq.Prehashed).To(BeTrue())
+			Expect(signReq.SignatureAlgorithm).To(Equal("pkcs1v15"))
+			hash, err := base64.StdEncoding.DecodeString(signReq.Input)
+			Expect(err).ToNot(HaveOccurred())
+			sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"signature": "vault:v1:" + base64.StdEncoding.EncodeToString(sig)},
+			})).To(Succeed())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+var _ = Describe("KMS", func() {
+
+	var (
+		key   *rsa.PrivateKey
+		vault *httptest.Server
+	)
+
+	BeforeEach(func() {
+		var err error
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		vault = newFakeVault(key)
+		Expect(os.Setenv(signatures.VaultAddrEnvName, vault.URL)).To(Succeed())
+		Expect(os.Setenv(signatures.VaultTokenEnvName, "my-token")).To(Succeed())
+	})
+
+	AfterEach(func() {
+		vault.Close()
+		Expect(os.Unsetenv(signatures.VaultAddrEnvName)).To(Succeed())
+		Expect(os.Unsetenv(signatures.VaultTokenEnvName)).To(Succeed())
+	})
+
+	It("should sign a component descriptor with a vault key", func() {
+		kmsSigner, err := signatures.NewKMSSigner(context.TODO(), "hashivault://mykey")
+		Expect(err).ToNot(HaveOccurred())
+		signer, err := signatures.NewCryptoSigner(kmsSigner, cdv2.MediaTypePEM)
+		Expect(err).ToNot(HaveOccurred())
+		verifier, err := cdv2Sign.CreateRSAVerifier(&key.PublicKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		cd := cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/component"
+		cd.Version = "v0.1.0"
+		Expect(cdv2Sign.SignComponentDescriptor(&cd, signer, *hasher, "test")).To(Succeed())
+		Expect(cdv2Sign.VerifySignedComponentDescriptor(&cd, verifier, "test")).To(Succeed())
+	})
+
+	It("should create cosign signatures with a vault key", func() {
+		dir, err := ioutil.TempDir("", "kms-")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		fs := osfs.New()
+
+		cdManifest := []byte(`{"schemaVersion":2,"config":{"mediaType":"application/vnd.gardener.cloud.cnudie.component.config.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`)
+		registry := newFakeRegistry(map[string][]byte{
+			"/v2/component-descriptors/example.com/component/manifests/v0.1.0":                                   cdManifest,
+			"/v2/component-descriptors/example.com/component/manifests/" + digest.FromBytes(cdManifest).String(): cdManifest,
+		})
+		defer registry.Close()
+		registryURL, err := url.Parse(registry.URL)
+		Expect(err).ToNot(HaveOccurred())
+
+		signOpts := &signature.CosignSignOptions{
+			KeyURI: "hashivault://mykey",
+		}
+		signOpts.OciOptions.AllowPlainHttp = true
+		signOpts.OciOptions.CacheDir = filepath.Join(dir, "cache")
+		Expect(signOpts.Complete([]string{registryURL.Host, "example.com/component", "v0.1.0"})).To(Succeed())
+		Expect(signOpts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+
+		writePublicKey(fs, filepath.Join(dir, "public.key"), &key.PublicKey)
+		verifyOpts := &signature.CosignVerifyOptions{
+			PathToPublicKey: filepath.Join(dir, "public.key"),
+		}
+		verifyOpts.OciOptions.AllowPlainHttp = true
+		verifyOpts.OciOptions.CacheDir = filepath.Join(dir, "cache")
+		Expect(verifyOpts.Complete([]string{registryURL.Host, "example.com/component", "v0.1.0"})).To(Succeed())
+		Expect(verifyOpts.Run(context.TODO(), logr.Discard(), fs)).To(Succeed())
+	})
+
+	It("should fail for kms providers that are not registered", func() {
+		_, err := signatures.NewKMSSigner(context.TODO(), "unknownkms://foo")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(signatures.AWSKMSScheme))
+	})
+
+})
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signature_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/signature/sign"
+	"github.com/gardener/component-cli/pkg/signatures"
+)
+
+var _ = Describe("PKCS11", func() {
+
+	var (
+		dir        string
+		modulePath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "pkcs11-")
+		Expect(err).ToNot(HaveOccurred())
+		modulePath = filepath.Join(dir, "libpkcs11.so")
+		Expect(ioutil.WriteFile(modulePath, []byte{}, 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+		Expect(os.Unsetenv(signatures.PKCS11PinEnvName)).To(Succeed())
+	})
+
+	newRSASignOptions := func() *sign.RSASignOptions {
+		opts := &sign.RSASignOptions{}
+		opts.SignatureName = "test"
+		opts.UploadBaseUrlForSigned = "example.com/signed"
+		return opts
+	}
+
+	It("should validate the pkcs#11 flags of the rsa sign command", func() {
+		opts := newRSASignOptions()
+		opts.PKCS11.ModulePath = modulePath
+		Expect(opts.Complete([]string{dir})).ToNot(Succeed())
+
+		Expect(os.Setenv(signatures.PKCS11PinEnvName, "123456")).To(Succeed())
+		Expect(opts.Complete([]string{dir})).To(Succeed())
+		Expect(opts.PKCS11.Pin).To(Equal("123456"))
+
+		opts.PathToPrivateKey = filepath.Join(dir, "private.key")
+		Expect(opts.Complete([]string{dir})).ToNot(Succeed())
+
+		opts = newRSASignOptions()
+		opts.PKCS11.ModulePath = filepath.Join(dir, "unknown.so")
+		opts.PKCS11.Pin = "123456"
+		Expect(opts.Complete([]string{dir})).ToNot(Succeed())
+	})
+
+	It("should sign a component descriptor with a key of the registered pkcs#11 provider", func() {
+		config := signatures.PKCS11Config{
+			ModulePath: modulePath,
+			Slot:       1,
+			Pin:        "123456",
+			KeyLabel:   "signing-key",
+		}
+		// the empty module cannot be loaded
+		_, err := signatures.NewPKCS11Signer(context.TODO(), config)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("pkcs#11 module"))
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(signatures.RegisterPKCS11Provider(func(_ context.Context, c signatures.PKCS11Config) (crypto.Signer, error) {
+			Expect(c).To(Equal(config))
+			return key, nil
+		})).To(Succeed())
+
+		pkcs11Signer, err := signatures.NewPKCS11Signer(context.TODO(), config)
+		Expect(err).ToNot(HaveOccurred())
+		signer, err := signatures.NewCryptoSigner(pkcs11Signer, cdv2.MediaTypePEM)
+		Expect(err).ToNot(HaveOccurred())
+		verifier, err := cdv2Sign.CreateRSAVerifier(&key.PublicKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		cd := cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/component"
+		cd.Version = "v0.1.0"
+		Expect(cdv2Sign.SignComponentDescriptor(&cd, signer, *hasher, "test")).To(Succeed())
+		Expect(cdv2Sign.VerifySignedComponentDescriptor(&cd, verifier, "test")).To(Succeed())
+	})
+
+})
@@ -0,0 +1,210 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signature_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/signatures"
+)
+
+// policyResolver resolves component descriptors from a map of "name:version" to component descriptor.
+type policyResolver map[string]*cdv2.ComponentDescriptor
+
+func (r policyResolver) Resolve(_ context.Context, _ cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	cd, ok := r[name+":"+version]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return cd, nil
+}
+
+func (r policyResolver) ResolveWithBlobResolver(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	cd, err := r.Resolve(ctx, repoCtx, name, version)
+	return cd, nil, err
+}
+
+var _ = Describe("VerificationPolicy", func() {
+
+	var (
+		fs   vfs.FileSystem
+		keys map[string]*rsa.PrivateKey
+	)
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(fs.MkdirAll("/policy/keys", 0755)).To(Succeed())
+		keys = map[string]*rsa.PrivateKey{}
+		for _, name := range []string{"release-1", "release-2", "security", "qa"} {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).ToNot(HaveOccurred())
+			keys[name] = key
+			writePublicKey(fs, "/policy/keys/"+name+".pub", &key.PublicKey)
+		}
+	})
+
+	newComponent := func(name, baseURL string, refs ...string) *cdv2.ComponentDescriptor {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Name = name
+		cd.Version = "v0.1.0"
+		Expect(cdv2.InjectRepositoryContext(cd, cdv2.NewOCIRegistryRepository(baseURL, ""))).To(Succeed())
+		for _, ref := range refs {
+			cd.ComponentReferences = append(cd.ComponentReferences, cdv2.ComponentReference{
+				Name:          ref,
+				ComponentName: ref,
+				Version:       "v0.1.0",
+			})
+		}
+		return cd
+	}
+
+	sign := func(cd *cdv2.ComponentDescriptor, signatureName string, key *rsa.PrivateKey) {
+		signer, err := signatures.NewCryptoSigner(key, cdv2.MediaTypePEM)
+		Expect(err).ToNot(HaveOccurred())
+		hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cdv2Sign.SignComponentDescriptor(cd, signer, *hasher, signatureName)).To(Succeed())
+	}
+
+	writePolicy := func(policy string) *signatures.VerificationPolicy {
+		Expect(vfs.WriteFile(fs, "/policy/policy.yaml", []byte(policy), 0644)).To(Succeed())
+		p, err := signatures.LoadVerificationPolicy(fs, "/policy/policy.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		return p
+	}
+
+	It("should require the configured number of signatures with allowed keys", func() {
+		policy := writePolicy(`
+requiredSignatures: 2
+signatures:
+- name: release
+  publicKeys:
+  - keys/release-1.pub
+  - keys/release-2.pub
+- name: security
+  publicKeys:
+  - keys/security.pub
+- name: qa
+  publicKeys:
+  - /policy/keys/qa.pub
+`)
+		cd := newComponent("example.com/a", "example.com/components")
+		sign(cd, "release", keys["release-2"])
+		valid, err := policy.VerifySignatures(cd)
+		Expect(err).To(HaveOccurred())
+		Expect(valid).To(ConsistOf("release"))
+
+		// the security signature is created with a key that is not allowed for it
+		sign(cd, "security", keys["qa"])
+		_, err = policy.VerifySignatures(cd)
+		Expect(err).To(HaveOccurred())
+
+		sign(cd, "qa", keys["qa"])
+		valid, err = policy.VerifySignatures(cd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(valid).To(ConsistOf("release", "qa"))
+	})
+
+	It("should reject invalid policies", func() {
+		for _, policy := range []string{
+			`signatures: []`,
+			"signatures:\n- name: release\n  publicKeys: []",
+			"requiredSignatures: 2\nsignatures:\n- name: release\n  publicKeys: [keys/qa.pub]",
+			"signatures:\n- name: release\n  publicKeys: [keys/unknown.pub]",
+			"signatures:\n- name: release\n  publicKey: keys/qa.pub",
+		} {
+			Expect(vfs.WriteFile(fs, "/policy/policy.yaml", []byte(policy), 0644)).To(Succeed())
+			_, err := signatures.LoadVerificationPolicy(fs, "/policy/policy.yaml")
+			Expect(err).To(HaveOccurred(), policy)
+		}
+	})
+
+	It("should restrict the repositories of transitively referenced components", func() {
+		policy := writePolicy(`
+signatures:
+- name: release
+  publicKeys:
+  - keys/release-1.pub
+allowedRepositories:
+- example.com/components
+- example.com/partners/*
+`)
+		root := newComponent("example.com/root", "example.com/components", "example.com/a")
+		resolver := policyResolver{
+			"example.com/a:v0.1.0": newComponent("example.com/a", "example.com/partners/a", "example.com/b"),
+			"example.com/b:v0.1.0": newComponent("example.com/b", "example.com/components"),
+		}
+		Expect(policy.CheckRepositories(context.TODO(), resolver, root)).To(Succeed())
+
+		resolver["example.com/b:v0.1.0"] = newComponent("example.com/b", "evil.example.com/components")
+		err := policy.CheckRepositories(context.TODO(), resolver, root)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("evil.example.com/components"))
+	})
+
+	It("should enforce the validity period and audience of signing contexts", func() {
+		policy := writePolicy(`
+signatures:
+- name: release
+  publicKeys:
+  - keys/release-1.pub
+signingContext:
+  audience: live
+`)
+		addSigningContext := func(cd *cdv2.ComponentDescriptor, claims signatures.SigningClaims) {
+			signer, err := signatures.NewCryptoSigner(keys["release-1"], cdv2.MediaTypePEM)
+			Expect(err).ToNot(HaveOccurred())
+			hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signatures.AddSigningContext(cd, signer, *hasher, "release", claims)).To(Succeed())
+		}
+		past := time.Now().Add(-time.Hour)
+		future := time.Now().Add(time.Hour)
+
+		cd := newComponent("example.com/a", "example.com/components")
+		sign(cd, "release", keys["release-1"])
+		_, err := policy.VerifySignatures(cd)
+		Expect(err).To(HaveOccurred(), "the signing context is required by the audience")
+
+		addSigningContext(cd, signatures.SigningClaims{NotBefore: &past, ExpiresAt: &future, Audience: []string{"canary", "live"}})
+		valid, err := policy.VerifySignatures(cd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(valid).To(ConsistOf("release"))
+
+		addSigningContext(cd, signatures.SigningClaims{ExpiresAt: &past, Audience: []string{"live"}})
+		_, err = policy.VerifySignatures(cd)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("expired"))
+
+		addSigningContext(cd, signatures.SigningClaims{Audience: []string{"canary"}})
+		_, err = policy.VerifySignatures(cd)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("audience"))
+
+		// modifications of the claims are detected
+		signingCtx, err := signatures.GetSigningContext(cd, "release")
+		Expect(err).ToNot(HaveOccurred())
+		signingCtx.Context.Audience = []string{"live"}
+		value, err := json.Marshal([]signatures.SignedSigningContext{*signingCtx})
+		Expect(err).ToNot(HaveOccurred())
+		cd.Labels[0].Value = value
+		_, err = policy.VerifySignatures(cd)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("signing context does not match"))
+	})
+
+})
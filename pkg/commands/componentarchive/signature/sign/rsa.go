@@ -5,12 +5,15 @@ package sign
 
 import (
 	"context"
+	"crypto"
+	"crypto/rsa"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
-	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
 
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/osfs"
@@ -19,11 +22,18 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/signatures"
 )
 
 type RSASignOptions struct {
 	// PathToPrivateKey for RSA signing
 	PathToPrivateKey string
+	// PathToPassphrase is the path to a file that contains the passphrase of an encrypted private key.
+	PathToPassphrase string
+	// KeyURI references a rsa key in a kms (e.g. hashivault://mykey) that is used instead of a local private key.
+	KeyURI string
+	// PKCS11 references a rsa key on a pkcs#11 token (e.g. a YubiKey or a HSM) that is used instead of a local private key.
+	PKCS11 signatures.PKCS11Config
 
 	GenericSignOptions
 }
@@ -53,20 +63,94 @@ func NewRSASignCommand(ctx context.Context) *cobra.Command {
 }
 
 func (o *RSASignOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
-	signer, err := cdv2Sign.CreateRSASignerFromKeyFile(o.PathToPrivateKey, cdv2.MediaTypePEM)
+	if len(o.PKCS11.ModulePath) != 0 {
+		pkcs11Signer, err := signatures.NewPKCS11Signer(ctx, o.PKCS11)
+		if err != nil {
+			return fmt.Errorf("unable to create pkcs#11 signer: %w", err)
+		}
+		if closer, ok := pkcs11Signer.(io.Closer); ok {
+			defer closer.Close()
+		}
+		signer, err := signatures.NewCryptoSigner(pkcs11Signer, cdv2.MediaTypePEM)
+		if err != nil {
+			return fmt.Errorf("unable to create rsa signer: %w", err)
+		}
+		return o.SignAndUploadWithSigner(ctx, log, fs, signer)
+	}
+	if len(o.KeyURI) != 0 {
+		kmsSigner, err := signatures.NewKMSSigner(ctx, o.KeyURI)
+		if err != nil {
+			return fmt.Errorf("unable to create kms signer: %w", err)
+		}
+		signer, err := signatures.NewCryptoSigner(kmsSigner, cdv2.MediaTypePEM)
+		if err != nil {
+			return fmt.Errorf("unable to create rsa signer: %w", err)
+		}
+		return o.SignAndUploadWithSigner(ctx, log, fs, signer)
+	}
+
+	privateKey, err := o.loadPrivateKey(fs)
+	if err != nil {
+		return err
+	}
+	if _, ok := privateKey.(*rsa.PrivateKey); !ok {
+		return fmt.Errorf("unsupported private key type %T: component descriptors can only be signed with rsa keys, other keys can be used to create cosign signatures", privateKey)
+	}
+	signer, err := signatures.NewCryptoSigner(privateKey, cdv2.MediaTypePEM)
 	if err != nil {
 		return fmt.Errorf("unable to create rsa signer: %w", err)
 	}
 	return o.SignAndUploadWithSigner(ctx, log, fs, signer)
 }
 
+// loadPrivateKey reads the private key file, which is decrypted with the passphrase if it is encrypted.
+func (o *RSASignOptions) loadPrivateKey(fs vfs.FileSystem) (crypto.Signer, error) {
+	data, err := vfs.ReadFile(fs, o.PathToPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read private key: %w", err)
+	}
+	var passphrase []byte
+	if len(o.PathToPassphrase) != 0 {
+		passphrase, err = vfs.ReadFile(fs, o.PathToPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read passphrase: %w", err)
+		}
+		passphrase = []byte(strings.TrimSpace(string(passphrase)))
+	}
+	privateKey, err := signatures.LoadPrivateKey(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load private key: %w", err)
+	}
+	return privateKey, nil
+}
+
 func (o *RSASignOptions) Complete(args []string) error {
 	if err := o.GenericSignOptions.Complete(args); err != nil {
 		return err
 	}
 
-	if o.PathToPrivateKey == "" {
-		return errors.New("a path to a private key file must be provided")
+	keySources := 0
+	for _, source := range []string{o.PathToPrivateKey, o.KeyURI, o.PKCS11.ModulePath} {
+		if source != "" {
+			keySources++
+		}
+	}
+	if keySources == 0 {
+		return errors.New("a path to a private key file, a key uri or a pkcs#11 module must be provided")
+	}
+	if keySources > 1 {
+		return errors.New("only one of a private key file, a key uri or a pkcs#11 module can be provided")
+	}
+	if o.PathToPassphrase != "" && o.PathToPrivateKey == "" {
+		return errors.New("a passphrase file can only be used with a private key file")
+	}
+	if o.PKCS11.ModulePath != "" {
+		if o.PKCS11.Pin == "" {
+			o.PKCS11.Pin = os.Getenv(signatures.PKCS11PinEnvName)
+		}
+		if err := o.PKCS11.Validate(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -75,4 +159,10 @@ func (o *RSASignOptions) Complete(args []string) error {
 func (o *RSASignOptions) AddFlags(fs *pflag.FlagSet) {
 	o.GenericSignOptions.AddFlags(fs)
 	fs.StringVar(&o.PathToPrivateKey, "private-key", "", "path to private key file used for signing")
+	fs.StringVar(&o.PathToPassphrase, "passphrase-file", "", "path to a file that contains the passphrase of an encrypted private key")
+	fs.StringVar(&o.KeyURI, "key-uri", "", fmt.Sprintf("uri of a rsa key in a kms that is used for signing instead of a private key file, e.g. hashivault://mykey. Supported schemes: %s", strings.Join(signatures.KMSSchemes(), ", ")))
+	fs.StringVar(&o.PKCS11.ModulePath, "pkcs11-module", "", "path to the pkcs#11 module of a hardware token (e.g. a YubiKey or a HSM) whose rsa key is used for signing instead of a private key file. Requires a component-cli that is built with cgo")
+	fs.UintVar(&o.PKCS11.Slot, "pkcs11-slot", 0, "id of the slot of the pkcs#11 token")
+	fs.StringVar(&o.PKCS11.Pin, "pkcs11-pin", "", fmt.Sprintf("user pin of the pkcs#11 token. Defaults to the %s environment variable", signatures.PKCS11PinEnvName))
+	fs.StringVar(&o.PKCS11.KeyLabel, "pkcs11-key-label", "", "[OPTIONAL] label of the private key on the pkcs#11 token. Required if the token contains more than one private key")
 }
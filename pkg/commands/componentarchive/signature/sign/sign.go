@@ -9,11 +9,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
 	"github.com/gardener/component-spec/bindings-go/ctf"
-	cdoci "github.com/gardener/component-spec/bindings-go/oci"
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	"github.com/spf13/cobra"
@@ -23,7 +23,7 @@ import (
 	"github.com/gardener/component-cli/pkg/commands/constants"
 	"github.com/gardener/component-cli/pkg/componentarchive"
 	"github.com/gardener/component-cli/pkg/components"
-	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/components/schema"
 	"github.com/gardener/component-cli/pkg/signatures"
 )
 
@@ -64,6 +64,13 @@ type GenericSignOptions struct {
 	// SkipAccessTypes defines the access types that will be ignored for signing
 	SkipAccessTypes []string
 
+	// NotBefore is the RFC3339 time from which on the signature is valid.
+	NotBefore string
+	// ValidFor is the validity period of the signature starting at NotBefore or at the time of signing.
+	ValidFor time.Duration
+	// Audience are the intended audiences of the signature, e.g. landscapes.
+	Audience []string
+
 	// OciOptions contains all exposed options to configure the oci client.
 	OciOptions ociopts.Options
 }
@@ -107,7 +114,59 @@ func (o *GenericSignOptions) Complete(args []string) error {
 	if o.SignatureName == "" {
 		return errors.New("a signature name must be provided")
 	}
+	if o.ValidFor < 0 {
+		return errors.New("the validity period must not be negative")
+	}
+	if o.NotBefore != "" {
+		if _, err := time.Parse(time.RFC3339, o.NotBefore); err != nil {
+			return fmt.Errorf("invalid not before time %q: %w", o.NotBefore, err)
+		}
+	}
+
+	return nil
+}
+
+// signingClaims returns the claims of the signing context of the signatures.
+// Nil is returned if no claims are configured.
+func (o *GenericSignOptions) signingClaims(now time.Time) (*signatures.SigningClaims, error) {
+	if o.NotBefore == "" && o.ValidFor == 0 && len(o.Audience) == 0 {
+		return nil, nil
+	}
+	claims := &signatures.SigningClaims{
+		Audience: o.Audience,
+	}
+	start := now.UTC().Truncate(time.Second)
+	if o.NotBefore != "" {
+		notBefore, err := time.Parse(time.RFC3339, o.NotBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid not before time %q: %w", o.NotBefore, err)
+		}
+		start = notBefore.UTC()
+		claims.NotBefore = &start
+	}
+	if o.ValidFor != 0 {
+		expiresAt := start.Add(o.ValidFor)
+		claims.ExpiresAt = &expiresAt
+	}
+	return claims, nil
+}
+
+// sign signs the component descriptor and adds the signing context if claims are configured.
+func (o *GenericSignOptions) sign(cd *cdv2.ComponentDescriptor, signer cdv2Sign.Signer, claims *signatures.SigningClaims) error {
+	hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+	if err != nil {
+		return fmt.Errorf("unable to create hasher: %w", err)
+	}
 
+	if err := cdv2Sign.SignComponentDescriptor(cd, signer, *hasher, o.SignatureName); err != nil {
+		return fmt.Errorf("unable to sign component descriptor: %w", err)
+	}
+	if claims == nil {
+		return nil
+	}
+	if err := signatures.AddSigningContext(cd, signer, *hasher, o.SignatureName, *claims); err != nil {
+		return fmt.Errorf("unable to add signing context: %w", err)
+	}
 	return nil
 }
 
@@ -117,6 +176,9 @@ func (o *GenericSignOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&o.SkipAccessTypes, "skip-access-types", []string{}, "[OPTIONAL] comma separated list of access types that will not be digested and signed")
 	fs.BoolVar(&o.Force, "force", false, "[OPTIONAL] force overwrite of already existing component descriptors")
 	fs.BoolVar(&o.RecursiveSigning, "recursive", false, "[OPTIONAL] recursively sign and upload all referenced component descriptors")
+	fs.StringVar(&o.NotBefore, "not-before", "", "[OPTIONAL] RFC3339 time from which on the signature is valid")
+	fs.DurationVar(&o.ValidFor, "valid-for", 0, "[OPTIONAL] validity period of the signature starting at the not before time or now, e.g. 720h")
+	fs.StringSliceVar(&o.Audience, "audience", []string{}, "[OPTIONAL] comma separated list of the intended audiences of the signature, e.g. landscapes")
 	o.OciOptions.AddFlags(fs)
 }
 
@@ -143,7 +205,7 @@ func (o *GenericSignOptions) SignAndUploadWithSigner(ctx context.Context, log lo
 		repoCtx = &_repoCtx
 	} else {
 		repoCtx = cdv2.NewOCIRegistryRepository(o.BaseUrl, "")
-		cdresolver := cdoci.NewResolver(ociClient)
+		cdresolver := schema.NewResolver(ociClient)
 		_cd, _blobResolver, err := cdresolver.ResolveWithBlobResolver(ctx, repoCtx, o.ComponentName, o.Version)
 		if err != nil {
 			return fmt.Errorf("unable to to fetch component descriptor %s:%s: %w", o.ComponentName, o.Version, err)
@@ -172,36 +234,31 @@ func (o *GenericSignOptions) SignAndUploadWithSigner(ctx context.Context, log lo
 
 	targetRepoCtx := cdv2.NewOCIRegistryRepository(o.UploadBaseUrlForSigned, "")
 
+	claims, err := o.signingClaims(time.Now())
+	if err != nil {
+		return err
+	}
+
 	if o.RecursiveSigning {
 		for _, digestedCd := range digestedCds {
-			hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
-			if err != nil {
-				return fmt.Errorf("unable to create hasher: %w", err)
-			}
-
-			if err := cdv2Sign.SignComponentDescriptor(digestedCd, signer, *hasher, o.SignatureName); err != nil {
-				return fmt.Errorf("unable to sign component descriptor: %w", err)
+			if err := o.sign(digestedCd, signer, claims); err != nil {
+				return err
 			}
-			logger.Log.Info(fmt.Sprintf("Signed component descriptor %s %s", digestedCd.Name, digestedCd.Version))
+			log.Info(fmt.Sprintf("Signed component descriptor %s %s", digestedCd.Name, digestedCd.Version))
 
-			logger.Log.Info(fmt.Sprintf("Uploading to %s %s %s", o.UploadBaseUrlForSigned, digestedCd.Name, digestedCd.Version))
+			log.Info(fmt.Sprintf("Uploading to %s %s %s", o.UploadBaseUrlForSigned, digestedCd.Name, digestedCd.Version))
 
 			if err := signatures.UploadCDPreservingLocalOciBlobs(ctx, *digestedCd, *targetRepoCtx, ociClient, cache, blobResolvers, o.Force, log); err != nil {
 				return fmt.Errorf("unable to upload component descriptor: %w", err)
 			}
 		}
 	} else {
-		hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
-		if err != nil {
-			return fmt.Errorf("unable to create hasher: %w", err)
-		}
-
-		if err := cdv2Sign.SignComponentDescriptor(&cd, signer, *hasher, o.SignatureName); err != nil {
-			return fmt.Errorf("unable to sign component descriptor: %w", err)
+		if err := o.sign(&cd, signer, claims); err != nil {
+			return err
 		}
-		logger.Log.Info(fmt.Sprintf("Signed component descriptor %s %s", cd.Name, cd.Version))
+		log.Info(fmt.Sprintf("Signed component descriptor %s %s", cd.Name, cd.Version))
 
-		logger.Log.Info(fmt.Sprintf("Uploading to %s %s %s", o.UploadBaseUrlForSigned, cd.Name, cd.Version))
+		log.Info(fmt.Sprintf("Uploading to %s %s %s", o.UploadBaseUrlForSigned, cd.Name, cd.Version))
 
 		if err := signatures.UploadCDPreservingLocalOciBlobs(ctx, cd, *targetRepoCtx, ociClient, cache, blobResolvers, o.Force, log); err != nil {
 			return fmt.Errorf("unable to upload component descriptor: %w", err)
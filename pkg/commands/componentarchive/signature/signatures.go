@@ -22,6 +22,9 @@ func NewSignaturesCommand(ctx context.Context) *cobra.Command {
 
 	cmd.AddCommand(NewAddDigestsCommand(ctx))
 	cmd.AddCommand(NewCheckDigest(ctx))
+	cmd.AddCommand(NewKeygenCommand(ctx))
+	cmd.AddCommand(NewCosignSignCommand(ctx))
+	cmd.AddCommand(NewCosignVerifyCommand(ctx))
 	cmd.AddCommand(sign.NewSignCommand(ctx))
 	cmd.AddCommand(verify.NewVerifyCommand(ctx))
 
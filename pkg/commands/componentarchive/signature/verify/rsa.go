@@ -18,11 +18,14 @@ import (
 	"github.com/spf13/pflag"
 
 	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/signatures"
 )
 
 type RSAVerifyOptions struct {
 	// PathToPublicKey for RSA verification
 	PathToPublicKey string
+	// PathToPolicy is the path to a verification policy that is used instead of a single public key.
+	PathToPolicy string
 
 	GenericVerifyOptions
 }
@@ -33,6 +36,39 @@ func NewRSAVerifyCommand(ctx context.Context) *cobra.Command {
 		Use:   "rsa BASE_URL COMPONENT_NAME VERSION",
 		Args:  cobra.ExactArgs(3),
 		Short: "fetch the component descriptor from an oci registry and verify its integrity based on a RSASSA-PKCS1-V1_5-SIGN signature",
+		Long: `
+fetch the component descriptor from an oci registry and verify its integrity based on a RSASSA-PKCS1-V1_5-SIGN signature.
+
+Instead of a single signature and public key, a policy file can be provided with "--policy".
+The policy defines the public keys that are allowed for each signature, how many of the signatures must be valid
+the repositories that the component and all transitively referenced components may come from
+and the requirements for the signing contexts of the signatures:
+
+	# number of the signatures that must be valid. Defaults to all signatures.
+	requiredSignatures: 2
+	signatures:
+	- name: release
+	  # paths to PEM encoded public keys, relative to the policy file
+	  publicKeys:
+	  - ./release-1.pub
+	  - ./release-2.pub
+	- name: security
+	  publicKeys:
+	  - ./security.pub
+	- name: qa
+	  publicKeys:
+	  - ./qa.pub
+	# patterns of the allowed repository base urls (see path.Match). All repositories are allowed if empty.
+	allowedRepositories:
+	- eu.gcr.io/gardener-project/*
+	# requirements for the signing contexts that are added with "sign --valid-for/--not-before/--audience".
+	# The validity period of existing signing contexts is always enforced.
+	signingContext:
+	  # reject signatures without signing context
+	  required: true
+	  # audience that must be part of the signing contexts, e.g. the landscape. Implies required.
+	  audience: live
+`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
 				fmt.Println(err.Error())
@@ -52,12 +88,28 @@ func NewRSAVerifyCommand(ctx context.Context) *cobra.Command {
 }
 
 func (o *RSAVerifyOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	if o.PathToPolicy != "" {
+		policy, err := signatures.LoadVerificationPolicy(fs, o.PathToPolicy)
+		if err != nil {
+			return err
+		}
+		if err := o.GenericVerifyOptions.VerifyWithPolicy(ctx, log, fs, policy); err != nil {
+			return fmt.Errorf("unable to verify component descriptor: %w", err)
+		}
+		return nil
+	}
+
 	verifier, err := cdv2Sign.CreateRSAVerifierFromKeyFile(o.PathToPublicKey)
 	if err != nil {
 		return fmt.Errorf("unable to create rsa verifier: %w", err)
 	}
 
-	if err := o.GenericVerifyOptions.VerifyWithVerifier(ctx, log, fs, verifier); err != nil {
+	fingerprint, err := signatures.PublicKeyFileFingerprint(o.PathToPublicKey)
+	if err != nil {
+		return fmt.Errorf("unable to calculate public key fingerprint: %w", err)
+	}
+
+	if err := o.GenericVerifyOptions.VerifyWithVerifier(ctx, log, fs, verifier, fingerprint); err != nil {
 		return fmt.Errorf("unable to verify component descriptor: %w", err)
 	}
 	return nil
@@ -67,8 +119,17 @@ func (o *RSAVerifyOptions) Complete(args []string) error {
 	if err := o.GenericVerifyOptions.Complete(args); err != nil {
 		return err
 	}
+	if o.PathToPolicy != "" {
+		if o.PathToPublicKey != "" || o.SignatureName != "" {
+			return errors.New("a public key and signature name must not be provided together with a policy")
+		}
+		return nil
+	}
+	if err := o.completeSignatureName(); err != nil {
+		return err
+	}
 	if o.PathToPublicKey == "" {
-		return errors.New("a path to a public key file must be provided")
+		return errors.New("a path to a public key file or a policy must be provided")
 	}
 
 	return nil
@@ -77,4 +138,5 @@ func (o *RSAVerifyOptions) Complete(args []string) error {
 func (o *RSAVerifyOptions) AddFlags(fs *pflag.FlagSet) {
 	o.GenericVerifyOptions.AddFlags(fs)
 	fs.StringVar(&o.PathToPublicKey, "public-key", "", "path to public key file")
+	fs.StringVar(&o.PathToPolicy, "policy", "", "path to a verification policy file that defines the required signatures, their public keys and the allowed repositories")
 }
@@ -10,6 +10,8 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"time"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
@@ -20,6 +22,7 @@ import (
 	"github.com/gardener/component-cli/ociclient"
 	ociopts "github.com/gardener/component-cli/ociclient/options"
 	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/components/schema"
 	"github.com/gardener/component-cli/pkg/logger"
 	"github.com/gardener/component-cli/pkg/signatures"
 
@@ -50,6 +53,11 @@ type GenericVerifyOptions struct {
 	// SignatureName selects the matching signature to verify
 	SignatureName string
 
+	// NoCache disables the local cache of successful verifications.
+	NoCache bool
+	// VerificationCacheDir is the directory of the local cache of successful verifications.
+	VerificationCacheDir string
+
 	// OciOptions contains all exposed options to configure the oci client.
 	OciOptions ociopts.Options
 }
@@ -66,6 +74,7 @@ func (o *GenericVerifyOptions) Complete(args []string) error {
 	}
 
 	o.OciOptions.CacheDir = filepath.Join(cliHomeDir, "components")
+	o.VerificationCacheDir = filepath.Join(cliHomeDir, "verifications")
 	if err := os.MkdirAll(o.OciOptions.CacheDir, os.ModePerm); err != nil {
 		return fmt.Errorf("unable to create cache directory %s: %w", o.OciOptions.CacheDir, err)
 	}
@@ -79,6 +88,11 @@ func (o *GenericVerifyOptions) Complete(args []string) error {
 	if len(o.Version) == 0 {
 		return errors.New("a component version must be provided")
 	}
+	return nil
+}
+
+// completeSignatureName validates that a signature name is provided.
+func (o *GenericVerifyOptions) completeSignatureName() error {
 	if o.SignatureName == "" {
 		return errors.New("a signature name must be provided")
 	}
@@ -87,10 +101,15 @@ func (o *GenericVerifyOptions) Complete(args []string) error {
 
 func (o *GenericVerifyOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.SignatureName, "signature-name", "", "name of the signature to verify")
+	fs.BoolVar(&o.NoCache, "no-cache", false, "verify the signature even if the identical signed component descriptor has already been verified with the same key")
 	o.OciOptions.AddFlags(fs)
 }
 
-func (o *GenericVerifyOptions) VerifyWithVerifier(ctx context.Context, log logr.Logger, fs vfs.FileSystem, verifier cdv2Sign.Verifier) error {
+// VerifyWithVerifier verifies the digests and the signature of the component descriptor with the given verifier.
+// Successful signature verifications are cached by the digest of the signed component descriptor, the signature and the fingerprint of the key,
+// so that repeated signature verifications of the identical signed component descriptor are skipped unless the cache is disabled.
+// The digests of the referenced components and resources are recalculated on every verification.
+func (o *GenericVerifyOptions) VerifyWithVerifier(ctx context.Context, log logr.Logger, fs vfs.FileSystem, verifier cdv2Sign.Verifier, keyFingerprint string) error {
 	repoCtx := cdv2.NewOCIRegistryRepository(o.BaseUrl, "")
 
 	ociClient, _, err := o.OciOptions.Build(log, fs)
@@ -98,23 +117,89 @@ func (o *GenericVerifyOptions) VerifyWithVerifier(ctx context.Context, log logr.
 		return fmt.Errorf("unable to build oci client: %s", err.Error())
 	}
 
-	cdresolver := cdoci.NewResolver(ociClient)
+	cdresolver := schema.NewResolver(ociClient)
 	cd, err := cdresolver.Resolve(ctx, repoCtx, o.ComponentName, o.Version)
 	if err != nil {
 		return fmt.Errorf("unable to to fetch component descriptor %s:%s: %w", o.ComponentName, o.Version, err)
 	}
 
 	// check componentReferences and resources
-	if err := CheckCdDigests(cd, *repoCtx, ociClient, context.TODO()); err != nil {
+	if err := CheckCdDigests(cd, *repoCtx, ociClient, ctx); err != nil {
 		return fmt.Errorf("unable to check component descriptor digests: %w", err)
 	}
 
+	var (
+		verificationCache *signatures.VerificationCache
+		cacheKey          string
+	)
+	if !o.NoCache && len(o.VerificationCacheDir) != 0 {
+		verificationCache = signatures.NewVerificationCache(fs, o.VerificationCacheDir)
+		cacheKey, err = signatures.VerificationCacheKey(*cd, o.SignatureName, keyFingerprint)
+		if err != nil {
+			return fmt.Errorf("unable to calculate verification cache key: %w", err)
+		}
+		entry, err := verificationCache.Get(cacheKey)
+		if err != nil {
+			log.Error(err, "unable to read verification cache")
+		} else if entry != nil {
+			log.Info(fmt.Sprintf("Signature %s has already been verified at %s", o.SignatureName, entry.VerifiedAt.Format(time.RFC3339)))
+			return nil
+		}
+	}
+
 	// check if digest is correctly signed and the hash matches the normalised cd
 	if err = cdv2Sign.VerifySignedComponentDescriptor(cd, verifier, o.SignatureName); err != nil {
 		return fmt.Errorf("unable to verify signature: %w", err)
 	}
 
 	log.Info(fmt.Sprintf("Signature %s is valid and calculated digest matches existing digest", o.SignatureName))
+
+	if verificationCache != nil {
+		entry := signatures.VerificationCacheEntry{
+			ComponentName: o.ComponentName,
+			Version:       o.Version,
+			SignatureName: o.SignatureName,
+			VerifiedAt:    time.Now(),
+		}
+		if err := verificationCache.Add(cacheKey, entry); err != nil {
+			log.Error(err, "unable to write verification cache")
+		}
+	}
+	return nil
+}
+
+// VerifyWithPolicy verifies the signatures of the component descriptor with the public keys of the policy
+// and checks that the component and all transitively referenced components come from repositories that are allowed by the policy.
+// Verifications with a policy are not cached.
+func (o *GenericVerifyOptions) VerifyWithPolicy(ctx context.Context, log logr.Logger, fs vfs.FileSystem, policy *signatures.VerificationPolicy) error {
+	repoCtx := cdv2.NewOCIRegistryRepository(o.BaseUrl, "")
+
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+
+	cdresolver := schema.NewResolver(ociClient)
+	cd, err := cdresolver.Resolve(ctx, repoCtx, o.ComponentName, o.Version)
+	if err != nil {
+		return fmt.Errorf("unable to to fetch component descriptor %s:%s: %w", o.ComponentName, o.Version, err)
+	}
+
+	if err := policy.CheckRepositories(ctx, cdresolver, cd); err != nil {
+		return fmt.Errorf("unable to check repositories: %w", err)
+	}
+
+	// check componentReferences and resources
+	if err := CheckCdDigests(cd, *repoCtx, ociClient, ctx); err != nil {
+		return fmt.Errorf("unable to check component descriptor digests: %w", err)
+	}
+
+	valid, err := policy.VerifySignatures(cd)
+	if err != nil {
+		return fmt.Errorf("unable to verify signatures: %w", err)
+	}
+
+	log.Info(fmt.Sprintf("Signatures %s are valid and calculated digest matches existing digest", strings.Join(valid, ", ")))
 	return nil
 }
 
@@ -125,7 +210,7 @@ func CheckCdDigests(cd *cdv2.ComponentDescriptor, repoContext cdv2.OCIRegistryRe
 			return fmt.Errorf("unable to build oci reference from component reference: %w", err)
 		}
 
-		cdresolver := cdoci.NewResolver(ociClient)
+		cdresolver := schema.NewResolver(ociClient)
 		childCd, err := cdresolver.Resolve(ctx, &repoContext, reference.ComponentName, reference.Version)
 		if err != nil {
 			return fmt.Errorf("unable to to fetch component descriptor %s: %w", ociRef, err)
@@ -190,7 +275,7 @@ func recursivelyCheckCdsDigests(cd *cdv2.ComponentDescriptor, repoContext cdv2.O
 			return nil, fmt.Errorf("unable to build oci reference from component reference: %w", err)
 		}
 
-		cdresolver := cdoci.NewResolver(ociClient)
+		cdresolver := schema.NewResolver(ociClient)
 		childCd, err := cdresolver.Resolve(ctx, &repoContext, reference.ComponentName, reference.Version)
 		if err != nil {
 			return nil, fmt.Errorf("unable to to fetch component descriptor %s: %w", ociRef, err)
@@ -71,7 +71,12 @@ func (o *X509CertificateVerifyOptions) Run(ctx context.Context, log logr.Logger,
 		return fmt.Errorf("unable to create rsa verifier: %w", err)
 	}
 
-	if err := o.GenericVerifyOptions.VerifyWithVerifier(ctx, log, fs, verifier); err != nil {
+	fingerprint, err := signatures.PublicKeyFingerprint(publicKey)
+	if err != nil {
+		return fmt.Errorf("unable to calculate public key fingerprint: %w", err)
+	}
+
+	if err := o.GenericVerifyOptions.VerifyWithVerifier(ctx, log, fs, verifier, fingerprint); err != nil {
 		return fmt.Errorf("unable to verify component descriptor: %w", err)
 	}
 	return nil
@@ -81,6 +86,9 @@ func (o *X509CertificateVerifyOptions) Complete(args []string) error {
 	if err := o.GenericVerifyOptions.Complete(args); err != nil {
 		return err
 	}
+	if err := o.completeSignatureName(); err != nil {
+		return err
+	}
 
 	if o.certPath == "" {
 		return errors.New("a path to a certificate file must be provided")
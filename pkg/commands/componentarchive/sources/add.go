@@ -17,7 +17,6 @@ import (
 	"github.com/gardener/component-spec/bindings-go/apis/v2/cdutils"
 	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
 	"github.com/gardener/component-spec/bindings-go/ctf"
-	"github.com/ghodss/yaml"
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
@@ -26,8 +25,9 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
 
-	"github.com/gardener/component-cli/pkg/commands/componentarchive/input"
 	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/componentarchive/input"
+	"github.com/gardener/component-cli/pkg/components/schema"
 	"github.com/gardener/component-cli/pkg/logger"
 	"github.com/gardener/component-cli/pkg/template"
 )
@@ -124,7 +124,7 @@ input:
 func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
 	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
 
-	archive, err := o.BuilderOptions.Build(fs)
+	archive, schemaVersion, err := o.BuilderOptions.Build(fs)
 	if err != nil {
 		return err
 	}
@@ -136,7 +136,7 @@ func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) e
 
 	for _, src := range sources {
 		if src.Input != nil {
-			log.Info(fmt.Sprintf("add input blob from %q", src.Input.Path))
+			log.Info(fmt.Sprintf("add input blob from %q", src.Input.Location()))
 			if err := o.addInputBlob(ctx, fs, archive, src); err != nil {
 				return err
 			}
@@ -162,7 +162,7 @@ func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) e
 		return fmt.Errorf("invalid component descriptor: %w", err)
 	}
 
-	data, err := yaml.Marshal(archive.ComponentDescriptor)
+	data, err := schema.EncodeYAML(archive.ComponentDescriptor, schemaVersion)
 	if err != nil {
 		return fmt.Errorf("unable to encode component descriptor: %w", err)
 	}
@@ -200,6 +200,30 @@ func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	// specify the resource
 	fs.StringVarP(&o.SourceObjectPath, "resource", "r", "", "The path to the resources defined as yaml or json")
 	_ = fs.MarkDeprecated("resource", "the resources flag is deprecated use the arguments instead.")
+	o.TemplateOptions.AddProvenanceFlags(fs)
+}
+
+// InputPaths returns the paths of the input blobs of all sources that are defined in the source templates.
+// Sources without input and docker inputs are ignored.
+func (o *Options) InputPaths(log logr.Logger, fs vfs.FileSystem) ([]string, error) {
+	sources, err := o.generateSources(log, fs)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0)
+	for _, src := range sources {
+		if src.Input == nil {
+			continue
+		}
+		inputPath, err := src.Input.ResolvePath(src.Path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve input path of source %s: %w", src.Name, err)
+		}
+		if len(inputPath) != 0 {
+			paths = append(paths, inputPath)
+		}
+	}
+	return paths, nil
 }
 
 // generateSources parses component references from the given path and stdin.
@@ -267,7 +291,21 @@ func (o *Options) generateSourcesFromReader(reader io.Reader) ([]SourceOptions,
 	if err != nil {
 		return nil, fmt.Errorf("unable to template source definition: %w", err)
 	}
-	return generateSourcesFromReader(bytes.NewBufferString(tmplData))
+	sources, err := generateSourcesFromReader(bytes.NewBufferString(tmplData))
+	if err != nil {
+		return nil, err
+	}
+
+	provenanceLabel, err := o.TemplateOptions.ProvenanceLabel(data.String())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create template provenance: %w", err)
+	}
+	if provenanceLabel != nil {
+		for i := range sources {
+			sources[i].Labels = cdutils.SetRawLabel(sources[i].Labels, provenanceLabel.Name, provenanceLabel.Value)
+		}
+	}
+	return sources, nil
 }
 
 // generateSourcesFromReader generates a resource given resource options and a resource template file.
@@ -289,24 +327,7 @@ func generateSourcesFromReader(reader io.Reader) ([]SourceOptions, error) {
 }
 
 func (o *Options) addInputBlob(ctx context.Context, fs vfs.FileSystem, archive *ctf.ComponentArchive, src InternalSourceOptions) error {
-	blob, err := src.Input.Read(ctx, fs, src.Path)
-	if err != nil {
-		return err
-	}
-
-	err = archive.AddSource(&src.Source, ctf.BlobInfo{
-		MediaType: src.Type,
-		Digest:    blob.Digest,
-		Size:      blob.Size,
-	}, blob.Reader)
-	if err != nil {
-		blob.Reader.Close()
-		return fmt.Errorf("unable to add input blob to archive: %w", err)
-	}
-	if err := blob.Reader.Close(); err != nil {
-		return fmt.Errorf("unable to close input file: %w", err)
-	}
-	return nil
+	return input.AddSource(ctx, fs, archive, &src.Source, src.Input, src.Path)
 }
 
 func convertToInternalSourceOptions(srcOpts []SourceOptions, filepath string) []InternalSourceOptions {
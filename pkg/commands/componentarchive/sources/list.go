@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// sourceColumns are the columns of the table output of sources.
+var sourceColumns = []componentarchive.ListColumn{
+	{Header: "NAME", Field: "name"},
+	{Header: "VERSION", Field: "version"},
+	{Header: "TYPE", Field: "type"},
+	{Header: "ACCESS", Field: "access.type"},
+	{Header: "EXTRA IDENTITY", Field: "extraIdentity"},
+}
+
+// ListOptions defines all options for the list sources command.
+type ListOptions struct {
+	componentarchive.ListOptions
+}
+
+// NewListCommand creates a command to list the sources of a component descriptor.
+func NewListCommand(ctx context.Context) *cobra.Command {
+	opts := &ListOptions{}
+	cmd := &cobra.Command{
+		Use:     "list [component-archive-path | BASE_URL COMPONENT_NAME VERSION]",
+		Aliases: []string{"ls"},
+		Args:    cobra.RangeArgs(0, 3),
+		Short:   "lists the sources of a component archive or a remote component descriptor",
+		Long: `
+list prints the sources of the component descriptor of a component archive
+or of the remote component descriptor with the given base url, name and version.
+
+The sources can be filtered with field selectors ("--selector type=ociImage").
+Nested fields are separated by a dot ("--selector access.type=ociRegistry")
+and keys that are no field are matched against the extra identity.
+
+The sources are printed as table, json or yaml ("-o json").
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	opts.AddFlags(cmd.Flags(), "table")
+	return cmd
+}
+
+// Run prints the sources to stdout.
+func (o *ListOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	return o.RunWithWriter(ctx, log, fs, os.Stdout)
+}
+
+// RunWithWriter writes the sources to the given writer.
+func (o *ListOptions) RunWithWriter(ctx context.Context, log logr.Logger, fs vfs.FileSystem, w io.Writer) error {
+	cd, err := o.ComponentDescriptor(ctx, log, fs)
+	if err != nil {
+		return err
+	}
+	selected, err := selectSources(&o.ListOptions, cd)
+	if err != nil {
+		return err
+	}
+	return o.Print(w, selected, sourceColumns)
+}
+
+// selectSources returns the sources of the component descriptor that match the field selectors.
+func selectSources(opts *componentarchive.ListOptions, cd *cdv2.ComponentDescriptor) ([]cdv2.Source, error) {
+	selected := []cdv2.Source{}
+	for _, src := range cd.Sources {
+		ok, err := opts.Matches(src)
+		if err != nil {
+			return nil, fmt.Errorf("unable to match source %q: %w", src.Name, err)
+		}
+		if ok {
+			selected = append(selected, src)
+		}
+	}
+	return selected, nil
+}
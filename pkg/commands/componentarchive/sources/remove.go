@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// RemoveOptions defines all options for the remove sources command.
+type RemoveOptions struct {
+	componentarchive.BuilderOptions
+	// Selector selects the sources that are removed.
+	Selector componentarchive.ElementSelector
+	// IgnoreMissing defines that the command does not fail if no source matches the selector.
+	IgnoreMissing bool
+}
+
+// NewRemoveCommand creates a command to remove sources from a component descriptor.
+func NewRemoveCommand(ctx context.Context) *cobra.Command {
+	opts := &RemoveOptions{}
+	cmd := &cobra.Command{
+		Use:     "remove [component-archive-path]",
+		Aliases: []string{"rm"},
+		Args:    cobra.RangeArgs(0, 1),
+		Short:   "removes sources from the component descriptor of a component archive",
+		Long: `
+remove deletes all sources that match the given identity from the component descriptor of a component archive.
+The sources are selected by their name ("--name") and optionally by their version ("--version")
+and their extra identity ("--extra-identity key=value").
+Local blobs of the removed sources are deleted from the blobs directory if they are no longer referenced.
+The command fails if no source matches unless "--ignore-missing" is set.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			removed, err := opts.Run(ctx, logger.Log, osfs.New())
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			fmt.Printf("Successfully removed %d sources\n", removed)
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// Run removes the selected sources and returns the number of removed sources.
+func (o *RemoveOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) (int, error) {
+	archive, schemaVersion, err := o.BuilderOptions.Build(fs)
+	if err != nil {
+		return 0, err
+	}
+	cd := archive.ComponentDescriptor
+
+	removed := componentarchive.RemoveSources(cd, o.Selector)
+	if len(removed) == 0 {
+		if o.IgnoreMissing {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("no source matches %q", o.Selector.Name)
+	}
+
+	accesses := make([]*cdv2.UnstructuredTypedObject, 0, len(removed))
+	for _, src := range removed {
+		accesses = append(accesses, src.Access)
+		log.V(3).Info(fmt.Sprintf("removed source %q", src.Name))
+	}
+
+	if err := cdvalidation.Validate(cd); err != nil {
+		return 0, fmt.Errorf("invalid component descriptor: %w", err)
+	}
+	data, err := schema.EncodeYAML(cd, schemaVersion)
+	if err != nil {
+		return 0, fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
+	if err := vfs.WriteFile(fs, compDescFilePath, data, 0664); err != nil {
+		return 0, fmt.Errorf("unable to write modified component descriptor: %w", err)
+	}
+
+	// blobs are only removed after the component descriptor has been written
+	// so that a failed write does not leave dangling references.
+	deleted, err := componentarchive.RemoveOrphanedBlobs(fs, o.ComponentArchivePath, cd, accesses...)
+	if err != nil {
+		return 0, err
+	}
+	for _, filename := range deleted {
+		log.V(3).Info(fmt.Sprintf("removed orphaned blob %q", filename))
+	}
+	return len(removed), nil
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *RemoveOptions) Complete(args []string) error {
+	if len(args) != 0 {
+		o.BuilderOptions.ComponentArchivePath = args[0]
+	}
+	o.BuilderOptions.Default()
+	if err := o.BuilderOptions.Validate(); err != nil {
+		return err
+	}
+	return o.Selector.Validate()
+}
+
+func (o *RemoveOptions) AddFlags(fs *pflag.FlagSet) {
+	o.Selector.AddFlags(fs, "sources")
+	fs.BoolVar(&o.IgnoreMissing, "ignore-missing", false, "do not fail if no source matches")
+	o.BuilderOptions.AddFlags(fs)
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// ValidateOptions defines all options for the validate command.
+type ValidateOptions struct {
+	// ComponentArchivePath is the path to the component archive.
+	ComponentArchivePath string
+	// PolicyPath is the path to an optional validation policy file.
+	PolicyPath string
+	// OutputFormat defines the format of the findings.
+	OutputFormat string
+}
+
+// NewValidateCommand creates a new command that validates a component archive.
+func NewValidateCommand(ctx context.Context) *cobra.Command {
+	opts := &ValidateOptions{}
+	cmd := &cobra.Command{
+		Use:   "validate [component-archive-path]",
+		Args:  cobra.RangeArgs(0, 1),
+		Short: "validates a component archive",
+		Long: `
+validate checks a component archive (directory, tar or tgz) beyond the schema of its component descriptor:
+
+- all localFilesystemBlob accesses reference a blob of the archive
+- the blobs match the digest of their filename and the genericBlobDigest/v1 digest of their resource
+- the image references of all ociRegistry accesses are well formed
+- the labels match the label policies of the optional policy file
+- blobs that are not referenced are reported as warnings
+
+A policy file contains a list of label policies:
+
+labels:
+- target: resources # one of component, resources, sources or componentReferences
+  name: "^cloud.gardener.cnudie/responsibles$" # regex for the label name
+  value: ".+" # optional regex for the label value
+  required: true # every target must have a matching label
+
+All findings are printed and the command exits with a non-zero code if at least one finding is an error.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+// Run validates the component archive and prints the findings.
+func (o *ValidateOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	return o.RunWithWriter(ctx, log, fs, os.Stdout)
+}
+
+// RunWithWriter validates the component archive and writes the findings to the given writer.
+func (o *ValidateOptions) RunWithWriter(ctx context.Context, log logr.Logger, fs vfs.FileSystem, w io.Writer) error {
+	var policy *componentarchive.ValidationPolicy
+	if len(o.PolicyPath) != 0 {
+		var err error
+		policy, err = componentarchive.ReadValidationPolicy(fs, o.PolicyPath)
+		if err != nil {
+			return err
+		}
+	}
+	findings, err := componentarchive.ValidateArchive(fs, o.ComponentArchivePath, policy)
+	if err != nil {
+		return err
+	}
+	if err := writeFindings(w, findings, o.OutputFormat); err != nil {
+		return err
+	}
+	if findings.HasErrors() {
+		return fmt.Errorf("component archive %s is invalid", o.ComponentArchivePath)
+	}
+	return nil
+}
+
+func writeFindings(w io.Writer, findings componentarchive.Findings, format string) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to encode findings: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "yaml":
+		data, err := yaml.Marshal(findings)
+		if err != nil {
+			return fmt.Errorf("unable to encode findings: %w", err)
+		}
+		_, err = fmt.Fprint(w, string(data))
+		return err
+	}
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "component archive is valid")
+		return err
+	}
+	for _, finding := range findings {
+		if _, err := fmt.Fprintln(w, finding.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *ValidateOptions) Complete(args []string) error {
+	if len(args) != 0 {
+		o.ComponentArchivePath = args[0]
+	}
+	if len(o.ComponentArchivePath) == 0 {
+		var err error
+		o.ComponentArchivePath, err = os.Getwd()
+		if err != nil {
+			return fmt.Errorf("unable to get current working directory: %w", err)
+		}
+	}
+	if len(o.OutputFormat) == 0 {
+		o.OutputFormat = "text"
+	}
+	if o.OutputFormat != "text" && o.OutputFormat != "json" && o.OutputFormat != "yaml" {
+		return fmt.Errorf("unsupported output format %q: must be text, json or yaml", o.OutputFormat)
+	}
+	return nil
+}
+
+func (o *ValidateOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.ComponentArchivePath, "archive", "a", "", "path to the component archive (directory, tar or tgz)")
+	fs.StringVar(&o.PolicyPath, "policy", "", "[OPTIONAL] path to a validation policy file with label policies")
+	fs.StringVarP(&o.OutputFormat, "output", "o", "text", "output format of the findings. One of text, json or yaml")
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/commands/componentarchive"
+	cacomponentarchive "github.com/gardener/component-cli/pkg/componentarchive"
+)
+
+var _ = Describe("Validate", func() {
+
+	var testdataFs vfs.FileSystem
+
+	BeforeEach(func() {
+		baseFs, err := projectionfs.New(osfs.New(), "./testdata")
+		Expect(err).ToNot(HaveOccurred())
+		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+	})
+
+	It("should succeed for a valid component archive", func() {
+		opts := &componentarchive.ValidateOptions{}
+		Expect(opts.Complete([]string{"01-ca-blob"})).To(Succeed())
+		var out bytes.Buffer
+		Expect(opts.RunWithWriter(context.TODO(), logr.Discard(), testdataFs, &out)).To(Succeed())
+		Expect(out.String()).To(Equal("component archive is valid\n"))
+	})
+
+	It("should report missing blobs, digest mismatches, invalid image references and orphaned blobs", func() {
+		opts := &componentarchive.ValidateOptions{OutputFormat: "json"}
+		Expect(opts.Complete([]string{"03-ca-validate"})).To(Succeed())
+		var out bytes.Buffer
+		Expect(opts.RunWithWriter(context.TODO(), logr.Discard(), testdataFs, &out)).ToNot(Succeed())
+
+		findings := cacomponentarchive.Findings{}
+		Expect(json.Unmarshal(out.Bytes(), &findings)).To(Succeed())
+		Expect(findings).To(ConsistOf(
+			MatchFields(IgnoreExtras, Fields{
+				"Severity": Equal(cacomponentarchive.SeverityError),
+				"Rule":     Equal(cacomponentarchive.RuleBlobDigest),
+				"Path":     Equal("component.resources[0].access"),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Severity": Equal(cacomponentarchive.SeverityError),
+				"Rule":     Equal(cacomponentarchive.RuleBlobExists),
+				"Path":     Equal("component.resources[1].access"),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Severity": Equal(cacomponentarchive.SeverityError),
+				"Rule":     Equal(cacomponentarchive.RuleImageReference),
+				"Path":     Equal("component.resources[2].access.imageReference"),
+			}),
+			MatchFields(IgnoreExtras, Fields{
+				"Severity": Equal(cacomponentarchive.SeverityWarning),
+				"Rule":     Equal(cacomponentarchive.RuleOrphanedBlob),
+				"Path":     Equal("blobs/orphaned"),
+			}),
+		))
+	})
+
+	It("should report labels that violate the label policies", func() {
+		opts := &componentarchive.ValidateOptions{PolicyPath: "03-ca-validate-policy.yaml", OutputFormat: "yaml"}
+		Expect(opts.Complete([]string{"03-ca-validate"})).To(Succeed())
+		var out bytes.Buffer
+		Expect(opts.RunWithWriter(context.TODO(), logr.Discard(), testdataFs, &out)).ToNot(Succeed())
+
+		findings := cacomponentarchive.Findings{}
+		Expect(yaml.Unmarshal(out.Bytes(), &findings)).To(Succeed())
+		var labelFindings []string
+		for _, finding := range findings {
+			if finding.Rule == cacomponentarchive.RuleLabelPolicy {
+				labelFindings = append(labelFindings, finding.Path)
+			}
+		}
+		Expect(labelFindings).To(ConsistOf(
+			"component.labels[0]",
+			"component.resources[0]",
+			"component.resources[1]",
+		))
+	})
+
+})
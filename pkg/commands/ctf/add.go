@@ -5,7 +5,6 @@
 package ctf
 
 import (
-	"archive/tar"
 	"context"
 	"errors"
 	"fmt"
@@ -60,37 +59,9 @@ func NewAddCommand(ctx context.Context) *cobra.Command {
 }
 
 func (o *AddOptions) Run(_ context.Context, log logr.Logger, fs vfs.FileSystem) error {
-	info, err := fs.Stat(o.CTFPath)
+	ctfArchive, err := utils.OpenOrCreateCTF(log, fs, o.CTFPath)
 	if err != nil {
-		if !errors.Is(err, os.ErrNotExist) {
-			return fmt.Errorf("unable to get info for %s: %w", o.CTFPath, err)
-		}
-		log.Info("CTF Archive does not exist creating a new one")
-
-		file, err := fs.OpenFile(o.CTFPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
-		if err != nil {
-			return fmt.Errorf("unable to open file for %s: %w", o.CTFPath, err)
-		}
-		tw := tar.NewWriter(file)
-		if err := tw.Close(); err != nil {
-			return fmt.Errorf("unable to close tarwriter for emtpy tar: %w", err)
-		}
-		if err := file.Close(); err != nil {
-			return fmt.Errorf("unable to close tarwriter for emtpy tar: %w", err)
-		}
-		info, err = fs.Stat(o.CTFPath)
-		if err != nil {
-			return fmt.Errorf("unable to get info for %s: %w", o.CTFPath, err)
-		}
-	}
-	if info.IsDir() {
-		return fmt.Errorf(`%q is a directory. 
-It is expected that the given path points to a CTF Archive`, o.CTFPath)
-	}
-
-	ctfArchive, err := ctf.NewCTF(fs, o.CTFPath)
-	if err != nil {
-		return fmt.Errorf("unable to open ctf at %q: %s", o.CTFPath, err.Error())
+		return err
 	}
 
 	for _, caPath := range o.ComponentArchives {
@@ -17,5 +17,6 @@ func NewCTFCommand(ctx context.Context) *cobra.Command {
 	}
 	cmd.AddCommand(NewPushCommand(ctx))
 	cmd.AddCommand(NewAddCommand(ctx))
+	cmd.AddCommand(NewPullCommand(ctx))
 	return cmd
 }
@@ -0,0 +1,312 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/ociclient"
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/componentarchive"
+	"github.com/gardener/component-cli/pkg/componentarchive/input"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// PullOptions defines all options for the pull command.
+type PullOptions struct {
+	// CTFPath is the path to the ctf archive that the component archives are written to.
+	CTFPath string
+	// BaseUrl is the oci registry where the component is stored.
+	BaseUrl string
+	// ComponentName is the unique name of the component in the registry.
+	ComponentName string
+	// Version is the component Version in the oci registry.
+	Version string
+	// ArchiveFormat defines the format of the component archives in the ctf archive.
+	ArchiveFormat ctf.ArchiveFormat
+
+	// Recursive specifies if all component references should also be pulled.
+	Recursive bool
+	// CopyByValue defines if all referenced oci images and artifacts should be stored as local blobs.
+	CopyByValue bool
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+}
+
+// NewPullCommand creates a new command that downloads a component into a ctf archive.
+func NewPullCommand(ctx context.Context) *cobra.Command {
+	opts := &PullOptions{}
+	cmd := &cobra.Command{
+		Use:   "pull CTF_PATH BASE_URL COMPONENT_NAME VERSION",
+		Args:  cobra.ExactArgs(4),
+		Short: "Pulls a component and its local blobs from a remote repository into a ctf",
+		Long: `
+Pull downloads the component descriptor and all local blobs of a component into a ctf archive.
+The ctf archive is created if it does not exist, otherwise the component archives are added to it.
+
+With "--recursive" all transitively referenced components are pulled as well.
+Referenced components are resolved in the effective repository context of the referencing component.
+
+With "--copy-by-value" all oci images and artifacts of the components are downloaded as oci image layout tarballs
+and stored as local blobs, so that the ctf archive is self-contained and can be transferred into air-gapped environments.
+The ctf archive can be uploaded again with "ctf push".
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			fmt.Printf("Successfully pulled component into %s\n", opts.CTFPath)
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run pulls the component into the ctf archive.
+func (o *PullOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	return o.RunWithClient(ctx, log, fs, schema.NewResolver(ociClient), ociClient)
+}
+
+// RunWithClient pulls the component with the given resolver into the ctf archive.
+// The oci client is only used to download oci artifacts by value.
+func (o *PullOptions) RunWithClient(ctx context.Context, log logr.Logger, fs vfs.FileSystem, resolver ctf.ComponentResolver, client ociclient.Client) error {
+	ctfArchive, err := utils.OpenOrCreateCTF(log, fs, o.CTFPath)
+	if err != nil {
+		return err
+	}
+
+	p := &puller{
+		PullOptions: o,
+		log:         log,
+		fs:          fs,
+		resolver:    resolver,
+		client:      client,
+		archive:     ctfArchive,
+		visited:     map[string]bool{},
+	}
+	if err := p.pull(ctx, *cdv2.NewOCIRegistryRepository(o.BaseUrl, ""), o.ComponentName, o.Version); err != nil {
+		return err
+	}
+
+	if err := ctfArchive.Write(); err != nil {
+		return fmt.Errorf("unable to write modified ctf archive: %s", err.Error())
+	}
+	return ctfArchive.Close()
+}
+
+type puller struct {
+	*PullOptions
+	log      logr.Logger
+	fs       vfs.FileSystem
+	resolver ctf.ComponentResolver
+	client   ociclient.Client
+	archive  *ctf.CTF
+	visited  map[string]bool
+}
+
+func (p *puller) pull(ctx context.Context, repoCtx cdv2.OCIRegistryRepository, name, version string) error {
+	key := fmt.Sprintf("%s:%s", name, version)
+	if p.visited[key] {
+		return nil
+	}
+	p.visited[key] = true
+
+	p.log.Info(fmt.Sprintf("[%d] Pulling component %s", len(p.visited), key))
+	cd, blobResolver, err := p.resolver.ResolveWithBlobResolver(ctx, &repoCtx, name, version)
+	if err != nil {
+		return fmt.Errorf("unable to resolve component descriptor %s: %w", key, err)
+	}
+	cd = cd.DeepCopy()
+
+	// the blobs of the component archive are written to a temporary directory so that large
+	// oci artifacts are not kept in memory.
+	caDir, err := vfs.TempDir(p.fs, p.fs.FSTempDir(), "ctf-pull-")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary directory: %w", err)
+	}
+	defer func() {
+		if err := p.fs.RemoveAll(caDir); err != nil {
+			p.log.Error(err, "unable to remove temporary directory", "dir", caDir)
+		}
+	}()
+	caFs, err := projectionfs.New(p.fs, caDir)
+	if err != nil {
+		return fmt.Errorf("unable to create filesystem for component archive: %w", err)
+	}
+	ca := ctf.NewComponentArchive(cd, caFs)
+	for _, res := range cd.Resources {
+		res := res
+		if res.Access == nil {
+			continue
+		}
+		switch res.Access.GetType() {
+		case cdv2.LocalOCIBlobType, cdv2.LocalFilesystemBlobType:
+			if err := ca.AddResourceFromResolver(ctx, &res, blobResolver); err != nil {
+				return fmt.Errorf("unable to add blob of resource %s of %s: %w", res.Name, key, err)
+			}
+		case cdv2.OCIRegistryType:
+			if !p.CopyByValue {
+				continue
+			}
+			if err := p.addOCIArtifact(ctx, ca, &res); err != nil {
+				return fmt.Errorf("unable to add oci artifact of resource %s of %s: %w", res.Name, key, err)
+			}
+		}
+	}
+	for _, src := range cd.Sources {
+		src := src
+		if src.Access == nil {
+			continue
+		}
+		switch src.Access.GetType() {
+		case cdv2.LocalOCIBlobType, cdv2.LocalFilesystemBlobType:
+			var buf bytes.Buffer
+			info, err := blobResolver.Resolve(ctx, cdv2.Resource{Access: src.Access}, &buf)
+			if err != nil {
+				return fmt.Errorf("unable to read blob of source %s of %s: %w", src.Name, key, err)
+			}
+			if err := ca.AddSource(&src, *info, &buf); err != nil {
+				return fmt.Errorf("unable to add blob of source %s of %s: %w", src.Name, key, err)
+			}
+		}
+	}
+
+	if err := p.archive.AddComponentArchiveWithName(utils.CTFComponentArchiveFilename(name, version), ca, p.ArchiveFormat); err != nil {
+		return fmt.Errorf("unable to add component archive %s to ctf: %w", key, err)
+	}
+
+	if !p.Recursive {
+		return nil
+	}
+	refRepoCtx := repoCtx
+	if effective := cd.GetEffectiveRepositoryContext(); effective != nil {
+		if refRepoCtx, err = components.GetOCIRepositoryContext(effective); err != nil {
+			return fmt.Errorf("unable to get repository context of %s: %w", key, err)
+		}
+	}
+	for _, ref := range cd.ComponentReferences {
+		if err := p.pull(ctx, refRepoCtx, ref.ComponentName, ref.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addOCIArtifact downloads the oci artifact of the resource as oci image layout and adds it as local blob.
+func (p *puller) addOCIArtifact(ctx context.Context, ca *ctf.ComponentArchive, res *cdv2.Resource) error {
+	if p.client == nil {
+		return errors.New("no oci client configured")
+	}
+	ociAccess := &cdv2.OCIRegistryAccess{}
+	if err := res.Access.DecodeInto(ociAccess); err != nil {
+		return fmt.Errorf("unable to decode access: %w", err)
+	}
+	p.log.V(3).Info(fmt.Sprintf("Downloading oci artifact %s", ociAccess.ImageReference))
+
+	// the artifact is exported to a temporary file as its digest is only known after the export.
+	file, err := vfs.TempFile(p.fs, p.fs.FSTempDir(), "oci-artifact-")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+		if err := p.fs.Remove(file.Name()); err != nil {
+			p.log.Error(err, "unable to remove temporary file", "file", file.Name())
+		}
+	}()
+	digester := digest.Canonical.Digester()
+	if _, err := ociclient.ExportOCILayout(ctx, p.client, ociAccess.ImageReference, io.MultiWriter(file, digester.Hash())); err != nil {
+		return err
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to get size of temporary file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to reset temporary file: %w", err)
+	}
+	info := ctf.BlobInfo{
+		MediaType: input.MediaTypeOCIImageLayoutTar,
+		Digest:    digester.Digest().String(),
+		Size:      fileInfo.Size(),
+	}
+	return ca.AddResource(res, info, file)
+}
+
+// Complete validates the arguments and flags from the command line
+func (o *PullOptions) Complete(args []string) error {
+	if len(args) != 4 {
+		return fmt.Errorf("illegal number of arguments: %d", len(args))
+	}
+	o.CTFPath = args[0]
+	o.BaseUrl = args[1]
+	o.ComponentName = args[2]
+	o.Version = args[3]
+
+	var err error
+	o.OciOptions.CacheDir, err = utils.CacheDir()
+	if err != nil {
+		return fmt.Errorf("unable to get oci cache directory: %w", err)
+	}
+
+	return o.Validate()
+}
+
+// Validate validates pull options
+func (o *PullOptions) Validate() error {
+	if len(o.CTFPath) == 0 {
+		return errors.New("a path to the ctf archive must be provided")
+	}
+	if len(o.BaseUrl) == 0 {
+		return errors.New("a base url must be provided")
+	}
+	if len(o.ComponentName) == 0 {
+		return errors.New("a component name must be provided")
+	}
+	if len(o.Version) == 0 {
+		return errors.New("a component version must be provided")
+	}
+	return componentarchive.ValidateOutputFormat(o.ArchiveFormat, false)
+}
+
+func (o *PullOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Recursive, "recursive", false, "pull all transitively referenced components as well")
+	fs.BoolVar(&o.CopyByValue, "copy-by-value", false, "download all oci images and artifacts and store them as local blobs")
+	componentarchive.OutputFormatVar(fs, &o.ArchiveFormat, "format", ctf.ArchiveFormatTar,
+		componentarchive.ArchiveOutputFormatUsage)
+	o.OciOptions.AddFlags(fs)
+}
@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ctf_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	cmd "github.com/gardener/component-cli/pkg/commands/ctf"
+	"github.com/gardener/component-cli/pkg/componentarchive/input"
+	"github.com/gardener/component-cli/pkg/testutils"
+)
+
+// archiveResolver resolves components from a static list of component archives.
+type archiveResolver []*ctf.ComponentArchive
+
+func (r archiveResolver) Resolve(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	cd, _, err := r.ResolveWithBlobResolver(ctx, repoCtx, name, version)
+	return cd, err
+}
+
+func (r archiveResolver) ResolveWithBlobResolver(_ context.Context, _ cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	for _, ca := range r {
+		if ca.ComponentDescriptor.Name == name && ca.ComponentDescriptor.Version == version {
+			return ca.ComponentDescriptor.DeepCopy(), ca.BlobResolver, nil
+		}
+	}
+	return nil, nil, ctf.NotFoundError
+}
+
+var _ = Describe("Pull", func() {
+
+	newArchive := func(name, version string, refs ...cdv2.ComponentReference) *ctf.ComponentArchive {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Metadata.Version = cdv2.SchemaVersion
+		cd.Name = name
+		cd.Provider = "internal"
+		cd.Version = version
+		Expect(cdv2.InjectRepositoryContext(cd, cdv2.NewOCIRegistryRepository("example.com/components", ""))).To(Succeed())
+		cd.ComponentReferences = refs
+		return ctf.NewComponentArchive(cd, memoryfs.New())
+	}
+
+	It("should pull a component with its local blobs and all referenced components into a ctf", func() {
+		app := newArchive("example.com/app", "v1.0.0",
+			cdv2.ComponentReference{Name: "dep", ComponentName: "example.com/dep", Version: "v0.1.0"})
+		data := []byte("some data")
+		Expect(app.AddResource(&cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "res", Version: "v1.0.0", Type: "plain-text"},
+			Relation:           cdv2.LocalRelation,
+		}, ctf.BlobInfo{
+			MediaType: "text/plain",
+			Digest:    digest.FromBytes(data).String(),
+			Size:      int64(len(data)),
+		}, bytes.NewReader(data))).To(Succeed())
+		resolver := archiveResolver{app, newArchive("example.com/dep", "v0.1.0")}
+
+		fs := memoryfs.New()
+		opts := &cmd.PullOptions{
+			CTFPath:       "/component.ctf",
+			BaseUrl:       "example.com/components",
+			ComponentName: "example.com/app",
+			Version:       "v1.0.0",
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			Recursive:     true,
+		}
+		Expect(opts.RunWithClient(context.TODO(), logr.Discard(), fs, resolver, nil)).To(Succeed())
+
+		ctfArchive, err := ctf.NewCTF(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		pulled := map[string]*cdv2.ComponentDescriptor{}
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			pulled[ca.ComponentDescriptor.Name] = ca.ComponentDescriptor
+			if ca.ComponentDescriptor.Name != "example.com/app" {
+				return nil
+			}
+			var buf bytes.Buffer
+			_, err := ca.Resolve(context.TODO(), ca.ComponentDescriptor.Resources[0], &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.Bytes()).To(Equal(data))
+			return nil
+		})).To(Succeed())
+		Expect(pulled).To(HaveKey("example.com/app"))
+		Expect(pulled).To(HaveKey("example.com/dep"))
+		Expect(vfs.FileExists(fs, "/component.ctf")).To(BeTrue())
+	})
+
+	It("should download oci artifacts as local blobs with copy-by-value", func() {
+		ctx := context.TODO()
+		ref := testenv.Addr + "/pull/image:v1.0.0"
+		manifestDesc, _ := testutils.UploadTestImage(ctx, client, ref, ocispecv1.MediaTypeImageManifest, []byte("config"), [][]byte{[]byte("layer")})
+
+		app := newArchive("example.com/app", "v1.0.0")
+		ociAccess, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(ref))
+		Expect(err).ToNot(HaveOccurred())
+		app.ComponentDescriptor.Resources = append(app.ComponentDescriptor.Resources, cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "image", Version: "v1.0.0", Type: cdv2.OCIImageType},
+			Relation:           cdv2.ExternalRelation,
+			Access:             &ociAccess,
+		})
+
+		fs := memoryfs.New()
+		opts := &cmd.PullOptions{
+			CTFPath:       "/component.ctf",
+			BaseUrl:       "example.com/components",
+			ComponentName: "example.com/app",
+			Version:       "v1.0.0",
+			ArchiveFormat: ctf.ArchiveFormatTar,
+			CopyByValue:   true,
+		}
+		Expect(opts.RunWithClient(ctx, logr.Discard(), fs, archiveResolver{app}, client)).To(Succeed())
+		// the temporary files of the pull are removed
+		tmpFiles, err := vfs.ReadDir(fs, fs.FSTempDir())
+		Expect(err).ToNot(HaveOccurred())
+		for _, tmpFile := range tmpFiles {
+			Expect(tmpFile.Name()).ToNot(HavePrefix("ctf-pull-"))
+			Expect(tmpFile.Name()).ToNot(HavePrefix("oci-artifact-"))
+		}
+
+		ctfArchive, err := ctf.NewCTF(fs, "/component.ctf")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+			res := ca.ComponentDescriptor.Resources[0]
+			Expect(res.Access.GetType()).To(Equal(cdv2.LocalFilesystemBlobType))
+			Expect(res.Access.Object).To(HaveKeyWithValue("mediaType", input.MediaTypeOCIImageLayoutTar))
+
+			var buf bytes.Buffer
+			info, err := ca.Resolve(ctx, res, &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Digest).To(Equal(digest.FromBytes(buf.Bytes()).String()))
+			Expect(info.Size).To(Equal(int64(buf.Len())))
+			index := ocispecv1.Index{}
+			files := map[string][]byte{}
+			tr := tar.NewReader(&buf)
+			for {
+				header, err := tr.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				Expect(err).ToNot(HaveOccurred())
+				data, err := io.ReadAll(tr)
+				Expect(err).ToNot(HaveOccurred())
+				files[header.Name] = data
+			}
+			Expect(json.Unmarshal(files["index.json"], &index)).To(Succeed())
+			Expect(index.Manifests).To(HaveLen(1))
+			Expect(index.Manifests[0].Digest).To(Equal(manifestDesc.Digest))
+			Expect(files).To(HaveKey("blobs/sha256/" + digest.FromBytes([]byte("layer")).Encoded()))
+			return nil
+		})).To(Succeed())
+	})
+})
@@ -21,6 +21,8 @@ import (
 
 	"github.com/gardener/component-cli/pkg/components"
 
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
 	ociopts "github.com/gardener/component-cli/ociclient/options"
 	"github.com/gardener/component-cli/pkg/logger"
 	"github.com/gardener/component-cli/pkg/utils"
@@ -33,6 +35,8 @@ type PushOptions struct {
 	BaseUrl string
 	// AdditionalTags defines additional tags that the oci artifact should be tagged with.
 	AdditionalTags []string
+	// DryRun only prints the component descriptors that would be pushed without pushing them.
+	DryRun bool
 
 	// OciOptions contains all exposed options to configure the oci client.
 	OciOptions ociopts.Options
@@ -50,6 +54,9 @@ Push pushes all component archives and oci artifacts to the defined oci reposito
 
 The oci repository is automatically determined based on the component/artifact descriptor (repositoryContext, component name and version).
 
+All component descriptors and their local blobs are pushed in one invocation and the progress is reported for every component archive.
+With "--dry-run" the target references and the number of local blobs are printed without accessing the registry.
+
 Note: Currently only component archives are supoprted. Generic OCI Artifacts will be supported in the future.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -63,7 +70,9 @@ Note: Currently only component archives are supoprted. Generic OCI Artifacts wil
 				os.Exit(1)
 			}
 
-			fmt.Print("Successfully uploaded ctf\n")
+			if !opts.DryRun {
+				fmt.Print("Successfully uploaded ctf\n")
+			}
 		},
 	}
 
@@ -82,17 +91,35 @@ func (o *PushOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSyste
 It is expected that the given path points to a CTF Archive`, o.CTFPath)
 	}
 
-	ociClient, cache, err := o.OciOptions.Build(log, fs)
-	if err != nil {
-		return fmt.Errorf("unable to build oci client: %s", err.Error())
-	}
-
 	ctfArchive, err := ctf.NewCTF(fs, o.CTFPath)
 	if err != nil {
 		return fmt.Errorf("unable to open ctf at %q: %s", o.CTFPath, err.Error())
 	}
 
+	total := 0
+	if err := ctfArchive.Walk(func(_ *ctf.ComponentArchive) error {
+		total++
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error while reading component archives in ctf: %w", err)
+	}
+
+	var (
+		ociClient ociclient.Client
+		ociCache  cache.Cache
+	)
+	if !o.DryRun {
+		ociClient, ociCache, err = o.OciOptions.Build(log, fs)
+		if err != nil {
+			return fmt.Errorf("unable to build oci client: %s", err.Error())
+		}
+	}
+
+	current := 0
 	err = ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+		current++
+		progress := fmt.Sprintf("[%d/%d]", current, total)
+
 		// update repository context
 		if len(o.BaseUrl) != 0 {
 			if err := cdv2.InjectRepositoryContext(ca.ComponentDescriptor, cdv2.NewOCIRegistryRepository(o.BaseUrl, "")); err != nil {
@@ -100,19 +127,34 @@ It is expected that the given path points to a CTF Archive`, o.CTFPath)
 			}
 		}
 
-		manifest, err := cdoci.NewManifestBuilder(cache, ca).Build(ctx)
+		ref, err := components.OCIRef(ca.ComponentDescriptor.GetEffectiveRepositoryContext(), ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion())
 		if err != nil {
-			return fmt.Errorf("unable to build oci artifact for component acrchive: %w", err)
+			return fmt.Errorf("unable to calculate oci ref for %q: %s", ca.ComponentDescriptor.GetName(), err.Error())
 		}
 
-		ref, err := components.OCIRef(ca.ComponentDescriptor.GetEffectiveRepositoryContext(), ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion())
+		if o.DryRun {
+			log.Info(fmt.Sprintf("%s Would upload component archive %s:%s with %d local blobs to %q", progress,
+				ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion(), countLocalBlobs(ca.ComponentDescriptor), ref))
+			for _, tag := range o.AdditionalTags {
+				ref, err := components.OCIRef(ca.ComponentDescriptor.GetEffectiveRepositoryContext(), ca.ComponentDescriptor.GetName(), tag)
+				if err != nil {
+					return fmt.Errorf("unable to calculate oci ref for %q: %s", ca.ComponentDescriptor.GetName(), err.Error())
+				}
+				log.Info(fmt.Sprintf("%s Would tag component archive with %q", progress, ref))
+			}
+			return nil
+		}
+
+		log.Info(fmt.Sprintf("%s Uploading component archive %s:%s", progress, ca.ComponentDescriptor.GetName(), ca.ComponentDescriptor.GetVersion()))
+		manifest, err := cdoci.NewManifestBuilder(ociCache, ca).Build(ctx)
 		if err != nil {
-			return fmt.Errorf("unable to calculate oci ref for %q: %s", ca.ComponentDescriptor.GetName(), err.Error())
+			return fmt.Errorf("unable to build oci artifact for component acrchive: %w", err)
 		}
+
 		if err := ociClient.PushManifest(ctx, ref, manifest); err != nil {
 			return fmt.Errorf("unable to upload component archive to %q: %s", ref, err.Error())
 		}
-		log.Info(fmt.Sprintf("Successfully uploaded component archive to %q", ref))
+		log.Info(fmt.Sprintf("%s Successfully uploaded component archive to %q", progress, ref))
 
 		for _, tag := range o.AdditionalTags {
 			ref, err := components.OCIRef(ca.ComponentDescriptor.GetEffectiveRepositoryContext(), ca.ComponentDescriptor.GetName(), tag)
@@ -122,7 +164,7 @@ It is expected that the given path points to a CTF Archive`, o.CTFPath)
 			if err := ociClient.PushManifest(ctx, ref, manifest); err != nil {
 				return fmt.Errorf("unable to upload component archive to %q: %s", ref, err.Error())
 			}
-			log.Info(fmt.Sprintf("Successfully tagged component archive with %q", ref))
+			log.Info(fmt.Sprintf("%s Successfully tagged component archive with %q", progress, ref))
 		}
 
 		return nil
@@ -134,6 +176,32 @@ It is expected that the given path points to a CTF Archive`, o.CTFPath)
 	return ctfArchive.Close()
 }
 
+// countLocalBlobs returns the number of resources and sources that are stored as local blobs of the component archive.
+func countLocalBlobs(cd *cdv2.ComponentDescriptor) int {
+	count := 0
+	isLocal := func(access *cdv2.UnstructuredTypedObject) bool {
+		if access == nil {
+			return false
+		}
+		switch access.GetType() {
+		case cdv2.LocalFilesystemBlobType, cdv2.LocalOCIBlobType:
+			return true
+		}
+		return false
+	}
+	for _, res := range cd.Resources {
+		if isLocal(res.Access) {
+			count++
+		}
+	}
+	for _, src := range cd.Sources {
+		if isLocal(src.Access) {
+			count++
+		}
+	}
+	return count
+}
+
 func (o *PushOptions) Complete(args []string) error {
 	o.CTFPath = args[0]
 
@@ -161,6 +229,7 @@ func (o *PushOptions) Validate() error {
 func (o *PushOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.BaseUrl, "repo-ctx", "", "repository context url for component to upload. The repository url will be automatically added to the repository contexts.")
 	fs.StringArrayVarP(&o.AdditionalTags, "tag", "t", []string{}, "set additional tags on the oci artifact")
+	fs.BoolVar(&o.DryRun, "dry-run", false, "only print the component archives that would be uploaded")
 
 	o.OciOptions.AddFlags(fs)
 }
@@ -82,6 +82,32 @@ var _ = Describe("Add", func() {
 			"Expect that the second layer contains the local blob")
 	})
 
+	It("should not upload component archives in dry run mode", func() {
+		baseFs, err := projectionfs.New(osfs.New(), "../componentarchive")
+		Expect(err).ToNot(HaveOccurred())
+		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+		ctx := context.Background()
+
+		caOpts := &componentarchive.ComponentArchiveOptions{
+			CTFPath:        "/component.ctf",
+			ArchiveFormat:  ctf.ArchiveFormatTar,
+			ResourcesPaths: []string{"./resources/testdata/resources/21-res-dir.yaml"},
+		}
+		caOpts.ComponentArchivePath = "./testdata/00-ca"
+		Expect(caOpts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		opts := cmd.PushOptions{
+			CTFPath: "/component.ctf",
+			BaseUrl: testenv.Addr + "/dry-run",
+			DryRun:  true,
+		}
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		repos, err := client.ListRepositories(ctx, testenv.Addr+"/dry-run")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repos).To(BeEmpty())
+	})
+
 	It("should throw an error if a local resource does not exist", func() {
 		baseFs, err := projectionfs.New(osfs.New(), "../componentarchive")
 		Expect(err).ToNot(HaveOccurred())
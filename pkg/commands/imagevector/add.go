@@ -5,7 +5,9 @@
 package imagevector
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -14,19 +16,20 @@ import (
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
-	"github.com/gardener/component-spec/bindings-go/codec"
 	"github.com/gardener/component-spec/bindings-go/ctf"
-	cdoci "github.com/gardener/component-spec/bindings-go/oci"
 	iv "github.com/gardener/image-vector/pkg"
 	"github.com/ghodss/yaml"
 	"github.com/go-logr/logr"
 	"github.com/mandelsoft/vfs/pkg/osfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	"github.com/gardener/component-cli/ociclient"
 	ociopts "github.com/gardener/component-cli/ociclient/options"
 	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
 
 	"github.com/gardener/component-cli/pkg/commands/constants"
 	"github.com/gardener/component-cli/pkg/logger"
@@ -44,9 +47,18 @@ type AddOptions struct {
 	// GenericDependencies is a comma separated list of generic dependency names.
 	// The list will be merged with the parse image options names.
 	GenericDependencies string
+	// ResolveDigests defines whether the image references of the resources that are added from the image vector
+	// should be resolved and pinned to their digests.
+	ResolveDigests bool
+	// IncludeCosignSignatures defines whether the cosign signatures of the images that are added from the image vector
+	// should be added as additional resources.
+	IncludeCosignSignatures bool
 
 	// OciOptions contains all exposed options to configure the oci client.
 	OciOptions ociopts.Options
+	// ImageResolver is used to resolve the digests of images and their cosign signatures.
+	// Defaults to the configured oci client.
+	ImageResolver ociclient.Resolver
 }
 
 // NewAddCommand creates a command to add additional resources to a component descriptor.
@@ -199,6 +211,26 @@ resources:
     imageReference: eu.gcr.io/gardener-project/gardener/gardenlet:v0.0.0
 </pre>
 
+With "--resolve-digests" the image references of all resources that are added from the image vector are resolved
+in the oci registry and pinned to their digest (e.g. "eu.gcr.io/gardener-project/gardener/gardenlet@sha256:...").
+
+Images can also be defined by their repository and digest instead of a tag.
+The digest is used as extra identity ("imagevector-gardener-cloud+tag") and the resource gets a digest based access.
+The version of the resource defaults to the version of the component.
+A image must not define a tag and a digest.
+
+<pre>
+images:
+- name: pause-container
+  repository: gcr.io/google_containers/pause-amd64
+  digest: "sha256:179e67c248007299e05791db36298c41cbf0992372204a68473e12795a51b06b"
+</pre>
+
+With "--include-cosign-signatures" the cosign signatures of all images that are added from the image vector
+are added as additional resources of type "cosignSignature" with the name "IMAGE_NAME-signature".
+The signatures are expected at the default cosign location "REPOSITORY:DIGEST_ALGORITHM-DIGEST_VALUE.sig"
+and are referenced by their digest. Images without signature are skipped.
+
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
@@ -228,15 +260,19 @@ func (o *AddOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem
 	if err != nil {
 		return err
 	}
-	compResolver := cdoci.NewResolver(ociClient).
+	imageResolver := o.ImageResolver
+	if imageResolver == nil {
+		imageResolver = ociClient
+	}
+	compResolver := schema.NewResolver(ociClient).
 		WithLog(log)
 	if len(os.Getenv(constants.ComponentRepositoryCacheDirEnvVar)) != 0 {
 		compResolver.WithCache(components.NewLocalComponentCache(fs))
 	}
 
 	// add the input to the ctf format
-	cd := &cdv2.ComponentDescriptor{}
-	if err := codec.Decode(data, cd); err != nil {
+	cd, schemaVersion, err := schema.Decode(data)
+	if err != nil {
 		return fmt.Errorf("unable to decode component descriptor from %q: %s", o.ComponentDescriptorPath, err.Error())
 	}
 
@@ -244,11 +280,27 @@ func (o *AddOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem
 		return err
 	}
 
+	if o.ResolveDigests {
+		for i, res := range cd.Resources {
+			if _, ok := res.Labels.Get(iv.NameLabel); !ok {
+				continue
+			}
+			if _, err := components.ResolveDigest(ctx, imageResolver, &cd.Resources[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if o.IncludeCosignSignatures {
+		if err := addCosignSignatures(ctx, log, imageResolver, cd); err != nil {
+			return err
+		}
+	}
+
 	if err := cdvalidation.Validate(cd); err != nil {
 		return fmt.Errorf("invalid component descriptor: %w", err)
 	}
 
-	data, err = yaml.Marshal(cd)
+	data, err = schema.EncodeYAML(cd, schemaVersion)
 	if err != nil {
 		return fmt.Errorf("unable to encode component descriptor: %w", err)
 	}
@@ -293,15 +345,89 @@ func (o *AddOptions) AddFlags(set *pflag.FlagSet) {
 	set.StringArrayVar(&o.ParseImageOptions.ExcludeComponentReference, "exclude-component-reference", []string{}, "Specify all image name that should not be added as component reference")
 	set.StringArrayVar(&o.ParseImageOptions.GenericDependencies, "generic-dependency", []string{}, "Specify all image source names that are a generic dependency.")
 	set.StringVar(&o.GenericDependencies, "generic-dependencies", "", "Specify all prefixes that define a image  from another component")
+	set.BoolVar(&o.ResolveDigests, "resolve-digests", false, "resolve the image references of the added resources and pin them to their digests")
+	set.BoolVar(&o.IncludeCosignSignatures, "include-cosign-signatures", false, "add the cosign signatures of the added images as additional resources")
 	o.OciOptions.AddFlags(set)
 }
 
 // parseImageVector parses the given image vector and returns a list of all resources.
 func (o *AddOptions) parseImageVector(ctx context.Context, compResolver ctf.ComponentResolver, cd *cdv2.ComponentDescriptor, fs vfs.FileSystem) error {
-	file, err := fs.Open(o.ImageVectorPath)
+	data, err := vfs.ReadFile(fs, o.ImageVectorPath)
+	if err != nil {
+		return fmt.Errorf("unable to read image vector file: %q: %w", o.ImageVectorPath, err)
+	}
+	data, err = convertDigestEntries(data)
 	if err != nil {
-		return fmt.Errorf("unable to open image vector file: %q: %w", o.ImageVectorPath, err)
+		return fmt.Errorf("unable to parse image vector file %q: %w", o.ImageVectorPath, err)
 	}
-	defer file.Close()
-	return iv.ParseImageVector(ctx, compResolver, cd, file, &o.ParseImageOptions)
+	return iv.ParseImageVector(ctx, compResolver, cd, bytes.NewReader(data), &o.ParseImageOptions)
+}
+
+// convertDigestEntries converts image entries that define their image by "repository" and "digest"
+// into entries that define the digest as tag, which is how digests are handled by the image vector parser.
+// The resources of these entries get the digest as extra identity and a digest based access.
+func convertDigestEntries(data []byte) ([]byte, error) {
+	imageVector := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &imageVector); err != nil {
+		return nil, err
+	}
+	images, ok := imageVector["images"].([]interface{})
+	if !ok {
+		return data, nil
+	}
+	converted := false
+	for _, img := range images {
+		image, ok := img.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		dgst, ok := image["digest"]
+		if !ok {
+			continue
+		}
+		if _, ok := image["tag"]; ok {
+			return nil, fmt.Errorf("image %v defines a tag and a digest: only one of them can be defined", image["name"])
+		}
+		dgstStr, ok := dgst.(string)
+		if !ok {
+			return nil, fmt.Errorf("digest of image %v has to be a string", image["name"])
+		}
+		if _, err := digest.Parse(dgstStr); err != nil {
+			return nil, fmt.Errorf("invalid digest %q of image %v: %w", dgstStr, image["name"], err)
+		}
+		image["tag"] = dgstStr
+		delete(image, "digest")
+		converted = true
+	}
+	if !converted {
+		return data, nil
+	}
+	return json.Marshal(imageVector)
+}
+
+// addCosignSignatures adds the cosign signatures of all images that have been added from the image vector
+// as additional resources. Images without signature are skipped.
+func addCosignSignatures(ctx context.Context, log logr.Logger, resolver ociclient.Resolver, cd *cdv2.ComponentDescriptor) error {
+	images := make([]cdv2.Resource, 0)
+	for _, res := range cd.Resources {
+		if _, ok := res.Labels.Get(iv.NameLabel); ok && res.Access != nil && res.Access.GetType() == cdv2.OCIRegistryType {
+			images = append(images, res)
+		}
+	}
+	for _, res := range images {
+		sigRes, err := components.CosignSignatureResource(ctx, resolver, res)
+		if err != nil {
+			return err
+		}
+		if sigRes == nil {
+			log.V(3).Info("image has no cosign signature", "resource", res.Name)
+			continue
+		}
+		if id := cd.GetResourceIndex(*sigRes); id != -1 {
+			cd.Resources[id] = *sigRes
+		} else {
+			cd.Resources = append(cd.Resources, *sigRes)
+		}
+	}
+	return nil
 }
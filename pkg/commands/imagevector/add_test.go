@@ -7,7 +7,9 @@ package imagevector_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
+	"github.com/containerd/containerd/errdefs"
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/gardener/component-spec/bindings-go/codec"
 	iv "github.com/gardener/image-vector/pkg"
@@ -20,10 +22,20 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 
 	ivcmd "github.com/gardener/component-cli/pkg/commands/imagevector"
+	"github.com/gardener/component-cli/pkg/components"
 )
 
+// imageResolverFunc implements the ociclient.Resolver interface with a function.
+type imageResolverFunc func(ctx context.Context, ref string) (string, ocispecv1.Descriptor, error)
+
+func (f imageResolverFunc) Resolve(ctx context.Context, ref string) (string, ocispecv1.Descriptor, error) {
+	return f(ctx, ref)
+}
+
 var _ = Describe("Add", func() {
 
 	var testdataFs vfs.FileSystem
@@ -120,6 +132,61 @@ var _ = Describe("Add", func() {
 		}))
 	})
 
+	It("should add a image source with a repository and a digest", func() {
+		cd := runAdd(testdataFs, "./00-component/component-descriptor.yaml", "./resources/04-digest.yaml")
+
+		Expect(cd.Resources).To(HaveLen(2))
+		Expect(cd.Resources[0].IdentityObjectMeta).To(MatchFields(IgnoreExtras, Fields{
+			"Name":          Equal("pause-container"),
+			"Version":       Equal("v0.0.0"),
+			"ExtraIdentity": HaveKeyWithValue(iv.TagExtraIdentity, "sha256:179e67c248007299e05791db36298c41cbf0992372204a68473e12795a51b06b"),
+		}))
+		Expect(cd.Resources[0].Access.Object).To(MatchKeys(IgnoreExtras, Keys{
+			"imageReference": Equal("gcr.io/google_containers/pause-amd64@sha256:179e67c248007299e05791db36298c41cbf0992372204a68473e12795a51b06b"),
+		}))
+	})
+
+	It("should fail if a image defines a tag and a digest", func() {
+		opts := &ivcmd.AddOptions{
+			ComponentDescriptorPath: "./00-component/component-descriptor.yaml",
+			ImageVectorPath:         "./resources/05-digest-and-tag.yaml",
+		}
+		Expect(opts.Run(context.TODO(), logr.Discard(), testdataFs)).ToNot(Succeed())
+	})
+
+	It("should add the cosign signatures of signed images", func() {
+		imageDigest := digest.Digest("sha256:179e67c248007299e05791db36298c41cbf0992372204a68473e12795a51b06b")
+		sigDigest := digest.FromString("signature")
+		opts := &ivcmd.AddOptions{
+			IncludeCosignSignatures: true,
+			ImageResolver: imageResolverFunc(func(_ context.Context, ref string) (string, ocispecv1.Descriptor, error) {
+				switch ref {
+				case "gcr.io/google_containers/pause-amd64@" + imageDigest.String():
+					return ref, ocispecv1.Descriptor{Digest: imageDigest}, nil
+				case "gcr.io/google_containers/pause-amd64:sha256-" + imageDigest.Hex() + ".sig":
+					return ref, ocispecv1.Descriptor{Digest: sigDigest}, nil
+				case "example.com/busybox:1.0":
+					return ref, ocispecv1.Descriptor{Digest: digest.FromString("busybox")}, nil
+				}
+				return "", ocispecv1.Descriptor{}, fmt.Errorf("%s: %w", ref, errdefs.ErrNotFound)
+			}),
+		}
+		cd := runAdd(testdataFs, "./00-component/component-descriptor.yaml", "./resources/04-digest.yaml", opts)
+
+		Expect(cd.Resources).To(HaveLen(3))
+		sigRes := cd.Resources[2]
+		Expect(sigRes.IdentityObjectMeta).To(MatchFields(IgnoreExtras, Fields{
+			"Name":          Equal("pause-container-signature"),
+			"Type":          Equal(components.CosignSignatureType),
+			"ExtraIdentity": HaveKeyWithValue(iv.TagExtraIdentity, imageDigest.String()),
+		}))
+		Expect(sigRes.Access.Object).To(MatchKeys(IgnoreExtras, Keys{
+			"imageReference": Equal("gcr.io/google_containers/pause-amd64@" + sigDigest.String()),
+		}))
+		_, ok := sigRes.Labels.Get(components.CosignSignedResourceLabelName)
+		Expect(ok).To(BeTrue())
+	})
+
 	It("should add a image source with a label", func() {
 
 		opts := &ivcmd.AddOptions{
@@ -15,7 +15,6 @@ import (
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/gardener/component-spec/bindings-go/codec"
 	"github.com/gardener/component-spec/bindings-go/ctf"
-	cdoci "github.com/gardener/component-spec/bindings-go/oci"
 	iv "github.com/gardener/image-vector/pkg"
 	"github.com/ghodss/yaml"
 	"github.com/go-logr/logr"
@@ -27,6 +26,7 @@ import (
 	ociopts "github.com/gardener/component-cli/ociclient/options"
 	"github.com/gardener/component-cli/pkg/commands/constants"
 	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/components/schema"
 	"github.com/gardener/component-cli/pkg/logger"
 	"github.com/gardener/component-cli/pkg/utils"
 )
@@ -175,7 +175,7 @@ func (o *GenerateOverwriteOptions) Run(ctx context.Context, log logr.Logger, fs
 	if err != nil {
 		return err
 	}
-	compResolver := cdoci.NewResolver(ociClient).
+	compResolver := schema.NewResolver(ociClient).
 		WithLog(log)
 	if len(os.Getenv(constants.ComponentRepositoryCacheDirEnvVar)) != 0 {
 		compResolver.WithCache(components.NewLocalComponentCache(fs))
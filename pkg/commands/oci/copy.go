@@ -28,6 +28,15 @@ type CopyOptions struct {
 	// TargetRef is the target oci artifact reference where the artifact is copied to.
 	TargetRef string
 
+	// SourceRegistryConfigPath is the path to the dockerconfig.json that is used to pull the source artifact.
+	// Defaults to the registry config of the oci options.
+	SourceRegistryConfigPath string
+	// TargetRegistryConfigPath is the path to the dockerconfig.json that is used to push the target artifact.
+	// Defaults to the registry config of the oci options.
+	TargetRegistryConfigPath string
+	// LayerConcurrency is the maximal number of layers that are copied in parallel.
+	LayerConcurrency int
+
 	// OCIOptions contains all oci client related options.
 	OCIOptions ociopts.Options
 }
@@ -41,6 +50,9 @@ func NewCopyCommand(ctx context.Context) *cobra.Command {
 		Long: `
 Copy copies a artifact from a source to a target registry.
 The artifact is copied without modification.
+
+The credentials for the source and target registry can be configured independently with
+"--source-registry-config" and "--target-registry-config".
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := opts.Complete(args); err != nil {
@@ -59,6 +71,9 @@ The artifact is copied without modification.
 }
 
 func (o *CopyOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.SourceRegistryConfigPath, "source-registry-config", "", "path to the dockerconfig.json with the authentication information for the source registry. Defaults to --registry-config")
+	fs.StringVar(&o.TargetRegistryConfigPath, "target-registry-config", "", "path to the dockerconfig.json with the authentication information for the target registry. Defaults to --registry-config")
+	fs.IntVar(&o.LayerConcurrency, "layer-concurrency", 1, "maximum number of layers that are copied in parallel")
 	o.OCIOptions.AddFlags(fs)
 }
 
@@ -72,13 +87,30 @@ func (o *CopyOptions) Complete(args []string) error {
 }
 
 func (o *CopyOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
-	ociClient, _, err := o.OCIOptions.Build(log, fs)
+	srcClient, err := o.buildClient(log, fs, o.SourceRegistryConfigPath)
 	if err != nil {
-		return fmt.Errorf("unable to build oci client: %s", err.Error())
+		return fmt.Errorf("unable to build source oci client: %s", err.Error())
 	}
-	if err := ociclient.Copy(ctx, ociClient, o.SourceRef, o.TargetRef); err != nil {
+	tgtClient, err := o.buildClient(log, fs, o.TargetRegistryConfigPath)
+	if err != nil {
+		return fmt.Errorf("unable to build target oci client: %s", err.Error())
+	}
+	if err := ociclient.CopyWithClients(ctx, srcClient, tgtClient, o.SourceRef, o.TargetRef, ociclient.WithLayerConcurrency(o.LayerConcurrency)); err != nil {
 		return err
 	}
 	fmt.Printf("Successfully copied %q to %q", o.SourceRef, o.TargetRef)
 	return nil
 }
+
+// buildClient builds an oci client that uses the given registry config instead of the default one.
+func (o *CopyOptions) buildClient(log logr.Logger, fs vfs.FileSystem, registryConfigPath string) (ociclient.Client, error) {
+	opts := o.OCIOptions
+	if len(registryConfigPath) != 0 {
+		opts.RegistryConfigPath = registryConfigPath
+	}
+	ociClient, _, err := opts.Build(log, fs)
+	if err != nil {
+		return nil, err
+	}
+	return ociClient, nil
+}
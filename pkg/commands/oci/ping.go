@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/ociclient"
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// PingOptions defines all options for the ping command.
+type PingOptions struct {
+	// Registry is the registry host with an optional repository.
+	Registry string
+
+	// OCIOptions contains all oci client related options.
+	OCIOptions ociopts.Options
+}
+
+// NewPingCommand creates a new command that diagnoses the connection to a registry.
+func NewPingCommand(ctx context.Context) *cobra.Command {
+	opts := &PingOptions{}
+	cmd := &cobra.Command{
+		Use:   "ping REGISTRY",
+		Args:  cobra.ExactArgs(1),
+		Short: "Diagnoses the connection to a registry",
+		Long: `
+ping checks the connection to a registry and prints a diagnostic report.
+
+The report contains
+- the reachability of the api version check endpoint (/v2/)
+- the details of the tls connection and the presented certificates
+- the auth challenge of the registry and whether the configured credentials are accepted
+- whether the registry supports listing repositories (catalog)
+- whether the registry supports the referrers api (only if the registry contains a repository, e.g. "example.com/my/repo")
+
+The command fails if any of the checks could not be executed.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+func (o *PingOptions) AddFlags(fs *pflag.FlagSet) {
+	o.OCIOptions.AddFlags(fs)
+}
+
+func (o *PingOptions) Complete(args []string) error {
+	o.Registry = args[0]
+	return nil
+}
+
+func (o *PingOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ociClient, _, err := o.OCIOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	pinger, ok := ociClient.(ociclient.PingInterface)
+	if !ok {
+		return errors.New("the oci client does not support registry diagnostics")
+	}
+
+	report, err := pinger.Ping(ctx, o.Registry)
+	if err != nil {
+		return fmt.Errorf("unable to ping registry %q: %w", o.Registry, err)
+	}
+	data, err := yaml.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("unable to marshal report: %w", err)
+	}
+	fmt.Println(string(data))
+
+	if len(report.Errors) != 0 {
+		return fmt.Errorf("%d checks of registry %q failed", len(report.Errors), o.Registry)
+	}
+	return nil
+}
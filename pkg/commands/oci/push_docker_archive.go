@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/ociclient"
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/logger"
+)
+
+// PushDockerArchiveOptions defines all options for the push-docker-archive command.
+type PushDockerArchiveOptions struct {
+	// ArchivePath is the path to the docker save or oci image layout tarball.
+	ArchivePath string
+	// Ref is the oci artifact reference the image is pushed to.
+	Ref string
+
+	// OCIOptions contains all oci client related options.
+	OCIOptions ociopts.Options
+}
+
+// NewPushDockerArchiveCommand creates a new command that pushes the image of a docker save tarball.
+func NewPushDockerArchiveCommand(ctx context.Context) *cobra.Command {
+	opts := &PushDockerArchiveOptions{}
+	cmd := &cobra.Command{
+		Use:   "push-docker-archive ARCHIVE_PATH ARTIFACT_REFERENCE",
+		Args:  cobra.ExactArgs(2),
+		Short: "Pushes the image of a docker save or oci image layout tarball to a registry",
+		Long: `
+push-docker-archive reads a tarball that was created with "docker save" or that contains an oci image layout
+and pushes the contained image to the given reference.
+
+Images of "docker save" tarballs are converted to oci image manifests.
+The media types of the layers are detected from the compression of the layer content.
+Oci image layouts are pushed as they are, including multi-arch image indexes.
+
+The tarball has to contain exactly one image.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		},
+	}
+	opts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+func (o *PushDockerArchiveOptions) AddFlags(fs *pflag.FlagSet) {
+	o.OCIOptions.AddFlags(fs)
+}
+
+func (o *PushDockerArchiveOptions) Complete(args []string) error {
+	o.ArchivePath = args[0]
+	o.Ref = args[1]
+	return nil
+}
+
+func (o *PushDockerArchiveOptions) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	ociClient, _, err := o.OCIOptions.Build(log, fs)
+	if err != nil {
+		return fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+
+	file, err := fs.Open(o.ArchivePath)
+	if err != nil {
+		return fmt.Errorf("unable to open archive %q: %w", o.ArchivePath, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to get info for archive %q: %w", o.ArchivePath, err)
+	}
+
+	desc, err := ociclient.PushDockerArchive(ctx, ociClient, file, info.Size(), o.Ref)
+	if err != nil {
+		return fmt.Errorf("unable to push archive %q to %q: %w", o.ArchivePath, o.Ref, err)
+	}
+	fmt.Printf("Successfully pushed %s to %s (%s)\n", o.ArchivePath, o.Ref, desc.Digest)
+	return nil
+}
@@ -0,0 +1,467 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/pkg/transport/config"
+	"github.com/gardener/component-cli/pkg/transport/merge"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/downloaders"
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/uploaders"
+	processutils "github.com/gardener/component-cli/pkg/transport/process/utils"
+	"github.com/gardener/component-cli/pkg/transport/report"
+	"github.com/gardener/component-cli/pkg/transport/state"
+	"github.com/gardener/component-cli/pkg/transport/worker"
+)
+
+// pipelineFactory creates the processing pipelines of resources from the transport config.
+type pipelineFactory struct {
+	cfg     *config.ParsedTransportConfig
+	client  ociclient.Client
+	cache   cache.Cache
+	targets map[string]*cdv2.OCIRegistryRepository
+	// resolver resolves the existing component descriptors of the targets.
+	resolver ctf.ComponentResolver
+	// targetCds are the existing component descriptors by target and component version.
+	// Component versions that do not exist in the target are stored as nil.
+	targetCds    map[string]*cdv2.ComponentDescriptor
+	targetCdsMux sync.Mutex
+
+	downloaderFactory *downloaders.DownloaderFactory
+	processorFactory  *processors.ProcessorFactory
+	// uploaderFactories are the uploader factories by target name.
+	uploaderFactories    map[string]*uploaders.UploaderFactory
+	uploaderFactoriesMux sync.Mutex
+	// state records the processing status of all resources.
+	state *state.State
+	blobs *blobRecorder
+	// report records the stages of all pipelines.
+	report *report.Report
+}
+
+func newPipelineFactory(cfg *config.ParsedTransportConfig, client ociclient.Client, ocicache cache.Cache, resolver ctf.ComponentResolver, targets map[string]*cdv2.OCIRegistryRepository, s *state.State, blobs *blobRecorder, r *report.Report) *pipelineFactory {
+	return &pipelineFactory{
+		cfg:               cfg,
+		client:            client,
+		cache:             ocicache,
+		targets:           targets,
+		resolver:          resolver,
+		targetCds:         map[string]*cdv2.ComponentDescriptor{},
+		downloaderFactory: downloaders.NewDownloaderFactory(client, ocicache),
+		processorFactory:  processors.NewProcessorFactory(client, ocicache),
+		uploaderFactories: map[string]*uploaders.UploaderFactory{},
+		state:             s,
+		blobs:             blobs,
+		report:            r,
+	}
+}
+
+// uploaderFactory returns the uploader factory of the target.
+// Uploaders of targets without repository are created without target repository context.
+func (f *pipelineFactory) uploaderFactory(target string) *uploaders.UploaderFactory {
+	f.uploaderFactoriesMux.Lock()
+	defer f.uploaderFactoriesMux.Unlock()
+	if uf, ok := f.uploaderFactories[target]; ok {
+		return uf
+	}
+	targetCtx := cdv2.OCIRegistryRepository{}
+	if repoCtx, ok := f.targets[target]; ok {
+		targetCtx = *repoCtx
+	}
+	uf := uploaders.NewUploaderFactory(f.client, f.cache, targetCtx).WithMergeStrategy(f.cfg.MergeStrategy)
+	f.uploaderFactories[target] = uf
+	return uf
+}
+
+// targetComponentDescriptor returns the existing component descriptor of the component version in the target
+// or nil if the component version does not exist in the target.
+func (f *pipelineFactory) targetComponentDescriptor(ctx context.Context, target string, cd cdv2.ComponentDescriptor) (*cdv2.ComponentDescriptor, error) {
+	f.targetCdsMux.Lock()
+	defer f.targetCdsMux.Unlock()
+	key := fmt.Sprintf("%s/%s:%s", target, cd.Name, cd.Version)
+	if targetCd, ok := f.targetCds[key]; ok {
+		return targetCd, nil
+	}
+	targetCd, err := f.resolver.Resolve(ctx, f.targets[target], cd.Name, cd.Version)
+	if err != nil {
+		if !merge.IsNotFound(err) {
+			return nil, fmt.Errorf("unable to resolve component descriptor of target %q: %w", target, err)
+		}
+		targetCd = nil
+	}
+	f.targetCds[key] = targetCd
+	return targetCd, nil
+}
+
+// Create creates the pipeline of a resource that uploads the resource with the uploaders of every matching target.
+// The processed resource is stamped with the processing digest of the transport config (see config.ProcessingDigest),
+// the resource is not processed again if it is already stamped with the digest in all targets.
+func (f *pipelineFactory) Create(cd cdv2.ComponentDescriptor, res cdv2.Resource) (process.MultiTargetResourceProcessingPipeline, error) {
+	processingDigest, err := f.cfg.ProcessingDigest(cd, res)
+	if err != nil {
+		return nil, err
+	}
+
+	dls := f.cfg.MatchDownloaders(cd, res)
+	if len(dls) != 1 {
+		return nil, fmt.Errorf("%d downloaders match the resource, but exactly 1 downloader is required", len(dls))
+	}
+	downloader, err := f.downloaderFactory.Create(dls[0].Type, dls[0].Spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create downloader %s: %w", dls[0].Name, err)
+	}
+	procs := []process.ResourceStreamProcessor{f.report.Stage(cd, res, report.StageKindDownloader, dls[0].Name, downloader)}
+
+	for _, rule := range f.cfg.MatchProcessingRules(cd, res) {
+		for _, procDef := range rule.Processors {
+			proc, err := f.processorFactory.Create(procDef.Type, procDef.Spec)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create processor %s of processing rule %s: %w", procDef.Name, rule.Name, err)
+			}
+			procs = append(procs, f.report.Stage(cd, res, report.StageKindProcessor, procDef.Name, proc))
+		}
+	}
+	procs = append(procs, processors.NewProcessingStamper(processingDigest))
+
+	// the status of a stage is recorded by the last processor of the stage
+	procs[0] = f.state.StatusRecorder(cd, res, procs[0], state.StatusDownloaded)
+	procs[len(procs)-1] = f.state.StatusRecorder(cd, res, procs[len(procs)-1], state.StatusProcessed)
+
+	targetNames, uls := f.cfg.MatchUploadersByTarget(cd, res)
+	if len(targetNames) == 0 {
+		return nil, errors.New("no uploader matches the resource")
+	}
+	puls := f.cfg.MatchPostUploadersByTarget(cd, res)
+	for target := range puls {
+		if _, ok := uls[target]; !ok {
+			return nil, fmt.Errorf("post uploaders of target %q match the resource, but no uploader of the target", target)
+		}
+	}
+
+	targets := make([]process.ProcessingTarget, 0, len(targetNames))
+	for _, name := range targetNames {
+		uf := f.uploaderFactory(name)
+		target := process.ProcessingTarget{Name: name}
+		for _, ulDef := range uls[name] {
+			ul, err := uf.Create(ulDef.Type, ulDef.Spec)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create uploader %s: %w", ulDef.Name, err)
+			}
+			target.Uploaders = append(target.Uploaders, f.report.Stage(cd, res, report.StageKindUploader, ulDef.Name, ul))
+		}
+		// the blobs of local oci blob resources are recorded after the last uploader
+		// so that they can be added as layers to the component descriptor of the target.
+		target.Uploaders = append(target.Uploaders, f.blobs.forTarget(name))
+		for _, pulDef := range puls[name] {
+			pul, err := uf.Create(pulDef.Type, pulDef.Spec)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create post uploader %s: %w", pulDef.Name, err)
+			}
+			target.PostUploaders = append(target.PostUploaders, f.report.Stage(cd, res, report.StageKindPostUploader, pulDef.Name, pul))
+		}
+		targets = append(targets, target)
+	}
+
+	return f.report.MultiTargetPipeline(&resumablePipeline{
+		state:   f.state,
+		blobs:   f.blobs,
+		targets: targetNames,
+		pipeline: &stampedPipeline{
+			factory:  f,
+			digest:   processingDigest,
+			targets:  targetNames,
+			pipeline: process.NewMultiTargetResourceProcessingPipeline(procs, targets...),
+		},
+	}), nil
+}
+
+// stampedPipeline skips resources that have already been processed with the same processing config.
+// A resource is skipped if the resource in the existing component descriptors of all targets is stamped
+// with the processing digest. The resources of the targets are returned unchanged then,
+// the blobs of local oci blob resources are taken from the existing component descriptors.
+type stampedPipeline struct {
+	factory  *pipelineFactory
+	digest   string
+	targets  []string
+	pipeline process.MultiTargetResourceProcessingPipeline
+}
+
+func (p *stampedPipeline) Process(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) ([]process.TargetResult, error) {
+	results, err := p.processedResults(ctx, cd, res)
+	if err != nil {
+		return nil, err
+	}
+	if results != nil {
+		logr.FromContextOrDiscard(ctx).V(3).Info("resource has already been processed with the same processing config", "resource", res.Name)
+		return results, nil
+	}
+	return p.pipeline.Process(ctx, cd, res)
+}
+
+// processedResults returns the resources of all targets if the resource has already been processed
+// with the processing digest in every target. Otherwise nil is returned.
+func (p *stampedPipeline) processedResults(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) ([]process.TargetResult, error) {
+	if p.factory.resolver == nil {
+		return nil, nil
+	}
+	results := make([]process.TargetResult, 0, len(p.targets))
+	for _, target := range p.targets {
+		if _, ok := p.factory.targets[target]; !ok {
+			// resources of targets without repository cannot be looked up
+			return nil, nil
+		}
+		targetCd, err := p.factory.targetComponentDescriptor(ctx, target, cd)
+		if err != nil {
+			// the component descriptor cannot be uploaded to the target without resolving the existing one
+			return nil, worker.Fatal(err)
+		}
+		if !processutils.IsAlreadyProcessed(targetCd, res, p.digest) {
+			return nil, nil
+		}
+		targetRes := targetCd.Resources[targetCd.GetResourceIndex(res)]
+		processed, err := p.isArtifactProcessed(ctx, targetRes)
+		if err != nil {
+			return nil, err
+		}
+		if !processed {
+			return nil, nil
+		}
+		results = append(results, process.TargetResult{
+			Target:    target,
+			Resources: []cdv2.Resource{targetRes},
+		})
+	}
+	return results, nil
+}
+
+// isArtifactProcessed returns whether the oci artifact of an oci registry resource is stamped with the processing digest.
+// The stamps of oci artifacts can only be checked if the client supports referrers, otherwise the stamp of the
+// resource in the component descriptor is trusted.
+func (p *stampedPipeline) isArtifactProcessed(ctx context.Context, res cdv2.Resource) (bool, error) {
+	if _, ok := p.factory.client.(ociclient.ReferrersInterface); !ok {
+		return true, nil
+	}
+	if res.Access == nil || res.Access.GetType() != cdv2.OCIRegistryType {
+		return true, nil
+	}
+	ociAccess := &cdv2.OCIRegistryAccess{}
+	if err := res.Access.DecodeInto(ociAccess); err != nil {
+		return false, fmt.Errorf("unable to decode access of resource %s: %w", res.Name, err)
+	}
+	return uploaders.IsOCIArtifactAlreadyProcessed(ctx, p.factory.client, ociAccess.ImageReference, p.digest)
+}
+
+// resumablePipeline records the results of all targets of a resource in the state.
+// Resources that have been uploaded to all targets in a previous run are not processed again.
+// Instead, the recorded results are returned and the recorded blobs of local oci blob resources are added
+// to the blob recorder, so that they are added as layers to the component descriptors of the targets.
+type resumablePipeline struct {
+	state    *state.State
+	blobs    *blobRecorder
+	targets  []string
+	pipeline process.MultiTargetResourceProcessingPipeline
+}
+
+func (p *resumablePipeline) Process(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) ([]process.TargetResult, error) {
+	if results, ok := p.recordedResults(cd, res); ok {
+		return results, nil
+	}
+
+	results, err := p.pipeline.Process(ctx, cd, res)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]state.TargetState, 0, len(results))
+	for _, result := range results {
+		targets = append(targets, state.TargetState{
+			Target:              result.Target,
+			ComponentDescriptor: result.ComponentDescriptor,
+			Resources:           result.Resources,
+			Blobs:               p.blobs.forResources(result.Target, result.Resources),
+		})
+	}
+	if err := p.state.SetTargetsUploaded(cd, res, targets); err != nil {
+		return nil, fmt.Errorf("unable to record status of resource %s: %w", res.Name, err)
+	}
+	return results, nil
+}
+
+// recordedResults returns the recorded results of the resource if it has been uploaded to all targets of the pipeline.
+func (p *resumablePipeline) recordedResults(cd cdv2.ComponentDescriptor, res cdv2.Resource) ([]process.TargetResult, bool) {
+	resState, ok := p.state.Get(cd, res)
+	if !ok || resState.Status != state.StatusUploaded {
+		return nil, false
+	}
+	recorded := map[string]state.TargetState{}
+	for _, target := range resState.Targets {
+		recorded[target.Target] = target
+	}
+	results := make([]process.TargetResult, 0, len(p.targets))
+	for _, name := range p.targets {
+		target, ok := recorded[name]
+		if !ok {
+			// the target has been added since the previous run
+			return nil, false
+		}
+		results = append(results, process.TargetResult{
+			Target:              target.Target,
+			ComponentDescriptor: target.ComponentDescriptor,
+			Resources:           target.Resources,
+		})
+	}
+	for _, name := range p.targets {
+		for _, desc := range recorded[name].Blobs {
+			p.blobs.add(name, desc)
+		}
+	}
+	return results, true
+}
+
+// streamPipeline processes the resources of stream requests with the pipelines of the transport config.
+// The resources that are produced by the uploaders of all targets are returned.
+type streamPipeline struct {
+	factory *pipelineFactory
+}
+
+func (p *streamPipeline) Process(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) (*cdv2.ComponentDescriptor, []cdv2.Resource, error) {
+	pipeline, err := p.factory.Create(cd, res)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create pipeline: %w", err)
+	}
+	results, err := pipeline.Process(ctx, cd, res)
+	if err != nil {
+		return nil, nil, err
+	}
+	processedCd := &cd
+	var resources []cdv2.Resource
+	for _, result := range results {
+		if result.ComponentDescriptor != nil {
+			processedCd = result.ComponentDescriptor
+		}
+		resources = append(resources, result.Resources...)
+	}
+	return processedCd, resources, nil
+}
+
+// blobRecorder records the blobs of the local oci blob resources that are uploaded for a target.
+type blobRecorder struct {
+	mux sync.Mutex
+	// blobs are the descriptors of the uploaded blobs by target and digest.
+	blobs map[string]map[string]ocispecv1.Descriptor
+}
+
+func newBlobRecorder() *blobRecorder {
+	return &blobRecorder{
+		blobs: map[string]map[string]ocispecv1.Descriptor{},
+	}
+}
+
+// forTarget returns a processor that records the blobs of the target and passes the processor message unchanged.
+func (r *blobRecorder) forTarget(target string) process.ResourceStreamProcessor {
+	return &blobRecordingProcessor{
+		recorder: r,
+		target:   target,
+	}
+}
+
+// Get returns the descriptor of a recorded blob of the target.
+func (r *blobRecorder) Get(target, dgst string) (ocispecv1.Descriptor, bool) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	desc, ok := r.blobs[target][dgst]
+	return desc, ok
+}
+
+// forResources returns the descriptors of the recorded blobs of the local oci blob resources of the target.
+func (r *blobRecorder) forResources(target string, resources []cdv2.Resource) []ocispecv1.Descriptor {
+	var descs []ocispecv1.Descriptor
+	for _, res := range resources {
+		if res.Access == nil || res.Access.GetType() != cdv2.LocalOCIBlobType {
+			continue
+		}
+		localBlob := &cdv2.LocalOCIBlobAccess{}
+		if err := res.Access.DecodeInto(localBlob); err != nil {
+			continue
+		}
+		if desc, ok := r.Get(target, localBlob.Digest); ok {
+			descs = append(descs, desc)
+		}
+	}
+	return descs
+}
+
+func (r *blobRecorder) add(target string, desc ocispecv1.Descriptor) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if _, ok := r.blobs[target]; !ok {
+		r.blobs[target] = map[string]ocispecv1.Descriptor{}
+	}
+	r.blobs[target][desc.Digest.String()] = desc
+}
+
+type blobRecordingProcessor struct {
+	recorder *blobRecorder
+	target   string
+}
+
+func (p *blobRecordingProcessor) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, blobreader, err := processutils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if blobreader != nil {
+		defer blobreader.Close()
+	}
+
+	if res.Access != nil && res.Access.GetType() == cdv2.LocalOCIBlobType {
+		if blobreader == nil {
+			return fmt.Errorf("resource %s has a local oci blob access but no blob", res.Name)
+		}
+		localBlob := &cdv2.LocalOCIBlobAccess{}
+		if err := res.Access.DecodeInto(localBlob); err != nil {
+			return fmt.Errorf("unable to decode access of resource %s: %w", res.Name, err)
+		}
+		dgst, err := digest.Parse(localBlob.Digest)
+		if err != nil {
+			return fmt.Errorf("unable to parse digest of resource %s: %w", res.Name, err)
+		}
+		size, err := blobreader.Seek(0, io.SeekEnd)
+		if err != nil {
+			return fmt.Errorf("unable to get blob size: %w", err)
+		}
+		if _, err := blobreader.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("unable to seek to beginning of blob: %w", err)
+		}
+		p.recorder.add(p.target, ocispecv1.Descriptor{
+			MediaType: res.Type,
+			Digest:    dgst,
+			Size:      size,
+		})
+	}
+
+	var blob io.Reader
+	if blobreader != nil {
+		blob = blobreader
+	}
+	if err := processutils.WriteProcessorMessage(*cd, res, blob, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,439 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/logger"
+	"github.com/gardener/component-cli/pkg/transport/config"
+	"github.com/gardener/component-cli/pkg/transport/filters"
+	"github.com/gardener/component-cli/pkg/transport/inventory"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/report"
+	"github.com/gardener/component-cli/pkg/transport/state"
+	"github.com/gardener/component-cli/pkg/transport/stream"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// Options defines the options of the transport command.
+type Options struct {
+	ComponentName    string
+	ComponentVersion string
+	// SourceRepository is the base url of the repository the components are transported from.
+	SourceRepository string
+	// TargetRepositories are the repositories the component descriptors are uploaded to.
+	// An entry is either the base url of the default target or of the form "<target>=<base url>"
+	// for a target that is referenced by uploaders of the transport config.
+	TargetRepositories []string
+	// TransportConfigPath is the path to the transport config that defines the downloaders, processors and uploaders.
+	TransportConfigPath string
+	// Stream reads processing requests from stdin and writes the results to stdout instead of transporting a component.
+	Stream bool
+	// StateFile is the path to the file that records the processing status of all resources,
+	// so that a failed transport can be re-run and skips all resources that have already been uploaded.
+	StateFile string
+	// Verify verifies the uploaded artifacts in the target repositories after the transport.
+	Verify bool
+	// MaxWorkers is the max number of resources that are processed concurrently.
+	MaxWorkers int
+	// FailFast aborts the processing of all remaining resources in stream mode as soon as a resource could not be processed.
+	FailFast bool
+	// MaxBlobMemory is the max size of the blobs that all pipelines keep in memory (e.g. 512Mi).
+	// Blobs are always written to temporary files if empty.
+	MaxBlobMemory string
+	// MaxBlobDisk is the max size of the temporary files of all pipelines (e.g. 20Gi).
+	// The size of the temporary files is not limited if empty.
+	MaxBlobDisk string
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+	// Report configures the report file of the transport.
+	Report report.Options
+	// RequireSigned configures the transport to reject components without the required signatures.
+	RequireSigned state.RequireSignedOptions
+	// Selector restricts the transport to the resources of specific components and to specific resources.
+	Selector filters.SelectorOptions
+
+	// targets are the parsed target repositories by target name.
+	targets map[string]*cdv2.OCIRegistryRepository
+	// selector is the filter of the selected resources. All resources are selected if nil.
+	selector filters.Filter
+	// blobBudget is the budget that is shared by all pipelines. The blob usage is not limited if nil.
+	blobBudget *process.BlobBudget
+}
+
+// NewTransportCommand creates a new transport command.
+func NewTransportCommand(ctx context.Context) *cobra.Command {
+	opts := &Options{}
+	cmd := &cobra.Command{
+		Use:   "transport [COMPONENT_NAME VERSION] --from SOURCE_REPOSITORY --to TARGET_REPOSITORY --transport-cfg CONFIG",
+		Args:  cobra.RangeArgs(0, 2),
+		Short: "transports a component and all its referenced components from a source to a target repository",
+		Long: `
+transports a component descriptor, all transitively referenced component descriptors and their resources
+from the source repository to one or more target repositories.
+
+Every resource is processed by the downloader, the processors and the uploaders that match the resource
+in the transport config. The resulting resources replace the source resources in the component descriptor
+that is uploaded to the target repository.
+
+Uploaders can be assigned to a named target with the "target" attribute in the transport config.
+The resources are downloaded and processed only once and are then uploaded by the uploaders of every target.
+The repository of a named target is defined with "--to <target>=<base url>", the repository of the
+uploaders without target with "--to <base url>".
+Component descriptors are uploaded to every target with a repository. Targets without repository,
+e.g. targets that write ctf archives, only run their uploaders.
+
+Processed resources are stamped with the digest of the matching downloaders, processors and uploaders
+in the label "transport.gardener.cloud/processing-digest". Resources that are already stamped with the same digest
+in the component descriptors of all targets are not processed again.
+
+The inventory of the component versions that have been transported to the default target is published
+to the "inventoryRef" of the transport config.
+
+With "--stream", processing requests are read as json lines from stdin instead of transporting a component.
+A request contains a component descriptor and optionally the identities of the resources that are processed:
+
+  {"id": "1", "componentDescriptor": {...}, "resources": [{"name": "image"}]}
+
+A result json line is written to stdout for every processed resource, so that orchestrators can drive
+and monitor the transport. Only the resources are uploaded in stream mode, the component descriptors are not.
+
+With "--require-signed", the signatures of all components are verified with the signature policy and the
+digests of all component references are compared with the referenced components before any resource is processed.
+
+With "--state-file", the processing status and the results of all resources are recorded in the state file.
+A re-run of a failed transport with the same state file skips all resources that have already been uploaded
+to all targets and uses the recorded results instead, including the modifications of processors.
+
+With "--only-components" and "--only-resources", only the selected resources are processed.
+The complete component tree is still resolved and uploaded, the resources that are not selected are kept
+unchanged in the uploaded component descriptors.
+
+With "--verify", the uploaded component descriptors and resources are resolved in the repositories of all targets
+after the transport and their digests are compared with the expected ones. The results are recorded in the report
+and the command fails if an artifact is missing or does not match.
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Complete(args); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			if err := opts.Run(ctx, logger.Log, osfs.New()); err != nil {
+				logger.Log.Error(err, "")
+				os.Exit(1)
+			}
+		},
+	}
+
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// Run transports the component and all its referenced components.
+// In stream mode, the processing requests are read from stdin and the results are written to stdout.
+func (o *Options) Run(ctx context.Context, log logr.Logger, fs vfs.FileSystem) error {
+	if o.Stream {
+		return o.RunStream(ctx, log, fs, os.Stdin, os.Stdout)
+	}
+	ctx = o.newContext(ctx, log)
+	if err := o.RequireSigned.Complete(fs); err != nil {
+		return err
+	}
+	transportCfg, ociClient, cache, err := o.build(log, fs)
+	if err != nil {
+		return err
+	}
+	defer cache.Close()
+	if _, ok := o.targets[""]; len(transportCfg.InventoryRef) != 0 && !ok {
+		return errors.New("an inventory can only be published if the repository of the default target is defined")
+	}
+
+	r := report.New()
+	err = o.transport(ctx, transportCfg, ociClient, cache, fs, r)
+	return o.writeReport(log, fs, r, err)
+}
+
+// RunStream reads processing requests as json lines from in, processes the requested resources
+// with the downloaders, processors and uploaders of the transport config and writes a result json line
+// for every resource to out (see the stream package).
+// Component descriptors are not uploaded in stream mode.
+func (o *Options) RunStream(ctx context.Context, log logr.Logger, fs vfs.FileSystem, in io.Reader, out io.Writer) error {
+	ctx = o.newContext(ctx, log)
+	transportCfg, ociClient, cache, err := o.build(log, fs)
+	if err != nil {
+		return err
+	}
+	defer cache.Close()
+
+	s, err := state.Load(fs, o.StateFile)
+	if err != nil {
+		return err
+	}
+	r := report.New()
+	pipeline := &streamPipeline{
+		factory: newPipelineFactory(transportCfg, ociClient, cache, schema.NewResolver(ociClient), o.targets, s, newBlobRecorder(), r),
+	}
+	err = stream.Process(ctx, in, out, pipeline, stream.Options{
+		MaxWorkers: o.MaxWorkers,
+		FailFast:   o.FailFast,
+	})
+	return o.writeReport(log, fs, r, err)
+}
+
+// newContext returns the context of the transport with the logger and the blob budget of all pipelines.
+func (o *Options) newContext(ctx context.Context, log logr.Logger) context.Context {
+	ctx = logr.NewContext(ctx, log)
+	if o.blobBudget != nil {
+		ctx = process.WithBlobBudget(ctx, o.blobBudget)
+	}
+	return ctx
+}
+
+// build parses the transport config and creates the oci client and cache.
+func (o *Options) build(log logr.Logger, fs vfs.FileSystem) (*config.ParsedTransportConfig, ociclient.ExtendedClient, cache.Cache, error) {
+	transportCfg, err := config.ParseTransportConfig(o.TransportConfigPath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to parse transport config: %w", err)
+	}
+	ociClient, cache, err := o.OciOptions.Build(log, fs, transportCfg.RegistryLimits)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	return transportCfg, ociClient, cache, nil
+}
+
+// writeReport finishes and writes the report and returns the error of the transport.
+func (o *Options) writeReport(log logr.Logger, fs vfs.FileSystem, r *report.Report, err error) error {
+	r.Finish()
+	if reportErr := o.Report.WriteReport(fs, r); reportErr != nil {
+		if err != nil {
+			log.Error(reportErr, "unable to write report")
+			return err
+		}
+		return reportErr
+	}
+	return err
+}
+
+// transport resolves the component tree from the source repository and transports all its components.
+// The processing of all resources is recorded in the report.
+func (o *Options) transport(ctx context.Context, transportCfg *config.ParsedTransportConfig, ociClient ociclient.Client, ocicache cache.Cache, fs vfs.FileSystem, r *report.Report) error {
+	resolver := schema.NewResolver(ociClient)
+	srcRepoCtx := cdv2.NewOCIRegistryRepository(o.SourceRepository, "")
+	cds, err := resolveComponentTree(ctx, resolver, srcRepoCtx, o.ComponentName, o.ComponentVersion)
+	if err != nil {
+		return err
+	}
+
+	s, err := state.Load(fs, o.StateFile)
+	if err != nil {
+		return err
+	}
+	// the root component is the last one of the resolved component tree
+	err = o.RequireSigned.Verify(ctx, s, resolver, cds[len(cds)-1])
+	r.SetSignatures(s.Signatures())
+	if err != nil {
+		return err
+	}
+
+	t := newTransporter(transportCfg, ociClient, ocicache, resolver, o.targets, o.selector, o.MaxWorkers, s, r)
+	for _, cd := range cds {
+		if err := t.transport(ctx, cd); err != nil {
+			return fmt.Errorf("unable to transport component %s:%s: %w", cd.Name, cd.Version, err)
+		}
+	}
+
+	fmt.Printf("Successfully transported %d component descriptors of %s:%s from %s\n", len(cds), o.ComponentName, o.ComponentVersion, o.SourceRepository)
+
+	if o.Verify {
+		if err := o.verify(ctx, ociClient, resolver, s, t, r); err != nil {
+			return err
+		}
+	}
+
+	if len(transportCfg.InventoryRef) != 0 {
+		inv, err := inventory.New(ctx, ociClient, *o.targets[""], t.uploaded[""])
+		if err != nil {
+			return fmt.Errorf("unable to create inventory: %w", err)
+		}
+		desc, err := inventory.Publish(ctx, ociClient, transportCfg.InventoryRef, inv)
+		if err != nil {
+			return fmt.Errorf("unable to publish inventory: %w", err)
+		}
+		fmt.Printf("Published the inventory of %d component versions to %s@%s\n", len(inv.Components), transportCfg.InventoryRef, desc.Digest)
+	}
+	return nil
+}
+
+// verify verifies the uploaded component descriptors and resources in the repositories of all targets
+// and records the results in the report.
+func (o *Options) verify(ctx context.Context, ociClient ociclient.Client, resolver ctf.ComponentResolver, s *state.State, t *transporter, r *report.Report) error {
+	targetNames := make([]string, 0, len(o.targets))
+	for target := range o.targets {
+		targetNames = append(targetNames, target)
+	}
+	sort.Strings(targetNames)
+
+	var errs []error
+	for _, target := range targetNames {
+		err := s.VerifyTarget(ctx, ociClient, resolver, *o.targets[target], t.uploaded[target])
+		r.AddVerification(target, s.Verification())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to verify target %q: %w", target, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully verified the uploaded artifacts of %d targets\n", len(targetNames))
+	return nil
+}
+
+// Complete parses the given command arguments and applies default options.
+func (o *Options) Complete(args []string) error {
+	if len(args) == 2 {
+		o.ComponentName = args[0]
+		o.ComponentVersion = args[1]
+	}
+
+	var err error
+	o.OciOptions.CacheDir, err = utils.CacheDir()
+	if err != nil {
+		return fmt.Errorf("unable to get oci cache directory: %w", err)
+	}
+
+	return o.Validate()
+}
+
+// Validate validates the options and parses the target repositories.
+func (o *Options) Validate() error {
+	if o.Stream {
+		if len(o.ComponentName) != 0 || len(o.ComponentVersion) != 0 {
+			return errors.New("no component must be specified in stream mode as the components are read from stdin")
+		}
+		if o.RequireSigned.RequireSigned {
+			return errors.New("signed components cannot be required in stream mode")
+		}
+		if o.Verify {
+			return errors.New("the targets cannot be verified in stream mode as no component descriptors are uploaded")
+		}
+		if !o.Selector.IsEmpty() {
+			return errors.New("resources cannot be selected in stream mode as the resources are defined by the requests")
+		}
+	} else {
+		if len(o.ComponentName) == 0 {
+			return errors.New("a component name has to be specified")
+		}
+		if len(o.ComponentVersion) == 0 {
+			return errors.New("a component version has to be specified")
+		}
+		if len(o.SourceRepository) == 0 {
+			return errors.New("a source repository has to be specified")
+		}
+	}
+	if len(o.TransportConfigPath) == 0 {
+		return errors.New("a transport config has to be specified")
+	}
+	if o.MaxWorkers < 0 {
+		return errors.New("the max number of workers must not be negative")
+	}
+	if err := o.Report.Validate(); err != nil {
+		return err
+	}
+	o.blobBudget = nil
+	if len(o.MaxBlobMemory) != 0 || len(o.MaxBlobDisk) != 0 {
+		memoryLimit, err := parseSize(o.MaxBlobMemory)
+		if err != nil {
+			return fmt.Errorf("invalid max blob memory: %w", err)
+		}
+		diskLimit, err := parseSize(o.MaxBlobDisk)
+		if err != nil {
+			return fmt.Errorf("invalid max blob disk: %w", err)
+		}
+		o.blobBudget = process.NewBlobBudget(memoryLimit, diskLimit)
+	}
+	o.selector = nil
+	if !o.Selector.IsEmpty() {
+		selector, err := o.Selector.Build()
+		if err != nil {
+			return err
+		}
+		o.selector = selector
+	}
+
+	o.targets = map[string]*cdv2.OCIRegistryRepository{}
+	for _, target := range o.TargetRepositories {
+		name, baseURL, ok := strings.Cut(target, "=")
+		if !ok {
+			name, baseURL = "", target
+		}
+		if len(baseURL) == 0 {
+			return fmt.Errorf("invalid target repository %q: the base url must not be empty", target)
+		}
+		if _, ok := o.targets[name]; ok {
+			if len(name) == 0 {
+				return errors.New("the repository of the default target is defined multiple times")
+			}
+			return fmt.Errorf("the repository of target %q is defined multiple times", name)
+		}
+		o.targets[name] = cdv2.NewOCIRegistryRepository(baseURL, "")
+	}
+	return nil
+}
+
+// parseSize parses a size quantity (e.g. 512Mi). An empty size is parsed as 0.
+func parseSize(size string) (int64, error) {
+	if len(size) == 0 {
+		return 0, nil
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return 0, err
+	}
+	if quantity.Sign() < 0 {
+		return 0, fmt.Errorf("size %q must not be negative", size)
+	}
+	return quantity.Value(), nil
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.SourceRepository, "from", "", "source repository base url")
+	fs.StringArrayVar(&o.TargetRepositories, "to", nil, "target repository base url of the default target or <target>=<base url> for a named target of the transport config. Can be specified multiple times")
+	fs.StringVar(&o.TransportConfigPath, "transport-cfg", "", "path to the transport config")
+	fs.StringVar(&o.StateFile, "state-file", "", "path to the file that records the processing status of all resources. A re-run of a failed transport skips all resources that have already been uploaded")
+	fs.BoolVar(&o.Verify, "verify", false, "verify the digests of the uploaded component descriptors and resources in the target repositories after the transport")
+	fs.IntVar(&o.MaxWorkers, "max-workers", 8, "max number of resources that are processed concurrently. The number is not limited if 0")
+	fs.BoolVar(&o.Stream, "stream", false, "read processing requests as json lines from stdin and write a result json line for every resource to stdout")
+	fs.BoolVar(&o.FailFast, "fail-fast", false, "abort the processing of all remaining resources in stream mode as soon as a resource could not be processed")
+	fs.StringVar(&o.MaxBlobMemory, "max-blob-memory", "", "max size of the blobs that are kept in memory by all concurrently processed resources (e.g. 512Mi). Blobs are written to temporary files if the size is exceeded. Blobs are always written to temporary files if empty")
+	fs.StringVar(&o.MaxBlobDisk, "max-blob-disk", "", "max size of the temporary files of all concurrently processed resources (e.g. 20Gi). Resources wait for other resources to release temporary files if the size is exceeded. The size is not limited if empty")
+	o.Selector.AddFlags(fs)
+	o.OciOptions.AddFlags(fs)
+	o.RequireSigned.AddFlags(fs)
+	o.Report.AddFlags(fs)
+}
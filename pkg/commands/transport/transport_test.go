@@ -0,0 +1,520 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package transport_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/layerfs"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/commands/componentarchive/remote"
+	"github.com/gardener/component-cli/pkg/commands/transport"
+	"github.com/gardener/component-cli/pkg/signatures"
+	"github.com/gardener/component-cli/pkg/transport/filters"
+	"github.com/gardener/component-cli/pkg/transport/inventory"
+	processutils "github.com/gardener/component-cli/pkg/transport/process/utils"
+	"github.com/gardener/component-cli/pkg/transport/report"
+	"github.com/gardener/component-cli/pkg/transport/state"
+	"github.com/gardener/component-cli/pkg/transport/stream"
+	"github.com/gardener/component-cli/pkg/transport/worker"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("Transport", func() {
+
+	const (
+		componentName    = "example.com/component"
+		componentVersion = "v0.0.0"
+	)
+
+	var (
+		ctx        context.Context
+		testdataFs vfs.FileSystem
+		tmpDir     string
+		srcURL     string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		baseFs, err := projectionfs.New(osfs.New(), "../componentarchive")
+		Expect(err).ToNot(HaveOccurred())
+		testdataFs = layerfs.New(memoryfs.New(), baseFs)
+		cf, err := testenv.GetConfigFileBytes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testdataFs, "/auth.json", cf, os.ModePerm)).To(Succeed())
+
+		tmpDir, err = ioutil.TempDir("", "transport-")
+		Expect(err).ToNot(HaveOccurred())
+
+		srcURL = testenv.Addr + "/src-" + utils.RandomString(5)
+		pushOpts := &remote.PushOptions{
+			OciOptions: options.Options{
+				RegistryConfigPath: "/auth.json",
+			},
+		}
+		pushOpts.ComponentArchivePath = "./testdata/01-ca-blob"
+		pushOpts.BaseUrl = srcURL
+		Expect(pushOpts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	writeConfig := func(cfg string) string {
+		configPath := filepath.Join(tmpDir, "transport-config.yaml")
+		Expect(ioutil.WriteFile(configPath, []byte(cfg), os.ModePerm)).To(Succeed())
+		return configPath
+	}
+
+	newOptions := func(configPath string, targets ...string) *transport.Options {
+		opts := &transport.Options{
+			ComponentName:       componentName,
+			ComponentVersion:    componentVersion,
+			SourceRepository:    srcURL,
+			TargetRepositories:  targets,
+			TransportConfigPath: configPath,
+			MaxWorkers:          2,
+			OciOptions: options.Options{
+				RegistryConfigPath: "/auth.json",
+			},
+		}
+		Expect(opts.Validate()).To(Succeed())
+		return opts
+	}
+
+	expectBlob := func(baseURL string) {
+		cd, blobs, err := cdoci.NewResolver(client).ResolveWithBlobResolver(ctx, cdv2.NewOCIRegistryRepository(baseURL, ""), componentName, componentVersion)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cd.Resources).To(HaveLen(1))
+		Expect(cd.Resources[0].Access.Type).To(Equal(cdv2.LocalOCIBlobType))
+		var blob bytes.Buffer
+		_, err = blobs.Resolve(ctx, cd.Resources[0], &blob)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blob.String()).To(Equal("blob test\n"))
+	}
+
+	It("should upload the resources with the uploaders of every target", func() {
+		configPath := writeConfig(`
+meta:
+  version: v1
+downloaders:
+- name: local-oci-blob-downloader
+  type: LocalOciBlobDownloader
+  filters:
+  - type: AccessTypeFilter
+    spec:
+      includeAccessTypes:
+      - localOciBlob
+uploaders:
+- name: local-oci-blob-uploader
+  type: LocalOciBlobUploader
+  filters:
+  - type: AccessTypeFilter
+    spec:
+      includeAccessTypes:
+      - localOciBlob
+- name: mirror-local-oci-blob-uploader
+  type: LocalOciBlobUploader
+  target: mirror
+  filters:
+  - type: AccessTypeFilter
+    spec:
+      includeAccessTypes:
+      - localOciBlob
+`)
+		suffix := utils.RandomString(5)
+		targetURL := testenv.Addr + "/target-" + suffix
+		mirrorURL := testenv.Addr + "/mirror-" + suffix
+		opts := newOptions(configPath, targetURL, "mirror="+mirrorURL)
+		opts.Report.ReportFile = "/report.json"
+		opts.Verify = true
+
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+		expectBlob(targetURL)
+		expectBlob(mirrorURL)
+
+		data, err := vfs.ReadFile(testdataFs, "/report.json")
+		Expect(err).ToNot(HaveOccurred())
+		r := &report.Report{}
+		Expect(json.Unmarshal(data, r)).To(Succeed())
+		Expect(r.Components).To(HaveLen(1))
+		Expect(r.Components[0].Resources).To(HaveLen(1))
+		resReport := r.Components[0].Resources[0]
+		Expect(resReport.Name).To(Equal("myconfig"))
+		Expect(resReport.Failed()).To(BeFalse())
+		Expect(resReport.Stages).To(HaveLen(3))
+		Expect(resReport.Stages[0].Name).To(Equal("local-oci-blob-downloader"))
+		Expect([]string{resReport.Stages[1].Name, resReport.Stages[2].Name}).To(ConsistOf("local-oci-blob-uploader", "mirror-local-oci-blob-uploader"))
+
+		Expect(r.Verification).To(HaveLen(2))
+		for _, v := range r.Verification {
+			// the component descriptor and its local oci blob resource are verified
+			Expect(v.Results).To(HaveLen(2))
+			for _, result := range v.Results {
+				Expect(result.Failed()).To(BeFalse(), result.String())
+			}
+		}
+	})
+
+	It("should skip the resources that have been uploaded in a previous run with the same state file", func() {
+		configPath := writeConfig(`
+meta:
+  version: v1
+downloaders:
+- name: local-oci-blob-downloader
+  type: LocalOciBlobDownloader
+uploaders:
+- name: local-oci-blob-uploader
+  type: LocalOciBlobUploader
+`)
+		targetURL := testenv.Addr + "/target-" + utils.RandomString(5)
+		opts := newOptions(configPath, targetURL)
+		// the os filesystem is used as the state file is replaced by renaming a temporary file
+		fs := osfs.New()
+		cf, err := testenv.GetConfigFileBytes()
+		Expect(err).ToNot(HaveOccurred())
+		opts.OciOptions.RegistryConfigPath = filepath.Join(tmpDir, "auth.json")
+		Expect(ioutil.WriteFile(opts.OciOptions.RegistryConfigPath, cf, os.ModePerm)).To(Succeed())
+		opts.StateFile = filepath.Join(tmpDir, "state.yaml")
+		opts.Report.ReportFile = filepath.Join(tmpDir, "report.json")
+		Expect(opts.Run(ctx, logr.Discard(), fs)).To(Succeed())
+
+		s, err := state.Load(fs, opts.StateFile)
+		Expect(err).ToNot(HaveOccurred())
+		cd, err := cdoci.NewResolver(client).Resolve(ctx, cdv2.NewOCIRegistryRepository(srcURL, ""), componentName, componentVersion)
+		Expect(err).ToNot(HaveOccurred())
+		resState, ok := s.Get(*cd, cd.Resources[0])
+		Expect(ok).To(BeTrue())
+		Expect(resState.Status).To(Equal(state.StatusUploaded))
+		Expect(resState.Targets).To(HaveLen(1))
+		Expect(resState.Targets[0].ComponentDescriptor).ToNot(BeNil())
+		Expect(resState.Targets[0].Blobs).To(HaveLen(1))
+
+		Expect(opts.Run(ctx, logr.Discard(), fs)).To(Succeed())
+		expectBlob(targetURL)
+		data, err := ioutil.ReadFile(opts.Report.ReportFile)
+		Expect(err).ToNot(HaveOccurred())
+		r := &report.Report{}
+		Expect(json.Unmarshal(data, r)).To(Succeed())
+		Expect(r.Components[0].Resources[0].Stages).To(BeEmpty(), "Expect that the resource is not processed again")
+	})
+
+	It("should skip the resources that have already been processed with the same transport config", func() {
+		cfg := `
+meta:
+  version: v1
+downloaders:
+- name: local-oci-blob-downloader
+  type: LocalOciBlobDownloader
+uploaders:
+- name: local-oci-blob-uploader
+  type: LocalOciBlobUploader
+`
+		targetURL := testenv.Addr + "/target-" + utils.RandomString(5)
+		// run transports the component and returns the report of the transport
+		run := func(opts *transport.Options, reportFile string) *report.Report {
+			opts.Report.ReportFile = reportFile
+			Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+			data, err := vfs.ReadFile(testdataFs, reportFile)
+			Expect(err).ToNot(HaveOccurred())
+			r := &report.Report{}
+			Expect(json.Unmarshal(data, r)).To(Succeed())
+			return r
+		}
+
+		opts := newOptions(writeConfig(cfg), targetURL)
+		r := run(opts, "/report-1.json")
+		Expect(r.Components[0].Resources[0].Stages).To(HaveLen(2))
+		cd, err := cdoci.NewResolver(client).Resolve(ctx, cdv2.NewOCIRegistryRepository(targetURL, ""), componentName, componentVersion)
+		Expect(err).ToNot(HaveOccurred())
+		stamp, ok := processutils.GetProcessingStamp(cd.Resources[0])
+		Expect(ok).To(BeTrue(), "Expect that the resource is stamped with the processing digest")
+
+		r = run(opts, "/report-2.json")
+		expectBlob(targetURL)
+		Expect(r.Components[0].Resources[0].Stages).To(BeEmpty(), "Expect that the resource is not processed again")
+
+		// the resource is processed again if the matching processing config changes
+		opts = newOptions(writeConfig(cfg+`
+processors:
+- name: labeler
+  type: ResourceLabeler
+  spec:
+    labels:
+    - name: processed
+      value: true
+processingRules:
+- name: label
+  processors:
+  - name: labeler
+    type: processor
+`), targetURL)
+		r = run(opts, "/report-3.json")
+		expectBlob(targetURL)
+		Expect(r.Components[0].Resources[0].Stages).To(HaveLen(3))
+		cd, err = cdoci.NewResolver(client).Resolve(ctx, cdv2.NewOCIRegistryRepository(targetURL, ""), componentName, componentVersion)
+		Expect(err).ToNot(HaveOccurred())
+		newStamp, ok := processutils.GetProcessingStamp(cd.Resources[0])
+		Expect(ok).To(BeTrue())
+		Expect(newStamp).ToNot(Equal(stamp))
+	})
+
+	It("should only process the selected resources and keep the other resources unchanged", func() {
+		configPath := writeConfig(`
+meta:
+  version: v1
+downloaders:
+- name: local-oci-blob-downloader
+  type: LocalOciBlobDownloader
+uploaders:
+- name: local-oci-blob-uploader
+  type: LocalOciBlobUploader
+`)
+		targetURL := testenv.Addr + "/target-" + utils.RandomString(5)
+		opts := newOptions(configPath, targetURL)
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		opts.Selector.OnlyResources = []string{"type=ociImage"}
+		opts.Report.ReportFile = "/report.json"
+		Expect(opts.Validate()).To(Succeed())
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+		// the blob of the resource that is not selected is kept from the existing component descriptor
+		expectBlob(targetURL)
+
+		data, err := vfs.ReadFile(testdataFs, "/report.json")
+		Expect(err).ToNot(HaveOccurred())
+		r := &report.Report{}
+		Expect(json.Unmarshal(data, r)).To(Succeed())
+		Expect(r.Components).To(BeEmpty(), "Expect that no resource is processed")
+	})
+
+	It("should reject invalid resource selectors", func() {
+		opts := &transport.Options{
+			ComponentName:       componentName,
+			ComponentVersion:    componentVersion,
+			SourceRepository:    srcURL,
+			TransportConfigPath: "transport-config.yaml",
+			Selector: filters.SelectorOptions{
+				OnlyResources: []string{"unknown=value"},
+			},
+		}
+		Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid resource selector")))
+	})
+
+	It("should fail if no uploader of a target with repository matches a resource", func() {
+		configPath := writeConfig(`
+meta:
+  version: v1
+downloaders:
+- name: local-oci-blob-downloader
+  type: LocalOciBlobDownloader
+  filters:
+  - type: AccessTypeFilter
+    spec:
+      includeAccessTypes:
+      - localOciBlob
+uploaders:
+- name: local-oci-blob-uploader
+  type: LocalOciBlobUploader
+  filters:
+  - type: AccessTypeFilter
+    spec:
+      includeAccessTypes:
+      - localOciBlob
+`)
+		r := utils.RandomString(5)
+		opts := newOptions(configPath, testenv.Addr+"/target-"+r, "mirror="+testenv.Addr+"/mirror-"+r)
+
+		err := opts.Run(ctx, logr.Discard(), testdataFs)
+		Expect(err).To(HaveOccurred())
+		Expect(worker.IsFatal(err)).To(BeTrue())
+		Expect(strings.Count(err.Error(), `no uploader of target "mirror"`)).To(Equal(1))
+	})
+
+	It("should publish the inventory of the default target", func() {
+		suffix := utils.RandomString(5)
+		inventoryRef := testenv.Addr + "/inventory-" + suffix + ":latest"
+		configPath := writeConfig(`
+meta:
+  version: v1
+inventoryRef: ` + inventoryRef + `
+downloaders:
+- name: local-oci-blob-downloader
+  type: LocalOciBlobDownloader
+uploaders:
+- name: local-oci-blob-uploader
+  type: LocalOciBlobUploader
+`)
+		targetURL := testenv.Addr + "/target-" + suffix
+		opts := newOptions(configPath, targetURL)
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+
+		inv, err := inventory.Get(ctx, client, inventoryRef)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inv.RepositoryContext.BaseURL).To(Equal(targetURL))
+		Expect(inv.Components).To(HaveLen(1))
+		Expect(inv.Components[0].Name).To(Equal(componentName))
+		Expect(inv.Components[0].Resources).To(HaveLen(1))
+		Expect(inv.Components[0].Resources[0].Digest.String()).To(HavePrefix("sha256:"))
+	})
+
+	It("should reject unsigned components if signed components are required", func() {
+		configPath := writeConfig(`
+meta:
+  version: v1
+downloaders:
+- name: local-oci-blob-downloader
+  type: LocalOciBlobDownloader
+uploaders:
+- name: local-oci-blob-uploader
+  type: LocalOciBlobUploader
+`)
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		pub, err := signatures.MarshalPublicKeyPEM(&key.PublicKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(testdataFs, "/release.pub", pub, os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(testdataFs, "/policy.yaml", []byte("signatures:\n- name: release\n  publicKeys: [release.pub]\n"), os.ModePerm)).To(Succeed())
+
+		targetURL := testenv.Addr + "/target-" + utils.RandomString(5)
+		opts := newOptions(configPath, targetURL)
+		opts.RequireSigned.RequireSigned = true
+		opts.RequireSigned.PathToPolicy = "/policy.yaml"
+
+		err = opts.Run(ctx, logr.Discard(), testdataFs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("not signed as required"))
+		_, err = cdoci.NewResolver(client).Resolve(ctx, cdv2.NewOCIRegistryRepository(targetURL, ""), componentName, componentVersion)
+		Expect(err).To(HaveOccurred(), "Expect that no component descriptor has been uploaded")
+	})
+
+	It("should process the resources of stream requests with the uploaders of every target", func() {
+		configPath := writeConfig(`
+meta:
+  version: v1
+downloaders:
+- name: local-oci-blob-downloader
+  type: LocalOciBlobDownloader
+uploaders:
+- name: local-oci-blob-uploader
+  type: LocalOciBlobUploader
+- name: mirror-local-oci-blob-uploader
+  type: LocalOciBlobUploader
+  target: mirror
+`)
+		cd, err := cdoci.NewResolver(client).Resolve(ctx, cdv2.NewOCIRegistryRepository(srcURL, ""), componentName, componentVersion)
+		Expect(err).ToNot(HaveOccurred())
+		req, err := json.Marshal(stream.Request{ID: "a", ComponentDescriptor: cd})
+		Expect(err).ToNot(HaveOccurred())
+
+		suffix := utils.RandomString(5)
+		opts := &transport.Options{
+			Stream:              true,
+			TargetRepositories:  []string{testenv.Addr + "/target-" + suffix, "mirror=" + testenv.Addr + "/mirror-" + suffix},
+			TransportConfigPath: configPath,
+			OciOptions: options.Options{
+				RegistryConfigPath: "/auth.json",
+			},
+		}
+		Expect(opts.Validate()).To(Succeed())
+		out := &bytes.Buffer{}
+		Expect(opts.RunStream(ctx, logr.Discard(), testdataFs, bytes.NewReader(req), out)).To(Succeed())
+
+		result := stream.Result{}
+		Expect(json.Unmarshal(out.Bytes(), &result)).To(Succeed())
+		Expect(result.ID).To(Equal("a"))
+		Expect(result.Status).To(Equal(stream.ResultStatusSucceeded))
+		Expect(result.Resources).To(HaveLen(2))
+		Expect(result.Resources[0].Access.Type).To(Equal(cdv2.LocalOCIBlobType))
+	})
+
+	It("should reject components in stream mode", func() {
+		opts := &transport.Options{
+			ComponentName:       componentName,
+			ComponentVersion:    componentVersion,
+			Stream:              true,
+			TransportConfigPath: "transport-config.yaml",
+		}
+		Expect(opts.Validate()).To(MatchError(ContainSubstring("stream mode")))
+	})
+
+	It("should reject target repositories that are defined multiple times", func() {
+		opts := &transport.Options{
+			ComponentName:       componentName,
+			ComponentVersion:    componentVersion,
+			SourceRepository:    srcURL,
+			TargetRepositories:  []string{"mirror=" + testenv.Addr + "/a", "mirror=" + testenv.Addr + "/b"},
+			TransportConfigPath: "transport-config.yaml",
+		}
+		Expect(opts.Validate()).To(MatchError(ContainSubstring(`target "mirror" is defined multiple times`)))
+	})
+
+	It("should reject a negative number of workers", func() {
+		opts := &transport.Options{
+			ComponentName:       componentName,
+			ComponentVersion:    componentVersion,
+			SourceRepository:    srcURL,
+			TargetRepositories:  []string{testenv.Addr + "/a"},
+			TransportConfigPath: "transport-config.yaml",
+			MaxWorkers:          -1,
+		}
+		Expect(opts.Validate()).To(MatchError(ContainSubstring("max number of workers")))
+	})
+
+	It("should process the resources with the blob budget", func() {
+		configPath := writeConfig(`
+meta:
+  version: v1
+downloaders:
+- name: local-oci-blob-downloader
+  type: LocalOciBlobDownloader
+uploaders:
+- name: local-oci-blob-uploader
+  type: LocalOciBlobUploader
+`)
+		targetURL := testenv.Addr + "/target-" + utils.RandomString(5)
+		opts := newOptions(configPath, targetURL)
+		opts.MaxBlobMemory = "1Ki"
+		opts.MaxBlobDisk = "1Mi"
+		Expect(opts.Validate()).To(Succeed())
+		Expect(opts.Run(ctx, logr.Discard(), testdataFs)).To(Succeed())
+		expectBlob(targetURL)
+	})
+
+	It("should reject invalid blob budgets", func() {
+		opts := &transport.Options{
+			ComponentName:       componentName,
+			ComponentVersion:    componentVersion,
+			SourceRepository:    srcURL,
+			TransportConfigPath: "transport-config.yaml",
+			MaxBlobMemory:       "1Gi",
+			MaxBlobDisk:         "-1Gi",
+		}
+		Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid max blob disk")))
+		opts.MaxBlobDisk = ""
+		opts.MaxBlobMemory = "one gigabyte"
+		Expect(opts.Validate()).To(MatchError(ContainSubstring("invalid max blob memory")))
+	})
+
+})
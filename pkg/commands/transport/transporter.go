@@ -0,0 +1,296 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/go-logr/logr"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/transport/config"
+	"github.com/gardener/component-cli/pkg/transport/filters"
+	"github.com/gardener/component-cli/pkg/transport/merge"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/report"
+	"github.com/gardener/component-cli/pkg/transport/state"
+	"github.com/gardener/component-cli/pkg/transport/worker"
+)
+
+// resolveComponentTree resolves the component descriptor and all transitively referenced component descriptors.
+// Every component version is returned once and referenced component descriptors are returned before the referencing ones.
+func resolveComponentTree(ctx context.Context, resolver ctf.ComponentResolver, repoCtx cdv2.Repository, name, version string) ([]*cdv2.ComponentDescriptor, error) {
+	cds := []*cdv2.ComponentDescriptor{}
+	visited := map[string]bool{}
+	var resolve func(name, version string) error
+	resolve = func(name, version string) error {
+		key := fmt.Sprintf("%s:%s", name, version)
+		if visited[key] {
+			return nil
+		}
+		visited[key] = true
+		cd, err := resolver.Resolve(ctx, repoCtx, name, version)
+		if err != nil {
+			return fmt.Errorf("unable to resolve component descriptor %s: %w", key, err)
+		}
+		for _, ref := range cd.ComponentReferences {
+			if err := resolve(ref.ComponentName, ref.Version); err != nil {
+				return err
+			}
+		}
+		cds = append(cds, cd)
+		return nil
+	}
+	if err := resolve(name, version); err != nil {
+		return nil, err
+	}
+	return cds, nil
+}
+
+// transporter transports component descriptors and their resources to all targets.
+type transporter struct {
+	client   ociclient.Client
+	cache    cache.Cache
+	resolver ctf.ComponentResolver
+	// targets are the repositories of the targets by target name.
+	targets       map[string]*cdv2.OCIRegistryRepository
+	mergeStrategy merge.Strategy
+
+	// selector selects the resources that are processed.
+	// Resources that are not selected are kept unchanged in the component descriptors of the targets.
+	selector  filters.Filter
+	pipelines *pipelineFactory
+	blobs     *blobRecorder
+	// maxWorkers is the max number of resources of a component that are processed concurrently.
+	maxWorkers int
+	// uploaded are the component descriptors that have been uploaded by target name.
+	uploaded map[string][]cdv2.ComponentDescriptor
+}
+
+func newTransporter(cfg *config.ParsedTransportConfig, client ociclient.Client, ocicache cache.Cache, resolver ctf.ComponentResolver, targets map[string]*cdv2.OCIRegistryRepository, selector filters.Filter, maxWorkers int, s *state.State, r *report.Report) *transporter {
+	blobs := newBlobRecorder()
+	return &transporter{
+		client:        client,
+		cache:         ocicache,
+		resolver:      resolver,
+		targets:       targets,
+		mergeStrategy: cfg.MergeStrategy,
+		selector:      selector,
+		pipelines:     newPipelineFactory(cfg, client, ocicache, resolver, targets, s, blobs, r),
+		blobs:         blobs,
+		maxWorkers:    maxWorkers,
+		uploaded:      map[string][]cdv2.ComponentDescriptor{},
+	}
+}
+
+// transport processes all resources of the component descriptor and uploads the resulting
+// component descriptor to every target with a repository.
+func (t *transporter) transport(ctx context.Context, cd *cdv2.ComponentDescriptor) error {
+	log := logr.FromContextOrDiscard(ctx).WithValues("component", cd.Name, "version", cd.Version)
+
+	// the resources are processed concurrently, their results are collected by resource index
+	// so that the resources keep their order in the component descriptors of the targets.
+	resourceResults := make([][]process.TargetResult, len(cd.Resources))
+	pool := worker.NewPool(ctx, t.maxWorkers)
+	for i, res := range cd.Resources {
+		i, res := i, res
+		if t.selector != nil && !t.selector.Matches(*cd, res) {
+			log.V(3).Info("resource is not selected, it is not processed", "resource", res.Name)
+			for target := range t.targets {
+				resourceResults[i] = append(resourceResults[i], process.TargetResult{
+					Target:    target,
+					Resources: []cdv2.Resource{res},
+				})
+			}
+			continue
+		}
+		if err := pool.Go(func(ctx context.Context) error {
+			log.V(3).Info("process resource", "resource", res.Name)
+			// errors of the transport config cannot be recovered by processing further resources
+			pipeline, err := t.pipelines.Create(*cd, res)
+			if err != nil {
+				return worker.Fatal(fmt.Errorf("unable to create pipeline for resource %s: %w", res.Name, err))
+			}
+			targetResults, err := pipeline.Process(ctx, *cd, res)
+			if err != nil {
+				return fmt.Errorf("unable to process resource %s: %w", res.Name, err)
+			}
+			// the component descriptor of a target with repository must contain all resources
+			for target := range t.targets {
+				if !hasTargetResult(targetResults, target) {
+					return worker.Fatal(fmt.Errorf("no uploader of target %q matches the resource %s", target, res.Name))
+				}
+			}
+			resourceResults[i] = targetResults
+			return nil
+		}); err != nil {
+			// the pool does not accept further tasks if a task failed with a fatal error or the context is done
+			if poolErr := pool.Wait(); poolErr != nil {
+				return poolErr
+			}
+			return err
+		}
+	}
+	if err := pool.Wait(); err != nil {
+		return err
+	}
+
+	// results are the processed resources by target.
+	results := map[string][]cdv2.Resource{}
+	// processedCds are the component descriptors of the last processed resource by target.
+	processedCds := map[string]*cdv2.ComponentDescriptor{}
+	for _, targetResults := range resourceResults {
+		for _, result := range targetResults {
+			results[result.Target] = append(results[result.Target], result.Resources...)
+			if result.ComponentDescriptor != nil {
+				processedCds[result.Target] = result.ComponentDescriptor
+			}
+		}
+	}
+
+	targetNames := make([]string, 0, len(t.targets))
+	for target := range t.targets {
+		targetNames = append(targetNames, target)
+	}
+	sort.Strings(targetNames)
+	for _, target := range targetNames {
+		targetCd := cd.DeepCopy()
+		if processedCd, ok := processedCds[target]; ok {
+			targetCd = processedCd.DeepCopy()
+		}
+		if resources, ok := results[target]; ok {
+			targetCd.Resources = resources
+		}
+		if err := t.uploadComponentDescriptor(ctx, target, targetCd); err != nil {
+			return fmt.Errorf("unable to upload component descriptor to target %q: %w", target, err)
+		}
+	}
+
+	for target := range results {
+		if _, ok := t.targets[target]; !ok {
+			log.V(3).Info("no repository is defined for the target, the component descriptor is not uploaded", "target", target)
+		}
+	}
+	return nil
+}
+
+func hasTargetResult(results []process.TargetResult, target string) bool {
+	for _, result := range results {
+		if result.Target == target {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadComponentDescriptor uploads the component descriptor to the repository of the target.
+// The blobs of local oci blob resources have already been uploaded by the uploaders and are added as layers.
+func (t *transporter) uploadComponentDescriptor(ctx context.Context, target string, cd *cdv2.ComponentDescriptor) error {
+	repoCtx := t.targets[target]
+	log := logr.FromContextOrDiscard(ctx).WithValues("component", cd.Name, "version", cd.Version, "target", target)
+
+	if err := cdv2.InjectRepositoryContext(cd, repoCtx); err != nil {
+		return fmt.Errorf("unable to inject target repository: %w", err)
+	}
+	cd, err := merge.ResolveAndMerge(ctx, t.resolver, repoCtx, cd, t.mergeStrategy)
+	if err != nil {
+		return err
+	}
+
+	ref, err := components.OCIRef(repoCtx, cd.Name, cd.Version)
+	if err != nil {
+		return fmt.Errorf("invalid component reference: %w", err)
+	}
+
+	manifest, err := cdoci.NewManifestBuilder(t.cache, ctf.NewComponentArchive(cd, nil)).Build(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to build oci artifact for component archive: %w", err)
+	}
+	layers, err := t.blobLayers(ctx, target, ref, cd)
+	if err != nil {
+		return err
+	}
+	manifest.Layers = append(manifest.Layers, layers...)
+
+	blobs := map[string]bool{}
+	for _, layer := range layers {
+		blobs[layer.Digest.String()] = true
+	}
+	store := ociclient.GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
+		if blobs[desc.Digest.String()] {
+			// the blobs have already been uploaded to the repository of the component descriptor
+			return t.client.Fetch(ctx, ref, desc, writer)
+		}
+		rc, err := t.cache.Get(desc)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		_, err = io.Copy(writer, rc)
+		return err
+	})
+
+	log.V(3).Info("upload component descriptor", "ref", ref)
+	if err := t.client.PushManifest(ctx, ref, manifest, ociclient.WithStore(store)); err != nil {
+		return err
+	}
+	t.uploaded[target] = append(t.uploaded[target], *cd)
+	return nil
+}
+
+// blobLayers returns the layers of all local oci blob resources of the component descriptor.
+// Blobs of resources that have not been uploaded by the transport, e.g. resources that are kept by the merge
+// of an existing component descriptor, are taken from the manifest of the existing component descriptor.
+func (t *transporter) blobLayers(ctx context.Context, target, ref string, cd *cdv2.ComponentDescriptor) ([]ocispecv1.Descriptor, error) {
+	var (
+		layers   []ocispecv1.Descriptor
+		added    = map[string]bool{}
+		existing *ocispecv1.Manifest
+	)
+	for _, res := range cd.Resources {
+		if res.Access == nil || res.Access.GetType() != cdv2.LocalOCIBlobType {
+			continue
+		}
+		localBlob := &cdv2.LocalOCIBlobAccess{}
+		if err := res.Access.DecodeInto(localBlob); err != nil {
+			return nil, fmt.Errorf("unable to decode access of resource %s: %w", res.Name, err)
+		}
+		if added[localBlob.Digest] {
+			continue
+		}
+
+		desc, ok := t.blobs.Get(target, localBlob.Digest)
+		if !ok {
+			if existing == nil {
+				var err error
+				existing, err = t.client.GetManifest(ctx, ref)
+				if err != nil {
+					return nil, fmt.Errorf("unable to get blob of resource %s from the existing component descriptor: %w", res.Name, err)
+				}
+			}
+			for _, layer := range existing.Layers {
+				if layer.Digest.String() == localBlob.Digest {
+					desc, ok = layer, true
+					break
+				}
+			}
+			if !ok {
+				return nil, fmt.Errorf("blob %s of resource %s has not been uploaded", localBlob.Digest, res.Name)
+			}
+		}
+		layers = append(layers, desc)
+		added[localBlob.Digest] = true
+	}
+	return layers, nil
+}
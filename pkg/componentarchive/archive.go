@@ -8,6 +8,7 @@ import (
 	"compress/gzip"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
@@ -15,13 +16,14 @@ import (
 	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
 	"github.com/gardener/component-spec/bindings-go/codec"
 	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
 	"github.com/mandelsoft/vfs/pkg/projectionfs"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	"github.com/spf13/pflag"
-	"sigs.k8s.io/yaml"
 
-	"github.com/gardener/component-cli/pkg/commands/componentarchive/input"
 	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/componentarchive/input"
+	"github.com/gardener/component-cli/pkg/components/schema"
 	"github.com/gardener/component-cli/pkg/utils"
 )
 
@@ -32,6 +34,9 @@ type BuilderOptions struct {
 	Version              string
 	BaseUrl              string
 	ComponentNameMapping string
+	// SchemaVersion is the schema version of a newly created component descriptor.
+	// Defaults to the v2 schema.
+	SchemaVersion string
 
 	Overwrite bool
 }
@@ -69,66 +74,73 @@ func (o *BuilderOptions) Validate() error {
 			return fmt.Errorf("unknown component name mapping method %q", o.ComponentNameMapping)
 		}
 	}
+	if len(o.SchemaVersion) != 0 {
+		if err := schema.ValidateSchemaVersion(o.SchemaVersion); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// Build creates a component archives with the given configuration
-func (o *BuilderOptions) Build(fs vfs.FileSystem) (*ctf.ComponentArchive, error) {
+// Build creates a component archives with the given configuration.
+// The schema version of the component descriptor file is returned as well,
+// so that changes to the component descriptor can be written in the same schema version.
+func (o *BuilderOptions) Build(fs vfs.FileSystem) (*ctf.ComponentArchive, string, error) {
 	o.Default()
 	if err := o.Validate(); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	compDescFilePath := filepath.Join(o.ComponentArchivePath, ctf.ComponentDescriptorFileName)
 	if !o.Overwrite {
 		_, err := fs.Stat(compDescFilePath)
 		if err != nil && !os.IsNotExist(err) {
-			return nil, err
+			return nil, "", err
 		}
 		if err == nil {
 			// add the input to the ctf format
 			archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
 			if err != nil {
-				return nil, fmt.Errorf("unable to create projectionfilesystem: %w", err)
+				return nil, "", fmt.Errorf("unable to create projectionfilesystem: %w", err)
 			}
 
-			archive, err := ctf.NewComponentArchiveFromFilesystem(archiveFs, codec.DisableValidation(true))
+			archive, schemaVersion, err := NewComponentArchiveFromFilesystem(archiveFs, codec.DisableValidation(true))
 			if err != nil {
-				return nil, fmt.Errorf("unable to parse component archive from %s: %w", o.ComponentArchivePath, err)
+				return nil, "", fmt.Errorf("unable to parse component archive from %s: %w", o.ComponentArchivePath, err)
 			}
 
 			cd := archive.ComponentDescriptor
 
 			if o.Name != "" {
 				if cd.Name != "" && cd.Name != o.Name {
-					return nil, errors.New("unable to overwrite the existing component name: forbidden")
+					return nil, "", errors.New("unable to overwrite the existing component name: forbidden")
 				}
 				cd.Name = o.Name
 			}
 
 			if o.Version != "" {
 				if cd.Version != "" && cd.Version != o.Version {
-					return nil, errors.New("unable to overwrite the existing component version: forbidden")
+					return nil, "", errors.New("unable to overwrite the existing component version: forbidden")
 				}
 				cd.Version = o.Version
 			}
 
 			if err = cdvalidation.Validate(cd); err != nil {
-				return nil, fmt.Errorf("invalid component descriptor: %w", err)
+				return nil, "", fmt.Errorf("invalid component descriptor: %w", err)
 			}
 
-			return archive, nil
+			return archive, schemaVersion, nil
 		}
 	}
 
 	// build minimal archive
 
 	if err := fs.MkdirAll(o.ComponentArchivePath, os.ModePerm); err != nil {
-		return nil, fmt.Errorf("unable to create component-archive path %q: %w", o.ComponentArchivePath, err)
+		return nil, "", fmt.Errorf("unable to create component-archive path %q: %w", o.ComponentArchivePath, err)
 	}
 	archiveFs, err := projectionfs.New(fs, o.ComponentArchivePath)
 	if err != nil {
-		return nil, fmt.Errorf("unable to create projectionfilesystem: %w", err)
+		return nil, "", fmt.Errorf("unable to create projectionfilesystem: %w", err)
 	}
 
 	cd := &cdv2.ComponentDescriptor{}
@@ -140,30 +152,34 @@ func (o *BuilderOptions) Build(fs vfs.FileSystem) (*ctf.ComponentArchive, error)
 	if len(o.BaseUrl) != 0 {
 		repoCtx, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryRepository(o.BaseUrl, cdv2.ComponentNameMapping(o.ComponentNameMapping)))
 		if err != nil {
-			return nil, fmt.Errorf("unable to create repository context: %w", err)
+			return nil, "", fmt.Errorf("unable to create repository context: %w", err)
 		}
 		cd.RepositoryContexts = []*cdv2.UnstructuredTypedObject{&repoCtx}
 	}
 	if err := cdv2.DefaultComponent(cd); err != nil {
 		utils.PrintPrettyYaml(cd, true)
-		return nil, fmt.Errorf("unable to default component descriptor: %w", err)
+		return nil, "", fmt.Errorf("unable to default component descriptor: %w", err)
 	}
 
 	if err := cdvalidation.Validate(cd); err != nil {
-		return nil, fmt.Errorf("unable to validate component descriptor: %w", err)
+		return nil, "", fmt.Errorf("unable to validate component descriptor: %w", err)
 	}
 
-	data, err := yaml.Marshal(cd)
+	schemaVersion := o.SchemaVersion
+	if len(schemaVersion) == 0 {
+		schemaVersion = schema.SchemaVersionV2
+	}
+	data, err := schema.EncodeYAML(cd, schemaVersion)
 	if err != nil {
 		utils.PrintPrettyYaml(cd, true)
-		return nil, fmt.Errorf("unable to marshal component descriptor: %w", err)
+		return nil, "", fmt.Errorf("unable to marshal component descriptor: %w", err)
 	}
 	if err := vfs.WriteFile(fs, compDescFilePath, data, os.ModePerm); err != nil {
 		utils.PrintPrettyYaml(cd, true)
-		return nil, fmt.Errorf("unable to write component descriptor to %s: %w", compDescFilePath, err)
+		return nil, "", fmt.Errorf("unable to write component descriptor to %s: %w", compDescFilePath, err)
 	}
 
-	return ctf.NewComponentArchive(cd, archiveFs), nil
+	return ctf.NewComponentArchive(cd, archiveFs), schemaVersion, nil
 }
 
 // Parse parses a component archive from a given path.
@@ -184,7 +200,7 @@ func Parse(fs vfs.FileSystem, path string) (*ctf.ComponentArchive, ctf.ArchiveFo
 		if err != nil {
 			return nil, "", fmt.Errorf("unable to create filesystem from %s: %s", path, err.Error())
 		}
-		ca, err := ctf.NewComponentArchiveFromFilesystem(archiveFs)
+		ca, _, err := NewComponentArchiveFromFilesystem(archiveFs)
 		return ca, ctf.ArchiveFormatFilesystem, err
 	}
 
@@ -204,7 +220,7 @@ func Parse(fs vfs.FileSystem, path string) (*ctf.ComponentArchive, ctf.ArchiveFo
 		if err != nil {
 			return nil, "", fmt.Errorf("unable to open gzip reader: %w", err)
 		}
-		ca, err := ctf.NewComponentArchiveFromTarReader(zr)
+		ca, err := newComponentArchiveFromTarReader(zr)
 		if err != nil {
 			return nil, "", fmt.Errorf("unable to unzip componentarchive: %s", err.Error())
 		}
@@ -216,7 +232,7 @@ func Parse(fs vfs.FileSystem, path string) (*ctf.ComponentArchive, ctf.ArchiveFo
 		}
 		return ca, ctf.ArchiveFormatTar, nil
 	case "application/octet-stream": // expect that is has to be a tar
-		ca, err := ctf.NewComponentArchiveFromTarReader(file)
+		ca, err := newComponentArchiveFromTarReader(file)
 		if err != nil {
 			return nil, "", fmt.Errorf("unable to unzip componentarchive: %s", err.Error())
 		}
@@ -228,3 +244,29 @@ func Parse(fs vfs.FileSystem, path string) (*ctf.ComponentArchive, ctf.ArchiveFo
 		return nil, "", fmt.Errorf("unsupported file type %q. Expected a tar or a tar.gz", mimetype)
 	}
 }
+
+// NewComponentArchiveFromFilesystem creates a component archive from a filesystem
+// that contains a component descriptor of any supported schema version.
+// The schema version of the component descriptor file is returned as well.
+func NewComponentArchiveFromFilesystem(fs vfs.FileSystem, decodeOpts ...codec.DecodeOption) (*ctf.ComponentArchive, string, error) {
+	data, err := vfs.ReadFile(fs, filepath.Join("/", ctf.ComponentDescriptorFileName))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read the component descriptor from %s: %w", ctf.ComponentDescriptorFileName, err)
+	}
+	cd, schemaVersion, err := schema.Decode(data, decodeOpts...)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to parse component descriptor read from %s: %w", ctf.ComponentDescriptorFileName, err)
+	}
+	return ctf.NewComponentArchive(cd, fs), schemaVersion, nil
+}
+
+// newComponentArchiveFromTarReader extracts a component archive tar to a memory filesystem
+// and creates a component archive from it.
+func newComponentArchiveFromTarReader(in io.Reader) (*ctf.ComponentArchive, error) {
+	fs := memoryfs.New()
+	if err := ctf.ExtractTarToFs(fs, in); err != nil {
+		return nil, fmt.Errorf("unable to extract tar: %w", err)
+	}
+	ca, _, err := NewComponentArchiveFromFilesystem(fs)
+	return ca, err
+}
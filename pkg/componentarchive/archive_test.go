@@ -34,7 +34,7 @@ var _ = Describe("Archive", func() {
 	It("should return error for empty component descriptor if name and version not set in options", func() {
 		opts := BuilderOptions{ComponentArchivePath: "./00-component"}
 
-		_, err := opts.Build(testdataFs)
+		_, _, err := opts.Build(testdataFs)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).Should(ContainSubstring("invalid component descriptor"))
 	})
@@ -51,7 +51,7 @@ var _ = Describe("Archive", func() {
 			Version:              componentVersion,
 		}
 
-		archive, err := opts.Build(testdataFs)
+		archive, _, err := opts.Build(testdataFs)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(archive.ComponentDescriptor.Name).To(Equal(componentName))
 		Expect(archive.ComponentDescriptor.Version).To(Equal(componentVersion))
@@ -69,7 +69,7 @@ var _ = Describe("Archive", func() {
 			Version:              componentVersion,
 		}
 
-		_, err := opts.Build(testdataFs)
+		_, _, err := opts.Build(testdataFs)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).Should(ContainSubstring("unable to overwrite the existing component name: forbidden"))
 	})
@@ -85,7 +85,7 @@ var _ = Describe("Archive", func() {
 			Version:              componentVersion,
 		}
 
-		_, err := opts.Build(testdataFs)
+		_, _, err := opts.Build(testdataFs)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).Should(ContainSubstring("unable to overwrite the existing component version: forbidden"))
 	})
@@ -102,7 +102,7 @@ var _ = Describe("Archive", func() {
 			Version:              componentVersion,
 		}
 
-		_, err := opts.Build(testdataFs)
+		_, _, err := opts.Build(testdataFs)
 		Expect(err).ToNot(HaveOccurred())
 	})
 
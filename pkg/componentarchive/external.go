@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+)
+
+// ExternalFileType is the access type of resources whose blob is a file outside of the component archive.
+// The file is not part of the component archive but is uploaded as local blob when the archive is pushed
+// so that the published component version is self-contained.
+const ExternalFileType = "externalFile"
+
+// ExternalFileAccess describes the access to a file outside of the component archive.
+type ExternalFileAccess struct {
+	cdv2.ObjectType `json:",inline"`
+	// Path is the path to the file.
+	// Relative paths are resolved relative to the component archive directory.
+	Path string `json:"path"`
+	// Digest is the expected digest of the file, e.g. "sha256:...".
+	// The push fails if the file has another digest.
+	Digest string `json:"digest"`
+	// MediaType is the media type of the file.
+	// The type of the resource is used if not defined.
+	// +optional
+	MediaType string `json:"mediaType,omitempty"`
+}
+
+// NewExternalFileAccess creates a new external file access.
+func NewExternalFileAccess(path string, dgst digest.Digest, mediaType string) *ExternalFileAccess {
+	return &ExternalFileAccess{
+		ObjectType: cdv2.ObjectType{
+			Type: ExternalFileType,
+		},
+		Path:      path,
+		Digest:    dgst.String(),
+		MediaType: mediaType,
+	}
+}
+
+// ExternalFileBlobResolver resolves the blobs of resources with an external file access.
+type ExternalFileBlobResolver struct {
+	fs vfs.FileSystem
+	// basePath is the path relative paths are resolved against.
+	basePath string
+}
+
+var _ ctf.TypedBlobResolver = &ExternalFileBlobResolver{}
+
+// NewExternalFileBlobResolver creates a new resolver for external files.
+// Relative paths are resolved against the given base path, which is usually the component archive directory.
+func NewExternalFileBlobResolver(fs vfs.FileSystem, basePath string) *ExternalFileBlobResolver {
+	return &ExternalFileBlobResolver{
+		fs:       fs,
+		basePath: basePath,
+	}
+}
+
+// AddExternalFileResolver adds a resolver for external files to the component archive,
+// so that the external files are uploaded as local blobs when the archive is pushed.
+func AddExternalFileResolver(archive *ctf.ComponentArchive, fs vfs.FileSystem, archivePath string) error {
+	resolver, err := ctf.AggregateBlobResolvers(archive.BlobResolver, NewExternalFileBlobResolver(fs, archivePath))
+	if err != nil {
+		return fmt.Errorf("unable to add external file resolver: %w", err)
+	}
+	archive.BlobResolver = resolver
+	return nil
+}
+
+func (r *ExternalFileBlobResolver) CanResolve(res cdv2.Resource) bool {
+	return res.Access != nil && res.Access.GetType() == ExternalFileType
+}
+
+func (r *ExternalFileBlobResolver) Info(_ context.Context, res cdv2.Resource) (*ctf.BlobInfo, error) {
+	access, path, err := r.decode(res)
+	if err != nil {
+		return nil, err
+	}
+	info, err := r.fs.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get info for external file %q of resource %s: %w", access.Path, res.GetName(), err)
+	}
+	return r.blobInfo(res, access, info.Size()), nil
+}
+
+// Resolve writes the content of the external file to the writer.
+// An error is returned if the content does not match the digest of the access.
+func (r *ExternalFileBlobResolver) Resolve(_ context.Context, res cdv2.Resource, writer io.Writer) (*ctf.BlobInfo, error) {
+	access, path, err := r.decode(res)
+	if err != nil {
+		return nil, err
+	}
+	file, err := r.fs.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open external file %q of resource %s: %w", access.Path, res.GetName(), err)
+	}
+	defer file.Close()
+
+	dgst := digest.Digest(access.Digest)
+	verifier := dgst.Verifier()
+	size, err := io.Copy(io.MultiWriter(writer, verifier), file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read external file %q of resource %s: %w", access.Path, res.GetName(), err)
+	}
+	if !verifier.Verified() {
+		return nil, fmt.Errorf("external file %q of resource %s does not match the digest %s", access.Path, res.GetName(), access.Digest)
+	}
+	return r.blobInfo(res, access, size), nil
+}
+
+func (r *ExternalFileBlobResolver) decode(res cdv2.Resource) (*ExternalFileAccess, string, error) {
+	if !r.CanResolve(res) {
+		return nil, "", ctf.UnsupportedResolveType
+	}
+	access := &ExternalFileAccess{}
+	if err := res.Access.DecodeInto(access); err != nil {
+		return nil, "", fmt.Errorf("unable to decode access to type '%s': %w", res.Access.GetType(), err)
+	}
+	if len(access.Path) == 0 {
+		return nil, "", errors.New("the path of an external file must not be empty")
+	}
+	if err := digest.Digest(access.Digest).Validate(); err != nil {
+		return nil, "", fmt.Errorf("invalid digest of external file %q: %w", access.Path, err)
+	}
+	path := access.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.basePath, path)
+	}
+	return access, path, nil
+}
+
+func (r *ExternalFileBlobResolver) blobInfo(res cdv2.Resource, access *ExternalFileAccess, size int64) *ctf.BlobInfo {
+	mediaType := res.GetType()
+	if len(access.MediaType) != 0 {
+		mediaType = access.MediaType
+	}
+	return &ctf.BlobInfo{
+		MediaType: mediaType,
+		Digest:    access.Digest,
+		Size:      size,
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/ociclient/cache"
+)
+
+var _ = Describe("External files", func() {
+
+	var (
+		ctx  context.Context
+		fs   vfs.FileSystem
+		data = []byte("large external file")
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fs = memoryfs.New()
+		Expect(fs.MkdirAll("/data", os.ModePerm)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/data/file.bin", data, os.ModePerm)).To(Succeed())
+	})
+
+	buildArchive := func(access *ExternalFileAccess) (*BuilderOptions, *cdv2.Resource) {
+		opts := &BuilderOptions{
+			ComponentArchivePath: "/archive",
+			Name:                 "example.com/component",
+			Version:              "v0.0.1",
+		}
+		archive, _, err := opts.Build(fs)
+		Expect(err).ToNot(HaveOccurred())
+		uAcc, err := cdv2.NewUnstructured(access)
+		Expect(err).ToNot(HaveOccurred())
+		res := &cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "file",
+				Version: "v0.0.1",
+				Type:    "blob",
+			},
+			Relation: cdv2.LocalRelation,
+			Access:   &uAcc,
+		}
+		archive.ComponentDescriptor.Resources = append(archive.ComponentDescriptor.Resources, *res)
+		cdData, err := yaml.Marshal(archive.ComponentDescriptor)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vfs.WriteFile(fs, "/archive/component-descriptor.yaml", cdData, os.ModePerm)).To(Succeed())
+		return opts, res
+	}
+
+	It("should upload an external file relative to the archive as local blob", func() {
+		opts, _ := buildArchive(NewExternalFileAccess("../data/file.bin", digest.FromBytes(data), "application/octet-stream"))
+		archive, _, err := opts.Build(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(AddExternalFileResolver(archive, fs, opts.ComponentArchivePath)).To(Succeed())
+
+		store := cache.NewInMemoryCache()
+		manifest, err := cdoci.NewManifestBuilder(store, archive).Build(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Layers).To(HaveLen(2))
+		Expect(manifest.Layers[1].Digest).To(Equal(digest.FromBytes(data)))
+		Expect(manifest.Layers[1].MediaType).To(Equal("application/octet-stream"))
+		Expect(archive.ComponentDescriptor.Resources[0].Access.GetType()).To(Equal(cdv2.LocalOCIBlobType))
+
+		// the archive itself does not contain the external file.
+		_, err = fs.Stat("/archive/blobs")
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should fail if the external file does not match the digest", func() {
+		opts, _ := buildArchive(NewExternalFileAccess("/data/file.bin", digest.FromString("other"), ""))
+		archive, _, err := opts.Build(fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(AddExternalFileResolver(archive, fs, opts.ComponentArchivePath)).To(Succeed())
+
+		_, err = cdoci.NewManifestBuilder(cache.NewInMemoryCache(), archive).Build(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("does not match the digest"))
+	})
+
+	It("should return the blob info of an external file", func() {
+		_, res := buildArchive(NewExternalFileAccess("/data/file.bin", digest.FromBytes(data), ""))
+		info, err := NewExternalFileBlobResolver(fs, "/archive").Info(ctx, *res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Size).To(Equal(int64(len(data))))
+		Expect(info.MediaType).To(Equal("blob"))
+		Expect(info.Digest).To(Equal(digest.FromBytes(data).String()))
+	})
+
+})
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package input
+
+import (
+	"context"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// AddResource reads the input blob and adds it as local blob of the given resource to the component archive.
+// Relative paths of the input are resolved relative to the directory of inputFilePath
+// or to the current working directory if inputFilePath is empty.
+// The media type of the input defaults to MediaTypeOctetStream if it is not defined and cannot be derived from the input.
+// The resource is added to the component descriptor of the archive or replaced if a resource with the same identity exists.
+// Its access is set to the local blob.
+func AddResource(ctx context.Context, fs vfs.FileSystem, archive *ctf.ComponentArchive, res *cdv2.Resource, in *BlobInput, inputFilePath string) error {
+	blob, err := in.Read(ctx, fs, inputFilePath)
+	if err != nil {
+		return err
+	}
+	// default media type to binary data if nothing else is defined
+	in.SetMediaTypeIfNotDefined(MediaTypeOctetStream)
+
+	err = archive.AddResource(res, ctf.BlobInfo{
+		MediaType: in.MediaType,
+		Digest:    blob.Digest,
+		Size:      blob.Size,
+	}, blob.Reader)
+	if err != nil {
+		blob.Reader.Close()
+		return fmt.Errorf("unable to add input blob to archive: %w", err)
+	}
+	if err := blob.Reader.Close(); err != nil {
+		return fmt.Errorf("unable to close input file: %w", err)
+	}
+	return nil
+}
+
+// AddSource reads the input blob and adds it as local blob of the given source to the component archive.
+// Relative paths of the input are resolved like in AddResource.
+// The blob is added with the type of the source as media type.
+func AddSource(ctx context.Context, fs vfs.FileSystem, archive *ctf.ComponentArchive, src *cdv2.Source, in *BlobInput, inputFilePath string) error {
+	blob, err := in.Read(ctx, fs, inputFilePath)
+	if err != nil {
+		return err
+	}
+
+	err = archive.AddSource(src, ctf.BlobInfo{
+		MediaType: src.Type,
+		Digest:    blob.Digest,
+		Size:      blob.Size,
+	}, blob.Reader)
+	if err != nil {
+		blob.Reader.Close()
+		return fmt.Errorf("unable to add input blob to archive: %w", err)
+	}
+	if err := blob.Reader.Close(); err != nil {
+		return fmt.Errorf("unable to close input file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package input reads the local inputs of resources and sources of a component archive.
+// It is used by the "component-archive resources add" and "component-archive sources add" commands
+// and can be used by other tools to construct component archives with the same semantics.
+//
+// A BlobInput describes a file, a directory, a helm chart or a docker image.
+// BlobInput.Read reads the input, tars and compresses it if necessary and calculates its digest.
+// AddResource and AddSource read an input and add it as local blob to a component archive:
+//
+//	res := cdv2.Resource{IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "chart", Version: "v0.1.0", Type: "helm"}}
+//	in := &input.BlobInput{Type: input.DirInputType, Path: "./charts/mychart"}
+//	if err := input.AddResource(ctx, osfs.New(), archive, &res, in, ""); err != nil {
+//		return err
+//	}
+//
+// ToOCMInput and FromOCMInput convert inputs from and to the input specification of the Open Component Model cli.
+package input
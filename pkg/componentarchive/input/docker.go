@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/ociclient"
+)
+
+// MediaTypeOCIImageLayoutTar is the media type of a tarred oci image layout.
+const MediaTypeOCIImageLayoutTar = ociclient.MediaTypeOCIImageLayoutTar
+
+// MediaTypeOCIImageLayoutTarGzip is the media type of a gzipped tarred oci image layout.
+const MediaTypeOCIImageLayoutTarGzip = "application/vnd.oci.image.layout.v1+tar+gzip"
+
+// readDockerImage exports the configured image from the local docker daemon and converts it to an oci image layout.
+// The docker executable has to be available in the PATH.
+func (input *BlobInput) readDockerImage(ctx context.Context) (*BlobOutput, error) {
+	if len(input.Image) == 0 {
+		return nil, errors.New("an image has to be defined for input type docker")
+	}
+	dockerBin, err := exec.LookPath("docker")
+	if err != nil {
+		return nil, fmt.Errorf("unable to find docker executable: %w", err)
+	}
+
+	tmpfile, err := ioutil.TempFile("", "docker-save-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create tempfile: %w", err)
+	}
+	defer func() {
+		_ = tmpfile.Close()
+		_ = os.Remove(tmpfile.Name())
+	}()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, dockerBin, "save", "--output", tmpfile.Name(), input.Image)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("unable to export image %q from docker: %w: %s", input.Image, err, stderr.String())
+	}
+	info, err := tmpfile.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get info of exported image %q: %w", input.Image, err)
+	}
+	return input.convertDockerArchive(tmpfile, info.Size())
+}
+
+// readDockerArchive reads a docker save or oci image layout tarball and converts it to an oci image layout.
+func (input *BlobInput) readDockerArchive(fs vfs.FileSystem, inputPath string) (*BlobOutput, error) {
+	file, err := fs.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read input blob from %q: %w", inputPath, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get info for input blob from %q, %w", inputPath, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("resource type is docker-archive but a directory was provided")
+	}
+	return input.convertDockerArchive(file, info.Size())
+}
+
+func (input *BlobInput) convertDockerArchive(archive io.ReaderAt, size int64) (*BlobOutput, error) {
+	var data bytes.Buffer
+	if input.Compress() {
+		input.SetMediaTypeIfNotDefined(MediaTypeOCIImageLayoutTarGzip)
+		gw := gzip.NewWriter(&data)
+		if _, err := ociclient.ConvertDockerArchive(archive, size, gw); err != nil {
+			return nil, fmt.Errorf("unable to convert image to oci image layout: %w", err)
+		}
+		if err := gw.Close(); err != nil {
+			return nil, fmt.Errorf("unable to close gzip writer: %w", err)
+		}
+	} else {
+		input.SetMediaTypeIfNotDefined(MediaTypeOCIImageLayoutTar)
+		if _, err := ociclient.ConvertDockerArchive(archive, size, &data); err != nil {
+			return nil, fmt.Errorf("unable to convert image to oci image layout: %w", err)
+		}
+	}
+
+	return &BlobOutput{
+		Digest: digest.FromBytes(data.Bytes()).String(),
+		Size:   int64(data.Len()),
+		Reader: ioutil.NopCloser(&data),
+	}, nil
+}
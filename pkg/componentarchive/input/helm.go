@@ -0,0 +1,305 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package input
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	pathutil "path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/ociclient"
+)
+
+// MediaTypeHelmChartContent is the media type of a packaged helm chart as defined by helm for oci registries.
+const MediaTypeHelmChartContent = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+// MediaTypeHelmChartConfig is the media type of the config of a helm chart oci artifact.
+const MediaTypeHelmChartConfig = "application/vnd.cncf.helm.config.v1+json"
+
+// MediaTypeHelmChartProvenance is the media type of the provenance file of a helm chart oci artifact.
+const MediaTypeHelmChartProvenance = "application/vnd.cncf.helm.chart.provenance.v1.prov"
+
+// HelmChartFileName is the name of the file that contains the metadata of a helm chart.
+const HelmChartFileName = "Chart.yaml"
+
+// HelmChartMetadata describes the metadata of a helm chart that is required to package it.
+type HelmChartMetadata struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+}
+
+// Validate validates the helm chart metadata.
+func (m HelmChartMetadata) Validate() error {
+	if len(m.Name) == 0 {
+		return errors.New("helm chart has no name")
+	}
+	if len(m.Version) == 0 {
+		return fmt.Errorf("helm chart %q has no version", m.Name)
+	}
+	return nil
+}
+
+// ParseHelmChartMetadata parses and validates the content of a Chart.yaml.
+func ParseHelmChartMetadata(data []byte) (HelmChartMetadata, error) {
+	metadata := HelmChartMetadata{}
+	if err := yaml.Unmarshal(data, &metadata); err != nil {
+		return HelmChartMetadata{}, fmt.Errorf("unable to decode %s: %w", HelmChartFileName, err)
+	}
+	if err := metadata.Validate(); err != nil {
+		return HelmChartMetadata{}, err
+	}
+	return metadata, nil
+}
+
+// HelmIgnoreFileName is the name of the file that defines the files that are not packaged with a helm chart.
+const HelmIgnoreFileName = ".helmignore"
+
+// readHelmChart reads a helm chart from a chart directory or an already packaged chart (.tgz)
+// and returns it as helm chart oci artifact in a tarred oci image layout.
+// A chart directory is packaged like "helm package" does with the chart name as top level directory.
+// The provenance file of a packaged chart (<chart>.tgz.prov) is added to the artifact if it exists.
+func (input *BlobInput) readHelmChart(ctx context.Context, fs vfs.FileSystem, inputPath string) (*BlobOutput, error) {
+	input.SetMediaTypeIfNotDefined(MediaTypeOCIImageLayoutTar)
+	inputInfo, err := fs.Stat(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get info for input blob from %q, %w", inputPath, err)
+	}
+
+	var (
+		chartData  []byte
+		chart      []byte
+		provenance []byte
+	)
+	if inputInfo.IsDir() {
+		chartData, chart, err = input.packageHelmChart(ctx, fs, inputPath)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		chart, err = vfs.ReadFile(fs, inputPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read input blob from %q: %w", inputPath, err)
+		}
+		chartData, err = readPackagedHelmChartFile(bytes.NewReader(chart))
+		if err != nil {
+			return nil, fmt.Errorf("invalid helm chart %q: %w", inputPath, err)
+		}
+		provenance, err = vfs.ReadFile(fs, inputPath+".prov")
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("unable to read provenance file of helm chart %q: %w", inputPath, err)
+		}
+	}
+
+	metadata, err := ParseHelmChartMetadata(chartData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid helm chart %q: %w", inputPath, err)
+	}
+	var data bytes.Buffer
+	if err := writeHelmChartArtifact(&data, metadata, chartData, chart, provenance); err != nil {
+		return nil, fmt.Errorf("unable to create oci artifact for helm chart %q: %w", inputPath, err)
+	}
+	return &BlobOutput{
+		Digest: digest.FromBytes(data.Bytes()).String(),
+		Size:   int64(data.Len()),
+		Reader: ioutil.NopCloser(&data),
+	}, nil
+}
+
+// packageHelmChart packages a chart directory and returns the content of its Chart.yaml and the packaged chart.
+// Files that are ignored by the .helmignore of the chart are not packaged.
+func (input *BlobInput) packageHelmChart(ctx context.Context, fs vfs.FileSystem, inputPath string) ([]byte, []byte, error) {
+	chartData, err := vfs.ReadFile(fs, filepath.Join(inputPath, HelmChartFileName))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read %s of helm chart %q: %w", HelmChartFileName, inputPath, err)
+	}
+	metadata, err := ParseHelmChartMetadata(chartData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid helm chart %q: %w", inputPath, err)
+	}
+
+	rules := defaultHelmIgnoreRules()
+	ignoreData, err := vfs.ReadFile(fs, filepath.Join(inputPath, HelmIgnoreFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("unable to read %s of helm chart %q: %w", HelmIgnoreFileName, inputPath, err)
+	}
+	if err == nil {
+		rules, err = parseHelmIgnore(ignoreData)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s of helm chart %q: %w", HelmIgnoreFileName, inputPath, err)
+		}
+	}
+
+	var data bytes.Buffer
+	gw := gzip.NewWriter(&data)
+	if err := TarFileSystem(ctx, fs, inputPath, gw, TarFileSystemOptions{
+		IncludeFiles:        input.IncludeFiles,
+		ExcludeFiles:        input.ExcludeFiles,
+		FollowSymlinks:      input.FollowSymlinks,
+		PreservePermissions: input.PreservePermissions(),
+		root:                metadata.Name,
+		ignore:              rules.Ignored,
+	}); err != nil {
+		return nil, nil, fmt.Errorf("unable to package helm chart %q: %w", inputPath, err)
+	}
+	if err := gw.Close(); err != nil {
+		return nil, nil, fmt.Errorf("unable to close gzip writer: %w", err)
+	}
+	return chartData, data.Bytes(), nil
+}
+
+// writeHelmChartArtifact writes a helm chart oci artifact as defined by helm as oci image layout tarball.
+// The config of the artifact is the chart metadata as json, the layers are the packaged chart and the optional provenance file.
+func writeHelmChartArtifact(w io.Writer, metadata HelmChartMetadata, chartData, chart, provenance []byte) error {
+	config, err := yaml.YAMLToJSON(chartData)
+	if err != nil {
+		return fmt.Errorf("unable to convert %s to json: %w", HelmChartFileName, err)
+	}
+	blobs := map[digest.Digest][]byte{}
+	blobDescriptor := func(mediaType string, data []byte) ocispecv1.Descriptor {
+		desc := ocispecv1.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(data),
+			Size:      int64(len(data)),
+		}
+		blobs[desc.Digest] = data
+		return desc
+	}
+
+	manifest := ocispecv1.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config:    blobDescriptor(MediaTypeHelmChartConfig, config),
+		Layers: []ocispecv1.Descriptor{
+			blobDescriptor(MediaTypeHelmChartContent, chart),
+		},
+		Annotations: map[string]string{
+			ocispecv1.AnnotationTitle:   metadata.Name,
+			ocispecv1.AnnotationVersion: metadata.Version,
+		},
+	}
+	if provenance != nil {
+		manifest.Layers = append(manifest.Layers, blobDescriptor(MediaTypeHelmChartProvenance, provenance))
+	}
+	_, err = ociclient.WriteOCILayout(w, manifest, blobs)
+	return err
+}
+
+// helmIgnoreRules are the rules of a .helmignore file.
+type helmIgnoreRules []helmIgnoreRule
+
+// helmIgnoreRule is a shell file name pattern of a .helmignore file.
+type helmIgnoreRule struct {
+	pattern string
+	// negate defines that a matching path is not ignored ("!" prefix).
+	negate bool
+	// dirOnly defines that only directories are matched ("/" suffix).
+	dirOnly bool
+}
+
+// defaultHelmIgnoreRules returns the rules that are always applied by helm.
+func defaultHelmIgnoreRules() helmIgnoreRules {
+	return helmIgnoreRules{{pattern: "templates/.?*"}}
+}
+
+// parseHelmIgnore parses the content of a .helmignore file like helm does.
+// Empty lines and lines starting with "#" are skipped.
+func parseHelmIgnore(data []byte) (helmIgnoreRules, error) {
+	rules := defaultHelmIgnoreRules()
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, "**") {
+			return nil, fmt.Errorf("double-star (**) syntax is not supported in %q", line)
+		}
+		rule := helmIgnoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if _, err := pathutil.Match(line, ""); err != nil {
+			return nil, fmt.Errorf("malformed filepath syntax %q", line)
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Ignored determines whether the path relative to the chart directory is ignored.
+// The last matching rule wins, so that negated rules can include previously ignored files.
+// Patterns starting with "/" or containing a "/" are matched against the whole path, others against the file name.
+func (rules helmIgnoreRules) Ignored(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.matches(path) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+func (rule helmIgnoreRule) matches(path string) bool {
+	if strings.Contains(rule.pattern, "/") {
+		match, _ := pathutil.Match(strings.TrimPrefix(rule.pattern, "/"), path)
+		return match
+	}
+	match, _ := pathutil.Match(rule.pattern, pathutil.Base(path))
+	return match
+}
+
+// readPackagedHelmChartFile reads the Chart.yaml of the top level directory of a packaged helm chart.
+func readPackagedHelmChartFile(reader io.Reader) ([]byte, error) {
+	gr, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("a packaged helm chart has to be gzip compressed: %w", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("no %s found in packaged helm chart", HelmChartFileName)
+			}
+			return nil, fmt.Errorf("unable to read packaged helm chart: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		dir, file := pathutil.Split(pathutil.Clean(header.Name))
+		if file != HelmChartFileName || len(dir) == 0 || pathutil.Dir(pathutil.Clean(dir)) != "." {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", header.Name, err)
+		}
+		return data, nil
+	}
+}
@@ -41,8 +41,11 @@ type BlobOutput struct {
 type BlobInputType string
 
 const (
-	FileInputType = "file"
-	DirInputType  = "dir"
+	FileInputType          = "file"
+	DirInputType           = "dir"
+	DockerInputType        = "docker"
+	DockerArchiveInputType = "docker-archive"
+	HelmInputType          = "helm"
 )
 
 // BlobInput defines a local resource input that should be added to the component descriptor and
@@ -50,12 +53,18 @@ const (
 type BlobInput struct {
 	// Type defines the input type of the blob to be added.
 	// Note that a input blob of type "dir" is automatically tarred.
+	// Input blobs of type "docker" and "docker-archive" are converted to a tarred oci image layout.
+	// Input blobs of type "helm" are packaged as helm chart oci artifact in a tarred oci image layout.
 	Type BlobInputType `json:"type"`
 	// MediaType is the mediatype of the defined file that is also added to the oci layer.
 	// Should be a custom media type in the form of "application/vnd.<mydomain>.<my description>"
 	MediaType string `json:"mediaType,omitempty"`
 	// Path is the path that points to the blob to be added.
+	// Not relevant for blobinput type "docker".
 	Path string `json:"path"`
+	// Image is the name of the image in the local docker daemon.
+	// Only relevant for blobinput type "docker".
+	Image string `json:"image,omitempty"`
 	// CompressWithGzip defines that the blob should be automatically compressed using gzip.
 	CompressWithGzip *bool `json:"compress,omitempty"`
 	// PreserveDir defines that the directory specified in the Path field should be included in the blob.
@@ -63,16 +72,21 @@ type BlobInput struct {
 	PreserveDir bool `json:"preserveDir,omitempty"`
 	// IncludeFiles is a list of shell file name patterns that describe the files that should be included.
 	// If nothing is defined all files are included.
-	// Only relevant for blobinput type "dir".
+	// Only relevant for blobinput type "dir" and "helm".
 	IncludeFiles []string `json:"includeFiles,omitempty"`
 	// ExcludeFiles is a list of shell file name patterns that describe the files that should be excluded from the resulting tar.
 	// Excluded files always overwrite included files.
-	// Only relevant for blobinput type "dir".
+	// Only relevant for blobinput type "dir" and "helm".
 	ExcludeFiles []string `json:"excludeFiles,omitempty"`
 	// FollowSymlinks configures to follow and resolve symlinks when a directory is tarred.
 	// This options will include the content of the symlink directly in the tar.
 	// This option should be used with care.
 	FollowSymlinks bool `json:"followSymlinks,omitempty"`
+	// PreserveFilePermissions defines that the file modes and owners are included in the resulting tar.
+	// Defaults to true. If false, files are added with mode 0644 (0755 for directories and executables)
+	// and without owner, so that the resulting blob does not depend on the local permissions.
+	// Only relevant for blobinput type "dir" and "helm".
+	PreserveFilePermissions *bool `json:"preservePermissions,omitempty"`
 }
 
 // Compress returns if the blob should be compressed using gzip.
@@ -83,6 +97,14 @@ func (input BlobInput) Compress() bool {
 	return *input.CompressWithGzip
 }
 
+// PreservePermissions returns if the file modes and owners should be included when a directory is tarred.
+func (input BlobInput) PreservePermissions() bool {
+	if input.PreserveFilePermissions == nil {
+		return true
+	}
+	return *input.PreserveFilePermissions
+}
+
 // SetMediaTypeIfNotDefined sets the media type of the input blob if its not defined
 func (input *BlobInput) SetMediaTypeIfNotDefined(mediaType string) {
 	if len(input.MediaType) != 0 {
@@ -91,22 +113,54 @@ func (input *BlobInput) SetMediaTypeIfNotDefined(mediaType string) {
 	input.MediaType = mediaType
 }
 
+// Location returns the path or image the blob is read from.
+func (input BlobInput) Location() string {
+	if input.Type == DockerInputType {
+		return input.Image
+	}
+	return input.Path
+}
+
+// ResolvePath returns the path of the input blob.
+// Relative paths are resolved relative to the directory of the input file that defines the input
+// or relative to the current working directory if no input file is given.
+// An empty path is returned for docker inputs as they are read from the docker daemon.
+func (input BlobInput) ResolvePath(inputFilePath string) (string, error) {
+	if input.Type == DockerInputType {
+		return "", nil
+	}
+	if filepath.IsAbs(input.Path) {
+		return input.Path, nil
+	}
+	var wd string
+	if len(inputFilePath) == 0 {
+		// default to working directory if now input filepath is given
+		var err error
+		wd, err = os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("unable to read current working directory: %w", err)
+		}
+	} else {
+		wd = filepath.Dir(inputFilePath)
+	}
+	return filepath.Join(wd, input.Path), nil
+}
+
 // Read reads the configured blob and returns a reader to the given file.
 func (input *BlobInput) Read(ctx context.Context, fs vfs.FileSystem, inputFilePath string) (*BlobOutput, error) {
-	inputPath := input.Path
-	if !filepath.IsAbs(input.Path) {
-		var wd string
-		if len(inputFilePath) == 0 {
-			// default to working directory if now input filepath is given
-			var err error
-			wd, err = os.Getwd()
-			if err != nil {
-				return nil, fmt.Errorf("unable to read current working directory: %w", err)
-			}
-		} else {
-			wd = filepath.Dir(inputFilePath)
-		}
-		inputPath = filepath.Join(wd, input.Path)
+	if input.Type == DockerInputType {
+		return input.readDockerImage(ctx)
+	}
+
+	inputPath, err := input.ResolvePath(inputFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if input.Type == DockerArchiveInputType {
+		return input.readDockerArchive(fs, inputPath)
+	}
+	if input.Type == HelmInputType {
+		return input.readHelmChart(ctx, fs, inputPath)
 	}
 	inputInfo, err := fs.Stat(inputPath)
 	if err != nil {
@@ -126,10 +180,11 @@ func (input *BlobInput) Read(ctx context.Context, fs vfs.FileSystem, inputFilePa
 			input.SetMediaTypeIfNotDefined(MediaTypeGZip)
 			gw := gzip.NewWriter(&data)
 			if err := TarFileSystem(ctx, fs, inputPath, gw, TarFileSystemOptions{
-				IncludeFiles:   input.IncludeFiles,
-				ExcludeFiles:   input.ExcludeFiles,
-				PreserveDir:    input.PreserveDir,
-				FollowSymlinks: input.FollowSymlinks,
+				IncludeFiles:        input.IncludeFiles,
+				ExcludeFiles:        input.ExcludeFiles,
+				PreserveDir:         input.PreserveDir,
+				FollowSymlinks:      input.FollowSymlinks,
+				PreservePermissions: input.PreservePermissions(),
 			}); err != nil {
 				return nil, fmt.Errorf("unable to tar input artifact: %w", err)
 			}
@@ -139,10 +194,11 @@ func (input *BlobInput) Read(ctx context.Context, fs vfs.FileSystem, inputFilePa
 		} else {
 			input.SetMediaTypeIfNotDefined(MediaTypeTar)
 			if err := TarFileSystem(ctx, fs, inputPath, &data, TarFileSystemOptions{
-				IncludeFiles:   input.IncludeFiles,
-				ExcludeFiles:   input.ExcludeFiles,
-				PreserveDir:    input.PreserveDir,
-				FollowSymlinks: input.FollowSymlinks,
+				IncludeFiles:        input.IncludeFiles,
+				ExcludeFiles:        input.ExcludeFiles,
+				PreserveDir:         input.PreserveDir,
+				FollowSymlinks:      input.FollowSymlinks,
+				PreservePermissions: input.PreservePermissions(),
 			}); err != nil {
 				return nil, fmt.Errorf("unable to tar input artifact: %w", err)
 			}
@@ -205,25 +261,25 @@ type TarFileSystemOptions struct {
 	// Only supported for Type dir.
 	PreserveDir    bool
 	FollowSymlinks bool
+	// PreservePermissions defines that the file modes and owners of the files are added to the tar.
+	// Otherwise, files are added with mode 0644 (0755 for directories and executables) and without owner.
+	PreservePermissions bool
 
 	root string
+	// ignore additionally excludes files and directories by their path relative to the root, e.g. by the rules of a .helmignore.
+	ignore func(path string, isDir bool) bool
 }
 
 // Included determines whether a file should be included.
 func (opts *TarFileSystemOptions) Included(path string) (bool, error) {
-	// if a root path is given remove it rom the path to be checked
-	if len(opts.root) != 0 {
-		path = strings.TrimPrefix(path, opts.root)
-	}
+	path = opts.relativePath(path)
 	// first check if a exclude regex matches
-	for _, ex := range opts.ExcludeFiles {
-		match, err := filepath.Match(ex, path)
-		if err != nil {
-			return false, fmt.Errorf("malformed filepath syntax %q", ex)
-		}
-		if match {
-			return false, nil
-		}
+	excluded, err := opts.Excluded(path)
+	if err != nil {
+		return false, err
+	}
+	if excluded {
+		return false, nil
 	}
 
 	// if no includes are defined, include all files
@@ -232,9 +288,25 @@ func (opts *TarFileSystemOptions) Included(path string) (bool, error) {
 	}
 	// otherwise check if the file should be included
 	for _, in := range opts.IncludeFiles {
-		match, err := filepath.Match(in, path)
+		match, err := matchFilePattern(in, path)
+		if err != nil {
+			return false, err
+		}
+		if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Excluded determines whether a file or directory is excluded.
+// The content of an excluded directory is excluded as well.
+func (opts *TarFileSystemOptions) Excluded(path string) (bool, error) {
+	path = opts.relativePath(path)
+	for _, ex := range opts.ExcludeFiles {
+		match, err := matchFilePattern(ex, path)
 		if err != nil {
-			return false, fmt.Errorf("malformed filepath syntax %q", in)
+			return false, err
 		}
 		if match {
 			return true, nil
@@ -243,6 +315,45 @@ func (opts *TarFileSystemOptions) Included(path string) (bool, error) {
 	return false, nil
 }
 
+// includedFile determines whether a file or directory should be included.
+// Include patterns are only checked for files so that files in subdirectories can be included.
+func (opts *TarFileSystemOptions) includedFile(path string, info os.FileInfo) (bool, error) {
+	if opts.ignore != nil && opts.ignore(opts.relativePath(path), info.IsDir()) {
+		return false, nil
+	}
+	if !info.IsDir() {
+		return opts.Included(path)
+	}
+	excluded, err := opts.Excluded(path)
+	if err != nil {
+		return false, err
+	}
+	return !excluded, nil
+}
+
+// relativePath removes the root path from the path to be checked.
+func (opts *TarFileSystemOptions) relativePath(path string) string {
+	if len(opts.root) == 0 {
+		return path
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(path, opts.root), "/")
+}
+
+// matchFilePattern matches a shell file name pattern against the relative path of a file.
+// Patterns without a path separator are also matched against the name of the file,
+// so that e.g. "*.txt" matches text files in all subdirectories.
+func matchFilePattern(pattern, path string) (bool, error) {
+	match, err := pathutil.Match(pattern, path)
+	if err != nil {
+		return false, fmt.Errorf("malformed filepath syntax %q", pattern)
+	}
+	if match || strings.Contains(pattern, "/") {
+		return match, nil
+	}
+	match, _ = pathutil.Match(pattern, pathutil.Base(path))
+	return match, nil
+}
+
 // TarFileSystem creates a tar archive from a filesystem.
 func TarFileSystem(ctx context.Context, fs vfs.FileSystem, root string, writer io.Writer, opts TarFileSystemOptions) error {
 	tw := tar.NewWriter(writer)
@@ -261,8 +372,12 @@ func addFileToTar(ctx context.Context, fs vfs.FileSystem, tw *tar.Writer, path s
 	}
 	log := logr.FromContextOrDiscard(ctx)
 
+	info, err := fs.Lstat(realPath)
+	if err != nil {
+		return err
+	}
 	if len(path) != 0 { // do not check the root
-		include, err := opts.Included(path)
+		include, err := opts.includedFile(path, info)
 		if err != nil {
 			return err
 		}
@@ -270,16 +385,15 @@ func addFileToTar(ctx context.Context, fs vfs.FileSystem, tw *tar.Writer, path s
 			return nil
 		}
 	}
-	info, err := fs.Lstat(realPath)
-	if err != nil {
-		return err
-	}
 
 	header, err := tar.FileInfoHeader(info, "")
 	if err != nil {
 		return err
 	}
 	header.Name = path
+	if !opts.PreservePermissions {
+		normalizeHeader(header)
+	}
 
 	switch {
 	case info.IsDir():
@@ -289,20 +403,16 @@ func addFileToTar(ctx context.Context, fs vfs.FileSystem, tw *tar.Writer, path s
 				return fmt.Errorf("unable to write header for %q: %w", path, err)
 			}
 		}
-		err := vfs.Walk(fs, realPath, func(subFilePath string, info os.FileInfo, err error) error {
-			if subFilePath == realPath {
-				return nil
-			}
-			if err != nil {
+		entries, err := vfs.ReadDir(fs, realPath)
+		if err != nil {
+			return fmt.Errorf("unable to read directory %q: %w", realPath, err)
+		}
+		for _, entry := range entries {
+			if err := addFileToTar(ctx, fs, tw, pathutil.Join(path, entry.Name()), filepath.Join(realPath, entry.Name()), opts); err != nil {
 				return err
 			}
-			relPath, err := filepath.Rel(realPath, subFilePath)
-			if err != nil {
-				return fmt.Errorf("unable to calculate relative path for %s: %w", subFilePath, err)
-			}
-			return addFileToTar(ctx, fs, tw, pathutil.Join(path, relPath), subFilePath, opts)
-		})
-		return err
+		}
+		return nil
 	case info.Mode().IsRegular():
 		if err := tw.WriteHeader(header); err != nil {
 			return fmt.Errorf("unable to write header for %q: %w", path, err)
@@ -333,3 +443,17 @@ func addFileToTar(ctx context.Context, fs vfs.FileSystem, tw *tar.Writer, path s
 		return fmt.Errorf("unsupported file type %s in %s", info.Mode().String(), path)
 	}
 }
+
+// normalizeHeader removes the file owner and sets a default mode
+// so that the resulting tar does not depend on the local permissions.
+func normalizeHeader(header *tar.Header) {
+	header.Uid = 0
+	header.Gid = 0
+	header.Uname = ""
+	header.Gname = ""
+	if header.Typeflag == tar.TypeDir || header.Mode&0111 != 0 {
+		header.Mode = 0755
+	} else {
+		header.Mode = 0644
+	}
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package input_test
+
+import (
+	"context"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/pkg/componentarchive/input"
+)
+
+var _ = Describe("Input", func() {
+
+	var fs vfs.FileSystem
+
+	BeforeEach(func() {
+		fs = memoryfs.New()
+		Expect(fs.MkdirAll("/input", 0755)).To(Succeed())
+		Expect(vfs.WriteFile(fs, "/input/data.txt", []byte("test"), 0644)).To(Succeed())
+	})
+
+	Context("AddResource", func() {
+		It("should add a file input as local blob resource", func() {
+			archive := ctf.NewComponentArchive(&cdv2.ComponentDescriptor{}, fs)
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "data",
+					Version: "v0.0.1",
+					Type:    "plain-text",
+				},
+				Relation: cdv2.LocalRelation,
+			}
+			in := &input.BlobInput{
+				Type: input.FileInputType,
+				Path: "data.txt",
+			}
+
+			Expect(input.AddResource(context.TODO(), fs, archive, &res, in, "/input/resources.yaml")).To(Succeed())
+			Expect(archive.ComponentDescriptor.Resources).To(HaveLen(1))
+
+			acc := &cdv2.LocalFilesystemBlobAccess{}
+			Expect(archive.ComponentDescriptor.Resources[0].Access.DecodeInto(acc)).To(Succeed())
+			Expect(acc.Filename).To(Equal(digest.FromString("test").String()))
+			Expect(acc.MediaType).To(Equal(input.MediaTypeOctetStream))
+		})
+	})
+
+	Context("AddSource", func() {
+		It("should add a file input as local blob source with the source type as media type", func() {
+			archive := ctf.NewComponentArchive(&cdv2.ComponentDescriptor{}, fs)
+			src := cdv2.Source{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "data",
+					Version: "v0.0.1",
+					Type:    "git",
+				},
+			}
+			in := &input.BlobInput{
+				Type: input.FileInputType,
+				Path: "/input/data.txt",
+			}
+
+			Expect(input.AddSource(context.TODO(), fs, archive, &src, in, "")).To(Succeed())
+			Expect(archive.ComponentDescriptor.Sources).To(HaveLen(1))
+
+			acc := &cdv2.LocalFilesystemBlobAccess{}
+			Expect(archive.ComponentDescriptor.Sources[0].Access.DecodeInto(acc)).To(Succeed())
+			Expect(acc.MediaType).To(Equal("git"))
+		})
+	})
+
+	Context("OCM", func() {
+		It("should convert a dir input to an ocm input and back", func() {
+			compress := true
+			in := input.BlobInput{
+				Type:             input.DirInputType,
+				Path:             "./charts",
+				MediaType:        "application/x-tar",
+				CompressWithGzip: &compress,
+				PreserveDir:      true,
+				ExcludeFiles:     []string{"*.bak"},
+			}
+
+			ocmIn, err := input.ToOCMInput(in)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ocmIn).To(Equal(&input.OCMInput{
+				Type:         input.OCMDirInputType,
+				Path:         "./charts",
+				MediaType:    "application/x-tar",
+				Compress:     true,
+				PreserveDir:  true,
+				ExcludeFiles: []string{"*.bak"},
+			}))
+
+			out, err := input.FromOCMInput(*ocmIn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*out).To(Equal(in))
+		})
+
+		It("should convert the image of a docker input", func() {
+			ocmIn, err := input.ToOCMInput(input.BlobInput{
+				Type:  input.DockerInputType,
+				Image: "example.com/image:v1",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ocmIn.Type).To(Equal(input.OCMDockerInputType))
+			Expect(ocmIn.Path).To(Equal("example.com/image:v1"))
+
+			out, err := input.FromOCMInput(*ocmIn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.Image).To(Equal("example.com/image:v1"))
+			Expect(out.Path).To(BeEmpty())
+		})
+
+		It("should fail to convert inputs without ocm equivalent", func() {
+			_, err := input.ToOCMInput(input.BlobInput{
+				Type: input.DockerArchiveInputType,
+				Path: "image.tar",
+			})
+			Expect(err).To(HaveOccurred())
+
+			_, err = input.FromOCMInput(input.OCMInput{
+				Type: "spiff",
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+})
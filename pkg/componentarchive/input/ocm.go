@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package input
+
+import (
+	"fmt"
+)
+
+const (
+	// OCMFileInputType is the ocm input type of a single file.
+	OCMFileInputType = "file"
+	// OCMDirInputType is the ocm input type of a directory that is tarred.
+	OCMDirInputType = "dir"
+	// OCMDockerInputType is the ocm input type of an image of the local docker daemon.
+	OCMDockerInputType = "docker"
+	// OCMHelmInputType is the ocm input type of a helm chart.
+	OCMHelmInputType = "helm"
+)
+
+// OCMInput is the input specification of the Open Component Model (OCM) cli.
+// Only the attributes that have an equivalent in BlobInput are supported.
+type OCMInput struct {
+	// Type is the ocm input type.
+	Type string `json:"type"`
+	// MediaType is the media type of the resulting blob.
+	MediaType string `json:"mediaType,omitempty"`
+	// Path is the path of the file or directory.
+	// For inputs of type "docker" it is the name of the image in the local docker daemon.
+	Path string `json:"path,omitempty"`
+	// Compress defines that the blob is compressed using gzip.
+	Compress bool `json:"compress,omitempty"`
+	// PreserveDir defines that the directory itself is included in the blob.
+	PreserveDir bool `json:"preserveDir,omitempty"`
+	// IncludeFiles is a list of shell file name patterns that describe the files that are included.
+	IncludeFiles []string `json:"includeFiles,omitempty"`
+	// ExcludeFiles is a list of shell file name patterns that describe the files that are excluded.
+	ExcludeFiles []string `json:"excludeFiles,omitempty"`
+	// FollowSymlinks defines that symlinks are resolved when a directory is tarred.
+	FollowSymlinks bool `json:"followSymlinks,omitempty"`
+}
+
+// ToOCMInput converts a blob input into the equivalent ocm input.
+// Inputs of type "docker-archive" and inputs that do not preserve file permissions cannot be converted
+// as the ocm cli does not support them.
+func ToOCMInput(in BlobInput) (*OCMInput, error) {
+	out := &OCMInput{
+		MediaType:      in.MediaType,
+		Path:           in.Path,
+		Compress:       in.Compress(),
+		PreserveDir:    in.PreserveDir,
+		IncludeFiles:   in.IncludeFiles,
+		ExcludeFiles:   in.ExcludeFiles,
+		FollowSymlinks: in.FollowSymlinks,
+	}
+	switch in.Type {
+	case FileInputType:
+		out.Type = OCMFileInputType
+	case DirInputType:
+		out.Type = OCMDirInputType
+	case HelmInputType:
+		out.Type = OCMHelmInputType
+	case DockerInputType:
+		out.Type = OCMDockerInputType
+		out.Path = in.Image
+	default:
+		return nil, fmt.Errorf("input type %q has no ocm equivalent", in.Type)
+	}
+	if !in.PreservePermissions() {
+		return nil, fmt.Errorf("inputs that do not preserve file permissions have no ocm equivalent")
+	}
+	return out, nil
+}
+
+// FromOCMInput converts an ocm input into the equivalent blob input.
+func FromOCMInput(in OCMInput) (*BlobInput, error) {
+	out := &BlobInput{
+		MediaType:      in.MediaType,
+		Path:           in.Path,
+		PreserveDir:    in.PreserveDir,
+		IncludeFiles:   in.IncludeFiles,
+		ExcludeFiles:   in.ExcludeFiles,
+		FollowSymlinks: in.FollowSymlinks,
+	}
+	if in.Compress {
+		compress := true
+		out.CompressWithGzip = &compress
+	}
+	switch in.Type {
+	case OCMFileInputType:
+		out.Type = FileInputType
+	case OCMDirInputType:
+		out.Type = DirInputType
+	case OCMHelmInputType:
+		out.Type = HelmInputType
+	case OCMDockerInputType:
+		out.Type = DockerInputType
+		out.Path = ""
+		out.Image = in.Path
+	default:
+		return nil, fmt.Errorf("ocm input type %q is not supported", in.Type)
+	}
+	return out, nil
+}
@@ -0,0 +1,250 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/pkg/componentarchive/input"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// IntegrityManifestFileName is the name of the integrity manifest that is embedded in tar and tgz component archives.
+const IntegrityManifestFileName = "integrity.json"
+
+// IntegrityManifest contains the digests of all files of an archived component archive.
+type IntegrityManifest struct {
+	// ComponentDescriptorDigest is the digest of the component descriptor as computed by the component archive.
+	ComponentDescriptorDigest string `json:"componentDescriptorDigest"`
+	// Files maps the path of every file in the archive to its sha256 digest.
+	Files map[string]string `json:"files"`
+}
+
+// WriteWithIntegrityManifest writes the given component archive as tar or tgz and
+// appends an integrity manifest with the digests of all contained files.
+func WriteWithIntegrityManifest(fs vfs.FileSystem, path string, ca *ctf.ComponentArchive, format ctf.ArchiveFormat) error {
+	if err := ValidateOutputFormat(format, false); err != nil {
+		return err
+	}
+	if format == ctf.ArchiveFormatFilesystem {
+		return fmt.Errorf("an integrity manifest can only be embedded in %q or %q archives", ctf.ArchiveFormatTar, ctf.ArchiveFormatTarGzip)
+	}
+
+	cdDigest, err := ca.Digest()
+	if err != nil {
+		return fmt.Errorf("unable to compute component descriptor digest: %w", err)
+	}
+
+	out, err := fs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("unable to open exported file %s: %s", path, err.Error())
+	}
+	defer out.Close()
+
+	var (
+		w  io.Writer = out
+		gw *gzip.Writer
+	)
+	if format == ctf.ArchiveFormatTarGzip {
+		gw = gzip.NewWriter(out)
+		w = gw
+	}
+
+	// the component archive is written to a pipe so that its entries can be hashed
+	// and copied to the resulting archive before the manifest is appended.
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(ca.WriteTar(pw))
+	}()
+
+	manifest := IntegrityManifest{
+		ComponentDescriptorDigest: cdDigest,
+		Files:                     map[string]string{},
+	}
+	tw := tar.NewWriter(w)
+	tr := tar.NewReader(pr)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			_ = pr.CloseWithError(err)
+			return fmt.Errorf("unable to read component archive: %w", err)
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			_ = pr.CloseWithError(err)
+			return fmt.Errorf("unable to write header of %s: %w", header.Name, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		digester := digest.SHA256.Digester()
+		if _, err := io.Copy(io.MultiWriter(tw, digester.Hash()), tr); err != nil {
+			_ = pr.CloseWithError(err)
+			return fmt.Errorf("unable to write %s: %w", header.Name, err)
+		}
+		manifest.Files[header.Name] = digester.Digest().String()
+	}
+	// drain the pipe so that the writing go routine terminates.
+	if _, err := io.Copy(ioutil.Discard, pr); err != nil {
+		return fmt.Errorf("unable to read component archive: %w", err)
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to encode integrity manifest: %w", err)
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Name:    IntegrityManifestFileName,
+		Size:    int64(len(manifestBytes)),
+		Mode:    0644,
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to write integrity manifest header: %w", err)
+	}
+	if _, err := tw.Write(manifestBytes); err != nil {
+		return fmt.Errorf("unable to write integrity manifest: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("unable to close tar writer: %w", err)
+	}
+	if gw != nil {
+		if err := gw.Close(); err != nil {
+			return fmt.Errorf("unable to close gzip writer: %w", err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("unable to close file: %w", err)
+	}
+	return nil
+}
+
+// VerifyIntegrity checks a tar or tgz component archive against its embedded integrity manifest.
+// The verification fails if a file is missing, has been added or does not match its digest.
+func VerifyIntegrity(fs vfs.FileSystem, path string) (*IntegrityManifest, error) {
+	mimetype, err := utils.GetFileType(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get mimetype of %q: %s", path, err.Error())
+	}
+	file, err := fs.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read component archive from %q: %w", path, err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	switch mimetype {
+	case "application/x-gzip", input.MediaTypeGZip, "application/tar+gzip":
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open gzip reader: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case "application/octet-stream":
+	default:
+		return nil, fmt.Errorf("unsupported file type %q. Expected a tar or a tar.gz", mimetype)
+	}
+
+	var (
+		manifest *IntegrityManifest
+		cdBytes  []byte
+		digests  = map[string]string{}
+	)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("unable to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Name == IntegrityManifestFileName {
+			manifest = &IntegrityManifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("unable to decode integrity manifest: %w", err)
+			}
+			continue
+		}
+
+		digester := digest.SHA256.Digester()
+		var w io.Writer = digester.Hash()
+		var buf bytes.Buffer
+		if header.Name == ctf.ComponentDescriptorFileName {
+			w = io.MultiWriter(w, &buf)
+		}
+		if _, err := io.Copy(w, tr); err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", header.Name, err)
+		}
+		digests[header.Name] = digester.Digest().String()
+		if header.Name == ctf.ComponentDescriptorFileName {
+			cdBytes = buf.Bytes()
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("archive %q does not contain an integrity manifest", path)
+	}
+
+	var errs []string
+	for name, expected := range manifest.Files {
+		actual, ok := digests[name]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("file %s is missing", name))
+			continue
+		}
+		if actual != expected {
+			errs = append(errs, fmt.Sprintf("file %s has digest %s but expected %s", name, actual, expected))
+		}
+	}
+	for name := range digests {
+		if _, ok := manifest.Files[name]; !ok {
+			errs = append(errs, fmt.Sprintf("file %s is not part of the integrity manifest", name))
+		}
+	}
+
+	if cdBytes != nil {
+		cd := &cdv2.ComponentDescriptor{}
+		if err := codec.Decode(cdBytes, cd); err != nil {
+			return nil, fmt.Errorf("unable to decode component descriptor: %w", err)
+		}
+		cdDigest, err := ctf.NewComponentArchive(cd, nil).Digest()
+		if err != nil {
+			return nil, fmt.Errorf("unable to compute component descriptor digest: %w", err)
+		}
+		if cdDigest != manifest.ComponentDescriptorDigest {
+			errs = append(errs, fmt.Sprintf("component descriptor has digest %s but expected %s", cdDigest, manifest.ComponentDescriptorDigest))
+		}
+	}
+
+	if len(errs) != 0 {
+		sort.Strings(errs)
+		return manifest, fmt.Errorf("integrity check of %q failed:\n%s", path, strings.Join(errs, "\n"))
+	}
+	return manifest, nil
+}
@@ -0,0 +1,250 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+
+	ociopts "github.com/gardener/component-cli/ociclient/options"
+	"github.com/gardener/component-cli/pkg/commands/constants"
+	"github.com/gardener/component-cli/pkg/components/schema"
+)
+
+// ListOptions defines the options to print the elements of a component descriptor.
+// The component descriptor is read from a component archive or fetched from an oci registry.
+type ListOptions struct {
+	// ComponentArchivePath is the path to the component archive.
+	ComponentArchivePath string
+
+	// BaseUrl is the oci registry where the remote component descriptor is stored.
+	BaseUrl string
+	// ComponentName is the name of the remote component descriptor.
+	ComponentName string
+	// Version is the version of the remote component descriptor.
+	Version string
+
+	ComponentNameMapping string
+
+	// Selector contains field selectors of the form "field=value" that the printed elements must match.
+	// Nested fields are separated by a dot (e.g. "access.type"). Keys that are no field of the element
+	// are matched against its extra identity.
+	Selector map[string]string
+	// OutputFormat defines the format of the output.
+	OutputFormat string
+
+	// OciOptions contains all exposed options to configure the oci client.
+	OciOptions ociopts.Options
+}
+
+// ListColumn is a column of the table output.
+type ListColumn struct {
+	// Header is the header of the column.
+	Header string
+	// Field is the dot separated path of the printed field.
+	Field string
+}
+
+func (o *ListOptions) AddFlags(fs *pflag.FlagSet, defaultOutputFormat string) {
+	fs.StringVar(&o.ComponentNameMapping, "component-name-mapping", string(cdv2.OCIRegistryURLPathMapping), "[OPTIONAL] repository context name mapping")
+	fs.StringToStringVar(&o.Selector, "selector", map[string]string{}, "field selector of the form field=value, e.g. type=ociImage or access.type=ociRegistry. Can be specified multiple times")
+	fs.StringVarP(&o.OutputFormat, "output", "o", defaultOutputFormat, "output format. One of table, json or yaml")
+	o.OciOptions.AddFlags(fs)
+}
+
+// Complete parses the arguments which are either a component archive path
+// or the base url, name and version of a remote component descriptor.
+func (o *ListOptions) Complete(args []string) error {
+	switch len(args) {
+	case 0:
+		o.ComponentArchivePath = filepath.Dir(os.Getenv(constants.ComponentArchivePathEnvName))
+	case 1:
+		o.ComponentArchivePath = args[0]
+	case 3:
+		o.BaseUrl = args[0]
+		o.ComponentName = args[1]
+		o.Version = args[2]
+
+		if len(o.OciOptions.CacheDir) == 0 {
+			cliHomeDir, err := constants.CliHomeDir()
+			if err != nil {
+				return err
+			}
+			o.OciOptions.CacheDir = filepath.Join(cliHomeDir, "components")
+			if err := os.MkdirAll(o.OciOptions.CacheDir, os.ModePerm); err != nil {
+				return fmt.Errorf("unable to create cache directory %s: %w", o.OciOptions.CacheDir, err)
+			}
+		}
+	default:
+		return fmt.Errorf("illegal number of arguments: %d", len(args))
+	}
+
+	if len(o.ComponentArchivePath) == 0 && len(o.BaseUrl) == 0 {
+		return errors.New("a component archive path or a remote component descriptor must be provided")
+	}
+	switch o.OutputFormat {
+	case "table", "json", "yaml":
+	default:
+		return fmt.Errorf("unsupported output format %q: must be table, json or yaml", o.OutputFormat)
+	}
+	return nil
+}
+
+// ComponentDescriptor reads the component descriptor of the component archive or fetches the remote component descriptor.
+func (o *ListOptions) ComponentDescriptor(ctx context.Context, log logr.Logger, fs vfs.FileSystem) (*cdv2.ComponentDescriptor, error) {
+	if len(o.BaseUrl) == 0 {
+		archive, _, err := Parse(fs, o.ComponentArchivePath)
+		if err != nil {
+			return nil, err
+		}
+		return archive.ComponentDescriptor, nil
+	}
+
+	ociClient, _, err := o.OciOptions.Build(log, fs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build oci client: %s", err.Error())
+	}
+	repoCtx := cdv2.NewOCIRegistryRepository(o.BaseUrl, cdv2.ComponentNameMapping(o.ComponentNameMapping))
+	cd, err := schema.NewResolver(ociClient).Resolve(ctx, repoCtx, o.ComponentName, o.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch component descriptor %s:%s: %w", o.ComponentName, o.Version, err)
+	}
+	return cd, nil
+}
+
+// Matches checks whether the element matches all field selectors.
+func (o *ListOptions) Matches(element interface{}) (bool, error) {
+	if len(o.Selector) == 0 {
+		return true, nil
+	}
+	fields, err := toFieldMap(element)
+	if err != nil {
+		return false, err
+	}
+	for key, value := range o.Selector {
+		actual, ok := lookupField(fields, key)
+		if !ok {
+			extraIdentity, _ := fields["extraIdentity"].(map[string]interface{})
+			actual, ok = extraIdentity[key]
+		}
+		if !ok || formatFieldValue(actual) != value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Print writes the elements as table with the given columns, as json or as yaml.
+// The elements must be a slice.
+func (o *ListOptions) Print(w io.Writer, elements interface{}, columns []ListColumn) error {
+	switch o.OutputFormat {
+	case "json":
+		out, err := json.MarshalIndent(elements, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	case "yaml":
+		out, err := yaml.Marshal(elements)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(w, string(out))
+		return err
+	}
+
+	data, err := json.Marshal(elements)
+	if err != nil {
+		return err
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return fmt.Errorf("unable to decode elements: %w", err)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = column.Header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			if value, ok := lookupField(row, column.Field); ok {
+				values[i] = formatFieldValue(value)
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+// toFieldMap returns the json representation of the element as map.
+func toFieldMap(element interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(element)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// lookupField returns the value of the dot separated field path.
+func lookupField(fields map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = fields
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// formatFieldValue formats a json value for the table output and the selector matching.
+// Objects are formatted as sorted comma separated key=value pairs.
+func formatFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(v))
+		for key, val := range v {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", key, formatFieldValue(val)))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
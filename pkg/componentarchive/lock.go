@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// LockFileName is the default name of the lock file of a component archive.
+const LockFileName = "component-lock.yaml"
+
+// Lock pins all external references of a component descriptor by their digest.
+type Lock struct {
+	// Resources contains the digests of all resources with an oci registry access.
+	Resources []LockedResource `json:"resources,omitempty"`
+	// ComponentReferences contains the manifest digests of all referenced component descriptors.
+	ComponentReferences []LockedComponentReference `json:"componentReferences,omitempty"`
+}
+
+// LockedResource describes the pinned oci artifact of a resource.
+type LockedResource struct {
+	Name           string        `json:"name"`
+	ExtraIdentity  cdv2.Identity `json:"extraIdentity,omitempty"`
+	ImageReference string        `json:"imageReference"`
+	Digest         string        `json:"digest"`
+}
+
+// LockedComponentReference describes the pinned component descriptor of a component reference.
+type LockedComponentReference struct {
+	Name          string        `json:"name"`
+	ExtraIdentity cdv2.Identity `json:"extraIdentity,omitempty"`
+	ComponentName string        `json:"componentName"`
+	Version       string        `json:"version"`
+	Digest        string        `json:"digest"`
+}
+
+// GenerateLock resolves all oci registry accesses and component references of the component descriptor
+// to their current digest.
+// Component references are resolved in the effective repository context of the component descriptor.
+func GenerateLock(ctx context.Context, resolver ociclient.Resolver, cd *cdv2.ComponentDescriptor) (*Lock, error) {
+	lock := &Lock{}
+	for _, res := range cd.Resources {
+		if res.Access == nil || res.Access.GetType() != cdv2.OCIRegistryType {
+			continue
+		}
+		ociAccess := &cdv2.OCIRegistryAccess{}
+		if err := res.Access.DecodeInto(ociAccess); err != nil {
+			return nil, fmt.Errorf("unable to decode access of resource %q: %w", res.Name, err)
+		}
+		resolved, err := ociclient.ResolveReference(ctx, resolver, ociAccess.ImageReference)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve %q of resource %q: %w", ociAccess.ImageReference, res.Name, err)
+		}
+		lock.Resources = append(lock.Resources, LockedResource{
+			Name:           res.Name,
+			ExtraIdentity:  res.ExtraIdentity,
+			ImageReference: ociAccess.ImageReference,
+			Digest:         resolved.Digest.String(),
+		})
+	}
+
+	if len(cd.ComponentReferences) == 0 {
+		return lock, nil
+	}
+	repoCtx, err := getOCIRepositoryContext(cd)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range cd.ComponentReferences {
+		ociRef, err := cdoci.OCIRef(*repoCtx, ref.ComponentName, ref.Version)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get oci reference of component reference %q: %w", ref.Name, err)
+		}
+		_, desc, err := resolver.Resolve(ctx, ociRef)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve component reference %q: %w", ref.Name, err)
+		}
+		lock.ComponentReferences = append(lock.ComponentReferences, LockedComponentReference{
+			Name:          ref.Name,
+			ExtraIdentity: ref.ExtraIdentity,
+			ComponentName: ref.ComponentName,
+			Version:       ref.Version,
+			Digest:        desc.Digest.String(),
+		})
+	}
+	return lock, nil
+}
+
+// VerifyLock resolves all external references of the component descriptor again and
+// returns a description of every reference that drifted from the lock.
+// An empty list is returned if the component descriptor still matches the lock.
+func VerifyLock(ctx context.Context, resolver ociclient.Resolver, cd *cdv2.ComponentDescriptor, lock *Lock) ([]string, error) {
+	current, err := GenerateLock(ctx, resolver, cd)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := []string{}
+	lockedResources := map[string]LockedResource{}
+	for _, res := range lock.Resources {
+		lockedResources[lockKey(res.Name, res.ExtraIdentity)] = res
+	}
+	for _, res := range current.Resources {
+		key := lockKey(res.Name, res.ExtraIdentity)
+		locked, ok := lockedResources[key]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("resource %q is not locked", res.Name))
+			continue
+		}
+		delete(lockedResources, key)
+		if locked.Digest != res.Digest {
+			drift = append(drift, fmt.Sprintf("resource %q (%s) is locked to %s but resolves to %s", res.Name, res.ImageReference, locked.Digest, res.Digest))
+		}
+	}
+	for _, res := range lock.Resources {
+		if _, ok := lockedResources[lockKey(res.Name, res.ExtraIdentity)]; ok {
+			drift = append(drift, fmt.Sprintf("locked resource %q is not part of the component descriptor", res.Name))
+		}
+	}
+
+	lockedRefs := map[string]LockedComponentReference{}
+	for _, ref := range lock.ComponentReferences {
+		lockedRefs[lockKey(ref.Name, ref.ExtraIdentity)] = ref
+	}
+	for _, ref := range current.ComponentReferences {
+		key := lockKey(ref.Name, ref.ExtraIdentity)
+		locked, ok := lockedRefs[key]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("component reference %q is not locked", ref.Name))
+			continue
+		}
+		delete(lockedRefs, key)
+		if locked.Digest != ref.Digest {
+			drift = append(drift, fmt.Sprintf("component reference %q (%s:%s) is locked to %s but resolves to %s", ref.Name, ref.ComponentName, ref.Version, locked.Digest, ref.Digest))
+		}
+	}
+	for _, ref := range lock.ComponentReferences {
+		if _, ok := lockedRefs[lockKey(ref.Name, ref.ExtraIdentity)]; ok {
+			drift = append(drift, fmt.Sprintf("locked component reference %q is not part of the component descriptor", ref.Name))
+		}
+	}
+	return drift, nil
+}
+
+// PinResourceAccesses rewrites the oci registry accesses of all locked resources to their digest form.
+func PinResourceAccesses(cd *cdv2.ComponentDescriptor, lock *Lock) error {
+	for _, locked := range lock.Resources {
+		for i, res := range cd.Resources {
+			if lockKey(res.Name, res.ExtraIdentity) != lockKey(locked.Name, locked.ExtraIdentity) {
+				continue
+			}
+			refspec, err := oci.ParseRef(locked.ImageReference)
+			if err != nil {
+				return fmt.Errorf("unable to parse image reference of resource %q: %w", res.Name, err)
+			}
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(refspec.DigestRef(digest.Digest(locked.Digest))))
+			if err != nil {
+				return fmt.Errorf("unable to create access of resource %q: %w", res.Name, err)
+			}
+			cd.Resources[i].Access = &acc
+		}
+	}
+	return nil
+}
+
+func getOCIRepositoryContext(cd *cdv2.ComponentDescriptor) (*cdv2.OCIRegistryRepository, error) {
+	repoCtx := cd.GetEffectiveRepositoryContext()
+	if repoCtx == nil {
+		return nil, errors.New("a repository context is needed to resolve component references")
+	}
+	if repoCtx.GetType() != cdv2.OCIRegistryType {
+		return nil, fmt.Errorf("unsupported repository context type %q", repoCtx.GetType())
+	}
+	ociRepoCtx := &cdv2.OCIRegistryRepository{}
+	if err := repoCtx.DecodeInto(ociRepoCtx); err != nil {
+		return nil, fmt.Errorf("unable to decode repository context: %w", err)
+	}
+	return ociRepoCtx, nil
+}
+
+func lockKey(name string, extraIdentity cdv2.Identity) string {
+	meta := cdv2.IdentityObjectMeta{Name: name, ExtraIdentity: extraIdentity}
+	return string(meta.GetIdentityDigest())
+}
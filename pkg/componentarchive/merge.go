@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+)
+
+// MergeStrategy defines how conflicting entries of two component archives are merged.
+type MergeStrategy string
+
+const (
+	// MergeStrategyFail fails the merge if both archives contain a different entry with the same identity.
+	MergeStrategyFail MergeStrategy = "fail"
+	// MergeStrategyPreferFirst keeps the entry of the first archive on conflicts.
+	MergeStrategyPreferFirst MergeStrategy = "prefer-first"
+	// MergeStrategyPreferSecond keeps the entry of the second archive on conflicts.
+	MergeStrategyPreferSecond MergeStrategy = "prefer-second"
+)
+
+// ValidateMergeStrategy validates the merge strategy.
+func ValidateMergeStrategy(strategy MergeStrategy) error {
+	switch strategy {
+	case MergeStrategyFail, MergeStrategyPreferFirst, MergeStrategyPreferSecond:
+		return nil
+	default:
+		return fmt.Errorf("unsupported merge strategy %q, use %q, %q or %q",
+			strategy, MergeStrategyFail, MergeStrategyPreferFirst, MergeStrategyPreferSecond)
+	}
+}
+
+// Merge merges the resources, sources and component references of two component archives of the same component version
+// into a new in-memory component archive. All other fields of the component descriptor are taken from the first archive.
+// Entries are matched by their identity, identical entries are only added once and
+// different entries with the same identity are resolved with the given strategy.
+// The local blobs of the merged entries are copied into the new archive.
+func Merge(ctx context.Context, first, second *ctf.ComponentArchive, strategy MergeStrategy) (*ctf.ComponentArchive, error) {
+	if err := ValidateMergeStrategy(strategy); err != nil {
+		return nil, err
+	}
+	firstCd, secondCd := first.ComponentDescriptor, second.ComponentDescriptor
+	if firstCd.Name != secondCd.Name || firstCd.Version != secondCd.Version {
+		return nil, fmt.Errorf("only component archives of the same component version can be merged but got %s:%s and %s:%s",
+			firstCd.Name, firstCd.Version, secondCd.Name, secondCd.Version)
+	}
+
+	cd := firstCd.DeepCopy()
+	cd.Resources = make([]cdv2.Resource, 0)
+	cd.Sources = make([]cdv2.Source, 0)
+	cd.ComponentReferences = make([]cdv2.ComponentReference, 0)
+	m := &merger{
+		strategy: strategy,
+		fs:       memoryfs.New(),
+	}
+	if err := m.fs.Mkdir(ctf.BlobsDirectoryName, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("unable to create blob directory: %w", err)
+	}
+
+	// the origins are the archives that contain the blobs of the merged resources and sources.
+	var resOrigins, srcOrigins []*ctf.ComponentArchive
+
+	// resources
+	resources := map[string]int{}
+	for i, archive := range []*ctf.ComponentArchive{first, second} {
+		for _, res := range archive.ComponentDescriptor.Resources {
+			key := string(res.GetIdentityDigest())
+			idx, ok := resources[key]
+			if !ok {
+				resources[key] = len(cd.Resources)
+				cd.Resources = append(cd.Resources, res)
+				resOrigins = append(resOrigins, archive)
+				continue
+			}
+			replace, err := m.resolveConflict(i, "resource", res.GetName(), cd.Resources[idx], res)
+			if err != nil {
+				return nil, err
+			}
+			if replace {
+				cd.Resources[idx] = res
+				resOrigins[idx] = archive
+			}
+		}
+	}
+
+	// sources
+	sources := map[string]int{}
+	for i, archive := range []*ctf.ComponentArchive{first, second} {
+		for _, src := range archive.ComponentDescriptor.Sources {
+			key := string(src.GetIdentityDigest())
+			idx, ok := sources[key]
+			if !ok {
+				sources[key] = len(cd.Sources)
+				cd.Sources = append(cd.Sources, src)
+				srcOrigins = append(srcOrigins, archive)
+				continue
+			}
+			replace, err := m.resolveConflict(i, "source", src.GetName(), cd.Sources[idx], src)
+			if err != nil {
+				return nil, err
+			}
+			if replace {
+				cd.Sources[idx] = src
+				srcOrigins[idx] = archive
+			}
+		}
+	}
+
+	// component references
+	refs := map[string]int{}
+	for i, archive := range []*ctf.ComponentArchive{first, second} {
+		for _, ref := range archive.ComponentDescriptor.ComponentReferences {
+			key := string(ref.GetIdentityDigest())
+			idx, ok := refs[key]
+			if !ok {
+				refs[key] = len(cd.ComponentReferences)
+				cd.ComponentReferences = append(cd.ComponentReferences, ref)
+				continue
+			}
+			replace, err := m.resolveConflict(i, "component reference", ref.GetName(), cd.ComponentReferences[idx], ref)
+			if err != nil {
+				return nil, err
+			}
+			if replace {
+				cd.ComponentReferences[idx] = ref
+			}
+		}
+	}
+
+	// only the blobs of the merged entries are copied
+	for i, res := range cd.Resources {
+		if err := m.copyBlob(ctx, resOrigins[i], res.Access); err != nil {
+			return nil, fmt.Errorf("unable to copy blob of resource %s: %w", res.GetName(), err)
+		}
+	}
+	for i, src := range cd.Sources {
+		if err := m.copyBlob(ctx, srcOrigins[i], src.Access); err != nil {
+			return nil, fmt.Errorf("unable to copy blob of source %s: %w", src.GetName(), err)
+		}
+	}
+
+	return ctf.NewComponentArchive(cd, m.fs), nil
+}
+
+type merger struct {
+	strategy MergeStrategy
+	// fs is the filesystem of the merged component archive.
+	fs vfs.FileSystem
+}
+
+// resolveConflict returns whether the existing entry should be replaced by the entry of the archive with the given index.
+func (m *merger) resolveConflict(archiveIndex int, kind, name string, existing, entry interface{}) (bool, error) {
+	if archiveIndex == 0 {
+		return false, fmt.Errorf("the first component archive contains the %s %s multiple times", kind, name)
+	}
+	if equalEntries(existing, entry) {
+		return false, nil
+	}
+	switch m.strategy {
+	case MergeStrategyPreferFirst:
+		return false, nil
+	case MergeStrategyPreferSecond:
+		return true, nil
+	default:
+		return false, fmt.Errorf("the %s %s is defined differently in both component archives", kind, name)
+	}
+}
+
+// equalEntries compares two entries by their json representation as unstructured accesses are not comparable otherwise.
+func equalEntries(a, b interface{}) bool {
+	aData, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bData, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	var aObj, bObj interface{}
+	if err := json.Unmarshal(aData, &aObj); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(bData, &bObj); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(aObj, bObj)
+}
+
+// copyBlob copies the blob of a local filesystem access from the given archive into the merged archive.
+// Other access types are ignored.
+func (m *merger) copyBlob(ctx context.Context, archive *ctf.ComponentArchive, access *cdv2.UnstructuredTypedObject) error {
+	if access == nil || access.GetType() != cdv2.LocalFilesystemBlobType {
+		return nil
+	}
+	localFsAccess := &cdv2.LocalFilesystemBlobAccess{}
+	if err := access.DecodeInto(localFsAccess); err != nil {
+		return fmt.Errorf("unable to decode access: %w", err)
+	}
+	blobPath := ctf.BlobPath(localFsAccess.Filename)
+
+	var buf bytes.Buffer
+	if _, err := archive.BlobResolver.Resolve(ctx, cdv2.Resource{Access: access}, &buf); err != nil {
+		return fmt.Errorf("unable to read blob %s: %w", localFsAccess.Filename, err)
+	}
+
+	existing, err := vfs.ReadFile(m.fs, blobPath)
+	if err == nil {
+		// blobs are usually named by their digest, so that different blobs with the same name are not expected.
+		if digest.FromBytes(existing) != digest.FromBytes(buf.Bytes()) {
+			return fmt.Errorf("both component archives contain a different blob %s", localFsAccess.Filename)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("unable to read blob %s: %w", blobPath, err)
+	}
+	if err := vfs.WriteFile(m.fs, blobPath, buf.Bytes(), os.ModePerm); err != nil {
+		return fmt.Errorf("unable to write blob %s: %w", blobPath, err)
+	}
+	return nil
+}
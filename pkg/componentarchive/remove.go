@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/pflag"
+)
+
+// ElementSelector selects resources, sources or component references of a component descriptor by their identity.
+// Empty fields match all elements.
+type ElementSelector struct {
+	// Name is the name of the selected elements.
+	Name string
+	// Version is the version of the selected elements.
+	Version string
+	// ExtraIdentity contains key value pairs that the extra identity of the selected elements must contain.
+	ExtraIdentity map[string]string
+}
+
+func (s *ElementSelector) AddFlags(fs *pflag.FlagSet, kind string) {
+	fs.StringVar(&s.Name, "name", "", fmt.Sprintf("name of the %s", kind))
+	fs.StringVar(&s.Version, "version", "", fmt.Sprintf("[OPTIONAL] version of the %s", kind))
+	fs.StringToStringVar(&s.ExtraIdentity, "extra-identity", map[string]string{}, fmt.Sprintf("[OPTIONAL] extra identity of the %s", kind))
+}
+
+// Validate validates the selector.
+func (s *ElementSelector) Validate() error {
+	if len(s.Name) == 0 {
+		return errors.New("a name has to be defined")
+	}
+	return nil
+}
+
+// Matches checks whether the element with the given identity is selected.
+func (s ElementSelector) Matches(meta cdv2.IdentityObjectMeta) bool {
+	if len(s.Name) != 0 && meta.Name != s.Name {
+		return false
+	}
+	if len(s.Version) != 0 && meta.Version != s.Version {
+		return false
+	}
+	for key, value := range s.ExtraIdentity {
+		if meta.ExtraIdentity[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// RemoveResources removes all resources that match the selector from the component descriptor
+// and returns the removed resources.
+func RemoveResources(cd *cdv2.ComponentDescriptor, selector ElementSelector) []cdv2.Resource {
+	var kept, removed []cdv2.Resource
+	for _, res := range cd.Resources {
+		if selector.Matches(res.IdentityObjectMeta) {
+			removed = append(removed, res)
+			continue
+		}
+		kept = append(kept, res)
+	}
+	cd.Resources = kept
+	if cd.Resources == nil {
+		cd.Resources = []cdv2.Resource{}
+	}
+	return removed
+}
+
+// RemoveSources removes all sources that match the selector from the component descriptor
+// and returns the removed sources.
+func RemoveSources(cd *cdv2.ComponentDescriptor, selector ElementSelector) []cdv2.Source {
+	var kept, removed []cdv2.Source
+	for _, src := range cd.Sources {
+		if selector.Matches(src.IdentityObjectMeta) {
+			removed = append(removed, src)
+			continue
+		}
+		kept = append(kept, src)
+	}
+	cd.Sources = kept
+	if cd.Sources == nil {
+		cd.Sources = []cdv2.Source{}
+	}
+	return removed
+}
+
+// RemoveComponentReferences removes all component references that match the selector from the component descriptor
+// and returns the removed component references.
+func RemoveComponentReferences(cd *cdv2.ComponentDescriptor, selector ElementSelector) []cdv2.ComponentReference {
+	var kept, removed []cdv2.ComponentReference
+	for _, ref := range cd.ComponentReferences {
+		meta := cdv2.IdentityObjectMeta{Name: ref.Name, ExtraIdentity: ref.ExtraIdentity}
+		if selector.Matches(meta) && (len(selector.Version) == 0 || ref.Version == selector.Version) {
+			removed = append(removed, ref)
+			continue
+		}
+		kept = append(kept, ref)
+	}
+	cd.ComponentReferences = kept
+	if cd.ComponentReferences == nil {
+		cd.ComponentReferences = []cdv2.ComponentReference{}
+	}
+	return removed
+}
+
+// RemoveOrphanedBlobs deletes the local blobs of the given accesses from the blobs directory
+// of the component archive at the given path if they are no longer referenced by the component descriptor.
+// It returns the filenames of the deleted blobs.
+func RemoveOrphanedBlobs(fs vfs.FileSystem, archivePath string, cd *cdv2.ComponentDescriptor, accesses ...*cdv2.UnstructuredTypedObject) ([]string, error) {
+	referenced := map[string]bool{}
+	for _, res := range cd.Resources {
+		if filename, ok := localBlobFilename(res.Access); ok {
+			referenced[filename] = true
+		}
+	}
+	for _, src := range cd.Sources {
+		if filename, ok := localBlobFilename(src.Access); ok {
+			referenced[filename] = true
+		}
+	}
+
+	var deleted []string
+	for _, access := range accesses {
+		filename, ok := localBlobFilename(access)
+		if !ok || referenced[filename] {
+			continue
+		}
+		blobPath := filepath.Join(archivePath, ctf.BlobPath(filename))
+		if err := fs.Remove(blobPath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return deleted, fmt.Errorf("unable to remove blob %q: %w", blobPath, err)
+		}
+		// the same blob may be referenced by multiple removed elements.
+		referenced[filename] = true
+		deleted = append(deleted, filename)
+	}
+	return deleted, nil
+}
+
+// localBlobFilename returns the filename of a local filesystem blob access.
+func localBlobFilename(access *cdv2.UnstructuredTypedObject) (string, bool) {
+	if access == nil || access.GetType() != cdv2.LocalFilesystemBlobType {
+		return "", false
+	}
+	localAccess := &cdv2.LocalFilesystemBlobAccess{}
+	if err := access.DecodeInto(localAccess); err != nil {
+		return "", false
+	}
+	return localAccess.Filename, true
+}
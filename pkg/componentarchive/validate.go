@@ -0,0 +1,452 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package componentarchive
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/apis/v2/jsonscheme"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/projectionfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/ociclient/oci"
+	"github.com/gardener/component-cli/pkg/componentarchive/input"
+	"github.com/gardener/component-cli/pkg/components/schema"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// Severity is the severity of a validation finding.
+type Severity string
+
+const (
+	// SeverityError marks findings that make the component archive invalid.
+	SeverityError Severity = "error"
+	// SeverityWarning marks findings that do not make the component archive invalid.
+	SeverityWarning Severity = "warning"
+)
+
+// Validation rules that are reported in the findings.
+const (
+	RuleSchema         = "schema"
+	RuleBlobExists     = "blob-exists"
+	RuleBlobDigest     = "blob-digest"
+	RuleOrphanedBlob   = "orphaned-blob"
+	RuleImageReference = "image-reference"
+	RuleLabelPolicy    = "label-policy"
+)
+
+// Label policy targets.
+const (
+	LabelPolicyTargetComponent           = "component"
+	LabelPolicyTargetResources           = "resources"
+	LabelPolicyTargetSources             = "sources"
+	LabelPolicyTargetComponentReferences = "componentReferences"
+)
+
+// Finding describes a single problem of a component archive.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	// Rule is the validation rule that reported the finding.
+	Rule string `json:"rule"`
+	// Path is the path of the affected element in the component descriptor (e.g. "component.resources[0]")
+	// or the path of the affected file in the component archive.
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s [%s] %s: %s", f.Severity, f.Rule, f.Path, f.Message)
+}
+
+// Findings is a list of validation findings.
+type Findings []Finding
+
+// HasErrors returns whether the findings contain at least one error.
+func (f Findings) HasErrors() bool {
+	for _, finding := range f {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidationPolicy defines additional checks of the component descriptor of a component archive.
+type ValidationPolicy struct {
+	// Labels defines the policies for the labels of the component descriptor.
+	Labels []LabelPolicy `json:"labels,omitempty"`
+}
+
+// LabelPolicy defines a policy for the labels of the component or one of its elements.
+type LabelPolicy struct {
+	// Target defines which labels are checked.
+	// One of "component", "resources", "sources" or "componentReferences". Defaults to "component".
+	Target string `json:"target,omitempty"`
+	// Name is a regular expression that selects the labels by their name.
+	Name string `json:"name"`
+	// Value is an optional regular expression that the value of all selected labels must match.
+	// String values are matched without quotes, all other values are matched by their json representation.
+	Value string `json:"value,omitempty"`
+	// Required defines whether every target must have at least one label that matches the name.
+	Required bool `json:"required,omitempty"`
+}
+
+// ReadValidationPolicy reads a validation policy from a yaml or json file.
+func ReadValidationPolicy(fs vfs.FileSystem, path string) (*ValidationPolicy, error) {
+	data, err := vfs.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read validation policy from %q: %w", path, err)
+	}
+	policy := &ValidationPolicy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("unable to decode validation policy from %q: %w", path, err)
+	}
+	return policy, nil
+}
+
+// ValidateArchive reads the component archive at the given path and validates it with Validate.
+// The archive can be a directory, a tar or a tgz.
+// The component descriptor is decoded without validation so that schema violations are reported as findings.
+func ValidateArchive(fs vfs.FileSystem, path string, policy *ValidationPolicy) (Findings, error) {
+	archiveFs, err := openArchiveFilesystem(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := vfs.ReadFile(archiveFs, ctf.ComponentDescriptorFileName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read component descriptor of %q: %w", path, err)
+	}
+	cd, _, err := schema.Decode(data, codec.DisableValidation(true))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode component descriptor of %q: %w", path, err)
+	}
+	return Validate(archiveFs, cd, policy)
+}
+
+// openArchiveFilesystem returns a filesystem with the content of a component archive
+// in the fs, tar or tgz format.
+func openArchiveFilesystem(fs vfs.FileSystem, path string) (vfs.FileSystem, error) {
+	info, err := fs.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("component archive at %q does not exist", path)
+		}
+		return nil, fmt.Errorf("unable to read %q: %w", path, err)
+	}
+	if info.IsDir() {
+		archiveFs, err := projectionfs.New(fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create filesystem from %s: %w", path, err)
+		}
+		return archiveFs, nil
+	}
+
+	mimetype, err := utils.GetFileType(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get mimetype of %q: %w", path, err)
+	}
+	file, err := fs.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read component archive from %q: %w", path, err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	switch mimetype {
+	case "application/x-gzip", input.MediaTypeGZip, "application/tar+gzip":
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open gzip reader: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case "application/octet-stream":
+	default:
+		return nil, fmt.Errorf("unsupported file type %q. Expected a tar or a tar.gz", mimetype)
+	}
+	archiveFs := memoryfs.New()
+	if err := ctf.ExtractTarToFs(archiveFs, r); err != nil {
+		return nil, fmt.Errorf("unable to extract component archive: %w", err)
+	}
+	return archiveFs, nil
+}
+
+// Validate validates a component archive beyond the schema of its component descriptor.
+// The archive filesystem must contain the component descriptor and the blobs directory at its root.
+// Besides the schema it checks that all local blobs exist and match their digest,
+// that all oci image references are well formed and that the labels match the given policy.
+// Blobs that are not referenced by the component descriptor are reported as warnings.
+func Validate(archiveFs vfs.FileSystem, cd *cdv2.ComponentDescriptor, policy *ValidationPolicy) (Findings, error) {
+	findings := Findings{}
+	findings = append(findings, validateSchema(cd)...)
+
+	blobFindings, err := validateBlobs(archiveFs, cd)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, blobFindings...)
+
+	for i, res := range cd.Resources {
+		if res.Access == nil || res.Access.GetType() != cdv2.OCIRegistryType {
+			continue
+		}
+		path := fmt.Sprintf("component.resources[%d].access.imageReference", i)
+		ociAccess := &cdv2.OCIRegistryAccess{}
+		if err := res.Access.DecodeInto(ociAccess); err != nil {
+			findings = append(findings, newFinding(SeverityError, RuleImageReference, path, "unable to decode access: %s", err.Error()))
+			continue
+		}
+		if _, err := oci.ParseRef(ociAccess.ImageReference); err != nil {
+			findings = append(findings, newFinding(SeverityError, RuleImageReference, path, "invalid image reference %q: %s", ociAccess.ImageReference, err.Error()))
+		}
+	}
+
+	if policy != nil {
+		labelFindings, err := validateLabelPolicies(cd, policy.Labels)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, labelFindings...)
+	}
+	return findings, nil
+}
+
+func newFinding(severity Severity, rule, path, format string, args ...interface{}) Finding {
+	return Finding{
+		Severity: severity,
+		Rule:     rule,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+func validateSchema(cd *cdv2.ComponentDescriptor) Findings {
+	findings := Findings{}
+	if err := jsonscheme.ValidateComponentDescriptor(*cd); err != nil {
+		// the json schema validation joins all errors with a semicolon.
+		for _, msg := range strings.Split(err.Error(), ";") {
+			findings = append(findings, newFinding(SeverityError, RuleSchema, "component", "%s", msg))
+		}
+	}
+
+	err := cdvalidation.Validate(cd)
+	if err == nil {
+		return findings
+	}
+	var errs []error
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		errs = agg.Errors()
+	} else {
+		errs = []error{err}
+	}
+	for _, err := range errs {
+		var fieldErr *field.Error
+		if errors.As(err, &fieldErr) {
+			findings = append(findings, newFinding(SeverityError, RuleSchema, fieldErr.Field, "%s", fieldErr.ErrorBody()))
+			continue
+		}
+		findings = append(findings, newFinding(SeverityError, RuleSchema, "component", "%s", err.Error()))
+	}
+	return findings
+}
+
+// validateBlobs checks all local filesystem blobs of the resources and sources
+// and reports the blobs that are not referenced.
+func validateBlobs(archiveFs vfs.FileSystem, cd *cdv2.ComponentDescriptor) (Findings, error) {
+	findings := Findings{}
+	referenced := map[string]bool{}
+
+	check := func(path string, access *cdv2.UnstructuredTypedObject, expected *cdv2.DigestSpec) error {
+		if access == nil || access.GetType() != cdv2.LocalFilesystemBlobType {
+			return nil
+		}
+		localAccess := &cdv2.LocalFilesystemBlobAccess{}
+		if err := access.DecodeInto(localAccess); err != nil {
+			findings = append(findings, newFinding(SeverityError, RuleBlobExists, path, "unable to decode access: %s", err.Error()))
+			return nil
+		}
+		referenced[localAccess.Filename] = true
+		blobPath := ctf.BlobPath(localAccess.Filename)
+		file, err := archiveFs.Open(blobPath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				findings = append(findings, newFinding(SeverityError, RuleBlobExists, path, "blob %q does not exist in the component archive", localAccess.Filename))
+				return nil
+			}
+			return fmt.Errorf("unable to open blob %q: %w", blobPath, err)
+		}
+		defer file.Close()
+
+		// the blob is checked against the digest encoded in its filename (e.g. "sha256:<hex>" or "sha256-<hex>")
+		// and against the generic blob digest of the component descriptor.
+		var expectedDigests []digest.Digest
+		if dgst, ok := digestFromFilename(localAccess.Filename); ok {
+			expectedDigests = append(expectedDigests, dgst)
+		}
+		if expected != nil && expected.NormalisationAlgorithm == string(cdv2.GenericBlobDigestV1) {
+			dgst := digest.NewDigestFromEncoded(digest.Algorithm(strings.ToLower(expected.HashAlgorithm)), expected.Value)
+			if dgst.Validate() != nil {
+				findings = append(findings, newFinding(SeverityWarning, RuleBlobDigest, path, "unsupported digest %s:%s", expected.HashAlgorithm, expected.Value))
+			} else {
+				expectedDigests = append(expectedDigests, dgst)
+			}
+		}
+		if len(expectedDigests) == 0 {
+			return nil
+		}
+
+		digesters := map[digest.Algorithm]digest.Digester{}
+		var writers []io.Writer
+		for _, dgst := range expectedDigests {
+			if _, ok := digesters[dgst.Algorithm()]; ok {
+				continue
+			}
+			digester := dgst.Algorithm().Digester()
+			digesters[dgst.Algorithm()] = digester
+			writers = append(writers, digester.Hash())
+		}
+		if _, err := io.Copy(io.MultiWriter(writers...), file); err != nil {
+			return fmt.Errorf("unable to read blob %q: %w", blobPath, err)
+		}
+		for _, dgst := range expectedDigests {
+			if actual := digesters[dgst.Algorithm()].Digest(); actual != dgst {
+				findings = append(findings, newFinding(SeverityError, RuleBlobDigest, path, "blob %q has digest %s but %s is expected", localAccess.Filename, actual, dgst))
+			}
+		}
+		return nil
+	}
+
+	for i, res := range cd.Resources {
+		if err := check(fmt.Sprintf("component.resources[%d].access", i), res.Access, res.Digest); err != nil {
+			return nil, err
+		}
+	}
+	for i, src := range cd.Sources {
+		if err := check(fmt.Sprintf("component.sources[%d].access", i), src.Access, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	blobs, err := vfs.ReadDir(archiveFs, ctf.BlobsDirectoryName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return findings, nil
+		}
+		return nil, fmt.Errorf("unable to read blobs directory: %w", err)
+	}
+	for _, blob := range blobs {
+		if blob.IsDir() || referenced[blob.Name()] {
+			continue
+		}
+		findings = append(findings, newFinding(SeverityWarning, RuleOrphanedBlob, ctf.BlobPath(blob.Name()), "blob is not referenced by the component descriptor"))
+	}
+	return findings, nil
+}
+
+// digestFromFilename parses a blob filename of the form "<algorithm>:<hex>" or "<algorithm>-<hex>".
+func digestFromFilename(filename string) (digest.Digest, bool) {
+	for _, sep := range []string{":", "-"} {
+		i := strings.Index(filename, sep)
+		if i == -1 {
+			continue
+		}
+		dgst := digest.NewDigestFromEncoded(digest.Algorithm(filename[:i]), filename[i+1:])
+		if dgst.Validate() == nil {
+			return dgst, true
+		}
+	}
+	return "", false
+}
+
+// labelTarget is an element of the component descriptor that is checked by a label policy.
+type labelTarget struct {
+	path   string
+	labels cdv2.Labels
+}
+
+func validateLabelPolicies(cd *cdv2.ComponentDescriptor, policies []LabelPolicy) (Findings, error) {
+	findings := Findings{}
+	for i, policy := range policies {
+		nameRegex, err := regexp.Compile(policy.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name regex of label policy %d: %w", i, err)
+		}
+		var valueRegex *regexp.Regexp
+		if len(policy.Value) != 0 {
+			valueRegex, err = regexp.Compile(policy.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value regex of label policy %d: %w", i, err)
+			}
+		}
+
+		var targets []labelTarget
+		switch policy.Target {
+		case "", LabelPolicyTargetComponent:
+			targets = append(targets, labelTarget{path: "component", labels: cd.Labels})
+		case LabelPolicyTargetResources:
+			for j, res := range cd.Resources {
+				targets = append(targets, labelTarget{path: fmt.Sprintf("component.resources[%d]", j), labels: res.Labels})
+			}
+		case LabelPolicyTargetSources:
+			for j, src := range cd.Sources {
+				targets = append(targets, labelTarget{path: fmt.Sprintf("component.sources[%d]", j), labels: src.Labels})
+			}
+		case LabelPolicyTargetComponentReferences:
+			for j, ref := range cd.ComponentReferences {
+				targets = append(targets, labelTarget{path: fmt.Sprintf("component.componentReferences[%d]", j), labels: ref.Labels})
+			}
+		default:
+			return nil, fmt.Errorf("unknown target %q of label policy %d: must be one of %s, %s, %s or %s", policy.Target, i,
+				LabelPolicyTargetComponent, LabelPolicyTargetResources, LabelPolicyTargetSources, LabelPolicyTargetComponentReferences)
+		}
+
+		for _, target := range targets {
+			found := false
+			for j, label := range target.labels {
+				if !nameRegex.MatchString(label.Name) {
+					continue
+				}
+				found = true
+				if valueRegex == nil {
+					continue
+				}
+				if value := labelValueString(label.Value); !valueRegex.MatchString(value) {
+					findings = append(findings, newFinding(SeverityError, RuleLabelPolicy, fmt.Sprintf("%s.labels[%d]", target.path, j),
+						"value %s of label %q does not match %q", value, label.Name, policy.Value))
+				}
+			}
+			if policy.Required && !found {
+				findings = append(findings, newFinding(SeverityError, RuleLabelPolicy, target.path, "required label matching %q is missing", policy.Name))
+			}
+		}
+	}
+	return findings, nil
+}
+
+// labelValueString returns the unquoted value of string labels and the json representation of all other labels.
+func labelValueString(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+	return string(value)
+}
@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// ComponentVersion identifies a component by its name and version.
+type ComponentVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+func (cv ComponentVersion) String() string {
+	return cv.Name + ":" + cv.Version
+}
+
+// ParseComponentVersion parses a component version of the form "<name>:<version>".
+func ParseComponentVersion(s string) (ComponentVersion, error) {
+	i := strings.LastIndex(s, ":")
+	if i <= 0 || i == len(s)-1 {
+		return ComponentVersion{}, fmt.Errorf("invalid component %q: expected <name>:<version>", s)
+	}
+	return ComponentVersion{
+		Name:    s[:i],
+		Version: s[i+1:],
+	}, nil
+}
+
+// Consumer describes a component that references the searched component.
+type Consumer struct {
+	ComponentVersion `json:",inline"`
+	// ReferenceName is the name of the component reference in the consumer.
+	ReferenceName string `json:"referenceName"`
+	// References is the component that is referenced by the consumer.
+	// It is the searched component for direct consumers and another consumer for transitive consumers.
+	References ComponentVersion `json:"references"`
+	// Depth is 1 for direct consumers and is increased by one for every level of indirection.
+	Depth int `json:"depth"`
+}
+
+// ConsumerOptions configures the search for consumers of a component.
+type ConsumerOptions struct {
+	// Transitive also reports the components that reference a consumer.
+	Transitive bool
+	// SkipUnresolvable ignores candidates whose component descriptor cannot be resolved
+	// instead of failing the search, e.g. tags of a catalog walk that are no component descriptors.
+	SkipUnresolvable bool
+}
+
+// CatalogClient lists the repositories and tags of an oci registry.
+type CatalogClient interface {
+	// ListTags returns a list of all tags of the given ref.
+	ListTags(ctx context.Context, ref string) ([]string, error)
+	// ListRepositories lists all repositories for the given registry host.
+	ListRepositories(ctx context.Context, registryHost string) ([]string, error)
+}
+
+// ListComponentVersions walks the catalog of the registry of the repository context and returns all published component versions.
+// Only the url path component name mapping is supported as the component name cannot be derived from other mappings.
+func ListComponentVersions(ctx context.Context, client CatalogClient, repoCtx cdv2.OCIRegistryRepository) ([]ComponentVersion, error) {
+	if len(repoCtx.ComponentNameMapping) != 0 && repoCtx.ComponentNameMapping != cdv2.OCIRegistryURLPathMapping {
+		return nil, fmt.Errorf("the catalog of repositories with component name mapping %q cannot be listed", repoCtx.ComponentNameMapping)
+	}
+	prefix := strings.TrimSuffix(repoCtx.BaseURL, "/") + "/" + cdoci.ComponentDescriptorNamespace
+	prefixRef, err := oci.ParseRef(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse base url %q: %w", repoCtx.BaseURL, err)
+	}
+	repoPrefix := prefixRef.Repository + "/"
+	repos, err := client.ListRepositories(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list repositories of %s: %w", repoCtx.BaseURL, err)
+	}
+
+	cvs := make([]ComponentVersion, 0)
+	for _, repo := range repos {
+		refspec, err := oci.ParseRef(repo)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse repository %q: %w", repo, err)
+		}
+		if !strings.HasPrefix(refspec.Repository, repoPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(refspec.Repository, repoPrefix)
+		tags, err := client.ListTags(ctx, repo)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list tags of %s: %w", repo, err)
+		}
+		for _, tag := range tags {
+			// tags of the referrers tag schema (e.g. signatures) are no component versions.
+			if strings.HasPrefix(tag, "sha256-") {
+				continue
+			}
+			cvs = append(cvs, ComponentVersion{Name: name, Version: tag})
+		}
+	}
+	return cvs, nil
+}
+
+// FindConsumers resolves the candidate components and returns the ones that reference the component with the given name and version.
+// All versions of the component are matched if the version is empty.
+// The consumers are sorted by their depth, name and version.
+func FindConsumers(ctx context.Context, resolver ctf.ComponentResolver, repoCtx cdv2.OCIRegistryRepository, candidates []ComponentVersion, name, version string, opts ConsumerOptions) ([]Consumer, error) {
+	// referencedBy maps the referenced components to the candidates that reference them.
+	referencedBy := map[ComponentVersion][]Consumer{}
+	for _, candidate := range candidates {
+		cd, err := resolver.Resolve(ctx, &repoCtx, candidate.Name, candidate.Version)
+		if err != nil {
+			if opts.SkipUnresolvable {
+				continue
+			}
+			return nil, fmt.Errorf("unable to resolve component descriptor %s: %w", candidate, err)
+		}
+		for _, ref := range cd.ComponentReferences {
+			referenced := ComponentVersion{Name: ref.ComponentName, Version: ref.Version}
+			referencedBy[referenced] = append(referencedBy[referenced], Consumer{
+				ComponentVersion: candidate,
+				ReferenceName:    ref.Name,
+				References:       referenced,
+			})
+		}
+	}
+
+	var queue []ComponentVersion
+	for referenced := range referencedBy {
+		if referenced.Name == name && (len(version) == 0 || referenced.Version == version) {
+			queue = append(queue, referenced)
+		}
+	}
+
+	consumers := make([]Consumer, 0)
+	visited := map[ComponentVersion]bool{}
+	for depth := 1; len(queue) != 0; depth++ {
+		var next []ComponentVersion
+		for _, referenced := range queue {
+			for _, consumer := range referencedBy[referenced] {
+				consumer.Depth = depth
+				consumers = append(consumers, consumer)
+				if !visited[consumer.ComponentVersion] {
+					visited[consumer.ComponentVersion] = true
+					next = append(next, consumer.ComponentVersion)
+				}
+			}
+		}
+		if !opts.Transitive {
+			break
+		}
+		queue = next
+	}
+
+	sort.SliceStable(consumers, func(i, j int) bool {
+		a, b := consumers[i], consumers[j]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.References.String() < b.References.String()
+	})
+	return consumers, nil
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components_test
+
+import (
+	"context"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/components"
+)
+
+// staticCatalog lists the repositories and tags of a map of repository to tags.
+type staticCatalog map[string][]string
+
+func (c staticCatalog) ListTags(_ context.Context, ref string) ([]string, error) {
+	tags, ok := c[ref]
+	if !ok {
+		return nil, fmt.Errorf("repository %s not found", ref)
+	}
+	return tags, nil
+}
+
+func (c staticCatalog) ListRepositories(_ context.Context, _ string) ([]string, error) {
+	repos := make([]string, 0, len(c))
+	for repo := range c {
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+var _ = Describe("Consumers", func() {
+
+	newComponent := func(name, version string, refs ...components.ComponentVersion) *cdv2.ComponentDescriptor {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Name = name
+		cd.Version = version
+		for _, ref := range refs {
+			cd.ComponentReferences = append(cd.ComponentReferences, cdv2.ComponentReference{
+				Name:          "ref-" + ref.Version,
+				ComponentName: ref.Name,
+				Version:       ref.Version,
+			})
+		}
+		return cd
+	}
+
+	shared := components.ComponentVersion{Name: "example.com/shared", Version: "v1.0.0"}
+	sharedV2 := components.ComponentVersion{Name: "example.com/shared", Version: "v2.0.0"}
+	app := components.ComponentVersion{Name: "example.com/app", Version: "v0.1.0"}
+	other := components.ComponentVersion{Name: "example.com/other", Version: "v0.2.0"}
+	landscape := components.ComponentVersion{Name: "example.com/landscape", Version: "v1.0.0"}
+
+	resolver := staticResolver{
+		app.String():       newComponent(app.Name, app.Version, shared),
+		other.String():     newComponent(other.Name, other.Version, sharedV2),
+		landscape.String(): newComponent(landscape.Name, landscape.Version, app),
+		shared.String():    newComponent(shared.Name, shared.Version),
+	}
+	candidates := []components.ComponentVersion{app, other, landscape, shared}
+	repoCtx := *cdv2.NewOCIRegistryRepository("example.com/components", "")
+
+	It("should find the direct and transitive consumers of a component version", func() {
+		consumers, err := components.FindConsumers(context.TODO(), resolver, repoCtx, candidates, shared.Name, shared.Version, components.ConsumerOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(consumers).To(Equal([]components.Consumer{
+			{ComponentVersion: app, ReferenceName: "ref-v1.0.0", References: shared, Depth: 1},
+		}))
+
+		consumers, err = components.FindConsumers(context.TODO(), resolver, repoCtx, candidates, shared.Name, "", components.ConsumerOptions{Transitive: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(consumers).To(Equal([]components.Consumer{
+			{ComponentVersion: app, ReferenceName: "ref-v1.0.0", References: shared, Depth: 1},
+			{ComponentVersion: other, ReferenceName: "ref-v2.0.0", References: sharedV2, Depth: 1},
+			{ComponentVersion: landscape, ReferenceName: "ref-v0.1.0", References: app, Depth: 2},
+		}))
+	})
+
+	It("should fail for unresolvable candidates unless they are skipped", func() {
+		unknown := components.ComponentVersion{Name: "example.com/unknown", Version: "v0.0.1"}
+		_, err := components.FindConsumers(context.TODO(), resolver, repoCtx, append(candidates, unknown), shared.Name, shared.Version, components.ConsumerOptions{})
+		Expect(err).To(HaveOccurred())
+
+		consumers, err := components.FindConsumers(context.TODO(), resolver, repoCtx, append(candidates, unknown), shared.Name, shared.Version, components.ConsumerOptions{SkipUnresolvable: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(consumers).To(HaveLen(1))
+	})
+
+	It("should list the component versions of the repository context", func() {
+		catalog := staticCatalog{
+			"example.com/components/component-descriptors/example.com/app":    {"v0.1.0", "v0.2.0", "sha256-abc"},
+			"example.com/components/component-descriptors/example.com/shared": {"v1.0.0"},
+			"example.com/components/other/image":                              {"latest"},
+		}
+		cvs, err := components.ListComponentVersions(context.TODO(), catalog, repoCtx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cvs).To(ConsistOf(
+			components.ComponentVersion{Name: "example.com/app", Version: "v0.1.0"},
+			components.ComponentVersion{Name: "example.com/app", Version: "v0.2.0"},
+			shared,
+		))
+	})
+})
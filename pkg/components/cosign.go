@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/containerd/errdefs"
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/apis/v2/cdutils"
+
+	"github.com/gardener/component-cli/ociclient"
+)
+
+const (
+	// CosignSignatureType is the resource type of cosign signatures of oci images.
+	CosignSignatureType = "cosignSignature"
+	// CosignSignedResourceLabelName is the name of the label of a cosign signature resource
+	// that contains the identity of the signed resource.
+	CosignSignedResourceLabelName = "cli.gardener.cloud/cosign-signed-resource"
+)
+
+// CosignSignatureResource returns a resource for the cosign signature of the oci image of the given resource.
+// The signature is expected at the default cosign location "<repository>:<digest algorithm>-<digest value>.sig"
+// and is referenced by its digest.
+// Nil is returned if the image is not signed.
+func CosignSignatureResource(ctx context.Context, resolver ociclient.Resolver, res cdv2.Resource) (*cdv2.Resource, error) {
+	if res.Access == nil {
+		return nil, fmt.Errorf("resource %q has no access", res.Name)
+	}
+	if res.Access.GetType() != cdv2.OCIRegistryType {
+		return nil, fmt.Errorf("unsupported access type %q of resource %q", res.Access.GetType(), res.Name)
+	}
+	ociAccess := &cdv2.OCIRegistryAccess{}
+	if err := res.Access.DecodeInto(ociAccess); err != nil {
+		return nil, fmt.Errorf("unable to decode oci registry access of resource %q: %w", res.Name, err)
+	}
+
+	resolved, err := ociclient.ResolveReference(ctx, resolver, ociAccess.ImageReference)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve image %q of resource %q: %w", ociAccess.ImageReference, res.Name, err)
+	}
+	sigRef := resolved.TagRef(fmt.Sprintf("%s-%s.sig", resolved.Digest.Algorithm(), resolved.Digest.Hex()))
+	sigResolved, err := ociclient.ResolveReference(ctx, resolver, sigRef)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to resolve cosign signature %q of resource %q: %w", sigRef, res.Name, err)
+	}
+
+	acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(sigResolved.DigestRef()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode oci registry access: %w", err)
+	}
+	sigRes := &cdv2.Resource{
+		IdentityObjectMeta: cdv2.IdentityObjectMeta{
+			Name:          res.Name + "-signature",
+			Version:       res.Version,
+			Type:          CosignSignatureType,
+			ExtraIdentity: res.ExtraIdentity.DeepCopy(),
+		},
+		Relation: cdv2.ExternalRelation,
+		Access:   &acc,
+	}
+	sigRes.Labels, err = cdutils.SetLabel(sigRes.Labels, CosignSignedResourceLabelName, res.GetIdentity())
+	if err != nil {
+		return nil, fmt.Errorf("unable to set label: %w", err)
+	}
+	return sigRes, nil
+}
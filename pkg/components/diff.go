@@ -0,0 +1,246 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+)
+
+// ChangeType describes how an element changed between two component descriptors.
+type ChangeType string
+
+const (
+	ChangeTypeAdded    ChangeType = "added"
+	ChangeTypeRemoved  ChangeType = "removed"
+	ChangeTypeModified ChangeType = "modified"
+)
+
+// ElementKind is the kind of a changed element of a component descriptor.
+type ElementKind string
+
+const (
+	ElementKindResource           ElementKind = "resource"
+	ElementKindSource             ElementKind = "source"
+	ElementKindComponentReference ElementKind = "componentReference"
+	ElementKindLabel              ElementKind = "label"
+)
+
+// Diff describes the differences between two component descriptors.
+type Diff struct {
+	From    ComponentVersion `json:"from"`
+	To      ComponentVersion `json:"to"`
+	Changes []Change         `json:"changes"`
+}
+
+// Change describes a added, removed or modified element of a component descriptor.
+type Change struct {
+	Kind          ElementKind   `json:"kind"`
+	Name          string        `json:"name"`
+	ExtraIdentity cdv2.Identity `json:"extraIdentity,omitempty"`
+	Type          ChangeType    `json:"type"`
+	// Fields are the changed fields of a modified element.
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// FieldChange describes the old and the new value of a field.
+// Complex values like accesses and labels are json encoded.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	id := fmt.Sprintf("%s %q", c.Kind, c.Name)
+	if len(c.ExtraIdentity) != 0 {
+		id = fmt.Sprintf("%s %v", id, map[string]string(c.ExtraIdentity))
+	}
+	return fmt.Sprintf("%s %s", id, c.Type)
+}
+
+// DiffComponentDescriptors compares the resources, sources, component references and labels of two component descriptors.
+// Elements are matched by their identity. The changes are ordered by kind and by their position in the component descriptors.
+func DiffComponentDescriptors(from, to *cdv2.ComponentDescriptor) Diff {
+	diff := Diff{
+		From:    ComponentVersion{Name: from.Name, Version: from.Version},
+		To:      ComponentVersion{Name: to.Name, Version: to.Version},
+		Changes: make([]Change, 0),
+	}
+	diff.Changes = append(diff.Changes, diffEntries(ElementKindResource, resourceEntries(from), resourceEntries(to))...)
+	diff.Changes = append(diff.Changes, diffEntries(ElementKindSource, sourceEntries(from), sourceEntries(to))...)
+	diff.Changes = append(diff.Changes, diffEntries(ElementKindComponentReference, referenceEntries(from), referenceEntries(to))...)
+	diff.Changes = append(diff.Changes, diffEntries(ElementKindLabel, labelEntries(from.Labels), labelEntries(to.Labels))...)
+	return diff
+}
+
+// diffEntry is the comparable representation of an element of a component descriptor.
+type diffEntry struct {
+	key           string
+	name          string
+	extraIdentity cdv2.Identity
+	// fields are the ordered field names and values of the element.
+	fields [][2]string
+}
+
+func diffEntries(kind ElementKind, from, to []diffEntry) []Change {
+	changes := make([]Change, 0)
+	toEntries := map[string]diffEntry{}
+	for _, entry := range to {
+		toEntries[entry.key] = entry
+	}
+	fromKeys := map[string]bool{}
+	for _, entry := range from {
+		fromKeys[entry.key] = true
+		toEntry, ok := toEntries[entry.key]
+		if !ok {
+			changes = append(changes, newChange(kind, entry, ChangeTypeRemoved))
+			continue
+		}
+		if fields := diffFields(entry.fields, toEntry.fields); len(fields) != 0 {
+			change := newChange(kind, entry, ChangeTypeModified)
+			change.Fields = fields
+			changes = append(changes, change)
+		}
+	}
+	for _, entry := range to {
+		if !fromKeys[entry.key] {
+			changes = append(changes, newChange(kind, entry, ChangeTypeAdded))
+		}
+	}
+	return changes
+}
+
+func newChange(kind ElementKind, entry diffEntry, changeType ChangeType) Change {
+	return Change{
+		Kind:          kind,
+		Name:          entry.name,
+		ExtraIdentity: entry.extraIdentity,
+		Type:          changeType,
+	}
+}
+
+func diffFields(from, to [][2]string) []FieldChange {
+	toValues := map[string]string{}
+	for _, field := range to {
+		toValues[field[0]] = field[1]
+	}
+	fromFields := map[string]bool{}
+	changes := make([]FieldChange, 0)
+	for _, field := range from {
+		fromFields[field[0]] = true
+		if newValue := toValues[field[0]]; newValue != field[1] {
+			changes = append(changes, FieldChange{Field: field[0], Old: field[1], New: newValue})
+		}
+	}
+	for _, field := range to {
+		if !fromFields[field[0]] {
+			changes = append(changes, FieldChange{Field: field[0], New: field[1]})
+		}
+	}
+	return changes
+}
+
+func resourceEntries(cd *cdv2.ComponentDescriptor) []diffEntry {
+	entries := make([]diffEntry, 0, len(cd.Resources))
+	for _, res := range cd.Resources {
+		fields := [][2]string{
+			{"version", res.Version},
+			{"type", res.Type},
+			{"relation", string(res.Relation)},
+			{"access", encodeValue(res.Access)},
+		}
+		if res.Digest != nil {
+			fields = append(fields, [2]string{"digest", encodeValue(res.Digest)})
+		}
+		entries = append(entries, diffEntry{
+			key:           string(res.GetIdentityDigest()),
+			name:          res.Name,
+			extraIdentity: res.ExtraIdentity,
+			fields:        append(fields, labelFields(res.Labels)...),
+		})
+	}
+	return entries
+}
+
+func sourceEntries(cd *cdv2.ComponentDescriptor) []diffEntry {
+	entries := make([]diffEntry, 0, len(cd.Sources))
+	for _, src := range cd.Sources {
+		fields := [][2]string{
+			{"version", src.Version},
+			{"type", src.Type},
+			{"access", encodeValue(src.Access)},
+		}
+		entries = append(entries, diffEntry{
+			key:           string(src.GetIdentityDigest()),
+			name:          src.Name,
+			extraIdentity: src.ExtraIdentity,
+			fields:        append(fields, labelFields(src.Labels)...),
+		})
+	}
+	return entries
+}
+
+func referenceEntries(cd *cdv2.ComponentDescriptor) []diffEntry {
+	entries := make([]diffEntry, 0, len(cd.ComponentReferences))
+	for _, ref := range cd.ComponentReferences {
+		fields := [][2]string{
+			{"componentName", ref.ComponentName},
+			{"version", ref.Version},
+		}
+		if ref.Digest != nil {
+			fields = append(fields, [2]string{"digest", encodeValue(ref.Digest)})
+		}
+		entries = append(entries, diffEntry{
+			key:           string(ref.GetIdentityDigest()),
+			name:          ref.Name,
+			extraIdentity: ref.ExtraIdentity,
+			fields:        append(fields, labelFields(ref.Labels)...),
+		})
+	}
+	return entries
+}
+
+func labelEntries(labels cdv2.Labels) []diffEntry {
+	entries := make([]diffEntry, 0, len(labels))
+	for _, label := range labels {
+		entries = append(entries, diffEntry{
+			key:    label.Name,
+			name:   label.Name,
+			fields: [][2]string{{"value", encodeValue(label.Value)}},
+		})
+	}
+	return entries
+}
+
+// labelFields returns the labels of an element as fields that are named "labels.<name>".
+func labelFields(labels cdv2.Labels) [][2]string {
+	fields := make([][2]string, 0, len(labels))
+	for _, label := range labels {
+		fields = append(fields, [2]string{"labels." + label.Name, encodeValue(label.Value)})
+	}
+	return fields
+}
+
+// encodeValue returns the json encoding of the value with sorted keys so that values can be compared.
+func encodeValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	var obj interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return string(data)
+	}
+	if obj == nil {
+		return ""
+	}
+	if normalized, err := json.Marshal(obj); err == nil {
+		data = normalized
+	}
+	return string(data)
+}
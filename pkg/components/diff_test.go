@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components_test
+
+import (
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/components"
+)
+
+var _ = Describe("Diff", func() {
+
+	newResource := func(name, version string, extraIdentity cdv2.Identity) cdv2.Resource {
+		res := cdv2.Resource{}
+		res.Name = name
+		res.Version = version
+		res.Type = cdv2.OCIImageType
+		res.Relation = cdv2.ExternalRelation
+		res.ExtraIdentity = extraIdentity
+		access, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess("example.com/" + name + ":" + version))
+		Expect(err).ToNot(HaveOccurred())
+		res.Access = &access
+		return res
+	}
+
+	It("should report added, removed and modified elements", func() {
+		from := &cdv2.ComponentDescriptor{}
+		from.Name = "example.com/app"
+		from.Version = "v1.0.0"
+		from.Resources = []cdv2.Resource{
+			newResource("image", "v1.0.0", nil),
+			newResource("removed", "v1.0.0", nil),
+			newResource("unchanged", "v1.0.0", cdv2.Identity{"arch": "amd64"}),
+		}
+		from.ComponentReferences = []cdv2.ComponentReference{{Name: "dep", ComponentName: "example.com/dep", Version: "v0.1.0"}}
+		from.Labels = cdv2.Labels{{Name: "team", Value: []byte(`{"name": "a", "slack": "#a"}`)}}
+
+		to := from.DeepCopy()
+		to.Version = "v1.1.0"
+		to.Resources = []cdv2.Resource{
+			newResource("image", "v1.1.0", nil),
+			newResource("unchanged", "v1.0.0", cdv2.Identity{"arch": "amd64"}),
+			newResource("unchanged", "v1.0.0", cdv2.Identity{"arch": "arm64"}),
+		}
+		to.ComponentReferences[0].Version = "v0.2.0"
+		// the same label value with a different key order
+		to.Labels = cdv2.Labels{{Name: "team", Value: []byte(`{"slack":"#a","name":"a"}`)}}
+
+		diff := components.DiffComponentDescriptors(from, to)
+		Expect(diff.From).To(Equal(components.ComponentVersion{Name: "example.com/app", Version: "v1.0.0"}))
+		Expect(diff.To).To(Equal(components.ComponentVersion{Name: "example.com/app", Version: "v1.1.0"}))
+		Expect(diff.Changes).To(Equal([]components.Change{
+			{
+				Kind: components.ElementKindResource,
+				Name: "image",
+				Type: components.ChangeTypeModified,
+				Fields: []components.FieldChange{
+					{Field: "version", Old: "v1.0.0", New: "v1.1.0"},
+					{Field: "access", Old: `{"imageReference":"example.com/image:v1.0.0","type":"ociRegistry"}`, New: `{"imageReference":"example.com/image:v1.1.0","type":"ociRegistry"}`},
+				},
+			},
+			{Kind: components.ElementKindResource, Name: "removed", Type: components.ChangeTypeRemoved},
+			{Kind: components.ElementKindResource, Name: "unchanged", ExtraIdentity: cdv2.Identity{"arch": "arm64"}, Type: components.ChangeTypeAdded},
+			{
+				Kind:   components.ElementKindComponentReference,
+				Name:   "dep",
+				Type:   components.ChangeTypeModified,
+				Fields: []components.FieldChange{{Field: "version", Old: "v0.1.0", New: "v0.2.0"}},
+			},
+		}))
+	})
+
+	It("should report changed labels of elements", func() {
+		from := &cdv2.ComponentDescriptor{}
+		from.Sources = []cdv2.Source{{IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "repo", Type: "git"}}}
+		to := from.DeepCopy()
+		to.Sources[0].Labels = cdv2.Labels{{Name: "commit", Value: []byte(`"abc"`)}}
+		to.Labels = cdv2.Labels{{Name: "new", Value: []byte(`true`)}}
+
+		diff := components.DiffComponentDescriptors(from, to)
+		Expect(diff.Changes).To(Equal([]components.Change{
+			{
+				Kind:   components.ElementKindSource,
+				Name:   "repo",
+				Type:   components.ChangeTypeModified,
+				Fields: []components.FieldChange{{Field: "labels.commit", New: `"abc"`}},
+			},
+			{Kind: components.ElementKindLabel, Name: "new", Type: components.ChangeTypeAdded},
+		}))
+	})
+})
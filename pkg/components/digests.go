@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components
+
+import (
+	"context"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// ResolveDigest pins the image reference of a resource with an ociRegistry access to its digest.
+// The tag of the image reference is resolved in the oci registry and replaced by the digest ("repo@sha256:...")
+// so that the resource is immutable even if the tag is moved to another image afterwards.
+// The repository of the pinned reference is normalized, e.g. "ubuntu" becomes "index.docker.io/library/ubuntu".
+// Resources with other access types and image references that already contain a digest are not modified.
+// True is returned if the access of the resource has been modified.
+func ResolveDigest(ctx context.Context, resolver ociclient.Resolver, res *cdv2.Resource) (bool, error) {
+	if res.Access == nil || res.Access.GetType() != cdv2.OCIRegistryType {
+		return false, nil
+	}
+	ociAccess := &cdv2.OCIRegistryAccess{}
+	if err := res.Access.DecodeInto(ociAccess); err != nil {
+		return false, fmt.Errorf("unable to decode oci registry access of resource %q: %w", res.Name, err)
+	}
+	ref, err := oci.ParseRef(ociAccess.ImageReference)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse image reference %q of resource %q: %w", ociAccess.ImageReference, res.Name, err)
+	}
+	if ref.Digest != nil {
+		return false, nil
+	}
+
+	resolved, err := ociclient.ResolveReference(ctx, resolver, ociAccess.ImageReference)
+	if err != nil {
+		return false, fmt.Errorf("unable to resolve image reference %q of resource %q: %w", ociAccess.ImageReference, res.Name, err)
+	}
+	ociAccess.ImageReference = resolved.DigestRef()
+
+	acc, err := cdv2.NewUnstructured(ociAccess)
+	if err != nil {
+		return false, fmt.Errorf("unable to encode oci registry access of resource %q: %w", res.Name, err)
+	}
+	res.Access = &acc
+	return true, nil
+}
+
+// ResolveDigests pins the image references of all resources with an ociRegistry access to their digests.
+// See ResolveDigest for details.
+func ResolveDigests(ctx context.Context, resolver ociclient.Resolver, resources []cdv2.Resource) error {
+	for i := range resources {
+		if _, err := ResolveDigest(ctx, resolver, &resources[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
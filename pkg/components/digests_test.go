@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components_test
+
+import (
+	"context"
+	"errors"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/pkg/components"
+)
+
+// imageResolverFunc implements the ociclient.Resolver interface with a function.
+type imageResolverFunc func(ctx context.Context, ref string) (string, ocispecv1.Descriptor, error)
+
+func (f imageResolverFunc) Resolve(ctx context.Context, ref string) (string, ocispecv1.Descriptor, error) {
+	return f(ctx, ref)
+}
+
+var _ = Describe("Digests", func() {
+
+	dgst := digest.FromString("image")
+	resolver := imageResolverFunc(func(_ context.Context, ref string) (string, ocispecv1.Descriptor, error) {
+		return ref, ocispecv1.Descriptor{Digest: dgst}, nil
+	})
+
+	newResource := func(name string, access cdv2.TypedObjectAccessor) cdv2.Resource {
+		acc, err := cdv2.NewUnstructured(access)
+		Expect(err).ToNot(HaveOccurred())
+		return cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: name, Version: "v0.1.0", Type: cdv2.OCIImageType},
+			Relation:           cdv2.ExternalRelation,
+			Access:             &acc,
+		}
+	}
+
+	imageReference := func(res cdv2.Resource) string {
+		acc := &cdv2.OCIRegistryAccess{}
+		Expect(res.Access.DecodeInto(acc)).To(Succeed())
+		return acc.ImageReference
+	}
+
+	It("should pin the image references of all oci registry accesses to their digests", func() {
+		res := []cdv2.Resource{
+			newResource("tag", cdv2.NewOCIRegistryAccess("localhost:5000/example/image:v0.1.0")),
+			newResource("latest", cdv2.NewOCIRegistryAccess("localhost:5000/example/image")),
+			newResource("blob", cdv2.NewLocalFilesystemBlobAccess("sha256:abc", "application/octet-stream")),
+			newResource("dockerhub", cdv2.NewOCIRegistryAccess("ubuntu:18.04")),
+		}
+		Expect(components.ResolveDigests(context.TODO(), resolver, res)).To(Succeed())
+		Expect(imageReference(res[0])).To(Equal("localhost:5000/example/image@" + dgst.String()))
+		Expect(imageReference(res[1])).To(Equal("localhost:5000/example/image@" + dgst.String()))
+		Expect(res[2].Access.GetType()).To(Equal(cdv2.LocalFilesystemBlobType))
+		Expect(imageReference(res[3])).To(Equal("index.docker.io/library/ubuntu@" + dgst.String()))
+	})
+
+	It("should not resolve image references that already contain a digest", func() {
+		failingResolver := imageResolverFunc(func(_ context.Context, ref string) (string, ocispecv1.Descriptor, error) {
+			return "", ocispecv1.Descriptor{}, errors.New("should not be called")
+		})
+		ref := "example.com/image@" + dgst.String()
+		res := newResource("digest", cdv2.NewOCIRegistryAccess(ref))
+		pinned, err := components.ResolveDigest(context.TODO(), failingResolver, &res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pinned).To(BeFalse())
+		Expect(imageReference(res)).To(Equal(ref))
+	})
+
+})
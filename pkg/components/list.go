@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+)
+
+// ListVersions returns all published versions of the component with the given name in the repository context.
+// In contrast to ListComponentVersions, all component name mappings are supported.
+func ListVersions(ctx context.Context, client CatalogClient, repoCtx cdv2.OCIRegistryRepository, name string) ([]ComponentVersion, error) {
+	ref, err := cdoci.OCIRef(repoCtx, name, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get repository of component %s: %w", name, err)
+	}
+	repo := strings.TrimSuffix(ref, ":")
+	tags, err := client.ListTags(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tags of %s: %w", repo, err)
+	}
+	cvs := make([]ComponentVersion, 0, len(tags))
+	for _, tag := range tags {
+		// tags of the referrers tag schema (e.g. signatures) are no component versions.
+		if strings.HasPrefix(tag, "sha256-") {
+			continue
+		}
+		cvs = append(cvs, ComponentVersion{Name: name, Version: tag})
+	}
+	return cvs, nil
+}
+
+// FilterVersions returns the component versions that satisfy the semver constraint.
+// Versions that are no valid semver versions never satisfy a constraint.
+func FilterVersions(cvs []ComponentVersion, constraint *semver.Constraints) []ComponentVersion {
+	filtered := make([]ComponentVersion, 0, len(cvs))
+	for _, cv := range cvs {
+		v, err := semver.NewVersion(cv.Version)
+		if err != nil {
+			continue
+		}
+		if constraint.Check(v) {
+			filtered = append(filtered, cv)
+		}
+	}
+	return filtered
+}
+
+// SortVersions sorts the component versions by their name and semver version.
+// Versions that are no valid semver versions are sorted lexicographically after the valid ones.
+func SortVersions(cvs []ComponentVersion) {
+	sort.SliceStable(cvs, func(i, j int) bool {
+		if cvs[i].Name != cvs[j].Name {
+			return cvs[i].Name < cvs[j].Name
+		}
+		vi, errI := semver.NewVersion(cvs[i].Version)
+		vj, errJ := semver.NewVersion(cvs[j].Version)
+		switch {
+		case errI == nil && errJ == nil:
+			return vi.LessThan(vj)
+		case errI == nil:
+			return true
+		case errJ == nil:
+			return false
+		default:
+			return cvs[i].Version < cvs[j].Version
+		}
+	})
+}
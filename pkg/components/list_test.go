@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components_test
+
+import (
+	"context"
+
+	"github.com/Masterminds/semver/v3"
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/components"
+)
+
+var _ = Describe("List", func() {
+
+	repoCtx := *cdv2.NewOCIRegistryRepository("example.com/components", "")
+
+	It("should list the versions of a component", func() {
+		catalog := staticCatalog{
+			"example.com/components/component-descriptors/example.com/app": {"v0.2.0", "sha256-abc.sig", "v0.10.0", "v0.1.0"},
+		}
+		cvs, err := components.ListVersions(context.TODO(), catalog, repoCtx, "example.com/app")
+		Expect(err).ToNot(HaveOccurred())
+		components.SortVersions(cvs)
+		Expect(cvs).To(Equal([]components.ComponentVersion{
+			{Name: "example.com/app", Version: "v0.1.0"},
+			{Name: "example.com/app", Version: "v0.2.0"},
+			{Name: "example.com/app", Version: "v0.10.0"},
+		}))
+	})
+
+	It("should filter the versions with a semver constraint", func() {
+		cvs := []components.ComponentVersion{
+			{Name: "example.com/app", Version: "v1.1.0"},
+			{Name: "example.com/app", Version: "v1.2.0"},
+			{Name: "example.com/app", Version: "v1.5.3"},
+			{Name: "example.com/app", Version: "v2.0.0"},
+			{Name: "example.com/app", Version: "latest"},
+		}
+		constraint, err := semver.NewConstraint(">=1.2.0 <2.0.0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(components.FilterVersions(cvs, constraint)).To(Equal([]components.ComponentVersion{
+			{Name: "example.com/app", Version: "v1.2.0"},
+			{Name: "example.com/app", Version: "v1.5.3"},
+		}))
+	})
+})
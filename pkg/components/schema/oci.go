@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ReplaceComponentDescriptorLayer replaces the component descriptor layer and the config of a
+// component descriptor manifest that has been created by the cdoci.ManifestBuilder
+// with the component descriptor encoded in the given schema version.
+// The new layer and config are added to the blob store.
+// Manifests of v2 component descriptors are not modified.
+func ReplaceComponentDescriptorLayer(store cdoci.BlobStore, manifest *ocispecv1.Manifest, cd *cdv2.ComponentDescriptor, schemaVersion string) error {
+	if len(schemaVersion) == 0 || schemaVersion == SchemaVersionV2 {
+		return nil
+	}
+	if len(manifest.Layers) == 0 {
+		return fmt.Errorf("manifest does not contain a component descriptor layer")
+	}
+	data, err := EncodeYAML(cd, schemaVersion)
+	if err != nil {
+		return fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     ctf.ComponentDescriptorFileName,
+		Size:     int64(len(data)),
+		Mode:     0644,
+	}); err != nil {
+		return fmt.Errorf("unable to add component descriptor header: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("unable to write component descriptor to tar: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("unable to close tar writer: %w", err)
+	}
+
+	layerDesc := ocispecv1.Descriptor{
+		MediaType: cdoci.ComponentDescriptorTarMimeTypeOCM,
+		Digest:    digest.FromBytes(buf.Bytes()),
+		Size:      int64(buf.Len()),
+	}
+	if err := store.Add(layerDesc, io.NopCloser(&buf)); err != nil {
+		return fmt.Errorf("unable to add component descriptor layer to store: %w", err)
+	}
+
+	layerRef := cdoci.ConvertDescriptorToOCIBlobRef(layerDesc)
+	configData, err := json.Marshal(cdoci.ComponentDescriptorConfig{ComponentDescriptorLayer: &layerRef})
+	if err != nil {
+		return fmt.Errorf("unable to marshal component config: %w", err)
+	}
+	configDesc := ocispecv1.Descriptor{
+		MediaType: cdoci.ComponentDescriptorConfigMimeTypeOCM,
+		Digest:    digest.FromBytes(configData),
+		Size:      int64(len(configData)),
+	}
+	if err := store.Add(configDesc, io.NopCloser(bytes.NewBuffer(configData))); err != nil {
+		return fmt.Errorf("unable to add component config to store: %w", err)
+	}
+
+	manifest.Layers[0] = layerDesc
+	manifest.Config = configDesc
+	return nil
+}
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/go-logr/logr"
+)
+
+// ComponentResolver is a component resolver that also returns the schema version of the resolved component descriptor.
+type ComponentResolver interface {
+	ctf.ComponentResolver
+	// ResolveWithSchemaVersion resolves a component descriptor and returns a blob resolver to access the local artifacts
+	// and the schema version of the remote component descriptor.
+	ResolveWithSchemaVersion(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, string, error)
+}
+
+// Resolver resolves component descriptors of all supported schema versions from an oci registry.
+// The component descriptors are always returned as v2 component descriptors.
+// This resolver implements the ctf.ComponentResolver interface.
+type Resolver struct {
+	log        logr.Logger
+	client     cdoci.Client
+	cache      cdoci.Cache
+	decodeOpts []codec.DecodeOption
+}
+
+var _ ComponentResolver = &Resolver{}
+
+// NewResolver creates a new schema aware component descriptor resolver.
+func NewResolver(client cdoci.Client, decodeOpts ...codec.DecodeOption) *Resolver {
+	return &Resolver{
+		log:        logr.Discard(),
+		client:     client,
+		decodeOpts: decodeOpts,
+	}
+}
+
+// WithCache sets the cache of the resolver.
+// As the cache only contains v2 component descriptors, it is only used by Resolve.
+func (r *Resolver) WithCache(cache cdoci.Cache) *Resolver {
+	r.cache = cache
+	return r
+}
+
+// WithLog sets the logger for the resolver.
+func (r *Resolver) WithLog(log logr.Logger) *Resolver {
+	r.log = log.WithName("componentResolver")
+	return r
+}
+
+// Resolve resolves a component descriptor by name and version within the configured context.
+func (r *Resolver) Resolve(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	if r.cache != nil {
+		repo, err := ociRegistryRepository(repoCtx)
+		if err != nil {
+			return nil, err
+		}
+		cd, err := r.cache.Get(ctx, repo, name, version)
+		if err == nil {
+			return cd, nil
+		}
+		if errors.Is(err, ctf.NotFoundError) {
+			r.log.V(5).Info(err.Error())
+		} else {
+			r.log.Error(err, "unable to get component descriptor from cache")
+		}
+	}
+	cd, _, _, err := r.resolve(ctx, repoCtx, name, version)
+	return cd, err
+}
+
+// ResolveWithBlobResolver resolves a component descriptor by name and version within the configured context.
+// And it also returns a blob resolver to access the local artifacts.
+func (r *Resolver) ResolveWithBlobResolver(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	cd, blobResolver, _, err := r.resolve(ctx, repoCtx, name, version)
+	return cd, blobResolver, err
+}
+
+// ResolveWithSchemaVersion resolves a component descriptor by name and version within the configured context.
+// And it also returns a blob resolver to access the local artifacts and the schema version of the remote component descriptor.
+func (r *Resolver) ResolveWithSchemaVersion(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, string, error) {
+	return r.resolve(ctx, repoCtx, name, version)
+}
+
+// ociRegistryRepository decodes a repository context into an oci registry repository.
+func ociRegistryRepository(repoCtx cdv2.Repository) (cdv2.OCIRegistryRepository, error) {
+	var repo cdv2.OCIRegistryRepository
+	switch r := repoCtx.(type) {
+	case *cdv2.UnstructuredTypedObject:
+		if err := r.DecodeInto(&repo); err != nil {
+			return repo, err
+		}
+	case *cdv2.OCIRegistryRepository:
+		repo = *r
+	default:
+		return repo, fmt.Errorf("unknown repository context type %s", repoCtx.GetType())
+	}
+	if repo.Type != cdv2.OCIRegistryType {
+		return repo, fmt.Errorf("unsupported type %s expected %s", repo.Type, cdv2.OCIRegistryType)
+	}
+	return repo, nil
+}
+
+func (r *Resolver) resolve(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, string, error) {
+	repo, err := ociRegistryRepository(repoCtx)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	ref, err := cdoci.OCIRef(repo, name, version)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("unable to generate oci reference: %w", err)
+	}
+	manifest, err := r.client.GetManifest(ctx, ref)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("unable to fetch manifest from ref %s: %w", ref, err)
+	}
+
+	switch manifest.Config.MediaType {
+	case cdoci.ComponentDescriptorConfigMimeType, cdoci.ComponentDescriptorLegacyConfigMimeType, cdoci.ComponentDescriptorConfigMimeTypeOCM:
+	default:
+		return nil, nil, "", fmt.Errorf("unknown component config type %q", manifest.Config.MediaType)
+	}
+	var configData bytes.Buffer
+	if err := r.client.Fetch(ctx, ref, manifest.Config, &configData); err != nil {
+		return nil, nil, "", fmt.Errorf("unable to resolve component config: %w", err)
+	}
+	componentConfig := &cdoci.ComponentDescriptorConfig{}
+	if err := json.Unmarshal(configData.Bytes(), componentConfig); err != nil {
+		return nil, nil, "", fmt.Errorf("unable to decode manifest config into component config: %w", err)
+	}
+	if componentConfig.ComponentDescriptorLayer == nil {
+		return nil, nil, "", fmt.Errorf("no component descriptor layer defined")
+	}
+
+	layer := cdoci.GetLayerWithDigest(manifest.Layers, componentConfig.ComponentDescriptorLayer.Digest)
+	if layer == nil {
+		return nil, nil, "", fmt.Errorf("no component descriptor layer defined")
+	}
+	var layerData bytes.Buffer
+	if err := r.client.Fetch(ctx, ref, *layer, &layerData); err != nil {
+		return nil, nil, "", fmt.Errorf("unable to fetch component descriptor layer: %w", err)
+	}
+	data := layerData.Bytes()
+	switch layer.MediaType {
+	case cdoci.ComponentDescriptorTarMimeTypeOCM, cdoci.ComponentDescriptorTarMimeType, cdoci.LegacyComponentDescriptorTarMimeType:
+		data, err = cdoci.ReadComponentDescriptorFromTar(&layerData)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("unable to read component descriptor from tar: %w", err)
+		}
+	case cdoci.ComponentDescriptorJSONMimeType:
+	default:
+		return nil, nil, "", fmt.Errorf("unsupported media type %q", layer.MediaType)
+	}
+
+	cd, schemaVersion, err := Decode(data, r.decodeOpts...)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("unable to decode component descriptor: %w", err)
+	}
+	if err := cdv2.InjectRepositoryContext(cd, &repo); err != nil {
+		return nil, nil, "", err
+	}
+	if r.cache != nil {
+		if err := r.cache.Store(ctx, cd.DeepCopy()); err != nil {
+			r.log.Error(err, "unable to store component descriptor in cache")
+		}
+	}
+	return cd, cdoci.NewBlobResolver(r.client, ref, manifest, cd), schemaVersion, nil
+}
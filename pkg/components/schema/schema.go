@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package schema implements the decoding, encoding and conversion of component descriptors
+// in the v2 and the ocm.software/v3alpha1 schema.
+// Component descriptors are always handled as v2 component descriptors internally
+// and are only converted when they are read or written.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdvalidation "github.com/gardener/component-spec/bindings-go/apis/v2/validation"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SchemaVersionV2 is the v2 schema version of component descriptors.
+	SchemaVersionV2 = cdv2.SchemaVersion
+	// SchemaVersionV3alpha1 is the ocm.software/v3alpha1 schema version of component descriptors.
+	SchemaVersionV3alpha1 = "ocm.software/v3alpha1"
+)
+
+// SupportedSchemaVersions contains all schema versions that can be read and written.
+var SupportedSchemaVersions = []string{SchemaVersionV2, SchemaVersionV3alpha1}
+
+// ValidateSchemaVersion validates that the schema version is supported.
+func ValidateSchemaVersion(schemaVersion string) error {
+	for _, v := range SupportedSchemaVersions {
+		if v == schemaVersion {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported schema version %q: must be one of %v", schemaVersion, SupportedSchemaVersions)
+}
+
+// DetectSchemaVersion returns the schema version of a yaml or json encoded component descriptor.
+// v2 component descriptors define their version in "meta.schemaVersion",
+// newer component descriptors in "apiVersion".
+func DetectSchemaVersion(data []byte) (string, error) {
+	header := struct {
+		APIVersion string `json:"apiVersion"`
+		Meta       struct {
+			SchemaVersion string `json:"schemaVersion"`
+		} `json:"meta"`
+	}{}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return "", fmt.Errorf("unable to decode component descriptor: %w", err)
+	}
+	if len(header.APIVersion) != 0 {
+		return header.APIVersion, nil
+	}
+	if len(header.Meta.SchemaVersion) != 0 {
+		return header.Meta.SchemaVersion, nil
+	}
+	return "", fmt.Errorf("unable to detect the schema version of the component descriptor")
+}
+
+// Decode decodes a component descriptor of any supported schema version into a v2 component descriptor.
+// The schema version of the encoded component descriptor is returned as well.
+func Decode(data []byte, opts ...codec.DecodeOption) (*cdv2.ComponentDescriptor, string, error) {
+	schemaVersion, err := DetectSchemaVersion(data)
+	if err != nil {
+		return nil, "", err
+	}
+	switch schemaVersion {
+	case SchemaVersionV2:
+		cd := &cdv2.ComponentDescriptor{}
+		if err := codec.Decode(data, cd, opts...); err != nil {
+			return nil, "", err
+		}
+		return cd, schemaVersion, nil
+	case SchemaVersionV3alpha1:
+		options := &codec.DecodeOptions{}
+		options.ApplyOptions(opts)
+
+		v3 := &ComponentDescriptorV3alpha1{}
+		unmarshal := yaml.Unmarshal
+		if options.StrictMode {
+			unmarshal = yaml.UnmarshalStrict
+		}
+		if err := unmarshal(data, v3); err != nil {
+			return nil, "", fmt.Errorf("unable to decode %s component descriptor: %w", schemaVersion, err)
+		}
+		if v3.Kind != ComponentVersionKind {
+			return nil, "", fmt.Errorf("unexpected kind %q of %s component descriptor: must be %s", v3.Kind, schemaVersion, ComponentVersionKind)
+		}
+		cd := ConvertFromV3alpha1(v3)
+		if err := cdv2.DefaultComponent(cd); err != nil {
+			return nil, "", err
+		}
+		if !options.DisableValidation {
+			if err := cdvalidation.Validate(cd); err != nil {
+				return nil, "", err
+			}
+		}
+		return cd, schemaVersion, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported schema version %q", schemaVersion)
+	}
+}
+
+// Encode encodes a v2 component descriptor as json in the given schema version.
+func Encode(cd *cdv2.ComponentDescriptor, schemaVersion string) ([]byte, error) {
+	switch schemaVersion {
+	case "", SchemaVersionV2:
+		return codec.Encode(cd)
+	case SchemaVersionV3alpha1:
+		if err := cdv2.DefaultComponent(cd); err != nil {
+			return nil, err
+		}
+		return json.Marshal(ConvertToV3alpha1(cd))
+	default:
+		return nil, fmt.Errorf("unsupported schema version %q", schemaVersion)
+	}
+}
+
+// EncodeYAML encodes a v2 component descriptor as yaml in the given schema version.
+func EncodeYAML(cd *cdv2.ComponentDescriptor, schemaVersion string) ([]byte, error) {
+	data, err := Encode(cd, schemaVersion)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.JSONToYAML(data)
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/codec"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/pkg/components/schema"
+)
+
+// blobStore is a in-memory blob store that is used to build oci manifests.
+type blobStore map[string][]byte
+
+func (s blobStore) Add(desc ocispecv1.Descriptor, reader io.ReadCloser) error {
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	s[desc.Digest.String()] = data
+	return nil
+}
+
+// manifestClient serves a single manifest and the blobs of a blob store.
+type manifestClient struct {
+	manifest *ocispecv1.Manifest
+	store    blobStore
+	fetched  int
+}
+
+func (c *manifestClient) GetManifest(_ context.Context, _ string) (*ocispecv1.Manifest, error) {
+	return c.manifest, nil
+}
+
+func (c *manifestClient) Fetch(_ context.Context, _ string, desc ocispecv1.Descriptor, writer io.Writer) error {
+	c.fetched++
+	_, err := writer.Write(c.store[desc.Digest.String()])
+	return err
+}
+
+// componentCache is a in-memory component descriptor cache.
+type componentCache map[string]*cdv2.ComponentDescriptor
+
+func (c componentCache) Get(_ context.Context, _ cdv2.OCIRegistryRepository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	cd, ok := c[name+":"+version]
+	if !ok {
+		return nil, ctf.NotFoundError
+	}
+	return cd, nil
+}
+
+func (c componentCache) Store(_ context.Context, cd *cdv2.ComponentDescriptor) error {
+	c[cd.Name+":"+cd.Version] = cd
+	return nil
+}
+
+var _ = Describe("Schema", func() {
+
+	readV3 := func() []byte {
+		data, err := os.ReadFile("./testdata/v3alpha1.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		return data
+	}
+
+	It("should decode a v3alpha1 component descriptor", func() {
+		cd, schemaVersion, err := schema.Decode(readV3())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(schemaVersion).To(Equal(schema.SchemaVersionV3alpha1))
+		Expect(cd.Metadata.Version).To(Equal(schema.SchemaVersionV2))
+		Expect(cd.Name).To(Equal("example.com/component"))
+		Expect(cd.Version).To(Equal("v0.1.0"))
+		Expect(cd.Provider).To(Equal(cdv2.ProviderType("internal")))
+		Expect(cd.Labels).To(HaveLen(1))
+		Expect(cd.Sources).To(HaveLen(1))
+		Expect(cd.Resources).To(HaveLen(1))
+		Expect(cd.ComponentReferences).To(HaveLen(1))
+		Expect(cd.ComponentReferences[0].ComponentName).To(Equal("example.com/dependency"))
+		Expect(cd.RepositoryContexts).To(HaveLen(1))
+	})
+
+	It("should convert a component descriptor from v3alpha1 to v2 and back", func() {
+		cd, _, err := schema.Decode(readV3())
+		Expect(err).ToNot(HaveOccurred())
+
+		v2Data, err := schema.Encode(cd, schema.SchemaVersionV2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(schema.DetectSchemaVersion(v2Data)).To(Equal(schema.SchemaVersionV2))
+		v2Cd := &cdv2.ComponentDescriptor{}
+		Expect(codec.Decode(v2Data, v2Cd)).To(Succeed())
+
+		v3Data, err := schema.EncodeYAML(v2Cd, schema.SchemaVersionV3alpha1)
+		Expect(err).ToNot(HaveOccurred())
+		v3Cd, schemaVersion, err := schema.Decode(v3Data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(schemaVersion).To(Equal(schema.SchemaVersionV3alpha1))
+		Expect(v3Cd).To(Equal(cd))
+	})
+
+	It("should reject unknown schema versions and kinds", func() {
+		Expect(schema.ValidateSchemaVersion("v4")).ToNot(Succeed())
+		_, _, err := schema.Decode([]byte("apiVersion: ocm.software/v4\nkind: ComponentVersion"))
+		Expect(err).To(HaveOccurred())
+		_, _, err = schema.Decode(bytes.Replace(readV3(), []byte("kind: ComponentVersion"), []byte("kind: Component"), 1))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should replace the component descriptor layer of a manifest", func() {
+		cd, _, err := schema.Decode(readV3())
+		Expect(err).ToNot(HaveOccurred())
+		store := blobStore{}
+		manifest, err := cdoci.NewManifestBuilder(store, ctf.NewComponentArchive(cd, nil)).Build(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		v2Layer := manifest.Layers[0]
+
+		Expect(schema.ReplaceComponentDescriptorLayer(store, manifest, cd, schema.SchemaVersionV2)).To(Succeed())
+		Expect(manifest.Layers[0]).To(Equal(v2Layer))
+
+		Expect(schema.ReplaceComponentDescriptorLayer(store, manifest, cd, schema.SchemaVersionV3alpha1)).To(Succeed())
+		Expect(manifest.Layers).To(HaveLen(1))
+		Expect(manifest.Layers[0].MediaType).To(Equal(cdoci.ComponentDescriptorTarMimeTypeOCM))
+		Expect(manifest.Config.MediaType).To(Equal(cdoci.ComponentDescriptorConfigMimeTypeOCM))
+
+		config := &cdoci.ComponentDescriptorConfig{}
+		Expect(json.Unmarshal(store[manifest.Config.Digest.String()], config)).To(Succeed())
+		Expect(config.ComponentDescriptorLayer.Digest).To(Equal(manifest.Layers[0].Digest.String()))
+
+		data, err := cdoci.ReadComponentDescriptorFromTar(bytes.NewReader(store[manifest.Layers[0].Digest.String()]))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(schema.DetectSchemaVersion(data)).To(Equal(schema.SchemaVersionV3alpha1))
+	})
+
+	It("should resolve a component descriptor with its schema version and cache it", func() {
+		cd, _, err := schema.Decode(readV3())
+		Expect(err).ToNot(HaveOccurred())
+		store := blobStore{}
+		manifest, err := cdoci.NewManifestBuilder(store, ctf.NewComponentArchive(cd, nil)).Build(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(schema.ReplaceComponentDescriptorLayer(store, manifest, cd, schema.SchemaVersionV3alpha1)).To(Succeed())
+
+		client := &manifestClient{manifest: manifest, store: store}
+		cache := componentCache{}
+		resolver := schema.NewResolver(client).WithCache(cache)
+		repoCtx := cdv2.NewOCIRegistryRepository("example.com/components", "")
+
+		resolved, _, schemaVersion, err := resolver.ResolveWithSchemaVersion(context.TODO(), repoCtx, cd.Name, cd.Version)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(schemaVersion).To(Equal(schema.SchemaVersionV3alpha1))
+		Expect(resolved.Name).To(Equal(cd.Name))
+		Expect(cache).To(HaveKey(cd.Name + ":" + cd.Version))
+
+		fetched := client.fetched
+		resolved, err = resolver.Resolve(context.TODO(), repoCtx, cd.Name, cd.Version)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resolved.Name).To(Equal(cd.Name))
+		Expect(client.fetched).To(Equal(fetched))
+	})
+
+})
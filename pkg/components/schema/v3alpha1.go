@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+)
+
+// ComponentVersionKind is the kind of a v3alpha1 component descriptor.
+const ComponentVersionKind = "ComponentVersion"
+
+// ComponentDescriptorV3alpha1 is a component descriptor in the ocm.software/v3alpha1 schema.
+// In contrast to v2 the identity of the component is described in the metadata,
+// the provider is an object and the component references are named references.
+type ComponentDescriptorV3alpha1 struct {
+	// APIVersion is the schema version of the component descriptor.
+	APIVersion string `json:"apiVersion"`
+	// Kind is the kind of the component descriptor, always "ComponentVersion".
+	Kind string `json:"kind"`
+	// Metadata contains the identity of the component.
+	Metadata ComponentMetadataV3alpha1 `json:"metadata"`
+	// RepositoryContexts defines the previous repositories of the component.
+	RepositoryContexts []*cdv2.UnstructuredTypedObject `json:"repositoryContexts,omitempty"`
+	// Spec contains the elements of the component.
+	Spec ComponentSpecV3alpha1 `json:"spec"`
+	// Signatures contains the signatures of the component descriptor.
+	Signatures []cdv2.Signature `json:"signatures,omitempty"`
+}
+
+// ComponentMetadataV3alpha1 describes the identity and the provider of a v3alpha1 component.
+type ComponentMetadataV3alpha1 struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Labels  cdv2.Labels `json:"labels,omitempty"`
+	// Provider describes the provider of the component.
+	Provider ProviderV3alpha1 `json:"provider"`
+	// CreationTime defines the datetime the component was created.
+	CreationTime string `json:"creationTime,omitempty"`
+}
+
+// ProviderV3alpha1 describes the provider of a v3alpha1 component.
+type ProviderV3alpha1 struct {
+	Name   string      `json:"name"`
+	Labels cdv2.Labels `json:"labels,omitempty"`
+}
+
+// ComponentSpecV3alpha1 contains the sources, resources and references of a v3alpha1 component.
+type ComponentSpecV3alpha1 struct {
+	Sources    []cdv2.Source             `json:"sources,omitempty"`
+	Resources  []cdv2.Resource           `json:"resources,omitempty"`
+	References []cdv2.ComponentReference `json:"references,omitempty"`
+}
+
+// ConvertToV3alpha1 converts a v2 component descriptor to the v3alpha1 schema.
+func ConvertToV3alpha1(cd *cdv2.ComponentDescriptor) *ComponentDescriptorV3alpha1 {
+	cd = cd.DeepCopy()
+	return &ComponentDescriptorV3alpha1{
+		APIVersion: SchemaVersionV3alpha1,
+		Kind:       ComponentVersionKind,
+		Metadata: ComponentMetadataV3alpha1{
+			Name:    cd.Name,
+			Version: cd.Version,
+			Labels:  cd.Labels,
+			Provider: ProviderV3alpha1{
+				Name: string(cd.Provider),
+			},
+			CreationTime: cd.CreationTime,
+		},
+		RepositoryContexts: cd.RepositoryContexts,
+		Spec: ComponentSpecV3alpha1{
+			Sources:    cd.Sources,
+			Resources:  cd.Resources,
+			References: cd.ComponentReferences,
+		},
+		Signatures: cd.Signatures,
+	}
+}
+
+// ConvertFromV3alpha1 converts a v3alpha1 component descriptor to the v2 schema.
+// The labels of the provider are dropped as v2 only knows the provider name.
+func ConvertFromV3alpha1(cd *ComponentDescriptorV3alpha1) *cdv2.ComponentDescriptor {
+	v2 := &cdv2.ComponentDescriptor{
+		Metadata: cdv2.Metadata{Version: cdv2.SchemaVersion},
+		ComponentSpec: cdv2.ComponentSpec{
+			ObjectMeta: cdv2.ObjectMeta{
+				Name:    cd.Metadata.Name,
+				Version: cd.Metadata.Version,
+				Labels:  cd.Metadata.Labels,
+			},
+			RepositoryContexts:  cd.RepositoryContexts,
+			Provider:            cdv2.ProviderType(cd.Metadata.Provider.Name),
+			Sources:             cd.Spec.Sources,
+			ComponentReferences: cd.Spec.References,
+			Resources:           cd.Spec.Resources,
+			CreationTime:        cd.Metadata.CreationTime,
+		},
+		Signatures: cd.Signatures,
+	}
+	return v2.DeepCopy()
+}
@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+// ClosureStats describes the storage that is used by the oci artifacts of a component and all its transitively referenced components.
+type ClosureStats struct {
+	// Components is the number of components in the closure.
+	Components int `json:"components"`
+	// StorageBytes is the size of all unique manifests and blobs in all registries.
+	StorageBytes int64 `json:"storageBytes"`
+	// TransferBytes is the size of all manifests and blobs including blobs that are shared by multiple artifacts.
+	TransferBytes int64 `json:"transferBytes"`
+	// Registries contains the stats per registry.
+	Registries []RegistryStats `json:"registries"`
+}
+
+// RegistryStats describes the storage that is used by the oci artifacts of a component closure in one registry.
+type RegistryStats struct {
+	// Registry is the host of the registry.
+	Registry string `json:"registry"`
+	// Manifests is the number of unique manifests and image indexes.
+	Manifests int `json:"manifests"`
+	// ManifestBytes is the size of all unique manifests and image indexes.
+	ManifestBytes int64 `json:"manifestBytes"`
+	// Blobs is the number of unique blobs.
+	Blobs int `json:"blobs"`
+	// BlobBytes is the size of all unique blobs.
+	BlobBytes int64 `json:"blobBytes"`
+	// SharedBlobs is the number of blobs that are referenced by more than one manifest.
+	SharedBlobs int `json:"sharedBlobs"`
+	// SharedBlobBytes is the size that is saved by sharing blobs between manifests.
+	SharedBlobBytes int64 `json:"sharedBlobBytes"`
+}
+
+// closureStatsCollector walks a component closure and collects the sizes of all oci artifacts per registry.
+type closureStatsCollector struct {
+	client   ociclient.Client
+	resolver ctf.ComponentResolver
+	repoCtx  cdv2.OCIRegistryRepository
+
+	components map[string]bool
+	registries map[string]*registryStatsCollector
+}
+
+type registryStatsCollector struct {
+	manifests map[digest.Digest]int64
+	blobs     map[digest.Digest]int64
+	blobRefs  map[digest.Digest]int
+}
+
+// ComputeClosureStats computes the storage stats of the oci artifacts of a component and all its transitively referenced components.
+// The component descriptors, the local blobs and all oci images that are referenced by resources are taken into account.
+// Blobs are deduplicated per registry by their digest.
+func ComputeClosureStats(ctx context.Context, client ociclient.Client, resolver ctf.ComponentResolver, repoCtx cdv2.OCIRegistryRepository, name, version string) (*ClosureStats, error) {
+	c := &closureStatsCollector{
+		client:     client,
+		resolver:   resolver,
+		repoCtx:    repoCtx,
+		components: map[string]bool{},
+		registries: map[string]*registryStatsCollector{},
+	}
+	if err := c.collectComponent(ctx, name, version); err != nil {
+		return nil, err
+	}
+	return c.stats(), nil
+}
+
+func (c *closureStatsCollector) collectComponent(ctx context.Context, name, version string) error {
+	id := fmt.Sprintf("%s:%s", name, version)
+	if c.components[id] {
+		return nil
+	}
+	c.components[id] = true
+
+	cd, err := c.resolver.Resolve(ctx, &c.repoCtx, name, version)
+	if err != nil {
+		return fmt.Errorf("unable to resolve component descriptor %s: %w", id, err)
+	}
+
+	cdRef, err := OCIRef(&c.repoCtx, name, version)
+	if err != nil {
+		return fmt.Errorf("invalid component reference: %w", err)
+	}
+	if err := c.collectArtifact(ctx, cdRef); err != nil {
+		return fmt.Errorf("unable to collect component descriptor artifact of %s: %w", id, err)
+	}
+
+	for _, res := range cd.Resources {
+		if res.Access == nil || res.Access.GetType() != cdv2.OCIRegistryType {
+			continue
+		}
+		ociAccess := &cdv2.OCIRegistryAccess{}
+		if err := res.Access.DecodeInto(ociAccess); err != nil {
+			return fmt.Errorf("unable to decode access of resource %s of %s: %w", res.Name, id, err)
+		}
+		if err := c.collectArtifact(ctx, ociAccess.ImageReference); err != nil {
+			return fmt.Errorf("unable to collect resource %s of %s: %w", res.Name, id, err)
+		}
+	}
+
+	for _, ref := range cd.ComponentReferences {
+		if err := c.collectComponent(ctx, ref.ComponentName, ref.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectArtifact collects the sizes of the manifest and all blobs of an oci artifact.
+// Image indexes are followed to their manifests.
+func (c *closureStatsCollector) collectArtifact(ctx context.Context, ref string) error {
+	refspec, err := oci.ParseRef(ref)
+	if err != nil {
+		return fmt.Errorf("unable to parse ref %q: %w", ref, err)
+	}
+	desc, data, err := c.client.GetRawManifest(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("unable to get manifest %q: %w", ref, err)
+	}
+
+	registry := c.registry(refspec.Host)
+	if _, ok := registry.manifests[desc.Digest]; ok {
+		return nil
+	}
+	registry.manifests[desc.Digest] = int64(len(data))
+
+	if ociclient.IsMultiArchImage(desc.MediaType) {
+		index := ocispecv1.Index{}
+		if err := json.Unmarshal(data, &index); err != nil {
+			return fmt.Errorf("unable to decode image index %q: %w", ref, err)
+		}
+		for _, manifestDesc := range index.Manifests {
+			if err := c.collectArtifact(ctx, refspec.DigestRef(manifestDesc.Digest)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	manifest := ocispecv1.Manifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("unable to decode manifest %q: %w", ref, err)
+	}
+	if len(manifest.Config.Digest) != 0 {
+		registry.addBlob(manifest.Config)
+	}
+	for _, layer := range manifest.Layers {
+		registry.addBlob(layer)
+	}
+	return nil
+}
+
+func (c *closureStatsCollector) registry(host string) *registryStatsCollector {
+	registry, ok := c.registries[host]
+	if !ok {
+		registry = &registryStatsCollector{
+			manifests: map[digest.Digest]int64{},
+			blobs:     map[digest.Digest]int64{},
+			blobRefs:  map[digest.Digest]int{},
+		}
+		c.registries[host] = registry
+	}
+	return registry
+}
+
+func (r *registryStatsCollector) addBlob(desc ocispecv1.Descriptor) {
+	r.blobs[desc.Digest] = desc.Size
+	r.blobRefs[desc.Digest]++
+}
+
+func (c *closureStatsCollector) stats() *ClosureStats {
+	stats := &ClosureStats{
+		Components: len(c.components),
+		Registries: []RegistryStats{},
+	}
+	for host, registry := range c.registries {
+		regStats := RegistryStats{
+			Registry:  host,
+			Manifests: len(registry.manifests),
+			Blobs:     len(registry.blobs),
+		}
+		for _, size := range registry.manifests {
+			regStats.ManifestBytes += size
+		}
+		for dig, size := range registry.blobs {
+			regStats.BlobBytes += size
+			if refs := registry.blobRefs[dig]; refs > 1 {
+				regStats.SharedBlobs++
+				regStats.SharedBlobBytes += int64(refs-1) * size
+			}
+		}
+		stats.StorageBytes += regStats.ManifestBytes + regStats.BlobBytes
+		stats.TransferBytes += regStats.ManifestBytes + regStats.BlobBytes + regStats.SharedBlobBytes
+		stats.Registries = append(stats.Registries, regStats)
+	}
+	sort.Slice(stats.Registries, func(i, j int) bool {
+		return stats.Registries[i].Registry < stats.Registries[j].Registry
+	})
+	return stats
+}
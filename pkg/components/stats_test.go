@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	mock_ociclient "github.com/gardener/component-cli/ociclient/mock"
+	"github.com/gardener/component-cli/pkg/components"
+)
+
+// staticResolver resolves component descriptors from a map of "name:version" to component descriptor.
+type staticResolver map[string]*cdv2.ComponentDescriptor
+
+func (r staticResolver) Resolve(_ context.Context, _ cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	cd, ok := r[name+":"+version]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return cd, nil
+}
+
+func (r staticResolver) ResolveWithBlobResolver(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	cd, err := r.Resolve(ctx, repoCtx, name, version)
+	return cd, nil, err
+}
+
+var _ = Describe("ClosureStats", func() {
+
+	var (
+		mockCtrl      *gomock.Controller
+		mockOCIClient *mock_ociclient.MockClient
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockOCIClient = mock_ociclient.NewMockClient(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	newImageResource := func(name, imageRef string) cdv2.Resource {
+		acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(imageRef))
+		Expect(err).ToNot(HaveOccurred())
+		return cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    name,
+				Version: "v0.1.0",
+				Type:    cdv2.OCIImageType,
+			},
+			Relation: cdv2.ExternalRelation,
+			Access:   &acc,
+		}
+	}
+
+	blob := func(data string) ocispecv1.Descriptor {
+		return ocispecv1.Descriptor{
+			MediaType: "application/octet-stream",
+			Digest:    digest.FromString(data),
+			Size:      int64(len(data)),
+		}
+	}
+
+	It("should sum up the sizes of the closure per registry and distinguish shared blobs", func() {
+		root := &cdv2.ComponentDescriptor{}
+		root.Name = "example.com/root"
+		root.Version = "v0.1.0"
+		root.Resources = []cdv2.Resource{newImageResource("a", "example.com/images/a:v1")}
+		root.ComponentReferences = []cdv2.ComponentReference{
+			{Name: "child", ComponentName: "example.com/child", Version: "v0.1.0"},
+		}
+		child := &cdv2.ComponentDescriptor{}
+		child.Name = "example.com/child"
+		child.Version = "v0.1.0"
+		child.Resources = []cdv2.Resource{
+			newImageResource("b", "example.com/images/b:v1"),
+			newImageResource("c", "other.io/images/c:v1"),
+		}
+		resolver := staticResolver{
+			"example.com/root:v0.1.0":  root,
+			"example.com/child:v0.1.0": child,
+		}
+
+		sharedLayer := blob("shared-layer")
+		manifests := map[string]ocispecv1.Manifest{
+			"example.com/components/component-descriptors/example.com/root:v0.1.0": {
+				Config: blob("root-config"),
+				Layers: []ocispecv1.Descriptor{blob("root-cd")},
+			},
+			"example.com/components/component-descriptors/example.com/child:v0.1.0": {
+				Config: blob("child-config"),
+				Layers: []ocispecv1.Descriptor{blob("child-cd")},
+			},
+			"example.com/images/a:v1": {
+				Config: blob("a-config"),
+				Layers: []ocispecv1.Descriptor{sharedLayer, blob("a-layer")},
+			},
+			"example.com/images/b:v1": {
+				Config: blob("b-config"),
+				Layers: []ocispecv1.Descriptor{sharedLayer},
+			},
+			"other.io/images/c:v1": {
+				Config: blob("c-config"),
+				Layers: []ocispecv1.Descriptor{sharedLayer},
+			},
+		}
+		mockOCIClient.EXPECT().GetRawManifest(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, ref string) (ocispecv1.Descriptor, []byte, error) {
+			manifest, ok := manifests[ref]
+			if !ok {
+				return ocispecv1.Descriptor{}, nil, fmt.Errorf("manifest %s not found", ref)
+			}
+			data, err := json.Marshal(manifest)
+			Expect(err).ToNot(HaveOccurred())
+			return ocispecv1.Descriptor{
+				MediaType: ocispecv1.MediaTypeImageManifest,
+				Digest:    digest.FromBytes(data),
+				Size:      int64(len(data)),
+			}, data, nil
+		})
+
+		repoCtx := cdv2.NewOCIRegistryRepository("example.com/components", "")
+		stats, err := components.ComputeClosureStats(context.TODO(), mockOCIClient, resolver, *repoCtx, "example.com/root", "v0.1.0")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(stats.Components).To(Equal(2))
+		Expect(stats.Registries).To(HaveLen(2))
+
+		exampleStats := stats.Registries[0]
+		Expect(exampleStats.Registry).To(Equal("example.com"))
+		Expect(exampleStats.Manifests).To(Equal(4))
+		Expect(exampleStats.Blobs).To(Equal(8))
+		Expect(exampleStats.SharedBlobs).To(Equal(1))
+		Expect(exampleStats.SharedBlobBytes).To(Equal(sharedLayer.Size))
+
+		otherStats := stats.Registries[1]
+		Expect(otherStats.Registry).To(Equal("other.io"))
+		Expect(otherStats.Manifests).To(Equal(1))
+		Expect(otherStats.Blobs).To(Equal(2))
+		Expect(otherStats.SharedBlobs).To(Equal(0))
+
+		Expect(stats.TransferBytes - stats.StorageBytes).To(Equal(sharedLayer.Size))
+	})
+
+})
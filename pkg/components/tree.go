@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components
+
+import (
+	"context"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+)
+
+// DigestStatus describes whether the digest of a component reference matches the referenced component descriptor.
+type DigestStatus string
+
+const (
+	// DigestStatusMatch means that the digest of the reference matches the referenced component descriptor.
+	DigestStatusMatch DigestStatus = "match"
+	// DigestStatusMismatch means that the digest of the reference does not match the referenced component descriptor.
+	DigestStatusMismatch DigestStatus = "mismatch"
+	// DigestStatusMissing means that the reference has no digest.
+	DigestStatusMissing DigestStatus = "missing"
+	// DigestStatusUnknown means that the digest of the referenced component descriptor cannot be calculated,
+	// e.g. because its resources have no digests.
+	DigestStatusUnknown DigestStatus = "unknown"
+)
+
+// TreeNode is a component version in the dependency tree of a component.
+type TreeNode struct {
+	ComponentVersion `json:",inline"`
+	// ReferenceName is the name of the component reference of the parent that references the component.
+	// It is empty for the root.
+	ReferenceName string `json:"referenceName,omitempty"`
+	// RepositoryContext is the base url of the repository context the component is resolved from.
+	RepositoryContext string `json:"repositoryContext"`
+	// DigestStatus is the status of the digest of the component reference.
+	// It is only set if the digests are checked.
+	DigestStatus DigestStatus `json:"digestStatus,omitempty"`
+	// Cycle is true if the component is already one of its ancestors.
+	// The references of the component are not resolved again.
+	Cycle bool `json:"cycle,omitempty"`
+	// Children are the referenced components.
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// TreeOptions configures the resolution of a dependency tree.
+type TreeOptions struct {
+	// CheckDigests compares the digests of the component references with the referenced component descriptors.
+	// Only the component descriptors are hashed, the digests of their resources are not recalculated.
+	CheckDigests bool
+}
+
+// BuildTree recursively resolves the component references of the component descriptor and returns its dependency tree.
+// Referenced components are resolved in the effective repository context of the referencing component.
+func BuildTree(ctx context.Context, resolver ctf.ComponentResolver, cd *cdv2.ComponentDescriptor, opts TreeOptions) (*TreeNode, error) {
+	b := &treeBuilder{
+		resolver: resolver,
+		opts:     opts,
+		resolved: map[string]*cdv2.ComponentDescriptor{},
+	}
+	root := &TreeNode{
+		ComponentVersion: ComponentVersion{Name: cd.Name, Version: cd.Version},
+	}
+	if err := b.build(ctx, root, cd, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+type treeBuilder struct {
+	resolver ctf.ComponentResolver
+	opts     TreeOptions
+	// resolved caches the component descriptors of components that are referenced multiple times.
+	resolved map[string]*cdv2.ComponentDescriptor
+}
+
+func (b *treeBuilder) build(ctx context.Context, node *TreeNode, cd *cdv2.ComponentDescriptor, ancestors map[string]bool) error {
+	id := node.ComponentVersion.String()
+	effective := cd.GetEffectiveRepositoryContext()
+	if effective == nil {
+		return fmt.Errorf("component %s has no repository context", id)
+	}
+	repoCtx, err := GetOCIRepositoryContext(effective)
+	if err != nil {
+		return fmt.Errorf("unable to get repository context of component %s: %w", id, err)
+	}
+	node.RepositoryContext = repoCtx.BaseURL
+
+	ancestors[id] = true
+	defer delete(ancestors, id)
+
+	for _, ref := range cd.ComponentReferences {
+		child := &TreeNode{
+			ComponentVersion: ComponentVersion{Name: ref.ComponentName, Version: ref.Version},
+			ReferenceName:    ref.Name,
+		}
+		node.Children = append(node.Children, child)
+
+		refCd, err := b.resolve(ctx, repoCtx, child.ComponentVersion)
+		if err != nil {
+			return fmt.Errorf("unable to resolve component reference %s of %s: %w", ref.Name, id, err)
+		}
+		if b.opts.CheckDigests {
+			child.DigestStatus = referenceDigestStatus(ref, refCd)
+		}
+		if ancestors[child.ComponentVersion.String()] {
+			child.Cycle = true
+			child.RepositoryContext = repoCtx.BaseURL
+			continue
+		}
+		if err := b.build(ctx, child, refCd, ancestors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *treeBuilder) resolve(ctx context.Context, repoCtx cdv2.OCIRegistryRepository, cv ComponentVersion) (*cdv2.ComponentDescriptor, error) {
+	key := repoCtx.BaseURL + "/" + cv.String()
+	if cd, ok := b.resolved[key]; ok {
+		return cd, nil
+	}
+	cd, err := b.resolver.Resolve(ctx, &repoCtx, cv.Name, cv.Version)
+	if err != nil {
+		return nil, err
+	}
+	b.resolved[key] = cd
+	return cd, nil
+}
+
+// referenceDigestStatus compares the digest of the component reference with the hash of the referenced component descriptor.
+func referenceDigestStatus(ref cdv2.ComponentReference, cd *cdv2.ComponentDescriptor) DigestStatus {
+	if ref.Digest == nil {
+		return DigestStatusMissing
+	}
+	hasher, err := cdv2Sign.HasherForName(ref.Digest.HashAlgorithm)
+	if err != nil {
+		return DigestStatusUnknown
+	}
+	digest, err := cdv2Sign.HashForComponentDescriptor(*cd, *hasher)
+	if err != nil {
+		return DigestStatusUnknown
+	}
+	if digest.Value != ref.Digest.Value {
+		return DigestStatusMismatch
+	}
+	return DigestStatusMatch
+}
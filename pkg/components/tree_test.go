@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package components_test
+
+import (
+	"context"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/components"
+)
+
+var _ = Describe("Tree", func() {
+
+	repoCtx := cdv2.NewOCIRegistryRepository("example.com/components", "")
+
+	newComponent := func(name, version string, refs ...cdv2.ComponentReference) *cdv2.ComponentDescriptor {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Name = name
+		cd.Version = version
+		Expect(cdv2.InjectRepositoryContext(cd, repoCtx)).To(Succeed())
+		cd.ComponentReferences = refs
+		return cd
+	}
+
+	It("should resolve the dependency tree and detect cycles", func() {
+		resolver := staticResolver{
+			"example.com/app:v1.0.0": newComponent("example.com/app", "v1.0.0",
+				cdv2.ComponentReference{Name: "dep", ComponentName: "example.com/dep", Version: "v0.1.0"}),
+			"example.com/dep:v0.1.0": newComponent("example.com/dep", "v0.1.0",
+				cdv2.ComponentReference{Name: "app", ComponentName: "example.com/app", Version: "v1.0.0"}),
+		}
+
+		tree, err := components.BuildTree(context.TODO(), resolver, resolver["example.com/app:v1.0.0"], components.TreeOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree).To(Equal(&components.TreeNode{
+			ComponentVersion:  components.ComponentVersion{Name: "example.com/app", Version: "v1.0.0"},
+			RepositoryContext: "example.com/components",
+			Children: []*components.TreeNode{
+				{
+					ComponentVersion:  components.ComponentVersion{Name: "example.com/dep", Version: "v0.1.0"},
+					ReferenceName:     "dep",
+					RepositoryContext: "example.com/components",
+					Children: []*components.TreeNode{
+						{
+							ComponentVersion:  components.ComponentVersion{Name: "example.com/app", Version: "v1.0.0"},
+							ReferenceName:     "app",
+							RepositoryContext: "example.com/components",
+							Cycle:             true,
+						},
+					},
+				},
+			},
+		}))
+	})
+
+	It("should report the digest status of the component references", func() {
+		dep := newComponent("example.com/dep", "v0.1.0")
+		hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		digest, err := cdv2Sign.HashForComponentDescriptor(*dep, *hasher)
+		Expect(err).ToNot(HaveOccurred())
+
+		resolver := staticResolver{
+			"example.com/app:v1.0.0": newComponent("example.com/app", "v1.0.0",
+				cdv2.ComponentReference{Name: "match", ComponentName: "example.com/dep", Version: "v0.1.0", Digest: digest},
+				cdv2.ComponentReference{Name: "mismatch", ComponentName: "example.com/dep", Version: "v0.1.0", Digest: &cdv2.DigestSpec{
+					HashAlgorithm:          cdv2Sign.SHA256,
+					NormalisationAlgorithm: string(cdv2.JsonNormalisationV1),
+					Value:                  "abc",
+				}},
+				cdv2.ComponentReference{Name: "missing", ComponentName: "example.com/dep", Version: "v0.1.0"}),
+			"example.com/dep:v0.1.0": dep,
+		}
+
+		tree, err := components.BuildTree(context.TODO(), resolver, resolver["example.com/app:v1.0.0"], components.TreeOptions{CheckDigests: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tree.Children).To(HaveLen(3))
+		Expect(tree.DigestStatus).To(BeEmpty())
+		Expect(tree.Children[0].DigestStatus).To(Equal(components.DigestStatusMatch))
+		Expect(tree.Children[1].DigestStatus).To(Equal(components.DigestStatusMismatch))
+		Expect(tree.Children[2].DigestStatus).To(Equal(components.DigestStatusMissing))
+	})
+})
@@ -0,0 +1,524 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signatures
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/oci"
+)
+
+const (
+	// CosignArtifactType is the artifact type of cosign signatures that are stored as oci referrers.
+	CosignArtifactType = "application/vnd.dev.cosign.artifact.sig.v1+json"
+	// CosignSimpleSigningMediaType is the media type of the signed cosign payload.
+	CosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	// CosignSignatureType is the type of the critical section of a cosign payload.
+	CosignSignatureType = "cosign container image signature"
+
+	// CosignSignatureAnnotation is the layer annotation that contains the base64 encoded signature.
+	CosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// CosignCertificateAnnotation is the layer annotation that contains the PEM encoded signing certificate of keyless signatures.
+	CosignCertificateAnnotation = "dev.sigstore.cosign/certificate"
+	// CosignChainAnnotation is the layer annotation that contains the PEM encoded certificate chain of keyless signatures.
+	CosignChainAnnotation = "dev.sigstore.cosign/chain"
+	// CosignBundleAnnotation is the layer annotation that contains the rekor bundle of signatures that are uploaded to the transparency log.
+	CosignBundleAnnotation = "dev.sigstore.cosign/bundle"
+)
+
+var (
+	// fulcioIssuerV1OID is the certificate extension of fulcio certificates that contains the oidc issuer as raw string.
+	fulcioIssuerV1OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	// fulcioIssuerV2OID is the certificate extension of fulcio certificates that contains the oidc issuer as der encoded utf8 string.
+	fulcioIssuerV2OID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// CosignPayload is the simple signing payload that is signed by cosign.
+type CosignPayload struct {
+	Critical CosignCritical         `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// CosignCritical is the critical section of a cosign payload.
+type CosignCritical struct {
+	Identity CosignIdentity `json:"identity"`
+	Image    CosignImage    `json:"image"`
+	Type     string         `json:"type"`
+}
+
+// CosignIdentity describes the repository of the signed manifest.
+type CosignIdentity struct {
+	DockerReference string `json:"docker-reference"`
+}
+
+// CosignImage describes the digest of the signed manifest.
+type CosignImage struct {
+	DockerManifestDigest string `json:"docker-manifest-digest"`
+}
+
+// CosignClient defines the oci client functions that are needed to sign and verify manifests with cosign signatures.
+type CosignClient interface {
+	ociclient.ReferrersInterface
+	// GetRawManifest returns the raw manifest for a reference.
+	GetRawManifest(ctx context.Context, ref string) (ocispecv1.Descriptor, []byte, error)
+	// Fetch fetches the blob for the given ocispec Descriptor.
+	Fetch(ctx context.Context, ref string, desc ocispecv1.Descriptor, writer io.Writer) error
+}
+
+// CosignSignOptions defines the options to create a cosign signature.
+type CosignSignOptions struct {
+	// Signer is the private key that is used to sign the payload.
+	Signer crypto.Signer
+	// Certificate is the PEM encoded certificate of the signer for keyless signatures.
+	Certificate []byte
+	// Chain is the PEM encoded certificate chain of the certificate.
+	Chain []byte
+	// Rekor is the transparency log the signature is uploaded to.
+	// The signature is not uploaded if no rekor client is defined.
+	Rekor *RekorClient
+}
+
+// CosignVerifyOptions defines the options to verify cosign signatures.
+type CosignVerifyOptions struct {
+	// PublicKey is the key that is used to verify signatures that have been created with a key pair.
+	PublicKey crypto.PublicKey
+	// Roots are the root certificates that are used to verify the certificates of keyless signatures.
+	Roots *x509.CertPool
+	// Intermediates are optional intermediate certificates to verify the certificates of keyless signatures.
+	Intermediates *x509.CertPool
+	// Identity is the expected email or uri in the subject alternative names of the certificate of keyless signatures.
+	Identity string
+	// Issuer is the expected oidc issuer of the certificate of keyless signatures.
+	Issuer string
+	// RekorPublicKey is the public key of the transparency log.
+	// If set, only signatures with a valid rekor bundle are accepted.
+	RekorPublicKey crypto.PublicKey
+}
+
+// CosignSignature is a verified cosign signature.
+type CosignSignature struct {
+	// Descriptor is the descriptor of the signature manifest.
+	Descriptor ocispecv1.Descriptor
+	// Payload is the signed payload.
+	Payload CosignPayload
+	// Certificate is the signing certificate of keyless signatures.
+	Certificate *x509.Certificate
+	// Bundle is the rekor bundle of signatures that were uploaded to the transparency log.
+	Bundle *RekorBundle
+}
+
+// CosignSign signs the manifest of the given reference with a cosign compatible signature
+// and stores the signature as referrer of the manifest.
+func CosignSign(ctx context.Context, client CosignClient, ref string, opts CosignSignOptions) (ocispecv1.Descriptor, error) {
+	if opts.Signer == nil {
+		return ocispecv1.Descriptor{}, errors.New("a signer must be provided")
+	}
+	refspec, err := oci.ParseRef(ref)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to parse ref: %w", err)
+	}
+	subjectDesc, _, err := client.GetRawManifest(ctx, ref)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to get manifest of %q: %w", ref, err)
+	}
+
+	payload, err := json.Marshal(CosignPayload{
+		Critical: CosignCritical{
+			Identity: CosignIdentity{DockerReference: refspec.Name()},
+			Image:    CosignImage{DockerManifestDigest: subjectDesc.Digest.String()},
+			Type:     CosignSignatureType,
+		},
+	})
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to marshal payload: %w", err)
+	}
+	hash := sha256.Sum256(payload)
+	signature, err := opts.Signer.Sign(rand.Reader, hash[:], crypto.SHA256)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to sign payload: %w", err)
+	}
+
+	annotations := map[string]string{
+		CosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature),
+	}
+	if len(opts.Certificate) != 0 {
+		annotations[CosignCertificateAnnotation] = string(opts.Certificate)
+		if len(opts.Chain) != 0 {
+			annotations[CosignChainAnnotation] = string(opts.Chain)
+		}
+	}
+	if opts.Rekor != nil {
+		// keyless signatures are verified with the certificate, otherwise the public key is uploaded.
+		verificationMaterial := opts.Certificate
+		if len(verificationMaterial) == 0 {
+			verificationMaterial, err = MarshalPublicKeyPEM(opts.Signer.Public())
+			if err != nil {
+				return ocispecv1.Descriptor{}, err
+			}
+		}
+		bundle, err := opts.Rekor.UploadHashedRekord(ctx, hash[:], signature, verificationMaterial)
+		if err != nil {
+			return ocispecv1.Descriptor{}, fmt.Errorf("unable to upload signature to the transparency log: %w", err)
+		}
+		rawBundle, err := json.Marshal(bundle)
+		if err != nil {
+			return ocispecv1.Descriptor{}, fmt.Errorf("unable to marshal rekor bundle: %w", err)
+		}
+		annotations[CosignBundleAnnotation] = string(rawBundle)
+	}
+
+	payloadDesc := ocispecv1.Descriptor{
+		MediaType:   CosignSimpleSigningMediaType,
+		Digest:      digest.FromBytes(payload),
+		Size:        int64(len(payload)),
+		Annotations: annotations,
+	}
+	manifest := &ociclient.ReferrerManifest{
+		ArtifactType: CosignArtifactType,
+	}
+	manifest.Layers = []ocispecv1.Descriptor{payloadDesc}
+	store := ociclient.GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
+		if desc.Digest != payloadDesc.Digest {
+			return fmt.Errorf("unknown blob %s", desc.Digest)
+		}
+		_, err := writer.Write(payload)
+		return err
+	})
+	return client.PushReferrer(ctx, ref, manifest, ociclient.WithStore(store))
+}
+
+// CosignVerify verifies the cosign signatures that are attached as referrers to the manifest of the given reference.
+// All valid signatures are returned. An error is returned if no valid signature is found.
+func CosignVerify(ctx context.Context, client CosignClient, ref string, opts CosignVerifyOptions) ([]CosignSignature, error) {
+	if opts.PublicKey == nil && opts.Roots == nil {
+		return nil, errors.New("a public key or root certificates must be provided")
+	}
+	refspec, err := oci.ParseRef(ref)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse ref: %w", err)
+	}
+	subjectDesc, _, err := client.GetRawManifest(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get manifest of %q: %w", ref, err)
+	}
+	referrers, err := client.ListReferrers(ctx, ref, CosignArtifactType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list signatures: %w", err)
+	}
+
+	var (
+		verified []CosignSignature
+		errs     []error
+	)
+	for _, referrer := range referrers {
+		signatureRef := refspec.DigestRef(referrer.Digest)
+		_, rawManifest, err := client.GetRawManifest(ctx, signatureRef)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get signature manifest %q: %w", signatureRef, err)
+		}
+		manifest := ocispecv1.Manifest{}
+		if err := json.Unmarshal(rawManifest, &manifest); err != nil {
+			return nil, fmt.Errorf("unable to decode signature manifest %q: %w", signatureRef, err)
+		}
+		for _, layer := range manifest.Layers {
+			if layer.MediaType != CosignSimpleSigningMediaType {
+				continue
+			}
+			var buf bytes.Buffer
+			if err := client.Fetch(ctx, signatureRef, layer, &buf); err != nil {
+				return nil, fmt.Errorf("unable to fetch signature payload of %q: %w", signatureRef, err)
+			}
+			sig, err := VerifyCosignLayer(layer, buf.Bytes(), subjectDesc.Digest, opts)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("signature %s: %w", referrer.Digest, err))
+				continue
+			}
+			sig.Descriptor = referrer.Descriptor
+			verified = append(verified, *sig)
+		}
+	}
+
+	if len(verified) == 0 {
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("no cosign signatures found for %q", ref)
+		}
+		return nil, fmt.Errorf("no valid cosign signature found for %q: %v", ref, errs)
+	}
+	return verified, nil
+}
+
+// VerifyCosignLayer verifies a single simple signing layer of a cosign signature manifest for the manifest with the given digest.
+// The layer is verified with the certificate of keyless signatures if root certificates are configured, otherwise with the public key.
+func VerifyCosignLayer(layer ocispecv1.Descriptor, payload []byte, subject digest.Digest, opts CosignVerifyOptions) (*CosignSignature, error) {
+	if digest.FromBytes(payload) != layer.Digest {
+		return nil, errors.New("payload digest mismatch")
+	}
+	signature, err := base64.StdEncoding.DecodeString(layer.Annotations[CosignSignatureAnnotation])
+	if err != nil || len(signature) == 0 {
+		return nil, errors.New("missing or invalid signature annotation")
+	}
+	result := &CosignSignature{}
+	if err := json.Unmarshal(payload, &result.Payload); err != nil {
+		return nil, fmt.Errorf("unable to decode payload: %w", err)
+	}
+	if result.Payload.Critical.Type != CosignSignatureType {
+		return nil, fmt.Errorf("unexpected payload type %q", result.Payload.Critical.Type)
+	}
+	if result.Payload.Critical.Image.DockerManifestDigest != subject.String() {
+		return nil, fmt.Errorf("payload is signed for digest %q but expected %q", result.Payload.Critical.Image.DockerManifestDigest, subject)
+	}
+
+	if rawBundle, ok := layer.Annotations[CosignBundleAnnotation]; ok {
+		result.Bundle = &RekorBundle{}
+		if err := json.Unmarshal([]byte(rawBundle), result.Bundle); err != nil {
+			return nil, fmt.Errorf("unable to decode rekor bundle: %w", err)
+		}
+	}
+	// the bundle is only trusted if it has been verified with the public key of the transparency log.
+	var verifiedBundle *RekorBundle
+	if opts.RekorPublicKey != nil {
+		if result.Bundle == nil {
+			return nil, errors.New("signature has not been uploaded to the transparency log")
+		}
+		if err := result.Bundle.Verify(opts.RekorPublicKey, signature); err != nil {
+			return nil, err
+		}
+		verifiedBundle = result.Bundle
+	}
+
+	publicKey := opts.PublicKey
+	if rawCert, ok := layer.Annotations[CosignCertificateAnnotation]; ok && opts.Roots != nil {
+		cert, err := verifyCosignCertificate([]byte(rawCert), []byte(layer.Annotations[CosignChainAnnotation]), verifiedBundle, opts)
+		if err != nil {
+			return nil, err
+		}
+		result.Certificate = cert
+		publicKey = cert.PublicKey
+	}
+	if publicKey == nil {
+		return nil, errors.New("no public key or certificate to verify the signature")
+	}
+
+	// ed25519 signatures are created for the payload itself and not for its digest.
+	if key, ok := publicKey.(ed25519.PublicKey); ok {
+		if !ed25519.Verify(key, payload, signature) {
+			return nil, errors.New("invalid signature")
+		}
+		return result, nil
+	}
+	hash := sha256.Sum256(payload)
+	if err := verifyDigestSignature(publicKey, hash[:], signature); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// verifyCosignCertificate verifies the certificate of a keyless signature.
+// Fulcio certificates are only valid for a short time, therefore the certificate is verified at the time
+// the signature has been added to the transparency log if a verified bundle is given.
+// The time of an unverified bundle could be forged, so that the certificate is verified at the current time without one.
+func verifyCosignCertificate(rawCert, rawChain []byte, bundle *RekorBundle, opts CosignVerifyOptions) (*x509.Certificate, error) {
+	block, _ := pem.Decode(rawCert)
+	if block == nil {
+		return nil, errors.New("unable to decode signing certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse signing certificate: %w", err)
+	}
+
+	intermediates := x509.NewCertPool()
+	if opts.Intermediates != nil {
+		intermediates = opts.Intermediates.Clone()
+	}
+	if len(rawChain) != 0 {
+		intermediates.AppendCertsFromPEM(rawChain)
+	}
+	verifyOpts := x509.VerifyOptions{
+		Roots:         opts.Roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	if bundle != nil {
+		verifyOpts.CurrentTime = time.Unix(bundle.Payload.IntegratedTime, 0)
+	}
+	if _, err := cert.Verify(verifyOpts); err != nil {
+		return nil, fmt.Errorf("unable to verify signing certificate: %w", err)
+	}
+
+	if len(opts.Identity) != 0 && !certificateHasIdentity(cert, opts.Identity) {
+		return nil, fmt.Errorf("signing certificate does not belong to identity %q", opts.Identity)
+	}
+	if len(opts.Issuer) != 0 {
+		issuer := CertificateOIDCIssuer(cert)
+		if issuer != opts.Issuer {
+			return nil, fmt.Errorf("signing certificate has been issued for oidc issuer %q but expected %q", issuer, opts.Issuer)
+		}
+	}
+	return cert, nil
+}
+
+func certificateHasIdentity(cert *x509.Certificate, identity string) bool {
+	for _, email := range cert.EmailAddresses {
+		if email == identity {
+			return true
+		}
+	}
+	for _, uri := range cert.URIs {
+		if uri.String() == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// CertificateOIDCIssuer returns the oidc issuer of a fulcio certificate.
+func CertificateOIDCIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(fulcioIssuerV2OID):
+			var issuer string
+			if _, err := asn1.UnmarshalWithParams(ext.Value, &issuer, "utf8"); err == nil {
+				return issuer
+			}
+		case ext.Id.Equal(fulcioIssuerV1OID):
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+// verifyDigestSignature verifies a signature of a sha256 digest with a rsa or ecdsa public key.
+func verifyDigestSignature(publicKey crypto.PublicKey, hash, signature []byte) error {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, hash, signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash, signature); err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// LoadPrivateKey reads a PEM encoded rsa or ecdsa private key.
+// Keys that are encrypted with a passphrase (e.g. by the keygen command) are decrypted with the given passphrase.
+// The private key can be in the PKCS #1, SEC 1 or (encrypted) PKCS #8 form.
+func LoadPrivateKey(data, passphrase []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("unable to decode pem formatted block in key")
+	}
+	der := block.Bytes
+	switch {
+	case block.Type == EncryptedPrivateKeyPEMType:
+		if len(passphrase) == 0 {
+			return nil, errors.New("the private key is encrypted but no passphrase is provided")
+		}
+		var err error
+		der, err = DecryptPKCS8PrivateKey(block.Bytes, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt private key: %w", err)
+		}
+	case x509.IsEncryptedPEMBlock(block):
+		// keys that are encrypted with the legacy pem encryption (e.g. by older versions of the keygen command)
+		// can still be read but should be converted to encrypted PKCS #8 keys.
+		if len(passphrase) == 0 {
+			return nil, errors.New("the private key is encrypted but no passphrase is provided")
+		}
+		var err error
+		der, err = x509.DecryptPEMBlock(block, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt private key: %w", err)
+		}
+	}
+
+	var (
+		key interface{}
+		err error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(der)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(der)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key: %w", err)
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// LoadPublicKey reads a PEM encoded PKIX public key or the public key of a certificate.
+func LoadPublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("unable to decode pem formatted block in key")
+	}
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse certificate: %w", err)
+		}
+		return cert.PublicKey, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse public key: %w", err)
+	}
+	return key, nil
+}
+
+// LoadPublicKeyFile reads a PEM encoded PKIX public key from a file.
+func LoadPublicKeyFile(path string) (crypto.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read public key file: %w", err)
+	}
+	return LoadPublicKey(data)
+}
+
+// MarshalPublicKeyPEM encodes a public key PEM encoded in the PKIX form.
+func MarshalPublicKeyPEM(publicKey crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal public key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package signatures
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var _ = Describe("cosign", func() {
+
+	Context("VerifyCosignLayer", func() {
+
+		var (
+			roots     *x509.CertPool
+			rekorKey  *ecdsa.PrivateKey
+			subject   digest.Digest
+			payload   []byte
+			layer     ocispecv1.Descriptor
+			signature []byte
+			// certTime is a time in the validity period of the expired signing certificate.
+			certTime time.Time
+		)
+
+		// newBundle creates a rekor bundle of the signature that has been integrated at the given time.
+		newBundle := func(integratedTime time.Time, key *ecdsa.PrivateKey) string {
+			entry := rekorEntry{}
+			entry.Spec.Signature.Content = base64.StdEncoding.EncodeToString(signature)
+			body, err := json.Marshal(entry)
+			Expect(err).ToNot(HaveOccurred())
+			bundle := RekorBundle{
+				Payload: RekorBundlePayload{
+					Body:           base64.StdEncoding.EncodeToString(body),
+					IntegratedTime: integratedTime.Unix(),
+				},
+			}
+			canonicalPayload, err := json.Marshal(bundle.Payload)
+			Expect(err).ToNot(HaveOccurred())
+			hash := sha256.Sum256(canonicalPayload)
+			bundle.SignedEntryTimestamp, err = ecdsa.SignASN1(rand.Reader, key, hash[:])
+			Expect(err).ToNot(HaveOccurred())
+			data, err := json.Marshal(bundle)
+			Expect(err).ToNot(HaveOccurred())
+			return string(data)
+		}
+
+		BeforeEach(func() {
+			caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			caTemplate := &x509.Certificate{
+				SerialNumber:          big.NewInt(1),
+				Subject:               pkix.Name{CommonName: "fulcio"},
+				NotBefore:             time.Now().Add(-24 * time.Hour),
+				NotAfter:              time.Now().Add(24 * time.Hour),
+				KeyUsage:              x509.KeyUsageCertSign,
+				BasicConstraintsValid: true,
+				IsCA:                  true,
+			}
+			caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+			Expect(err).ToNot(HaveOccurred())
+			caCert, err := x509.ParseCertificate(caDer)
+			Expect(err).ToNot(HaveOccurred())
+			roots = x509.NewCertPool()
+			roots.AddCert(caCert)
+
+			// the short-lived signing certificate has already expired
+			signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			certTime = time.Now().Add(-2 * time.Hour)
+			certDer, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+				SerialNumber:   big.NewInt(2),
+				NotBefore:      certTime.Add(-time.Minute),
+				NotAfter:       certTime.Add(10 * time.Minute),
+				KeyUsage:       x509.KeyUsageDigitalSignature,
+				ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+				EmailAddresses: []string{"user@example.com"},
+			}, caCert, &signingKey.PublicKey, caKey)
+			Expect(err).ToNot(HaveOccurred())
+
+			rekorKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+
+			subject = digest.FromString("manifest")
+			payload, err = json.Marshal(CosignPayload{
+				Critical: CosignCritical{
+					Image: CosignImage{DockerManifestDigest: subject.String()},
+					Type:  CosignSignatureType,
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			hash := sha256.Sum256(payload)
+			signature, err = ecdsa.SignASN1(rand.Reader, signingKey, hash[:])
+			Expect(err).ToNot(HaveOccurred())
+
+			layer = ocispecv1.Descriptor{
+				MediaType: CosignSimpleSigningMediaType,
+				Digest:    digest.FromBytes(payload),
+				Size:      int64(len(payload)),
+				Annotations: map[string]string{
+					CosignSignatureAnnotation:   base64.StdEncoding.EncodeToString(signature),
+					CosignCertificateAnnotation: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer})),
+				},
+			}
+		})
+
+		It("should verify the certificate at the time of a verified rekor bundle", func() {
+			layer.Annotations[CosignBundleAnnotation] = newBundle(certTime, rekorKey)
+			sig, err := VerifyCosignLayer(layer, payload, subject, CosignVerifyOptions{
+				Roots:          roots,
+				Identity:       "user@example.com",
+				RekorPublicKey: &rekorKey.PublicKey,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sig.Certificate.EmailAddresses).To(ConsistOf("user@example.com"))
+		})
+
+		It("should verify the certificate at the current time if the rekor bundle is not verified", func() {
+			forgeryKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			layer.Annotations[CosignBundleAnnotation] = newBundle(certTime, forgeryKey)
+			_, err = VerifyCosignLayer(layer, payload, subject, CosignVerifyOptions{
+				Roots:    roots,
+				Identity: "user@example.com",
+			})
+			Expect(err).To(MatchError(ContainSubstring("unable to verify signing certificate")))
+		})
+
+		It("should reject a rekor bundle that is not signed by the transparency log", func() {
+			forgeryKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			layer.Annotations[CosignBundleAnnotation] = newBundle(certTime, forgeryKey)
+			_, err = VerifyCosignLayer(layer, payload, subject, CosignVerifyOptions{
+				Roots:          roots,
+				Identity:       "user@example.com",
+				RekorPublicKey: &rekorKey.PublicKey,
+			})
+			Expect(err).To(HaveOccurred())
+		})
+
+	})
+
+})
@@ -13,11 +13,11 @@ import (
 	"reflect"
 
 	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/pkg/components/schema"
 	"github.com/gardener/component-cli/pkg/logger"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
-	cdoci "github.com/gardener/component-spec/bindings-go/oci"
 )
 
 type Digester struct {
@@ -69,7 +69,7 @@ func (d *Digester) digestForLocalOciBlob(ctx context.Context, componentDescripto
 	}
 	defer tmpfile.Close()
 
-	resolver := cdoci.NewResolver(d.ociClient)
+	resolver := schema.NewResolver(d.ociClient)
 	_, blobResolver, err := resolver.ResolveWithBlobResolver(ctx, &repoctx, componentDescriptor.Name, componentDescriptor.Version)
 	if err != nil {
 		return nil, fmt.Errorf("unable to resolve component descriptor: %w", err)
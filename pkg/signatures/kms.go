@@ -0,0 +1,297 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signatures
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+)
+
+const (
+	// VaultKMSScheme is the scheme of key uris of the hashicorp vault transit secrets engine, e.g. "hashivault://mykey".
+	VaultKMSScheme = "hashivault"
+
+	// VaultAddrEnvName is the environment variable that contains the address of the vault server.
+	VaultAddrEnvName = "VAULT_ADDR"
+	// VaultTokenEnvName is the environment variable that contains the vault token.
+	VaultTokenEnvName = "VAULT_TOKEN"
+	// VaultTransitPathEnvName is the environment variable that contains the mount path of the transit secrets engine.
+	VaultTransitPathEnvName = "TRANSIT_SECRET_ENGINE_PATH"
+)
+
+// KMSProviderFunc creates a signer for the key with the given uri.
+// The private key is expected to never leave the kms, all signing operations are delegated to it.
+type KMSProviderFunc func(ctx context.Context, keyURI string) (crypto.Signer, error)
+
+var (
+	kmsProvidersMux sync.RWMutex
+	kmsProviders    = map[string]KMSProviderFunc{
+		VaultKMSScheme: newVaultSigner,
+		AWSKMSScheme: func(ctx context.Context, keyURI string) (crypto.Signer, error) {
+			return newAWSKMSSigner(ctx, nil, keyURI)
+		},
+		GCPKMSScheme: func(ctx context.Context, keyURI string) (crypto.Signer, error) {
+			return newGCPKMSSigner(ctx, nil, keyURI)
+		},
+		AzureKMSScheme: func(ctx context.Context, keyURI string) (crypto.Signer, error) {
+			return newAzureKMSSigner(ctx, nil, keyURI)
+		},
+	}
+)
+
+// RegisterKMSProvider registers a kms provider for key uris with a scheme that is not built into the component-cli.
+func RegisterKMSProvider(scheme string, provider KMSProviderFunc) error {
+	if len(scheme) == 0 {
+		return errors.New("kms scheme must not be empty")
+	}
+	if provider == nil {
+		return fmt.Errorf("kms provider for scheme %s must not be nil", scheme)
+	}
+	kmsProvidersMux.Lock()
+	defer kmsProvidersMux.Unlock()
+	if _, ok := kmsProviders[scheme]; ok {
+		return fmt.Errorf("kms provider for scheme %s is already registered", scheme)
+	}
+	kmsProviders[scheme] = provider
+	return nil
+}
+
+// KMSSchemes returns the sorted schemes of all registered kms providers.
+func KMSSchemes() []string {
+	kmsProvidersMux.RLock()
+	defer kmsProvidersMux.RUnlock()
+	schemes := make([]string, 0, len(kmsProviders))
+	for scheme := range kmsProviders {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// NewKMSSigner creates a signer for a key uri of the form "<scheme>://<key>" with the kms provider of the scheme.
+func NewKMSSigner(ctx context.Context, keyURI string) (crypto.Signer, error) {
+	i := strings.Index(keyURI, "://")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid key uri %q: expected <scheme>://<key>", keyURI)
+	}
+	scheme := keyURI[:i]
+	kmsProvidersMux.RLock()
+	provider, ok := kmsProviders[scheme]
+	kmsProvidersMux.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown kms scheme %q. Supported schemes are %s", scheme, strings.Join(KMSSchemes(), ", "))
+	}
+	return provider(ctx, keyURI)
+}
+
+// CryptoSigner signs component descriptors with a crypto.Signer, e.g. a key in a kms.
+// Only rsa keys are supported as component descriptor signatures are RSASSA-PKCS1-V1_5 signatures.
+type CryptoSigner struct {
+	signer    crypto.Signer
+	mediaType string
+}
+
+// NewCryptoSigner creates a component descriptor signer for the given signer.
+// mediaType defines the format of the signature that is saved to the component descriptor.
+func NewCryptoSigner(signer crypto.Signer, mediaType string) (*CryptoSigner, error) {
+	if _, ok := signer.Public().(*rsa.PublicKey); !ok {
+		return nil, fmt.Errorf("unsupported key type %T: only rsa keys can be used to sign component descriptors", signer.Public())
+	}
+	if mediaType != cdv2.MediaTypePEM && mediaType != cdv2.MediaTypeRSASignature {
+		return nil, fmt.Errorf("unsupported signature media type %s", mediaType)
+	}
+	return &CryptoSigner{
+		signer:    signer,
+		mediaType: mediaType,
+	}, nil
+}
+
+// Sign returns the signature for the digest of the component descriptor.
+func (s *CryptoSigner) Sign(_ cdv2.ComponentDescriptor, digest cdv2.DigestSpec) (*cdv2.SignatureSpec, error) {
+	hashfunc, ok := cdv2Sign.HashFunctions[digest.HashAlgorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash algorithm %s", digest.HashAlgorithm)
+	}
+	decodedHash, err := hex.DecodeString(digest.Value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to hex decode hash: %w", err)
+	}
+	signature, err := s.signer.Sign(rand.Reader, decodedHash, hashfunc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign hash: %w", err)
+	}
+
+	if s.mediaType == cdv2.MediaTypeRSASignature {
+		return &cdv2.SignatureSpec{
+			Algorithm: cdv2.RSAPKCS1v15,
+			Value:     hex.EncodeToString(signature),
+			MediaType: cdv2.MediaTypeRSASignature,
+		}, nil
+	}
+	signatureBlock := &pem.Block{
+		Type: cdv2.SignaturePEMBlockType,
+		Headers: map[string]string{
+			cdv2.SignatureAlgorithmHeader: cdv2.RSAPKCS1v15,
+		},
+		Bytes: signature,
+	}
+	return &cdv2.SignatureSpec{
+		Algorithm: cdv2.RSAPKCS1v15,
+		Value:     string(pem.EncodeToMemory(signatureBlock)),
+		MediaType: cdv2.MediaTypePEM,
+	}, nil
+}
+
+// vaultSigner signs digests with a key of the transit secrets engine of hashicorp vault.
+type vaultSigner struct {
+	ctx       context.Context
+	address   string
+	token     string
+	path      string
+	keyName   string
+	publicKey crypto.PublicKey
+}
+
+// newVaultSigner creates a signer for a key uri of the form "hashivault://<key name>".
+// The address and token of vault are read from the VAULT_ADDR and VAULT_TOKEN environment variables.
+func newVaultSigner(ctx context.Context, keyURI string) (crypto.Signer, error) {
+	s := &vaultSigner{
+		ctx:     ctx,
+		address: strings.TrimSuffix(os.Getenv(VaultAddrEnvName), "/"),
+		token:   os.Getenv(VaultTokenEnvName),
+		path:    os.Getenv(VaultTransitPathEnvName),
+		keyName: strings.TrimPrefix(keyURI, VaultKMSScheme+"://"),
+	}
+	if len(s.address) == 0 {
+		return nil, fmt.Errorf("the vault address must be set with %s", VaultAddrEnvName)
+	}
+	if len(s.token) == 0 {
+		return nil, fmt.Errorf("the vault token must be set with %s", VaultTokenEnvName)
+	}
+	if len(s.path) == 0 {
+		s.path = "transit"
+	}
+	if len(s.keyName) == 0 || strings.Contains(s.keyName, "/") {
+		return nil, fmt.Errorf("invalid vault key uri %q: expected %s://<key name>", keyURI, VaultKMSScheme)
+	}
+
+	key := struct {
+		Data struct {
+			LatestVersion int `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}{}
+	if err := s.do(http.MethodGet, "keys/"+s.keyName, nil, &key); err != nil {
+		return nil, fmt.Errorf("unable to get vault key %q: %w", s.keyName, err)
+	}
+	latest, ok := key.Data.Keys[strconv.Itoa(key.Data.LatestVersion)]
+	if !ok || len(latest.PublicKey) == 0 {
+		return nil, fmt.Errorf("vault key %q has no public key", s.keyName)
+	}
+	publicKey, err := LoadPublicKey([]byte(latest.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load public key of vault key %q: %w", s.keyName, err)
+	}
+	s.publicKey = publicKey
+	return s, nil
+}
+
+func (s *vaultSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *vaultSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var hashAlgorithm string
+	switch opts.HashFunc() {
+	case crypto.SHA256:
+		hashAlgorithm = "sha2-256"
+	case crypto.SHA384:
+		hashAlgorithm = "sha2-384"
+	case crypto.SHA512:
+		hashAlgorithm = "sha2-512"
+	default:
+		return nil, fmt.Errorf("unsupported hash function %s", opts.HashFunc())
+	}
+	req := map[string]interface{}{
+		"input":     base64.StdEncoding.EncodeToString(digest),
+		"prehashed": true,
+	}
+	if _, ok := s.publicKey.(*rsa.PublicKey); ok {
+		req["signature_algorithm"] = "pkcs1v15"
+	} else {
+		req["marshaling_algorithm"] = "asn1"
+	}
+	resp := struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}{}
+	if err := s.do(http.MethodPost, fmt.Sprintf("sign/%s/%s", s.keyName, hashAlgorithm), req, &resp); err != nil {
+		return nil, fmt.Errorf("unable to sign with vault key %q: %w", s.keyName, err)
+	}
+	// vault signatures are of the form "vault:v<key version>:<base64 signature>"
+	parts := strings.Split(resp.Data.Signature, ":")
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, fmt.Errorf("unexpected vault signature format %q", resp.Data.Signature)
+	}
+	return base64.StdEncoding.DecodeString(parts[2])
+}
+
+func (s *vaultSigner) do(method, path string, body, into interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("unable to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	url := fmt.Sprintf("%s/v1/%s/%s", s.address, strings.Trim(s.path, "/"), path)
+	req, err := http.NewRequestWithContext(s.ctx, method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response from %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed with status %d: %s", url, resp.StatusCode, string(data))
+	}
+	if err := json.Unmarshal(data, into); err != nil {
+		return fmt.Errorf("unable to decode response from %s: %w", url, err)
+	}
+	return nil
+}
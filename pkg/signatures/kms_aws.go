@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signatures
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// AWSKMSScheme is the scheme of key uris of aws kms, e.g. "awskms:///alias/mykey" or "awskms://<endpoint>/<key id>".
+const AWSKMSScheme = "awskms"
+
+// awsKMSSigner signs digests with an asymmetric key of aws kms.
+type awsKMSSigner struct {
+	ctx       context.Context
+	client    *kms.Client
+	keyID     string
+	publicKey crypto.PublicKey
+}
+
+// newAWSKMSSigner creates a signer for a key uri of the form "awskms://[endpoint]/<key id, alias, key arn or alias arn>".
+// The credentials and the region are read with the default credential chain of the aws sdk.
+// The region of arns is taken from the arn.
+func newAWSKMSSigner(ctx context.Context, httpClient *http.Client, keyURI string) (crypto.Signer, error) {
+	endpoint, keyID, ok := strings.Cut(strings.TrimPrefix(keyURI, AWSKMSScheme+"://"), "/")
+	if !ok || len(keyID) == 0 {
+		return nil, fmt.Errorf("invalid aws kms key uri %q: expected %s://[endpoint]/<key id>", keyURI, AWSKMSScheme)
+	}
+
+	var opts []func(*awsconfig.LoadOptions) error
+	if strings.HasPrefix(keyID, "arn:") {
+		// arn:<partition>:kms:<region>:<account id>:key/<key id>
+		parts := strings.Split(keyID, ":")
+		if len(parts) != 6 || parts[2] != "kms" {
+			return nil, fmt.Errorf("invalid aws kms key arn %q", keyID)
+		}
+		opts = append(opts, awsconfig.WithRegion(parts[3]))
+	}
+	if httpClient != nil {
+		opts = append(opts, awsconfig.WithHTTPClient(httpClient))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load aws config: %w", err)
+	}
+	client := kms.NewFromConfig(cfg, func(o *kms.Options) {
+		if len(endpoint) != 0 {
+			o.BaseEndpoint = aws.String("https://" + endpoint)
+		}
+	})
+
+	res, err := client.GetPublicKey(ctx, &kms.GetPublicKeyInput{
+		KeyId: aws.String(keyID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get public key of aws kms key %q: %w", keyID, err)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(res.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse public key of aws kms key %q: %w", keyID, err)
+	}
+	return &awsKMSSigner{
+		ctx:       ctx,
+		client:    client,
+		keyID:     keyID,
+		publicKey: publicKey,
+	}, nil
+}
+
+func (s *awsKMSSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *awsKMSSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	algorithm, err := awsSigningAlgorithm(s.publicKey, opts.HashFunc())
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.client.Sign(s.ctx, &kms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign with aws kms key %q: %w", s.keyID, err)
+	}
+	return res.Signature, nil
+}
+
+// awsSigningAlgorithm returns the aws kms signing algorithm for the key type and the hash function.
+func awsSigningAlgorithm(publicKey crypto.PublicKey, hash crypto.Hash) (types.SigningAlgorithmSpec, error) {
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		switch hash {
+		case crypto.SHA256:
+			return types.SigningAlgorithmSpecRsassaPkcs1V15Sha256, nil
+		case crypto.SHA384:
+			return types.SigningAlgorithmSpecRsassaPkcs1V15Sha384, nil
+		case crypto.SHA512:
+			return types.SigningAlgorithmSpecRsassaPkcs1V15Sha512, nil
+		}
+	case *ecdsa.PublicKey:
+		switch hash {
+		case crypto.SHA256:
+			return types.SigningAlgorithmSpecEcdsaSha256, nil
+		case crypto.SHA384:
+			return types.SigningAlgorithmSpecEcdsaSha384, nil
+		case crypto.SHA512:
+			return types.SigningAlgorithmSpecEcdsaSha512, nil
+		}
+	default:
+		return "", fmt.Errorf("unsupported key type %T", publicKey)
+	}
+	return "", fmt.Errorf("unsupported hash function %s", hash)
+}
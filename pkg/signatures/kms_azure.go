@@ -0,0 +1,236 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signatures
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+const (
+	// AzureKMSScheme is the scheme of key uris of azure key vault, e.g. "azurekms://myvault.vault.azure.net/mykey".
+	AzureKMSScheme = "azurekms"
+
+	azureKeyVaultScope      = "https://vault.azure.net/.default"
+	azureKeyVaultAPIVersion = "7.4"
+)
+
+// azureKeyVaultKey is the json web key of a key vault key.
+type azureKeyVaultKey struct {
+	KID string `json:"kid"`
+	KTY string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	CRV string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// azureKMSSigner signs digests with a key of azure key vault.
+type azureKMSSigner struct {
+	ctx        context.Context
+	httpClient *http.Client
+	cred       azcore.TokenCredential
+	keyURL     string
+	publicKey  crypto.PublicKey
+}
+
+// newAzureKMSSigner creates a signer for a key uri of the form "azurekms://<vault host>/<key name>[/<key version>]".
+// The latest version of the key is used if no version is given.
+// The credentials are read with the default credential chain of the azure sdk.
+func newAzureKMSSigner(ctx context.Context, httpClient *http.Client, keyURI string) (crypto.Signer, error) {
+	vault, key, ok := strings.Cut(strings.TrimPrefix(keyURI, AzureKMSScheme+"://"), "/")
+	if !ok || len(vault) == 0 || len(key) == 0 || strings.Count(key, "/") > 1 {
+		return nil, fmt.Errorf("invalid azure key vault key uri %q: expected %s://<vault host>/<key name>[/<key version>]", keyURI, AzureKMSScheme)
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	cred, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+		ClientOptions: azcore.ClientOptions{
+			Transport: httpClient,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create azure credentials: %w", err)
+	}
+	s := &azureKMSSigner{
+		ctx:        ctx,
+		httpClient: httpClient,
+		cred:       cred,
+		keyURL:     fmt.Sprintf("https://%s/keys/%s", vault, key),
+	}
+
+	res := struct {
+		Key azureKeyVaultKey `json:"key"`
+	}{}
+	if err := s.do(http.MethodGet, s.keyURL, nil, &res); err != nil {
+		return nil, fmt.Errorf("unable to get azure key vault key %q: %w", key, err)
+	}
+	publicKey, err := res.Key.publicKey()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load public key of azure key vault key %q: %w", key, err)
+	}
+	s.publicKey = publicKey
+	// sign with the key version of the public key even if a newer version is created in the meantime.
+	if len(res.Key.KID) != 0 {
+		s.keyURL = res.Key.KID
+	}
+	return s, nil
+}
+
+func (s *azureKMSSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *azureKMSSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var algorithm string
+	switch opts.HashFunc() {
+	case crypto.SHA256:
+		algorithm = "256"
+	case crypto.SHA384:
+		algorithm = "384"
+	case crypto.SHA512:
+		algorithm = "512"
+	default:
+		return nil, fmt.Errorf("unsupported hash function %s", opts.HashFunc())
+	}
+	_, isECDSA := s.publicKey.(*ecdsa.PublicKey)
+	if isECDSA {
+		algorithm = "ES" + algorithm
+	} else {
+		algorithm = "RS" + algorithm
+	}
+
+	req := map[string]string{
+		"alg":   algorithm,
+		"value": base64.RawURLEncoding.EncodeToString(digest),
+	}
+	resp := struct {
+		Value string `json:"value"`
+	}{}
+	if err := s.do(http.MethodPost, s.keyURL+"/sign", req, &resp); err != nil {
+		return nil, fmt.Errorf("unable to sign with azure key vault key %q: %w", s.keyURL, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(resp.Value, "="))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode signature: %w", err)
+	}
+	if !isECDSA {
+		return signature, nil
+	}
+	// key vault returns ecdsa signatures as concatenation of r and s, crypto.Signer signatures are asn.1 encoded.
+	half := len(signature) / 2
+	return asn1.Marshal(struct {
+		R, S *big.Int
+	}{
+		R: new(big.Int).SetBytes(signature[:half]),
+		S: new(big.Int).SetBytes(signature[half:]),
+	})
+}
+
+func (s *azureKMSSigner) do(method, url string, body, into interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("unable to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	token, err := s.cred.GetToken(s.ctx, policy.TokenRequestOptions{
+		Scopes: []string{azureKeyVaultScope},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to get azure access token: %w", err)
+	}
+	url = fmt.Sprintf("%s?api-version=%s", url, azureKeyVaultAPIVersion)
+	req, err := http.NewRequestWithContext(s.ctx, method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response from %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed with status %d: %s", url, resp.StatusCode, string(data))
+	}
+	if err := json.Unmarshal(data, into); err != nil {
+		return fmt.Errorf("unable to decode response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// publicKey returns the public key of a rsa or ec json web key.
+func (k azureKeyVaultKey) publicKey() (crypto.PublicKey, error) {
+	decode := func(v string) (*big.Int, error) {
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(v, "="))
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(data), nil
+	}
+	switch strings.TrimSuffix(k.KTY, "-HSM") {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode modulus: %w", err)
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.CRV {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.CRV)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode x coordinate: %w", err)
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode y coordinate: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KTY)
+	}
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signatures
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// GCPKMSScheme is the scheme of key uris of google cloud kms,
+	// e.g. "gcpkms://projects/<project>/locations/<location>/keyRings/<key ring>/cryptoKeys/<key>/cryptoKeyVersions/<version>".
+	GCPKMSScheme = "gcpkms"
+
+	// GCPKMSEndpointEnvName is the environment variable that overwrites the endpoint of the google cloud kms api.
+	// It is the same variable that is used by gcloud.
+	GCPKMSEndpointEnvName = "CLOUDSDK_API_ENDPOINT_OVERRIDES_CLOUDKMS"
+
+	gcpKMSDefaultEndpoint = "https://cloudkms.googleapis.com/"
+	gcpKMSScope           = "https://www.googleapis.com/auth/cloudkms"
+)
+
+// gcpKMSKeyVersionPattern matches the resource names of crypto key versions.
+var gcpKMSKeyVersionPattern = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+/cryptoKeyVersions/[^/]+$`)
+
+// gcpKMSSigner signs digests with an asymmetric crypto key version of google cloud kms.
+type gcpKMSSigner struct {
+	ctx       context.Context
+	client    *http.Client
+	endpoint  string
+	name      string
+	publicKey crypto.PublicKey
+}
+
+// newGCPKMSSigner creates a signer for a key uri of the form "gcpkms://<crypto key version resource name>".
+// The credentials are read from the application default credentials of the google sdk.
+func newGCPKMSSigner(ctx context.Context, httpClient *http.Client, keyURI string) (crypto.Signer, error) {
+	name := strings.TrimPrefix(keyURI, GCPKMSScheme+"://")
+	if !gcpKMSKeyVersionPattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid gcp kms key uri %q: expected %s://projects/<project>/locations/<location>/keyRings/<key ring>/cryptoKeys/<key>/cryptoKeyVersions/<version>", keyURI, GCPKMSScheme)
+	}
+	endpoint := os.Getenv(GCPKMSEndpointEnvName)
+	if len(endpoint) == 0 {
+		endpoint = gcpKMSDefaultEndpoint
+	}
+
+	clientCtx := ctx
+	if httpClient != nil {
+		clientCtx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	}
+	client, err := google.DefaultClient(clientCtx, gcpKMSScope)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find google application default credentials: %w", err)
+	}
+	s := &gcpKMSSigner{
+		ctx:      ctx,
+		client:   client,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		name:     name,
+	}
+
+	key := struct {
+		Pem string `json:"pem"`
+	}{}
+	if err := s.do(http.MethodGet, "publicKey", nil, &key); err != nil {
+		return nil, fmt.Errorf("unable to get public key of gcp kms key %q: %w", name, err)
+	}
+	publicKey, err := LoadPublicKey([]byte(key.Pem))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load public key of gcp kms key %q: %w", name, err)
+	}
+	s.publicKey = publicKey
+	return s, nil
+}
+
+func (s *gcpKMSSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *gcpKMSSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var digestName string
+	switch opts.HashFunc() {
+	case crypto.SHA256:
+		digestName = "sha256"
+	case crypto.SHA384:
+		digestName = "sha384"
+	case crypto.SHA512:
+		digestName = "sha512"
+	default:
+		return nil, fmt.Errorf("unsupported hash function %s", opts.HashFunc())
+	}
+	req := map[string]interface{}{
+		"digest": map[string]string{
+			digestName: base64.StdEncoding.EncodeToString(digest),
+		},
+	}
+	resp := struct {
+		Signature string `json:"signature"`
+	}{}
+	if err := s.do(http.MethodPost, ":asymmetricSign", req, &resp); err != nil {
+		return nil, fmt.Errorf("unable to sign with gcp kms key %q: %w", s.name, err)
+	}
+	return base64.StdEncoding.DecodeString(resp.Signature)
+}
+
+// do sends a request for the crypto key version.
+// The method is either a sub path (e.g. "publicKey") or a custom method (e.g. ":asymmetricSign").
+func (s *gcpKMSSigner) do(method, path string, body, into interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("unable to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	url := fmt.Sprintf("%s/v1/%s", s.endpoint, s.name)
+	if strings.HasPrefix(path, ":") {
+		url += path
+	} else {
+		url += "/" + path
+	}
+	req, err := http.NewRequestWithContext(s.ctx, method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read response from %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed with status %d: %s", url, resp.StatusCode, string(data))
+	}
+	if err := json.Unmarshal(data, into); err != nil {
+		return fmt.Errorf("unable to decode response from %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package signatures
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "signatures Test Suite")
+}
+
+var _ = Describe("kms providers", func() {
+
+	var (
+		oldEnv map[string]*string
+		key    *rsa.PrivateKey
+	)
+
+	setEnv := func(name, value string) {
+		if _, ok := oldEnv[name]; !ok {
+			if oldValue, ok := os.LookupEnv(name); ok {
+				oldEnv[name] = &oldValue
+			} else {
+				oldEnv[name] = nil
+			}
+		}
+		if len(value) == 0 {
+			Expect(os.Unsetenv(name)).To(Succeed())
+			return
+		}
+		Expect(os.Setenv(name, value)).To(Succeed())
+	}
+
+	// newClient returns a client for the tls server that sends the requests of all hosts to the server.
+	newClient := func(srv *httptest.Server) *http.Client {
+		httpClient := srv.Client()
+		httpClient.Transport = hostRewriter{
+			to:        strings.TrimPrefix(srv.URL, "https://"),
+			transport: httpClient.Transport,
+		}
+		return httpClient
+	}
+
+	// signAndVerify signs a component descriptor with the kms signer and verifies the signature with the rsa key.
+	signAndVerify := func(kmsSigner crypto.Signer) {
+		signer, err := NewCryptoSigner(kmsSigner, cdv2.MediaTypePEM)
+		Expect(err).ToNot(HaveOccurred())
+		verifier, err := cdv2Sign.CreateRSAVerifier(&key.PublicKey)
+		Expect(err).ToNot(HaveOccurred())
+		hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		cd := cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/component"
+		cd.Version = "v0.1.0"
+		Expect(cdv2Sign.SignComponentDescriptor(&cd, signer, *hasher, "test")).To(Succeed())
+		Expect(cdv2Sign.VerifySignedComponentDescriptor(&cd, verifier, "test")).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		oldEnv = map[string]*string{}
+		var err error
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		for name, value := range oldEnv {
+			if value == nil {
+				Expect(os.Unsetenv(name)).To(Succeed())
+				continue
+			}
+			Expect(os.Setenv(name, *value)).To(Succeed())
+		}
+	})
+
+	It("should register the built-in kms providers", func() {
+		Expect(KMSSchemes()).To(Equal([]string{AWSKMSScheme, AzureKMSScheme, GCPKMSScheme, VaultKMSScheme}))
+		Expect(RegisterKMSProvider(AWSKMSScheme, newVaultSigner)).ToNot(Succeed())
+	})
+
+	It("should sign with an aws kms key", func() {
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			Expect(req.Header.Get("Authorization")).To(ContainSubstring("Credential=AKID/"))
+			body := map[string]string{}
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+			Expect(body["KeyId"]).To(Equal("alias/mykey"))
+			w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+			switch req.Header.Get("X-Amz-Target") {
+			case "TrentService.GetPublicKey":
+				publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(json.NewEncoder(w).Encode(map[string]interface{}{
+					"KeyId":     "arn:aws:kms:eu-west-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+					"PublicKey": publicKey,
+				})).To(Succeed())
+			case "TrentService.Sign":
+				Expect(body["MessageType"]).To(Equal("DIGEST"))
+				Expect(body["SigningAlgorithm"]).To(Equal("RSASSA_PKCS1_V1_5_SHA_256"))
+				digest, err := base64.StdEncoding.DecodeString(body["Message"])
+				Expect(err).ToNot(HaveOccurred())
+				sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(json.NewEncoder(w).Encode(map[string]interface{}{
+					"Signature": sig,
+				})).To(Succeed())
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		}))
+		defer srv.Close()
+		dir, err := os.MkdirTemp("", "kms-")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		setEnv("AWS_ACCESS_KEY_ID", "AKID")
+		setEnv("AWS_SECRET_ACCESS_KEY", "secret")
+		setEnv("AWS_REGION", "eu-west-1")
+		setEnv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+		setEnv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+		// the ca bundle cannot be added to the tls client of the test server
+		setEnv("AWS_CA_BUNDLE", "")
+
+		kmsSigner, err := newAWSKMSSigner(context.TODO(), newClient(srv), "awskms:///alias/mykey")
+		Expect(err).ToNot(HaveOccurred())
+		signAndVerify(kmsSigner)
+
+		_, err = newAWSKMSSigner(context.TODO(), newClient(srv), "awskms://mykey")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should sign with a gcp kms key", func() {
+		keyName := "projects/my-project/locations/global/keyRings/my-ring/cryptoKeys/my-key/cryptoKeyVersions/1"
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch req.URL.Path {
+			case "/token":
+				_, _ = w.Write([]byte(`{"access_token": "gcp-token", "token_type": "Bearer", "expires_in": 3600}`))
+				return
+			case "/v1/" + keyName + "/publicKey":
+				Expect(req.Header.Get("Authorization")).To(Equal("Bearer gcp-token"))
+				publicKey, err := MarshalPublicKeyPEM(&key.PublicKey)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(json.NewEncoder(w).Encode(map[string]string{
+					"pem":       string(publicKey),
+					"algorithm": "RSA_SIGN_PKCS1_2048_SHA256",
+				})).To(Succeed())
+			case "/v1/" + keyName + ":asymmetricSign":
+				Expect(req.Header.Get("Authorization")).To(Equal("Bearer gcp-token"))
+				body := struct {
+					Digest map[string]string `json:"digest"`
+				}{}
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+				digest, err := base64.StdEncoding.DecodeString(body.Digest["sha256"])
+				Expect(err).ToNot(HaveOccurred())
+				sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(json.NewEncoder(w).Encode(map[string]string{
+					"signature": base64.StdEncoding.EncodeToString(sig),
+				})).To(Succeed())
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer srv.Close()
+
+		saKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		credsData, err := json.Marshal(map[string]string{
+			"type":         "service_account",
+			"client_email": "sa@project.iam.gserviceaccount.com",
+			"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(saKey)})),
+			"token_uri":    srv.URL + "/token",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		dir, err := os.MkdirTemp("", "kms-")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		credsFile := filepath.Join(dir, "credentials.json")
+		Expect(os.WriteFile(credsFile, credsData, os.ModePerm)).To(Succeed())
+		setEnv("GOOGLE_APPLICATION_CREDENTIALS", credsFile)
+
+		kmsSigner, err := newGCPKMSSigner(context.TODO(), newClient(srv), GCPKMSScheme+"://"+keyName)
+		Expect(err).ToNot(HaveOccurred())
+		signAndVerify(kmsSigner)
+
+		_, err = newGCPKMSSigner(context.TODO(), newClient(srv), GCPKMSScheme+"://projects/my-project/locations/global/keyRings/my-ring/cryptoKeys/my-key")
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("azure key vault", func() {
+
+		// newKeyVault creates a server for the azure active directory and a key vault that contains the key "mykey".
+		newKeyVault := func(jwk map[string]string, sign func(alg string, digest []byte) []byte) *httptest.Server {
+			var srv *httptest.Server
+			srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch req.URL.Path {
+				case "/common/discovery/instance":
+					_ = json.NewEncoder(w).Encode(map[string]string{
+						"tenant_discovery_endpoint": srv.URL + "/tenant/v2.0/.well-known/openid-configuration",
+					})
+				case "/tenant/v2.0/.well-known/openid-configuration":
+					_ = json.NewEncoder(w).Encode(map[string]string{
+						"authorization_endpoint": srv.URL + "/tenant/oauth2/v2.0/authorize",
+						"token_endpoint":         srv.URL + "/tenant/oauth2/v2.0/token",
+						"issuer":                 srv.URL + "/tenant/v2.0",
+					})
+				case "/tenant/oauth2/v2.0/token":
+					Expect(req.ParseForm()).To(Succeed())
+					Expect(req.PostForm.Get("client_secret")).To(Equal("secret"))
+					Expect(req.PostForm.Get("scope")).To(ContainSubstring(azureKeyVaultScope))
+					_, _ = w.Write([]byte(`{"access_token": "aad-token", "expires_in": 3600, "token_type": "Bearer"}`))
+				case "/keys/mykey":
+					Expect(req.Header.Get("Authorization")).To(Equal("Bearer aad-token"))
+					jwk["kid"] = "https://myvault.vault.azure.net/keys/mykey/v1"
+					_ = json.NewEncoder(w).Encode(map[string]interface{}{"key": jwk})
+				case "/keys/mykey/v1/sign":
+					Expect(req.Header.Get("Authorization")).To(Equal("Bearer aad-token"))
+					body := map[string]string{}
+					Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+					digest, err := base64.RawURLEncoding.DecodeString(body["value"])
+					Expect(err).ToNot(HaveOccurred())
+					_ = json.NewEncoder(w).Encode(map[string]string{
+						"kid":   "https://myvault.vault.azure.net/keys/mykey/v1",
+						"value": base64.RawURLEncoding.EncodeToString(sign(body["alg"], digest)),
+					})
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			return srv
+		}
+
+		BeforeEach(func() {
+			setEnv("AZURE_TENANT_ID", "tenant")
+			setEnv("AZURE_CLIENT_ID", "client")
+			setEnv("AZURE_CLIENT_SECRET", "secret")
+		})
+
+		It("should sign with an rsa key", func() {
+			srv := newKeyVault(map[string]string{
+				"kty": "RSA-HSM",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}, func(alg string, digest []byte) []byte {
+				Expect(alg).To(Equal("RS256"))
+				sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest)
+				Expect(err).ToNot(HaveOccurred())
+				return sig
+			})
+			defer srv.Close()
+
+			kmsSigner, err := newAzureKMSSigner(context.TODO(), newClient(srv), "azurekms://myvault.vault.azure.net/mykey")
+			Expect(err).ToNot(HaveOccurred())
+			signAndVerify(kmsSigner)
+		})
+
+		It("should sign with an ec key", func() {
+			ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			srv := newKeyVault(map[string]string{
+				"kty": "EC",
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+				"y":   base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+			}, func(alg string, digest []byte) []byte {
+				Expect(alg).To(Equal("ES256"))
+				r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest)
+				Expect(err).ToNot(HaveOccurred())
+				return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+			})
+			defer srv.Close()
+
+			kmsSigner, err := newAzureKMSSigner(context.TODO(), newClient(srv), "azurekms://myvault.vault.azure.net/mykey")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(kmsSigner.Public()).To(Equal(&ecKey.PublicKey))
+			digest := sha256.Sum256([]byte("data"))
+			sig, err := kmsSigner.Sign(rand.Reader, digest[:], crypto.SHA256)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ecdsa.VerifyASN1(&ecKey.PublicKey, digest[:], sig)).To(BeTrue())
+		})
+
+	})
+
+})
+
+// hostRewriter is a transport that sends the requests of all hosts to one host.
+type hostRewriter struct {
+	to        string
+	transport http.RoundTripper
+}
+
+func (r hostRewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Host = r.to
+	return r.transport.RoundTrip(req)
+}
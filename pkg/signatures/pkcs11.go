@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signatures
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// PKCS11PinEnvName is the environment variable that contains the user pin of the pkcs#11 token.
+const PKCS11PinEnvName = "PKCS11_PIN"
+
+// PKCS11Config describes a key on a pkcs#11 token, e.g. a YubiKey or a HSM.
+type PKCS11Config struct {
+	// ModulePath is the path to the pkcs#11 module (shared library) of the token vendor.
+	ModulePath string
+	// Slot is the id of the slot that contains the token.
+	Slot uint
+	// Pin is the user pin that is used to log in to the token.
+	Pin string
+	// KeyLabel is the label of the private key on the token.
+	// The token is expected to contain exactly one private key if no label is given.
+	KeyLabel string
+}
+
+// Validate validates the pkcs#11 config.
+func (c PKCS11Config) Validate() error {
+	if len(c.ModulePath) == 0 {
+		return errors.New("the path to the pkcs#11 module must be provided")
+	}
+	if _, err := os.Stat(c.ModulePath); err != nil {
+		return fmt.Errorf("unable to find pkcs#11 module %q: %w", c.ModulePath, err)
+	}
+	if len(c.Pin) == 0 {
+		return fmt.Errorf("the pin of the pkcs#11 token must be provided with --pkcs11-pin or %s", PKCS11PinEnvName)
+	}
+	return nil
+}
+
+// PKCS11ProviderFunc creates a signer for the key on a pkcs#11 token.
+// The private key is expected to never leave the token, all signing operations are delegated to it.
+type PKCS11ProviderFunc func(ctx context.Context, config PKCS11Config) (crypto.Signer, error)
+
+var (
+	pkcs11ProviderMux sync.RWMutex
+	pkcs11Provider    PKCS11ProviderFunc
+)
+
+// RegisterPKCS11Provider registers a provider that is used instead of loading the pkcs#11 module of the config.
+// By default the pkcs#11 module is loaded, which requires a component-cli that is built with cgo.
+func RegisterPKCS11Provider(provider PKCS11ProviderFunc) error {
+	if provider == nil {
+		return errors.New("pkcs#11 provider must not be nil")
+	}
+	pkcs11ProviderMux.Lock()
+	defer pkcs11ProviderMux.Unlock()
+	if pkcs11Provider != nil {
+		return errors.New("a pkcs#11 provider is already registered")
+	}
+	pkcs11Provider = provider
+	return nil
+}
+
+// NewPKCS11Signer creates a signer for the rsa key on the pkcs#11 token.
+// The returned signer implements io.Closer and should be closed to log out of the token.
+func NewPKCS11Signer(ctx context.Context, config PKCS11Config) (crypto.Signer, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	pkcs11ProviderMux.RLock()
+	provider := pkcs11Provider
+	pkcs11ProviderMux.RUnlock()
+	if provider == nil {
+		provider = newPKCS11ModuleSigner
+	}
+	signer, err := provider(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open key on pkcs#11 token in slot %d: %w", config.Slot, err)
+	}
+	return signer, nil
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build cgo
+// +build cgo
+
+package signatures
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// pkcs1DigestInfoPrefixes are the asn.1 DigestInfo prefixes of the hash functions.
+// The CKM_RSA_PKCS mechanism expects the DigestInfo of the digest and not the digest itself.
+var pkcs1DigestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// pkcs11Signer signs digests with a rsa key on a pkcs#11 token.
+type pkcs11Signer struct {
+	mux       sync.Mutex
+	ctx       *pkcs11.Ctx
+	session   pkcs11.SessionHandle
+	key       pkcs11.ObjectHandle
+	publicKey *rsa.PublicKey
+}
+
+// newPKCS11ModuleSigner loads the pkcs#11 module and opens the rsa private key on the token.
+func newPKCS11ModuleSigner(_ context.Context, config PKCS11Config) (crypto.Signer, error) {
+	p := pkcs11.New(config.ModulePath)
+	if p == nil {
+		return nil, fmt.Errorf("unable to load pkcs#11 module %q", config.ModulePath)
+	}
+	if err := p.Initialize(); err != nil {
+		p.Destroy()
+		return nil, fmt.Errorf("unable to initialize pkcs#11 module: %w", err)
+	}
+	s := &pkcs11Signer{
+		ctx: p,
+	}
+	if err := s.open(config); err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *pkcs11Signer) open(config PKCS11Config) error {
+	session, err := s.ctx.OpenSession(config.Slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("unable to open session: %w", err)
+	}
+	s.session = session
+	if err := s.ctx.Login(session, pkcs11.CKU_USER, config.Pin); err != nil {
+		return fmt.Errorf("unable to log in: %w", err)
+	}
+
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+	}
+	if len(config.KeyLabel) != 0 {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, config.KeyLabel))
+	}
+	if err := s.ctx.FindObjectsInit(session, template); err != nil {
+		return fmt.Errorf("unable to find private keys: %w", err)
+	}
+	keys, _, err := s.ctx.FindObjects(session, 2)
+	if finalErr := s.ctx.FindObjectsFinal(session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return fmt.Errorf("unable to find private keys: %w", err)
+	}
+	switch {
+	case len(keys) == 0 && len(config.KeyLabel) != 0:
+		return fmt.Errorf("no rsa private key with label %q found", config.KeyLabel)
+	case len(keys) == 0:
+		return errors.New("no rsa private key found")
+	case len(keys) > 1:
+		return errors.New("more than one rsa private key found, the key has to be selected with its label")
+	}
+	s.key = keys[0]
+
+	attrs, err := s.ctx.GetAttributeValue(session, s.key, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to read public key of the private key: %w", err)
+	}
+	s.publicKey = &rsa.PublicKey{}
+	for _, attr := range attrs {
+		switch attr.Type {
+		case pkcs11.CKA_MODULUS:
+			s.publicKey.N = new(big.Int).SetBytes(attr.Value)
+		case pkcs11.CKA_PUBLIC_EXPONENT:
+			s.publicKey.E = int(new(big.Int).SetBytes(attr.Value).Int64())
+		}
+	}
+	if s.publicKey.N == nil || s.publicKey.E == 0 {
+		return errors.New("the private key has no public modulus and exponent")
+	}
+	return nil
+}
+
+func (s *pkcs11Signer) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		return nil, errors.New("rsa pss signatures are not supported")
+	}
+	prefix, ok := pkcs1DigestInfoPrefixes[opts.HashFunc()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported hash function %s", opts.HashFunc())
+	}
+	if len(digest) != opts.HashFunc().Size() {
+		return nil, fmt.Errorf("invalid digest length %d for hash function %s", len(digest), opts.HashFunc())
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil)}
+	if err := s.ctx.SignInit(s.session, mechanism, s.key); err != nil {
+		return nil, fmt.Errorf("unable to initialize signing: %w", err)
+	}
+	signature, err := s.ctx.Sign(s.session, append(append([]byte{}, prefix...), digest...))
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign: %w", err)
+	}
+	return signature, nil
+}
+
+// Close logs out of the token and unloads the pkcs#11 module.
+func (s *pkcs11Signer) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.session != 0 {
+		_ = s.ctx.Logout(s.session)
+		_ = s.ctx.CloseSession(s.session)
+	}
+	err := s.ctx.Finalize()
+	s.ctx.Destroy()
+	return err
+}
@@ -0,0 +1,19 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !cgo
+// +build !cgo
+
+package signatures
+
+import (
+	"context"
+	"crypto"
+	"errors"
+)
+
+// newPKCS11ModuleSigner returns an error as pkcs#11 modules can only be loaded with cgo.
+func newPKCS11ModuleSigner(_ context.Context, _ PKCS11Config) (crypto.Signer, error) {
+	return nil, errors.New("pkcs#11 modules cannot be loaded as the component-cli is built without cgo, it has to be built with CGO_ENABLED=1")
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build cgo
+// +build cgo
+
+package signatures
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("pkcs#11", func() {
+
+	It("should prefix the digest with the DigestInfo of its hash function", func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		for hash, prefix := range pkcs1DigestInfoPrefixes {
+			h := hash.New()
+			_, _ = h.Write([]byte("data"))
+			digest := h.Sum(nil)
+
+			expected, err := rsa.SignPKCS1v15(rand.Reader, key, hash, digest)
+			Expect(err).ToNot(HaveOccurred())
+			// CKM_RSA_PKCS only pads the data like a pkcs#1 v1.5 signature without hash function
+			actual, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.Hash(0), append(append([]byte{}, prefix...), digest...))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(expected), hash.String())
+		}
+	})
+
+})
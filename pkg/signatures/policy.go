@@ -0,0 +1,246 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signatures
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/components"
+)
+
+// VerificationPolicy defines the signatures that a component descriptor must have to be trusted
+// and the repositories that the component and its transitively referenced components may come from.
+type VerificationPolicy struct {
+	// RequiredSignatures is the number of signatures of the policy that must be valid.
+	// Defaults to the number of signatures of the policy.
+	RequiredSignatures int `json:"requiredSignatures,omitempty"`
+	// Signatures maps the names of signatures to the public keys that are allowed to create them.
+	Signatures []SignaturePolicy `json:"signatures"`
+	// AllowedRepositories are patterns of the base urls of the repository contexts that the component
+	// and all transitively referenced components may come from. The patterns are matched with path.Match.
+	// All repositories are allowed if empty.
+	AllowedRepositories []string `json:"allowedRepositories,omitempty"`
+	// SigningContext defines the requirements for the signing contexts of the signatures.
+	// Existing signing contexts are always verified and their validity period is always enforced.
+	SigningContext *SigningContextPolicy `json:"signingContext,omitempty"`
+}
+
+// SigningContextPolicy defines the requirements for the signing contexts of the signatures.
+type SigningContextPolicy struct {
+	// Required rejects signatures without signing context.
+	Required bool `json:"required,omitempty"`
+	// Audience must be one of the audiences of the signing contexts, e.g. the name of the landscape that verifies the component.
+	// Signing contexts are required if an audience is set.
+	Audience string `json:"audience,omitempty"`
+}
+
+// SignaturePolicy defines the public keys of a signature.
+type SignaturePolicy struct {
+	// Name is the name of the signature in the component descriptor.
+	Name string `json:"name"`
+	// PublicKeys are the paths to the PEM encoded rsa public keys that are allowed to create the signature.
+	// Relative paths are relative to the directory of the policy file.
+	PublicKeys []string `json:"publicKeys"`
+
+	keys []*rsa.PublicKey
+}
+
+// LoadVerificationPolicy reads and validates the policy file at the given path and loads its public keys.
+func LoadVerificationPolicy(fs vfs.FileSystem, policyPath string) (*VerificationPolicy, error) {
+	data, err := vfs.ReadFile(fs, policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read policy %q: %w", policyPath, err)
+	}
+	policy := &VerificationPolicy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("unable to decode policy %q: %w", policyPath, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %q: %w", policyPath, err)
+	}
+
+	for i, sig := range policy.Signatures {
+		for _, keyPath := range sig.PublicKeys {
+			if !filepath.IsAbs(keyPath) {
+				keyPath = filepath.Join(filepath.Dir(policyPath), keyPath)
+			}
+			data, err := vfs.ReadFile(fs, keyPath)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read public key %q of signature %s: %w", keyPath, sig.Name, err)
+			}
+			key, err := LoadPublicKey(data)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load public key %q of signature %s: %w", keyPath, sig.Name, err)
+			}
+			rsaKey, ok := key.(*rsa.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("public key %q of signature %s is not a rsa key", keyPath, sig.Name)
+			}
+			policy.Signatures[i].keys = append(policy.Signatures[i].keys, rsaKey)
+		}
+	}
+	return policy, nil
+}
+
+// Validate validates the policy.
+func (p *VerificationPolicy) Validate() error {
+	if len(p.Signatures) == 0 {
+		return errors.New("at least one signature must be defined")
+	}
+	names := map[string]bool{}
+	for i, sig := range p.Signatures {
+		if len(sig.Name) == 0 {
+			return fmt.Errorf("signatures[%d]: a name must be provided", i)
+		}
+		if names[sig.Name] {
+			return fmt.Errorf("signatures[%d]: duplicate signature %s", i, sig.Name)
+		}
+		names[sig.Name] = true
+		if len(sig.PublicKeys) == 0 {
+			return fmt.Errorf("signatures[%d]: at least one public key must be provided for signature %s", i, sig.Name)
+		}
+	}
+	if p.RequiredSignatures < 0 || p.RequiredSignatures > len(p.Signatures) {
+		return fmt.Errorf("the number of required signatures must be between 1 and %d", len(p.Signatures))
+	}
+	for _, pattern := range p.AllowedRepositories {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid repository pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// VerifySignatures verifies the signatures of the component descriptor with the public keys of the policy.
+// The names of the valid signatures are returned if at least the required number of signatures is valid.
+func (p *VerificationPolicy) VerifySignatures(cd *cdv2.ComponentDescriptor) ([]string, error) {
+	required := p.RequiredSignatures
+	if required == 0 {
+		required = len(p.Signatures)
+	}
+
+	var (
+		valid    []string
+		failures []string
+	)
+	now := time.Now()
+	for _, sig := range p.Signatures {
+		var err error
+		for _, key := range sig.keys {
+			var verifier *cdv2Sign.RSAVerifier
+			verifier, err = cdv2Sign.CreateRSAVerifier(key)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create rsa verifier for signature %s: %w", sig.Name, err)
+			}
+			if err = cdv2Sign.VerifySignedComponentDescriptor(cd, verifier, sig.Name); err != nil {
+				continue
+			}
+			if err = p.verifySigningContext(cd, verifier, sig.Name, now); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", sig.Name, err.Error()))
+			continue
+		}
+		valid = append(valid, sig.Name)
+	}
+	if len(valid) < required {
+		return valid, fmt.Errorf("%d of %d required signatures are valid: %s", len(valid), required, strings.Join(failures, "; "))
+	}
+	return valid, nil
+}
+
+// verifySigningContext verifies the signing context of the signature and checks its claims.
+func (p *VerificationPolicy) verifySigningContext(cd *cdv2.ComponentDescriptor, verifier cdv2Sign.Verifier, signatureName string, now time.Time) error {
+	signingCtx, err := GetSigningContext(cd, signatureName)
+	if err != nil {
+		return err
+	}
+	if signingCtx == nil {
+		if p.SigningContext != nil && (p.SigningContext.Required || len(p.SigningContext.Audience) != 0) {
+			return errors.New("signature has no signing context")
+		}
+		return nil
+	}
+	signature, err := cdv2Sign.GetSignatureByName(cd, signatureName)
+	if err != nil {
+		return err
+	}
+	if err := signingCtx.Verify(verifier, *cd, *signature); err != nil {
+		return err
+	}
+	audience := ""
+	if p.SigningContext != nil {
+		audience = p.SigningContext.Audience
+	}
+	return signingCtx.Context.Check(now, audience)
+}
+
+// IsRepositoryAllowed checks whether components may come from the repository with the given base url.
+func (p *VerificationPolicy) IsRepositoryAllowed(baseURL string) bool {
+	if len(p.AllowedRepositories) == 0 {
+		return true
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	for _, pattern := range p.AllowedRepositories {
+		if ok, _ := path.Match(strings.TrimSuffix(pattern, "/"), baseURL); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckRepositories checks that the component and all transitively referenced components come from allowed repositories.
+// Referenced components are resolved in the effective repository context of the referencing component.
+func (p *VerificationPolicy) CheckRepositories(ctx context.Context, resolver ctf.ComponentResolver, cd *cdv2.ComponentDescriptor) error {
+	if len(p.AllowedRepositories) == 0 {
+		return nil
+	}
+	return p.checkRepositories(ctx, resolver, cd, map[string]bool{})
+}
+
+func (p *VerificationPolicy) checkRepositories(ctx context.Context, resolver ctf.ComponentResolver, cd *cdv2.ComponentDescriptor, visited map[string]bool) error {
+	id := cd.Name + ":" + cd.Version
+	if visited[id] {
+		return nil
+	}
+	visited[id] = true
+
+	effective := cd.GetEffectiveRepositoryContext()
+	if effective == nil {
+		return fmt.Errorf("component %s has no repository context", id)
+	}
+	repoCtx, err := components.GetOCIRepositoryContext(effective)
+	if err != nil {
+		return fmt.Errorf("unable to get repository context of component %s: %w", id, err)
+	}
+	if !p.IsRepositoryAllowed(repoCtx.BaseURL) {
+		return fmt.Errorf("component %s comes from repository %s which is not allowed by the policy", id, repoCtx.BaseURL)
+	}
+
+	for _, ref := range cd.ComponentReferences {
+		refCd, err := resolver.Resolve(ctx, &repoCtx, ref.ComponentName, ref.Version)
+		if err != nil {
+			return fmt.Errorf("unable to resolve component reference %s of %s: %w", ref.Name, id, err)
+		}
+		if err := p.checkRepositories(ctx, resolver, refCd, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
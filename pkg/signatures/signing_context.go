@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package signatures
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+)
+
+// SigningContextLabelName is the name of the component descriptor label that contains the signing contexts of the signatures.
+const SigningContextLabelName = "signatures.gardener.cloud/signing-context"
+
+// SigningClaims restrict the validity of a signature.
+type SigningClaims struct {
+	// NotBefore is the time from which on the signature is valid.
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	// ExpiresAt is the time from which on the signature is no longer valid.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Audience are the intended audiences of the signature, e.g. the landscapes the component may be deployed to.
+	Audience []string `json:"audience,omitempty"`
+}
+
+// SigningContext binds signing claims to a signature of a component descriptor.
+type SigningContext struct {
+	// Signature is the name of the signature.
+	Signature string `json:"signature"`
+	// ComponentDigest is the value of the digest of the signature that the claims are bound to.
+	ComponentDigest string `json:"componentDigest"`
+	SigningClaims   `json:",inline"`
+}
+
+// SignedSigningContext is a signing context that is signed with the key of its signature.
+// Labels are not part of the normalised component descriptor,
+// so that the claims have to be signed separately to protect them against modifications.
+type SignedSigningContext struct {
+	Context   SigningContext     `json:"context"`
+	Digest    cdv2.DigestSpec    `json:"digest"`
+	Signature cdv2.SignatureSpec `json:"signature"`
+}
+
+// AddSigningContext signs the claims for the signature with the given name and adds them to the
+// signing context label of the component descriptor.
+// The component descriptor must already be signed, an existing signing context of the signature is replaced.
+func AddSigningContext(cd *cdv2.ComponentDescriptor, signer cdv2Sign.Signer, hasher cdv2Sign.Hasher, signatureName string, claims SigningClaims) error {
+	signature, err := cdv2Sign.GetSignatureByName(cd, signatureName)
+	if err != nil {
+		return fmt.Errorf("unable to get signature: %w", err)
+	}
+	signingCtx := SigningContext{
+		Signature:       signatureName,
+		ComponentDigest: signature.Digest.Value,
+		SigningClaims:   claims,
+	}
+	digest, err := hashSigningContext(signingCtx, hasher)
+	if err != nil {
+		return err
+	}
+	signatureSpec, err := signer.Sign(*cd, *digest)
+	if err != nil {
+		return fmt.Errorf("unable to sign signing context: %w", err)
+	}
+
+	contexts, err := GetSigningContexts(cd)
+	if err != nil {
+		return err
+	}
+	signed := SignedSigningContext{
+		Context:   signingCtx,
+		Digest:    *digest,
+		Signature: *signatureSpec,
+	}
+	replaced := false
+	for i, c := range contexts {
+		if c.Context.Signature == signatureName {
+			contexts[i] = signed
+			replaced = true
+		}
+	}
+	if !replaced {
+		contexts = append(contexts, signed)
+	}
+
+	value, err := json.Marshal(contexts)
+	if err != nil {
+		return fmt.Errorf("unable to encode signing contexts: %w", err)
+	}
+	for i, label := range cd.Labels {
+		if label.Name == SigningContextLabelName {
+			cd.Labels[i].Value = value
+			return nil
+		}
+	}
+	cd.Labels = append(cd.Labels, cdv2.Label{
+		Name:  SigningContextLabelName,
+		Value: value,
+	})
+	return nil
+}
+
+// GetSigningContexts returns the signing contexts of all signatures of the component descriptor.
+func GetSigningContexts(cd *cdv2.ComponentDescriptor) ([]SignedSigningContext, error) {
+	contexts := []SignedSigningContext{}
+	value, ok := cd.Labels.Get(SigningContextLabelName)
+	if !ok {
+		return contexts, nil
+	}
+	if err := json.Unmarshal(value, &contexts); err != nil {
+		return nil, fmt.Errorf("unable to decode signing context label: %w", err)
+	}
+	return contexts, nil
+}
+
+// GetSigningContext returns the signing context of the signature with the given name.
+// Nil is returned if the signature has no signing context.
+func GetSigningContext(cd *cdv2.ComponentDescriptor, signatureName string) (*SignedSigningContext, error) {
+	contexts, err := GetSigningContexts(cd)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range contexts {
+		if c.Context.Signature == signatureName {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+// Verify verifies that the signing context is signed by the verifier and bound to the given signature.
+func (c SignedSigningContext) Verify(verifier cdv2Sign.Verifier, cd cdv2.ComponentDescriptor, signature cdv2.Signature) error {
+	if c.Context.Signature != signature.Name || c.Context.ComponentDigest != signature.Digest.Value {
+		return errors.New("signing context is not bound to the signature")
+	}
+	hasher, err := cdv2Sign.HasherForName(c.Digest.HashAlgorithm)
+	if err != nil {
+		return fmt.Errorf("unable to create hasher for %s: %w", c.Digest.HashAlgorithm, err)
+	}
+	digest, err := hashSigningContext(c.Context, *hasher)
+	if err != nil {
+		return err
+	}
+	if digest.Value != c.Digest.Value {
+		return errors.New("signing context does not match the digest of its signature")
+	}
+	if err := verifier.Verify(cd, cdv2.Signature{Name: signature.Name, Digest: c.Digest, Signature: c.Signature}); err != nil {
+		return fmt.Errorf("unable to verify signing context: %w", err)
+	}
+	return nil
+}
+
+// Check checks that the claims are valid at the given time.
+// If an audience is given, it must be one of the audiences of the claims.
+func (c SigningClaims) Check(now time.Time, audience string) error {
+	if c.NotBefore != nil && now.Before(*c.NotBefore) {
+		return fmt.Errorf("signature is not valid before %s", c.NotBefore.Format(time.RFC3339))
+	}
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return fmt.Errorf("signature expired at %s", c.ExpiresAt.Format(time.RFC3339))
+	}
+	if len(audience) == 0 {
+		return nil
+	}
+	for _, aud := range c.Audience {
+		if aud == audience {
+			return nil
+		}
+	}
+	return fmt.Errorf("signature is not intended for audience %q", audience)
+}
+
+func hashSigningContext(signingCtx SigningContext, hasher cdv2Sign.Hasher) (*cdv2.DigestSpec, error) {
+	data, err := json.Marshal(signingCtx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode signing context: %w", err)
+	}
+	hasher.HashFunction.Reset()
+	if _, err := hasher.HashFunction.Write(data); err != nil {
+		return nil, fmt.Errorf("unable to hash signing context: %w", err)
+	}
+	return &cdv2.DigestSpec{
+		HashAlgorithm:          hasher.AlgorithmName,
+		NormalisationAlgorithm: string(cdv2.JsonNormalisationV1),
+		Value:                  hex.EncodeToString(hasher.HashFunction.Sum(nil)),
+	}, nil
+}
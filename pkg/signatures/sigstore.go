@@ -0,0 +1,275 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signatures
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultFulcioURL is the url of the public fulcio instance of the sigstore project.
+	DefaultFulcioURL = "https://fulcio.sigstore.dev"
+	// DefaultRekorURL is the url of the public rekor instance of the sigstore project.
+	DefaultRekorURL = "https://rekor.sigstore.dev"
+)
+
+// RekorClient uploads signatures to a rekor transparency log.
+type RekorClient struct {
+	// URL is the base url of the rekor instance.
+	URL string
+	// HTTPClient is the http client that is used for requests. The default client is used if nil.
+	HTTPClient *http.Client
+}
+
+// RekorBundle is the proof of the inclusion of a signature in the transparency log, as it is stored by cosign.
+type RekorBundle struct {
+	// SignedEntryTimestamp is the signature of the transparency log over the payload.
+	SignedEntryTimestamp []byte             `json:"SignedEntryTimestamp"`
+	Payload              RekorBundlePayload `json:"Payload"`
+}
+
+// RekorBundlePayload describes the log entry of a signature.
+// The fields are ordered alphabetically so that the json encoding is canonical.
+type RekorBundlePayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// rekorEntry is the hashedrekord log entry of a signature.
+type rekorEntry struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Spec       rekorEntrySpec `json:"spec"`
+}
+
+type rekorEntrySpec struct {
+	Data struct {
+		Hash struct {
+			Algorithm string `json:"algorithm"`
+			Value     string `json:"value"`
+		} `json:"hash"`
+	} `json:"data"`
+	Signature struct {
+		Content   string `json:"content"`
+		PublicKey struct {
+			Content string `json:"content"`
+		} `json:"publicKey"`
+	} `json:"signature"`
+}
+
+// rekorLogEntry is a log entry as returned by the rekor api.
+type rekorLogEntry struct {
+	RekorBundlePayload
+	Verification struct {
+		SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+	} `json:"verification"`
+}
+
+// UploadHashedRekord uploads the signature of a sha256 digest to the transparency log.
+// The verification material is either a PEM encoded public key or certificate.
+func (c *RekorClient) UploadHashedRekord(ctx context.Context, hash, signature, verificationMaterial []byte) (*RekorBundle, error) {
+	entry := rekorEntry{
+		APIVersion: "0.0.1",
+		Kind:       "hashedrekord",
+	}
+	entry.Spec.Data.Hash.Algorithm = "sha256"
+	entry.Spec.Data.Hash.Value = hex.EncodeToString(hash)
+	entry.Spec.Signature.Content = base64.StdEncoding.EncodeToString(signature)
+	entry.Spec.Signature.PublicKey.Content = base64.StdEncoding.EncodeToString(verificationMaterial)
+
+	respData, err := postJSON(ctx, c.HTTPClient, strings.TrimSuffix(c.URL, "/")+"/api/v1/log/entries", "", entry)
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]rekorLogEntry{}
+	if err := json.Unmarshal(respData, &entries); err != nil {
+		return nil, fmt.Errorf("unable to decode rekor response: %w", err)
+	}
+	for _, logEntry := range entries {
+		return &RekorBundle{
+			SignedEntryTimestamp: logEntry.Verification.SignedEntryTimestamp,
+			Payload:              logEntry.RekorBundlePayload,
+		}, nil
+	}
+	return nil, errors.New("rekor response contains no log entry")
+}
+
+// Verify verifies the signed entry timestamp of the bundle with the public key of the transparency log
+// and checks that the log entry contains the given signature.
+func (b *RekorBundle) Verify(rekorPublicKey crypto.PublicKey, signature []byte) error {
+	key, ok := rekorPublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unsupported rekor public key type %T", rekorPublicKey)
+	}
+	canonicalPayload, err := json.Marshal(b.Payload)
+	if err != nil {
+		return fmt.Errorf("unable to marshal rekor bundle payload: %w", err)
+	}
+	hash := sha256.Sum256(canonicalPayload)
+	if !ecdsa.VerifyASN1(key, hash[:], b.SignedEntryTimestamp) {
+		return errors.New("invalid signed entry timestamp of the rekor bundle")
+	}
+
+	body, err := base64.StdEncoding.DecodeString(b.Payload.Body)
+	if err != nil {
+		return fmt.Errorf("unable to decode rekor bundle body: %w", err)
+	}
+	entry := rekorEntry{}
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return fmt.Errorf("unable to decode rekor log entry: %w", err)
+	}
+	if entry.Spec.Signature.Content != base64.StdEncoding.EncodeToString(signature) {
+		return errors.New("the rekor log entry does not contain the signature")
+	}
+	return nil
+}
+
+// FulcioClient requests short-lived signing certificates from a fulcio certificate authority.
+type FulcioClient struct {
+	// URL is the base url of the fulcio instance.
+	URL string
+	// HTTPClient is the http client that is used for requests. The default client is used if nil.
+	HTTPClient *http.Client
+}
+
+type fulcioCertificateRequest struct {
+	Credentials struct {
+		OIDCIdentityToken string `json:"oidcIdentityToken"`
+	} `json:"credentials"`
+	PublicKeyRequest struct {
+		PublicKey struct {
+			Algorithm string `json:"algorithm"`
+			Content   string `json:"content"`
+		} `json:"publicKey"`
+		ProofOfPossession string `json:"proofOfPossession"`
+	} `json:"publicKeyRequest"`
+}
+
+type fulcioCertificateChain struct {
+	Chain struct {
+		Certificates []string `json:"certificates"`
+	} `json:"chain"`
+}
+
+type fulcioCertificateResponse struct {
+	SignedCertificateEmbeddedSct *fulcioCertificateChain `json:"signedCertificateEmbeddedSct"`
+	SignedCertificateDetachedSct *fulcioCertificateChain `json:"signedCertificateDetachedSct"`
+}
+
+// RequestCertificate requests a signing certificate for the public key of the ecdsa signer.
+// The identity of the certificate is the subject of the oidc identity token.
+// The PEM encoded certificate and certificate chain are returned.
+func (c *FulcioClient) RequestCertificate(ctx context.Context, signer *ecdsa.PrivateKey, identityToken string) ([]byte, []byte, error) {
+	subject, err := identityTokenSubject(identityToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the proof of possession of the private key is the signature of the subject of the token.
+	subjectHash := sha256.Sum256([]byte(subject))
+	proof, err := signer.Sign(rand.Reader, subjectHash[:], crypto.SHA256)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create proof of possession: %w", err)
+	}
+	publicKey, err := MarshalPublicKeyPEM(signer.Public())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := fulcioCertificateRequest{}
+	req.Credentials.OIDCIdentityToken = identityToken
+	req.PublicKeyRequest.PublicKey.Algorithm = "ECDSA"
+	req.PublicKeyRequest.PublicKey.Content = string(publicKey)
+	req.PublicKeyRequest.ProofOfPossession = base64.StdEncoding.EncodeToString(proof)
+
+	respData, err := postJSON(ctx, c.HTTPClient, strings.TrimSuffix(c.URL, "/")+"/api/v2/signingCert", identityToken, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp := fulcioCertificateResponse{}
+	if err := json.Unmarshal(respData, &resp); err != nil {
+		return nil, nil, fmt.Errorf("unable to decode fulcio response: %w", err)
+	}
+	chain := resp.SignedCertificateEmbeddedSct
+	if chain == nil {
+		chain = resp.SignedCertificateDetachedSct
+	}
+	if chain == nil || len(chain.Chain.Certificates) == 0 {
+		return nil, nil, errors.New("fulcio response contains no certificate")
+	}
+	return []byte(chain.Chain.Certificates[0]), []byte(strings.Join(chain.Chain.Certificates[1:], "")), nil
+}
+
+// identityTokenSubject returns the email or the subject of an oidc identity token.
+// The token is not verified as this is done by fulcio.
+func identityTokenSubject(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("invalid oidc identity token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", fmt.Errorf("unable to decode oidc identity token: %w", err)
+	}
+	claims := struct {
+		Subject string `json:"sub"`
+		Email   string `json:"email"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("unable to decode oidc identity token claims: %w", err)
+	}
+	if len(claims.Email) != 0 {
+		return claims.Email, nil
+	}
+	if len(claims.Subject) == 0 {
+		return "", errors.New("the oidc identity token has no subject")
+	}
+	return claims.Subject, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, url, bearerToken string, body interface{}) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if len(bearerToken) != 0 {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	respData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response from %s: %w", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("request to %s failed with status %d: %s", url, resp.StatusCode, string(respData))
+	}
+	return respData, nil
+}
@@ -22,6 +22,7 @@ import (
 	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
 
 	ociCache "github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/pkg/components/schema"
 )
 
 func RecursivelyAddDigestsToCd(cd *cdv2.ComponentDescriptor, repoContext cdv2.OCIRegistryRepository, ociClient ociclient.Client, blobResolvers map[string]ctf.BlobResolver, ctx context.Context, skipAccessTypes map[string]bool) ([]*cdv2.ComponentDescriptor, error) {
@@ -33,7 +34,7 @@ func RecursivelyAddDigestsToCd(cd *cdv2.ComponentDescriptor, repoContext cdv2.OC
 			return nil, fmt.Errorf("invalid component reference: %w", err)
 		}
 
-		cdresolver := cdoci.NewResolver(ociClient)
+		cdresolver := schema.NewResolver(ociClient)
 		childCd, blobResolver, err := cdresolver.ResolveWithBlobResolver(ctx, &repoContext, cr.ComponentName, cr.Version)
 		if err != nil {
 			return nil, fmt.Errorf("unable to to fetch component descriptor %s: %w", ociRef, err)
@@ -85,7 +86,7 @@ func RecursivelyAddDigestsToCd(cd *cdv2.ComponentDescriptor, repoContext cdv2.OC
 func UploadCDPreservingLocalOciBlobs(ctx context.Context, cd cdv2.ComponentDescriptor, targetRepository cdv2.OCIRegistryRepository, ociClient ociclient.ExtendedClient, cache ociCache.Cache, blobResolvers map[string]ctf.BlobResolver, force bool, log logr.Logger) error {
 	// check if the component descriptor already exists and skip if not forced to overwrite
 	if !force {
-		cdresolver := cdoci.NewResolver(ociClient)
+		cdresolver := schema.NewResolver(ociClient)
 		if _, err := cdresolver.Resolve(ctx, &targetRepository, cd.Name, cd.Version); err == nil {
 			log.V(3).Info(fmt.Sprintf("Component Descriptor %s %s already exists in %s. Skip uploading cd", cd.Name, cd.Version, targetRepository.BaseURL))
 			return nil
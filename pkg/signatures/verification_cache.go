@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package signatures
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// VerificationCacheEntry describes a successful verification of a signed component descriptor.
+type VerificationCacheEntry struct {
+	ComponentName string    `json:"componentName"`
+	Version       string    `json:"version"`
+	SignatureName string    `json:"signatureName"`
+	VerifiedAt    time.Time `json:"verifiedAt"`
+}
+
+// VerificationCache stores successful verifications of signed component descriptors on the filesystem,
+// so that repeated verifications of the identical signed component descriptor with the same key can be skipped.
+type VerificationCache struct {
+	fs  vfs.FileSystem
+	dir string
+}
+
+// NewVerificationCache creates a new verification cache in the given directory.
+func NewVerificationCache(fs vfs.FileSystem, dir string) *VerificationCache {
+	return &VerificationCache{
+		fs:  fs,
+		dir: dir,
+	}
+}
+
+// VerificationCacheKey calculates the cache key of the verification of a signature of a component descriptor.
+// The key consists of the digest of the normalised component descriptor, the name and value of the signature
+// and the fingerprint of the key that is used for the verification.
+func VerificationCacheKey(cd cdv2.ComponentDescriptor, signatureName, keyFingerprint string) (string, error) {
+	var signature *cdv2.Signature
+	for i, sig := range cd.Signatures {
+		if sig.Name == signatureName {
+			signature = &cd.Signatures[i]
+			break
+		}
+	}
+	if signature == nil {
+		return "", fmt.Errorf("signature %q not found in component descriptor", signatureName)
+	}
+
+	hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+	if err != nil {
+		return "", fmt.Errorf("unable to create hasher: %w", err)
+	}
+	cdDigest, err := cdv2Sign.HashForComponentDescriptor(cd, *hasher)
+	if err != nil {
+		return "", fmt.Errorf("unable to hash component descriptor: %w", err)
+	}
+
+	key := strings.Join([]string{
+		cdDigest.Value,
+		signature.Name,
+		signature.Digest.Value,
+		signature.Signature.Value,
+		keyFingerprint,
+	}, "\n")
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Get returns the cache entry for the given key or nil if the verification is not cached.
+func (c *VerificationCache) Get(key string) (*VerificationCacheEntry, error) {
+	data, err := vfs.ReadFile(c.fs, c.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to read verification cache entry: %w", err)
+	}
+	entry := &VerificationCacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, fmt.Errorf("unable to decode verification cache entry: %w", err)
+	}
+	return entry, nil
+}
+
+// Add stores a successful verification for the given key.
+func (c *VerificationCache) Add(key string, entry VerificationCacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("unable to encode verification cache entry: %w", err)
+	}
+	if err := c.fs.MkdirAll(c.dir, os.ModePerm); err != nil {
+		return fmt.Errorf("unable to create verification cache directory %s: %w", c.dir, err)
+	}
+	if err := vfs.WriteFile(c.fs, c.path(key), data, 0644); err != nil {
+		return fmt.Errorf("unable to write verification cache entry: %w", err)
+	}
+	return nil
+}
+
+func (c *VerificationCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// PublicKeyFingerprint returns the sha256 fingerprint of the PKIX encoding of a public key.
+func PublicKeyFingerprint(publicKey interface{}) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal public key: %w", err)
+	}
+	hash := sha256.Sum256(der)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// PublicKeyFileFingerprint returns the sha256 fingerprint of a PKIX public key in a PEM file.
+// The fingerprint is equal to the one of PublicKeyFingerprint for the parsed key.
+func PublicKeyFileFingerprint(pathToPublicKey string) (string, error) {
+	data, err := ioutil.ReadFile(pathToPublicKey)
+	if err != nil {
+		return "", fmt.Errorf("unable to open public key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", errors.New("unable to decode pem formatted block in key")
+	}
+	hash := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(hash[:]), nil
+}
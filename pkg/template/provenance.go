@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/opencontainers/go-digest"
+	"github.com/spf13/pflag"
+)
+
+// ProvenanceLabelName is the name of the label that describes how an entry of a component descriptor
+// has been generated from a template.
+const ProvenanceLabelName = "cli.gardener.cloud/template-provenance"
+
+// DefaultProvenanceExcludePatterns are the default patterns of variable names whose values are not recorded.
+var DefaultProvenanceExcludePatterns = []string{
+	"(?i)password",
+	"(?i)secret",
+	"(?i)token",
+	"(?i)key",
+	"(?i)credential",
+}
+
+// Provenance describes the template rendering an entry of a component descriptor has been generated from.
+type Provenance struct {
+	// TemplateDigest is the digest of the unrendered template.
+	TemplateDigest string `json:"templateDigest"`
+	// Variables are the variables that have been used to render the template.
+	Variables map[string]string `json:"variables,omitempty"`
+	// ExcludedVariables are the names of the variables whose values have been omitted.
+	ExcludedVariables []string `json:"excludedVariables,omitempty"`
+}
+
+// AddProvenanceFlags adds the flags to configure the recording of the template provenance.
+func (o *Options) AddProvenanceFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.RecordProvenance, "template-provenance", false, fmt.Sprintf("record the template digest and the template variables as %q label on the added entries", ProvenanceLabelName))
+	fs.StringArrayVar(&o.ProvenanceExcludePatterns, "template-provenance-exclude", DefaultProvenanceExcludePatterns, "regular expressions of template variable names whose values are not recorded")
+}
+
+// Provenance returns the provenance of the rendering of the given template data.
+// The default exclude patterns are used if no exclude patterns are defined.
+func (o *Options) Provenance(data string) (*Provenance, error) {
+	patterns := o.ProvenanceExcludePatterns
+	if patterns == nil {
+		patterns = DefaultProvenanceExcludePatterns
+	}
+	excludes := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		exp, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid provenance exclude pattern %q: %w", pattern, err)
+		}
+		excludes[i] = exp
+	}
+
+	provenance := &Provenance{
+		TemplateDigest: digest.FromString(data).String(),
+	}
+	for name, value := range o.Vars {
+		if matchesAny(excludes, name) {
+			provenance.ExcludedVariables = append(provenance.ExcludedVariables, name)
+			continue
+		}
+		if provenance.Variables == nil {
+			provenance.Variables = map[string]string{}
+		}
+		provenance.Variables[name] = value
+	}
+	sort.Strings(provenance.ExcludedVariables)
+	return provenance, nil
+}
+
+// ProvenanceLabel returns the provenance label for the given template data.
+// Nil is returned if no provenance should be recorded or if the template has not been rendered with variables.
+func (o *Options) ProvenanceLabel(data string) (*cdv2.Label, error) {
+	if !o.RecordProvenance || len(o.Vars) == 0 {
+		return nil, nil
+	}
+	provenance, err := o.Provenance(data)
+	if err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(provenance)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode template provenance: %w", err)
+	}
+	return &cdv2.Label{
+		Name:  ProvenanceLabelName,
+		Value: value,
+	}, nil
+}
+
+func matchesAny(exps []*regexp.Regexp, s string) bool {
+	for _, exp := range exps {
+		if exp.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
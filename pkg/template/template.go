@@ -13,6 +13,13 @@ import (
 // Options defines the options for component-cli templating
 type Options struct {
 	Vars map[string]string
+
+	// RecordProvenance defines whether the provenance of the template rendering
+	// should be recorded as label on the generated entries.
+	RecordProvenance bool
+	// ProvenanceExcludePatterns are regular expressions that match the names of variables
+	// whose values must not be recorded in the provenance (e.g. secrets).
+	ProvenanceExcludePatterns []string
 }
 
 // Usage prints out the usage for templating
@@ -9,6 +9,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
 
 	"github.com/gardener/component-cli/pkg/template"
 )
@@ -82,4 +83,42 @@ var _ = Describe("Template", func() {
 
 	})
 
+	Context("Provenance", func() {
+		It("should record the template digest and the variables", func() {
+			s := "my ${MY_VAR}"
+			opts := template.Options{}
+			opts.Vars = map[string]string{
+				"MY_VAR": "test",
+			}
+			provenance, err := opts.Provenance(s)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provenance.TemplateDigest).To(Equal(digest.FromString(s).String()))
+			Expect(provenance.Variables).To(HaveKeyWithValue("MY_VAR", "test"))
+			Expect(provenance.ExcludedVariables).To(BeEmpty())
+		})
+
+		It("should not record the values of excluded variables", func() {
+			opts := template.Options{}
+			opts.Vars = map[string]string{
+				"MY_VAR":    "test",
+				"API_TOKEN": "abc",
+			}
+			opts.ProvenanceExcludePatterns = []string{"^MY_"}
+			provenance, err := opts.Provenance("my ${MY_VAR} ${API_TOKEN}")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provenance.Variables).To(HaveKeyWithValue("API_TOKEN", "abc"))
+			Expect(provenance.ExcludedVariables).To(ConsistOf("MY_VAR"))
+		})
+
+		It("should not return a label if the provenance is not recorded", func() {
+			opts := template.Options{}
+			opts.Vars = map[string]string{
+				"MY_VAR": "test",
+			}
+			label, err := opts.ProvenanceLabel("my ${MY_VAR}")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(label).To(BeNil())
+		})
+	})
+
 })
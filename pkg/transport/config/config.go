@@ -5,6 +5,8 @@ package config
 
 import (
 	"encoding/json"
+
+	"github.com/gardener/component-cli/ociclient"
 )
 
 type meta struct {
@@ -17,6 +19,18 @@ type transportConfig struct {
 	Processors      []processorDefinition      `json:"processors"`
 	Downloaders     []downloaderDefinition     `json:"downloaders"`
 	ProcessingRules []processingRuleDefinition `json:"processingRules"`
+	// PostUploaders are executed after the uploaders of their target and receive the uploaded resources without blob.
+	PostUploaders []uploaderDefinition `json:"postUploaders"`
+	// ArtifactTypes defines downloaders and uploaders for resources with custom access types.
+	ArtifactTypes []artifactTypeDefinition `json:"artifactTypes"`
+	// ComponentDescriptorMergeStrategy defines how component descriptors are merged
+	// if the component version already exists in the target repository.
+	ComponentDescriptorMergeStrategy string `json:"componentDescriptorMergeStrategy"`
+	// InventoryRef is the oci reference the inventory of the transported component versions is published to after a successful transport.
+	// No inventory is published if empty.
+	InventoryRef string `json:"inventoryRef"`
+	// RegistryLimits limit the requests to single target registries.
+	RegistryLimits []registryLimitDefinition `json:"registryLimits"`
 }
 
 type baseProcessorDefinition struct {
@@ -37,6 +51,7 @@ type downloaderDefinition struct {
 
 type uploaderDefinition struct {
 	baseProcessorDefinition
+	Target  string             `json:"target"`
 	Filters []filterDefinition `json:"filters"`
 }
 
@@ -54,3 +69,22 @@ type processingRuleDefinition struct {
 	Filters    []filterDefinition   `json:"filters"`
 	Processors []processorReference `json:"processors"`
 }
+
+// artifactTypeDefinition defines the downloader and uploader of resources with a custom access type.
+// The processors are either registered by Go code or executables (type "Executable").
+type artifactTypeDefinition struct {
+	AccessType string                          `json:"accessType"`
+	Downloader *baseProcessorDefinition        `json:"downloader"`
+	Uploader   *artifactTypeUploaderDefinition `json:"uploader"`
+}
+
+type artifactTypeUploaderDefinition struct {
+	baseProcessorDefinition
+	Target string `json:"target"`
+}
+
+// registryLimitDefinition defines the limits of a single registry host.
+type registryLimitDefinition struct {
+	Registry string `json:"registry"`
+	ociclient.RegistryLimits
+}
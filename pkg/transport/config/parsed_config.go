@@ -2,13 +2,17 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/opencontainers/go-digest"
 	"sigs.k8s.io/yaml"
 
+	"github.com/gardener/component-cli/ociclient"
 	"github.com/gardener/component-cli/pkg/transport/filters"
+	"github.com/gardener/component-cli/pkg/transport/merge"
 )
 
 type ParsedTransportConfig struct {
@@ -16,6 +20,18 @@ type ParsedTransportConfig struct {
 	Processors      []ParsedProcessorDefinition
 	Uploaders       []ParsedUploaderDefinition
 	ProcessingRules []ParsedProcessingRuleDefinition
+	// PostUploaders are executed after the uploaders of their target.
+	// They receive the resources with the final target access instead of the blob,
+	// e.g. to add tags or to attach referrers in the target registry.
+	PostUploaders []ParsedUploaderDefinition
+	// MergeStrategy defines how component descriptors are merged
+	// if the component version already exists in the target repository.
+	MergeStrategy merge.Strategy
+	// InventoryRef is the oci reference the inventory of the transported component versions is published to
+	// after a successful transport (see the inventory package). No inventory is published if empty.
+	InventoryRef string
+	// RegistryLimits are the limits per registry host that are applied to the oci clients of the transport.
+	RegistryLimits ociclient.WithRegistryLimits
 }
 
 type ParsedDownloaderDefinition struct {
@@ -32,9 +48,12 @@ type ParsedProcessorDefinition struct {
 }
 
 type ParsedUploaderDefinition struct {
-	Name    string
-	Type    string
-	Spec    *json.RawMessage
+	Name string
+	Type string
+	Spec *json.RawMessage
+	// Target is the name of the target the uploader belongs to.
+	// Uploaders of different targets are executed independently on the shared processing results.
+	Target  string
 	Filters []filters.Filter
 }
 
@@ -59,6 +78,18 @@ func ParseTransportConfig(configFilePath string) (*ParsedTransportConfig, error)
 	var parsedConfig ParsedTransportConfig
 	ff := filters.NewFilterFactory()
 
+	parsedConfig.MergeStrategy, err = merge.ParseStrategy(config.ComponentDescriptorMergeStrategy)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse component descriptor merge strategy: %w", err)
+	}
+
+	parsedConfig.InventoryRef = config.InventoryRef
+
+	parsedConfig.RegistryLimits, err = parseRegistryLimits(config.RegistryLimits)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse registry limits: %w", err)
+	}
+
 	// downloaders
 	for _, downloaderDefinition := range config.Downloaders {
 		filters, err := createFilterList(downloaderDefinition.Filters, ff)
@@ -92,10 +123,33 @@ func ParseTransportConfig(configFilePath string) (*ParsedTransportConfig, error)
 			Name:    uploaderDefinition.Name,
 			Type:    uploaderDefinition.Type,
 			Spec:    uploaderDefinition.Spec,
+			Target:  uploaderDefinition.Target,
+			Filters: filters,
+		})
+	}
+
+	// post uploaders
+	for _, postUploaderDefinition := range config.PostUploaders {
+		filters, err := createFilterList(postUploaderDefinition.Filters, ff)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create filters for post uploader %s: %w", postUploaderDefinition.Name, err)
+		}
+		parsedConfig.PostUploaders = append(parsedConfig.PostUploaders, ParsedUploaderDefinition{
+			Name:    postUploaderDefinition.Name,
+			Type:    postUploaderDefinition.Type,
+			Spec:    postUploaderDefinition.Spec,
+			Target:  postUploaderDefinition.Target,
 			Filters: filters,
 		})
 	}
 
+	// artifact types
+	for _, artifactType := range config.ArtifactTypes {
+		if err := parseArtifactType(artifactType, &parsedConfig); err != nil {
+			return nil, fmt.Errorf("unable to parse artifact type %s: %w", artifactType.AccessType, err)
+		}
+	}
+
 	// processing rules
 	for _, processingRule := range config.ProcessingRules {
 		filters, err := createFilterList(processingRule.Filters, ff)
@@ -124,6 +178,89 @@ func ParseTransportConfig(configFilePath string) (*ParsedTransportConfig, error)
 	return &parsedConfig, nil
 }
 
+// parseArtifactType adds the downloader and uploader of an artifact type to the parsed config.
+// The processors only match resources with the access type of the artifact type.
+func parseArtifactType(artifactType artifactTypeDefinition, parsedConfig *ParsedTransportConfig) error {
+	if len(artifactType.AccessType) == 0 {
+		return errors.New("accessType must not be empty")
+	}
+	if artifactType.Downloader == nil && artifactType.Uploader == nil {
+		return errors.New("at least a downloader or an uploader has to be defined")
+	}
+	newFilter := func() ([]filters.Filter, error) {
+		filter, err := filters.NewAccessTypeFilter(filters.AccessTypeFilterSpec{
+			IncludeAccessTypes: []string{artifactType.AccessType},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []filters.Filter{filter}, nil
+	}
+
+	if artifactType.Downloader != nil {
+		if len(artifactType.Downloader.Type) == 0 {
+			return errors.New("downloader type must not be empty")
+		}
+		filters, err := newFilter()
+		if err != nil {
+			return err
+		}
+		name := artifactType.Downloader.Name
+		if len(name) == 0 {
+			name = artifactType.AccessType + "-downloader"
+		}
+		parsedConfig.Downloaders = append(parsedConfig.Downloaders, ParsedDownloaderDefinition{
+			Name:    name,
+			Type:    artifactType.Downloader.Type,
+			Spec:    artifactType.Downloader.Spec,
+			Filters: filters,
+		})
+	}
+
+	if artifactType.Uploader != nil {
+		if len(artifactType.Uploader.Type) == 0 {
+			return errors.New("uploader type must not be empty")
+		}
+		filters, err := newFilter()
+		if err != nil {
+			return err
+		}
+		name := artifactType.Uploader.Name
+		if len(name) == 0 {
+			name = artifactType.AccessType + "-uploader"
+		}
+		parsedConfig.Uploaders = append(parsedConfig.Uploaders, ParsedUploaderDefinition{
+			Name:    name,
+			Type:    artifactType.Uploader.Type,
+			Spec:    artifactType.Uploader.Spec,
+			Target:  artifactType.Uploader.Target,
+			Filters: filters,
+		})
+	}
+	return nil
+}
+
+// parseRegistryLimits validates the registry limits and indexes them by registry host.
+func parseRegistryLimits(definitions []registryLimitDefinition) (ociclient.WithRegistryLimits, error) {
+	if len(definitions) == 0 {
+		return nil, nil
+	}
+	limits := ociclient.WithRegistryLimits{}
+	for i, def := range definitions {
+		if len(def.Registry) == 0 {
+			return nil, fmt.Errorf("registry of limit %d must not be empty", i)
+		}
+		if _, ok := limits[def.Registry]; ok {
+			return nil, fmt.Errorf("duplicate limits for registry %s", def.Registry)
+		}
+		if def.MaxConcurrentUploads < 0 || def.MaxBytesPerSecond < 0 || def.MaxRequestsPerSecond < 0 {
+			return nil, fmt.Errorf("limits of registry %s must not be negative", def.Registry)
+		}
+		limits[def.Registry] = def.RegistryLimits
+	}
+	return limits, nil
+}
+
 // MatchDownloaders finds all matching downloaders
 func (c *ParsedTransportConfig) MatchDownloaders(cd cdv2.ComponentDescriptor, res cdv2.Resource) []ParsedDownloaderDefinition {
 	dls := []ParsedDownloaderDefinition{}
@@ -146,6 +283,40 @@ func (c *ParsedTransportConfig) MatchUploaders(cd cdv2.ComponentDescriptor, res
 	return uls
 }
 
+// MatchUploadersByTarget finds all matching uploaders and groups them by their target.
+// The targets are returned in the order of their first occurrence in the config.
+func (c *ParsedTransportConfig) MatchUploadersByTarget(cd cdv2.ComponentDescriptor, res cdv2.Resource) ([]string, map[string][]ParsedUploaderDefinition) {
+	targets := []string{}
+	uls := map[string][]ParsedUploaderDefinition{}
+	for _, uploader := range c.MatchUploaders(cd, res) {
+		if _, ok := uls[uploader.Target]; !ok {
+			targets = append(targets, uploader.Target)
+		}
+		uls[uploader.Target] = append(uls[uploader.Target], uploader)
+	}
+	return targets, uls
+}
+
+// MatchPostUploaders finds all matching post uploaders
+func (c *ParsedTransportConfig) MatchPostUploaders(cd cdv2.ComponentDescriptor, res cdv2.Resource) []ParsedUploaderDefinition {
+	puls := []ParsedUploaderDefinition{}
+	for _, postUploader := range c.PostUploaders {
+		if areAllFiltersMatching(postUploader.Filters, cd, res) {
+			puls = append(puls, postUploader)
+		}
+	}
+	return puls
+}
+
+// MatchPostUploadersByTarget finds all matching post uploaders and groups them by their target.
+func (c *ParsedTransportConfig) MatchPostUploadersByTarget(cd cdv2.ComponentDescriptor, res cdv2.Resource) map[string][]ParsedUploaderDefinition {
+	puls := map[string][]ParsedUploaderDefinition{}
+	for _, postUploader := range c.MatchPostUploaders(cd, res) {
+		puls[postUploader.Target] = append(puls[postUploader.Target], postUploader)
+	}
+	return puls
+}
+
 // MatchProcessingRules finds all matching processing rules
 func (c *ParsedTransportConfig) MatchProcessingRules(cd cdv2.ComponentDescriptor, res cdv2.Resource) []ParsedProcessingRuleDefinition {
 	prs := []ParsedProcessingRuleDefinition{}
@@ -157,6 +328,38 @@ func (c *ParsedTransportConfig) MatchProcessingRules(cd cdv2.ComponentDescriptor
 	return prs
 }
 
+// ProcessingDigest returns the digest of all downloaders, processors and uploaders that match the resource.
+// Resources that are stamped with the digest (see utils.ProcessingStampName) do not need to be processed again
+// as long as the matching processing config does not change.
+func (c *ParsedTransportConfig) ProcessingDigest(cd cdv2.ComponentDescriptor, res cdv2.Resource) (string, error) {
+	type definition struct {
+		Name string           `json:"name"`
+		Type string           `json:"type"`
+		Spec *json.RawMessage `json:"spec,omitempty"`
+	}
+	defs := []definition{}
+	for _, downloader := range c.MatchDownloaders(cd, res) {
+		defs = append(defs, definition{Name: downloader.Name, Type: downloader.Type, Spec: downloader.Spec})
+	}
+	for _, processingRule := range c.MatchProcessingRules(cd, res) {
+		for _, processor := range processingRule.Processors {
+			defs = append(defs, definition{Name: processor.Name, Type: processor.Type, Spec: processor.Spec})
+		}
+	}
+	for _, uploader := range c.MatchUploaders(cd, res) {
+		defs = append(defs, definition{Name: uploader.Name, Type: uploader.Type, Spec: uploader.Spec})
+	}
+	for _, postUploader := range c.MatchPostUploaders(cd, res) {
+		defs = append(defs, definition{Name: postUploader.Name, Type: postUploader.Type, Spec: postUploader.Spec})
+	}
+
+	data, err := json.Marshal(defs)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal processing definitions: %w", err)
+	}
+	return digest.FromBytes(data).String(), nil
+}
+
 func areAllFiltersMatching(filters []filters.Filter, cd cdv2.ComponentDescriptor, res cdv2.Resource) bool {
 	for _, filter := range filters {
 		if !filter.Matches(cd, res) {
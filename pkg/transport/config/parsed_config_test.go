@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/pkg/transport/config"
+)
+
+var _ = Describe("parsed config", func() {
+
+	var tmpDir string
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "transport-config-")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	parse := func(cfg string) (*config.ParsedTransportConfig, error) {
+		configPath := filepath.Join(tmpDir, "config.yaml")
+		Expect(ioutil.WriteFile(configPath, []byte(cfg), os.ModePerm)).To(Succeed())
+		return config.ParseTransportConfig(configPath)
+	}
+
+	It("should add the downloader and uploader of an artifact type for its access type", func() {
+		parsedConfig, err := parse(`
+meta:
+  version: v1
+artifactTypes:
+- accessType: mavenArtifact
+  downloader:
+    type: Executable
+    spec:
+      bin: ./maven-downloader
+  uploader:
+    name: maven
+    type: MavenUploader
+    target: artifactory
+`)
+		Expect(err).ToNot(HaveOccurred())
+
+		cd := cdv2.ComponentDescriptor{}
+		maven := cdv2.Resource{Access: cdv2.NewUnstructuredType("mavenArtifact", map[string]interface{}{})}
+		oci := cdv2.Resource{Access: cdv2.NewUnstructuredType(cdv2.OCIRegistryType, map[string]interface{}{})}
+
+		downloaders := parsedConfig.MatchDownloaders(cd, maven)
+		Expect(downloaders).To(HaveLen(1))
+		Expect(downloaders[0].Name).To(Equal("mavenArtifact-downloader"))
+		Expect(downloaders[0].Type).To(Equal("Executable"))
+		uploaders := parsedConfig.MatchUploaders(cd, maven)
+		Expect(uploaders).To(HaveLen(1))
+		Expect(uploaders[0].Name).To(Equal("maven"))
+		Expect(uploaders[0].Target).To(Equal("artifactory"))
+
+		Expect(parsedConfig.MatchDownloaders(cd, oci)).To(BeEmpty())
+		Expect(parsedConfig.MatchUploaders(cd, oci)).To(BeEmpty())
+	})
+
+	It("should parse post uploaders and group them by target", func() {
+		parsedConfig, err := parse(`
+meta:
+  version: v1
+postUploaders:
+- name: tag-latest
+  type: OciArtifactTagger
+  target: registry
+  spec:
+    tags:
+    - latest
+  filters:
+  - type: AccessTypeFilter
+    spec:
+      includeAccessTypes:
+      - ociRegistry
+`)
+		Expect(err).ToNot(HaveOccurred())
+
+		cd := cdv2.ComponentDescriptor{}
+		oci := cdv2.Resource{Access: cdv2.NewUnstructuredType(cdv2.OCIRegistryType, map[string]interface{}{})}
+		local := cdv2.Resource{Access: cdv2.NewUnstructuredType(cdv2.LocalOCIBlobType, map[string]interface{}{})}
+
+		postUploaders := parsedConfig.MatchPostUploadersByTarget(cd, oci)
+		Expect(postUploaders).To(HaveKey("registry"))
+		Expect(postUploaders["registry"]).To(HaveLen(1))
+		Expect(postUploaders["registry"][0].Type).To(Equal("OciArtifactTagger"))
+		Expect(parsedConfig.MatchPostUploaders(cd, local)).To(BeEmpty())
+	})
+
+	It("should parse the inventory reference", func() {
+		parsedConfig, err := parse(`
+meta:
+  version: v1
+inventoryRef: example.com/target/inventory:v1
+`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedConfig.InventoryRef).To(Equal("example.com/target/inventory:v1"))
+	})
+
+	It("should parse the registry limits", func() {
+		parsedConfig, err := parse(`
+meta:
+  version: v1
+registryLimits:
+- registry: eu.gcr.io
+  maxConcurrentUploads: 2
+  maxBytesPerSecond: 1048576
+  maxRequestsPerSecond: 5
+- registry: localhost:5000
+  maxConcurrentUploads: 1
+`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedConfig.RegistryLimits).To(HaveLen(2))
+		Expect(parsedConfig.RegistryLimits).To(HaveKeyWithValue("eu.gcr.io", ociclient.RegistryLimits{
+			MaxConcurrentUploads: 2,
+			MaxBytesPerSecond:    1048576,
+			MaxRequestsPerSecond: 5,
+		}))
+		Expect(parsedConfig.RegistryLimits).To(HaveKeyWithValue("localhost:5000", ociclient.RegistryLimits{
+			MaxConcurrentUploads: 1,
+		}))
+	})
+
+	It("should fail if a registry has multiple limits", func() {
+		_, err := parse(`
+registryLimits:
+- registry: eu.gcr.io
+  maxConcurrentUploads: 2
+- registry: eu.gcr.io
+  maxRequestsPerSecond: 5
+`)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail if an artifact type has no access type", func() {
+		_, err := parse(`
+artifactTypes:
+- downloader:
+    type: Executable
+`)
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -4,6 +4,7 @@
 package filters_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
@@ -216,4 +217,70 @@ var _ = Describe("filters", func() {
 
 	})
 
+	Context("selector", func() {
+
+		newResource := func(name, typ string, labels ...cdv2.Label) cdv2.Resource {
+			return cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:   name,
+					Type:   typ,
+					Labels: labels,
+				},
+			}
+		}
+
+		It("should match all resources if no selector is defined", func() {
+			opts := filter.SelectorOptions{}
+			Expect(opts.IsEmpty()).To(BeTrue())
+			f, err := opts.Build()
+			Expect(err).ToNot(HaveOccurred())
+
+			cd := cdv2.ComponentDescriptor{}
+			cd.Name = "github.com/gardener/component-cli"
+			Expect(f.Matches(cd, newResource("my-res", cdv2.OCIImageType))).To(BeTrue())
+		})
+
+		It("should match resources of components whose name matches a glob", func() {
+			opts := filter.SelectorOptions{
+				OnlyComponents: []string{"github.com/gardener/*"},
+			}
+			f, err := opts.Build()
+			Expect(err).ToNot(HaveOccurred())
+
+			cd := cdv2.ComponentDescriptor{}
+			cd.Name = "github.com/gardener/component-cli"
+			Expect(f.Matches(cd, newResource("my-res", cdv2.OCIImageType))).To(BeTrue())
+			cd.Name = "github.com/example/component"
+			Expect(f.Matches(cd, newResource("my-res", cdv2.OCIImageType))).To(BeFalse())
+		})
+
+		It("should match resources by name, type and labels", func() {
+			opts := filter.SelectorOptions{
+				OnlyResources: []string{
+					"name=img-*,type=ociImage,label=my-label=true",
+					"helm-chart",
+				},
+			}
+			f, err := opts.Build()
+			Expect(err).ToNot(HaveOccurred())
+
+			cd := cdv2.ComponentDescriptor{}
+			label := cdv2.Label{Name: "my-label", Value: json.RawMessage(`true`)}
+			Expect(f.Matches(cd, newResource("img-a", cdv2.OCIImageType, label))).To(BeTrue())
+			Expect(f.Matches(cd, newResource("img-a", cdv2.OCIImageType))).To(BeFalse())
+			Expect(f.Matches(cd, newResource("img-a", "helm", label))).To(BeFalse())
+			Expect(f.Matches(cd, newResource("other", cdv2.OCIImageType, label))).To(BeFalse())
+			Expect(f.Matches(cd, newResource("helm-chart", "helm"))).To(BeTrue())
+		})
+
+		It("should return an error for an invalid resource selector", func() {
+			opts := filter.SelectorOptions{
+				OnlyResources: []string{"version=v1"},
+			}
+			_, err := opts.Build()
+			Expect(err).To(HaveOccurred())
+		})
+
+	})
+
 })
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package filters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/spf13/pflag"
+)
+
+// SelectorOptions defines the cli selectors that restrict a transport to specific components and resources.
+// The selectors only restrict which resources are processed. The complete component tree is still resolved
+// so that the selected resources are processed with their full context.
+type SelectorOptions struct {
+	// OnlyComponents are name globs of the components whose resources should be processed.
+	OnlyComponents []string
+	// OnlyResources are selectors for the resources that should be processed.
+	// A selector is a comma separated list of terms that all have to match:
+	// "name=<glob>", "type=<type>", "label=<name>" or "label=<name>=<value>".
+	// A term without key is interpreted as name glob.
+	OnlyResources []string
+}
+
+// AddFlags adds the selector flags to the given flagset.
+func (o *SelectorOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringArrayVar(&o.OnlyComponents, "only-components", []string{}, "only process resources of components whose name matches the given glob. Can be specified multiple times")
+	fs.StringArrayVar(&o.OnlyResources, "only-resources", []string{}, `only process resources that match the given selector, e.g. "name=my-*,type=ociImage,label=my-label=true". Can be specified multiple times`)
+}
+
+// IsEmpty returns whether no selector is defined.
+func (o *SelectorOptions) IsEmpty() bool {
+	return len(o.OnlyComponents) == 0 && len(o.OnlyResources) == 0
+}
+
+// Build creates a filter that matches all resources that are selected by the options.
+// If multiple component or resource selectors are defined, a resource has to match at least one of each.
+func (o *SelectorOptions) Build() (Filter, error) {
+	filter := &selectorFilter{}
+	for _, glob := range o.OnlyComponents {
+		if len(glob) == 0 {
+			return nil, fmt.Errorf("component selector must not be empty")
+		}
+		filter.components = append(filter.components, compileGlob(glob))
+	}
+	for _, rawSelector := range o.OnlyResources {
+		sel, err := parseResourceSelector(rawSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resource selector %q: %w", rawSelector, err)
+		}
+		filter.resources = append(filter.resources, sel)
+	}
+	return filter, nil
+}
+
+type selectorFilter struct {
+	components []*regexp.Regexp
+	resources  []resourceSelector
+}
+
+func (f selectorFilter) Matches(cd cdv2.ComponentDescriptor, r cdv2.Resource) bool {
+	if len(f.components) != 0 {
+		matched := false
+		for _, c := range f.components {
+			if c.MatchString(cd.Name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.resources) == 0 {
+		return true
+	}
+	for _, sel := range f.resources {
+		if sel.matches(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceSelector matches resources by name, type and labels.
+// Empty fields are ignored.
+type resourceSelector struct {
+	name   *regexp.Regexp
+	typ    string
+	labels []labelSelector
+}
+
+type labelSelector struct {
+	name  string
+	value *string
+}
+
+func parseResourceSelector(rawSelector string) (resourceSelector, error) {
+	sel := resourceSelector{}
+	for _, term := range strings.Split(rawSelector, ",") {
+		term = strings.TrimSpace(term)
+		if len(term) == 0 {
+			return sel, fmt.Errorf("empty term")
+		}
+		key, value := "name", term
+		if i := strings.Index(term, "="); i != -1 {
+			key, value = term[:i], term[i+1:]
+		}
+		if len(value) == 0 {
+			return sel, fmt.Errorf("term %q has no value", term)
+		}
+		switch key {
+		case "name":
+			sel.name = compileGlob(value)
+		case "type":
+			sel.typ = value
+		case "label":
+			label := labelSelector{name: value}
+			if i := strings.Index(value, "="); i != -1 {
+				labelValue := value[i+1:]
+				label.name = value[:i]
+				label.value = &labelValue
+			}
+			sel.labels = append(sel.labels, label)
+		default:
+			return sel, fmt.Errorf("unknown key %q. Expected name, type or label", key)
+		}
+	}
+	return sel, nil
+}
+
+func (s resourceSelector) matches(r cdv2.Resource) bool {
+	if s.name != nil && !s.name.MatchString(r.Name) {
+		return false
+	}
+	if len(s.typ) != 0 && s.typ != r.Type {
+		return false
+	}
+	for _, ls := range s.labels {
+		if !ls.matches(r.Labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s labelSelector) matches(labels cdv2.Labels) bool {
+	for _, l := range labels {
+		if l.Name != s.name {
+			continue
+		}
+		if s.value == nil {
+			return true
+		}
+		// string values are compared without their quotes, all other values by their compact json representation.
+		var str string
+		if err := json.Unmarshal(l.Value, &str); err == nil {
+			return str == *s.value
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, l.Value); err != nil {
+			return false
+		}
+		return compact.String() == *s.value
+	}
+	return false
+}
+
+// compileGlob converts a glob where "*" matches any sequence of characters (including "/")
+// and "?" matches a single character into an anchored regular expression.
+func compileGlob(glob string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(glob)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.MustCompile("^" + expr + "$")
+}
@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package inventory creates and publishes the list of component versions that have been transported into a target repository.
+// Admission tooling in the target environment can consult the inventory to only allow transported content.
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdoci "github.com/gardener/component-spec/bindings-go/oci"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+)
+
+const (
+	// ConfigMediaType is the media type of the config of an inventory artifact.
+	ConfigMediaType = "application/vnd.gardener.cloud.cnudie.transport-inventory.config.v1+json"
+	// MediaType is the media type of the layer of an inventory artifact that contains the json encoded inventory.
+	MediaType = "application/vnd.gardener.cloud.cnudie.transport-inventory.v1+json"
+)
+
+// Inventory is the machine-readable list of the component versions that have been transported into a repository.
+type Inventory struct {
+	// RepositoryContext is the repository context the component versions have been transported to.
+	RepositoryContext cdv2.OCIRegistryRepository `json:"repositoryContext"`
+	// CreatedAt is the time the inventory has been created.
+	CreatedAt time.Time `json:"createdAt"`
+	// Components are the transported component versions.
+	Components []Component `json:"components"`
+}
+
+// Component is a transported component version.
+type Component struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Digest is the manifest digest of the component descriptor in the target repository.
+	Digest digest.Digest `json:"digest"`
+	// Resources are the resources of the component version.
+	Resources []Resource `json:"resources,omitempty"`
+}
+
+// Resource is a resource of a transported component version.
+type Resource struct {
+	Name          string        `json:"name"`
+	Version       string        `json:"version"`
+	ExtraIdentity cdv2.Identity `json:"extraIdentity,omitempty"`
+	Type          string        `json:"type"`
+	// ImageReference is the image reference of resources with an ociRegistry access.
+	ImageReference string `json:"imageReference,omitempty"`
+	// Digest is the manifest digest of resources with an ociRegistry access
+	// and the blob digest of resources with a localOciBlob access.
+	Digest digest.Digest `json:"digest,omitempty"`
+	// ResourceDigest is the normalised digest of the resource in the component descriptor.
+	ResourceDigest *cdv2.DigestSpec `json:"resourceDigest,omitempty"`
+}
+
+// New creates the inventory of the given component descriptors that have been transported into the repository context.
+// The digests of the component descriptors and of the oci artifact resources are resolved in the target repository,
+// so that the inventory reflects the content of the target and not of the source.
+func New(ctx context.Context, client ociclient.Client, repoCtx cdv2.OCIRegistryRepository, cds []cdv2.ComponentDescriptor) (*Inventory, error) {
+	inv := &Inventory{
+		RepositoryContext: repoCtx,
+		CreatedAt:         time.Now().UTC(),
+		Components:        make([]Component, 0, len(cds)),
+	}
+	for _, cd := range cds {
+		ref, err := cdoci.OCIRef(repoCtx, cd.Name, cd.Version)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get oci reference of %s:%s: %w", cd.Name, cd.Version, err)
+		}
+		_, desc, err := client.Resolve(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve component descriptor %s: %w", ref, err)
+		}
+		comp := Component{
+			Name:    cd.Name,
+			Version: cd.Version,
+			Digest:  desc.Digest,
+		}
+		for _, res := range cd.Resources {
+			invRes, err := newResource(ctx, client, res)
+			if err != nil {
+				return nil, fmt.Errorf("unable to get inventory entry of resource %s of %s:%s: %w", res.Name, cd.Name, cd.Version, err)
+			}
+			comp.Resources = append(comp.Resources, invRes)
+		}
+		inv.Components = append(inv.Components, comp)
+	}
+	return inv, nil
+}
+
+func newResource(ctx context.Context, client ociclient.Client, res cdv2.Resource) (Resource, error) {
+	invRes := Resource{
+		Name:           res.Name,
+		Version:        res.Version,
+		ExtraIdentity:  res.ExtraIdentity,
+		Type:           res.Type,
+		ResourceDigest: res.Digest,
+	}
+	if res.Access == nil {
+		return invRes, nil
+	}
+	switch res.Access.GetType() {
+	case cdv2.OCIRegistryType:
+		ociAccess := &cdv2.OCIRegistryAccess{}
+		if err := res.Access.DecodeInto(ociAccess); err != nil {
+			return Resource{}, fmt.Errorf("unable to decode resource access: %w", err)
+		}
+		_, desc, err := client.Resolve(ctx, ociAccess.ImageReference)
+		if err != nil {
+			return Resource{}, fmt.Errorf("unable to resolve %s: %w", ociAccess.ImageReference, err)
+		}
+		invRes.ImageReference = ociAccess.ImageReference
+		invRes.Digest = desc.Digest
+	case cdv2.LocalOCIBlobType:
+		localBlobAccess := &cdv2.LocalOCIBlobAccess{}
+		if err := res.Access.DecodeInto(localBlobAccess); err != nil {
+			return Resource{}, fmt.Errorf("unable to decode resource access: %w", err)
+		}
+		invRes.Digest = digest.Digest(localBlobAccess.Digest)
+	}
+	return invRes, nil
+}
+
+// Publish pushes the inventory as oci artifact to the given reference and returns the descriptor of the pushed manifest.
+func Publish(ctx context.Context, client ociclient.Client, ref string, inv *Inventory) (ocispecv1.Descriptor, error) {
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to encode inventory: %w", err)
+	}
+	config := []byte("{}")
+
+	manifest := &ocispecv1.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config: ocispecv1.Descriptor{
+			MediaType: ConfigMediaType,
+			Digest:    digest.FromBytes(config),
+			Size:      int64(len(config)),
+		},
+		Layers: []ocispecv1.Descriptor{
+			{
+				MediaType: MediaType,
+				Digest:    digest.FromBytes(data),
+				Size:      int64(len(data)),
+			},
+		},
+	}
+	blobs := map[digest.Digest][]byte{
+		manifest.Config.Digest:    config,
+		manifest.Layers[0].Digest: data,
+	}
+	store := ociclient.GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
+		blob, ok := blobs[desc.Digest]
+		if !ok {
+			return fmt.Errorf("unknown blob %s", desc.Digest)
+		}
+		_, err := writer.Write(blob)
+		return err
+	})
+	if err := client.PushManifest(ctx, ref, manifest, ociclient.WithStore(store)); err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to push inventory to %s: %w", ref, err)
+	}
+
+	desc, err := ociclient.CreateDescriptorFromManifest(manifest)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to create manifest descriptor: %w", err)
+	}
+	return desc, nil
+}
+
+// Get fetches the inventory from the given reference.
+func Get(ctx context.Context, client ociclient.Client, ref string) (*Inventory, error) {
+	manifest, err := client.GetManifest(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get manifest of %s: %w", ref, err)
+	}
+	if manifest.Config.MediaType != ConfigMediaType {
+		return nil, fmt.Errorf("unexpected config media type %q, expected %q", manifest.Config.MediaType, ConfigMediaType)
+	}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != MediaType {
+			continue
+		}
+		var data bytes.Buffer
+		if err := client.Fetch(ctx, ref, layer, &data); err != nil {
+			return nil, fmt.Errorf("unable to fetch inventory: %w", err)
+		}
+		inv := &Inventory{}
+		if err := json.Unmarshal(data.Bytes(), inv); err != nil {
+			return nil, fmt.Errorf("unable to decode inventory: %w", err)
+		}
+		return inv, nil
+	}
+	return nil, fmt.Errorf("no inventory layer found in %s", ref)
+}
+
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package inventory_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	mock_ociclient "github.com/gardener/component-cli/ociclient/mock"
+	"github.com/gardener/component-cli/pkg/transport/inventory"
+)
+
+var _ = Describe("Inventory", func() {
+
+	var (
+		mockCtrl      *gomock.Controller
+		mockOCIClient *mock_ociclient.MockClient
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockOCIClient = mock_ociclient.NewMockClient(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should list the transported component versions with their target digests", func() {
+		ociAcc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess("example.com/target/image:1.0.0"))
+		Expect(err).ToNot(HaveOccurred())
+		localAcc, err := cdv2.NewUnstructured(cdv2.NewLocalOCIBlobAccess("sha256:abc"))
+		Expect(err).ToNot(HaveOccurred())
+		cd := cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/my-component"
+		cd.Version = "v0.1.0"
+		cd.Resources = []cdv2.Resource{
+			{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "image", Version: "1.0.0", Type: cdv2.OCIImageType},
+				Access:             &ociAcc,
+			},
+			{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "blob", Version: "v0.1.0", Type: "plain-text"},
+				Access:             &localAcc,
+			},
+		}
+
+		cdDigest := digest.FromString("component-descriptor")
+		imageDigest := digest.FromString("image")
+		mockOCIClient.EXPECT().Resolve(gomock.Any(), "example.com/target/component-descriptors/example.com/my-component:v0.1.0").
+			Return("", ocispecv1.Descriptor{Digest: cdDigest}, nil)
+		mockOCIClient.EXPECT().Resolve(gomock.Any(), "example.com/target/image:1.0.0").
+			Return("", ocispecv1.Descriptor{Digest: imageDigest}, nil)
+
+		repoCtx := *cdv2.NewOCIRegistryRepository("example.com/target", "")
+		inv, err := inventory.New(context.TODO(), mockOCIClient, repoCtx, []cdv2.ComponentDescriptor{cd})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(inv.RepositoryContext).To(Equal(repoCtx))
+		Expect(inv.Components).To(HaveLen(1))
+		Expect(inv.Components[0].Digest).To(Equal(cdDigest))
+		Expect(inv.Components[0].Resources).To(ConsistOf(
+			inventory.Resource{Name: "image", Version: "1.0.0", Type: cdv2.OCIImageType, ImageReference: "example.com/target/image:1.0.0", Digest: imageDigest},
+			inventory.Resource{Name: "blob", Version: "v0.1.0", Type: "plain-text", Digest: "sha256:abc"},
+		))
+	})
+
+	It("should publish the inventory as oci artifact", func() {
+		inv := &inventory.Inventory{
+			RepositoryContext: *cdv2.NewOCIRegistryRepository("example.com/target", ""),
+			Components: []inventory.Component{
+				{Name: "example.com/my-component", Version: "v0.1.0", Digest: digest.FromString("component-descriptor")},
+			},
+		}
+
+		var pushedManifest *ocispecv1.Manifest
+		var pushedInventory bytes.Buffer
+		mockOCIClient.EXPECT().PushManifest(gomock.Any(), "example.com/target/inventory:v1", gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, ref string, manifest *ocispecv1.Manifest, opts ...ociclient.PushOption) error {
+				pushedManifest = manifest
+				options := &ociclient.PushOptions{}
+				options.ApplyOptions(opts)
+				r, err := options.Store.Get(manifest.Layers[0])
+				Expect(err).ToNot(HaveOccurred())
+				defer r.Close()
+				_, err = pushedInventory.ReadFrom(r)
+				return err
+			})
+
+		desc, err := inventory.Publish(context.TODO(), mockOCIClient, "example.com/target/inventory:v1", inv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(desc.MediaType).To(Equal(ocispecv1.MediaTypeImageManifest))
+		Expect(pushedManifest.Config.MediaType).To(Equal(inventory.ConfigMediaType))
+		Expect(pushedManifest.Layers).To(HaveLen(1))
+		Expect(pushedManifest.Layers[0].MediaType).To(Equal(inventory.MediaType))
+
+		actual := &inventory.Inventory{}
+		Expect(json.Unmarshal(pushedInventory.Bytes(), actual)).To(Succeed())
+		Expect(actual.Components).To(Equal(inv.Components))
+	})
+
+})
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package merge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/containerd/containerd/errdefs"
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// Strategy defines how a component descriptor is merged with an already existing
+// component descriptor of the same component version in the target repository.
+type Strategy string
+
+const (
+	// StrategyFail fails the upload if the component version already exists.
+	StrategyFail Strategy = "fail"
+	// StrategyOverwrite replaces the existing component descriptor with the new one.
+	StrategyOverwrite Strategy = "overwrite"
+	// StrategyMergeResourcesByIdentity adds all resources, sources and component references of the existing
+	// component descriptor that are not part of the new one (compared by their identity).
+	// Existing signatures are kept if they are still valid for the merged component descriptor.
+	StrategyMergeResourcesByIdentity Strategy = "merge-resources-by-identity"
+	// StrategyAppendSignaturesOnly keeps the existing component descriptor and only appends the signatures of the new one.
+	// The merge fails if the content of the component descriptors differs.
+	StrategyAppendSignaturesOnly Strategy = "append-signatures-only"
+)
+
+// ErrAlreadyExists is returned by the fail strategy if the component version already exists.
+var ErrAlreadyExists = errors.New("component version already exists")
+
+// ParseStrategy parses a merge strategy.
+// An empty string defaults to the overwrite strategy.
+func ParseStrategy(s string) (Strategy, error) {
+	switch Strategy(s) {
+	case "":
+		return StrategyOverwrite, nil
+	case StrategyFail, StrategyOverwrite, StrategyMergeResourcesByIdentity, StrategyAppendSignaturesOnly:
+		return Strategy(s), nil
+	default:
+		return "", fmt.Errorf("unknown merge strategy %q. Expected one of %q, %q, %q or %q", s,
+			StrategyFail, StrategyOverwrite, StrategyMergeResourcesByIdentity, StrategyAppendSignaturesOnly)
+	}
+}
+
+// Merge merges the new component descriptor with the existing one according to the strategy.
+// If no component descriptor exists, the new one is returned.
+func Merge(existing, newCd *cdv2.ComponentDescriptor, strategy Strategy) (*cdv2.ComponentDescriptor, error) {
+	if existing == nil {
+		return newCd, nil
+	}
+	switch strategy {
+	case StrategyFail:
+		return nil, fmt.Errorf("%s:%s: %w", newCd.Name, newCd.Version, ErrAlreadyExists)
+	case StrategyOverwrite:
+		return newCd, nil
+	case StrategyMergeResourcesByIdentity:
+		return mergeByIdentity(existing, newCd)
+	case StrategyAppendSignaturesOnly:
+		return appendSignatures(existing, newCd)
+	default:
+		return nil, fmt.Errorf("unknown merge strategy %q", strategy)
+	}
+}
+
+// ResolveAndMerge resolves the component descriptor that already exists in the target repository
+// and merges the new component descriptor into it.
+// The new component descriptor is returned if the component version does not exist in the target repository.
+func ResolveAndMerge(ctx context.Context, resolver ctf.ComponentResolver, repoCtx cdv2.Repository, newCd *cdv2.ComponentDescriptor, strategy Strategy) (*cdv2.ComponentDescriptor, error) {
+	if strategy == StrategyOverwrite {
+		return newCd, nil
+	}
+	existing, err := resolver.Resolve(ctx, repoCtx, newCd.Name, newCd.Version)
+	if err != nil {
+		if IsNotFound(err) {
+			return newCd, nil
+		}
+		return nil, fmt.Errorf("unable to resolve existing component descriptor %s:%s: %w", newCd.Name, newCd.Version, err)
+	}
+	return Merge(existing, newCd, strategy)
+}
+
+// IsNotFound checks whether the error of a component resolver reports that the component version does not exist.
+// Resolvers report missing component versions with the ctf not found error, the containerd not found error
+// or the http status of the registry.
+func IsNotFound(err error) bool {
+	if errors.Is(err, ctf.NotFoundError) || errdefs.IsNotFound(err) {
+		return true
+	}
+	var transportErr *transport.Error
+	return errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound
+}
+
+func mergeByIdentity(existing, newCd *cdv2.ComponentDescriptor) (*cdv2.ComponentDescriptor, error) {
+	merged := newCd.DeepCopy()
+	for _, res := range existing.Resources {
+		if merged.GetResourceIndex(res) == -1 {
+			merged.Resources = append(merged.Resources, res)
+		}
+	}
+	for _, src := range existing.Sources {
+		if merged.GetSourceIndex(src) == -1 {
+			merged.Sources = append(merged.Sources, src)
+		}
+	}
+	for _, ref := range existing.ComponentReferences {
+		if merged.GetComponentReferenceIndex(ref) == -1 {
+			merged.ComponentReferences = append(merged.ComponentReferences, ref)
+		}
+	}
+
+	// signatures of the existing component descriptor are only kept if they still match the merged content.
+	for _, sig := range existing.Signatures {
+		if hasSignature(merged, sig.Name) {
+			continue
+		}
+		hasher, err := cdv2Sign.HasherForName(sig.Digest.HashAlgorithm)
+		if err != nil {
+			continue
+		}
+		digest, err := cdv2Sign.HashForComponentDescriptor(*merged, *hasher)
+		if err != nil || digest.Value != sig.Digest.Value {
+			continue
+		}
+		merged.Signatures = append(merged.Signatures, sig)
+	}
+	return merged, nil
+}
+
+func appendSignatures(existing, newCd *cdv2.ComponentDescriptor) (*cdv2.ComponentDescriptor, error) {
+	equal, err := contentEqual(existing, newCd)
+	if err != nil {
+		return nil, err
+	}
+	if !equal {
+		return nil, fmt.Errorf("component descriptor %s:%s differs from the existing one, only signatures can be appended", newCd.Name, newCd.Version)
+	}
+	merged := existing.DeepCopy()
+	for _, sig := range newCd.Signatures {
+		if !hasSignature(merged, sig.Name) {
+			merged.Signatures = append(merged.Signatures, sig)
+		}
+	}
+	return merged, nil
+}
+
+// contentEqual compares two component descriptors without their signatures and repository contexts.
+func contentEqual(a, b *cdv2.ComponentDescriptor) (bool, error) {
+	encode := func(cd *cdv2.ComponentDescriptor) ([]byte, error) {
+		c := cd.DeepCopy()
+		c.Signatures = nil
+		c.RepositoryContexts = nil
+		return json.Marshal(c)
+	}
+	aData, err := encode(a)
+	if err != nil {
+		return false, fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	bData, err := encode(b)
+	if err != nil {
+		return false, fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	return bytes.Equal(aData, bData), nil
+}
+
+func hasSignature(cd *cdv2.ComponentDescriptor, name string) bool {
+	for _, sig := range cd.Signatures {
+		if sig.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package merge_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/containerd/containerd/errdefs"
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/merge"
+)
+
+func newComponentDescriptor(resources ...string) *cdv2.ComponentDescriptor {
+	cd := &cdv2.ComponentDescriptor{}
+	cd.Name = "github.com/component-cli/test-component"
+	cd.Version = "0.1.0"
+	for _, name := range resources {
+		res := cdv2.Resource{}
+		res.Name = name
+		res.Version = "0.1.0"
+		res.Type = "plainText"
+		res.Relation = cdv2.ExternalRelation
+		cd.Resources = append(cd.Resources, res)
+	}
+	return cd
+}
+
+// errorResolver is a component resolver that always fails with the given error.
+type errorResolver struct {
+	err error
+}
+
+func (r errorResolver) Resolve(_ context.Context, _ cdv2.Repository, _, _ string) (*cdv2.ComponentDescriptor, error) {
+	return nil, r.err
+}
+
+func (r errorResolver) ResolveWithBlobResolver(_ context.Context, _ cdv2.Repository, _, _ string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	return nil, nil, r.err
+}
+
+var _ = Describe("merge", func() {
+
+	It("should return the new component descriptor if no component descriptor exists", func() {
+		newCd := newComponentDescriptor("a")
+		merged, err := merge.Merge(nil, newCd, merge.StrategyFail)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(merged).To(Equal(newCd))
+	})
+
+	It("should fail if the component version already exists", func() {
+		_, err := merge.Merge(newComponentDescriptor("a"), newComponentDescriptor("a"), merge.StrategyFail)
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, merge.ErrAlreadyExists)).To(BeTrue())
+	})
+
+	It("should merge resources by their identity", func() {
+		existing := newComponentDescriptor("a", "b")
+		existing.Signatures = []cdv2.Signature{{Name: "outdated", Digest: cdv2.DigestSpec{HashAlgorithm: "sha256", Value: "abc"}}}
+		newCd := newComponentDescriptor("b", "c")
+		newCd.Resources[0].Labels = cdv2.Labels{{Name: "new", Value: []byte(`true`)}}
+
+		merged, err := merge.Merge(existing, newCd, merge.StrategyMergeResourcesByIdentity)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(merged.Resources).To(HaveLen(3))
+		Expect(merged.Resources[0].Name).To(Equal("b"))
+		Expect(merged.Resources[0].Labels).To(HaveLen(1))
+		Expect(merged.Resources[2].Name).To(Equal("a"))
+		Expect(merged.Signatures).To(BeEmpty())
+	})
+
+	It("should append signatures to an equal component descriptor", func() {
+		existing := newComponentDescriptor("a")
+		existing.Signatures = []cdv2.Signature{{Name: "first"}}
+		newCd := newComponentDescriptor("a")
+		newCd.Signatures = []cdv2.Signature{{Name: "first"}, {Name: "second"}}
+
+		merged, err := merge.Merge(existing, newCd, merge.StrategyAppendSignaturesOnly)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(merged.Signatures).To(HaveLen(2))
+		Expect(merged.Signatures[1].Name).To(Equal("second"))
+
+		_, err = merge.Merge(existing, newComponentDescriptor("a", "b"), merge.StrategyAppendSignaturesOnly)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject unknown strategies", func() {
+		strategy, err := merge.ParseStrategy("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strategy).To(Equal(merge.StrategyOverwrite))
+		_, err = merge.ParseStrategy("unknown")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return the new component descriptor if the resolver reports a not existing component version", func() {
+		newCd := newComponentDescriptor("a")
+		repoCtx := cdv2.NewOCIRegistryRepository("example.com/components", "")
+		for _, err := range []error{
+			ctf.NotFoundError,
+			fmt.Errorf("unable to fetch manifest: %w", errdefs.ErrNotFound),
+			fmt.Errorf("unable to fetch manifest: %w", &transport.Error{StatusCode: http.StatusNotFound}),
+		} {
+			merged, err := merge.ResolveAndMerge(context.TODO(), errorResolver{err: err}, repoCtx, newCd, merge.StrategyFail)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(merged).To(Equal(newCd))
+		}
+
+		_, err := merge.ResolveAndMerge(context.TODO(), errorResolver{err: &transport.Error{StatusCode: http.StatusUnauthorized}}, repoCtx, newCd, merge.StrategyFail)
+		Expect(err).To(HaveOccurred())
+	})
+
+})
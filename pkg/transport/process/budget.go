@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package process
+
+import (
+	"context"
+	"sync"
+)
+
+// BlobBudget limits the size of the blobs that concurrent processing pipelines hold in memory and in temporary files.
+// A single budget is meant to be shared by all pipelines of a transport via WithBlobBudget.
+//
+// Processor messages are kept in memory as long as the memory limit is not exceeded and are spilled to
+// temporary files otherwise.
+// The disk limit applies backpressure: a pipeline that does not hold any temporary files waits until enough
+// disk budget is released by other pipelines.
+// A pipeline that already holds temporary files is never blocked so that pipelines cannot wait on each other.
+type BlobBudget struct {
+	memoryLimit int64
+	diskLimit   int64
+
+	mux      sync.Mutex
+	memory   int64
+	disk     int64
+	released chan struct{}
+}
+
+// NewBlobBudget creates a new blob budget.
+// A memory limit of 0 disables in-memory processor messages, a disk limit of 0 disables the limit for temporary files.
+func NewBlobBudget(memoryLimit, diskLimit int64) *BlobBudget {
+	return &BlobBudget{
+		memoryLimit: memoryLimit,
+		diskLimit:   diskLimit,
+		released:    make(chan struct{}),
+	}
+}
+
+// MemoryUsage returns the number of bytes that are currently held in memory.
+func (b *BlobBudget) MemoryUsage() int64 {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.memory
+}
+
+// DiskUsage returns the number of bytes that are currently held in temporary files.
+func (b *BlobBudget) DiskUsage() int64 {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.disk
+}
+
+// TryAcquireMemory reserves size bytes of memory.
+// False is returned if the reservation would exceed the memory limit.
+func (b *BlobBudget) TryAcquireMemory(size int64) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.memory+size > b.memoryLimit {
+		return false
+	}
+	b.memory += size
+	return true
+}
+
+// ReleaseMemory releases size bytes of previously reserved memory.
+func (b *BlobBudget) ReleaseMemory(size int64) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.memory -= size
+}
+
+// Acquire reserves size bytes of disk and blocks until the reservation fits into the disk limit.
+// A reservation that is larger than the limit is granted if no other disk space is reserved.
+func (b *BlobBudget) Acquire(ctx context.Context, size int64) error {
+	for {
+		b.mux.Lock()
+		if b.diskLimit == 0 || b.disk == 0 || b.disk+size <= b.diskLimit {
+			b.disk += size
+			b.mux.Unlock()
+			return nil
+		}
+		released := b.released
+		b.mux.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+// Grow reserves size bytes of disk without blocking.
+// It must only be used by holders of an existing reservation, which may exceed the disk limit.
+func (b *BlobBudget) Grow(size int64) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.disk += size
+}
+
+// Release releases size bytes of previously reserved disk and wakes up all waiting reservations.
+func (b *BlobBudget) Release(size int64) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.disk -= size
+	close(b.released)
+	b.released = make(chan struct{})
+}
+
+type blobBudgetContextKey struct{}
+
+// WithBlobBudget returns a context that limits the blob usage of all pipelines that process with it.
+func WithBlobBudget(ctx context.Context, budget *BlobBudget) context.Context {
+	return context.WithValue(ctx, blobBudgetContextKey{}, budget)
+}
+
+// blobBudgetFromContext returns the blob budget of the context or nil if no budget is defined.
+func blobBudgetFromContext(ctx context.Context) *BlobBudget {
+	budget, _ := ctx.Value(blobBudgetContextKey{}).(*BlobBudget)
+	return budget
+}
@@ -7,6 +7,9 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"sigs.k8s.io/yaml"
+
 	"github.com/gardener/component-cli/ociclient"
 	"github.com/gardener/component-cli/ociclient/cache"
 	"github.com/gardener/component-cli/pkg/transport/process"
@@ -17,15 +20,37 @@ const (
 	// LocalOCIBlobDownloaderType defines the type of a local oci blob downloader
 	LocalOCIBlobDownloaderType = "LocalOciBlobDownloader"
 
+	// LocalFilesystemBlobDownloaderType defines the type of a local filesystem blob downloader
+	LocalFilesystemBlobDownloaderType = "LocalFilesystemBlobDownloader"
+
 	// OCIArtifactDownloaderType defines the type of an oci artifact downloader
 	OCIArtifactDownloaderType = "OciArtifactDownloader"
+
+	// GitRepositoryDownloaderType defines the type of a git repository downloader
+	GitRepositoryDownloaderType = "GitRepositoryDownloader"
 )
 
+// registry contains the downloaders that are registered by external Go code.
+var registry = process.NewProcessorRegistry()
+
+// Register registers a downloader type that is not built into the component-cli.
+// Registered downloaders can be used like built-in downloaders in the transport config,
+// e.g. to download resources with custom access types.
+// Register is meant to be called during initialization before any downloader factory is used.
+func Register(downloaderType string, factory process.ProcessorFactoryFunc) error {
+	switch downloaderType {
+	case LocalOCIBlobDownloaderType, LocalFilesystemBlobDownloaderType, OCIArtifactDownloaderType, GitRepositoryDownloaderType, extensions.ExecutableType, extensions.ContainerType:
+		return fmt.Errorf("downloader type %s is a built-in type", downloaderType)
+	}
+	return registry.Register(downloaderType, factory)
+}
+
 // NewDownloaderFactory creates a new downloader factory
 // How to add a new downloader (without using extension mechanism):
 // - Add Go file to downloader package which contains the source code of the new downloader
 // - Add string constant for new downloader type -> will be used in DownloaderFactory.Create()
 // - Add source code for creating new downloader to DownloaderFactory.Create() method
+// Alternatively, external Go code can add a new downloader with Register().
 func NewDownloaderFactory(client ociclient.Client, ocicache cache.Cache) *DownloaderFactory {
 	return &DownloaderFactory{
 		client: client,
@@ -44,11 +69,36 @@ func (f *DownloaderFactory) Create(downloaderType string, spec *json.RawMessage)
 	switch downloaderType {
 	case LocalOCIBlobDownloaderType:
 		return NewLocalOCIBlobDownloader(f.client)
+	case LocalFilesystemBlobDownloaderType:
+		return f.createLocalFilesystemBlobDownloader(spec)
 	case OCIArtifactDownloaderType:
 		return NewOCIArtifactDownloader(f.client, f.cache)
+	case GitRepositoryDownloaderType:
+		return NewGitRepositoryDownloader()
 	case extensions.ExecutableType:
 		return extensions.CreateExecutable(spec)
+	case extensions.ContainerType:
+		return extensions.CreateContainer(spec)
 	default:
+		if factory, ok := registry.Get(downloaderType); ok {
+			return factory(spec)
+		}
 		return nil, fmt.Errorf("unknown downloader type %s", downloaderType)
 	}
 }
+
+func (f *DownloaderFactory) createLocalFilesystemBlobDownloader(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type downloaderSpec struct {
+		// Path is the path to the ctf archive that contains the blobs.
+		Path string `json:"path"`
+	}
+
+	var spec downloaderSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	return NewLocalFilesystemBlobDownloader(osfs.New(), spec.Path)
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package downloaders
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+// DefaultGitProtocols are the transport protocols git is allowed to use when fetching a repository.
+var DefaultGitProtocols = []string{"https", "ssh"}
+
+type gitRepositoryDownloader struct {
+	gitBin           string
+	allowedProtocols []string
+}
+
+// NewGitRepositoryDownloader creates a new gitRepositoryDownloader.
+// The downloader fetches the commit of resources with github access and streams the tree of the commit as tar archive.
+// Git is only allowed to use the given transport protocols, DefaultGitProtocols are used if none are given.
+// The git executable has to be available in the PATH.
+func NewGitRepositoryDownloader(allowedProtocols ...string) (process.ResourceStreamProcessor, error) {
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		return nil, fmt.Errorf("unable to find git executable: %w", err)
+	}
+	if len(allowedProtocols) == 0 {
+		allowedProtocols = DefaultGitProtocols
+	}
+
+	obj := gitRepositoryDownloader{
+		gitBin:           gitBin,
+		allowedProtocols: allowedProtocols,
+	}
+	return &obj, nil
+}
+
+func (d *gitRepositoryDownloader) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, _, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+
+	if res.Access == nil {
+		return errors.New("resource access is nil")
+	}
+
+	if res.Access.GetType() != cdv2.GitHubAccessType {
+		return fmt.Errorf("unsupported access type: %s", res.Access.Type)
+	}
+
+	gitAccess := &cdv2.GitHubAccess{}
+	if err := res.Access.DecodeInto(gitAccess); err != nil {
+		return fmt.Errorf("unable to decode resource access: %w", err)
+	}
+
+	tmpfile, err := ioutil.TempFile("", "")
+	if err != nil {
+		return fmt.Errorf("unable to create tempfile: %w", err)
+	}
+	defer tmpfile.Close()
+
+	if err := ArchiveGitRepository(ctx, d.gitBin, *gitAccess, d.allowedProtocols, tmpfile); err != nil {
+		return fmt.Errorf("unable to archive git repository: %w", err)
+	}
+
+	if _, err := tmpfile.Seek(0, 0); err != nil {
+		return fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, tmpfile, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+// ArchiveGitRepository fetches the commit (or the ref if no commit is defined) of the given access
+// and writes the tree of the commit as tar archive to the writer.
+// The archive is created with "git archive", so it is deterministic for a commit:
+// all entries are ordered and use the commit time as modification time.
+// Git is only allowed to use the given transport protocols.
+// As sources share the access types with resources, the function can also be used to archive sources.
+func ArchiveGitRepository(ctx context.Context, gitBin string, access cdv2.GitHubAccess, allowedProtocols []string, w io.Writer) error {
+	if len(access.RepoURL) == 0 {
+		return errors.New("repoUrl must not be empty")
+	}
+	// the url and the revision are passed as arguments to git and must not be interpreted as options
+	if strings.HasPrefix(access.RepoURL, "-") {
+		return fmt.Errorf("invalid repoUrl %q: must not start with \"-\"", access.RepoURL)
+	}
+	rev := access.Commit
+	if len(rev) == 0 {
+		rev = access.Ref
+	}
+	if len(rev) == 0 {
+		return errors.New("either a commit or a ref has to be defined")
+	}
+	if strings.HasPrefix(rev, "-") {
+		return fmt.Errorf("invalid revision %q: must not start with \"-\"", rev)
+	}
+
+	// deny all protocols (e.g. "ext" or "file") that are not explicitly allowed
+	config := []string{"-c", "protocol.allow=never"}
+	for _, protocol := range allowedProtocols {
+		config = append(config, "-c", fmt.Sprintf("protocol.%s.allow=always", protocol))
+	}
+
+	repoDir, err := ioutil.TempDir("", "git-")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(repoDir)
+
+	git := func(stdout io.Writer, args ...string) error {
+		var stderr bytes.Buffer
+		gitArgs := append(append([]string{"-C", repoDir}, config...), args...)
+		cmd := exec.CommandContext(ctx, gitBin, gitArgs...)
+		cmd.Stdout = stdout
+		cmd.Stderr = &stderr
+		// never prompt for credentials in a pipeline
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("git %s failed: %w: %s", args[0], err, stderr.String())
+		}
+		return nil
+	}
+
+	if err := git(nil, "init", "-q"); err != nil {
+		return err
+	}
+	if err := git(nil, "fetch", "-q", "--depth", "1", "--", access.RepoURL, rev); err != nil {
+		return err
+	}
+	if err := git(w, "archive", "--format=tar", "FETCH_HEAD"); err != nil {
+		return err
+	}
+	return nil
+}
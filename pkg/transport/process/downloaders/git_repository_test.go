@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package downloaders_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/downloaders"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("gitRepository", func() {
+
+	var (
+		repoDir string
+		commit  string
+	)
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", repoDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		Expect(err).ToNot(HaveOccurred(), string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	BeforeEach(func() {
+		var err error
+		repoDir, err = ioutil.TempDir("", "git-repo-")
+		Expect(err).ToNot(HaveOccurred())
+
+		git("init", "-q")
+		Expect(ioutil.WriteFile(filepath.Join(repoDir, "README.md"), []byte("Hello World"), os.ModePerm)).To(Succeed())
+		git("add", "README.md")
+		git("commit", "-q", "-m", "initial commit")
+		commit = git("rev-parse", "HEAD")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(repoDir)).To(Succeed())
+	})
+
+	Context("Process", func() {
+
+		It("should download the commit and stream a deterministic archive", func() {
+			acc, err := cdv2.NewUnstructured(cdv2.NewGitHubAccess("file://"+repoDir, "", commit))
+			Expect(err).ToNot(HaveOccurred())
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "sources",
+					Version: "v0.1.0",
+					Type:    cdv2.GitType,
+				},
+				Relation: cdv2.LocalRelation,
+				Access:   &acc,
+			}
+			cd := cdv2.ComponentDescriptor{}
+			cd.Name = "example.com/component"
+			cd.Version = "v0.1.0"
+
+			d, err := downloaders.NewGitRepositoryDownloader("file")
+			Expect(err).ToNot(HaveOccurred())
+
+			download := func() []byte {
+				inProcessorMsg := bytes.NewBuffer([]byte{})
+				Expect(utils.WriteProcessorMessage(cd, res, nil, inProcessorMsg)).To(Succeed())
+
+				outProcessorMsg := bytes.NewBuffer([]byte{})
+				Expect(d.Process(context.TODO(), inProcessorMsg, outProcessorMsg)).To(Succeed())
+
+				_, actualRes, resBlobReader, err := utils.ReadProcessorMessage(outProcessorMsg)
+				Expect(err).ToNot(HaveOccurred())
+				defer resBlobReader.Close()
+				Expect(actualRes.Name).To(Equal(res.Name))
+
+				data, err := ioutil.ReadAll(resBlobReader)
+				Expect(err).ToNot(HaveOccurred())
+				return data
+			}
+
+			archive := download()
+			Expect(download()).To(Equal(archive))
+
+			tr := tar.NewReader(bytes.NewReader(archive))
+			files := map[string]string{}
+			for {
+				header, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				Expect(err).ToNot(HaveOccurred())
+				data, err := ioutil.ReadAll(tr)
+				Expect(err).ToNot(HaveOccurred())
+				files[header.Name] = string(data)
+			}
+			Expect(files).To(HaveKeyWithValue("README.md", "Hello World"))
+		})
+
+		It("should only allow the default protocols", func() {
+			err := downloaders.ArchiveGitRepository(context.TODO(), "git", *cdv2.NewGitHubAccess("file://"+repoDir, "", commit), downloaders.DefaultGitProtocols, ioutil.Discard)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("transport 'file' not allowed"))
+		})
+
+		It("should reject urls and revisions that could be interpreted as options", func() {
+			err := downloaders.ArchiveGitRepository(context.TODO(), "git", *cdv2.NewGitHubAccess("--upload-pack=touch /tmp/pwned", "", commit), downloaders.DefaultGitProtocols, ioutil.Discard)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid repoUrl"))
+
+			err = downloaders.ArchiveGitRepository(context.TODO(), "git", *cdv2.NewGitHubAccess("https://example.com/repo.git", "--upload-pack=touch /tmp/pwned", ""), downloaders.DefaultGitProtocols, ioutil.Discard)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid revision"))
+		})
+
+		It("should return an error if the resource has no access", func() {
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "sources",
+					Version: "v0.1.0",
+					Type:    cdv2.GitType,
+				},
+				Relation: cdv2.LocalRelation,
+			}
+			inProcessorMsg := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cdv2.ComponentDescriptor{}, res, nil, inProcessorMsg)).To(Succeed())
+
+			d, err := downloaders.NewGitRepositoryDownloader()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(d.Process(context.TODO(), inProcessorMsg, bytes.NewBuffer([]byte{}))).To(HaveOccurred())
+		})
+
+	})
+})
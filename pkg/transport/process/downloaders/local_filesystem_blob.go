@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package downloaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type localFilesystemBlobDownloader struct {
+	fs      vfs.FileSystem
+	ctfPath string
+}
+
+// NewLocalFilesystemBlobDownloader creates a new localFilesystemBlobDownloader that reads the blobs of resources
+// with a localFilesystemBlob access from the component archives of the ctf archive at the given path,
+// e.g. a ctf archive that is written by the ctf archive uploader.
+func NewLocalFilesystemBlobDownloader(fs vfs.FileSystem, ctfPath string) (process.ResourceStreamProcessor, error) {
+	if fs == nil {
+		return nil, errors.New("fs must not be nil")
+	}
+	if len(ctfPath) == 0 {
+		return nil, errors.New("path must not be empty")
+	}
+
+	obj := localFilesystemBlobDownloader{
+		fs:      fs,
+		ctfPath: ctfPath,
+	}
+	return &obj, nil
+}
+
+func (d *localFilesystemBlobDownloader) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, _, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+
+	if res.Access.GetType() != cdv2.LocalFilesystemBlobType {
+		return fmt.Errorf("unsupported access type: %s", res.Access.Type)
+	}
+
+	tmpfile, err := ioutil.TempFile("", "")
+	if err != nil {
+		return fmt.Errorf("unable to create tempfile: %w", err)
+	}
+	defer func() {
+		_ = tmpfile.Close()
+		_ = os.Remove(tmpfile.Name())
+	}()
+
+	if err := d.fetchLocalFilesystemBlob(ctx, cd, res, tmpfile); err != nil {
+		return fmt.Errorf("unable to fetch blob: %w", err)
+	}
+
+	if _, err := tmpfile.Seek(0, 0); err != nil {
+		return fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, tmpfile, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+func (d *localFilesystemBlobDownloader) fetchLocalFilesystemBlob(ctx context.Context, cd *cdv2.ComponentDescriptor, res cdv2.Resource, w io.Writer) error {
+	ctfArchive, err := ctf.NewCTF(d.fs, d.ctfPath)
+	if err != nil {
+		return fmt.Errorf("unable to open ctf archive %s: %w", d.ctfPath, err)
+	}
+	defer ctfArchive.Close()
+
+	found := false
+	err = ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+		if found || ca.ComponentDescriptor.Name != cd.Name || ca.ComponentDescriptor.Version != cd.Version {
+			return nil
+		}
+		found = true
+		if _, err := ca.Resolve(ctx, res, w); err != nil {
+			return fmt.Errorf("unable to to resolve blob: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("component %s:%s not found in ctf archive %s", cd.Name, cd.Version, d.ctfPath)
+	}
+	return nil
+}
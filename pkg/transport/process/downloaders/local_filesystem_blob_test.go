@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package downloaders_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/pkg/transport/process/downloaders"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+	cliutils "github.com/gardener/component-cli/pkg/utils"
+)
+
+var _ = Describe("localFilesystemBlob", func() {
+
+	Context("Process", func() {
+
+		It("should read and stream the resource blob from the ctf archive", func() {
+			data := []byte("Hello World")
+			cd := &cdv2.ComponentDescriptor{}
+			cd.Metadata.Version = cdv2.SchemaVersion
+			cd.Name = "github.com/component-cli/test-component"
+			cd.Version = "0.1.0"
+			cd.Provider = "internal"
+			ca := ctf.NewComponentArchive(cd, memoryfs.New())
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "res", Version: "0.1.0", Type: "plain-text"},
+				Relation:           cdv2.LocalRelation,
+			}
+			Expect(ca.AddResource(&res, ctf.BlobInfo{
+				MediaType: "text/plain",
+				Digest:    digest.FromBytes(data).String(),
+				Size:      int64(len(data)),
+			}, bytes.NewReader(data))).To(Succeed())
+
+			fs := memoryfs.New()
+			ctfArchive, err := cliutils.OpenOrCreateCTF(logr.Discard(), fs, "/component.ctf")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ctfArchive.AddComponentArchive(ca, ctf.ArchiveFormatTar)).To(Succeed())
+			Expect(ctfArchive.Write()).To(Succeed())
+			Expect(ctfArchive.Close()).To(Succeed())
+
+			inProcessorMsg := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(*cd, res, nil, inProcessorMsg)).To(Succeed())
+
+			d, err := downloaders.NewLocalFilesystemBlobDownloader(fs, "/component.ctf")
+			Expect(err).ToNot(HaveOccurred())
+
+			outProcessorMsg := bytes.NewBuffer([]byte{})
+			Expect(d.Process(context.TODO(), inProcessorMsg, outProcessorMsg)).To(Succeed())
+
+			_, actualRes, resBlobReader, err := utils.ReadProcessorMessage(outProcessorMsg)
+			Expect(err).ToNot(HaveOccurred())
+			defer resBlobReader.Close()
+			Expect(actualRes.Name).To(Equal(res.Name))
+			Expect(actualRes.Access.GetType()).To(Equal(cdv2.LocalFilesystemBlobType))
+
+			resBlob := bytes.NewBuffer([]byte{})
+			_, err = io.Copy(resBlob, resBlobReader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resBlob.Bytes()).To(Equal(data))
+		})
+
+	})
+
+})
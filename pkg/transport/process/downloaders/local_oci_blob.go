@@ -11,9 +11,9 @@ import (
 	"io/ioutil"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
-	cdoci "github.com/gardener/component-spec/bindings-go/oci"
 
 	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/pkg/components/schema"
 	"github.com/gardener/component-cli/pkg/transport/process"
 	"github.com/gardener/component-cli/pkg/transport/process/utils"
 )
@@ -71,7 +71,7 @@ func (d *localOCIBlobDownloader) fetchLocalOCIBlob(ctx context.Context, cd *cdv2
 		return fmt.Errorf("unable to decode repository context: %w", err)
 	}
 
-	resolver := cdoci.NewResolver(d.client)
+	resolver := schema.NewResolver(d.client)
 	_, blobResolver, err := resolver.ResolveWithBlobResolver(ctx, &repoctx, cd.Name, cd.Version)
 	if err != nil {
 		return fmt.Errorf("unable to resolve component descriptor: %w", err)
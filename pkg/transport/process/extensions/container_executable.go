@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package extensions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+const (
+	// DefaultDockerHost is the address of the docker daemon that is used if DOCKER_HOST is not set.
+	DefaultDockerHost = "unix:///var/run/docker.sock"
+
+	// containerSocketDir is the directory in the container that contains the socket of the processor server.
+	containerSocketDir = "/run/component-cli"
+
+	// dockerAPIVersion is the docker engine api version that is used.
+	dockerAPIVersion = "v1.40"
+)
+
+type containerExecutable struct {
+	image      string
+	args       []string
+	env        []string
+	dockerHost string
+	protocol   Protocol
+	client     *http.Client
+}
+
+// NewContainerExecutable returns a resource processor extension which runs a container image via the docker engine api
+// when calling Process(). It communicates with the processor in the container via Unix Domain Sockets
+// that are shared with the container through a bind mounted directory, like the processors of NewUnixDomainSocketExecutable.
+// The docker daemon must run on the same host, e.g. bind mounted sockets are not supported by docker desktop.
+// The docker daemon is defined by dockerHost, DOCKER_HOST or DefaultDockerHost. Only unix sockets are supported.
+// The protocol defines how the processor message is exchanged with the processor.
+func NewContainerExecutable(image string, args []string, env map[string]string, dockerHost string, protocol Protocol) (process.ResourceStreamProcessor, error) {
+	if len(image) == 0 {
+		return nil, fmt.Errorf("image must not be empty")
+	}
+	if _, ok := env[ProcessorServerAddressEnv]; ok {
+		return nil, fmt.Errorf("the env variable %s is not allowed to be set manually", ProcessorServerAddressEnv)
+	}
+
+	parsedEnv := []string{}
+	for k, v := range env {
+		parsedEnv = append(parsedEnv, fmt.Sprintf("%s=%s", k, v))
+	}
+	parsedEnv = append(parsedEnv, protocol.env()...)
+
+	if len(dockerHost) == 0 {
+		dockerHost = os.Getenv("DOCKER_HOST")
+	}
+	if len(dockerHost) == 0 {
+		dockerHost = DefaultDockerHost
+	}
+	if !strings.HasPrefix(dockerHost, "unix://") {
+		return nil, fmt.Errorf("unsupported docker host %q: only unix sockets are supported", dockerHost)
+	}
+	socketPath := strings.TrimPrefix(dockerHost, "unix://")
+
+	e := containerExecutable{
+		image:      image,
+		args:       args,
+		env:        parsedEnv,
+		dockerHost: dockerHost,
+		protocol:   protocol,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+
+	return &e, nil
+}
+
+func (e *containerExecutable) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	// the socket directory is created for every run so that parallel runs of the same processor do not interfere.
+	socketDir, err := ioutil.TempDir("", "processor-")
+	if err != nil {
+		return fmt.Errorf("unable to create socket directory: %w", err)
+	}
+	defer os.RemoveAll(socketDir)
+	// the processor in the container may run as any user.
+	if err := os.Chmod(socketDir, 0777); err != nil {
+		return fmt.Errorf("unable to set permissions of socket directory: %w", err)
+	}
+	socketName := fmt.Sprintf("%s.sock", utils.RandomString(8))
+
+	id, err := e.createContainer(ctx, socketDir, socketName)
+	if err != nil {
+		return fmt.Errorf("unable to create processor container: %w", err)
+	}
+	defer func() {
+		// the context may already be done, therefore the container is removed with a new context.
+		if err := e.removeContainer(context.Background(), id); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to remove container %s: %s", id, err.Error())
+		}
+	}()
+
+	if err := e.do(ctx, http.MethodPost, fmt.Sprintf("/containers/%s/start", id), nil, nil, nil); err != nil {
+		return fmt.Errorf("unable to start processor container: %w", err)
+	}
+
+	conn, err := tryConnect(filepath.Join(socketDir, socketName))
+	if err != nil {
+		return fmt.Errorf("unable to connect to processor: %w", err)
+	}
+	defer conn.Close()
+
+	if err := e.protocol.process(ctx, conn, r, w); err != nil {
+		return err
+	}
+
+	// stopping the container sends a SIGTERM to the processor.
+	// extension servers must implement ordinary shutdown (!)
+	if err := e.do(ctx, http.MethodPost, fmt.Sprintf("/containers/%s/stop", id), url.Values{"t": []string{"10"}}, nil, nil); err != nil {
+		return fmt.Errorf("unable to stop processor container: %w", err)
+	}
+
+	waitResult := struct {
+		StatusCode int `json:"StatusCode"`
+	}{}
+	if err := e.do(ctx, http.MethodPost, fmt.Sprintf("/containers/%s/wait", id), nil, nil, &waitResult); err != nil {
+		return fmt.Errorf("unable to wait for processor container: %w", err)
+	}
+	if waitResult.StatusCode != 0 {
+		return fmt.Errorf("processor container exited with status code %d", waitResult.StatusCode)
+	}
+
+	return nil
+}
+
+// createContainer creates the processor container. The image is pulled if it does not exist.
+func (e *containerExecutable) createContainer(ctx context.Context, socketDir, socketName string) (string, error) {
+	type hostConfig struct {
+		Binds []string `json:"Binds"`
+	}
+	body := struct {
+		Image      string     `json:"Image"`
+		Cmd        []string   `json:"Cmd,omitempty"`
+		Env        []string   `json:"Env"`
+		HostConfig hostConfig `json:"HostConfig"`
+	}{
+		Image: e.image,
+		Cmd:   e.args,
+		Env:   append(append([]string{}, e.env...), fmt.Sprintf("%s=%s", ProcessorServerAddressEnv, filepath.Join(containerSocketDir, socketName))),
+		HostConfig: hostConfig{
+			Binds: []string{fmt.Sprintf("%s:%s", socketDir, containerSocketDir)},
+		},
+	}
+
+	created := struct {
+		ID string `json:"Id"`
+	}{}
+	err := e.do(ctx, http.MethodPost, "/containers/create", nil, body, &created)
+	if err != nil && isDockerNotFound(err) {
+		if err := e.pullImage(ctx); err != nil {
+			return "", err
+		}
+		err = e.do(ctx, http.MethodPost, "/containers/create", nil, body, &created)
+	}
+	if err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (e *containerExecutable) pullImage(ctx context.Context) error {
+	// the pull progress is streamed and the pull is finished when the stream is closed.
+	if err := e.do(ctx, http.MethodPost, "/images/create", url.Values{"fromImage": []string{e.image}}, nil, ioutil.Discard); err != nil {
+		return fmt.Errorf("unable to pull image %s: %w", e.image, err)
+	}
+	return nil
+}
+
+func (e *containerExecutable) removeContainer(ctx context.Context, id string) error {
+	return e.do(ctx, http.MethodDelete, fmt.Sprintf("/containers/%s", id), url.Values{"force": []string{"true"}}, nil, nil)
+}
+
+// dockerError is an error response of the docker engine api.
+type dockerError struct {
+	StatusCode int
+	Message    string `json:"message"`
+}
+
+func (e *dockerError) Error() string {
+	return fmt.Sprintf("docker api returned status code %d: %s", e.StatusCode, e.Message)
+}
+
+func isDockerNotFound(err error) bool {
+	dockerErr, ok := err.(*dockerError)
+	return ok && dockerErr.StatusCode == http.StatusNotFound
+}
+
+// do calls the docker engine api. The response is decoded into out if out is not nil.
+// If out is an io.Writer, the response body is copied to it.
+func (e *containerExecutable) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("unable to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	u := url.URL{
+		Scheme:   "http",
+		Host:     "docker",
+		Path:     fmt.Sprintf("/%s%s", dockerAPIVersion, path),
+		RawQuery: query.Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to call docker daemon at %s: %w", e.dockerHost, err)
+	}
+	defer resp.Body.Close()
+
+	// not modified is returned if a container is already started or stopped.
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		dockerErr := &dockerError{}
+		data, _ := ioutil.ReadAll(resp.Body)
+		if err := json.Unmarshal(data, dockerErr); err != nil {
+			dockerErr.Message = string(data)
+		}
+		dockerErr.StatusCode = resp.StatusCode
+		return dockerErr
+	}
+
+	switch o := out.(type) {
+	case nil:
+		return nil
+	case io.Writer:
+		if _, err := io.Copy(o, resp.Body); err != nil {
+			return fmt.Errorf("unable to read response: %w", err)
+		}
+		return nil
+	default:
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("unable to decode response: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package extensions_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/extensions"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+// fakeDockerDaemon emulates the docker engine api. The "container" is an echo server
+// that listens on the socket in the bind mounted directory.
+type fakeDockerDaemon struct {
+	mux       sync.Mutex
+	calls     []string
+	pulled    bool
+	socketDir string
+	env       []string
+	server    *utils.UnixDomainSocketServer
+}
+
+func (d *fakeDockerDaemon) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	path := strings.TrimPrefix(req.URL.Path, "/v1.40")
+	d.calls = append(d.calls, req.Method+" "+path)
+
+	switch {
+	case path == "/images/create":
+		d.pulled = true
+		_, _ = w.Write([]byte(`{"status":"pulled"}`))
+	case path == "/containers/create":
+		if !d.pulled {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"No such image"}`))
+			return
+		}
+		body := struct {
+			Env        []string
+			HostConfig struct {
+				Binds []string
+			}
+		}{}
+		Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+		d.env = body.Env
+		d.socketDir = strings.Split(body.HostConfig.Binds[0], ":")[0]
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"Id":"my-container"}`))
+	case path == "/containers/my-container/start":
+		socketName := ""
+		for _, env := range d.env {
+			if strings.HasPrefix(env, extensions.ProcessorServerAddressEnv+"=") {
+				socketName = filepath.Base(env)
+			}
+		}
+		server, err := utils.NewUnixDomainSocketServer(filepath.Join(d.socketDir, socketName), func(r io.Reader, w io.WriteCloser) {
+			_, _ = io.Copy(w, r)
+			_ = w.Close()
+		})
+		Expect(err).ToNot(HaveOccurred())
+		server.Start()
+		d.server = server
+		w.WriteHeader(http.StatusNoContent)
+	case path == "/containers/my-container/stop":
+		d.server.Stop()
+		w.WriteHeader(http.StatusNoContent)
+	case path == "/containers/my-container/wait":
+		_, _ = w.Write([]byte(`{"StatusCode":0}`))
+	case path == "/containers/my-container" && req.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+var _ = Describe("container executable", func() {
+
+	var (
+		tmpDir     string
+		dockerHost string
+		daemon     *fakeDockerDaemon
+		server     *http.Server
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "docker-")
+		Expect(err).ToNot(HaveOccurred())
+		socketPath := filepath.Join(tmpDir, "docker.sock")
+		listener, err := net.Listen("unix", socketPath)
+		Expect(err).ToNot(HaveOccurred())
+		dockerHost = "unix://" + socketPath
+
+		daemon = &fakeDockerDaemon{}
+		server = &http.Server{Handler: daemon}
+		go func() {
+			_ = server.Serve(listener)
+		}()
+	})
+
+	AfterEach(func() {
+		Expect(server.Close()).To(Succeed())
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("should run the processor in a container and communicate via the shared socket", func() {
+		processor, err := extensions.NewContainerExecutable("example.com/my-processor:1.0.0", nil, map[string]string{"MY_ENV": "value"}, dockerHost, extensions.BinaryProtocol)
+		Expect(err).ToNot(HaveOccurred())
+
+		out := bytes.NewBuffer([]byte{})
+		Expect(processor.Process(context.TODO(), bytes.NewBufferString("processor-message"), out)).To(Succeed())
+		Expect(out.String()).To(Equal("processor-message"))
+
+		Expect(daemon.env).To(ContainElement("MY_ENV=value"))
+		Expect(daemon.calls).To(Equal([]string{
+			"POST /containers/create",
+			"POST /images/create",
+			"POST /containers/create",
+			"POST /containers/my-container/start",
+			"POST /containers/my-container/stop",
+			"POST /containers/my-container/wait",
+			"DELETE /containers/my-container",
+		}))
+	})
+
+	It("should raise an error when trying to set the server address env variable manually", func() {
+		_, err := extensions.NewContainerExecutable("example.com/my-processor:1.0.0", nil, map[string]string{
+			extensions.ProcessorServerAddressEnv: "/tmp/my-processor.sock",
+		}, dockerHost, extensions.BinaryProtocol)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should raise an error for docker hosts that are no unix sockets", func() {
+		_, err := extensions.NewContainerExecutable("example.com/my-processor:1.0.0", nil, nil, "tcp://localhost:2375", extensions.BinaryProtocol)
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -7,9 +7,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -17,6 +20,10 @@ import (
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 
 	"github.com/gardener/component-cli/pkg/transport/process"
 	"github.com/gardener/component-cli/pkg/transport/process/extensions"
@@ -110,6 +117,55 @@ var _ = Describe("transport extensions", func() {
 		})
 	})
 
+	Context("grpc executable", func() {
+		It("should modify the processed resource correctly", func() {
+			args := []string{}
+			env := map[string]string{}
+			processor, err := extensions.NewGRPCExecutable(exampleProcessorBinaryPath, args, env)
+			Expect(err).ToNot(HaveOccurred())
+
+			runExampleResourceTest(processor)
+		})
+
+		It("should be created from an executable spec with the grpc protocol", func() {
+			spec := json.RawMessage(`{"bin": "` + exampleProcessorBinaryPath + `", "protocol": "grpc"}`)
+			processor, err := extensions.CreateExecutable(&spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			runExampleResourceTest(processor)
+		})
+
+		It("should return the error of the processor to the client", func() {
+			dir, err := ioutil.TempDir("", "processor-")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+			addr := filepath.Join(dir, "processor.sock")
+			srv, err := extensions.NewGRPCServer(addr, func(r io.Reader, w io.WriteCloser) error {
+				return errors.New("invalid resource")
+			})
+			Expect(err).ToNot(HaveOccurred())
+			srv.Start()
+			defer srv.Stop()
+
+			cc, err := grpc.Dial("unix://"+addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			Expect(err).ToNot(HaveOccurred())
+			defer cc.Close()
+			stream, err := extensions.NewResourceProcessorClient(cc).ProcessResource(context.TODO())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stream.Send(&extensions.ProcessResourceChunk{Data: []byte("data")})).To(Succeed())
+			Expect(stream.CloseSend()).To(Succeed())
+			_, err = stream.Recv()
+			Expect(status.Code(err)).To(Equal(codes.Unknown))
+			Expect(status.Convert(err).Message()).To(Equal("invalid resource"))
+		})
+
+		It("should raise an error for an unknown protocol", func() {
+			spec := json.RawMessage(`{"bin": "` + exampleProcessorBinaryPath + `", "protocol": "http"}`)
+			_, err := extensions.CreateExecutable(&spec)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 })
 
 func runTimeoutTest(processor process.ResourceStreamProcessor) {
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package extensions
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative processor.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// grpcChunkSize is the maximum size of the data of a ProcessResourceChunk.
+const grpcChunkSize = 32 * 1024
+
+// processGRPC calls the ProcessResource method of the grpc server of conn.
+// The connection is owned by the caller and is only used for a single call.
+func processGRPC(ctx context.Context, conn net.Conn, r io.Reader, w io.Writer) error {
+	cc, err := grpc.DialContext(ctx, "passthrough:///processor",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(singleConnDialer(conn)),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to create grpc connection: %w", err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := NewResourceProcessorClient(cc).ProcessResource(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to call processor: %w", err)
+	}
+
+	sendErrs := make(chan error, 1)
+	go func() {
+		sendErrs <- sendChunks(stream, r)
+	}()
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if s, ok := status.FromError(err); ok {
+				return fmt.Errorf("processor returned grpc status %s: %s", s.Code(), s.Message())
+			}
+			return fmt.Errorf("unable to read output: %w", err)
+		}
+		if _, err := w.Write(chunk.GetData()); err != nil {
+			return fmt.Errorf("unable to write output: %w", err)
+		}
+	}
+
+	// the server may have finished without reading the whole input, which aborts the sending with io.EOF
+	if err := <-sendErrs; err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("unable to write input: %w", err)
+	}
+	return nil
+}
+
+// sendChunks sends the content of r as ProcessResourceChunk messages and closes the send direction of the stream.
+func sendChunks(stream ResourceProcessor_ProcessResourceClient, r io.Reader) error {
+	buf := make([]byte, grpcChunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := stream.Send(&ProcessResourceChunk{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return stream.CloseSend()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// singleConnDialer returns a dialer that returns the already established connection to the processor once.
+// The processor serves a single call, so that the connection is not reestablished.
+func singleConnDialer(conn net.Conn) func(context.Context, string) (net.Conn, error) {
+	var once sync.Once
+	return func(context.Context, string) (net.Conn, error) {
+		var c net.Conn
+		once.Do(func() {
+			c = conn
+		})
+		if c == nil {
+			return nil, errors.New("the connection to the processor is closed")
+		}
+		return c, nil
+	}
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package extensions
+
+import (
+	"io"
+	"log"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCHandlerFunc defines the interface of a function that is served by a grpc server.
+// It reads the processor message from the reader and writes the processed processor message to the writer.
+// A returned error is sent to the client as grpc status.
+type GRPCHandlerFunc func(io.Reader, io.WriteCloser) error
+
+// GRPCServer implements a server for the ResourceProcessor grpc service (see processor.proto) on a Unix Domain Socket.
+type GRPCServer struct {
+	UnimplementedResourceProcessorServer
+
+	listener net.Listener
+	server   *grpc.Server
+	wg       sync.WaitGroup
+	handler  GRPCHandlerFunc
+}
+
+// NewGRPCServer returns a new grpc server.
+// The parameters define the server address and the handler func it serves
+func NewGRPCServer(addr string, handler GRPCHandlerFunc) (*GRPCServer, error) {
+	l, err := net.Listen("unix", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &GRPCServer{
+		listener: l,
+		server:   grpc.NewServer(),
+		handler:  handler,
+	}
+	RegisterResourceProcessorServer(s.server, s)
+	return s, nil
+}
+
+// Start starts the server goroutine
+func (s *GRPCServer) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.server.Serve(s.listener); err != nil {
+			log.Println("serve error", err)
+		}
+	}()
+}
+
+// ProcessResource implements the ResourceProcessorServer interface.
+func (s *GRPCServer) ProcessResource(stream ResourceProcessor_ProcessResourceServer) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(recvChunks(stream, pw))
+	}()
+	defer pr.Close()
+
+	if err := s.handler(pr, &grpcChunkWriter{stream: stream}); err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Error(codes.Unknown, err.Error())
+	}
+	return nil
+}
+
+// Stop stops the server goroutine after all running calls are finished
+func (s *GRPCServer) Stop() {
+	s.server.GracefulStop()
+	s.wg.Wait()
+}
+
+// recvChunks receives ProcessResourceChunk messages and writes their data to w.
+func recvChunks(stream ResourceProcessor_ProcessResourceServer, w io.Writer) error {
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(chunk.GetData()); err != nil {
+			return err
+		}
+	}
+}
+
+// grpcChunkWriter writes the data as ProcessResourceChunk messages to a grpc stream.
+type grpcChunkWriter struct {
+	stream ResourceProcessor_ProcessResourceServer
+}
+
+func (c *grpcChunkWriter) Write(p []byte) (int, error) {
+	for i := 0; i < len(p); i += grpcChunkSize {
+		end := i + grpcChunkSize
+		if end > len(p) {
+			end = len(p)
+		}
+		if err := c.stream.Send(&ProcessResourceChunk{Data: p[i:end]}); err != nil {
+			return i, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close does nothing as the stream is finished when the handler returns.
+func (c *grpcChunkWriter) Close() error {
+	return nil
+}
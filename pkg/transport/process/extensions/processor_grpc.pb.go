@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: processor.proto
+
+package extensions
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ResourceProcessor_ProcessResource_FullMethodName = "/componentcli.transport.process.v1.ResourceProcessor/ProcessResource"
+)
+
+// ResourceProcessorClient is the client API for ResourceProcessor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ResourceProcessorClient interface {
+	// ProcessResource processes a resource.
+	// The client streams the processor message (see utils.WriteProcessorMessage) in chunks
+	// and the server streams back the processed processor message in chunks.
+	ProcessResource(ctx context.Context, opts ...grpc.CallOption) (ResourceProcessor_ProcessResourceClient, error)
+}
+
+type resourceProcessorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewResourceProcessorClient(cc grpc.ClientConnInterface) ResourceProcessorClient {
+	return &resourceProcessorClient{cc}
+}
+
+func (c *resourceProcessorClient) ProcessResource(ctx context.Context, opts ...grpc.CallOption) (ResourceProcessor_ProcessResourceClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ResourceProcessor_ServiceDesc.Streams[0], ResourceProcessor_ProcessResource_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &resourceProcessorProcessResourceClient{stream}
+	return x, nil
+}
+
+type ResourceProcessor_ProcessResourceClient interface {
+	Send(*ProcessResourceChunk) error
+	Recv() (*ProcessResourceChunk, error)
+	grpc.ClientStream
+}
+
+type resourceProcessorProcessResourceClient struct {
+	grpc.ClientStream
+}
+
+func (x *resourceProcessorProcessResourceClient) Send(m *ProcessResourceChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *resourceProcessorProcessResourceClient) Recv() (*ProcessResourceChunk, error) {
+	m := new(ProcessResourceChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ResourceProcessorServer is the server API for ResourceProcessor service.
+// All implementations must embed UnimplementedResourceProcessorServer
+// for forward compatibility
+type ResourceProcessorServer interface {
+	// ProcessResource processes a resource.
+	// The client streams the processor message (see utils.WriteProcessorMessage) in chunks
+	// and the server streams back the processed processor message in chunks.
+	ProcessResource(ResourceProcessor_ProcessResourceServer) error
+	mustEmbedUnimplementedResourceProcessorServer()
+}
+
+// UnimplementedResourceProcessorServer must be embedded to have forward compatible implementations.
+type UnimplementedResourceProcessorServer struct {
+}
+
+func (UnimplementedResourceProcessorServer) ProcessResource(ResourceProcessor_ProcessResourceServer) error {
+	return status.Errorf(codes.Unimplemented, "method ProcessResource not implemented")
+}
+func (UnimplementedResourceProcessorServer) mustEmbedUnimplementedResourceProcessorServer() {}
+
+// UnsafeResourceProcessorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ResourceProcessorServer will
+// result in compilation errors.
+type UnsafeResourceProcessorServer interface {
+	mustEmbedUnimplementedResourceProcessorServer()
+}
+
+func RegisterResourceProcessorServer(s grpc.ServiceRegistrar, srv ResourceProcessorServer) {
+	s.RegisterService(&ResourceProcessor_ServiceDesc, srv)
+}
+
+func _ResourceProcessor_ProcessResource_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ResourceProcessorServer).ProcessResource(&resourceProcessorProcessResourceServer{stream})
+}
+
+type ResourceProcessor_ProcessResourceServer interface {
+	Send(*ProcessResourceChunk) error
+	Recv() (*ProcessResourceChunk, error)
+	grpc.ServerStream
+}
+
+type resourceProcessorProcessResourceServer struct {
+	grpc.ServerStream
+}
+
+func (x *resourceProcessorProcessResourceServer) Send(m *ProcessResourceChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *resourceProcessorProcessResourceServer) Recv() (*ProcessResourceChunk, error) {
+	m := new(ProcessResourceChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ResourceProcessor_ServiceDesc is the grpc.ServiceDesc for ResourceProcessor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ResourceProcessor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "componentcli.transport.process.v1.ResourceProcessor",
+	HandlerType: (*ResourceProcessorServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ProcessResource",
+			Handler:       _ResourceProcessor_ProcessResource_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "processor.proto",
+}
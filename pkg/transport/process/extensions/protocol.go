@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package extensions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+)
+
+// ProcessorServerProtocolEnv is the environment variable key which is used to store the
+// protocol that a resource processor server should serve. It is only set for the grpc protocol
+// so that existing extensions keep on working with the binary protocol.
+const ProcessorServerProtocolEnv = "PROCESSOR_SERVER_PROTOCOL"
+
+// Protocol defines the protocol that is used to communicate with a processor extension via Unix Domain Sockets.
+type Protocol string
+
+const (
+	// BinaryProtocol streams the processor message as is over the socket.
+	// The end of the input is signaled by closing the write side of the connection.
+	BinaryProtocol Protocol = "binary"
+
+	// GRPCProtocol streams the processor message in chunks via the ProcessResource method of the
+	// ResourceProcessor grpc service (see processor.proto).
+	GRPCProtocol Protocol = "grpc"
+)
+
+// ParseProtocol parses the protocol of an extension spec. The binary protocol is used if the protocol is empty.
+func ParseProtocol(protocol string) (Protocol, error) {
+	switch Protocol(protocol) {
+	case "", BinaryProtocol:
+		return BinaryProtocol, nil
+	case GRPCProtocol:
+		return GRPCProtocol, nil
+	default:
+		return "", fmt.Errorf("unknown protocol %q: must be one of %s, %s", protocol, BinaryProtocol, GRPCProtocol)
+	}
+}
+
+// env returns the additional environment variables that are passed to an extension which serves the protocol.
+func (p Protocol) env() []string {
+	if p == GRPCProtocol {
+		return []string{fmt.Sprintf("%s=%s", ProcessorServerProtocolEnv, GRPCProtocol)}
+	}
+	return nil
+}
+
+// process writes the processor message of r to the processor server of conn and writes the processed message to w.
+func (p Protocol) process(ctx context.Context, conn net.Conn, r io.Reader, w io.Writer) error {
+	if p == GRPCProtocol {
+		return processGRPC(ctx, conn, r, w)
+	}
+
+	if _, err := io.Copy(conn, r); err != nil {
+		return fmt.Errorf("unable to write input: %w", err)
+	}
+
+	usock := conn.(*net.UnixConn)
+	if err := usock.CloseWrite(); err != nil {
+		return fmt.Errorf("unable to close input writer: %w", err)
+	}
+
+	if _, err := io.Copy(w, conn); err != nil {
+		return fmt.Errorf("unable to read output: %w", err)
+	}
+	return nil
+}
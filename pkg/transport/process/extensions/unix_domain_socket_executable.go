@@ -22,15 +22,27 @@ import (
 const ProcessorServerAddressEnv = "PROCESSOR_SERVER_ADDRESS"
 
 type unixDomainSocketExecutable struct {
-	bin  string
-	args []string
-	env  []string
-	addr string
+	bin      string
+	args     []string
+	env      []string
+	addr     string
+	protocol Protocol
 }
 
 // NewUnixDomainSocketExecutable returns a resource processor extension which runs an executable in the
 // background when calling Process(). It communicates with this processor via Unix Domain Sockets.
 func NewUnixDomainSocketExecutable(bin string, args []string, env map[string]string) (process.ResourceStreamProcessor, error) {
+	return newUnixDomainSocketExecutable(bin, args, env, BinaryProtocol)
+}
+
+// NewGRPCExecutable returns a resource processor extension which runs an executable in the
+// background when calling Process(). It communicates with this processor via the ProcessResource method
+// of the ResourceProcessor grpc service (see processor.proto) on a Unix Domain Socket.
+func NewGRPCExecutable(bin string, args []string, env map[string]string) (process.ResourceStreamProcessor, error) {
+	return newUnixDomainSocketExecutable(bin, args, env, GRPCProtocol)
+}
+
+func newUnixDomainSocketExecutable(bin string, args []string, env map[string]string, protocol Protocol) (process.ResourceStreamProcessor, error) {
 	if _, ok := env[ProcessorServerAddressEnv]; ok {
 		return nil, fmt.Errorf("the env variable %s is not allowed to be set manually", ProcessorServerAddressEnv)
 	}
@@ -46,12 +58,14 @@ func NewUnixDomainSocketExecutable(bin string, args []string, env map[string]str
 	}
 	addr := fmt.Sprintf("%s/%s.sock", wd, utils.RandomString(8))
 	parsedEnv = append(parsedEnv, fmt.Sprintf("%s=%s", ProcessorServerAddressEnv, addr))
+	parsedEnv = append(parsedEnv, protocol.env()...)
 
 	e := unixDomainSocketExecutable{
-		bin:  bin,
-		args: args,
-		env:  parsedEnv,
-		addr: addr,
+		bin:      bin,
+		args:     args,
+		env:      parsedEnv,
+		addr:     addr,
+		protocol: protocol,
 	}
 
 	return &e, nil
@@ -77,17 +91,10 @@ func (e *unixDomainSocketExecutable) Process(ctx context.Context, r io.Reader, w
 		}
 	}()
 
-	if _, err := io.Copy(conn, r); err != nil {
-		return fmt.Errorf("unable to write input: %w", err)
-	}
-
-	usock := conn.(*net.UnixConn)
-	if err := usock.CloseWrite(); err != nil {
-		return fmt.Errorf("unable to close input writer: %w", err)
-	}
+	defer conn.Close()
 
-	if _, err := io.Copy(w, conn); err != nil {
-		return fmt.Errorf("unable to read output: %w", err)
+	if err := e.protocol.process(ctx, conn, r, w); err != nil {
+		return err
 	}
 
 	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
@@ -15,6 +15,9 @@ import (
 const (
 	// ExecutableType defines the type of an executable
 	ExecutableType = "Executable"
+
+	// ContainerType defines the type of a container
+	ContainerType = "Container"
 )
 
 // CreateExecutable creates a new executable defined by a spec
@@ -23,6 +26,9 @@ func CreateExecutable(rawSpec *json.RawMessage) (process.ResourceStreamProcessor
 		Bin  string
 		Args []string
 		Env  map[string]string
+		// Protocol is the protocol that is used to communicate with the executable ("binary" or "grpc").
+		// Defaults to "binary".
+		Protocol string
 	}
 
 	var spec executableSpec
@@ -30,5 +36,38 @@ func CreateExecutable(rawSpec *json.RawMessage) (process.ResourceStreamProcessor
 		return nil, fmt.Errorf("unable to parse spec: %w", err)
 	}
 
+	protocol, err := ParseProtocol(spec.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	if protocol == GRPCProtocol {
+		return NewGRPCExecutable(spec.Bin, spec.Args, spec.Env)
+	}
 	return NewUnixDomainSocketExecutable(spec.Bin, spec.Args, spec.Env)
 }
+
+// CreateContainer creates a new container executable defined by a spec
+func CreateContainer(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type containerSpec struct {
+		Image      string
+		Args       []string
+		Env        map[string]string
+		DockerHost string
+		// Protocol is the protocol that is used to communicate with the container ("binary" or "grpc").
+		// Defaults to "binary".
+		Protocol string
+	}
+
+	var spec containerSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	protocol, err := ParseProtocol(spec.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	return NewContainerExecutable(spec.Image, spec.Args, spec.Env, spec.DockerHost, protocol)
+}
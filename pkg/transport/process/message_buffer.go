@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package process
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// pipelineRun tracks the blob budget that is held by a single run of a pipeline.
+type pipelineRun struct {
+	ctx    context.Context
+	budget *BlobBudget
+	// diskHeld is the disk budget that is currently held by all message buffers of the run.
+	diskHeld int64
+}
+
+func newPipelineRun(ctx context.Context) *pipelineRun {
+	return &pipelineRun{
+		ctx:    ctx,
+		budget: blobBudgetFromContext(ctx),
+	}
+}
+
+// reserveDisk reserves disk budget for the run.
+// The reservation only blocks if the run does not hold any disk budget yet.
+func (r *pipelineRun) reserveDisk(size int64) error {
+	if r.budget == nil {
+		return nil
+	}
+	if r.diskHeld == 0 {
+		if err := r.budget.Acquire(r.ctx, size); err != nil {
+			return fmt.Errorf("unable to acquire blob budget: %w", err)
+		}
+	} else {
+		r.budget.Grow(size)
+	}
+	r.diskHeld += size
+	return nil
+}
+
+func (r *pipelineRun) releaseDisk(size int64) {
+	if r.budget == nil || size == 0 {
+		return
+	}
+	r.diskHeld -= size
+	r.budget.Release(size)
+}
+
+// messageBuffer holds a processor message of a pipeline.
+// The message is kept in memory as long as the blob budget of the run allows it and is spilled to a temporary file otherwise.
+// Without a blob budget all messages are written to temporary files.
+type messageBuffer struct {
+	run *pipelineRun
+
+	mem          []byte
+	file         *os.File
+	size         int64
+	diskReserved int64
+	refs         int
+}
+
+func (r *pipelineRun) newMessageBuffer() *messageBuffer {
+	return &messageBuffer{
+		run:  r,
+		refs: 1,
+	}
+}
+
+func (m *messageBuffer) Write(p []byte) (int, error) {
+	if m.file == nil {
+		if m.run.budget != nil && m.run.budget.TryAcquireMemory(int64(len(p))) {
+			m.mem = append(m.mem, p...)
+			m.size += int64(len(p))
+			return len(p), nil
+		}
+		if err := m.spill(); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := m.run.reserveDisk(int64(len(p))); err != nil {
+		return 0, err
+	}
+	m.diskReserved += int64(len(p))
+	n, err := m.file.Write(p)
+	m.size += int64(n)
+	return n, err
+}
+
+// spill moves the in-memory content of the buffer to a temporary file.
+func (m *messageBuffer) spill() error {
+	f, err := ioutil.TempFile("", "")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+	m.file = f
+	if len(m.mem) == 0 {
+		return nil
+	}
+
+	memSize := int64(len(m.mem))
+	if err := m.run.reserveDisk(memSize); err != nil {
+		return err
+	}
+	m.diskReserved += memSize
+	if _, err := f.Write(m.mem); err != nil {
+		return fmt.Errorf("unable to write temporary file: %w", err)
+	}
+	m.run.budget.ReleaseMemory(memSize)
+	m.mem = nil
+	return nil
+}
+
+// Reader returns a new reader for the content of the buffer.
+func (m *messageBuffer) Reader() io.ReadSeeker {
+	if m.file == nil {
+		return bytes.NewReader(m.mem)
+	}
+	return io.NewSectionReader(m.file, 0, m.size)
+}
+
+// retain adds a reference to the buffer so that it has to be closed once more.
+func (m *messageBuffer) retain() *messageBuffer {
+	m.refs++
+	return m
+}
+
+// Close releases the buffer and its blob budget when the last reference is closed.
+// Temporary files are removed.
+func (m *messageBuffer) Close() error {
+	m.refs--
+	if m.refs > 0 {
+		return nil
+	}
+	if m.mem != nil {
+		m.run.budget.ReleaseMemory(int64(len(m.mem)))
+		m.mem = nil
+	}
+	if m.file == nil {
+		return nil
+	}
+	err := m.file.Close()
+	if rmErr := os.Remove(m.file.Name()); rmErr != nil && err == nil {
+		err = rmErr
+	}
+	m.run.releaseDisk(m.diskReserved)
+	m.diskReserved = 0
+	m.file = nil
+	return err
+}
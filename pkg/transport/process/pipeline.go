@@ -5,12 +5,11 @@ package process
 
 import (
 	"context"
+	"errors"
 	"io"
-	"os"
 	"time"
 
 	"fmt"
-	"io/ioutil"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 
@@ -23,66 +22,294 @@ type resourceProcessingPipelineImpl struct {
 	processors []ResourceStreamProcessor
 }
 
-func (p *resourceProcessingPipelineImpl) Process(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) (*cdv2.ComponentDescriptor, cdv2.Resource, error) {
-	infile, err := ioutil.TempFile("", "")
+func (p *resourceProcessingPipelineImpl) Process(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) (*cdv2.ComponentDescriptor, []cdv2.Resource, error) {
+	run := newPipelineRun(ctx)
+	infile, err := createInputFile(run, cd, res)
 	if err != nil {
-		return nil, cdv2.Resource{}, fmt.Errorf("unable to create temporary infile: %w", err)
+		return nil, nil, err
 	}
+	defer infile.Close()
 
-	if err := utils.WriteProcessorMessage(cd, res, nil, infile); err != nil {
-		return nil, cdv2.Resource{}, fmt.Errorf("unable to write: %w", err)
+	outfiles, err := runProcessors(ctx, run, []*messageBuffer{infile}, p.processors)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeFiles(outfiles)
+
+	return readProcessorResults(cd, outfiles)
+}
+
+type multiTargetResourceProcessingPipelineImpl struct {
+	processors []ResourceStreamProcessor
+	targets    []ProcessingTarget
+}
+
+func (p *multiTargetResourceProcessingPipelineImpl) Process(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) ([]TargetResult, error) {
+	run := newPipelineRun(ctx)
+	infile, err := createInputFile(run, cd, res)
+	if err != nil {
+		return nil, err
 	}
+	defer infile.Close()
+
+	sharedfiles, err := runProcessors(ctx, run, []*messageBuffer{infile}, p.processors)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFiles(sharedfiles)
 
-	for _, proc := range p.processors {
-		outfile, err := p.runProcessor(ctx, infile, proc)
+	results := []TargetResult{}
+	for _, target := range p.targets {
+		outfiles, err := runProcessors(ctx, run, sharedfiles, target.Uploaders)
 		if err != nil {
-			return nil, cdv2.Resource{}, err
+			return nil, fmt.Errorf("unable to process target %s: %w", target.Name, err)
+		}
+		if len(target.PostUploaders) != 0 {
+			outfiles, err = runPostUploaders(ctx, run, outfiles, target.PostUploaders)
+			if err != nil {
+				return nil, fmt.Errorf("unable to process target %s: %w", target.Name, err)
+			}
 		}
 
-		infile = outfile
+		processedCD, processedResources, err := readProcessorResults(cd, outfiles)
+		closeFiles(outfiles)
+		if err != nil {
+			return nil, fmt.Errorf("unable to process target %s: %w", target.Name, err)
+		}
+		results = append(results, TargetResult{
+			Target:              target.Name,
+			ComponentDescriptor: processedCD,
+			Resources:           processedResources,
+		})
 	}
-	defer infile.Close()
 
-	if _, err := infile.Seek(0, io.SeekStart); err != nil {
-		return nil, cdv2.Resource{}, fmt.Errorf("unable to seek to beginning of input file: %w", err)
+	return results, nil
+}
+
+// createInputFile writes the processor message for the first processor of a pipeline.
+func createInputFile(run *pipelineRun, cd cdv2.ComponentDescriptor, res cdv2.Resource) (*messageBuffer, error) {
+	infile := run.newMessageBuffer()
+	if err := utils.WriteProcessorMessage(cd, res, nil, infile); err != nil {
+		infile.Close()
+		return nil, fmt.Errorf("unable to write: %w", err)
 	}
+	return infile, nil
+}
 
-	processedCD, processedRes, blobreader, err := utils.ReadProcessorMessage(infile)
-	if err != nil {
-		return nil, cdv2.Resource{}, fmt.Errorf("unable to read output data: %w", err)
+// runProcessors sequentially executes the processors for all input files and returns the output files of the last processor.
+// The input files are not closed so that they can be processed multiple times, all intermediate files are closed.
+// If no processors are defined, the input files are returned as is.
+func runProcessors(ctx context.Context, run *pipelineRun, infiles []*messageBuffer, processors []ResourceStreamProcessor) ([]*messageBuffer, error) {
+	if len(processors) == 0 {
+		return retainFiles(infiles), nil
 	}
-	if blobreader != nil {
-		defer blobreader.Close()
+
+	current := infiles
+	isInput := true
+	closeCurrent := func() {
+		if !isInput {
+			closeFiles(current)
+		}
 	}
+	for _, proc := range processors {
+		outfiles := []*messageBuffer{}
+		for _, infile := range current {
+			outfile, err := runProcessor(ctx, run, infile, proc)
+			if err != nil {
+				closeCurrent()
+				closeFiles(outfiles)
+				return nil, err
+			}
+
+			splittedOutfiles, err := splitProcessorMessage(run, outfile)
+			if err != nil {
+				closeCurrent()
+				closeFiles(outfiles)
+				return nil, err
+			}
+			outfiles = append(outfiles, splittedOutfiles...)
+		}
 
-	return processedCD, processedRes, nil
+		closeCurrent()
+		current = outfiles
+		isInput = false
+	}
+	return current, nil
 }
 
-func (p *resourceProcessingPipelineImpl) runProcessor(ctx context.Context, infile *os.File, proc ResourceStreamProcessor) (*os.File, error) {
-	defer infile.Close()
+// runPostUploaders removes the blobs from the output files of the uploaders and executes the post uploaders for them.
+// The given files are closed.
+func runPostUploaders(ctx context.Context, run *pipelineRun, uploadedfiles []*messageBuffer, postUploaders []ResourceStreamProcessor) ([]*messageBuffer, error) {
+	infiles, err := removeBlobs(run, uploadedfiles)
+	closeFiles(uploadedfiles)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFiles(infiles)
+	return runProcessors(ctx, run, infiles, postUploaders)
+}
 
-	if _, err := infile.Seek(0, io.SeekStart); err != nil {
-		return nil, fmt.Errorf("unable to seek to beginning of input file: %w", err)
+// removeBlobs rewrites the processor messages of the given files without resource blob.
+func removeBlobs(run *pipelineRun, files []*messageBuffer) ([]*messageBuffer, error) {
+	strippedFiles := []*messageBuffer{}
+	for _, f := range files {
+		cd, res, blobreader, err := utils.ReadProcessorMessage(f.Reader())
+		if err != nil {
+			closeFiles(strippedFiles)
+			return nil, fmt.Errorf("unable to read output data: %w", err)
+		}
+		if blobreader != nil {
+			blobreader.Close()
+		}
+
+		strippedFile := run.newMessageBuffer()
+		strippedFiles = append(strippedFiles, strippedFile)
+		if err := utils.WriteProcessorMessage(*cd, res, nil, strippedFile); err != nil {
+			closeFiles(strippedFiles)
+			return nil, fmt.Errorf("unable to write processor message for resource %s: %w", res.Name, err)
+		}
 	}
+	return strippedFiles, nil
+}
 
-	outfile, err := ioutil.TempFile("", "")
-	if err != nil {
-		return nil, fmt.Errorf("unable to create temporary outfile: %w", err)
+// retainFiles adds a reference to the given files so that the returned files can be closed independently.
+func retainFiles(files []*messageBuffer) []*messageBuffer {
+	retained := []*messageBuffer{}
+	for _, f := range files {
+		retained = append(retained, f.retain())
+	}
+	return retained
+}
+
+// readProcessorResults reads the component descriptor and the resources from the output files of the last processor.
+func readProcessorResults(cd cdv2.ComponentDescriptor, outfiles []*messageBuffer) (*cdv2.ComponentDescriptor, []cdv2.Resource, error) {
+	processedCD := &cd
+	processedResources := []cdv2.Resource{}
+	for _, outfile := range outfiles {
+		outCD, outRes, blobreader, err := utils.ReadProcessorMessage(outfile.Reader())
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read output data: %w", err)
+		}
+		if blobreader != nil {
+			blobreader.Close()
+		}
+
+		processedCD = outCD
+		processedResources = append(processedResources, outRes)
 	}
 
-	inreader := infile
+	return processedCD, processedResources, nil
+}
+
+func runProcessor(ctx context.Context, run *pipelineRun, infile *messageBuffer, proc ResourceStreamProcessor) (*messageBuffer, error) {
+	outfile := run.newMessageBuffer()
+
+	inreader := infile.Reader()
 	outwriter := outfile
 
 	ctx, cancelfunc := context.WithTimeout(ctx, processorTimeout)
 	defer cancelfunc()
 
 	if err := proc.Process(ctx, inreader, outwriter); err != nil {
+		// a failed processor may have written an error envelope which describes the error better than the exit error
+		perr, readErr := utils.ReadProcessorError(outfile.Reader())
+		outfile.Close()
+		if readErr == nil && perr != nil {
+			return nil, fmt.Errorf("unable to process resource: %w (%s)", perr, err.Error())
+		}
 		return nil, fmt.Errorf("unable to process resource: %w", err)
 	}
 
+	perr, err := utils.ReadProcessorError(outfile.Reader())
+	if err != nil {
+		outfile.Close()
+		return nil, fmt.Errorf("unable to read output data: %w", err)
+	}
+	if perr != nil {
+		outfile.Close()
+		return nil, fmt.Errorf("unable to process resource: %w", perr)
+	}
+
 	return outfile, nil
 }
 
+// AsProcessorError returns the error envelope of an external processor if the error is or wraps one.
+func AsProcessorError(err error) (*utils.ProcessorError, bool) {
+	var perr *utils.ProcessorError
+	if errors.As(err, &perr) {
+		return perr, true
+	}
+	return nil, false
+}
+
+// IsRetryable checks whether the error is or wraps an error envelope of a processor that may be resolved by a retry.
+func IsRetryable(err error) bool {
+	perr, ok := AsProcessorError(err)
+	return ok && perr.Retryable()
+}
+
+// splitProcessorMessage splits a multi resource processor message into single resource processor messages
+// so that subsequent processors can process each resource individually.
+// A single resource processor message is returned as is.
+func splitProcessorMessage(run *pipelineRun, f *messageBuffer) ([]*messageBuffer, error) {
+	isMultiResourceMsg, err := utils.IsMultiResourceProcessorMessage(f.Reader())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("unable to read output data: %w", err)
+	}
+	if !isMultiResourceMsg {
+		return []*messageBuffer{f}, nil
+	}
+	defer f.Close()
+
+	cd, resources, blobReaders, err := utils.ReadMultiResourceProcessorMessage(f.Reader())
+	if err != nil {
+		return nil, fmt.Errorf("unable to read output data: %w", err)
+	}
+	defer closeReaders(blobReaders)
+
+	splittedFiles := []*messageBuffer{}
+	for i, res := range resources {
+		splittedFile := run.newMessageBuffer()
+		splittedFiles = append(splittedFiles, splittedFile)
+
+		var blobReader io.Reader
+		if blobReaders[i] != nil {
+			blobReader = blobReaders[i]
+		}
+		if err := utils.WriteProcessorMessage(*cd, res, blobReader, splittedFile); err != nil {
+			closeFiles(splittedFiles)
+			return nil, fmt.Errorf("unable to write processor message for resource %s: %w", res.Name, err)
+		}
+	}
+
+	return splittedFiles, nil
+}
+
+func closeFiles(files []*messageBuffer) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+func closeReaders(readers []io.ReadSeekCloser) {
+	for _, r := range readers {
+		if r != nil {
+			r.Close()
+		}
+	}
+}
+
+// NewMultiTargetResourceProcessingPipeline returns a new MultiTargetResourceProcessingPipeline.
+// The processors are executed once for a resource and their results are passed to the uploaders of every target.
+func NewMultiTargetResourceProcessingPipeline(processors []ResourceStreamProcessor, targets ...ProcessingTarget) MultiTargetResourceProcessingPipeline {
+	p := multiTargetResourceProcessingPipelineImpl{
+		processors: processors,
+		targets:    targets,
+	}
+	return &p
+}
+
 // NewResourceProcessingPipeline returns a new ResourceProcessingPipeline
 func NewResourceProcessingPipeline(processors ...ResourceStreamProcessor) ResourceProcessingPipeline {
 	p := resourceProcessingPipelineImpl{
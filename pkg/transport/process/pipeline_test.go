@@ -4,8 +4,12 @@
 package process_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"time"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	. "github.com/onsi/ginkgo"
@@ -13,8 +17,89 @@ import (
 
 	"github.com/gardener/component-cli/pkg/transport/process"
 	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
 )
 
+// resourceSplitter is a test processor that splits a resource into count resources.
+type resourceSplitter struct {
+	count int
+}
+
+func (p *resourceSplitter) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return err
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	resources := []cdv2.Resource{}
+	for i := 0; i < p.count; i++ {
+		splittedRes := res
+		splittedRes.Name = fmt.Sprintf("%s-%d", res.Name, i)
+		resources = append(resources, splittedRes)
+	}
+	return utils.WriteMultiResourceProcessorMessage(*cd, resources, nil, w)
+}
+
+// countingProcessor is a test processor that counts its invocations and passes the processor message through.
+type countingProcessor struct {
+	count int
+}
+
+func (p *countingProcessor) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	p.count++
+	_, err := io.Copy(w, r)
+	return err
+}
+
+// blobWriter is a test processor that replaces the resource blob with the given data.
+type blobWriter struct {
+	data []byte
+}
+
+func (p *blobWriter) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return err
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+	return utils.WriteProcessorMessage(*cd, res, bytes.NewReader(p.data), w)
+}
+
+// blobRecorder is a test processor that records whether it received a resource blob.
+type blobRecorder struct {
+	receivedBlob bool
+}
+
+func (p *blobRecorder) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return err
+	}
+	if resBlobReader != nil {
+		p.receivedBlob = true
+		defer resBlobReader.Close()
+	}
+	return utils.WriteProcessorMessage(*cd, res, nil, w)
+}
+
+// errorEnvelopeWriter is a test processor that writes an error envelope and optionally fails with an exit error.
+type errorEnvelopeWriter struct {
+	perr    utils.ProcessorError
+	exitErr error
+}
+
+func (p *errorEnvelopeWriter) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	if err := utils.WriteProcessorError(p.perr, w); err != nil {
+		return err
+	}
+	return p.exitErr
+}
+
 var _ = Describe("pipeline", func() {
 
 	Context("Process", func() {
@@ -56,7 +141,309 @@ var _ = Describe("pipeline", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(*actualCD).To(Equal(cd))
-			Expect(actualRes).To(Equal(expectedRes))
+			Expect(actualRes).To(ConsistOf(expectedRes))
+		})
+
+		It("should process all resources that are split by a processor", func() {
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+
+			l1 := cdv2.Label{
+				Name:  "processor-0",
+				Value: json.RawMessage(`"true"`),
+			}
+
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+
+			expectedRes1 := res
+			expectedRes1.Name = "my-res-0"
+			expectedRes1.Labels = append(expectedRes1.Labels, l1)
+			expectedRes2 := res
+			expectedRes2.Name = "my-res-1"
+			expectedRes2.Labels = append(expectedRes2.Labels, l1)
+
+			pipeline := process.NewResourceProcessingPipeline(&resourceSplitter{count: 2}, processors.NewResourceLabeler(l1))
+
+			actualCD, actualRes, err := pipeline.Process(context.TODO(), cd, res)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(*actualCD).To(Equal(cd))
+			Expect(actualRes).To(ConsistOf(expectedRes1, expectedRes2))
+		})
+
+	})
+
+	Context("error envelope", func() {
+
+		var (
+			cd  cdv2.ComponentDescriptor
+			res cdv2.Resource
+		)
+
+		BeforeEach(func() {
+			res = cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+			cd = cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{res},
+				},
+			}
+		})
+
+		It("should fail with the error envelope of a processor and skip the subsequent processors", func() {
+			failing := &errorEnvelopeWriter{
+				perr: utils.ProcessorError{
+					Code:     "SCAN_TIMEOUT",
+					Category: utils.ErrorCategoryRetryable,
+					Message:  "scanner did not respond",
+					Details:  map[string]string{"scannedLayers": "2"},
+				},
+			}
+			next := &countingProcessor{}
+			pipeline := process.NewResourceProcessingPipeline(failing, next)
+
+			_, _, err := pipeline.Process(context.TODO(), cd, res)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("SCAN_TIMEOUT: scanner did not respond"))
+			Expect(process.IsRetryable(err)).To(BeTrue())
+			perr, ok := process.AsProcessorError(err)
+			Expect(ok).To(BeTrue())
+			Expect(perr.Details).To(HaveKeyWithValue("scannedLayers", "2"))
+			Expect(next.count).To(Equal(0))
+		})
+
+		It("should prefer the error envelope over the exit error of a processor", func() {
+			failing := &errorEnvelopeWriter{
+				perr: utils.ProcessorError{
+					Code:     "INVALID_CONFIG",
+					Category: utils.ErrorCategoryFatal,
+					Message:  "unknown scanner",
+				},
+				exitErr: fmt.Errorf("exit status 1"),
+			}
+			pipeline := process.NewResourceProcessingPipeline(failing)
+
+			_, _, err := pipeline.Process(context.TODO(), cd, res)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("exit status 1"))
+			perr, ok := process.AsProcessorError(err)
+			Expect(ok).To(BeTrue())
+			Expect(perr.Fatal()).To(BeTrue())
+			Expect(process.IsRetryable(err)).To(BeFalse())
+		})
+	})
+
+	Context("multi target Process", func() {
+
+		It("should process a resource once and upload it to all targets", func() {
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+
+			l1 := cdv2.Label{
+				Name:  "target-1",
+				Value: json.RawMessage(`"true"`),
+			}
+			l2 := cdv2.Label{
+				Name:  "target-2",
+				Value: json.RawMessage(`"true"`),
+			}
+			expectedRes1 := res
+			expectedRes1.Labels = append(expectedRes1.Labels, l1)
+			expectedRes2 := res
+			expectedRes2.Labels = append(expectedRes2.Labels, l2)
+
+			shared := &countingProcessor{}
+			pipeline := process.NewMultiTargetResourceProcessingPipeline(
+				[]process.ResourceStreamProcessor{shared},
+				process.ProcessingTarget{
+					Name:      "target-1",
+					Uploaders: []process.ResourceStreamProcessor{processors.NewResourceLabeler(l1)},
+				},
+				process.ProcessingTarget{
+					Name:      "target-2",
+					Uploaders: []process.ResourceStreamProcessor{processors.NewResourceLabeler(l2)},
+				},
+			)
+
+			results, err := pipeline.Process(context.TODO(), cd, res)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shared.count).To(Equal(1))
+
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].Target).To(Equal("target-1"))
+			Expect(*results[0].ComponentDescriptor).To(Equal(cd))
+			Expect(results[0].Resources).To(ConsistOf(expectedRes1))
+			Expect(results[1].Target).To(Equal("target-2"))
+			Expect(*results[1].ComponentDescriptor).To(Equal(cd))
+			Expect(results[1].Resources).To(ConsistOf(expectedRes2))
+		})
+
+		It("should execute the post uploaders of a target without the resource blob", func() {
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+
+			l1 := cdv2.Label{
+				Name:  "post-uploaded",
+				Value: json.RawMessage(`"true"`),
+			}
+			expectedRes := res
+			expectedRes.Labels = append(expectedRes.Labels, l1)
+
+			recorder := &blobRecorder{}
+			pipeline := process.NewMultiTargetResourceProcessingPipeline(
+				nil,
+				process.ProcessingTarget{
+					Name:          "target-1",
+					Uploaders:     []process.ResourceStreamProcessor{&blobWriter{data: []byte("resource-blob")}},
+					PostUploaders: []process.ResourceStreamProcessor{recorder, processors.NewResourceLabeler(l1)},
+				},
+			)
+
+			results, err := pipeline.Process(context.TODO(), cd, res)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(recorder.receivedBlob).To(BeFalse())
+
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Resources).To(ConsistOf(expectedRes))
+		})
+
+	})
+
+	Context("BlobBudget", func() {
+
+		var (
+			res cdv2.Resource
+			cd  cdv2.ComponentDescriptor
+			l1  cdv2.Label
+		)
+
+		BeforeEach(func() {
+			res = cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+			cd = cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+			l1 = cdv2.Label{
+				Name:  "processor-0",
+				Value: json.RawMessage(`"true"`),
+			}
+		})
+
+		It("should process resources in memory and release the budget", func() {
+			budget := process.NewBlobBudget(1024*1024, 0)
+			ctx := process.WithBlobBudget(context.TODO(), budget)
+
+			expectedRes := res
+			expectedRes.Labels = append(expectedRes.Labels, l1)
+
+			pipeline := process.NewResourceProcessingPipeline(processors.NewResourceLabeler(l1))
+			_, actualRes, err := pipeline.Process(ctx, cd, res)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualRes).To(ConsistOf(expectedRes))
+			Expect(budget.MemoryUsage()).To(BeZero())
+			Expect(budget.DiskUsage()).To(BeZero())
+		})
+
+		It("should spill processor messages to disk if the memory budget is exceeded", func() {
+			budget := process.NewBlobBudget(10, 1024*1024)
+			ctx := process.WithBlobBudget(context.TODO(), budget)
+
+			expectedRes1 := res
+			expectedRes1.Name = "my-res-0"
+			expectedRes1.Labels = append(expectedRes1.Labels, l1)
+			expectedRes2 := res
+			expectedRes2.Name = "my-res-1"
+			expectedRes2.Labels = append(expectedRes2.Labels, l1)
+
+			pipeline := process.NewResourceProcessingPipeline(&resourceSplitter{count: 2}, processors.NewResourceLabeler(l1))
+			_, actualRes, err := pipeline.Process(ctx, cd, res)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualRes).To(ConsistOf(expectedRes1, expectedRes2))
+			Expect(budget.MemoryUsage()).To(BeZero())
+			Expect(budget.DiskUsage()).To(BeZero())
+		})
+
+		It("should wait until enough disk budget is released", func() {
+			budget := process.NewBlobBudget(0, 10)
+			Expect(budget.Acquire(context.TODO(), 10)).To(Succeed())
+
+			ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+			defer cancel()
+			Expect(budget.Acquire(ctx, 1)).To(MatchError(context.DeadlineExceeded))
+
+			acquired := make(chan error)
+			go func() {
+				acquired <- budget.Acquire(context.TODO(), 5)
+			}()
+			Consistently(acquired).ShouldNot(Receive())
+			budget.Release(10)
+			Eventually(acquired).Should(Receive(BeNil()))
+			Expect(budget.DiskUsage()).To(Equal(int64(5)))
+		})
+
+		It("should fail the pipeline if the disk budget cannot be acquired", func() {
+			budget := process.NewBlobBudget(0, 10)
+			Expect(budget.Acquire(context.TODO(), 10)).To(Succeed())
+
+			ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+			defer cancel()
+			ctx = process.WithBlobBudget(ctx, budget)
+
+			pipeline := process.NewResourceProcessingPipeline(processors.NewResourceLabeler(l1))
+			_, _, err := pipeline.Process(ctx, cd, res)
+			Expect(err).To(HaveOccurred())
+			Expect(budget.DiskUsage()).To(Equal(int64(10)))
 		})
 
 	})
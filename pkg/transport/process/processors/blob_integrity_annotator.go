@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+// BlobIntegrityLabelName is the name of the resource label that contains the size and the digests of the resource blob.
+const BlobIntegrityLabelName = "transport.gardener.cloud/blob-integrity"
+
+// BlobIntegrity is the value of the blob integrity label.
+type BlobIntegrity struct {
+	// Size is the size of the resource blob in bytes.
+	Size int64 `json:"size"`
+	// Digests are the digests of the resource blob, one per configured hash algorithm.
+	Digests []digest.Digest `json:"digests"`
+}
+
+type blobIntegrityAnnotator struct {
+	algorithms []digest.Algorithm
+}
+
+// NewBlobIntegrityAnnotator returns a processor that computes the size and the digests of the resource blob
+// and stores them in the BlobIntegrityLabelName label of the resource.
+// The digests are computed with the given hash algorithms (sha256, sha384 or sha512), sha256 is used if none is given.
+// The blob is read only once, all digests are computed while streaming it.
+func NewBlobIntegrityAnnotator(algorithms ...digest.Algorithm) (process.ResourceStreamProcessor, error) {
+	if len(algorithms) == 0 {
+		algorithms = []digest.Algorithm{digest.SHA256}
+	}
+	for _, algorithm := range algorithms {
+		if !algorithm.Available() {
+			return nil, fmt.Errorf("unsupported hash algorithm %q", algorithm)
+		}
+	}
+	obj := blobIntegrityAnnotator{
+		algorithms: algorithms,
+	}
+	return &obj, nil
+}
+
+func (p *blobIntegrityAnnotator) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader == nil {
+		return errors.New("resource blob must not be nil")
+	}
+	defer resBlobReader.Close()
+
+	hashes := make([]hash.Hash, len(p.algorithms))
+	writers := make([]io.Writer, len(p.algorithms))
+	for i, algorithm := range p.algorithms {
+		hashes[i] = algorithm.Hash()
+		writers[i] = hashes[i]
+	}
+	size, err := io.Copy(io.MultiWriter(writers...), resBlobReader)
+	if err != nil {
+		return fmt.Errorf("unable to read resource blob: %w", err)
+	}
+	if _, err := resBlobReader.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to beginning of resource blob: %w", err)
+	}
+
+	integrity := BlobIntegrity{
+		Size: size,
+	}
+	for i, algorithm := range p.algorithms {
+		integrity.Digests = append(integrity.Digests, digest.NewDigest(algorithm, hashes[i]))
+	}
+	if err := setBlobIntegrity(&res, integrity); err != nil {
+		return err
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+// setBlobIntegrity sets the blob integrity label of the resource.
+// An already existing label is replaced, as the blob might have been modified by previous processors.
+func setBlobIntegrity(res *cdv2.Resource, integrity BlobIntegrity) error {
+	value, err := json.Marshal(integrity)
+	if err != nil {
+		return fmt.Errorf("unable to encode blob integrity: %w", err)
+	}
+	for i, label := range res.Labels {
+		if label.Name == BlobIntegrityLabelName {
+			res.Labels[i].Value = value
+			return nil
+		}
+	}
+	res.Labels = append(res.Labels, cdv2.Label{
+		Name:  BlobIntegrityLabelName,
+		Value: value,
+	})
+	return nil
+}
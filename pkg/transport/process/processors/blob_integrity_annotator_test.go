@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("blobIntegrityAnnotator", func() {
+
+	Context("Process", func() {
+
+		It("should label the resource with the size and digests of the blob", func() {
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "plain-text",
+				},
+			}
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+			resBytes := []byte("resource-blob")
+
+			inBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, bytes.NewReader(resBytes), inBuf)).To(Succeed())
+
+			outbuf := bytes.NewBuffer([]byte{})
+			p, err := processors.NewBlobIntegrityAnnotator(digest.SHA256, digest.SHA512)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p.Process(context.TODO(), inBuf, outbuf)).To(Succeed())
+
+			_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outbuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualRes.Labels).To(HaveLen(1))
+			Expect(actualRes.Labels[0].Name).To(Equal(processors.BlobIntegrityLabelName))
+			integrity := processors.BlobIntegrity{}
+			Expect(json.Unmarshal(actualRes.Labels[0].Value, &integrity)).To(Succeed())
+			Expect(integrity).To(Equal(processors.BlobIntegrity{
+				Size: int64(len(resBytes)),
+				Digests: []digest.Digest{
+					digest.SHA256.FromBytes(resBytes),
+					digest.SHA512.FromBytes(resBytes),
+				},
+			}))
+
+			actualResBlobBuf := bytes.NewBuffer([]byte{})
+			_, err = io.Copy(actualResBlobBuf, actualResBlobReader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualResBlobBuf.Bytes()).To(Equal(resBytes))
+		})
+
+		It("should reject unsupported hash algorithms", func() {
+			_, err := processors.NewBlobIntegrityAnnotator(digest.Algorithm("md5"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should be created by the processor factory", func() {
+			spec := json.RawMessage(`{"algorithms": ["sha256", "sha512"]}`)
+			p, err := processors.NewProcessorFactory(nil, nil).Create(processors.BlobIntegrityAnnotatorProcessorType, &spec)
+			Expect(err).ToNot(HaveOccurred())
+			expected, err := processors.NewBlobIntegrityAnnotator(digest.SHA256, digest.SHA512)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p).To(Equal(expected))
+
+			_, err = processors.NewProcessorFactory(nil, nil).Create(processors.BlobIntegrityAnnotatorProcessorType, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processors.Register(processors.BlobIntegrityAnnotatorProcessorType, nil)).ToNot(Succeed())
+		})
+
+	})
+})
@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/apis/v2/cdutils"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/pkg/signatures"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+const (
+	// CosignSignatureAnnotation is the annotation of a cosign signature layer that contains the base64 encoded signature.
+	CosignSignatureAnnotation = signatures.CosignSignatureAnnotation
+	// CosignCertificateAnnotation is the annotation of a cosign signature layer that contains the PEM encoded signing certificate of keyless signatures.
+	CosignCertificateAnnotation = signatures.CosignCertificateAnnotation
+	// CosignChainAnnotation is the annotation of a cosign signature layer that contains the PEM encoded certificate chain of keyless signatures.
+	CosignChainAnnotation = signatures.CosignChainAnnotation
+
+	// CosignVerifiedLabelName is the name of the label that is set on resources if unsigned resources are tagged instead of rejected.
+	CosignVerifiedLabelName = "cli.gardener.cloud/cosign-verified"
+)
+
+// CosignVerifierOptions configures the verification of cosign signatures.
+type CosignVerifierOptions struct {
+	// PublicKeys are the PEM encoded public keys that are accepted for signatures.
+	PublicKeys [][]byte
+	// RootCertificates are the PEM encoded root certificates (e.g. the Fulcio roots)
+	// that the signing certificates of keyless signatures have to chain up to.
+	RootCertificates []byte
+	// Identity is the email or uri the signing certificates of keyless signatures have to be issued for.
+	// Required if root certificates are defined.
+	Identity string
+	// Issuer is the oidc issuer the signing certificates of keyless signatures have to be issued by.
+	// Required if root certificates are defined.
+	Issuer string
+	// RekorPublicKey is the PEM encoded public key of the transparency log.
+	// If set, only signatures with a valid rekor bundle are accepted. Required if root certificates are defined,
+	// as the short-lived signing certificates of keyless signatures are verified at the time of the transparency log entry.
+	RekorPublicKey []byte
+	// TagUnverified defines whether resources without a valid signature are labeled
+	// with the CosignVerifiedLabelName label instead of failing the processing.
+	TagUnverified bool
+}
+
+type cosignVerifier struct {
+	client        ociclient.Client
+	verifyOpts    []signatures.CosignVerifyOptions
+	tagUnverified bool
+}
+
+// NewCosignVerifier returns a processor that verifies the cosign signatures of oci image resources.
+// The signatures are expected at the default cosign location "<repository>:<digest algorithm>-<digest value>.sig".
+// The signatures are verified with signatures.VerifyCosignLayer.
+func NewCosignVerifier(client ociclient.Client, opts CosignVerifierOptions) (process.ResourceStreamProcessor, error) {
+	if client == nil {
+		return nil, errors.New("client must not be nil")
+	}
+
+	var rekorPublicKey crypto.PublicKey
+	if len(opts.RekorPublicKey) != 0 {
+		key, err := signatures.LoadPublicKey(opts.RekorPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load rekor public key: %w", err)
+		}
+		rekorPublicKey = key
+	}
+
+	obj := cosignVerifier{
+		client:        client,
+		tagUnverified: opts.TagUnverified,
+	}
+	for _, rawKey := range opts.PublicKeys {
+		key, err := signatures.LoadPublicKey(rawKey)
+		if err != nil {
+			return nil, err
+		}
+		obj.verifyOpts = append(obj.verifyOpts, signatures.CosignVerifyOptions{
+			PublicKey:      key,
+			RekorPublicKey: rekorPublicKey,
+		})
+	}
+	if len(opts.RootCertificates) != 0 {
+		if len(opts.Identity) == 0 || len(opts.Issuer) == 0 {
+			return nil, errors.New("an identity and an issuer have to be defined to verify keyless signatures")
+		}
+		if rekorPublicKey == nil {
+			return nil, errors.New("a rekor public key has to be defined to verify keyless signatures")
+		}
+		roots := x509.NewCertPool()
+		if ok := roots.AppendCertsFromPEM(opts.RootCertificates); !ok {
+			return nil, errors.New("unable to parse root certificates")
+		}
+		obj.verifyOpts = append(obj.verifyOpts, signatures.CosignVerifyOptions{
+			Roots:          roots,
+			Identity:       opts.Identity,
+			Issuer:         opts.Issuer,
+			RekorPublicKey: rekorPublicKey,
+		})
+	}
+	if len(obj.verifyOpts) == 0 {
+		return nil, errors.New("at least one public key or root certificate has to be defined")
+	}
+	return &obj, nil
+}
+func (p *cosignVerifier) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	verifyErr := p.verify(ctx, res)
+	if verifyErr != nil && !p.tagUnverified {
+		return fmt.Errorf("unable to verify cosign signature of resource %s: %w", res.Name, verifyErr)
+	}
+	if p.tagUnverified {
+		res.Labels, err = cdutils.SetLabel(res.Labels, CosignVerifiedLabelName, verifyErr == nil)
+		if err != nil {
+			return fmt.Errorf("unable to set label: %w", err)
+		}
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+	return nil
+}
+
+// verify checks whether the image of the resource has at least one valid cosign signature.
+func (p *cosignVerifier) verify(ctx context.Context, res cdv2.Resource) error {
+	if res.Access == nil || res.Access.GetType() != cdv2.OCIRegistryType {
+		return fmt.Errorf("unsupported access type: %s", accessType(res))
+	}
+	ociAccess := &cdv2.OCIRegistryAccess{}
+	if err := res.Access.DecodeInto(ociAccess); err != nil {
+		return fmt.Errorf("unable to decode resource access: %w", err)
+	}
+
+	resolved, err := ociclient.ResolveReference(ctx, p.client, ociAccess.ImageReference)
+	if err != nil {
+		return fmt.Errorf("unable to resolve image %s: %w", ociAccess.ImageReference, err)
+	}
+	sigRef := resolved.TagRef(fmt.Sprintf("%s-%s.sig", resolved.Digest.Algorithm(), resolved.Digest.Hex()))
+
+	sigManifest, err := p.client.GetManifest(ctx, sigRef)
+	if err != nil {
+		return fmt.Errorf("unable to get signature manifest %s: %w", sigRef, err)
+	}
+
+	var errs []string
+	for _, layer := range sigManifest.Layers {
+		if _, ok := layer.Annotations[CosignSignatureAnnotation]; !ok {
+			continue
+		}
+		if err := p.verifyLayer(ctx, sigRef, layer, resolved.Digest); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		return nil
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("no signatures found in %s", sigRef)
+	}
+	return fmt.Errorf("no valid signature found in %s: %s", sigRef, strings.Join(errs, "; "))
+}
+
+// verifyLayer verifies a single signature layer of a cosign signature manifest.
+func (p *cosignVerifier) verifyLayer(ctx context.Context, sigRef string, layer ocispecv1.Descriptor, imageDigest digest.Digest) error {
+	var payload bytes.Buffer
+	if err := p.client.Fetch(ctx, sigRef, layer, &payload); err != nil {
+		return fmt.Errorf("unable to fetch signature payload: %w", err)
+	}
+
+	var errs []string
+	for _, opts := range p.verifyOpts {
+		_, err := signatures.VerifyCosignLayer(layer, payload.Bytes(), imageDigest, opts)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return fmt.Errorf("signature does not match any of the configured keys or identities: %s", strings.Join(errs, "; "))
+}
+
+func accessType(res cdv2.Resource) string {
+	if res.Access == nil {
+		return ""
+	}
+	return res.Access.GetType()
+}
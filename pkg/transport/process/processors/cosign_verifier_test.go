@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/apis/v2/cdutils"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	mock_ociclient "github.com/gardener/component-cli/ociclient/mock"
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("cosignVerifier", func() {
+
+	var (
+		mockCtrl      *gomock.Controller
+		mockOCIClient *mock_ociclient.MockClient
+
+		signingKey  *ecdsa.PrivateKey
+		imageDigest digest.Digest
+		cd          cdv2.ComponentDescriptor
+		res         cdv2.Resource
+	)
+
+	encodePublicKey := func(key *ecdsa.PublicKey) []byte {
+		data, err := x509.MarshalPKIXPublicKey(key)
+		Expect(err).ToNot(HaveOccurred())
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: data})
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockOCIClient = mock_ociclient.NewMockClient(mockCtrl)
+
+		var err error
+		signingKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		imageDigest = digest.FromString("image-manifest")
+		acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess("example.com/my-image:v0.1.0"))
+		Expect(err).ToNot(HaveOccurred())
+		res = cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    "ociImage",
+			},
+			Access: &acc,
+		}
+		cd = cdv2.ComponentDescriptor{
+			ComponentSpec: cdv2.ComponentSpec{
+				Resources: []cdv2.Resource{
+					res,
+				},
+			},
+		}
+
+		payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"example.com/my-image"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, imageDigest))
+		hash := sha256.Sum256(payload)
+		signature, err := ecdsa.SignASN1(rand.Reader, signingKey, hash[:])
+		Expect(err).ToNot(HaveOccurred())
+
+		sigRef := fmt.Sprintf("example.com/my-image:sha256-%s.sig", imageDigest.Hex())
+		layer := ocispecv1.Descriptor{
+			MediaType: "application/vnd.dev.cosign.simplesigning.v1+json",
+			Digest:    digest.FromBytes(payload),
+			Size:      int64(len(payload)),
+			Annotations: map[string]string{
+				processors.CosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature),
+			},
+		}
+
+		mockOCIClient.EXPECT().Resolve(gomock.Any(), "example.com/my-image:v0.1.0").Return("example.com/my-image:v0.1.0", ocispecv1.Descriptor{Digest: imageDigest}, nil)
+		mockOCIClient.EXPECT().GetManifest(gomock.Any(), sigRef).Return(&ocispecv1.Manifest{Layers: []ocispecv1.Descriptor{layer}}, nil)
+		mockOCIClient.EXPECT().Fetch(gomock.Any(), sigRef, layer, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ ocispecv1.Descriptor, w io.Writer) error {
+			_, err := w.Write(payload)
+			return err
+		})
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should pass a resource with a valid signature", func() {
+		p, err := processors.NewCosignVerifier(mockOCIClient, processors.CosignVerifierOptions{
+			PublicKeys: [][]byte{encodePublicKey(&signingKey.PublicKey)},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		inProcessorMsg := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cd, res, nil, inProcessorMsg)).To(Succeed())
+
+		outProcessorMsg := bytes.NewBuffer([]byte{})
+		Expect(p.Process(context.TODO(), inProcessorMsg, outProcessorMsg)).To(Succeed())
+
+		_, actualRes, _, err := utils.ReadProcessorMessage(outProcessorMsg)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(actualRes.IdentityObjectMeta).To(Equal(res.IdentityObjectMeta))
+		Expect(actualRes.Access.Object).To(HaveKeyWithValue("imageReference", "example.com/my-image:v0.1.0"))
+	})
+
+	It("should fail if the signature does not match the configured public keys", func() {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		p, err := processors.NewCosignVerifier(mockOCIClient, processors.CosignVerifierOptions{
+			PublicKeys: [][]byte{encodePublicKey(&otherKey.PublicKey)},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		inProcessorMsg := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cd, res, nil, inProcessorMsg)).To(Succeed())
+
+		outProcessorMsg := bytes.NewBuffer([]byte{})
+		Expect(p.Process(context.TODO(), inProcessorMsg, outProcessorMsg)).ToNot(Succeed())
+	})
+
+	It("should tag a resource with an invalid signature if configured", func() {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		p, err := processors.NewCosignVerifier(mockOCIClient, processors.CosignVerifierOptions{
+			PublicKeys:    [][]byte{encodePublicKey(&otherKey.PublicKey)},
+			TagUnverified: true,
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		inProcessorMsg := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cd, res, nil, inProcessorMsg)).To(Succeed())
+
+		outProcessorMsg := bytes.NewBuffer([]byte{})
+		Expect(p.Process(context.TODO(), inProcessorMsg, outProcessorMsg)).To(Succeed())
+
+		_, actualRes, _, err := utils.ReadProcessorMessage(outProcessorMsg)
+		Expect(err).ToNot(HaveOccurred())
+		label, ok := cdutils.GetLabel(actualRes.Labels, processors.CosignVerifiedLabelName)
+		Expect(ok).To(BeTrue())
+		var verified bool
+		Expect(json.Unmarshal(label.Value, &verified)).To(Succeed())
+		Expect(verified).To(BeFalse())
+	})
+
+})
+
+var _ = Describe("cosignVerifier options", func() {
+
+	var (
+		mockCtrl      *gomock.Controller
+		mockOCIClient *mock_ociclient.MockClient
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockOCIClient = mock_ociclient.NewMockClient(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("should require an identity, an issuer and a rekor public key for keyless signatures", func() {
+		_, err := processors.NewCosignVerifier(mockOCIClient, processors.CosignVerifierOptions{
+			RootCertificates: []byte("roots"),
+		})
+		Expect(err).To(MatchError(ContainSubstring("identity and an issuer")))
+
+		_, err = processors.NewCosignVerifier(mockOCIClient, processors.CosignVerifierOptions{
+			RootCertificates: []byte("roots"),
+			Identity:         "user@example.com",
+			Issuer:           "https://accounts.example.com",
+		})
+		Expect(err).To(MatchError(ContainSubstring("rekor public key")))
+	})
+
+	It("should be created by the processor factory", func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		data, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		Expect(err).ToNot(HaveOccurred())
+		spec, err := json.Marshal(map[string]interface{}{
+			"publicKeys": []string{string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: data}))},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		rawSpec := json.RawMessage(spec)
+
+		p, err := processors.NewProcessorFactory(mockOCIClient, nil).Create(processors.CosignVerifierProcessorType, &rawSpec)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p).ToNot(BeNil())
+
+		_, err = processors.NewProcessorFactory(nil, nil).Create(processors.CosignVerifierProcessorType, &rawSpec)
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -37,13 +37,7 @@ func main() {
 	}
 	// if addr is set, use unix domain sockets for communication
 
-	h := func(r io.Reader, w io.WriteCloser) {
-		if err := processorRoutine(r, w); err != nil {
-			log.Fatal(err)
-		}
-	}
-
-	srv, err := utils.NewUnixDomainSocketServer(addr, h)
+	srv, err := newServer(addr)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -57,6 +51,25 @@ func main() {
 	srv.Stop()
 }
 
+type server interface {
+	Start()
+	Stop()
+}
+
+// newServer returns a grpc server if the grpc protocol is requested and a unix domain socket server otherwise.
+// The grpc server returns processing errors to the client, the unix domain socket server can only exit.
+func newServer(addr string) (server, error) {
+	if os.Getenv(extensions.ProcessorServerProtocolEnv) == string(extensions.GRPCProtocol) {
+		return extensions.NewGRPCServer(addr, processorRoutine)
+	}
+	h := func(r io.Reader, w io.WriteCloser) {
+		if err := processorRoutine(r, w); err != nil {
+			log.Fatal(err)
+		}
+	}
+	return utils.NewUnixDomainSocketServer(addr, h)
+}
+
 func processorRoutine(inputStream io.Reader, outputStream io.WriteCloser) error {
 	defer outputStream.Close()
 
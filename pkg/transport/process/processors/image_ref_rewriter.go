@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+// ImageRefRewriterSpec defines the rewrite rules of an image reference rewriter.
+type ImageRefRewriterSpec struct {
+	// Rules are applied in order to the image reference, e.g. to switch the registry or to add a path prefix.
+	// The name of a rule is ignored.
+	Rules []ReplaceRule `json:"rules"`
+}
+
+type imageRefRewriter struct {
+	rules []compiledReplaceRule
+}
+
+// NewImageRefRewriter returns a processor that rewrites the image reference of resources with an ociRegistry access.
+// Resources with other access types are passed through unchanged.
+func NewImageRefRewriter(spec ImageRefRewriterSpec) (process.ResourceStreamProcessor, error) {
+	rules, err := compileReplaceRules(spec.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rewrite rule: %w", err)
+	}
+
+	obj := imageRefRewriter{
+		rules: rules,
+	}
+	return &obj, nil
+}
+
+func (p *imageRefRewriter) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	if res.Access != nil && res.Access.GetType() == cdv2.OCIRegistryType {
+		ociAccess := &cdv2.OCIRegistryAccess{}
+		if err := res.Access.DecodeInto(ociAccess); err != nil {
+			return fmt.Errorf("unable to decode resource access: %w", err)
+		}
+
+		imageRef := ociAccess.ImageReference
+		for _, rule := range p.rules {
+			imageRef = rule.regex.ReplaceAllString(imageRef, rule.Replacement)
+		}
+
+		if imageRef != ociAccess.ImageReference {
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(imageRef))
+			if err != nil {
+				return fmt.Errorf("unable to create resource access: %w", err)
+			}
+			res.Access = &acc
+		}
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
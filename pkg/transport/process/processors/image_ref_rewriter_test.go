@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("imageRefRewriter", func() {
+
+	Context("Process", func() {
+
+		process := func(spec string, res cdv2.Resource) cdv2.Resource {
+			rawSpec, err := yaml.YAMLToJSON([]byte(spec))
+			Expect(err).ToNot(HaveOccurred())
+			jsonSpec := json.RawMessage(rawSpec)
+			p, err := processors.NewProcessorFactory(nil, nil).Create(processors.ImageRefRewriterProcessorType, &jsonSpec)
+			Expect(err).ToNot(HaveOccurred())
+
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+			inBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, bytes.NewReader([]byte("resource-blob")), inBuf)).To(Succeed())
+			outBuf := bytes.NewBuffer([]byte{})
+			Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+			_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualResBlobReader.Close()).To(Succeed())
+			return actualRes
+		}
+
+		It("should rewrite the image reference of an oci registry access", func() {
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess("eu.gcr.io/gardener-project/gardener/apiserver:v1.0.0"))
+			Expect(err).ToNot(HaveOccurred())
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "apiserver",
+					Version: "v1.0.0",
+					Type:    cdv2.OCIImageType,
+				},
+				Access: &acc,
+			}
+
+			actualRes := process(`
+rules:
+- regex: ^eu\.gcr\.io/
+  replacement: registry.example.com/
+- regex: ^registry\.example\.com/(.*)$
+  replacement: registry.example.com/mirror/$1
+`, res)
+
+			ociAccess := cdv2.OCIRegistryAccess{}
+			Expect(actualRes.Access.DecodeInto(&ociAccess)).To(Succeed())
+			Expect(ociAccess.ImageReference).To(Equal("registry.example.com/mirror/gardener-project/gardener/apiserver:v1.0.0"))
+		})
+
+		It("should not modify resources with other access types", func() {
+			acc, err := cdv2.NewUnstructured(cdv2.NewLocalOCIBlobAccess("sha256:abc"))
+			Expect(err).ToNot(HaveOccurred())
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "blob",
+					Version: "v1.0.0",
+					Type:    "plain-text",
+				},
+				Access: &acc,
+			}
+
+			actualRes := process(`
+rules:
+- regex: abc
+  replacement: def
+`, res)
+			Expect(actualRes.Access.Object).To(HaveKeyWithValue("digest", "sha256:abc"))
+		})
+
+	})
+})
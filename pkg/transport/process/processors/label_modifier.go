@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/apis/v2/cdutils"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+// LabelModifierSpec defines the modifications of the labels and the extra identity of a resource.
+type LabelModifierSpec struct {
+	// Labels defines the modifications of the labels.
+	Labels LabelRules `json:"labels"`
+	// ExtraIdentity defines the modifications of the extra identity.
+	ExtraIdentity ExtraIdentityRules `json:"extraIdentity"`
+}
+
+// LabelRules defines the modifications of the labels of a resource.
+// Labels are removed first, then added and replaced at last.
+type LabelRules struct {
+	// Add adds the labels. Existing labels with the same name are overwritten.
+	Add []cdv2.Label `json:"add"`
+	// Remove removes the labels with the given names.
+	Remove []string `json:"remove"`
+	// Replace rewrites the values of labels with a string value.
+	Replace []ReplaceRule `json:"replace"`
+}
+
+// ExtraIdentityRules defines the modifications of the extra identity of a resource.
+// Keys are removed first, then added and replaced at last.
+type ExtraIdentityRules struct {
+	// Add adds the keys. Existing keys are overwritten.
+	Add map[string]string `json:"add"`
+	// Remove removes the given keys.
+	Remove []string `json:"remove"`
+	// Replace rewrites the values of keys.
+	Replace []ReplaceRule `json:"replace"`
+}
+
+// ReplaceRule replaces all matches of a regular expression in a value.
+type ReplaceRule struct {
+	// Name is the name of the label or the extra identity key whose value is replaced.
+	// The rule is applied to all values if the name is empty.
+	Name string `json:"name"`
+	// Regex is the regular expression that is matched against the value.
+	Regex string `json:"regex"`
+	// Replacement is the replacement of the matches, which can contain references like "$1" to submatches.
+	Replacement string `json:"replacement"`
+}
+
+type compiledReplaceRule struct {
+	ReplaceRule
+	regex *regexp.Regexp
+}
+
+func (r compiledReplaceRule) apply(name, value string) string {
+	if len(r.Name) != 0 && r.Name != name {
+		return value
+	}
+	return r.regex.ReplaceAllString(value, r.Replacement)
+}
+
+type labelModifier struct {
+	spec                 LabelModifierSpec
+	labelReplaces        []compiledReplaceRule
+	extraIdentityReplace []compiledReplaceRule
+}
+
+// NewLabelModifier returns a processor that adds, removes and rewrites the labels and the extra identity of a resource.
+func NewLabelModifier(spec LabelModifierSpec) (process.ResourceStreamProcessor, error) {
+	labelReplaces, err := compileReplaceRules(spec.Labels.Replace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label replace rule: %w", err)
+	}
+	extraIdentityReplaces, err := compileReplaceRules(spec.ExtraIdentity.Replace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extra identity replace rule: %w", err)
+	}
+
+	obj := labelModifier{
+		spec:                 spec,
+		labelReplaces:        labelReplaces,
+		extraIdentityReplace: extraIdentityReplaces,
+	}
+	return &obj, nil
+}
+
+func compileReplaceRules(rules []ReplaceRule) ([]compiledReplaceRule, error) {
+	compiled := make([]compiledReplaceRule, len(rules))
+	for i, rule := range rules {
+		regex, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("unable to compile regex %q: %w", rule.Regex, err)
+		}
+		compiled[i] = compiledReplaceRule{
+			ReplaceRule: rule,
+			regex:       regex,
+		}
+	}
+	return compiled, nil
+}
+
+func (p *labelModifier) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	res.Labels, err = p.modifyLabels(res.Labels)
+	if err != nil {
+		return err
+	}
+	res.ExtraIdentity = p.modifyExtraIdentity(res.ExtraIdentity)
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+func (p *labelModifier) modifyLabels(labels cdv2.Labels) (cdv2.Labels, error) {
+	if len(p.spec.Labels.Remove) != 0 {
+		remaining := cdv2.Labels{}
+		for _, label := range labels {
+			if !containsString(p.spec.Labels.Remove, label.Name) {
+				remaining = append(remaining, label)
+			}
+		}
+		labels = remaining
+	}
+	for _, label := range p.spec.Labels.Add {
+		labels = cdutils.SetRawLabel(labels, label.Name, label.Value)
+	}
+	if len(p.labelReplaces) == 0 {
+		return labels, nil
+	}
+
+	modified := make(cdv2.Labels, len(labels))
+	for i, label := range labels {
+		modified[i] = label
+		var value string
+		if err := json.Unmarshal(label.Value, &value); err != nil {
+			// only string values are rewritten
+			continue
+		}
+		for _, rule := range p.labelReplaces {
+			value = rule.apply(label.Name, value)
+		}
+		rawValue, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode value of label %s: %w", label.Name, err)
+		}
+		modified[i].Value = rawValue
+	}
+	return modified, nil
+}
+
+func (p *labelModifier) modifyExtraIdentity(extraIdentity cdv2.Identity) cdv2.Identity {
+	modified := cdv2.Identity{}
+	for key, value := range extraIdentity {
+		modified[key] = value
+	}
+	for _, key := range p.spec.ExtraIdentity.Remove {
+		delete(modified, key)
+	}
+	for key, value := range p.spec.ExtraIdentity.Add {
+		modified[key] = value
+	}
+	for key, value := range modified {
+		for _, rule := range p.extraIdentityReplace {
+			value = rule.apply(key, value)
+		}
+		modified[key] = value
+	}
+	if len(modified) == 0 {
+		return nil
+	}
+	return modified
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("labelModifier", func() {
+
+	Context("Process", func() {
+
+		It("should add, remove and rewrite labels and extra identity defined by the spec", func() {
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+					ExtraIdentity: cdv2.Identity{
+						"platform": "linux-amd64",
+						"build":    "123",
+					},
+					Labels: cdv2.Labels{
+						{Name: "obsolete", Value: json.RawMessage(`"true"`)},
+						{Name: "source-repo", Value: json.RawMessage(`"github.com/gardener/component-cli"`)},
+						{Name: "structured", Value: json.RawMessage(`{"key":"github.com"}`)},
+					},
+				},
+			}
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+
+			rawSpec, err := yaml.YAMLToJSON([]byte(`
+labels:
+  add:
+  - name: added
+    value:
+      key: val
+  remove:
+  - obsolete
+  replace:
+  - name: source-repo
+    regex: ^github\.com/(.*)$
+    replacement: mirror.example.com/$1
+extraIdentity:
+  add:
+    os: linux
+  remove:
+  - build
+  replace:
+  - regex: amd64
+    replacement: x86_64
+`))
+			Expect(err).ToNot(HaveOccurred())
+			spec := json.RawMessage(rawSpec)
+			p, err := processors.NewProcessorFactory(nil, nil).Create(processors.LabelModifierProcessorType, &spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			inBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, bytes.NewReader([]byte("resource-blob")), inBuf)).To(Succeed())
+			outBuf := bytes.NewBuffer([]byte{})
+			Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+			_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualResBlobReader.Close()).To(Succeed())
+
+			Expect(actualRes.ExtraIdentity).To(Equal(cdv2.Identity{
+				"platform": "linux-x86_64",
+				"os":       "linux",
+			}))
+			Expect(actualRes.Labels).To(HaveLen(3))
+			Expect(actualRes.Labels[0].Name).To(Equal("source-repo"))
+			Expect(actualRes.Labels[0].Value).To(MatchJSON(`"mirror.example.com/gardener/component-cli"`))
+			Expect(actualRes.Labels[1].Name).To(Equal("structured"))
+			Expect(actualRes.Labels[1].Value).To(MatchJSON(`{"key":"github.com"}`))
+			Expect(actualRes.Labels[2].Name).To(Equal("added"))
+			Expect(actualRes.Labels[2].Value).To(MatchJSON(`{"key":"val"}`))
+		})
+
+		It("should return an error for invalid regular expressions", func() {
+			_, err := processors.NewLabelModifier(processors.LabelModifierSpec{
+				Labels: processors.LabelRules{
+					Replace: []processors.ReplaceRule{{Regex: "("}},
+				},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+
+	})
+})
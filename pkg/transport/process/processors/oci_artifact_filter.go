@@ -0,0 +1,309 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/ociclient/oci"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+// OCIArtifactFilterSpec defines the files that are removed from the layers of oci artifacts.
+type OCIArtifactFilterSpec struct {
+	// RemovePatterns are the glob patterns (see path.Match) of the files that are removed from the layers.
+	// A pattern that matches a directory removes the directory with all its files.
+	RemovePatterns []string `json:"removePatterns"`
+	// CompressionOptions configure the compression of the modified gzip layers.
+	// Unmodified layers are kept as they are.
+	utils.CompressionOptions
+}
+
+type ociArtifactFilter struct {
+	cache          cache.Cache
+	removePatterns []string
+	compression    utils.CompressionOptions
+}
+
+// NewOCIArtifactFilter returns a processor that removes files from the layers of oci image resources.
+// Modified gzip layers are compressed deterministically, so that the filtered artifact has the same digest on every run.
+// Resources of other types are passed through unchanged.
+func NewOCIArtifactFilter(cache cache.Cache, spec OCIArtifactFilterSpec) (process.ResourceStreamProcessor, error) {
+	if cache == nil {
+		return nil, errors.New("cache must not be nil")
+	}
+	if len(spec.RemovePatterns) == 0 {
+		return nil, errors.New("at least one remove pattern must be provided")
+	}
+	patterns := make([]string, 0, len(spec.RemovePatterns))
+	for _, pattern := range spec.RemovePatterns {
+		pattern = strings.TrimPrefix(path.Clean("/"+pattern), "/")
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid remove pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	if err := spec.CompressionOptions.Validate(); err != nil {
+		return nil, err
+	}
+
+	obj := ociArtifactFilter{
+		cache:          cache,
+		removePatterns: patterns,
+		compression:    spec.CompressionOptions,
+	}
+	return &obj, nil
+}
+
+func (f *ociArtifactFilter) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader == nil {
+		return errors.New("resource blob must not be nil")
+	}
+	defer resBlobReader.Close()
+
+	if res.Type != cdv2.OCIImageType {
+		if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+			return fmt.Errorf("unable to write processor message: %w", err)
+		}
+		return nil
+	}
+
+	ociArtifact, err := utils.DeserializeOCIArtifact(resBlobReader, f.cache)
+	if err != nil {
+		return fmt.Errorf("unable to deserialize oci artifact: %w", err)
+	}
+	if ociArtifact.IsIndex() {
+		for _, m := range ociArtifact.GetIndex().Manifests {
+			if err := f.filterImage(m); err != nil {
+				return fmt.Errorf("unable to filter image %s: %w", m.Descriptor.Digest, err)
+			}
+		}
+	} else {
+		if err := f.filterImage(ociArtifact.GetManifest()); err != nil {
+			return fmt.Errorf("unable to filter image: %w", err)
+		}
+	}
+
+	blobReader, err := utils.SerializeOCIArtifact(*ociArtifact, f.cache)
+	if err != nil {
+		return fmt.Errorf("unable to serialize oci artifact: %w", err)
+	}
+	defer blobReader.Close()
+
+	if err := utils.WriteProcessorMessage(*cd, res, blobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+// filterImage removes the files from all layers of the image.
+// The diff ids of the modified layers are updated in the image config.
+func (f *ociArtifactFilter) filterImage(manifest *oci.Manifest) error {
+	diffIDs := map[int]digest.Digest{}
+	for i, layer := range manifest.Data.Layers {
+		filteredLayer, diffID, modified, err := f.filterLayer(layer)
+		if err != nil {
+			return fmt.Errorf("unable to filter layer %s: %w", layer.Digest, err)
+		}
+		if !modified {
+			continue
+		}
+		manifest.Data.Layers[i] = filteredLayer
+		diffIDs[i] = diffID
+	}
+	if len(diffIDs) == 0 {
+		return nil
+	}
+
+	configReader, err := f.cache.Get(manifest.Data.Config)
+	if err != nil {
+		return fmt.Errorf("unable to get config blob from cache: %w", err)
+	}
+	defer configReader.Close()
+	configData, err := ioutil.ReadAll(configReader)
+	if err != nil {
+		return fmt.Errorf("unable to read config blob: %w", err)
+	}
+
+	config := map[string]json.RawMessage{}
+	if err := json.Unmarshal(configData, &config); err != nil {
+		return fmt.Errorf("unable to unmarshal config: %w", err)
+	}
+	rawRootFS, ok := config["rootfs"]
+	if !ok {
+		// the config of other artifacts than container images does not reference the layers
+		return nil
+	}
+	var rootFS ocispecv1.RootFS
+	if err := json.Unmarshal(rawRootFS, &rootFS); err != nil {
+		return fmt.Errorf("unable to unmarshal rootfs of config: %w", err)
+	}
+	for i, diffID := range diffIDs {
+		if i >= len(rootFS.DiffIDs) {
+			return fmt.Errorf("config does not contain the diff id of layer %d", i)
+		}
+		rootFS.DiffIDs[i] = diffID
+	}
+	if config["rootfs"], err = json.Marshal(rootFS); err != nil {
+		return fmt.Errorf("unable to marshal rootfs of config: %w", err)
+	}
+	if configData, err = json.Marshal(config); err != nil {
+		return fmt.Errorf("unable to marshal config: %w", err)
+	}
+
+	manifest.Data.Config.Digest = digest.FromBytes(configData)
+	manifest.Data.Config.Size = int64(len(configData))
+	if err := f.cache.Add(manifest.Data.Config, ioutil.NopCloser(bytes.NewReader(configData))); err != nil {
+		return fmt.Errorf("unable to add config blob to cache: %w", err)
+	}
+	return nil
+}
+
+// filterLayer removes the files from a tar layer and returns the descriptor and the diff id of the filtered layer.
+// Layers of other media types and layers that do not contain any of the files are not modified.
+func (f *ociArtifactFilter) filterLayer(layer ocispecv1.Descriptor) (ocispecv1.Descriptor, digest.Digest, bool, error) {
+	compressed := isGzipLayer(layer.MediaType)
+	if !compressed && !isTarLayer(layer.MediaType) {
+		return layer, "", false, nil
+	}
+
+	layerReader, err := f.cache.Get(layer)
+	if err != nil {
+		return layer, "", false, fmt.Errorf("unable to get layer blob from cache: %w", err)
+	}
+	defer layerReader.Close()
+	var tarReader io.Reader = layerReader
+	if compressed {
+		gr, err := gzip.NewReader(layerReader)
+		if err != nil {
+			return layer, "", false, fmt.Errorf("unable to create gzip reader: %w", err)
+		}
+		defer gr.Close()
+		tarReader = gr
+	}
+
+	tmpfile, err := ioutil.TempFile("", "")
+	if err != nil {
+		return layer, "", false, fmt.Errorf("unable to create tempfile: %w", err)
+	}
+	defer func() {
+		_ = tmpfile.Close()
+		_ = os.Remove(tmpfile.Name())
+	}()
+
+	// the filtered tar is written uncompressed to the diff id digester and (compressed) to the tempfile.
+	layerDigester := digest.Canonical.Digester()
+	var layerWriter io.Writer = io.MultiWriter(tmpfile, layerDigester.Hash())
+	var gw *gzip.Writer
+	if compressed {
+		if gw, err = utils.NewGzipWriter(layerWriter, f.compression); err != nil {
+			return layer, "", false, err
+		}
+		layerWriter = gw
+	}
+	diffIDDigester := digest.Canonical.Digester()
+	tw := tar.NewWriter(io.MultiWriter(layerWriter, diffIDDigester.Hash()))
+
+	removed, err := f.filterTar(tar.NewReader(tarReader), tw)
+	if err != nil {
+		return layer, "", false, err
+	}
+	if removed == 0 {
+		return layer, "", false, nil
+	}
+	if err := tw.Close(); err != nil {
+		return layer, "", false, fmt.Errorf("unable to close tar writer: %w", err)
+	}
+	if gw != nil {
+		if err := gw.Close(); err != nil {
+			return layer, "", false, fmt.Errorf("unable to close gzip writer: %w", err)
+		}
+	}
+
+	size, err := tmpfile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return layer, "", false, fmt.Errorf("unable to get size of tempfile: %w", err)
+	}
+	if _, err := tmpfile.Seek(0, io.SeekStart); err != nil {
+		return layer, "", false, fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+	}
+	filteredLayer := ocispecv1.Descriptor{
+		MediaType:   layer.MediaType,
+		Digest:      layerDigester.Digest(),
+		Size:        size,
+		Annotations: layer.Annotations,
+	}
+	if err := f.cache.Add(filteredLayer, ioutil.NopCloser(tmpfile)); err != nil {
+		return layer, "", false, fmt.Errorf("unable to add filtered layer to cache: %w", err)
+	}
+	return filteredLayer, diffIDDigester.Digest(), true, nil
+}
+
+// filterTar copies all files that do not match a remove pattern and returns the number of removed files.
+func (f *ociArtifactFilter) filterTar(tr *tar.Reader, tw *tar.Writer) (int, error) {
+	removed := 0
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if err == io.EOF {
+				return removed, nil
+			}
+			return removed, fmt.Errorf("unable to read tar header: %w", err)
+		}
+		if f.shouldRemove(header.Name) {
+			removed++
+			continue
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return removed, fmt.Errorf("unable to write tar header: %w", err)
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return removed, fmt.Errorf("unable to copy file %s: %w", header.Name, err)
+		}
+	}
+}
+
+// shouldRemove returns whether the file or one of its parent directories matches a remove pattern.
+func (f *ociArtifactFilter) shouldRemove(name string) bool {
+	for p := strings.TrimPrefix(path.Clean("/"+name), "/"); len(p) != 0 && p != "."; p = path.Dir(p) {
+		for _, pattern := range f.removePatterns {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isGzipLayer returns whether the media type is a gzip compressed tar layer.
+func isGzipLayer(mediaType string) bool {
+	return strings.HasSuffix(mediaType, ".tar+gzip") || strings.HasSuffix(mediaType, ".tar.gzip")
+}
+
+// isTarLayer returns whether the media type is an uncompressed tar layer.
+func isTarLayer(mediaType string) bool {
+	return strings.HasSuffix(mediaType, ".tar")
+}
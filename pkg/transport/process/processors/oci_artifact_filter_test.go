@@ -0,0 +1,214 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/ociclient/oci"
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("ociArtifactFilter", func() {
+
+	createLayer := func(files map[string]string) []byte {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		gw.ModTime = time.Now()
+		tw := tar.NewWriter(gw)
+		for _, name := range []string{"bin/sh", "etc/passwd", "usr/share/doc/readme"} {
+			content, ok := files[name]
+			if !ok {
+				continue
+			}
+			Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))})).To(Succeed())
+			_, err := tw.Write([]byte(content))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(tw.Close()).To(Succeed())
+		Expect(gw.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	readLayer := func(data []byte) map[string]string {
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gr.Header.ModTime.IsZero()).To(BeTrue())
+		files := map[string]string{}
+		tr := tar.NewReader(gr)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).ToNot(HaveOccurred())
+			content, err := ioutil.ReadAll(tr)
+			Expect(err).ToNot(HaveOccurred())
+			files[header.Name] = string(content)
+		}
+		return files
+	}
+
+	process := func(spec string, res cdv2.Resource, layerData []byte) (*ocispecv1.Manifest, cache.Cache) {
+		configData := []byte(`{"architecture":"amd64","rootfs":{"type":"layers","diff_ids":["sha256:0000000000000000000000000000000000000000000000000000000000000000"]}}`)
+		manifest := &ocispecv1.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			Config: ocispecv1.Descriptor{
+				MediaType: ocispecv1.MediaTypeImageConfig,
+				Digest:    digest.FromBytes(configData),
+				Size:      int64(len(configData)),
+			},
+			Layers: []ocispecv1.Descriptor{
+				{
+					MediaType: ocispecv1.MediaTypeImageLayerGzip,
+					Digest:    digest.FromBytes(layerData),
+					Size:      int64(len(layerData)),
+				},
+			},
+		}
+		inCache := cache.NewInMemoryCache()
+		Expect(inCache.Add(manifest.Config, ioutil.NopCloser(bytes.NewReader(configData)))).To(Succeed())
+		Expect(inCache.Add(manifest.Layers[0], ioutil.NopCloser(bytes.NewReader(layerData)))).To(Succeed())
+		ociArtifact, err := oci.NewManifestArtifact(&oci.Manifest{Data: manifest})
+		Expect(err).ToNot(HaveOccurred())
+		blobReader, err := utils.SerializeOCIArtifact(*ociArtifact, inCache)
+		Expect(err).ToNot(HaveOccurred())
+		defer blobReader.Close()
+
+		outCache := cache.NewInMemoryCache()
+		rawSpec := json.RawMessage(spec)
+		p, err := processors.NewProcessorFactory(nil, outCache).Create(processors.OCIArtifactFilterProcessorType, &rawSpec)
+		Expect(err).ToNot(HaveOccurred())
+
+		cd := cdv2.ComponentDescriptor{
+			ComponentSpec: cdv2.ComponentSpec{
+				Resources: []cdv2.Resource{res},
+			},
+		}
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cd, res, blobReader, inBuf)).To(Succeed())
+		outBuf := bytes.NewBuffer([]byte{})
+		Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+		_, _, actualBlobReader, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		defer actualBlobReader.Close()
+		actualArtifact, err := utils.DeserializeOCIArtifact(actualBlobReader, outCache)
+		Expect(err).ToNot(HaveOccurred())
+		return actualArtifact.GetManifest().Data, outCache
+	}
+
+	getBlob := func(c cache.Cache, desc ocispecv1.Descriptor) []byte {
+		r, err := c.Get(desc)
+		Expect(err).ToNot(HaveOccurred())
+		defer r.Close()
+		data, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		return data
+	}
+
+	image := cdv2.Resource{
+		IdentityObjectMeta: cdv2.IdentityObjectMeta{
+			Name:    "image",
+			Version: "v1.0.0",
+			Type:    cdv2.OCIImageType,
+		},
+	}
+
+	It("should remove the files from the layers and update the diff ids", func() {
+		layerData := createLayer(map[string]string{
+			"bin/sh":               "shell",
+			"etc/passwd":           "root",
+			"usr/share/doc/readme": "docs",
+		})
+		manifest, c := process(`{"removePatterns": ["etc/passwd", "usr/share/doc"]}`, image, layerData)
+
+		Expect(manifest.Layers).To(HaveLen(1))
+		Expect(manifest.Layers[0].Digest).ToNot(Equal(digest.FromBytes(layerData)))
+		filteredLayer := getBlob(c, manifest.Layers[0])
+		Expect(readLayer(filteredLayer)).To(Equal(map[string]string{"bin/sh": "shell"}))
+
+		gr, err := gzip.NewReader(bytes.NewReader(filteredLayer))
+		Expect(err).ToNot(HaveOccurred())
+		diffID, err := digest.FromReader(gr)
+		Expect(err).ToNot(HaveOccurred())
+		config := ocispecv1.Image{}
+		Expect(json.Unmarshal(getBlob(c, manifest.Config), &config)).To(Succeed())
+		Expect(config.RootFS.DiffIDs).To(Equal([]digest.Digest{diffID}))
+		Expect(config.Architecture).To(Equal("amd64"))
+	})
+
+	It("should produce identical digests for identical inputs", func() {
+		spec := `{"removePatterns": ["etc/*"], "compressionLevel": 9}`
+		files := map[string]string{
+			"bin/sh":     "shell",
+			"etc/passwd": "root",
+		}
+		// the layers differ in the modification time of their gzip headers
+		first, _ := process(spec, image, createLayer(files))
+		time.Sleep(time.Second)
+		second, _ := process(spec, image, createLayer(files))
+		Expect(second.Layers[0].Digest).To(Equal(first.Layers[0].Digest))
+		Expect(second.Config.Digest).To(Equal(first.Config.Digest))
+	})
+
+	It("should not modify layers that do not contain any of the files", func() {
+		layerData := createLayer(map[string]string{
+			"bin/sh": "shell",
+		})
+		manifest, _ := process(`{"removePatterns": ["etc/passwd"]}`, image, layerData)
+		Expect(manifest.Layers[0].Digest).To(Equal(digest.FromBytes(layerData)))
+	})
+
+	It("should not modify resources of other types", func() {
+		rawSpec := json.RawMessage(`{"removePatterns": ["etc/passwd"]}`)
+		p, err := processors.NewProcessorFactory(nil, cache.NewInMemoryCache()).Create(processors.OCIArtifactFilterProcessorType, &rawSpec)
+		Expect(err).ToNot(HaveOccurred())
+
+		res := cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "blob",
+				Version: "v1.0.0",
+				Type:    "plain-text",
+			},
+		}
+		cd := cdv2.ComponentDescriptor{}
+		inBuf := bytes.NewBuffer([]byte{})
+		Expect(utils.WriteProcessorMessage(cd, res, bytes.NewReader([]byte("resource-blob")), inBuf)).To(Succeed())
+		outBuf := bytes.NewBuffer([]byte{})
+		Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+		_, _, actualBlobReader, err := utils.ReadProcessorMessage(outBuf)
+		Expect(err).ToNot(HaveOccurred())
+		defer actualBlobReader.Close()
+		data, err := ioutil.ReadAll(actualBlobReader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("resource-blob"))
+	})
+
+	It("should reject invalid specs", func() {
+		for _, spec := range []string{`{}`, `{"removePatterns": ["["]}`, `{"removePatterns": ["etc"], "compressionLevel": 10}`} {
+			rawSpec := json.RawMessage(spec)
+			_, err := processors.NewProcessorFactory(nil, cache.NewInMemoryCache()).Create(processors.OCIArtifactFilterProcessorType, &rawSpec)
+			Expect(err).To(HaveOccurred(), spec)
+		}
+	})
+
+})
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type processingStamper struct {
+	digest string
+}
+
+// NewProcessingStamper returns a processor that stamps a resource with the digest of the processing config.
+// Uploaders that support stamping (e.g. the oci artifact uploader) additionally attach the stamp as referrer to the uploaded artifact.
+func NewProcessingStamper(digest string) process.ResourceStreamProcessor {
+	obj := processingStamper{
+		digest: digest,
+	}
+	return &obj
+}
+
+func (p *processingStamper) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	if err := utils.SetProcessingStamp(&res, p.digest); err != nil {
+		return err
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
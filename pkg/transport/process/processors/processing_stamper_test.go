@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("processingStamper", func() {
+
+	Context("Process", func() {
+
+		It("should stamp the resource and detect already processed resources", func() {
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+			Expect(utils.SetProcessingStamp(&res, "sha256:old")).To(Succeed())
+
+			inBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, nil, inBuf)).To(Succeed())
+
+			outbuf := bytes.NewBuffer([]byte{})
+			p := processors.NewProcessingStamper("sha256:new")
+			Expect(p.Process(context.TODO(), inBuf, outbuf)).To(Succeed())
+
+			_, actualRes, _, err := utils.ReadProcessorMessage(outbuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualRes.Labels).To(ConsistOf(cdv2.Label{
+				Name:  utils.ProcessingStampName,
+				Value: json.RawMessage(`"sha256:new"`),
+			}))
+
+			target := cd.DeepCopy()
+			target.Resources[0] = actualRes
+			Expect(utils.IsAlreadyProcessed(target, res, "sha256:new")).To(BeTrue())
+			Expect(utils.IsAlreadyProcessed(target, res, "sha256:old")).To(BeFalse())
+			Expect(utils.IsAlreadyProcessed(&cd, res, "sha256:new")).To(BeFalse())
+		})
+
+		It("should be created by the processor factory", func() {
+			spec := json.RawMessage(`{"digest": "sha256:new"}`)
+			p, err := processors.NewProcessorFactory(nil, nil).Create(processors.ProcessingStamperProcessorType, &spec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p).To(Equal(processors.NewProcessingStamper("sha256:new")))
+
+			_, err = processors.NewProcessorFactory(nil, nil).Create(processors.ProcessingStamperProcessorType, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(processors.Register(processors.ProcessingStamperProcessorType, nil)).ToNot(Succeed())
+		})
+
+	})
+})
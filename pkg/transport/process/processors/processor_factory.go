@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/opencontainers/go-digest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/extensions"
+)
+
+const (
+	// ResourceLabelerProcessorType defines the type of a resource labeler
+	ResourceLabelerProcessorType = "ResourceLabeler"
+
+	// LabelModifierProcessorType defines the type of a label modifier
+	LabelModifierProcessorType = "LabelModifier"
+
+	// ImageRefRewriterProcessorType defines the type of an image reference rewriter
+	ImageRefRewriterProcessorType = "ImageRefRewriter"
+
+	// VulnerabilityScannerProcessorType defines the type of a vulnerability scanner
+	VulnerabilityScannerProcessorType = "VulnerabilityScanner"
+
+	// CosignVerifierProcessorType defines the type of a cosign signature verifier
+	CosignVerifierProcessorType = "CosignVerifier"
+
+	// ProcessingStamperProcessorType defines the type of a processing stamper
+	ProcessingStamperProcessorType = "ProcessingStamper"
+
+	// BlobIntegrityAnnotatorProcessorType defines the type of a blob integrity annotator
+	BlobIntegrityAnnotatorProcessorType = "BlobIntegrityAnnotator"
+
+	// OCIArtifactFilterProcessorType defines the type of an oci artifact filter
+	OCIArtifactFilterProcessorType = "OCIArtifactFilter"
+)
+
+// registry contains the processors that are registered by external Go code.
+var registry = process.NewProcessorRegistry()
+
+// Register registers a processor type that is not built into the component-cli.
+// Registered processors can be used like built-in processors in the transport config.
+// Register is meant to be called during initialization before any processor factory is used.
+func Register(processorType string, factory process.ProcessorFactoryFunc) error {
+	switch processorType {
+	case ResourceLabelerProcessorType, LabelModifierProcessorType, ImageRefRewriterProcessorType, VulnerabilityScannerProcessorType, CosignVerifierProcessorType, ProcessingStamperProcessorType, BlobIntegrityAnnotatorProcessorType, OCIArtifactFilterProcessorType, extensions.ExecutableType, extensions.ContainerType:
+		return fmt.Errorf("processor type %s is a built-in type", processorType)
+	}
+	return registry.Register(processorType, factory)
+}
+
+// NewProcessorFactory creates a new processor factory
+// How to add a new processor (without using extension mechanism):
+// - Add Go file to processors package which contains the source code of the new processor
+// - Add string constant for new processor type -> will be used in ProcessorFactory.Create()
+// - Add source code for creating new processor to ProcessorFactory.Create() method
+// Alternatively, external Go code can add a new processor with Register().
+func NewProcessorFactory(client ociclient.Client, ociCache cache.Cache) *ProcessorFactory {
+	return &ProcessorFactory{
+		client: client,
+		cache:  ociCache,
+	}
+}
+
+// ProcessorFactory defines a helper struct for creating processors
+type ProcessorFactory struct {
+	// client is only required for processors that access the oci registry (e.g. the cosign verifier)
+	client ociclient.Client
+	// cache is only required for processors that modify oci artifacts (e.g. the oci artifact filter)
+	cache cache.Cache
+}
+
+// Create creates a new processor defined by a type and a spec
+func (f *ProcessorFactory) Create(processorType string, spec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	switch processorType {
+	case ResourceLabelerProcessorType:
+		return f.createResourceLabeler(spec)
+	case LabelModifierProcessorType:
+		return f.createLabelModifier(spec)
+	case ImageRefRewriterProcessorType:
+		return f.createImageRefRewriter(spec)
+	case VulnerabilityScannerProcessorType:
+		return f.createVulnerabilityScanner(spec)
+	case CosignVerifierProcessorType:
+		return f.createCosignVerifier(spec)
+	case ProcessingStamperProcessorType:
+		return f.createProcessingStamper(spec)
+	case BlobIntegrityAnnotatorProcessorType:
+		return f.createBlobIntegrityAnnotator(spec)
+	case OCIArtifactFilterProcessorType:
+		return f.createOCIArtifactFilter(spec)
+	case extensions.ExecutableType:
+		return extensions.CreateExecutable(spec)
+	case extensions.ContainerType:
+		return extensions.CreateContainer(spec)
+	default:
+		if factory, ok := registry.Get(processorType); ok {
+			return factory(spec)
+		}
+		return nil, fmt.Errorf("unknown processor type %s", processorType)
+	}
+}
+
+func (f *ProcessorFactory) createResourceLabeler(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type processorSpec struct {
+		// Labels are the labels that are appended to the resource.
+		Labels cdv2.Labels `json:"labels"`
+	}
+
+	var spec processorSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	return NewResourceLabeler(spec.Labels...), nil
+}
+
+func (f *ProcessorFactory) createLabelModifier(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	var spec LabelModifierSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	return NewLabelModifier(spec)
+}
+
+func (f *ProcessorFactory) createImageRefRewriter(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	var spec ImageRefRewriterSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	return NewImageRefRewriter(spec)
+}
+
+func (f *ProcessorFactory) createVulnerabilityScanner(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	var spec VulnerabilityScannerSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	return NewVulnerabilityScanner(spec)
+}
+
+func (f *ProcessorFactory) createCosignVerifier(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type processorSpec struct {
+		// PublicKeys are the PEM encoded public keys that are accepted for signatures.
+		PublicKeys []string `json:"publicKeys,omitempty"`
+		// RootCertificates are the PEM encoded root certificates of keyless signatures.
+		RootCertificates string `json:"rootCertificates,omitempty"`
+		// Identity is the email or uri the signing certificates of keyless signatures have to be issued for.
+		Identity string `json:"identity,omitempty"`
+		// Issuer is the oidc issuer the signing certificates of keyless signatures have to be issued by.
+		Issuer string `json:"issuer,omitempty"`
+		// RekorPublicKey is the PEM encoded public key of the transparency log.
+		RekorPublicKey string `json:"rekorPublicKey,omitempty"`
+		// TagUnverified defines whether resources without a valid signature are labeled instead of rejected.
+		TagUnverified bool `json:"tagUnverified,omitempty"`
+	}
+
+	var spec processorSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	opts := CosignVerifierOptions{
+		RootCertificates: []byte(spec.RootCertificates),
+		Identity:         spec.Identity,
+		Issuer:           spec.Issuer,
+		RekorPublicKey:   []byte(spec.RekorPublicKey),
+		TagUnverified:    spec.TagUnverified,
+	}
+	for _, key := range spec.PublicKeys {
+		opts.PublicKeys = append(opts.PublicKeys, []byte(key))
+	}
+	return NewCosignVerifier(f.client, opts)
+}
+
+func (f *ProcessorFactory) createProcessingStamper(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type processorSpec struct {
+		// Digest is the digest of the processing config the resources are stamped with.
+		Digest string `json:"digest"`
+	}
+
+	var spec processorSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+	if len(spec.Digest) == 0 {
+		return nil, errors.New("a digest must be provided")
+	}
+
+	return NewProcessingStamper(spec.Digest), nil
+}
+
+func (f *ProcessorFactory) createBlobIntegrityAnnotator(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type processorSpec struct {
+		// Algorithms are the hash algorithms the digests of the blob are computed with (sha256, sha384 or sha512).
+		Algorithms []digest.Algorithm `json:"algorithms,omitempty"`
+	}
+
+	var spec processorSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	return NewBlobIntegrityAnnotator(spec.Algorithms...)
+}
+
+func (f *ProcessorFactory) createOCIArtifactFilter(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	var spec OCIArtifactFilterSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	return NewOCIArtifactFilter(f.cache, spec)
+}
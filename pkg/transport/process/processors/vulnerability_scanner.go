@@ -0,0 +1,277 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/apis/v2/cdutils"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+const (
+	// VulnerabilityScanLabelName is the name of the label that contains the summary of the vulnerability scan of a resource.
+	VulnerabilityScanLabelName = "cli.gardener.cloud/vulnerability-scan"
+
+	// DefaultTrivyPath is the default path of the trivy binary.
+	DefaultTrivyPath = "trivy"
+)
+
+// severities are the severities of trivy findings in ascending order.
+var severities = []string{"UNKNOWN", "LOW", "MEDIUM", "HIGH", "CRITICAL"}
+
+// VulnerabilityScannerSpec configures the vulnerability scan of oci image resources.
+type VulnerabilityScannerSpec struct {
+	// TrivyPath is the path to the trivy binary. The trivy binary is looked up in the PATH if not set.
+	TrivyPath string `json:"trivyPath,omitempty"`
+	// Args are additional arguments that are passed to "trivy image" (e.g. "--ignore-unfixed").
+	Args []string `json:"args,omitempty"`
+	// SeverityThreshold is the minimal severity of findings that fail the processing (UNKNOWN, LOW, MEDIUM, HIGH or CRITICAL).
+	// The processing does not fail because of findings if not set.
+	SeverityThreshold string `json:"severityThreshold,omitempty"`
+}
+
+// VulnerabilityScanSummary is the summary of a vulnerability scan that is attached as label to the resource.
+type VulnerabilityScanSummary struct {
+	// Scanner is the name of the scanner.
+	Scanner string `json:"scanner"`
+	// Findings contains the number of findings by severity.
+	Findings map[string]int `json:"findings"`
+}
+
+// trivyReport is the subset of the json report of trivy that is evaluated.
+type trivyReport struct {
+	Results []struct {
+		Target          string `json:"Target"`
+		Vulnerabilities []struct {
+			VulnerabilityID string `json:"VulnerabilityID"`
+			Severity        string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+type vulnerabilityScanner struct {
+	trivyPath string
+	args      []string
+	threshold int
+}
+
+// NewVulnerabilityScanner returns a processor that scans the layers of oci image resources with trivy.
+// The processing fails if findings with at least the severity threshold are found.
+// Otherwise, the summary of the scan is attached as label to the resource.
+// Resources that are not of type ociImage are passed through unchanged.
+func NewVulnerabilityScanner(spec VulnerabilityScannerSpec) (process.ResourceStreamProcessor, error) {
+	obj := vulnerabilityScanner{
+		trivyPath: spec.TrivyPath,
+		args:      spec.Args,
+		threshold: -1,
+	}
+	if len(obj.trivyPath) == 0 {
+		obj.trivyPath = DefaultTrivyPath
+	}
+	if len(spec.SeverityThreshold) != 0 {
+		obj.threshold = severityIndex(spec.SeverityThreshold)
+		if obj.threshold < 0 {
+			return nil, fmt.Errorf("invalid severity threshold %q: must be one of %s", spec.SeverityThreshold, strings.Join(severities, ", "))
+		}
+	}
+	return &obj, nil
+}
+
+func (p *vulnerabilityScanner) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	if res.Type == cdv2.OCIImageType {
+		if resBlobReader == nil {
+			return errors.New("resource blob must not be nil")
+		}
+		summary, err := p.scan(ctx, resBlobReader)
+		if err != nil {
+			return fmt.Errorf("unable to scan resource %s: %w", res.Name, err)
+		}
+		if err := p.checkThreshold(summary); err != nil {
+			return fmt.Errorf("vulnerability scan of resource %s failed: %w", res.Name, err)
+		}
+		res.Labels, err = cdutils.SetLabel(res.Labels, VulnerabilityScanLabelName, summary)
+		if err != nil {
+			return fmt.Errorf("unable to set label: %w", err)
+		}
+		if _, err := resBlobReader.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("unable to seek to beginning of resource blob: %w", err)
+		}
+	}
+
+	if err := utils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+// scan converts the serialized oci artifact into an oci image layout and scans it with trivy.
+func (p *vulnerabilityScanner) scan(ctx context.Context, r io.Reader) (*VulnerabilityScanSummary, error) {
+	layoutDir, err := ioutil.TempDir("", "vulnerability-scan-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(layoutDir)
+
+	if err := writeOCILayoutDir(r, layoutDir); err != nil {
+		return nil, fmt.Errorf("unable to convert oci artifact to oci image layout: %w", err)
+	}
+
+	args := append([]string{"image", "--quiet", "--format", "json", "--input", layoutDir}, p.args...)
+	cmd := exec.CommandContext(ctx, p.trivyPath, args...)
+	stdout := bytes.NewBuffer([]byte{})
+	stderr := bytes.NewBuffer([]byte{})
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("unable to run trivy: %w: %s", err, stderr.String())
+	}
+
+	report := trivyReport{}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		return nil, fmt.Errorf("unable to decode trivy report: %w", err)
+	}
+
+	summary := VulnerabilityScanSummary{
+		Scanner:  "trivy",
+		Findings: map[string]int{},
+	}
+	for _, result := range report.Results {
+		for _, vuln := range result.Vulnerabilities {
+			severity := strings.ToUpper(vuln.Severity)
+			if severityIndex(severity) < 0 {
+				severity = "UNKNOWN"
+			}
+			summary.Findings[severity]++
+		}
+	}
+	return &summary, nil
+}
+
+// checkThreshold returns an error if the summary contains findings with at least the severity threshold.
+func (p *vulnerabilityScanner) checkThreshold(summary *VulnerabilityScanSummary) error {
+	if p.threshold < 0 {
+		return nil
+	}
+	var exceeded []string
+	for i := p.threshold; i < len(severities); i++ {
+		if count := summary.Findings[severities[i]]; count > 0 {
+			exceeded = append(exceeded, fmt.Sprintf("%d %s", count, severities[i]))
+		}
+	}
+	if len(exceeded) != 0 {
+		return fmt.Errorf("found vulnerabilities above the severity threshold %s: %s", severities[p.threshold], strings.Join(exceeded, ", "))
+	}
+	return nil
+}
+
+func severityIndex(severity string) int {
+	for i, s := range severities {
+		if strings.EqualFold(s, severity) {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeOCILayoutDir writes a serialized oci artifact (see utils.SerializeOCIArtifact) as oci image layout into the given directory.
+func writeOCILayoutDir(r io.Reader, dir string) error {
+	blobsDir := filepath.Join(dir, utils.BlobsDir, digest.SHA256.String())
+	if err := os.MkdirAll(blobsDir, os.ModePerm); err != nil {
+		return fmt.Errorf("unable to create blobs directory: %w", err)
+	}
+
+	var indexData []byte
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("unable to read tar header: %w", err)
+		}
+
+		switch {
+		case header.Name == utils.ManifestFile:
+			manifestData, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return fmt.Errorf("unable to read %s: %w", utils.ManifestFile, err)
+			}
+			manifestDesc := ocispecv1.Descriptor{
+				MediaType: ocispecv1.MediaTypeImageManifest,
+				Digest:    digest.FromBytes(manifestData),
+				Size:      int64(len(manifestData)),
+			}
+			if err := ioutil.WriteFile(filepath.Join(blobsDir, manifestDesc.Digest.Encoded()), manifestData, os.ModePerm); err != nil {
+				return fmt.Errorf("unable to write manifest: %w", err)
+			}
+			indexData, err = json.Marshal(ocispecv1.Index{
+				Versioned: specs.Versioned{SchemaVersion: 2},
+				Manifests: []ocispecv1.Descriptor{manifestDesc},
+			})
+			if err != nil {
+				return fmt.Errorf("unable to marshal image index: %w", err)
+			}
+		case header.Name == utils.IndexFile:
+			indexData, err = ioutil.ReadAll(tr)
+			if err != nil {
+				return fmt.Errorf("unable to read %s: %w", utils.IndexFile, err)
+			}
+		case strings.HasPrefix(header.Name, utils.BlobsDir+"/"):
+			blobFile, err := os.Create(filepath.Join(blobsDir, filepath.Base(header.Name)))
+			if err != nil {
+				return fmt.Errorf("unable to create blob file: %w", err)
+			}
+			_, err = io.Copy(blobFile, tr)
+			if closeErr := blobFile.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return fmt.Errorf("unable to write blob %s: %w", header.Name, err)
+			}
+		}
+	}
+
+	if indexData == nil {
+		return fmt.Errorf("neither %s nor %s found", utils.ManifestFile, utils.IndexFile)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "index.json"), indexData, os.ModePerm); err != nil {
+		return fmt.Errorf("unable to write image index: %w", err)
+	}
+	layoutData, err := json.Marshal(ocispecv1.ImageLayout{Version: ocispecv1.ImageLayoutVersion})
+	if err != nil {
+		return fmt.Errorf("unable to marshal oci layout: %w", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, ocispecv1.ImageLayoutFile), layoutData, os.ModePerm); err != nil {
+		return fmt.Errorf("unable to write oci layout: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package processors_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/pkg/transport/process/processors"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+	cliutils "github.com/gardener/component-cli/pkg/utils"
+)
+
+// fakeTrivyScript is a fake trivy binary that checks that it is called with an oci image layout and prints a fixed report.
+const fakeTrivyScript = `#!/bin/sh
+test -f "$6/oci-layout" || exit 1
+test -f "$6/index.json" || exit 1
+cat <<EOT
+{"Results":[{"Target":"alpine","Vulnerabilities":[
+  {"VulnerabilityID":"CVE-0000-0001","Severity":"MEDIUM"},
+  {"VulnerabilityID":"CVE-0000-0002","Severity":"HIGH"},
+  {"VulnerabilityID":"CVE-0000-0003","Severity":"HIGH"}
+]}]}
+EOT
+`
+
+var _ = Describe("vulnerabilityScanner", func() {
+
+	Context("Process", func() {
+
+		var (
+			tmpDir    string
+			trivyPath string
+			res       cdv2.Resource
+			cd        cdv2.ComponentDescriptor
+			ociBlob   []byte
+		)
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "")
+			Expect(err).ToNot(HaveOccurred())
+			trivyPath = filepath.Join(tmpDir, "trivy")
+			Expect(ioutil.WriteFile(trivyPath, []byte(fakeTrivyScript), 0755)).To(Succeed())
+
+			layerData := []byte("layer-data")
+			configData := []byte("{}")
+			manifest := ocispecv1.Manifest{
+				Config: ocispecv1.Descriptor{
+					MediaType: ocispecv1.MediaTypeImageConfig,
+					Digest:    digest.FromBytes(configData),
+					Size:      int64(len(configData)),
+				},
+				Layers: []ocispecv1.Descriptor{
+					{
+						MediaType: ocispecv1.MediaTypeImageLayer,
+						Digest:    digest.FromBytes(layerData),
+						Size:      int64(len(layerData)),
+					},
+				},
+			}
+			manifest.SchemaVersion = 2
+			manifestData, err := json.Marshal(manifest)
+			Expect(err).ToNot(HaveOccurred())
+
+			buf := bytes.NewBuffer([]byte{})
+			tw := tar.NewWriter(buf)
+			Expect(cliutils.WriteFileToTARArchive(utils.ManifestFile, bytes.NewReader(manifestData), tw)).To(Succeed())
+			Expect(cliutils.WriteFileToTARArchive(path.Join(utils.BlobsDir, manifest.Config.Digest.Encoded()), bytes.NewReader(configData), tw)).To(Succeed())
+			Expect(cliutils.WriteFileToTARArchive(path.Join(utils.BlobsDir, manifest.Layers[0].Digest.Encoded()), bytes.NewReader(layerData), tw)).To(Succeed())
+			Expect(tw.Close()).To(Succeed())
+			ociBlob = buf.Bytes()
+
+			res = cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-image",
+					Version: "v0.1.0",
+					Type:    cdv2.OCIImageType,
+				},
+			}
+			cd = cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tmpDir)).To(Succeed())
+		})
+
+		It("should label the resource with the scan summary", func() {
+			p, err := processors.NewVulnerabilityScanner(processors.VulnerabilityScannerSpec{
+				TrivyPath:         trivyPath,
+				SeverityThreshold: "CRITICAL",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			inBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, bytes.NewReader(ociBlob), inBuf)).To(Succeed())
+			outBuf := bytes.NewBuffer([]byte{})
+			Expect(p.Process(context.TODO(), inBuf, outBuf)).To(Succeed())
+
+			_, actualRes, actualResBlobReader, err := utils.ReadProcessorMessage(outBuf)
+			Expect(err).ToNot(HaveOccurred())
+			defer actualResBlobReader.Close()
+			actualBlob, err := ioutil.ReadAll(actualResBlobReader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualBlob).To(Equal(ociBlob))
+
+			Expect(actualRes.Labels).To(HaveLen(1))
+			Expect(actualRes.Labels[0].Name).To(Equal(processors.VulnerabilityScanLabelName))
+			summary := processors.VulnerabilityScanSummary{}
+			Expect(json.Unmarshal(actualRes.Labels[0].Value, &summary)).To(Succeed())
+			Expect(summary).To(Equal(processors.VulnerabilityScanSummary{
+				Scanner: "trivy",
+				Findings: map[string]int{
+					"MEDIUM": 1,
+					"HIGH":   2,
+				},
+			}))
+		})
+
+		It("should fail if findings exceed the severity threshold", func() {
+			p, err := processors.NewVulnerabilityScanner(processors.VulnerabilityScannerSpec{
+				TrivyPath:         trivyPath,
+				SeverityThreshold: "high",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			inBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, bytes.NewReader(ociBlob), inBuf)).To(Succeed())
+			err = p.Process(context.TODO(), inBuf, bytes.NewBuffer([]byte{}))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("2 HIGH"))
+		})
+
+	})
+})
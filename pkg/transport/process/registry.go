@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ProcessorFactoryFunc creates a resource stream processor from the spec of its definition in the transport config.
+type ProcessorFactoryFunc func(spec *json.RawMessage) (ResourceStreamProcessor, error)
+
+// ProcessorRegistry contains factory functions for processor types that are not built into the component-cli.
+// It is used to register downloaders and uploaders for custom access types (e.g. mavenArtifact or npmPackage).
+type ProcessorRegistry struct {
+	mux       sync.RWMutex
+	factories map[string]ProcessorFactoryFunc
+}
+
+// NewProcessorRegistry creates a new empty processor registry.
+func NewProcessorRegistry() *ProcessorRegistry {
+	return &ProcessorRegistry{
+		factories: map[string]ProcessorFactoryFunc{},
+	}
+}
+
+// Register adds a factory function for the given processor type.
+// A processor type can only be registered once.
+func (r *ProcessorRegistry) Register(processorType string, factory ProcessorFactoryFunc) error {
+	if len(processorType) == 0 {
+		return errors.New("processor type must not be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("factory for processor type %s must not be nil", processorType)
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if _, ok := r.factories[processorType]; ok {
+		return fmt.Errorf("processor type %s is already registered", processorType)
+	}
+	r.factories[processorType] = factory
+	return nil
+}
+
+// Get returns the factory function for the given processor type.
+func (r *ProcessorRegistry) Get(processorType string) (ProcessorFactoryFunc, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	factory, ok := r.factories[processorType]
+	return factory, ok
+}
+
+// Types returns the sorted list of all registered processor types.
+func (r *ProcessorRegistry) Types() []string {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	types := make([]string, 0, len(r.factories))
+	for processorType := range r.factories {
+		types = append(types, processorType)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package process_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+)
+
+var _ = Describe("registry", func() {
+
+	It("should create processors of registered types", func() {
+		registry := process.NewProcessorRegistry()
+		var receivedSpec *json.RawMessage
+		Expect(registry.Register("MavenDownloader", func(spec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+			receivedSpec = spec
+			return nil, nil
+		})).To(Succeed())
+
+		factory, ok := registry.Get("MavenDownloader")
+		Expect(ok).To(BeTrue())
+		spec := json.RawMessage(`{"repository":"central"}`)
+		_, err := factory(&spec)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(receivedSpec).To(Equal(&spec))
+
+		_, ok = registry.Get("NpmDownloader")
+		Expect(ok).To(BeFalse())
+		Expect(registry.Types()).To(ConsistOf("MavenDownloader"))
+	})
+
+	It("should fail to register a type twice", func() {
+		registry := process.NewProcessorRegistry()
+		factory := func(spec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+			return nil, nil
+		}
+		Expect(registry.Register("MavenDownloader", factory)).To(Succeed())
+		Expect(registry.Register("MavenDownloader", factory)).ToNot(Succeed())
+	})
+
+})
@@ -14,10 +14,42 @@ import (
 // Each processor receives its input from the preceding processor and writes the output for the
 // subsequent processor. To work correctly, a pipeline must consist of 1 downloader, 0..n processors,
 // and 1..n uploaders.
+// A processor can split a resource into multiple resources by writing a multi resource processor message.
+// All subsequent processors are then executed for each of the resulting resources.
 type ResourceProcessingPipeline interface {
 	// Process executes all processors for a resource.
-	// Returns the component descriptor and resource of the last processor.
-	Process(context.Context, cdv2.ComponentDescriptor, cdv2.Resource) (*cdv2.ComponentDescriptor, cdv2.Resource, error)
+	// Returns the component descriptor of the last processor and all resources that are produced by the last processor.
+	Process(context.Context, cdv2.ComponentDescriptor, cdv2.Resource) (*cdv2.ComponentDescriptor, []cdv2.Resource, error)
+}
+
+// MultiTargetResourceProcessingPipeline describes a pipeline that uploads a resource to multiple targets.
+// The downloader and processors are executed only once for a resource and their results are shared
+// by the uploaders of all targets.
+type MultiTargetResourceProcessingPipeline interface {
+	// Process executes all processors for a resource and returns the result for every target.
+	Process(context.Context, cdv2.ComponentDescriptor, cdv2.Resource) ([]TargetResult, error)
+}
+
+// ProcessingTarget describes a target of a MultiTargetResourceProcessingPipeline.
+type ProcessingTarget struct {
+	// Name is the name of the target.
+	Name string
+	// Uploaders are the processors that are executed for the target.
+	Uploaders []ResourceStreamProcessor
+	// PostUploaders are executed after the uploaders of the target.
+	// They receive the uploaded resources with their final target access but without blob,
+	// so that they can perform actions in the target repository like adding tags.
+	PostUploaders []ResourceStreamProcessor
+}
+
+// TargetResult is the result of processing a resource for a target.
+type TargetResult struct {
+	// Target is the name of the target.
+	Target string
+	// ComponentDescriptor is the component descriptor of the last uploader or post uploader of the target.
+	ComponentDescriptor *cdv2.ComponentDescriptor
+	// Resources are all resources that are produced by the last uploader or post uploader of the target.
+	Resources []cdv2.Resource
 }
 
 // ResourceStreamProcessor describes an individual processor for processing a resource.
@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package uploaders
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/pkg/transport/merge"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	processutils "github.com/gardener/component-cli/pkg/transport/process/utils"
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+type ctfArchiveUploader struct {
+	fs            vfs.FileSystem
+	cache         cache.Cache
+	ctfPath       string
+	format        ctf.ArchiveFormat
+	mergeStrategy merge.Strategy
+	// written contains the component versions that have already been written into a ctf archive by this transport.
+	// It is shared by all ctf archive uploaders of a uploader factory.
+	written *sync.Map
+}
+
+// NewCTFArchiveUploader returns an uploader that writes resources as local blobs into the component archives
+// of the ctf archive at the given path instead of a registry.
+// The ctf archive is created if it does not exist, a component archive is created from the component descriptor
+// of the processed resource if the ctf archive does not yet contain the component.
+// If the ctf archive already contains the component version before it is written for the first time,
+// the component descriptor is merged with the existing one according to the merge strategy.
+// Oci artifacts are stored as oci image layout tarballs.
+func NewCTFArchiveUploader(fs vfs.FileSystem, cache cache.Cache, ctfPath string, format ctf.ArchiveFormat, mergeStrategy merge.Strategy) (process.ResourceStreamProcessor, error) {
+	u, err := newCTFArchiveUploader(fs, cache, ctfPath, format, mergeStrategy, &sync.Map{})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func newCTFArchiveUploader(fs vfs.FileSystem, cache cache.Cache, ctfPath string, format ctf.ArchiveFormat, mergeStrategy merge.Strategy, written *sync.Map) (*ctfArchiveUploader, error) {
+	if fs == nil {
+		return nil, errors.New("fs must not be nil")
+	}
+	if cache == nil {
+		return nil, errors.New("cache must not be nil")
+	}
+	if len(ctfPath) == 0 {
+		return nil, errors.New("path must not be empty")
+	}
+	if len(format) == 0 {
+		format = ctf.ArchiveFormatTar
+	}
+	if len(mergeStrategy) == 0 {
+		mergeStrategy = merge.StrategyOverwrite
+	}
+
+	obj := ctfArchiveUploader{
+		fs:            fs,
+		cache:         cache,
+		ctfPath:       ctfPath,
+		format:        format,
+		mergeStrategy: mergeStrategy,
+		written:       written,
+	}
+	return &obj, nil
+}
+
+func (u *ctfArchiveUploader) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := processutils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader == nil {
+		return errors.New("resource blob must not be nil")
+	}
+	defer resBlobReader.Close()
+
+	tmpfile, err := ioutil.TempFile("", "")
+	if err != nil {
+		return fmt.Errorf("unable to create tempfile: %w", err)
+	}
+	defer func() {
+		_ = tmpfile.Close()
+		_ = os.Remove(tmpfile.Name())
+	}()
+
+	mediaType := res.Type
+	if res.Access != nil && res.Access.GetType() == cdv2.OCIRegistryType {
+		if err := u.writeOCILayoutTar(resBlobReader, res, tmpfile); err != nil {
+			return err
+		}
+		mediaType = ociclient.MediaTypeOCIImageLayoutTar
+	} else if _, err := io.Copy(tmpfile, resBlobReader); err != nil {
+		return fmt.Errorf("unable to copy resource blob to tempfile: %w", err)
+	}
+
+	if _, err := tmpfile.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+	}
+	dgst, err := digest.FromReader(tmpfile)
+	if err != nil {
+		return fmt.Errorf("unable to calculate digest: %w", err)
+	}
+	size, err := tmpfile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("unable to get size of tempfile: %w", err)
+	}
+	if _, err := tmpfile.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to beginning of tempfile: %w", err)
+	}
+
+	info := ctf.BlobInfo{
+		MediaType: mediaType,
+		Digest:    dgst.String(),
+		Size:      size,
+	}
+	if err := u.addResource(cd, &res, info, tmpfile); err != nil {
+		return err
+	}
+
+	if _, err := resBlobReader.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to beginning of resource blob: %w", err)
+	}
+	if err := processutils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+// writeOCILayoutTar converts the serialized oci artifact into an oci image layout tarball.
+func (u *ctfArchiveUploader) writeOCILayoutTar(r io.Reader, res cdv2.Resource, w io.Writer) error {
+	ociAccess := &cdv2.OCIRegistryAccess{}
+	if err := res.Access.DecodeInto(ociAccess); err != nil {
+		return fmt.Errorf("unable to decode resource access: %w", err)
+	}
+	ociArtifact, err := processutils.DeserializeOCIArtifact(r, u.cache)
+	if err != nil {
+		return fmt.Errorf("unable to deserialize oci artifact: %w", err)
+	}
+
+	tw := tar.NewWriter(w)
+	write := func(name string, r io.Reader) error {
+		return utils.WriteFileToTARArchive(name, r, tw)
+	}
+	desc, err := writeOCIArtifact(ociArtifact, u.cache, write)
+	if err != nil {
+		return fmt.Errorf("unable to write oci artifact: %w", err)
+	}
+	desc.Annotations = map[string]string{
+		ocispecv1.AnnotationRefName: ociAccess.ImageReference,
+	}
+	index, err := json.Marshal(ocispecv1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Manifests: []ocispecv1.Descriptor{desc},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to encode %s: %w", ociclient.OCILayoutIndexFile, err)
+	}
+	if err := write(ociclient.OCILayoutIndexFile, bytes.NewReader(index)); err != nil {
+		return err
+	}
+	layout, err := json.Marshal(ocispecv1.ImageLayout{Version: ocispecv1.ImageLayoutVersion})
+	if err != nil {
+		return fmt.Errorf("unable to encode oci layout: %w", err)
+	}
+	if err := write(ocispecv1.ImageLayoutFile, bytes.NewReader(layout)); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("unable to close tar writer: %w", err)
+	}
+	return nil
+}
+
+// addResource adds the resource blob to the component archive of the component in the ctf archive.
+// The access of the resource is set to the local blob.
+func (u *ctfArchiveUploader) addResource(cd *cdv2.ComponentDescriptor, res *cdv2.Resource, info ctf.BlobInfo, blob io.Reader) error {
+	// all resources of a component are written to the same component archive, so that concurrent uploads have to be serialized.
+	unlock := lockPath(u.ctfPath)
+	defer unlock()
+
+	ctfArchive, err := utils.OpenOrCreateCTF(logr.Discard(), u.fs, u.ctfPath)
+	if err != nil {
+		return err
+	}
+	defer ctfArchive.Close()
+
+	var existing *ctf.ComponentArchive
+	if err := ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+		if ca.ComponentDescriptor.Name == cd.Name && ca.ComponentDescriptor.Version == cd.Version {
+			existing = ca
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("unable to read ctf archive: %w", err)
+	}
+
+	key := path.Join(path.Clean(u.ctfPath), cd.Name+":"+cd.Version)
+	_, written := u.written.Load(key)
+	var ca *ctf.ComponentArchive
+	switch {
+	case existing == nil:
+		ca = ctf.NewComponentArchive(cd.DeepCopy(), memoryfs.New())
+	case written:
+		// the component archive has been written by a previous resource of the same component version.
+		ca = existing
+	default:
+		merged, err := merge.Merge(existing.ComponentDescriptor, cd, u.mergeStrategy)
+		if err != nil {
+			return fmt.Errorf("unable to merge component descriptor with the existing one in the ctf archive: %w", err)
+		}
+		if u.mergeStrategy == merge.StrategyOverwrite {
+			ca = ctf.NewComponentArchive(merged.DeepCopy(), memoryfs.New())
+		} else {
+			// the blobs of the existing component archive are kept as they may be referenced by the merged component descriptor.
+			existing.ComponentDescriptor = merged.DeepCopy()
+			ca = existing
+		}
+	}
+
+	if err := ca.AddResource(res, info, blob); err != nil {
+		return fmt.Errorf("unable to add resource blob to component archive: %w", err)
+	}
+	if err := ctfArchive.AddComponentArchiveWithName(utils.CTFComponentArchiveFilename(cd.Name, cd.Version), ca, u.format); err != nil {
+		return fmt.Errorf("unable to add component archive to ctf archive: %w", err)
+	}
+	if err := ctfArchive.Write(); err != nil {
+		return fmt.Errorf("unable to write ctf archive: %w", err)
+	}
+	u.written.Store(key, struct{}{})
+	return nil
+}
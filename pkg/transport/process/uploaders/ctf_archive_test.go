@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package uploaders_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/pkg/transport/merge"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/uploaders"
+	processutils "github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("ctfArchive", func() {
+
+	Context("Process", func() {
+
+		newResource := func(name string) cdv2.Resource {
+			acc, err := cdv2.NewUnstructured(cdv2.NewLocalOCIBlobAccess(digest.FromString(name).String()))
+			Expect(err).ToNot(HaveOccurred())
+			return cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    name,
+					Version: "0.1.0",
+					Type:    "plain-text",
+				},
+				Relation: cdv2.LocalRelation,
+				Access:   &acc,
+			}
+		}
+		newComponentDescriptor := func(resources ...string) cdv2.ComponentDescriptor {
+			cd := cdv2.ComponentDescriptor{
+				Metadata: cdv2.Metadata{
+					Version: cdv2.SchemaVersion,
+				},
+				ComponentSpec: cdv2.ComponentSpec{
+					ObjectMeta: cdv2.ObjectMeta{
+						Name:    "github.com/component-cli/test-component",
+						Version: "0.1.0",
+					},
+					Provider: "internal",
+				},
+			}
+			for _, name := range resources {
+				cd.Resources = append(cd.Resources, newResource(name))
+			}
+			return cd
+		}
+		upload := func(u process.ResourceStreamProcessor, cd cdv2.ComponentDescriptor, res cdv2.Resource) error {
+			inProcessorMsg := bytes.NewBuffer([]byte{})
+			Expect(processutils.WriteProcessorMessage(cd, res, bytes.NewReader([]byte(res.Name)), inProcessorMsg)).To(Succeed())
+			return u.Process(context.TODO(), inProcessorMsg, bytes.NewBuffer([]byte{}))
+		}
+		readComponentDescriptors := func(fs vfs.FileSystem) []*cdv2.ComponentDescriptor {
+			ctfArchive, err := ctf.NewCTF(fs, "/component.ctf")
+			Expect(err).ToNot(HaveOccurred())
+			defer ctfArchive.Close()
+			cds := []*cdv2.ComponentDescriptor{}
+			Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+				cds = append(cds, ca.ComponentDescriptor)
+				return nil
+			})).To(Succeed())
+			return cds
+		}
+
+		It("should add all resources of a component to the same component archive", func() {
+			cd := newComponentDescriptor("res-1", "res-2")
+
+			fs := memoryfs.New()
+			u, err := uploaders.NewCTFArchiveUploader(fs, cache.NewInMemoryCache(), "/component.ctf", ctf.ArchiveFormatTar, merge.StrategyOverwrite)
+			Expect(err).ToNot(HaveOccurred())
+			for _, res := range cd.Resources {
+				inProcessorMsg := bytes.NewBuffer([]byte{})
+				Expect(processutils.WriteProcessorMessage(cd, res, bytes.NewReader([]byte(res.Name)), inProcessorMsg)).To(Succeed())
+				outProcessorMsg := bytes.NewBuffer([]byte{})
+				Expect(u.Process(context.TODO(), inProcessorMsg, outProcessorMsg)).To(Succeed())
+
+				_, actualRes, resBlobReader, err := processutils.ReadProcessorMessage(outProcessorMsg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resBlobReader.Close()).To(Succeed())
+				acc := cdv2.LocalFilesystemBlobAccess{}
+				Expect(actualRes.Access.DecodeInto(&acc)).To(Succeed())
+				Expect(acc.Filename).To(Equal(digest.FromString(res.Name).String()))
+			}
+
+			ctfArchive, err := ctf.NewCTF(fs, "/component.ctf")
+			Expect(err).ToNot(HaveOccurred())
+			defer ctfArchive.Close()
+			count := 0
+			Expect(ctfArchive.Walk(func(ca *ctf.ComponentArchive) error {
+				count++
+				for _, res := range ca.ComponentDescriptor.Resources {
+					var buf bytes.Buffer
+					_, err := ca.Resolve(context.TODO(), res, &buf)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(buf.String()).To(Equal(res.Name))
+				}
+				return nil
+			})).To(Succeed())
+			Expect(count).To(Equal(1))
+		})
+
+		It("should merge the component descriptor with a component version that already exists in the ctf archive", func() {
+			fs := memoryfs.New()
+			u, err := uploaders.NewCTFArchiveUploader(fs, cache.NewInMemoryCache(), "/component.ctf", ctf.ArchiveFormatTar, merge.StrategyOverwrite)
+			Expect(err).ToNot(HaveOccurred())
+			existing := newComponentDescriptor("res-1")
+			Expect(upload(u, existing, existing.Resources[0])).To(Succeed())
+
+			// a new transport with a new uploader
+			u, err = uploaders.NewCTFArchiveUploader(fs, cache.NewInMemoryCache(), "/component.ctf", ctf.ArchiveFormatTar, merge.StrategyMergeResourcesByIdentity)
+			Expect(err).ToNot(HaveOccurred())
+			cd := newComponentDescriptor("res-2", "res-3")
+			for _, res := range cd.Resources {
+				Expect(upload(u, cd, res)).To(Succeed())
+			}
+
+			cds := readComponentDescriptors(fs)
+			Expect(cds).To(HaveLen(1))
+			Expect(cds[0].Resources).To(HaveLen(3))
+			Expect(cds[0].Resources[2].Name).To(Equal("res-1"))
+		})
+
+		It("should fail if a component version already exists in the ctf archive with the fail strategy", func() {
+			fs := memoryfs.New()
+			u, err := uploaders.NewCTFArchiveUploader(fs, cache.NewInMemoryCache(), "/component.ctf", ctf.ArchiveFormatTar, merge.StrategyFail)
+			Expect(err).ToNot(HaveOccurred())
+			cd := newComponentDescriptor("res-1", "res-2")
+			for _, res := range cd.Resources {
+				// resources of the same transport are added to the component version that has been written by the transport.
+				Expect(upload(u, cd, res)).To(Succeed())
+			}
+
+			u, err = uploaders.NewCTFArchiveUploader(fs, cache.NewInMemoryCache(), "/component.ctf", ctf.ArchiveFormatTar, merge.StrategyFail)
+			Expect(err).ToNot(HaveOccurred())
+			err = upload(u, cd, cd.Resources[0])
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, merge.ErrAlreadyExists)).To(BeTrue())
+		})
+
+	})
+})
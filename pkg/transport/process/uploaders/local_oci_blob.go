@@ -101,12 +101,7 @@ func (d *localOCIBlobUploader) Process(ctx context.Context, r io.Reader, w io.Wr
 func (d *localOCIBlobUploader) uploadLocalOCIBlob(ctx context.Context, cd *cdv2.ComponentDescriptor, res cdv2.Resource, r io.Reader, desc ocispecv1.Descriptor) error {
 	targetRef := utils.CalculateBlobUploadRef(d.targetCtx, cd.Name, cd.Version)
 
-	store := ociclient.GenericStore(func(ctx context.Context, desc ocispecv1.Descriptor, writer io.Writer) error {
-		_, err := io.Copy(writer, r)
-		return err
-	})
-
-	if err := d.client.PushBlob(ctx, targetRef, desc, ociclient.WithStore(store)); err != nil {
+	if err := d.client.PushBlobFromReader(ctx, targetRef, desc, r); err != nil {
 		return fmt.Errorf("unable to push blob: %w", err)
 	}
 
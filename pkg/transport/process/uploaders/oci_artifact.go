@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/containerd/containerd/errdefs"
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 
 	"github.com/gardener/component-cli/ociclient"
@@ -18,6 +19,10 @@ import (
 	"github.com/gardener/component-cli/pkg/utils"
 )
 
+// ProcessingStampArtifactType is the artifact type of the referrer that stamps an uploaded oci artifact
+// with the digest of the processing config (see processutils.ProcessingStampName).
+const ProcessingStampArtifactType = "application/vnd.gardener.transport.processing-stamp"
+
 type ociArtifactUploader struct {
 	client         ociclient.Client
 	cache          cache.Cache
@@ -86,6 +91,12 @@ func (u *ociArtifactUploader) Process(ctx context.Context, r io.Reader, w io.Wri
 		return fmt.Errorf("unable to push oci artifact: %w", err)
 	}
 
+	if stamp, ok := processutils.GetProcessingStamp(res); ok {
+		if err := stampOCIArtifact(ctx, u.client, target, stamp); err != nil {
+			return fmt.Errorf("unable to stamp oci artifact: %w", err)
+		}
+	}
+
 	blobReader, err := processutils.SerializeOCIArtifact(*ociArtifact, u.cache)
 	if err != nil {
 		return fmt.Errorf("unable to serialize oci artifact: %w", err)
@@ -98,3 +109,47 @@ func (u *ociArtifactUploader) Process(ctx context.Context, r io.Reader, w io.Wri
 
 	return nil
 }
+
+// stampOCIArtifact attaches the processing stamp as referrer to the uploaded oci artifact.
+// The artifact itself is not modified so that its digest stays the same as in the source.
+// Artifacts are not stamped if the client does not support referrers, the stamp is still kept
+// as label of the resource in the component descriptor.
+func stampOCIArtifact(ctx context.Context, client ociclient.Client, ref, stamp string) error {
+	referrers, ok := client.(ociclient.ReferrersInterface)
+	if !ok {
+		return nil
+	}
+	manifest := &ociclient.ReferrerManifest{
+		ArtifactType: ProcessingStampArtifactType,
+	}
+	manifest.Annotations = map[string]string{
+		processutils.ProcessingStampName: stamp,
+	}
+	if _, err := referrers.PushReferrer(ctx, ref, manifest); err != nil {
+		return fmt.Errorf("unable to push processing stamp referrer: %w", err)
+	}
+	return nil
+}
+
+// IsOCIArtifactAlreadyProcessed returns whether the oci artifact of the target reference
+// is stamped with the given processing stamp.
+// A non existing target artifact is not considered as processed.
+func IsOCIArtifactAlreadyProcessed(ctx context.Context, client ociclient.Client, ref, stamp string) (bool, error) {
+	referrers, ok := client.(ociclient.ReferrersInterface)
+	if !ok {
+		return false, nil
+	}
+	stamps, err := referrers.ListReferrers(ctx, ref, ProcessingStampArtifactType)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("unable to list processing stamps of oci artifact %s: %w", ref, err)
+	}
+	for _, referrer := range stamps {
+		if referrer.Annotations[processutils.ProcessingStampName] == stamp {
+			return true, nil
+		}
+	}
+	return false, nil
+}
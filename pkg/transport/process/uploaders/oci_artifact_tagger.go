@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package uploaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/oci"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	processutils "github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type ociArtifactTagger struct {
+	client ociclient.Client
+	tags   []string
+}
+
+// NewOCIArtifactTagger returns a post uploader that adds the given tags to the uploaded oci artifact of a resource.
+// The artifact is referenced by the ociRegistry access of the resource in the target repository.
+func NewOCIArtifactTagger(client ociclient.Client, tags ...string) (process.ResourceStreamProcessor, error) {
+	if client == nil {
+		return nil, errors.New("client must not be nil")
+	}
+	if len(tags) == 0 {
+		return nil, errors.New("at least one tag must be defined")
+	}
+
+	obj := ociArtifactTagger{
+		client: client,
+		tags:   tags,
+	}
+	return &obj, nil
+}
+
+func (t *ociArtifactTagger) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := processutils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+
+	if res.Access.GetType() != cdv2.OCIRegistryType {
+		return fmt.Errorf("unsupported access type: %s", res.Access.Type)
+	}
+	ociAccess := &cdv2.OCIRegistryAccess{}
+	if err := res.Access.DecodeInto(ociAccess); err != nil {
+		return fmt.Errorf("unable to decode resource access: %w", err)
+	}
+
+	desc, rawManifest, err := t.client.GetRawManifest(ctx, ociAccess.ImageReference)
+	if err != nil {
+		return fmt.Errorf("unable to get manifest of %s: %w", ociAccess.ImageReference, err)
+	}
+	refspec, err := oci.ParseRef(ociAccess.ImageReference)
+	if err != nil {
+		return fmt.Errorf("unable to parse image reference %s: %w", ociAccess.ImageReference, err)
+	}
+	for _, tag := range t.tags {
+		tagRef := refspec.TagRef(tag)
+		if err := t.client.PushRawManifest(ctx, tagRef, desc, rawManifest); err != nil {
+			return fmt.Errorf("unable to tag %s with %s: %w", ociAccess.ImageReference, tag, err)
+		}
+	}
+
+	if err := processutils.WriteProcessorMessage(*cd, res, nil, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package uploaders_test
+
+import (
+	"bytes"
+	"context"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	mock_ociclient "github.com/gardener/component-cli/ociclient/mock"
+	"github.com/gardener/component-cli/pkg/transport/process/uploaders"
+	processutils "github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("ociArtifactTagger", func() {
+
+	Context("Process", func() {
+
+		It("should add the tags to the oci artifact of the resource", func() {
+			mockCtrl := gomock.NewController(GinkgoT())
+			defer mockCtrl.Finish()
+			mockOCIClient := mock_ociclient.NewMockClient(mockCtrl)
+
+			rawManifest := []byte(`{"schemaVersion":2}`)
+			desc := ocispecv1.Descriptor{
+				MediaType: ocispecv1.MediaTypeImageManifest,
+				Digest:    digest.FromBytes(rawManifest),
+				Size:      int64(len(rawManifest)),
+			}
+			mockOCIClient.EXPECT().GetRawManifest(gomock.Any(), "example.com/my-image:1.0.0").Return(desc, rawManifest, nil)
+			mockOCIClient.EXPECT().PushRawManifest(gomock.Any(), "example.com/my-image:latest", desc, rawManifest).Return(nil)
+			mockOCIClient.EXPECT().PushRawManifest(gomock.Any(), "example.com/my-image:stable", desc, rawManifest).Return(nil)
+
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess("example.com/my-image:1.0.0"))
+			Expect(err).ToNot(HaveOccurred())
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "1.0.0",
+					Type:    "ociImage",
+				},
+				Access: &acc,
+			}
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+
+			t, err := uploaders.NewOCIArtifactTagger(mockOCIClient, "latest", "stable")
+			Expect(err).ToNot(HaveOccurred())
+
+			inProcessorMsg := bytes.NewBuffer([]byte{})
+			Expect(processutils.WriteProcessorMessage(cd, res, nil, inProcessorMsg)).To(Succeed())
+			outProcessorMsg := bytes.NewBuffer([]byte{})
+			Expect(t.Process(context.TODO(), inProcessorMsg, outProcessorMsg)).To(Succeed())
+
+			_, actualRes, resBlobReader, err := processutils.ReadProcessorMessage(outProcessorMsg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resBlobReader).To(BeNil())
+			Expect(actualRes.Name).To(Equal(res.Name))
+		})
+
+	})
+})
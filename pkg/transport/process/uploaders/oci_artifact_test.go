@@ -209,6 +209,71 @@ var _ = Describe("ociArtifact", func() {
 			Expect(actualOciArtifact).To(Equal(expectedOciArtifact))
 		})
 
+		It("should stamp the uploaded oci image with the processing stamp of the resource", func() {
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess("my-registry.com/stamped-image:0.1.0"))
+			Expect(err).ToNot(HaveOccurred())
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "0.1.0",
+					Type:    "plain-text",
+				},
+				Access: &acc,
+			}
+			Expect(utils.SetProcessingStamp(&res, "sha256:processing-config")).To(Succeed())
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					ObjectMeta: cdv2.ObjectMeta{
+						Name:    "github.com/component-cli/test-component",
+						Version: "0.1.0",
+					},
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+			expectedImageRef := targetCtx.BaseURL + "/stamped-image:0.1.0"
+			configData := []byte("config-data")
+			layers := [][]byte{
+				[]byte("layer-data"),
+			}
+			m, mdesc, _ := testutils.CreateImage(ocispecv1.MediaTypeImageManifest, configData, layers)
+			ociArtifact, err := oci.NewManifestArtifact(&oci.Manifest{
+				Descriptor: mdesc,
+				Data:       m,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			serializeCache := cache.NewInMemoryCache()
+			Expect(serializeCache.Add(m.Config, io.NopCloser(bytes.NewReader(configData)))).To(Succeed())
+			Expect(serializeCache.Add(m.Layers[0], io.NopCloser(bytes.NewReader(layers[0])))).To(Succeed())
+			serializedReader, err := utils.SerializeOCIArtifact(*ociArtifact, serializeCache)
+			Expect(err).ToNot(HaveOccurred())
+
+			inProcessorMsg := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, serializedReader, inProcessorMsg)).To(Succeed())
+
+			processed, err := uploaders.IsOCIArtifactAlreadyProcessed(context.TODO(), ociClient, expectedImageRef, "sha256:processing-config")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processed).To(BeFalse())
+
+			u, err := uploaders.NewOCIArtifactUploader(ociClient, serializeCache, targetCtx.BaseURL, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(u.Process(context.TODO(), inProcessorMsg, bytes.NewBuffer([]byte{}))).To(Succeed())
+
+			// the stamp must not modify the uploaded artifact
+			_, actualDesc, err := ociClient.Resolve(context.TODO(), expectedImageRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualDesc.Digest).To(Equal(mdesc.Digest))
+
+			processed, err = uploaders.IsOCIArtifactAlreadyProcessed(context.TODO(), ociClient, expectedImageRef, "sha256:processing-config")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processed).To(BeTrue())
+			processed, err = uploaders.IsOCIArtifactAlreadyProcessed(context.TODO(), ociClient, expectedImageRef, "sha256:other-config")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processed).To(BeFalse())
+		})
+
 		It("should return error for invalid access type", func() {
 			acc, err := cdv2.NewUnstructured(cdv2.NewLocalOCIBlobAccess("sha256:123"))
 			Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,251 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package uploaders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/ociclient/oci"
+	"github.com/gardener/component-cli/pkg/transport/process"
+	processutils "github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+type localOCILayoutUploader struct {
+	fs         vfs.FileSystem
+	cache      cache.Cache
+	layoutPath string
+}
+
+// NewLocalOCILayoutUploader returns an uploader that writes oci artifacts into the oci image layout directory
+// at the given path instead of a registry.
+// The artifacts are annotated with their image reference, so that the access of the resource is not changed
+// and the layout can be used to resolve the resources in offline mode (see ociclient.WithOfflineMode).
+func NewLocalOCILayoutUploader(fs vfs.FileSystem, cache cache.Cache, layoutPath string) (process.ResourceStreamProcessor, error) {
+	if fs == nil {
+		return nil, errors.New("fs must not be nil")
+	}
+	if cache == nil {
+		return nil, errors.New("cache must not be nil")
+	}
+	if len(layoutPath) == 0 {
+		return nil, errors.New("path must not be empty")
+	}
+
+	obj := localOCILayoutUploader{
+		fs:         fs,
+		cache:      cache,
+		layoutPath: layoutPath,
+	}
+	return &obj, nil
+}
+
+func (u *localOCILayoutUploader) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := processutils.ReadProcessorMessage(r)
+	if err != nil {
+		return fmt.Errorf("unable to read processor message: %w", err)
+	}
+	if resBlobReader == nil {
+		return errors.New("resource blob must not be nil")
+	}
+	defer resBlobReader.Close()
+
+	if res.Access.GetType() != cdv2.OCIRegistryType {
+		return fmt.Errorf("unsupported access type: %s", res.Access.Type)
+	}
+	ociAccess := &cdv2.OCIRegistryAccess{}
+	if err := res.Access.DecodeInto(ociAccess); err != nil {
+		return fmt.Errorf("unable to decode resource access: %w", err)
+	}
+
+	ociArtifact, err := processutils.DeserializeOCIArtifact(resBlobReader, u.cache)
+	if err != nil {
+		return fmt.Errorf("unable to deserialize oci artifact: %w", err)
+	}
+
+	// the index of the layout is shared by all resources, so that concurrent uploads have to be serialized.
+	unlock := lockPath(u.layoutPath)
+	defer unlock()
+	if err := u.fs.MkdirAll(u.layoutPath, os.ModePerm); err != nil {
+		return fmt.Errorf("unable to create oci layout directory: %w", err)
+	}
+	desc, err := writeOCIArtifact(ociArtifact, u.cache, u.writeBlob)
+	if err != nil {
+		return fmt.Errorf("unable to write oci artifact: %w", err)
+	}
+	if err := u.addToIndex(desc, ociAccess.ImageReference); err != nil {
+		return err
+	}
+
+	if _, err := resBlobReader.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to beginning of resource blob: %w", err)
+	}
+	if err := processutils.WriteProcessorMessage(*cd, res, resBlobReader, w); err != nil {
+		return fmt.Errorf("unable to write processor message: %w", err)
+	}
+
+	return nil
+}
+
+// writeBlob writes a blob into the layout directory.
+// Existing blobs are not written again as they are content addressed.
+func (u *localOCILayoutUploader) writeBlob(name string, r io.Reader) error {
+	blobPath := path.Join(u.layoutPath, name)
+	if _, err := u.fs.Stat(blobPath); err == nil {
+		return nil
+	}
+	if err := u.fs.MkdirAll(path.Dir(blobPath), os.ModePerm); err != nil {
+		return fmt.Errorf("unable to create directory for %s: %w", name, err)
+	}
+	file, err := u.fs.OpenFile(blobPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("unable to open %s: %w", name, err)
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("unable to write %s: %w", name, err)
+	}
+	return file.Close()
+}
+
+// addToIndex adds the descriptor with the ref name annotation to the index of the layout.
+// An existing descriptor with the same ref name is replaced.
+func (u *localOCILayoutUploader) addToIndex(desc ocispecv1.Descriptor, refName string) error {
+	indexPath := path.Join(u.layoutPath, ociclient.OCILayoutIndexFile)
+	index := ocispecv1.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+	}
+	data, err := vfs.ReadFile(u.fs, indexPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("unable to read %s: %w", ociclient.OCILayoutIndexFile, err)
+		}
+	} else if err := json.Unmarshal(data, &index); err != nil {
+		return fmt.Errorf("unable to decode %s: %w", ociclient.OCILayoutIndexFile, err)
+	}
+
+	desc.Annotations = map[string]string{
+		ocispecv1.AnnotationRefName: refName,
+	}
+	manifests := make([]ocispecv1.Descriptor, 0, len(index.Manifests)+1)
+	for _, m := range index.Manifests {
+		if m.Annotations[ocispecv1.AnnotationRefName] != refName {
+			manifests = append(manifests, m)
+		}
+	}
+	index.Manifests = append(manifests, desc)
+
+	data, err = json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("unable to encode %s: %w", ociclient.OCILayoutIndexFile, err)
+	}
+	if err := vfs.WriteFile(u.fs, indexPath, data, os.ModePerm); err != nil {
+		return fmt.Errorf("unable to write %s: %w", ociclient.OCILayoutIndexFile, err)
+	}
+	layout, err := json.Marshal(ocispecv1.ImageLayout{Version: ocispecv1.ImageLayoutVersion})
+	if err != nil {
+		return fmt.Errorf("unable to encode oci layout: %w", err)
+	}
+	if err := vfs.WriteFile(u.fs, path.Join(u.layoutPath, ocispecv1.ImageLayoutFile), layout, os.ModePerm); err != nil {
+		return fmt.Errorf("unable to write %s: %w", ocispecv1.ImageLayoutFile, err)
+	}
+	return nil
+}
+
+// ociLayoutFileWriter writes a file with the given name into an oci image layout.
+type ociLayoutFileWriter func(name string, r io.Reader) error
+
+// writeOCIArtifact writes the manifests, configs and layers of the oci artifact as blobs of an oci image layout
+// and returns the descriptor of the artifact.
+// Configs and layers are read from the cache.
+func writeOCIArtifact(artifact *oci.Artifact, cache cache.Cache, write ociLayoutFileWriter) (ocispecv1.Descriptor, error) {
+	if !artifact.IsIndex() {
+		return writeOCIManifest(artifact.GetManifest().Data, cache, write)
+	}
+
+	index := artifact.GetIndex()
+	manifests := make([]ocispecv1.Descriptor, 0, len(index.Manifests))
+	for _, m := range index.Manifests {
+		desc, err := writeOCIManifest(m.Data, cache, write)
+		if err != nil {
+			return ocispecv1.Descriptor{}, err
+		}
+		desc.Annotations = m.Descriptor.Annotations
+		desc.Platform = m.Descriptor.Platform
+		manifests = append(manifests, desc)
+	}
+	data, err := json.Marshal(ocispecv1.Index{
+		Versioned:   specs.Versioned{SchemaVersion: 2},
+		Manifests:   manifests,
+		Annotations: index.Annotations,
+	})
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to marshal image index: %w", err)
+	}
+	desc := ocispecv1.Descriptor{
+		MediaType: ocispecv1.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	if err := write(ociLayoutBlobPath(desc.Digest), bytes.NewReader(data)); err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+func writeOCIManifest(manifest *ocispecv1.Manifest, cache cache.Cache, write ociLayoutFileWriter) (ocispecv1.Descriptor, error) {
+	for _, blob := range append([]ocispecv1.Descriptor{manifest.Config}, manifest.Layers...) {
+		reader, err := cache.Get(blob)
+		if err != nil {
+			return ocispecv1.Descriptor{}, fmt.Errorf("unable to get blob %s from cache: %w", blob.Digest, err)
+		}
+		err = write(ociLayoutBlobPath(blob.Digest), reader)
+		_ = reader.Close()
+		if err != nil {
+			return ocispecv1.Descriptor{}, err
+		}
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to marshal manifest: %w", err)
+	}
+	desc, err := ociclient.CreateDescriptorFromManifest(manifest)
+	if err != nil {
+		return ocispecv1.Descriptor{}, fmt.Errorf("unable to create manifest descriptor: %w", err)
+	}
+	if err := write(ociLayoutBlobPath(desc.Digest), bytes.NewReader(data)); err != nil {
+		return ocispecv1.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+func ociLayoutBlobPath(dgst digest.Digest) string {
+	return path.Join("blobs", dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// pathLocks contains a lock for every target path of the filesystem uploaders.
+var pathLocks sync.Map
+
+// lockPath locks the given target path and returns the function that unlocks it.
+func lockPath(p string) func() {
+	mux, _ := pathLocks.LoadOrStore(path.Clean(p), &sync.Mutex{})
+	mux.(*sync.Mutex).Lock()
+	return mux.(*sync.Mutex).Unlock
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package uploaders_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/ociclient/oci"
+	"github.com/gardener/component-cli/pkg/transport/process/uploaders"
+	processutils "github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("localOciLayout", func() {
+
+	Context("Process", func() {
+
+		It("should write the oci artifact into the oci layout so that it can be resolved offline", func() {
+			const imageRef = "example.com/my-image:1.0.0"
+			layer := []byte("layer-data")
+			config := []byte("{}")
+			layerDesc := ocispecv1.Descriptor{MediaType: "text/plain", Digest: digest.FromBytes(layer), Size: int64(len(layer))}
+			configDesc := ocispecv1.Descriptor{MediaType: "application/json", Digest: digest.FromBytes(config), Size: int64(len(config))}
+			artifactCache := cache.NewInMemoryCache()
+			Expect(artifactCache.Add(layerDesc, ioutil.NopCloser(bytes.NewReader(layer)))).To(Succeed())
+			Expect(artifactCache.Add(configDesc, ioutil.NopCloser(bytes.NewReader(config)))).To(Succeed())
+			artifact, err := oci.NewManifestArtifact(&oci.Manifest{
+				Data: &ocispecv1.Manifest{
+					Config: configDesc,
+					Layers: []ocispecv1.Descriptor{layerDesc},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			serializedArtifact, err := processutils.SerializeOCIArtifact(*artifact, artifactCache)
+			Expect(err).ToNot(HaveOccurred())
+			defer serializedArtifact.Close()
+
+			acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess(imageRef))
+			Expect(err).ToNot(HaveOccurred())
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "1.0.0",
+					Type:    "ociImage",
+				},
+				Access: &acc,
+			}
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res,
+					},
+				},
+			}
+			inProcessorMsg := bytes.NewBuffer([]byte{})
+			Expect(processutils.WriteProcessorMessage(cd, res, serializedArtifact, inProcessorMsg)).To(Succeed())
+
+			fs := memoryfs.New()
+			u, err := uploaders.NewLocalOCILayoutUploader(fs, cache.NewInMemoryCache(), "/layout")
+			Expect(err).ToNot(HaveOccurred())
+			outProcessorMsg := bytes.NewBuffer([]byte{})
+			Expect(u.Process(context.TODO(), inProcessorMsg, outProcessorMsg)).To(Succeed())
+
+			_, actualRes, resBlobReader, err := processutils.ReadProcessorMessage(outProcessorMsg)
+			Expect(err).ToNot(HaveOccurred())
+			defer resBlobReader.Close()
+			actualAcc := cdv2.OCIRegistryAccess{}
+			Expect(actualRes.Access.DecodeInto(&actualAcc)).To(Succeed())
+			Expect(actualAcc.ImageReference).To(Equal(imageRef))
+
+			layout, err := ociclient.OpenOCILayout(fs, "/layout")
+			Expect(err).ToNot(HaveOccurred())
+			offlineClient, err := ociclient.NewClient(logr.Discard(),
+				ociclient.WithCache(cache.NewInMemoryCache()),
+				ociclient.WithOfflineMode(layout))
+			Expect(err).ToNot(HaveOccurred())
+			m, err := offlineClient.GetManifest(context.TODO(), imageRef)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m.Layers).To(ConsistOf(layerDesc))
+			var buf bytes.Buffer
+			Expect(offlineClient.Fetch(context.TODO(), imageRef, layerDesc, &buf)).To(Succeed())
+			Expect(buf.Bytes()).To(Equal(layer))
+		})
+
+	})
+})
@@ -6,12 +6,16 @@ package uploaders
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/osfs"
 	"sigs.k8s.io/yaml"
 
 	"github.com/gardener/component-cli/ociclient"
 	"github.com/gardener/component-cli/ociclient/cache"
+	"github.com/gardener/component-cli/pkg/transport/merge"
 	"github.com/gardener/component-cli/pkg/transport/process"
 	"github.com/gardener/component-cli/pkg/transport/process/extensions"
 )
@@ -22,42 +26,112 @@ const (
 
 	// OCIArtifactUploaderType defines the type of an oci artifact uploader
 	OCIArtifactUploaderType = "OciArtifactUploader"
+
+	// LocalOCILayoutUploaderType defines the type of an uploader that writes oci artifacts into an oci image layout directory
+	LocalOCILayoutUploaderType = "LocalOciLayoutUploader"
+
+	// CTFArchiveUploaderType defines the type of an uploader that writes resources into a ctf archive
+	CTFArchiveUploaderType = "CtfArchiveUploader"
+
+	// OCIArtifactTaggerType defines the type of a post uploader that adds tags to uploaded oci artifacts
+	OCIArtifactTaggerType = "OciArtifactTagger"
 )
 
+// registry contains the uploaders that are registered by external Go code.
+var registry = process.NewProcessorRegistry()
+
+// Register registers a uploader type that is not built into the component-cli.
+// Registered uploaders can be used like built-in uploaders in the transport config,
+// e.g. to upload resources with custom access types.
+// Register is meant to be called during initialization before any uploader factory is used.
+func Register(uploaderType string, factory process.ProcessorFactoryFunc) error {
+	switch uploaderType {
+	case LocalOCIBlobUploaderType, OCIArtifactUploaderType, LocalOCILayoutUploaderType, CTFArchiveUploaderType, OCIArtifactTaggerType, extensions.ExecutableType, extensions.ContainerType:
+		return fmt.Errorf("uploader type %s is a built-in type", uploaderType)
+	}
+	return registry.Register(uploaderType, factory)
+}
+
 // NewUploaderFactory creates a new uploader factory
 // How to add a new uploader (without using extension mechanism):
 // - Add Go file to uploaders package which contains the source code of the new uploader
 // - Add string constant for new uploader type -> will be used in UploaderFactory.Create()
 // - Add source code for creating new uploader to UploaderFactory.Create() method
+// Alternatively, external Go code can add a new uploader with Register().
 func NewUploaderFactory(client ociclient.Client, ocicache cache.Cache, targetCtx cdv2.OCIRegistryRepository) *UploaderFactory {
 	return &UploaderFactory{
-		client:    client,
-		cache:     ocicache,
-		targetCtx: targetCtx,
+		client:        client,
+		cache:         ocicache,
+		targetCtx:     targetCtx,
+		mergeStrategy: merge.StrategyOverwrite,
+		ctfWritten:    &sync.Map{},
 	}
 }
 
 // UploaderFactory defines a helper struct for creating uploaders
 type UploaderFactory struct {
-	client    ociclient.Client
-	cache     cache.Cache
-	targetCtx cdv2.OCIRegistryRepository
+	client        ociclient.Client
+	cache         cache.Cache
+	targetCtx     cdv2.OCIRegistryRepository
+	mergeStrategy merge.Strategy
+	// ctfWritten contains the component versions that have been written into ctf archives by the created uploaders.
+	ctfWritten *sync.Map
 }
 
-// Create creates a new uploader defined by a type and a spec
+// WithMergeStrategy configures how the uploaders merge component descriptors with already existing component descriptors
+// of the same component version in the target (see ParsedTransportConfig.MergeStrategy).
+func (f *UploaderFactory) WithMergeStrategy(strategy merge.Strategy) *UploaderFactory {
+	f.mergeStrategy = strategy
+	return f
+}
+
+// Create creates a new uploader defined by a type and a spec.
+// Post uploaders are created by the uploader factory as well.
 func (f *UploaderFactory) Create(uploaderType string, spec *json.RawMessage) (process.ResourceStreamProcessor, error) {
 	switch uploaderType {
 	case LocalOCIBlobUploaderType:
-		return NewLocalOCIBlobUploader(f.client, f.targetCtx)
+		return f.createLocalOCIBlobUploader(spec)
 	case OCIArtifactUploaderType:
 		return f.createOCIArtifactUploader(spec)
+	case LocalOCILayoutUploaderType:
+		return f.createLocalOCILayoutUploader(spec)
+	case CTFArchiveUploaderType:
+		return f.createCTFArchiveUploader(spec)
+	case OCIArtifactTaggerType:
+		return f.createOCIArtifactTagger(spec)
 	case extensions.ExecutableType:
 		return extensions.CreateExecutable(spec)
+	case extensions.ContainerType:
+		return extensions.CreateContainer(spec)
 	default:
+		if factory, ok := registry.Get(uploaderType); ok {
+			return factory(spec)
+		}
 		return nil, fmt.Errorf("unknown uploader type %s", uploaderType)
 	}
 }
 
+func (f *UploaderFactory) createLocalOCIBlobUploader(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type uploaderSpec struct {
+		// BaseUrl optionally overwrites the base url of the target repository context of the factory.
+		// This allows multiple uploaders with different target repository contexts.
+		BaseUrl string `json:"baseUrl"`
+	}
+
+	targetCtx := f.targetCtx
+	if rawSpec != nil {
+		var spec uploaderSpec
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+		if len(spec.BaseUrl) != 0 {
+			targetCtx = *cdv2.NewOCIRegistryRepository(spec.BaseUrl, targetCtx.ComponentNameMapping)
+		}
+	}
+
+	return NewLocalOCIBlobUploader(f.client, targetCtx)
+}
+
 func (f *UploaderFactory) createOCIArtifactUploader(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
 	type uploaderSpec struct {
 		BaseUrl        string `json:"baseUrl"`
@@ -72,3 +146,59 @@ func (f *UploaderFactory) createOCIArtifactUploader(rawSpec *json.RawMessage) (p
 
 	return NewOCIArtifactUploader(f.client, f.cache, spec.BaseUrl, spec.KeepSourceRepo)
 }
+
+func (f *UploaderFactory) createLocalOCILayoutUploader(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type uploaderSpec struct {
+		// Path is the path to the oci image layout directory.
+		Path string `json:"path"`
+	}
+
+	var spec uploaderSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	return NewLocalOCILayoutUploader(osfs.New(), f.cache, spec.Path)
+}
+
+func (f *UploaderFactory) createCTFArchiveUploader(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type uploaderSpec struct {
+		// Path is the path to the ctf archive.
+		Path string `json:"path"`
+		// Format is the format of the component archives in the ctf archive.
+		Format ctf.ArchiveFormat `json:"format"`
+	}
+
+	var spec uploaderSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	// all ctf archive uploaders of a transport share the written component versions,
+	// so that only component versions that existed before the transport are merged.
+	u, err := newCTFArchiveUploader(osfs.New(), f.cache, spec.Path, spec.Format, f.mergeStrategy, f.ctfWritten)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (f *UploaderFactory) createOCIArtifactTagger(rawSpec *json.RawMessage) (process.ResourceStreamProcessor, error) {
+	type postUploaderSpec struct {
+		// Tags are the tags that are added to the uploaded oci artifact.
+		Tags []string `json:"tags"`
+	}
+
+	var spec postUploaderSpec
+	if rawSpec != nil {
+		if err := yaml.Unmarshal(*rawSpec, &spec); err != nil {
+			return nil, fmt.Errorf("unable to parse spec: %w", err)
+		}
+	}
+
+	return NewOCIArtifactTagger(f.client, spec.Tags...)
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package utils
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"time"
+)
+
+// gzipOSUnknown is the value of the os field of the gzip header that marks the operating system as unknown.
+const gzipOSUnknown = 255
+
+// CompressionOptions configures the compression of layers that are rewritten by processors.
+// Processors that modify gzip compressed layers should embed the options in their spec
+// and compress the modified layers with NewGzipWriter, so that identical inputs result in identical digests.
+type CompressionOptions struct {
+	// CompressionLevel is the gzip compression level of modified layers.
+	// Valid values are 1 (best speed) to 9 (best compression), 0 (no compression) and -1 (default compression).
+	CompressionLevel *int `json:"compressionLevel,omitempty"`
+}
+
+// Level returns the configured compression level or the default compression level.
+func (o CompressionOptions) Level() int {
+	if o.CompressionLevel == nil {
+		return gzip.DefaultCompression
+	}
+	return *o.CompressionLevel
+}
+
+// Validate validates the compression options.
+func (o CompressionOptions) Validate() error {
+	level := o.Level()
+	if level < gzip.DefaultCompression || level > gzip.BestCompression {
+		return fmt.Errorf("invalid compression level %d: must be between %d and %d", level, gzip.DefaultCompression, gzip.BestCompression)
+	}
+	return nil
+}
+
+// NewGzipWriter creates a gzip writer with the configured compression level and a deterministic header.
+// The modification time, name, comment and os fields of the header are not set,
+// so that the compressed output only depends on the uncompressed data and the compression level.
+func NewGzipWriter(w io.Writer, opts CompressionOptions) (*gzip.Writer, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	gw, err := gzip.NewWriterLevel(w, opts.Level())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create gzip writer: %w", err)
+	}
+	gw.Header = gzip.Header{
+		ModTime: time.Time{},
+		OS:      gzipOSUnknown,
+	}
+	return gw, nil
+}
+
+// Recompress decompresses the gzip compressed data of the reader and compresses it deterministically
+// with the configured compression level.
+func Recompress(w io.Writer, r io.Reader, opts CompressionOptions) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("unable to create gzip reader: %w", err)
+	}
+	defer gr.Close()
+
+	gw, err := NewGzipWriter(w, opts)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(gw, gr); err != nil {
+		return fmt.Errorf("unable to recompress data: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("unable to close gzip writer: %w", err)
+	}
+	return nil
+}
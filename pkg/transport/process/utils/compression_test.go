@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package utils_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+)
+
+var _ = Describe("compression", func() {
+
+	compress := func(data []byte, opts utils.CompressionOptions) []byte {
+		var buf bytes.Buffer
+		gw, err := utils.NewGzipWriter(&buf, opts)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = gw.Write(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gw.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	It("should produce identical digests for identical inputs", func() {
+		data := bytes.Repeat([]byte("layer-data"), 1000)
+		level := gzip.BestCompression
+		opts := utils.CompressionOptions{CompressionLevel: &level}
+
+		first := compress(data, opts)
+		second := compress(data, opts)
+		Expect(digest.FromBytes(second)).To(Equal(digest.FromBytes(first)))
+
+		gr, err := gzip.NewReader(bytes.NewReader(first))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gr.Header.ModTime.IsZero()).To(BeTrue())
+		Expect(gr.Header.OS).To(Equal(byte(255)))
+		uncompressed, err := ioutil.ReadAll(gr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(uncompressed).To(Equal(data))
+	})
+
+	It("should recompress layers independent of their original gzip header", func() {
+		data := bytes.Repeat([]byte("layer-data"), 1000)
+		var original bytes.Buffer
+		gw := gzip.NewWriter(&original)
+		gw.Name = "layer.tar"
+		gw.ModTime = time.Now()
+		_, err := gw.Write(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gw.Close()).To(Succeed())
+
+		var recompressed bytes.Buffer
+		Expect(utils.Recompress(&recompressed, &original, utils.CompressionOptions{})).To(Succeed())
+		Expect(recompressed.Bytes()).To(Equal(compress(data, utils.CompressionOptions{})))
+	})
+
+	It("should reject invalid compression levels", func() {
+		level := 10
+		opts := utils.CompressionOptions{CompressionLevel: &level}
+		Expect(opts.Validate()).To(HaveOccurred())
+		_, err := utils.NewGzipWriter(&bytes.Buffer{}, opts)
+		Expect(err).To(HaveOccurred())
+	})
+
+})
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+)
+
+// ProcessingStampName is the name of the label and referrer annotation that contains the digest of the processing config
+// a resource has been processed with.
+// Uploaders stamp it onto uploaded resources and attach it to uploaded artifacts so that resources whose target already carries
+// a matching stamp can be skipped when a transport is executed again.
+const ProcessingStampName = "transport.gardener.cloud/processing-digest"
+
+// GetProcessingStamp returns the processing digest the resource is stamped with.
+func GetProcessingStamp(res cdv2.Resource) (string, bool) {
+	for _, label := range res.Labels {
+		if label.Name != ProcessingStampName {
+			continue
+		}
+		var digest string
+		if err := json.Unmarshal(label.Value, &digest); err != nil {
+			return "", false
+		}
+		return digest, true
+	}
+	return "", false
+}
+
+// SetProcessingStamp stamps the resource with the processing digest.
+// An already existing stamp is replaced.
+func SetProcessingStamp(res *cdv2.Resource, digest string) error {
+	value, err := json.Marshal(digest)
+	if err != nil {
+		return fmt.Errorf("unable to encode processing digest: %w", err)
+	}
+	for i, label := range res.Labels {
+		if label.Name == ProcessingStampName {
+			res.Labels[i].Value = value
+			return nil
+		}
+	}
+	res.Labels = append(res.Labels, cdv2.Label{
+		Name:  ProcessingStampName,
+		Value: value,
+	})
+	return nil
+}
+
+// IsAlreadyProcessed returns whether the resource with the same identity in the target component descriptor
+// is stamped with the given processing digest.
+func IsAlreadyProcessed(target *cdv2.ComponentDescriptor, res cdv2.Resource, digest string) bool {
+	if target == nil || len(digest) == 0 {
+		return false
+	}
+	idx := target.GetResourceIndex(res)
+	if idx == -1 {
+		return false
+	}
+	stamp, ok := GetProcessingStamp(target.Resources[idx])
+	return ok && stamp == digest
+}
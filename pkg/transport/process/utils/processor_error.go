@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/component-cli/pkg/utils"
+)
+
+// ProcessorErrorFile is the filename of the error envelope in a processor message tar archive
+const ProcessorErrorFile = "error.yaml"
+
+// ErrorCategory categorizes the error of a processor.
+type ErrorCategory string
+
+const (
+	// ErrorCategoryRetryable marks errors that may be resolved by processing the resource again,
+	// e.g. because a remote service is temporarily unavailable.
+	ErrorCategoryRetryable ErrorCategory = "retryable"
+	// ErrorCategoryFatal marks errors that cannot be resolved by processing the resource again
+	// and that should abort the processing of all resources, e.g. because of an invalid processor configuration.
+	ErrorCategoryFatal ErrorCategory = "fatal"
+)
+
+// ProcessorError is the error envelope that an external processor can write instead of or in addition to
+// a processor message, so that the processing does not have to infer the error from exit codes and stderr.
+// Errors without category are neither retried nor abort the processing of other resources.
+type ProcessorError struct {
+	// Code is a machine-readable error code that is defined by the processor, e.g. "SCAN_TIMEOUT".
+	Code string `json:"code,omitempty"`
+	// Category is the category of the error.
+	// +optional
+	Category ErrorCategory `json:"category,omitempty"`
+	// Message is a human-readable description of the error.
+	Message string `json:"message"`
+	// Details contains additional information about the error like partial results of the processor.
+	// +optional
+	Details map[string]string `json:"details,omitempty"`
+}
+
+func (e *ProcessorError) Error() string {
+	if len(e.Code) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Retryable returns whether the processing may succeed if it is retried.
+func (e *ProcessorError) Retryable() bool {
+	return e.Category == ErrorCategoryRetryable
+}
+
+// Fatal returns whether the error should abort the processing of all resources.
+func (e *ProcessorError) Fatal() bool {
+	return e.Category == ErrorCategoryFatal
+}
+
+// WriteProcessorError writes the error envelope as processor message.
+func WriteProcessorError(perr ProcessorError, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	marshaledErr, err := yaml.Marshal(perr)
+	if err != nil {
+		return fmt.Errorf("unable to marshal processor error: %w", err)
+	}
+
+	if err := utils.WriteFileToTARArchive(ProcessorErrorFile, bytes.NewReader(marshaledErr), tw); err != nil {
+		return fmt.Errorf("unable to write %s: %w", ProcessorErrorFile, err)
+	}
+	return nil
+}
+
+// ReadProcessorError reads the error envelope from a processor message.
+// Nil is returned if the processor message does not contain an error envelope.
+func ReadProcessorError(r io.Reader) (*ProcessorError, error) {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unable to read tar header: %w", err)
+		}
+		if header.Name != ProcessorErrorFile {
+			continue
+		}
+
+		buf := bytes.NewBuffer([]byte{})
+		if _, err := io.Copy(buf, tr); err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", ProcessorErrorFile, err)
+		}
+		perr := &ProcessorError{}
+		if err := yaml.Unmarshal(buf.Bytes(), perr); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal %s: %w", ProcessorErrorFile, err)
+		}
+		return perr, nil
+	}
+}
@@ -10,6 +10,9 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path"
+	"strconv"
+	"strings"
 
 	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
 	"sigs.k8s.io/yaml"
@@ -26,6 +29,13 @@ const (
 
 	// ResourceBlobFile is the filename of the resource blob in a processor message tar archive
 	ResourceBlobFile = "resource-blob"
+
+	// ResourceListFile is the filename of the resource list in a multi resource processor message tar archive
+	ResourceListFile = "resources.yaml"
+
+	// ResourceBlobsDir is the directory of the resource blobs in a multi resource processor message tar archive.
+	// The blob of the resource at index i of the resource list is stored under ResourceBlobsDir/i.
+	ResourceBlobsDir = "resource-blobs"
 )
 
 // WriteProcessorMessage writes a component descriptor, resource and resource blob as a processor
@@ -112,6 +122,171 @@ func ReadProcessorMessage(r io.Reader) (*cdv2.ComponentDescriptor, cdv2.Resource
 	return cd, res, f, nil
 }
 
+// WriteMultiResourceProcessorMessage writes a component descriptor and multiple resources with their resource blobs
+// as a multi resource processor message. This enables processors to split one input resource into multiple output resources.
+// blobReaders must either be nil or contain a reader for every resource. An individual reader can be nil if
+// the corresponding resource has no blob.
+func WriteMultiResourceProcessorMessage(cd cdv2.ComponentDescriptor, resources []cdv2.Resource, blobReaders []io.Reader, w io.Writer) error {
+	if blobReaders != nil && len(blobReaders) != len(resources) {
+		return fmt.Errorf("number of resource blobs (%d) does not match the number of resources (%d)", len(blobReaders), len(resources))
+	}
+
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	marshaledCD, err := yaml.Marshal(cd)
+	if err != nil {
+		return fmt.Errorf("unable to marshal component descriptor: %w", err)
+	}
+
+	if err := utils.WriteFileToTARArchive(ComponentDescriptorFile, bytes.NewReader(marshaledCD), tw); err != nil {
+		return fmt.Errorf("unable to write %s: %w", ComponentDescriptorFile, err)
+	}
+
+	marshaledResources, err := yaml.Marshal(resources)
+	if err != nil {
+		return fmt.Errorf("unable to marshal resources: %w", err)
+	}
+
+	if err := utils.WriteFileToTARArchive(ResourceListFile, bytes.NewReader(marshaledResources), tw); err != nil {
+		return fmt.Errorf("unable to write %s: %w", ResourceListFile, err)
+	}
+
+	for i, blobReader := range blobReaders {
+		if blobReader == nil {
+			continue
+		}
+		blobFile := path.Join(ResourceBlobsDir, strconv.Itoa(i))
+		if err := utils.WriteFileToTARArchive(blobFile, blobReader, tw); err != nil {
+			return fmt.Errorf("unable to write %s: %w", blobFile, err)
+		}
+	}
+
+	return nil
+}
+
+// ReadMultiResourceProcessorMessage reads the component descriptor and all resources with their resource blobs from
+// a processor message. Single resource as well as multi resource processor messages are supported.
+// The returned list of blob readers has the same length as the resource list. An individual reader is nil
+// if the corresponding resource has no blob. All non-nil readers must be closed by the caller.
+func ReadMultiResourceProcessorMessage(r io.Reader) (*cdv2.ComponentDescriptor, []cdv2.Resource, []io.ReadSeekCloser, error) {
+	tr := tar.NewReader(r)
+
+	var cd *cdv2.ComponentDescriptor
+	var resources []cdv2.Resource
+	blobs := map[int]*os.File{}
+
+	closeBlobs := func() {
+		for _, f := range blobs {
+			f.Close()
+		}
+	}
+
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			closeBlobs()
+			return nil, nil, nil, fmt.Errorf("unable to read tar header: %w", err)
+		}
+
+		switch {
+		case header.Name == ComponentDescriptorFile:
+			if cd, err = readComponentDescriptor(tr); err != nil {
+				closeBlobs()
+				return nil, nil, nil, fmt.Errorf("unable to read %s: %w", ComponentDescriptorFile, err)
+			}
+		case header.Name == ResourceFile:
+			res, err := readResource(tr)
+			if err != nil {
+				closeBlobs()
+				return nil, nil, nil, fmt.Errorf("unable to read %s: %w", ResourceFile, err)
+			}
+			resources = []cdv2.Resource{res}
+		case header.Name == ResourceListFile:
+			if resources, err = readResourceList(tr); err != nil {
+				closeBlobs()
+				return nil, nil, nil, fmt.Errorf("unable to read %s: %w", ResourceListFile, err)
+			}
+		case header.Name == ResourceBlobFile:
+			if blobs[0], err = readBlobToTempFile(tr); err != nil {
+				closeBlobs()
+				return nil, nil, nil, fmt.Errorf("unable to read %s: %w", ResourceBlobFile, err)
+			}
+		case strings.HasPrefix(header.Name, ResourceBlobsDir+"/"):
+			i, err := strconv.Atoi(strings.TrimPrefix(header.Name, ResourceBlobsDir+"/"))
+			if err != nil {
+				closeBlobs()
+				return nil, nil, nil, fmt.Errorf("invalid resource blob file %s: %w", header.Name, err)
+			}
+			if blobs[i], err = readBlobToTempFile(tr); err != nil {
+				closeBlobs()
+				return nil, nil, nil, fmt.Errorf("unable to read %s: %w", header.Name, err)
+			}
+		}
+	}
+
+	blobReaders := make([]io.ReadSeekCloser, len(resources))
+	for i, f := range blobs {
+		if i < 0 || i >= len(resources) {
+			closeBlobs()
+			return nil, nil, nil, fmt.Errorf("resource blob %d has no corresponding resource", i)
+		}
+		blobReaders[i] = f
+	}
+
+	return cd, resources, blobReaders, nil
+}
+
+// IsMultiResourceProcessorMessage checks whether a processor message is a multi resource processor message.
+func IsMultiResourceProcessorMessage(r io.Reader) (bool, error) {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, fmt.Errorf("unable to read tar header: %w", err)
+		}
+		if header.Name == ResourceListFile {
+			return true, nil
+		}
+	}
+}
+
+func readBlobToTempFile(r io.Reader) (*os.File, error) {
+	f, err := ioutil.TempFile("", "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create tempfile: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("unable to seek to beginning of resource blob file: %w", err)
+	}
+	return f, nil
+}
+
+func readResourceList(r *tar.Reader) ([]cdv2.Resource, error) {
+	buf := bytes.NewBuffer([]byte{})
+	if _, err := io.Copy(buf, r); err != nil {
+		return nil, fmt.Errorf("unable to read from stream: %w", err)
+	}
+
+	var resources []cdv2.Resource
+	if err := yaml.Unmarshal(buf.Bytes(), &resources); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal: %w", err)
+	}
+
+	return resources, nil
+}
+
 func readResource(r *tar.Reader) (cdv2.Resource, error) {
 	buf := bytes.NewBuffer([]byte{})
 	if _, err := io.Copy(buf, r); err != nil {
@@ -55,4 +55,80 @@ var _ = Describe("util", func() {
 
 	})
 
+	Context("WriteMultiResourceProcessorMessage & ReadMultiResourceProcessorMessage", func() {
+
+		It("should correctly write and read a multi resource processor message", func() {
+			res1 := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res-1",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+			res2 := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res-2",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+			resourceData := "test-data"
+
+			cd := cdv2.ComponentDescriptor{
+				ComponentSpec: cdv2.ComponentSpec{
+					Resources: []cdv2.Resource{
+						res1,
+						res2,
+					},
+				},
+			}
+
+			processMsgBuf := bytes.NewBuffer([]byte{})
+			err := utils.WriteMultiResourceProcessorMessage(cd, []cdv2.Resource{res1, res2}, []io.Reader{nil, strings.NewReader(resourceData)}, processMsgBuf)
+			Expect(err).ToNot(HaveOccurred())
+
+			isMultiResourceMsg, err := utils.IsMultiResourceProcessorMessage(bytes.NewReader(processMsgBuf.Bytes()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isMultiResourceMsg).To(BeTrue())
+
+			actualCD, actualResources, resourceBlobReaders, err := utils.ReadMultiResourceProcessorMessage(processMsgBuf)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(*actualCD).To(Equal(cd))
+			Expect(actualResources).To(Equal([]cdv2.Resource{res1, res2}))
+			Expect(resourceBlobReaders).To(HaveLen(2))
+			Expect(resourceBlobReaders[0]).To(BeNil())
+
+			resourceBlobBuf := bytes.NewBuffer([]byte{})
+			_, err = io.Copy(resourceBlobBuf, resourceBlobReaders[1])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resourceBlobBuf.String()).To(Equal(resourceData))
+		})
+
+		It("should read a single resource processor message", func() {
+			res := cdv2.Resource{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{
+					Name:    "my-res",
+					Version: "v0.1.0",
+					Type:    "ociImage",
+				},
+			}
+			cd := cdv2.ComponentDescriptor{}
+
+			processMsgBuf := bytes.NewBuffer([]byte{})
+			Expect(utils.WriteProcessorMessage(cd, res, nil, processMsgBuf)).To(Succeed())
+
+			isMultiResourceMsg, err := utils.IsMultiResourceProcessorMessage(bytes.NewReader(processMsgBuf.Bytes()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isMultiResourceMsg).To(BeFalse())
+
+			_, actualResources, resourceBlobReaders, err := utils.ReadMultiResourceProcessorMessage(processMsgBuf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actualResources).To(Equal([]cdv2.Resource{res}))
+			Expect(resourceBlobReaders).To(HaveLen(1))
+			Expect(resourceBlobReaders[0]).To(BeNil())
+		})
+
+	})
+
 })
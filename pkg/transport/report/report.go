@@ -0,0 +1,304 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package report records a machine-readable report of a transport run.
+// The report describes per component and resource which downloaders, processors and uploaders have been executed,
+// their durations, the transferred bytes and errors. It can be written as json or as junit xml so that CI systems can surface the results.
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+	"github.com/gardener/component-cli/pkg/transport/state"
+)
+
+// StageKind is the kind of a stage of a resource processing pipeline.
+type StageKind string
+
+const (
+	StageKindDownloader   StageKind = "downloader"
+	StageKindProcessor    StageKind = "processor"
+	StageKindUploader     StageKind = "uploader"
+	StageKindPostUploader StageKind = "postUploader"
+)
+
+// Report is the report of a transport run. It is safe for concurrent use.
+type Report struct {
+	StartedAt  time.Time          `json:"startedAt"`
+	FinishedAt time.Time          `json:"finishedAt"`
+	Components []*ComponentReport `json:"components"`
+	// Signatures contains the evidence of the signature verification of the source components.
+	Signatures []state.SignatureEvidence `json:"signatures,omitempty"`
+	// Verification contains the results of the verification of the uploaded artifacts by target.
+	Verification []TargetVerification `json:"verification,omitempty"`
+
+	mux       sync.Mutex
+	resources map[string]*ResourceReport
+}
+
+// TargetVerification is the result of the verification of the uploaded artifacts of a target.
+type TargetVerification struct {
+	// Target is the name of the target. It is empty for the default target.
+	Target  string                     `json:"target,omitempty"`
+	Results []state.VerificationResult `json:"results"`
+}
+
+// ComponentReport is the report of a component version.
+type ComponentReport struct {
+	Name      string            `json:"name"`
+	Version   string            `json:"version"`
+	Resources []*ResourceReport `json:"resources"`
+}
+
+// ResourceReport is the report of a resource of a component version.
+type ResourceReport struct {
+	Name          string        `json:"name"`
+	Version       string        `json:"version"`
+	ExtraIdentity cdv2.Identity `json:"extraIdentity,omitempty"`
+	Type          string        `json:"type"`
+	// Duration is the duration of the processing of the resource.
+	Duration time.Duration `json:"duration"`
+	// Stages are the executed stages in the order of their completion.
+	Stages []StageReport `json:"stages,omitempty"`
+	// Error is the error of the processing of the resource.
+	Error string `json:"error,omitempty"`
+	// ErrorCode is the code of the error envelope of the processor that failed.
+	ErrorCode string `json:"errorCode,omitempty"`
+	// ErrorCategory is the category of the error envelope of the processor that failed.
+	ErrorCategory utils.ErrorCategory `json:"errorCategory,omitempty"`
+	// ErrorDetails are the details of the error envelope of the processor that failed like partial results.
+	ErrorDetails map[string]string `json:"errorDetails,omitempty"`
+}
+
+// StageReport is the report of the execution of a stage of a resource processing pipeline.
+type StageReport struct {
+	Kind StageKind `json:"kind"`
+	Name string    `json:"name"`
+	// Duration is the duration of the execution of the stage.
+	Duration time.Duration `json:"duration"`
+	// BytesIn is the size of the processor message that has been read by the stage.
+	BytesIn int64 `json:"bytesIn"`
+	// BytesOut is the size of the processor message that has been written by the stage.
+	BytesOut int64  `json:"bytesOut"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Failed returns whether the processing of the resource failed.
+func (r *ResourceReport) Failed() bool {
+	return len(r.Error) != 0
+}
+
+// New returns a new report of a transport run that starts now.
+func New() *Report {
+	return &Report{
+		StartedAt:  time.Now(),
+		Components: []*ComponentReport{},
+		resources:  map[string]*ResourceReport{},
+	}
+}
+
+// Finish records the end of the transport run.
+func (r *Report) Finish() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.FinishedAt = time.Now()
+}
+
+// SetSignatures records the evidence of the signature verification of the source components (see state.State.RequireSigned).
+func (r *Report) SetSignatures(evidence []state.SignatureEvidence) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.Signatures = evidence
+}
+
+// AddVerification records the results of the verification of the uploaded artifacts of a target (see state.State.VerifyTarget).
+func (r *Report) AddVerification(target string, results []state.VerificationResult) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.Verification = append(r.Verification, TargetVerification{
+		Target:  target,
+		Results: results,
+	})
+}
+
+// Stage returns a processor that records the execution of the given processor as stage of the processing of the resource.
+func (r *Report) Stage(cd cdv2.ComponentDescriptor, res cdv2.Resource, kind StageKind, name string, proc process.ResourceStreamProcessor) process.ResourceStreamProcessor {
+	return &stageRecorder{
+		report: r,
+		cd:     cd,
+		res:    res,
+		kind:   kind,
+		name:   name,
+		proc:   proc,
+	}
+}
+
+// Pipeline returns a pipeline that records the duration and the error of the processing of every resource.
+// The stages of the pipeline must be wrapped with Stage to be included in the report.
+func (r *Report) Pipeline(pipeline process.ResourceProcessingPipeline) process.ResourceProcessingPipeline {
+	return &pipelineRecorder{
+		report:   r,
+		pipeline: pipeline,
+	}
+}
+
+// MultiTargetPipeline returns a pipeline that records the duration and the error of the processing of every resource.
+// The stages of the pipeline must be wrapped with Stage to be included in the report.
+func (r *Report) MultiTargetPipeline(pipeline process.MultiTargetResourceProcessingPipeline) process.MultiTargetResourceProcessingPipeline {
+	return &multiTargetPipelineRecorder{
+		report:   r,
+		pipeline: pipeline,
+	}
+}
+
+// resource returns the report of the resource. The report must be locked by the caller.
+func (r *Report) resource(cd cdv2.ComponentDescriptor, res cdv2.Resource) *ResourceReport {
+	var comp *ComponentReport
+	for _, c := range r.Components {
+		if c.Name == cd.Name && c.Version == cd.Version {
+			comp = c
+			break
+		}
+	}
+	if comp == nil {
+		comp = &ComponentReport{
+			Name:      cd.Name,
+			Version:   cd.Version,
+			Resources: []*ResourceReport{},
+		}
+		r.Components = append(r.Components, comp)
+	}
+
+	key := fmt.Sprintf("%s:%s/%s", cd.Name, cd.Version, res.GetIdentityDigest())
+	if resReport, ok := r.resources[key]; ok {
+		return resReport
+	}
+	resReport := &ResourceReport{
+		Name:          res.Name,
+		Version:       res.Version,
+		ExtraIdentity: res.ExtraIdentity,
+		Type:          res.Type,
+	}
+	comp.Resources = append(comp.Resources, resReport)
+	r.resources[key] = resReport
+	return resReport
+}
+
+type pipelineRecorder struct {
+	report   *Report
+	pipeline process.ResourceProcessingPipeline
+}
+
+func (p *pipelineRecorder) Process(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) (*cdv2.ComponentDescriptor, []cdv2.Resource, error) {
+	start := time.Now()
+	processedCD, resources, err := p.pipeline.Process(ctx, cd, res)
+	p.report.recordResult(cd, res, time.Since(start), err)
+	return processedCD, resources, err
+}
+
+type multiTargetPipelineRecorder struct {
+	report   *Report
+	pipeline process.MultiTargetResourceProcessingPipeline
+}
+
+func (p *multiTargetPipelineRecorder) Process(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) ([]process.TargetResult, error) {
+	start := time.Now()
+	results, err := p.pipeline.Process(ctx, cd, res)
+	p.report.recordResult(cd, res, time.Since(start), err)
+	return results, err
+}
+
+// recordResult records the duration and the error of the processing of the resource.
+func (r *Report) recordResult(cd cdv2.ComponentDescriptor, res cdv2.Resource, duration time.Duration, err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	resReport := r.resource(cd, res)
+	resReport.Duration = duration
+	if err != nil {
+		resReport.Error = err.Error()
+		if perr, ok := process.AsProcessorError(err); ok {
+			resReport.ErrorCode = perr.Code
+			resReport.ErrorCategory = perr.Category
+			resReport.ErrorDetails = perr.Details
+		}
+	}
+}
+
+type stageRecorder struct {
+	report *Report
+	cd     cdv2.ComponentDescriptor
+	res    cdv2.Resource
+	kind   StageKind
+	name   string
+	proc   process.ResourceStreamProcessor
+}
+
+func (s *stageRecorder) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	in := &countingReader{r: r}
+	out := &countingWriter{w: w}
+	start := time.Now()
+	err := s.proc.Process(ctx, in, out)
+
+	stage := StageReport{
+		Kind:     s.kind,
+		Name:     s.name,
+		Duration: time.Since(start),
+		BytesIn:  in.n,
+		BytesOut: out.n,
+	}
+	if err != nil {
+		stage.Error = err.Error()
+	}
+	s.report.mux.Lock()
+	resReport := s.report.resource(s.cd, s.res)
+	resReport.Stages = append(resReport.Stages, stage)
+	s.report.mux.Unlock()
+	return err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// String returns a short summary of the report.
+func (r *Report) String() string {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	var resources, failed int
+	for _, comp := range r.Components {
+		for _, res := range comp.Resources {
+			resources++
+			if res.Failed() {
+				failed++
+			}
+		}
+	}
+	return fmt.Sprintf("%d components, %d resources, %d failed", len(r.Components), resources, failed)
+}
@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+	"github.com/gardener/component-cli/pkg/transport/report"
+)
+
+// blobWriter is a test processor that replaces the resource blob with the given data.
+type blobWriter struct {
+	data string
+}
+
+func (p *blobWriter) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, resBlobReader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return err
+	}
+	if resBlobReader != nil {
+		defer resBlobReader.Close()
+	}
+	return utils.WriteProcessorMessage(*cd, res, strings.NewReader(p.data), w)
+}
+
+// failingProcessor is a test processor that always fails.
+type failingProcessor struct{}
+
+func (p *failingProcessor) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	return errors.New("processing failed")
+}
+
+var _ = Describe("Report", func() {
+
+	var (
+		cd  cdv2.ComponentDescriptor
+		res cdv2.Resource
+	)
+
+	BeforeEach(func() {
+		res = cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    "plainText",
+			},
+		}
+		cd = cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/a"
+		cd.Version = "v0.1.0"
+		cd.Resources = []cdv2.Resource{res}
+	})
+
+	newPipeline := func(r *report.Report, processor process.ResourceStreamProcessor) process.ResourceProcessingPipeline {
+		return r.Pipeline(process.NewResourceProcessingPipeline(
+			r.Stage(cd, res, report.StageKindDownloader, "download", &blobWriter{data: "12345"}),
+			r.Stage(cd, res, report.StageKindProcessor, "process", processor),
+			r.Stage(cd, res, report.StageKindUploader, "upload", &blobWriter{data: "1"}),
+		))
+	}
+
+	It("should record the stages of a resource", func() {
+		r := report.New()
+		_, _, err := newPipeline(r, &blobWriter{data: "123"}).Process(context.TODO(), cd, res)
+		Expect(err).ToNot(HaveOccurred())
+		r.Finish()
+
+		Expect(r.Components).To(HaveLen(1))
+		Expect(r.Components[0].Name).To(Equal("example.com/a"))
+		Expect(r.Components[0].Resources).To(HaveLen(1))
+		resReport := r.Components[0].Resources[0]
+		Expect(resReport.Name).To(Equal("my-res"))
+		Expect(resReport.Failed()).To(BeFalse())
+		Expect(resReport.Stages).To(HaveLen(3))
+		Expect(resReport.Stages[0].Kind).To(Equal(report.StageKindDownloader))
+		Expect(resReport.Stages[1].Name).To(Equal("process"))
+		Expect(resReport.Stages[2].Kind).To(Equal(report.StageKindUploader))
+		// the processor reads the processor message that has been written by the downloader
+		Expect(resReport.Stages[1].BytesIn).To(Equal(resReport.Stages[0].BytesOut))
+		Expect(resReport.Stages[1].BytesOut).To(BeNumerically(">", 0))
+
+		buf := &bytes.Buffer{}
+		Expect(r.Write(buf, report.FormatJSON)).To(Succeed())
+		decoded := map[string]interface{}{}
+		Expect(json.Unmarshal(buf.Bytes(), &decoded)).To(Succeed())
+		Expect(decoded).To(HaveKey("components"))
+	})
+
+	It("should record the uploader stages of all targets of a multi target pipeline", func() {
+		r := report.New()
+		pipeline := r.MultiTargetPipeline(process.NewMultiTargetResourceProcessingPipeline(
+			[]process.ResourceStreamProcessor{
+				r.Stage(cd, res, report.StageKindDownloader, "download", &blobWriter{data: "12345"}),
+			},
+			process.ProcessingTarget{
+				Uploaders: []process.ResourceStreamProcessor{r.Stage(cd, res, report.StageKindUploader, "upload", &blobWriter{data: "1"})},
+			},
+			process.ProcessingTarget{
+				Name:      "mirror",
+				Uploaders: []process.ResourceStreamProcessor{r.Stage(cd, res, report.StageKindUploader, "mirror-upload", &blobWriter{data: "1"})},
+			},
+		))
+		_, err := pipeline.Process(context.TODO(), cd, res)
+		Expect(err).ToNot(HaveOccurred())
+		r.Finish()
+
+		Expect(r.Components).To(HaveLen(1))
+		resReport := r.Components[0].Resources[0]
+		Expect(resReport.Failed()).To(BeFalse())
+		Expect(resReport.Duration).To(BeNumerically(">", 0))
+		Expect(resReport.Stages).To(HaveLen(3))
+		Expect(resReport.Stages[0].Kind).To(Equal(report.StageKindDownloader))
+		Expect([]string{resReport.Stages[1].Name, resReport.Stages[2].Name}).To(ConsistOf("upload", "mirror-upload"))
+	})
+
+	It("should record errors and write them as junit failures", func() {
+		r := report.New()
+		_, _, err := newPipeline(r, &failingProcessor{}).Process(context.TODO(), cd, res)
+		Expect(err).To(HaveOccurred())
+		r.Finish()
+
+		resReport := r.Components[0].Resources[0]
+		Expect(resReport.Failed()).To(BeTrue())
+		Expect(resReport.Stages).To(HaveLen(2))
+		Expect(resReport.Stages[1].Error).To(Equal("processing failed"))
+
+		fs := memoryfs.New()
+		opts := &report.Options{ReportFile: "/report.xml", Format: string(report.FormatJUnit)}
+		Expect(opts.Validate()).To(Succeed())
+		Expect(opts.WriteReport(fs, r)).To(Succeed())
+
+		data, err := vfs.ReadFile(fs, "/report.xml")
+		Expect(err).ToNot(HaveOccurred())
+		suites := struct {
+			Tests    int `xml:"tests,attr"`
+			Failures int `xml:"failures,attr"`
+			Suites   []struct {
+				Name  string `xml:"name,attr"`
+				Cases []struct {
+					Name    string `xml:"name,attr"`
+					Failure *struct {
+						Message string `xml:"message,attr"`
+					} `xml:"failure"`
+				} `xml:"testcase"`
+			} `xml:"testsuite"`
+		}{}
+		Expect(xml.Unmarshal(data, &suites)).To(Succeed())
+		Expect(suites.Tests).To(Equal(1))
+		Expect(suites.Failures).To(Equal(1))
+		Expect(suites.Suites).To(HaveLen(1))
+		Expect(suites.Suites[0].Name).To(Equal("example.com/a:v0.1.0"))
+		Expect(suites.Suites[0].Cases[0].Name).To(Equal("my-res"))
+		Expect(suites.Suites[0].Cases[0].Failure).ToNot(BeNil())
+		Expect(suites.Suites[0].Cases[0].Failure.Message).To(ContainSubstring("processing failed"))
+	})
+
+	It("should reject unknown formats", func() {
+		opts := &report.Options{Format: "yaml"}
+		Expect(opts.Validate()).ToNot(Succeed())
+	})
+
+})
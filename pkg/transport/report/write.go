@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/pflag"
+)
+
+// Format is the output format of a report.
+type Format string
+
+const (
+	// FormatJSON writes the report as json.
+	FormatJSON Format = "json"
+	// FormatJUnit writes the report as junit xml with a test suite per component and a test case per resource.
+	FormatJUnit Format = "junit"
+)
+
+// Options configures the report file of a transport run.
+type Options struct {
+	// ReportFile is the path the report is written to. No report is written if empty.
+	ReportFile string
+	// Format is the format of the report (json or junit). Defaults to json.
+	Format string
+}
+
+// AddFlags adds the flags of the report to the flag set.
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.ReportFile, "report-file", "", "path to the file the machine-readable report of the transport is written to")
+	fs.StringVar(&o.Format, "report-format", string(FormatJSON), "format of the report file (json or junit)")
+}
+
+// Validate validates the options.
+func (o *Options) Validate() error {
+	switch Format(o.Format) {
+	case "", FormatJSON, FormatJUnit:
+		return nil
+	default:
+		return fmt.Errorf("unknown report format %q: must be one of %s, %s", o.Format, FormatJSON, FormatJUnit)
+	}
+}
+
+// WriteReport writes the report to the report file if a report file is configured.
+func (o *Options) WriteReport(fs vfs.FileSystem, r *Report) error {
+	if len(o.ReportFile) == 0 {
+		return nil
+	}
+	file, err := fs.Create(o.ReportFile)
+	if err != nil {
+		return fmt.Errorf("unable to create report file %s: %w", o.ReportFile, err)
+	}
+	format := Format(o.Format)
+	if len(format) == 0 {
+		format = FormatJSON
+	}
+	if err := r.Write(file, format); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("unable to close report file %s: %w", o.ReportFile, err)
+	}
+	return nil
+}
+
+// Write writes the report in the given format.
+func (r *Report) Write(w io.Writer, format Format) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("unable to encode report: %w", err)
+		}
+		return nil
+	case FormatJUnit:
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return fmt.Errorf("unable to write report: %w", err)
+		}
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		if err := enc.Encode(r.junit()); err != nil {
+			return fmt.Errorf("unable to encode report: %w", err)
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return fmt.Errorf("unable to write report: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	// Type is the error code of the processor that failed.
+	Type    string `xml:"type,attr,omitempty"`
+	Content string `xml:",chardata"`
+}
+
+// junit converts the report into junit test suites. The report must be locked by the caller.
+func (r *Report) junit() junitTestSuites {
+	suites := junitTestSuites{
+		Name: "transport",
+	}
+	if !r.FinishedAt.IsZero() {
+		suites.Time = seconds(r.FinishedAt.Sub(r.StartedAt).Seconds())
+	}
+	for _, comp := range r.Components {
+		suite := junitTestSuite{
+			Name: fmt.Sprintf("%s:%s", comp.Name, comp.Version),
+		}
+		var total float64
+		for _, res := range comp.Resources {
+			name := res.Name
+			if len(res.ExtraIdentity) != 0 {
+				data, _ := json.Marshal(res.ExtraIdentity)
+				name = fmt.Sprintf("%s %s", res.Name, string(data))
+			}
+			tc := junitTestCase{
+				Name:      name,
+				ClassName: comp.Name,
+				Time:      seconds(res.Duration.Seconds()),
+			}
+			stages := make([]string, 0, len(res.Stages))
+			for _, stage := range res.Stages {
+				line := fmt.Sprintf("%s %s: %s, %d bytes in, %d bytes out", stage.Kind, stage.Name, stage.Duration, stage.BytesIn, stage.BytesOut)
+				if len(stage.Error) != 0 {
+					line += ": " + stage.Error
+				}
+				stages = append(stages, line)
+			}
+			tc.SystemOut = strings.Join(stages, "\n")
+			if res.Failed() {
+				tc.Failure = &junitFailure{
+					Message: res.Error,
+					Type:    res.ErrorCode,
+					Content: tc.SystemOut,
+				}
+				suite.Failures++
+			}
+			total += res.Duration.Seconds()
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Tests = len(suite.Cases)
+		suite.Time = seconds(total)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+	return suites
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package state
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+)
+
+type resumablePipeline struct {
+	state      *State
+	downloader process.ResourceStreamProcessor
+	processors []process.ResourceStreamProcessor
+	uploaders  []process.ResourceStreamProcessor
+}
+
+// NewResumablePipeline returns a resource processing pipeline that records the status of every resource in the state.
+// Resources that have already been uploaded in a previous run are not processed again.
+// Instead, the component descriptor and the resources that have been recorded in the state are returned.
+// Resources with any other status are processed from the beginning.
+func NewResumablePipeline(state *State, downloader process.ResourceStreamProcessor, processors []process.ResourceStreamProcessor, uploaders []process.ResourceStreamProcessor) process.ResourceProcessingPipeline {
+	return &resumablePipeline{
+		state:      state,
+		downloader: downloader,
+		processors: processors,
+		uploaders:  uploaders,
+	}
+}
+
+func (p *resumablePipeline) Process(ctx context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) (*cdv2.ComponentDescriptor, []cdv2.Resource, error) {
+	if resState, ok := p.state.Get(cd, res); ok && resState.Status == StatusUploaded {
+		// state files of previous versions do not contain the processed component descriptor
+		if resState.ComponentDescriptor != nil {
+			return resState.ComponentDescriptor, resState.Resources, nil
+		}
+		return &cd, resState.Resources, nil
+	}
+
+	stages := make([]process.ResourceStreamProcessor, 0, 1+len(p.processors)+len(p.uploaders))
+	// the processed status is recorded by the downloader if no processors are executed
+	downloadStatuses := []Status{StatusDownloaded}
+	if len(p.processors) == 0 {
+		downloadStatuses = append(downloadStatuses, StatusProcessed)
+	}
+	stages = append(stages, p.state.StatusRecorder(cd, res, p.downloader, downloadStatuses...))
+	for i, proc := range p.processors {
+		if i == len(p.processors)-1 {
+			proc = p.state.StatusRecorder(cd, res, proc, StatusProcessed)
+		}
+		stages = append(stages, proc)
+	}
+	stages = append(stages, p.uploaders...)
+
+	processedCD, resources, err := process.NewResourceProcessingPipeline(stages...).Process(ctx, cd, res)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := p.state.SetUploaded(cd, res, processedCD, resources); err != nil {
+		return nil, nil, fmt.Errorf("unable to record status of resource %s: %w", res.Name, err)
+	}
+	return processedCD, resources, nil
+}
+
+// statusRecorder is a processor that records statuses after the wrapped processor succeeded.
+type statusRecorder struct {
+	state    *State
+	cd       cdv2.ComponentDescriptor
+	res      cdv2.Resource
+	proc     process.ResourceStreamProcessor
+	statuses []Status
+}
+
+// StatusRecorder returns a processor that records the statuses of the resource after the given processor succeeded.
+// Pipelines record the status of a stage with the last processor of the stage.
+func (s *State) StatusRecorder(cd cdv2.ComponentDescriptor, res cdv2.Resource, proc process.ResourceStreamProcessor, statuses ...Status) process.ResourceStreamProcessor {
+	return &statusRecorder{
+		state:    s,
+		cd:       cd,
+		res:      res,
+		proc:     proc,
+		statuses: statuses,
+	}
+}
+
+func (r *statusRecorder) Process(ctx context.Context, reader io.Reader, writer io.Writer) error {
+	if err := r.proc.Process(ctx, reader, writer); err != nil {
+		return err
+	}
+	for _, status := range r.statuses {
+		if err := r.state.SetStatus(r.cd, r.res, status); err != nil {
+			return fmt.Errorf("unable to record status of resource %s: %w", r.res.Name, err)
+		}
+	}
+	return nil
+}
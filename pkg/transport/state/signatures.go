@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/components"
+	"github.com/gardener/component-cli/pkg/signatures"
+)
+
+// SignatureEvidence is the evidence of the signature verification of a source component version.
+type SignatureEvidence struct {
+	ComponentName string `json:"componentName"`
+	Version       string `json:"version"`
+	// Verified is true if the component version has the signatures that are required by the policy.
+	Verified bool `json:"verified"`
+	// Signatures are the valid signatures of the component version.
+	Signatures []SignatureEvidenceEntry `json:"signatures,omitempty"`
+	// Message describes why the verification failed.
+	Message    string    `json:"message,omitempty"`
+	VerifiedAt time.Time `json:"verifiedAt"`
+}
+
+// SignatureEvidenceEntry describes a valid signature of a component version.
+type SignatureEvidenceEntry struct {
+	Name string `json:"name"`
+	// Digest is the signed digest of the normalised component descriptor.
+	Digest cdv2.DigestSpec `json:"digest"`
+}
+
+// RequireSignedOptions configures the compliance mode of a transport which only transports signed components.
+type RequireSignedOptions struct {
+	// RequireSigned rejects components without the signatures that are required by the policy.
+	RequireSigned bool
+	// PathToPolicy is the path to the verification policy (see signatures.VerificationPolicy).
+	PathToPolicy string
+
+	policy *signatures.VerificationPolicy
+}
+
+// AddFlags adds the flags of the compliance mode to the flag set.
+func (o *RequireSignedOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.RequireSigned, "require-signed", false, "refuse to transport components that do not have the signatures required by the signature policy")
+	fs.StringVar(&o.PathToPolicy, "signature-policy", "", "path to the verification policy that defines the required signatures and their public keys")
+}
+
+// Complete validates the options and loads the verification policy.
+func (o *RequireSignedOptions) Complete(fs vfs.FileSystem) error {
+	if !o.RequireSigned {
+		return nil
+	}
+	if len(o.PathToPolicy) == 0 {
+		return errors.New("a signature policy must be provided if signed components are required")
+	}
+	policy, err := signatures.LoadVerificationPolicy(fs, o.PathToPolicy)
+	if err != nil {
+		return err
+	}
+	o.policy = policy
+	return nil
+}
+
+// Verify verifies the signatures of the component tree if signed components are required.
+// See State.RequireSigned.
+func (o *RequireSignedOptions) Verify(ctx context.Context, s *State, resolver ctf.ComponentResolver, cd *cdv2.ComponentDescriptor) error {
+	if !o.RequireSigned {
+		return nil
+	}
+	if o.policy == nil {
+		return errors.New("options are not completed")
+	}
+	return s.RequireSigned(ctx, resolver, o.policy, cd)
+}
+
+// Signatures returns the evidence of the last signature verification.
+func (s *State) Signatures() []SignatureEvidence {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return append([]SignatureEvidence{}, s.signatures...)
+}
+
+// RequireSigned verifies the signatures of the component descriptor and of all transitively referenced
+// component descriptors with the policy. Referenced components are resolved in the effective repository context
+// of the referencing component. The repositories of the components must be allowed by the policy.
+// The evidence of the verification is recorded in the state file and an error is returned if any component
+// is not signed as required, so that unsigned components are rejected before any resource is processed.
+func (s *State) RequireSigned(ctx context.Context, resolver ctf.ComponentResolver, policy *signatures.VerificationPolicy, cd *cdv2.ComponentDescriptor) error {
+	evidence := make([]SignatureEvidence, 0)
+	if err := collectSignatureEvidence(ctx, resolver, policy, cd, map[string]bool{}, &evidence); err != nil {
+		return err
+	}
+
+	s.mux.Lock()
+	s.signatures = evidence
+	err := s.write()
+	s.mux.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, e := range evidence {
+		if !e.Verified {
+			failed = append(failed, fmt.Sprintf("%s:%s: %s", e.ComponentName, e.Version, e.Message))
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("%d of %d components are not signed as required: %s", len(failed), len(evidence), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func collectSignatureEvidence(ctx context.Context, resolver ctf.ComponentResolver, policy *signatures.VerificationPolicy, cd *cdv2.ComponentDescriptor, visited map[string]bool, evidence *[]SignatureEvidence) error {
+	id := cd.Name + ":" + cd.Version
+	if visited[id] {
+		return nil
+	}
+	visited[id] = true
+	idx := len(*evidence)
+	*evidence = append(*evidence, verifySignatures(policy, cd))
+
+	if len(cd.ComponentReferences) == 0 {
+		return nil
+	}
+	effective := cd.GetEffectiveRepositoryContext()
+	if effective == nil {
+		return fmt.Errorf("component %s has no repository context", id)
+	}
+	repoCtx, err := components.GetOCIRepositoryContext(effective)
+	if err != nil {
+		return fmt.Errorf("unable to get repository context of component %s: %w", id, err)
+	}
+	for _, ref := range cd.ComponentReferences {
+		refCd, err := resolver.Resolve(ctx, &repoCtx, ref.ComponentName, ref.Version)
+		if err != nil {
+			return fmt.Errorf("unable to resolve component reference %s of %s: %w", ref.Name, id, err)
+		}
+		// the signature of the component only covers the referenced components through the digests of the references.
+		if err := verifyReferenceDigest(ref, refCd); err != nil {
+			e := &(*evidence)[idx]
+			e.Verified = false
+			if len(e.Message) != 0 {
+				e.Message += "; "
+			}
+			e.Message += err.Error()
+		}
+		if err := collectSignatureEvidence(ctx, resolver, policy, refCd, visited, evidence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyReferenceDigest compares the digest of the component reference with the digest of the normalised referenced component descriptor.
+func verifyReferenceDigest(ref cdv2.ComponentReference, refCd *cdv2.ComponentDescriptor) error {
+	if ref.Digest == nil {
+		return fmt.Errorf("component reference %s has no digest", ref.Name)
+	}
+	hasher, err := cdv2Sign.HasherForName(ref.Digest.HashAlgorithm)
+	if err != nil {
+		return fmt.Errorf("unable to create hasher for component reference %s: %w", ref.Name, err)
+	}
+	digest, err := cdv2Sign.HashForComponentDescriptor(*refCd, *hasher)
+	if err != nil {
+		return fmt.Errorf("unable to calculate digest of component reference %s: %w", ref.Name, err)
+	}
+	if digest.NormalisationAlgorithm != ref.Digest.NormalisationAlgorithm || digest.Value != ref.Digest.Value {
+		return fmt.Errorf("digest of component reference %s does not match the referenced component %s:%s", ref.Name, refCd.Name, refCd.Version)
+	}
+	return nil
+}
+
+func verifySignatures(policy *signatures.VerificationPolicy, cd *cdv2.ComponentDescriptor) SignatureEvidence {
+	evidence := SignatureEvidence{
+		ComponentName: cd.Name,
+		Version:       cd.Version,
+		VerifiedAt:    time.Now(),
+	}
+
+	if effective := cd.GetEffectiveRepositoryContext(); effective != nil {
+		repoCtx, err := components.GetOCIRepositoryContext(effective)
+		if err != nil {
+			evidence.Message = fmt.Sprintf("unable to get repository context: %s", err.Error())
+			return evidence
+		}
+		if !policy.IsRepositoryAllowed(repoCtx.BaseURL) {
+			evidence.Message = fmt.Sprintf("repository %s is not allowed by the policy", repoCtx.BaseURL)
+			return evidence
+		}
+	}
+
+	valid, err := policy.VerifySignatures(cd)
+	for _, name := range valid {
+		sig, sigErr := cdv2Sign.GetSignatureByName(cd, name)
+		if sigErr != nil {
+			continue
+		}
+		evidence.Signatures = append(evidence.Signatures, SignatureEvidenceEntry{
+			Name:   name,
+			Digest: sig.Digest,
+		})
+	}
+	if err != nil {
+		evidence.Message = err.Error()
+		return evidence
+	}
+	evidence.Verified = true
+	return evidence
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package state_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/component-cli/pkg/signatures"
+	"github.com/gardener/component-cli/pkg/transport/state"
+)
+
+var _ = Describe("signatures", func() {
+
+	var (
+		dir        string
+		stateFile  string
+		key        *rsa.PrivateKey
+		opts       *state.RequireSignedOptions
+		root       *cdv2.ComponentDescriptor
+		referenced *cdv2.ComponentDescriptor
+		resolver   targetResolver
+	)
+
+	newComponent := func(name string, refs ...*cdv2.ComponentDescriptor) *cdv2.ComponentDescriptor {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Name = name
+		cd.Version = "v0.1.0"
+		Expect(cdv2.InjectRepositoryContext(cd, cdv2.NewOCIRegistryRepository("example.com/components", ""))).To(Succeed())
+		hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		for _, ref := range refs {
+			digest, err := cdv2Sign.HashForComponentDescriptor(*ref, *hasher)
+			Expect(err).ToNot(HaveOccurred())
+			cd.ComponentReferences = append(cd.ComponentReferences, cdv2.ComponentReference{
+				Name:          ref.Name,
+				ComponentName: ref.Name,
+				Version:       ref.Version,
+				Digest:        digest,
+			})
+		}
+		return cd
+	}
+
+	sign := func(cd *cdv2.ComponentDescriptor) {
+		signer, err := signatures.NewCryptoSigner(key, cdv2.MediaTypePEM)
+		Expect(err).ToNot(HaveOccurred())
+		hasher, err := cdv2Sign.HasherForName(cdv2Sign.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cdv2Sign.SignComponentDescriptor(cd, signer, *hasher, "release")).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "transport-signatures-")
+		Expect(err).ToNot(HaveOccurred())
+		stateFile = filepath.Join(dir, "state.yaml")
+
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).ToNot(HaveOccurred())
+		pub, err := signatures.MarshalPublicKeyPEM(&key.PublicKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "release.pub"), pub, 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "policy.yaml"), []byte("signatures:\n- name: release\n  publicKeys: [release.pub]\n"), 0644)).To(Succeed())
+
+		opts = &state.RequireSignedOptions{}
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		opts.AddFlags(flags)
+		Expect(flags.Parse([]string{"--require-signed", "--signature-policy", filepath.Join(dir, "policy.yaml")})).To(Succeed())
+		Expect(opts.Complete(osfs.New())).To(Succeed())
+
+		referenced = newComponent("example.com/b")
+		root = newComponent("example.com/a", referenced)
+		resolver = targetResolver{"example.com/b:v0.1.0": referenced}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should accept signed component trees and record the evidence", func() {
+		sign(referenced)
+		sign(root)
+
+		s, err := state.Load(osfs.New(), stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(opts.Verify(context.TODO(), s, resolver, root)).To(Succeed())
+
+		s, err = state.Load(osfs.New(), stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		evidence := s.Signatures()
+		Expect(evidence).To(HaveLen(2))
+		Expect(evidence[0].ComponentName).To(Equal("example.com/a"))
+		Expect(evidence[0].Verified).To(BeTrue())
+		Expect(evidence[0].Signatures).To(HaveLen(1))
+		Expect(evidence[0].Signatures[0].Name).To(Equal("release"))
+		Expect(evidence[0].Signatures[0].Digest).To(Equal(root.Signatures[0].Digest))
+		Expect(evidence[1].ComponentName).To(Equal("example.com/b"))
+		Expect(evidence[1].Verified).To(BeTrue())
+	})
+
+	It("should reject component trees with unsigned components", func() {
+		sign(root)
+
+		s, err := state.Load(osfs.New(), stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		err = opts.Verify(context.TODO(), s, resolver, root)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("1 of 2"))
+		Expect(err.Error()).To(ContainSubstring("example.com/b:v0.1.0"))
+
+		evidence := s.Signatures()
+		Expect(evidence).To(HaveLen(2))
+		Expect(evidence[0].Verified).To(BeTrue())
+		Expect(evidence[1].Verified).To(BeFalse())
+		Expect(evidence[1].Message).ToNot(BeEmpty())
+	})
+
+	It("should reject component trees whose referenced components do not match the reference digests", func() {
+		sign(root)
+		referenced.Provider = "external"
+		sign(referenced)
+
+		s, err := state.Load(osfs.New(), stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		err = opts.Verify(context.TODO(), s, resolver, root)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("1 of 2"))
+		Expect(err.Error()).To(ContainSubstring("example.com/a:v0.1.0"))
+
+		evidence := s.Signatures()
+		Expect(evidence).To(HaveLen(2))
+		Expect(evidence[0].Verified).To(BeFalse())
+		Expect(evidence[0].Message).To(ContainSubstring("digest of component reference example.com/b does not match"))
+		Expect(evidence[1].Verified).To(BeTrue())
+	})
+
+	It("should require a policy", func() {
+		opts := &state.RequireSignedOptions{RequireSigned: true}
+		Expect(opts.Complete(osfs.New())).ToNot(Succeed())
+	})
+
+})
@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Status is the processing status of a resource.
+type Status string
+
+const (
+	// StatusDownloaded means that the resource has been downloaded.
+	StatusDownloaded Status = "downloaded"
+	// StatusProcessed means that all processors have been executed for the resource.
+	StatusProcessed Status = "processed"
+	// StatusUploaded means that the resource has been uploaded and its processing is completed.
+	StatusUploaded Status = "uploaded"
+)
+
+// ResourceState is the persisted processing state of a resource of a component version.
+type ResourceState struct {
+	ComponentName string        `json:"componentName"`
+	Version       string        `json:"version"`
+	ResourceName  string        `json:"resourceName"`
+	ExtraIdentity cdv2.Identity `json:"extraIdentity,omitempty"`
+	Status        Status        `json:"status"`
+	UpdatedAt     time.Time     `json:"updatedAt"`
+	// ComponentDescriptor is the component descriptor that has been produced by the pipeline,
+	// so that modifications of processors are kept when the transport is resumed.
+	// It is only set for uploaded resources.
+	ComponentDescriptor *cdv2.ComponentDescriptor `json:"componentDescriptor,omitempty"`
+	// Resources are the resources that have been produced by the pipeline.
+	// They are only set for uploaded resources.
+	Resources []cdv2.Resource `json:"resources,omitempty"`
+	// Targets are the results of every target of a multi target pipeline.
+	// They are only set for uploaded resources.
+	Targets []TargetState `json:"targets,omitempty"`
+}
+
+// TargetState is the persisted result of a target of a multi target pipeline.
+type TargetState struct {
+	Target string `json:"target"`
+	// ComponentDescriptor is the component descriptor that has been produced by the uploaders of the target.
+	ComponentDescriptor *cdv2.ComponentDescriptor `json:"componentDescriptor,omitempty"`
+	// Resources are the resources that have been produced by the uploaders of the target.
+	Resources []cdv2.Resource `json:"resources,omitempty"`
+	// Blobs are the descriptors of the local oci blobs that have been uploaded for the target.
+	Blobs []ocispecv1.Descriptor `json:"blobs,omitempty"`
+}
+
+// State records the processing status of all resources of a transport in a state file,
+// so that a failed transport can be re-run and skips all resources that have already been uploaded.
+// The state file is written after every status change. It is safe for concurrent use.
+type State struct {
+	fs   vfs.FileSystem
+	path string
+
+	mux          sync.Mutex
+	resources    map[string]ResourceState
+	verification []VerificationResult
+	signatures   []SignatureEvidence
+}
+
+// stateFile is the serialized form of the state.
+type stateFile struct {
+	Resources []ResourceState `json:"resources"`
+	// Verification contains the results of the last verification pass of the uploaded artifacts.
+	Verification []VerificationResult `json:"verification,omitempty"`
+	// Signatures contains the evidence of the last signature verification of the source components.
+	Signatures []SignatureEvidence `json:"signatures,omitempty"`
+}
+
+// Load reads the state from the state file.
+// An empty state is returned if the state file does not exist yet.
+// The state is only kept in memory if the path is empty.
+func Load(fs vfs.FileSystem, path string) (*State, error) {
+	s := &State{
+		fs:        fs,
+		path:      path,
+		resources: map[string]ResourceState{},
+	}
+	if len(path) == 0 {
+		return s, nil
+	}
+	data, err := vfs.ReadFile(fs, path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unable to read state file %s: %w", path, err)
+	}
+	file := stateFile{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("unable to decode state file %s: %w", path, err)
+	}
+	for _, res := range file.Resources {
+		s.resources[key(res.ComponentName, res.Version, res.ResourceName, res.ExtraIdentity)] = res
+	}
+	s.verification = file.Verification
+	s.signatures = file.Signatures
+	return s, nil
+}
+
+// Get returns the state of a resource of a component version.
+func (s *State) Get(cd cdv2.ComponentDescriptor, res cdv2.Resource) (ResourceState, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	state, ok := s.resources[resourceKey(cd, res)]
+	return state, ok
+}
+
+// IsUploaded returns whether the resource of the component version has already been uploaded.
+func (s *State) IsUploaded(cd cdv2.ComponentDescriptor, res cdv2.Resource) bool {
+	state, ok := s.Get(cd, res)
+	return ok && state.Status == StatusUploaded
+}
+
+// SetStatus records the status of a resource of a component version and writes the state file.
+func (s *State) SetStatus(cd cdv2.ComponentDescriptor, res cdv2.Resource, status Status) error {
+	return s.set(cd, res, ResourceState{Status: status})
+}
+
+// SetUploaded records that the resource of the component version has been uploaded together with
+// the resulting component descriptor and resources and writes the state file.
+func (s *State) SetUploaded(cd cdv2.ComponentDescriptor, res cdv2.Resource, processedCD *cdv2.ComponentDescriptor, resources []cdv2.Resource) error {
+	return s.set(cd, res, ResourceState{
+		Status:              StatusUploaded,
+		ComponentDescriptor: processedCD,
+		Resources:           resources,
+	})
+}
+
+// SetTargetsUploaded records that the resource of the component version has been uploaded by a multi target pipeline
+// together with the results of all targets and writes the state file.
+func (s *State) SetTargetsUploaded(cd cdv2.ComponentDescriptor, res cdv2.Resource, targets []TargetState) error {
+	return s.set(cd, res, ResourceState{
+		Status:  StatusUploaded,
+		Targets: targets,
+	})
+}
+
+func (s *State) set(cd cdv2.ComponentDescriptor, res cdv2.Resource, state ResourceState) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	state.ComponentName = cd.Name
+	state.Version = cd.Version
+	state.ResourceName = res.Name
+	state.ExtraIdentity = res.ExtraIdentity
+	state.UpdatedAt = time.Now()
+	s.resources[resourceKey(cd, res)] = state
+	return s.write()
+}
+
+// write writes the state file.
+// The file is written to a temporary file first and then renamed so that an interrupted write does not corrupt the state.
+func (s *State) write() error {
+	if len(s.path) == 0 {
+		return nil
+	}
+	file := stateFile{
+		Resources:    make([]ResourceState, 0, len(s.resources)),
+		Verification: s.verification,
+		Signatures:   s.signatures,
+	}
+	for _, res := range s.resources {
+		file.Resources = append(file.Resources, res)
+	}
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("unable to encode state: %w", err)
+	}
+	tmpPath := s.path + ".tmp"
+	if err := vfs.WriteFile(s.fs, tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("unable to write state file %s: %w", tmpPath, err)
+	}
+	if err := s.fs.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("unable to rename state file %s: %w", tmpPath, err)
+	}
+	return nil
+}
+
+func resourceKey(cd cdv2.ComponentDescriptor, res cdv2.Resource) string {
+	return key(cd.Name, cd.Version, res.Name, res.ExtraIdentity)
+}
+
+func key(componentName, version, resourceName string, extraIdentity cdv2.Identity) string {
+	meta := cdv2.IdentityObjectMeta{Name: resourceName, ExtraIdentity: extraIdentity}
+	return fmt.Sprintf("%s:%s/%s", componentName, version, meta.GetIdentityDigest())
+}
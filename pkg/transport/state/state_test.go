@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package state_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+	"github.com/gardener/component-cli/pkg/transport/state"
+)
+
+// testProcessor is a test processor that counts its invocations and passes the processor message through.
+type testProcessor struct {
+	count int
+	err   error
+}
+
+func (p *testProcessor) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	p.count++
+	if p.err != nil {
+		return p.err
+	}
+	_, err := io.Copy(w, r)
+	return err
+}
+
+// componentLabeler is a test processor that labels the component descriptor.
+type componentLabeler struct{}
+
+func (p *componentLabeler) Process(ctx context.Context, r io.Reader, w io.Writer) error {
+	cd, res, blobreader, err := utils.ReadProcessorMessage(r)
+	if err != nil {
+		return err
+	}
+	var blob io.Reader
+	if blobreader != nil {
+		defer blobreader.Close()
+		blob = blobreader
+	}
+	cd.Labels = append(cd.Labels, cdv2.Label{Name: "processed", Value: []byte(`true`)})
+	return utils.WriteProcessorMessage(*cd, res, blob, w)
+}
+
+var _ = Describe("state", func() {
+
+	var (
+		fs        vfs.FileSystem
+		dir       string
+		stateFile string
+		cd        cdv2.ComponentDescriptor
+		res       cdv2.Resource
+	)
+
+	BeforeEach(func() {
+		// the os filesystem is used as the state file is replaced by renaming a temporary file
+		fs = osfs.New()
+		var err error
+		dir, err = ioutil.TempDir("", "transport-state-")
+		Expect(err).ToNot(HaveOccurred())
+		stateFile = filepath.Join(dir, "state.yaml")
+		cd = cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/a"
+		cd.Version = "v0.1.0"
+		res = cdv2.Resource{
+			IdentityObjectMeta: cdv2.IdentityObjectMeta{
+				Name:    "my-res",
+				Version: "v0.1.0",
+				Type:    "ociImage",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should return an empty state if the state file does not exist", func() {
+		s, err := state.Load(fs, stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		_, ok := s.Get(cd, res)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should persist the status of resources", func() {
+		s, err := state.Load(fs, stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.SetStatus(cd, res, state.StatusDownloaded)).To(Succeed())
+
+		otherRes := res
+		otherRes.ExtraIdentity = cdv2.Identity{"platform": "arm64"}
+		Expect(s.SetUploaded(cd, otherRes, &cd, []cdv2.Resource{otherRes})).To(Succeed())
+
+		s, err = state.Load(fs, stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		resState, ok := s.Get(cd, res)
+		Expect(ok).To(BeTrue())
+		Expect(resState.Status).To(Equal(state.StatusDownloaded))
+		Expect(s.IsUploaded(cd, res)).To(BeFalse())
+		Expect(s.IsUploaded(cd, otherRes)).To(BeTrue())
+		resState, _ = s.Get(cd, otherRes)
+		Expect(resState.Resources).To(ConsistOf(otherRes))
+	})
+
+	It("should skip resources that have been uploaded in a previous run", func() {
+		downloader := &testProcessor{}
+		processor := &testProcessor{}
+		uploader := &testProcessor{err: errors.New("upload failed")}
+
+		s, err := state.Load(fs, stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		pipeline := state.NewResumablePipeline(s, downloader, []process.ResourceStreamProcessor{processor}, []process.ResourceStreamProcessor{uploader})
+		_, _, err = pipeline.Process(context.TODO(), cd, res)
+		Expect(err).To(HaveOccurred())
+		resState, _ := s.Get(cd, res)
+		Expect(resState.Status).To(Equal(state.StatusProcessed))
+
+		uploader.err = nil
+		s, err = state.Load(fs, stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		pipeline = state.NewResumablePipeline(s, downloader, []process.ResourceStreamProcessor{processor}, []process.ResourceStreamProcessor{uploader})
+		_, resources, err := pipeline.Process(context.TODO(), cd, res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(ConsistOf(res))
+		Expect(s.IsUploaded(cd, res)).To(BeTrue())
+
+		s, err = state.Load(fs, stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		pipeline = state.NewResumablePipeline(s, downloader, []process.ResourceStreamProcessor{processor}, []process.ResourceStreamProcessor{uploader})
+		_, resources, err = pipeline.Process(context.TODO(), cd, res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources).To(ConsistOf(res))
+		Expect(downloader.count).To(Equal(2))
+		Expect(processor.count).To(Equal(2))
+		Expect(uploader.count).To(Equal(2))
+	})
+
+	It("should return the processed component descriptor of resources that have been uploaded in a previous run", func() {
+		downloader := &testProcessor{}
+		uploader := &testProcessor{}
+
+		s, err := state.Load(fs, stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		processedCD, _, err := state.NewResumablePipeline(s, downloader, []process.ResourceStreamProcessor{&componentLabeler{}}, []process.ResourceStreamProcessor{uploader}).Process(context.TODO(), cd, res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(processedCD.Labels).To(HaveLen(1))
+
+		s, err = state.Load(fs, stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		resumedCD, _, err := state.NewResumablePipeline(s, downloader, nil, []process.ResourceStreamProcessor{uploader}).Process(context.TODO(), cd, res)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(downloader.count).To(Equal(1))
+		Expect(resumedCD.Labels).To(Equal(processedCD.Labels))
+	})
+
+	It("should persist the results of all targets", func() {
+		s, err := state.Load(fs, stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		targets := []state.TargetState{
+			{Target: "", ComponentDescriptor: &cd, Resources: []cdv2.Resource{res}},
+			{Target: "mirror", ComponentDescriptor: &cd, Resources: []cdv2.Resource{res}},
+		}
+		Expect(s.SetTargetsUploaded(cd, res, targets)).To(Succeed())
+
+		s, err = state.Load(fs, stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.IsUploaded(cd, res)).To(BeTrue())
+		resState, _ := s.Get(cd, res)
+		Expect(resState.Targets).To(Equal(targets))
+	})
+
+})
@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	cdv2Sign "github.com/gardener/component-spec/bindings-go/apis/v2/signatures"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/gardener/component-cli/ociclient"
+	"github.com/gardener/component-cli/pkg/signatures"
+)
+
+// VerificationStatus is the result of the verification of an uploaded component descriptor or resource.
+type VerificationStatus string
+
+const (
+	// VerificationStatusVerified means that the digest in the target matches the expected digest.
+	VerificationStatusVerified VerificationStatus = "verified"
+	// VerificationStatusPresent means that the artifact exists in the target but no digest is expected for it.
+	VerificationStatusPresent VerificationStatus = "present"
+	// VerificationStatusMismatch means that the digest in the target differs from the expected digest.
+	VerificationStatusMismatch VerificationStatus = "mismatch"
+	// VerificationStatusMissing means that the artifact cannot be resolved in the target.
+	VerificationStatusMissing VerificationStatus = "missing"
+)
+
+// VerificationResult is the result of the verification of a component descriptor or a resource in the target.
+type VerificationResult struct {
+	ComponentName string `json:"componentName"`
+	Version       string `json:"version"`
+	// ResourceName is the name of the verified resource. It is empty for component descriptors.
+	ResourceName  string             `json:"resourceName,omitempty"`
+	ExtraIdentity cdv2.Identity      `json:"extraIdentity,omitempty"`
+	Status        VerificationStatus `json:"status"`
+	Expected      string             `json:"expected,omitempty"`
+	Actual        string             `json:"actual,omitempty"`
+	Message       string             `json:"message,omitempty"`
+	VerifiedAt    time.Time          `json:"verifiedAt"`
+}
+
+// Failed returns whether the verification failed.
+func (r VerificationResult) Failed() bool {
+	return r.Status == VerificationStatusMismatch || r.Status == VerificationStatusMissing
+}
+
+func (r VerificationResult) String() string {
+	id := fmt.Sprintf("%s:%s", r.ComponentName, r.Version)
+	if len(r.ResourceName) != 0 {
+		id = fmt.Sprintf("resource %s of %s", r.ResourceName, id)
+	}
+	switch r.Status {
+	case VerificationStatusMismatch:
+		return fmt.Sprintf("%s: expected digest %s but found %s", id, r.Expected, r.Actual)
+	case VerificationStatusMissing:
+		return fmt.Sprintf("%s: %s", id, r.Message)
+	}
+	return fmt.Sprintf("%s: %s", id, r.Status)
+}
+
+// Verification returns the results of the last verification pass.
+func (s *State) Verification() []VerificationResult {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return append([]VerificationResult{}, s.verification...)
+}
+
+// VerifyTarget resolves the uploaded component descriptors and their oci artifact and local oci blob resources
+// in the target repository context and compares their digests with the expected ones.
+// Component descriptors are expected to match the given ones. Resources are expected to match their digest if it is set.
+// The results are recorded in the state file and an error is returned if any artifact is missing or mismatches,
+// so that the result of a transport reflects the actual state of the target and not only the successful uploads.
+func (s *State) VerifyTarget(ctx context.Context, client ociclient.Client, resolver ctf.ComponentResolver, repoCtx cdv2.OCIRegistryRepository, cds []cdv2.ComponentDescriptor) error {
+	results := make([]VerificationResult, 0)
+	for _, cd := range cds {
+		cd := *cd.DeepCopy()
+		if err := cdv2.InjectRepositoryContext(&cd, &repoCtx); err != nil {
+			return fmt.Errorf("unable to inject repository context into %s:%s: %w", cd.Name, cd.Version, err)
+		}
+		results = append(results, verifyComponentDescriptor(ctx, resolver, repoCtx, cd))
+		for _, res := range cd.Resources {
+			if res.Access == nil || (res.Access.Type != cdv2.OCIRegistryType && res.Access.Type != cdv2.LocalOCIBlobType) {
+				continue
+			}
+			results = append(results, verifyResource(ctx, client, cd, res))
+		}
+	}
+
+	s.mux.Lock()
+	s.verification = results
+	err := s.write()
+	s.mux.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, res := range results {
+		if res.Failed() {
+			failed = append(failed, res.String())
+		}
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("verification of %d of %d uploaded artifacts failed: %s", len(failed), len(results), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func verifyComponentDescriptor(ctx context.Context, resolver ctf.ComponentResolver, repoCtx cdv2.OCIRegistryRepository, cd cdv2.ComponentDescriptor) VerificationResult {
+	result := VerificationResult{
+		ComponentName: cd.Name,
+		Version:       cd.Version,
+		VerifiedAt:    time.Now(),
+	}
+	expected, err := componentDescriptorDigest(cd)
+	if err != nil {
+		result.Status = VerificationStatusMissing
+		result.Message = err.Error()
+		return result
+	}
+	result.Expected = expected.String()
+
+	actualCD, err := resolver.Resolve(ctx, &repoCtx, cd.Name, cd.Version)
+	if err != nil {
+		result.Status = VerificationStatusMissing
+		result.Message = fmt.Sprintf("unable to resolve component descriptor: %s", err.Error())
+		return result
+	}
+	actual, err := componentDescriptorDigest(*actualCD)
+	if err != nil {
+		result.Status = VerificationStatusMissing
+		result.Message = err.Error()
+		return result
+	}
+	result.Actual = actual.String()
+	result.Status = VerificationStatusVerified
+	if expected != actual {
+		result.Status = VerificationStatusMismatch
+	}
+	return result
+}
+
+// componentDescriptorDigest returns the digest of the json encoded component descriptor.
+// The normalised digest cannot be used as it requires digests for all resources.
+func componentDescriptorDigest(cd cdv2.ComponentDescriptor) (digest.Digest, error) {
+	data, err := json.Marshal(cd)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode component descriptor: %w", err)
+	}
+	return digest.FromBytes(data), nil
+}
+
+func verifyResource(ctx context.Context, client ociclient.Client, cd cdv2.ComponentDescriptor, res cdv2.Resource) VerificationResult {
+	result := VerificationResult{
+		ComponentName: cd.Name,
+		Version:       cd.Version,
+		ResourceName:  res.Name,
+		ExtraIdentity: res.ExtraIdentity,
+		VerifiedAt:    time.Now(),
+	}
+	// Resources that are excluded from signing have no expected digest.
+	expected := res.Digest
+	if expected != nil && reflect.DeepEqual(expected, cdv2.NewExcludeFromSignatureDigest()) {
+		expected = nil
+	}
+	hashAlgorithm := cdv2Sign.SHA256
+	if expected != nil && len(expected.HashAlgorithm) != 0 {
+		hashAlgorithm = expected.HashAlgorithm
+	}
+	hasher, err := cdv2Sign.HasherForName(hashAlgorithm)
+	if err != nil {
+		result.Status = VerificationStatusMissing
+		result.Message = fmt.Sprintf("unable to create hasher: %s", err.Error())
+		return result
+	}
+
+	// The digest is calculated from the target as the access of the uploaded resource points to the target.
+	res.Digest = nil
+	actual, err := signatures.NewDigester(client, *hasher).DigestForResource(ctx, cd, res)
+	if err != nil {
+		result.Status = VerificationStatusMissing
+		result.Message = fmt.Sprintf("unable to resolve resource: %s", err.Error())
+		return result
+	}
+	result.Actual = actual.Value
+
+	if expected == nil {
+		result.Status = VerificationStatusPresent
+		return result
+	}
+	result.Expected = expected.Value
+	result.Status = VerificationStatusVerified
+	if expected.NormalisationAlgorithm != actual.NormalisationAlgorithm || expected.Value != actual.Value {
+		result.Status = VerificationStatusMismatch
+	}
+	return result
+}
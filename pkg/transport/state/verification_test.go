@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package state_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/golang/mock/gomock"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/go-digest"
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+
+	mock_ociclient "github.com/gardener/component-cli/ociclient/mock"
+	"github.com/gardener/component-cli/pkg/transport/state"
+)
+
+// targetResolver resolves component descriptors from a map of "name:version" to component descriptor.
+type targetResolver map[string]*cdv2.ComponentDescriptor
+
+func (r targetResolver) Resolve(_ context.Context, _ cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, error) {
+	cd, ok := r[name+":"+version]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return cd, nil
+}
+
+func (r targetResolver) ResolveWithBlobResolver(ctx context.Context, repoCtx cdv2.Repository, name, version string) (*cdv2.ComponentDescriptor, ctf.BlobResolver, error) {
+	cd, err := r.Resolve(ctx, repoCtx, name, version)
+	return cd, nil, err
+}
+
+var _ = Describe("verification", func() {
+
+	var (
+		mockCtrl  *gomock.Controller
+		client    *mock_ociclient.MockClient
+		dir       string
+		stateFile string
+		repoCtx   *cdv2.OCIRegistryRepository
+		manifest  []byte
+		cd        cdv2.ComponentDescriptor
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		client = mock_ociclient.NewMockClient(mockCtrl)
+		var err error
+		dir, err = ioutil.TempDir("", "transport-verification-")
+		Expect(err).ToNot(HaveOccurred())
+		stateFile = filepath.Join(dir, "state.yaml")
+		repoCtx = cdv2.NewOCIRegistryRepository("example.com/target", "")
+
+		manifest = []byte(`{"schemaVersion":2}`)
+		acc, err := cdv2.NewUnstructured(cdv2.NewOCIRegistryAccess("example.com/target/image:v0.1.0"))
+		Expect(err).ToNot(HaveOccurred())
+		cd = cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/a"
+		cd.Version = "v0.1.0"
+		cd.Resources = []cdv2.Resource{
+			{
+				IdentityObjectMeta: cdv2.IdentityObjectMeta{Name: "image", Version: "v0.1.0", Type: cdv2.OCIImageType},
+				Relation:           cdv2.ExternalRelation,
+				Access:             &acc,
+				Digest: &cdv2.DigestSpec{
+					HashAlgorithm:          "sha256",
+					NormalisationAlgorithm: string(cdv2.OciArtifactDigestV1),
+					Value:                  digest.FromBytes(manifest).Hex(),
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should verify the uploaded component descriptors and resources and record the results", func() {
+		targetCD := cd.DeepCopy()
+		Expect(cdv2.InjectRepositoryContext(targetCD, repoCtx)).To(Succeed())
+		client.EXPECT().GetRawManifest(gomock.Any(), "example.com/target/image:v0.1.0").Return(ocispecv1.Descriptor{}, manifest, nil)
+
+		s, err := state.Load(osfs.New(), stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.VerifyTarget(context.TODO(), client, targetResolver{"example.com/a:v0.1.0": targetCD}, *repoCtx, []cdv2.ComponentDescriptor{cd})).To(Succeed())
+
+		s, err = state.Load(osfs.New(), stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		results := s.Verification()
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].ResourceName).To(BeEmpty())
+		Expect(results[0].Status).To(Equal(state.VerificationStatusVerified))
+		Expect(results[1].ResourceName).To(Equal("image"))
+		Expect(results[1].Status).To(Equal(state.VerificationStatusVerified))
+	})
+
+	It("should fail if an uploaded artifact mismatches or is missing in the target", func() {
+		client.EXPECT().GetRawManifest(gomock.Any(), "example.com/target/image:v0.1.0").Return(ocispecv1.Descriptor{}, []byte(`{"schemaVersion":2,"other":true}`), nil)
+
+		s, err := state.Load(osfs.New(), stateFile)
+		Expect(err).ToNot(HaveOccurred())
+		err = s.VerifyTarget(context.TODO(), client, targetResolver{}, *repoCtx, []cdv2.ComponentDescriptor{cd})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("2 of 2"))
+
+		results := s.Verification()
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Status).To(Equal(state.VerificationStatusMissing))
+		Expect(results[1].Status).To(Equal(state.VerificationStatusMismatch))
+		Expect(results[1].Expected).To(Equal(digest.FromBytes(manifest).Hex()))
+	})
+
+})
@@ -0,0 +1,333 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package stream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+
+	"github.com/gardener/component-cli/pkg/transport/process"
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+	"github.com/gardener/component-cli/pkg/transport/worker"
+)
+
+// maxRequestSize is the max size of a single request line.
+// Component descriptors can get large, so that the default buffer size of a scanner is not sufficient.
+const maxRequestSize = 16 * 1024 * 1024
+
+// Request is a processing request that is read as a single json line.
+type Request struct {
+	// ID identifies the request in the results.
+	// Defaults to the line number of the request.
+	ID string `json:"id,omitempty"`
+	// ComponentDescriptor is the component descriptor of the resources.
+	ComponentDescriptor *cdv2.ComponentDescriptor `json:"componentDescriptor"`
+	// Resources are the identities of the resources that are processed.
+	// An identity matches a resource if all of its attributes match, e.g. {"name": "image"}.
+	// All resources of the component descriptor are processed if empty.
+	Resources []cdv2.Identity `json:"resources,omitempty"`
+}
+
+// ResultStatus is the status of a processed item.
+type ResultStatus string
+
+const (
+	// ResultStatusSucceeded means that the resource has been processed successfully.
+	ResultStatusSucceeded ResultStatus = "succeeded"
+	// ResultStatusFailed means that the request could not be read or the resource could not be processed.
+	ResultStatusFailed ResultStatus = "failed"
+)
+
+// Result is the result of a single resource of a request that is written as a single json line.
+// Every request results in one result per processed resource, or in a single result without resource
+// if the request is invalid or no resource has to be processed.
+type Result struct {
+	// ID is the id of the request.
+	ID               string        `json:"id"`
+	ComponentName    string        `json:"componentName,omitempty"`
+	ComponentVersion string        `json:"componentVersion,omitempty"`
+	Resource         cdv2.Identity `json:"resource,omitempty"`
+	Status           ResultStatus  `json:"status"`
+	// Resources are the resources that are produced by the pipeline.
+	Resources []cdv2.Resource `json:"resources,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	// ErrorCode is the code of the error envelope of the processor that failed.
+	ErrorCode string `json:"errorCode,omitempty"`
+	// ErrorCategory is the category of the error envelope of the processor that failed.
+	ErrorCategory utils.ErrorCategory `json:"errorCategory,omitempty"`
+	// ErrorDetails are the details of the error envelope of the processor that failed.
+	ErrorDetails map[string]string `json:"errorDetails,omitempty"`
+	// Attempts is the number of times the resource has been processed.
+	Attempts int `json:"attempts,omitempty"`
+	// Duration is the processing time of the resource in milliseconds.
+	Duration int64 `json:"durationMs"`
+}
+
+// ResourceError is the error of a resource that could not be processed.
+type ResourceError struct {
+	// ID is the id of the request.
+	ID               string
+	ComponentName    string
+	ComponentVersion string
+	Resource         cdv2.Identity
+	Err              error
+}
+
+func (e *ResourceError) Error() string {
+	return fmt.Sprintf("unable to process resource %v of %s:%s (request %s): %s", e.Resource, e.ComponentName, e.ComponentVersion, e.ID, e.Err.Error())
+}
+
+// Unwrap returns the error of the pipeline.
+func (e *ResourceError) Unwrap() error {
+	return e.Err
+}
+
+// Options configures the processing of a stream.
+type Options struct {
+	// MaxWorkers is the max number of resources that are processed concurrently.
+	// The number is not limited if 0 or less.
+	MaxWorkers int
+	// FailFast aborts the processing of all remaining resources as soon as a resource could not be processed.
+	// By default, all requests are processed and the errors of all failed resources are returned.
+	FailFast bool
+	// MaxRetries is the max number of retries of a resource whose processing failed with a retryable processor error.
+	// Resources are not retried if 0.
+	MaxRetries int
+	// RetryInterval is the interval between two attempts to process a resource.
+	RetryInterval time.Duration
+}
+
+// Process reads processing requests as json lines from in, processes all requested resources with the pipeline
+// and writes a result json line for every resource to out as soon as the resource is processed.
+// This allows external orchestrators to drive and monitor the processing without parsing logs.
+// Results are written in the order of completion, not in the order of the requests.
+// An error is returned if any request failed, after all requests have been processed.
+// The error wraps a ResourceError for every resource that could not be processed.
+// Errors that prevent further results from being written abort the processing.
+func Process(ctx context.Context, in io.Reader, out io.Writer, pipeline process.ResourceProcessingPipeline, opts Options) error {
+	w := &resultWriter{
+		encoder: json.NewEncoder(out),
+	}
+	pool := worker.NewPool(ctx, opts.MaxWorkers)
+	var errs []error
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRequestSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		req, err := parseRequest(scanner.Bytes(), line)
+		if err != nil {
+			if err := w.write(Result{ID: req.ID, Status: ResultStatusFailed, Error: err.Error()}); err != nil {
+				return errors.Join(err, pool.Wait())
+			}
+			err = fmt.Errorf("invalid request %s: %w", req.ID, err)
+			if opts.FailFast {
+				return errors.Join(err, pool.Wait())
+			}
+			errs = append(errs, err)
+			continue
+		}
+		matched := 0
+		for _, res := range req.ComponentDescriptor.Resources {
+			if !matchesAny(res, req.Resources) {
+				continue
+			}
+			matched++
+			cd, res := *req.ComponentDescriptor, res
+			id := req.ID
+			if err := pool.Go(func(ctx context.Context) error {
+				result, err := processResource(ctx, pipeline, id, cd, res, opts)
+				if writeErr := w.write(result); writeErr != nil {
+					return worker.Fatal(writeErr)
+				}
+				if err != nil && (opts.FailFast || isFatalProcessorError(err)) {
+					return worker.Fatal(err)
+				}
+				return err
+			}); err != nil {
+				// the pool does not accept further tasks if a task failed with a fatal error or the context is done
+				if poolErr := pool.Wait(); poolErr != nil {
+					return poolErr
+				}
+				return err
+			}
+		}
+		if matched == 0 {
+			// every request results in at least one result so that orchestrators can track all requests
+			result := Result{
+				ID:               req.ID,
+				ComponentName:    req.ComponentDescriptor.Name,
+				ComponentVersion: req.ComponentDescriptor.Version,
+				Status:           ResultStatusSucceeded,
+			}
+			var reqErr error
+			if len(req.Resources) != 0 {
+				reqErr = fmt.Errorf("no resource of request %s matches the requested identities", req.ID)
+				result.Status = ResultStatusFailed
+				result.Error = "no resource matches the requested identities"
+			}
+			if err := w.write(result); err != nil {
+				return errors.Join(err, pool.Wait())
+			}
+			if reqErr != nil {
+				if opts.FailFast {
+					return errors.Join(reqErr, pool.Wait())
+				}
+				errs = append(errs, reqErr)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Join(fmt.Errorf("unable to read requests: %w", err), pool.Wait())
+	}
+	if err := pool.Wait(); err != nil {
+		if worker.IsFatal(err) {
+			return err
+		}
+		errs = append(errs, err)
+	}
+	if w.failed != 0 {
+		return fmt.Errorf("%d of %d results failed: %w", w.failed, w.written, errors.Join(errs...))
+	}
+	return nil
+}
+
+func parseRequest(data []byte, line int) (Request, error) {
+	req := Request{}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return Request{ID: strconv.Itoa(line)}, fmt.Errorf("unable to decode request in line %d: %w", line, err)
+	}
+	if len(req.ID) == 0 {
+		req.ID = strconv.Itoa(line)
+	}
+	if req.ComponentDescriptor == nil {
+		return req, errors.New("a component descriptor must be provided")
+	}
+	return req, nil
+}
+
+// processResource processes the resource and returns its result.
+// Resources that failed with a retryable processor error are processed again up to the configured max retries.
+// A ResourceError is returned in addition to the failed result if the resource could not be processed.
+func processResource(ctx context.Context, pipeline process.ResourceProcessingPipeline, id string, cd cdv2.ComponentDescriptor, res cdv2.Resource, opts Options) (Result, error) {
+	result := Result{
+		ID:               id,
+		ComponentName:    cd.Name,
+		ComponentVersion: cd.Version,
+		Resource:         res.GetIdentity(),
+		Status:           ResultStatusSucceeded,
+	}
+	start := time.Now()
+	var (
+		resources []cdv2.Resource
+		err       error
+	)
+	for {
+		result.Attempts++
+		_, resources, err = pipeline.Process(ctx, cd, res)
+		if err == nil || result.Attempts > opts.MaxRetries || !process.IsRetryable(err) {
+			break
+		}
+		if sleepErr := sleep(ctx, opts.RetryInterval); sleepErr != nil {
+			break
+		}
+	}
+	result.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Status = ResultStatusFailed
+		result.Error = err.Error()
+		if perr, ok := process.AsProcessorError(err); ok {
+			result.ErrorCode = perr.Code
+			result.ErrorCategory = perr.Category
+			result.ErrorDetails = perr.Details
+		}
+		return result, &ResourceError{
+			ID:               id,
+			ComponentName:    cd.Name,
+			ComponentVersion: cd.Version,
+			Resource:         result.Resource,
+			Err:              err,
+		}
+	}
+	result.Resources = resources
+	return result, nil
+}
+
+// isFatalProcessorError checks whether the error wraps a processor error that aborts the processing of all resources.
+func isFatalProcessorError(err error) bool {
+	perr, ok := process.AsProcessorError(err)
+	return ok && perr.Fatal()
+}
+
+// sleep blocks for the given duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// matchesAny checks whether the resource matches any of the identities.
+// All resources match if no identities are given.
+func matchesAny(res cdv2.Resource, identities []cdv2.Identity) bool {
+	if len(identities) == 0 {
+		return true
+	}
+	resIdentity := res.GetIdentity()
+	for _, identity := range identities {
+		matches := true
+		for k, v := range identity {
+			if resIdentity[k] != v {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// resultWriter writes the results as json lines.
+// The results of concurrently processed resources are written one after another so that lines are never interleaved.
+type resultWriter struct {
+	mux     sync.Mutex
+	encoder *json.Encoder
+	written int
+	failed  int
+}
+
+func (w *resultWriter) write(result Result) error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	w.written++
+	if result.Status == ResultStatusFailed {
+		w.failed++
+	}
+	if err := w.encoder.Encode(result); err != nil {
+		return fmt.Errorf("unable to write result of request %s: %w", result.ID, err)
+	}
+	return nil
+}
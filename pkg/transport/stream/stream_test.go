@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package stream_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	cdv2 "github.com/gardener/component-spec/bindings-go/apis/v2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/process/utils"
+	"github.com/gardener/component-cli/pkg/transport/stream"
+)
+
+// versionPipeline returns the processed resource with a modified version and fails for resources named "broken".
+type versionPipeline struct{}
+
+func (versionPipeline) Process(_ context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) (*cdv2.ComponentDescriptor, []cdv2.Resource, error) {
+	if res.Name == "broken" {
+		return nil, nil, errors.New("unable to download resource")
+	}
+	res.Version = res.Version + "-processed"
+	return &cd, []cdv2.Resource{res}, nil
+}
+
+// flakyPipeline fails with a processor error of the given category until it has been called failures times.
+type flakyPipeline struct {
+	category utils.ErrorCategory
+	failures int
+	calls    int32
+}
+
+func (p *flakyPipeline) Process(_ context.Context, cd cdv2.ComponentDescriptor, res cdv2.Resource) (*cdv2.ComponentDescriptor, []cdv2.Resource, error) {
+	if int(atomic.AddInt32(&p.calls, 1)) <= p.failures {
+		return nil, nil, fmt.Errorf("unable to process resource: %w", &utils.ProcessorError{
+			Code:     "UNAVAILABLE",
+			Category: p.category,
+			Message:  "service unavailable",
+		})
+	}
+	return &cd, []cdv2.Resource{res}, nil
+}
+
+func readResults(out *bytes.Buffer) map[string]stream.Result {
+	results := map[string]stream.Result{}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		result := stream.Result{}
+		Expect(json.Unmarshal(scanner.Bytes(), &result)).To(Succeed())
+		key := result.ID
+		if name, ok := result.Resource["name"]; ok {
+			key += "/" + name
+		}
+		results[key] = result
+	}
+	return results
+}
+
+var _ = Describe("Stream", func() {
+
+	request := func(id string, resources ...string) string {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/app"
+		cd.Version = "v1.0.0"
+		for _, name := range resources {
+			res := cdv2.Resource{}
+			res.Name = name
+			res.Version = "v1.0.0"
+			cd.Resources = append(cd.Resources, res)
+		}
+		data, err := json.Marshal(stream.Request{ID: id, ComponentDescriptor: cd})
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	It("should write a result line for every processed resource", func() {
+		in := strings.Join([]string{
+			request("a", "image", "chart"),
+			request("", "image"),
+		}, "\n")
+		var out bytes.Buffer
+		Expect(stream.Process(context.TODO(), strings.NewReader(in), &out, versionPipeline{}, stream.Options{MaxWorkers: 2})).To(Succeed())
+
+		results := readResults(&out)
+		Expect(results).To(HaveLen(3))
+		Expect(results).To(HaveKey("a/image"))
+		Expect(results).To(HaveKey("a/chart"))
+		Expect(results).To(HaveKey("2/image"), "the line number should be used as default id")
+		Expect(results["a/chart"].Status).To(Equal(stream.ResultStatusSucceeded))
+		Expect(results["a/chart"].ComponentName).To(Equal("example.com/app"))
+		Expect(results["a/chart"].Resources).To(HaveLen(1))
+		Expect(results["a/chart"].Resources[0].Version).To(Equal("v1.0.0-processed"))
+	})
+
+	It("should report failed resources and invalid requests and continue processing", func() {
+		in := strings.Join([]string{
+			request("a", "broken", "image"),
+			"{invalid",
+			`{"id": "no-cd"}`,
+		}, "\n")
+		var out bytes.Buffer
+		err := stream.Process(context.TODO(), strings.NewReader(in), &out, versionPipeline{}, stream.Options{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("3 of 4 results failed"))
+		resErr := &stream.ResourceError{}
+		Expect(errors.As(err, &resErr)).To(BeTrue())
+		Expect(resErr.ID).To(Equal("a"))
+		Expect(resErr.Resource).To(HaveKeyWithValue("name", "broken"))
+		Expect(resErr.Err).To(MatchError("unable to download resource"))
+
+		results := readResults(&out)
+		Expect(results).To(HaveLen(4))
+		Expect(results["a/image"].Status).To(Equal(stream.ResultStatusSucceeded))
+		Expect(results["a/broken"].Status).To(Equal(stream.ResultStatusFailed))
+		Expect(results["a/broken"].Error).To(ContainSubstring("unable to download resource"))
+		Expect(results["2"].Status).To(Equal(stream.ResultStatusFailed))
+		Expect(results["no-cd"].Error).To(ContainSubstring("a component descriptor must be provided"))
+	})
+
+	It("should abort the processing of the remaining requests if fail fast is configured", func() {
+		in := strings.Join([]string{
+			request("a", "broken"),
+			request("b", "image"),
+		}, "\n")
+		var out bytes.Buffer
+		err := stream.Process(context.TODO(), strings.NewReader(in), &out, versionPipeline{}, stream.Options{MaxWorkers: 1, FailFast: true})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("unable to download resource"))
+
+		results := readResults(&out)
+		Expect(results).To(HaveKey("a/broken"))
+		Expect(results).ToNot(HaveKey("b/image"))
+	})
+
+	It("should only process the requested resources", func() {
+		cd := &cdv2.ComponentDescriptor{}
+		cd.Name = "example.com/app"
+		cd.Version = "v1.0.0"
+		for _, name := range []string{"image", "chart"} {
+			res := cdv2.Resource{}
+			res.Name = name
+			cd.Resources = append(cd.Resources, res)
+		}
+		data, err := json.Marshal(stream.Request{ID: "a", ComponentDescriptor: cd, Resources: []cdv2.Identity{{"name": "chart"}}})
+		Expect(err).ToNot(HaveOccurred())
+
+		var out bytes.Buffer
+		Expect(stream.Process(context.TODO(), bytes.NewReader(data), &out, versionPipeline{}, stream.Options{})).To(Succeed())
+		results := readResults(&out)
+		Expect(results).To(HaveLen(1))
+		Expect(results).To(HaveKey("a/chart"))
+	})
+
+	It("should retry resources that failed with a retryable processor error", func() {
+		pipeline := &flakyPipeline{category: utils.ErrorCategoryRetryable, failures: 2}
+		var out bytes.Buffer
+		Expect(stream.Process(context.TODO(), strings.NewReader(request("a", "image")), &out, pipeline, stream.Options{MaxRetries: 2})).To(Succeed())
+
+		results := readResults(&out)
+		Expect(results["a/image"].Status).To(Equal(stream.ResultStatusSucceeded))
+		Expect(results["a/image"].Attempts).To(Equal(3))
+	})
+
+	It("should report the error envelope and not retry more than the max retries", func() {
+		pipeline := &flakyPipeline{category: utils.ErrorCategoryRetryable, failures: 5}
+		var out bytes.Buffer
+		err := stream.Process(context.TODO(), strings.NewReader(request("a", "image")), &out, pipeline, stream.Options{MaxRetries: 1})
+		Expect(err).To(HaveOccurred())
+
+		results := readResults(&out)
+		Expect(results["a/image"].Status).To(Equal(stream.ResultStatusFailed))
+		Expect(results["a/image"].Attempts).To(Equal(2))
+		Expect(results["a/image"].ErrorCode).To(Equal("UNAVAILABLE"))
+		Expect(results["a/image"].ErrorCategory).To(Equal(utils.ErrorCategoryRetryable))
+	})
+
+	It("should abort the processing if a processor fails with a fatal error", func() {
+		pipeline := &flakyPipeline{category: utils.ErrorCategoryFatal, failures: 1}
+		in := strings.Join([]string{
+			request("a", "image"),
+			request("b", "image"),
+		}, "\n")
+		var out bytes.Buffer
+		err := stream.Process(context.TODO(), strings.NewReader(in), &out, pipeline, stream.Options{MaxWorkers: 1, MaxRetries: 3})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("service unavailable"))
+
+		results := readResults(&out)
+		Expect(results["a/image"].Attempts).To(Equal(1), "fatal errors should not be retried")
+		Expect(results).ToNot(HaveKey("b/image"))
+	})
+})
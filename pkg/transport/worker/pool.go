@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Task is a unit of work that is executed by a worker of a pool.
+type Task func(ctx context.Context) error
+
+// fatalError marks an error that aborts the remaining work of a pool.
+type fatalError struct {
+	err error
+}
+
+func (e *fatalError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fatalError) Unwrap() error {
+	return e.err
+}
+
+// Fatal marks the error of a task as fatal.
+// A fatal error cancels the context of the pool, so that running tasks are aborted and no further tasks are started.
+func Fatal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &fatalError{err: err}
+}
+
+// IsFatal checks whether the error is or wraps an error that has been marked as fatal.
+func IsFatal(err error) bool {
+	var fatalErr *fatalError
+	return errors.As(err, &fatalErr)
+}
+
+// Pool executes tasks with a bounded number of concurrent workers.
+// Submitting a task blocks until a worker is free, which applies backpressure to the producer of the tasks
+// and bounds the number of goroutines, open files and connections of a transport.
+// Tasks must not submit further tasks to the same pool as they could wait for themselves.
+// Use separate pools for dependent kinds of work (e.g. components and resources) instead.
+//
+// The errors of all failed tasks are collected and returned by Wait.
+// If a task fails with a fatal error (see Fatal), the remaining work is aborted.
+type Pool struct {
+	ctx     context.Context
+	group   *errgroup.Group
+	workers chan struct{}
+
+	mux      sync.Mutex
+	errs     []error
+	fatalErr error
+}
+
+// NewPool creates a new pool with the given maximal number of concurrent workers.
+// The number of workers is not limited if maxWorkers is 0 or less.
+func NewPool(ctx context.Context, maxWorkers int) *Pool {
+	group, groupCtx := errgroup.WithContext(ctx)
+	p := &Pool{
+		ctx:   groupCtx,
+		group: group,
+	}
+	if maxWorkers > 0 {
+		p.workers = make(chan struct{}, maxWorkers)
+	}
+	return p
+}
+
+// Go executes the task as soon as a worker is free.
+// It blocks until the task is started and returns an error if the context of the pool is done before,
+// either because the parent context is done or because a task has failed with a fatal error.
+func (p *Pool) Go(task Task) error {
+	if err := p.err(); err != nil {
+		return err
+	}
+	if p.workers != nil {
+		select {
+		case <-p.ctx.Done():
+			return p.err()
+		case p.workers <- struct{}{}:
+		}
+		// the worker of a task that failed with a fatal error may be freed before the context is cancelled
+		if err := p.err(); err != nil {
+			<-p.workers
+			return err
+		}
+	}
+
+	p.group.Go(func() error {
+		if p.workers != nil {
+			defer func() { <-p.workers }()
+		}
+		err := task(p.ctx)
+		if err == nil {
+			return nil
+		}
+
+		p.mux.Lock()
+		defer p.mux.Unlock()
+		if IsFatal(err) {
+			if p.fatalErr == nil {
+				p.fatalErr = err
+			}
+			p.errs = append(p.errs, err)
+			// the error cancels the context of the pool
+			return err
+		}
+		if p.fatalErr != nil && errors.Is(err, context.Canceled) {
+			// the task has been aborted because of the fatal error, which is already reported
+			return nil
+		}
+		p.errs = append(p.errs, err)
+		return nil
+	})
+	return nil
+}
+
+// err returns the error why no further tasks are accepted.
+func (p *Pool) err() error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.fatalErr != nil {
+		return p.fatalErr
+	}
+	return p.ctx.Err()
+}
+
+// Wait blocks until all started tasks are finished and returns the errors of all failed tasks.
+// Use IsFatal to check whether the work has been aborted because of a fatal error.
+func (p *Pool) Wait() error {
+	_ = p.group.Wait()
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return errors.Join(p.errs...)
+}
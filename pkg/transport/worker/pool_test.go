@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2022 SAP SE or an SAP affiliate company and Gardener contributors.
+//
+// SPDX-License-Identifier: Apache-2.0
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/component-cli/pkg/transport/worker"
+)
+
+var _ = Describe("Pool", func() {
+
+	It("should not run more tasks concurrently than the maximal number of workers", func() {
+		pool := worker.NewPool(context.TODO(), 2)
+
+		var running, maxRunning int32
+		for i := 0; i < 10; i++ {
+			Expect(pool.Go(func(ctx context.Context) error {
+				current := atomic.AddInt32(&running, 1)
+				for {
+					max := atomic.LoadInt32(&maxRunning)
+					if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil
+			})).To(Succeed())
+		}
+		Expect(pool.Wait()).To(Succeed())
+		Expect(maxRunning).To(Equal(int32(2)))
+	})
+
+	It("should return the errors of all failed tasks", func() {
+		pool := worker.NewPool(context.TODO(), 1)
+		errA := errors.New("a")
+		errB := errors.New("b")
+		Expect(pool.Go(func(ctx context.Context) error { return errA })).To(Succeed())
+		Expect(pool.Go(func(ctx context.Context) error { return nil })).To(Succeed())
+		Expect(pool.Go(func(ctx context.Context) error { return errB })).To(Succeed())
+
+		err := pool.Wait()
+		Expect(errors.Is(err, errA)).To(BeTrue())
+		Expect(errors.Is(err, errB)).To(BeTrue())
+	})
+
+	It("should stop accepting tasks if the context is done", func() {
+		ctx, cancel := context.WithCancel(context.TODO())
+		pool := worker.NewPool(ctx, 1)
+		block := make(chan struct{})
+		Expect(pool.Go(func(ctx context.Context) error {
+			<-block
+			return nil
+		})).To(Succeed())
+
+		cancel()
+		Expect(pool.Go(func(ctx context.Context) error { return nil })).To(MatchError(context.Canceled))
+		close(block)
+		Expect(pool.Wait()).To(Succeed())
+	})
+
+	It("should abort the remaining work if a task fails with a fatal error", func() {
+		pool := worker.NewPool(context.TODO(), 1)
+		errFatal := errors.New("fatal")
+		errA := errors.New("a")
+		Expect(pool.Go(func(ctx context.Context) error { return errA })).To(Succeed())
+		Expect(pool.Go(func(ctx context.Context) error { return worker.Fatal(errFatal) })).To(Succeed())
+		Eventually(func() error {
+			return pool.Go(func(ctx context.Context) error { return nil })
+		}).Should(MatchError(errFatal))
+
+		err := pool.Wait()
+		Expect(worker.IsFatal(err)).To(BeTrue())
+		Expect(errors.Is(err, errFatal)).To(BeTrue())
+		Expect(errors.Is(err, errA)).To(BeTrue())
+	})
+
+	It("should cancel running tasks and not report their cancellation if a task fails with a fatal error", func() {
+		pool := worker.NewPool(context.TODO(), 2)
+		started := make(chan struct{})
+		Expect(pool.Go(func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return fmt.Errorf("aborted: %w", ctx.Err())
+		})).To(Succeed())
+		<-started
+		Expect(pool.Go(func(ctx context.Context) error { return worker.Fatal(errors.New("fatal")) })).To(Succeed())
+
+		err := pool.Wait()
+		Expect(err).To(MatchError("fatal"))
+		Expect(errors.Is(err, context.Canceled)).To(BeFalse())
+	})
+
+})
@@ -5,11 +5,56 @@
 package utils
 
 import (
+	"archive/tar"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
+
+	"github.com/gardener/component-spec/bindings-go/ctf"
+	"github.com/go-logr/logr"
+	"github.com/mandelsoft/vfs/pkg/vfs"
 )
 
 // CTFComponentArchiveFilename returns the name of the componant archive file in the ctf.
 func CTFComponentArchiveFilename(name, version string) string {
 	return fmt.Sprintf("%s-%s.tar", strings.ReplaceAll(name, "/", "_"), version)
 }
+
+// OpenOrCreateCTF opens the ctf archive at the given path.
+// An empty ctf archive is created if the path does not exist.
+func OpenOrCreateCTF(log logr.Logger, fs vfs.FileSystem, ctfPath string) (*ctf.CTF, error) {
+	info, err := fs.Stat(ctfPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("unable to get info for %s: %w", ctfPath, err)
+		}
+		log.Info("CTF Archive does not exist creating a new one")
+
+		file, err := fs.OpenFile(ctfPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open file for %s: %w", ctfPath, err)
+		}
+		tw := tar.NewWriter(file)
+		if err := tw.Close(); err != nil {
+			return nil, fmt.Errorf("unable to close tarwriter for emtpy tar: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			return nil, fmt.Errorf("unable to close tarwriter for emtpy tar: %w", err)
+		}
+		info, err = fs.Stat(ctfPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get info for %s: %w", ctfPath, err)
+		}
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf(`%q is a directory. 
+It is expected that the given path points to a CTF Archive`, ctfPath)
+	}
+
+	ctfArchive, err := ctf.NewCTF(fs, ctfPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open ctf at %q: %s", ctfPath, err.Error())
+	}
+	return ctfArchive, nil
+}